@@ -37,6 +37,7 @@ import (
 	"gnd.la/signal"
 	"gnd.la/template"
 	"gnd.la/template/assets"
+	"gnd.la/trace"
 	"gnd.la/util/stringutil"
 
 	"gopkgs.com/vfs.v1"
@@ -95,13 +96,16 @@ type ErrorHandler func(*Context, string, int) bool
 type LanguageHandler func(*Context) string
 
 type handlerInfo struct {
-	host      string
-	name      string
-	path      string
-	pathMatch []int
-	re        *regexp.Regexp
-	rc        *regexpCache
-	handler   Handler
+	host        string
+	name        string
+	path        string
+	pathMatch   []int
+	re          *regexp.Regexp
+	rc          *regexpCache
+	handler     Handler
+	maxBodySize int64
+	cache       *CachePolicy
+	earlyHints  []string
 }
 
 type includedApp struct {
@@ -181,16 +185,20 @@ type App struct {
 
 	handlers           []*handlerInfo
 	trustXHeaders      bool
+	ormComments        bool
 	appendSlash        bool
 	errorHandler       ErrorHandler
 	languageHandler    LanguageHandler
 	name               string
 	userFunc           UserFunc
+	exposureFunc       ExposureFunc
+	experimentsMutex   sync.RWMutex
 	assetsManager      *assets.Manager
 	templatesFS        vfs.VFS
 	templatesMutex     sync.RWMutex
 	templatesCache     map[string]*Template
 	templateProcessors []TemplateProcessor
+	lazyBlocks         []string
 	namespace          *namespace
 	hooks              []*template.Hook
 	started            time.Time
@@ -199,7 +207,14 @@ type App struct {
 	c                  *cache.Cache
 	o                  *orm.Orm
 	store              *blobstore.Blobstore
+	tracer             *trace.Tracer
+	tracingLogs        *trace.OTLPLogWriter
+	canonical          *CanonicalOptions
+	languagePrefix     *LanguagePrefixOptions
 	prepared           bool
+	// prefix when mounted in a Server
+	mount string
+	grpc  http.Handler
 
 	// Used for included apps
 	included  []*includedApp
@@ -230,16 +245,25 @@ func (app *App) HandleOptions(pattern string, handler Handler, opts *HandlerOpti
 	re := regexp.MustCompile(pattern)
 	var host string
 	var name string
+	var maxBodySize int64
+	var cache *CachePolicy
+	var earlyHints []string
 	if opts != nil {
 		host = opts.Host
 		name = opts.Name
+		maxBodySize = opts.MaxBodySize
+		cache = opts.Cache
+		earlyHints = opts.EarlyHints
 	}
 	info := &handlerInfo{
-		host:    host,
-		name:    name,
-		re:      re,
-		rc:      newRegexpCache(re),
-		handler: handler,
+		host:        host,
+		name:        name,
+		re:          re,
+		rc:          newRegexpCache(re),
+		handler:     handler,
+		maxBodySize: maxBodySize,
+		cache:       cache,
+		earlyHints:  earlyHints,
 	}
 	if p := literalRegexp(re); p != "" {
 		info.path = p
@@ -413,6 +437,24 @@ func (app *App) SetTrustXHeaders(t bool) {
 	app.trustXHeaders = t
 }
 
+// OrmComments returns if the app adds comments to the
+// queries performed by Context.Orm. See SetOrmComments.
+func (app *App) OrmComments() bool {
+	return app.ormComments
+}
+
+// SetOrmComments sets if the ORM returned from Context.Orm
+// prepends a comment with the handler name (or the request
+// path for unnamed handlers) and the value of the X-Request-Id
+// header, if any, to every query it performs (see
+// gnd.la/orm.Orm.WithComment). This makes it possible to trace
+// the queries in the database logs back to the requests which
+// issued them, but it also disables prepared statements for
+// those queries. The default is disabled.
+func (app *App) SetOrmComments(b bool) {
+	app.ormComments = b
+}
+
 // AppendSlash returns if the app will automatically append
 // a slash when appropriate. See SetAppendSlash for a more
 // detailed description.
@@ -738,7 +780,15 @@ func (app *App) HandleAssets(prefix string, dir string) {
 
 func (app *App) addAssetsManager(manager *assets.Manager, main bool) {
 	handler := HandlerFromHTTPFunc(manager.Handler())
-	app.Handle("^"+manager.Prefix(), handler)
+	prefix := manager.Prefix()
+	if app.mount != "" && strings.HasPrefix(prefix, "/") && !strings.HasPrefix(prefix, app.mount+"/") {
+		// Handlers match the path without the mount prefix,
+		// but the manager must generate URLs including it.
+		manager.SetPrefix(app.mount + prefix)
+	} else {
+		prefix = strings.TrimPrefix(prefix, app.mount)
+	}
+	app.Handle("^"+prefix, handler)
 	if main {
 		app.Handle("^/favicon.ico$", handler)
 		app.Handle("^/robots.txt$", handler)
@@ -768,10 +818,14 @@ func (app *App) Reverse(name string, args ...interface{}) (string, error) {
 }
 
 func (app *App) reverse(name string, args []interface{}) (string, error) {
+	return app.reverseLanguage(name, args, "")
+}
+
+func (app *App) reverseLanguage(name string, args []interface{}, lang string) (string, error) {
 	if name == "" {
 		return "", errors.New("can't reverse, no handler name specified")
 	}
-	found, s, err := app.reverseHandler(name, args)
+	found, s, err := app.reverseHandler(name, args, lang)
 	if err != nil {
 		return "", err
 	}
@@ -781,7 +835,7 @@ func (app *App) reverse(name string, args []interface{}) (string, error) {
 	return s, nil
 }
 
-func (app *App) reverseHandler(name string, args []interface{}) (bool, string, error) {
+func (app *App) reverseHandler(name string, args []interface{}, lang string) (bool, string, error) {
 	for _, v := range app.handlers {
 		if v.name == name {
 			reversed, err := formatRegexp(v.rc, args)
@@ -802,6 +856,14 @@ func (app *App) reverseHandler(name string, args []interface{}) (bool, string, e
 				// Include, we can just prepend it.
 				reversed = app.childInfo.prefix + reversed
 			}
+			if lang != "" {
+				if opts := app.LanguagePrefix(); opts != nil && opts.isLocalized(reversed) {
+					reversed = languagePath(lang, reversed)
+				}
+			}
+			if mount := app.mountPrefix(); mount != "" {
+				reversed = mount + reversed
+			}
 			if v.host != "" {
 				reversed = fmt.Sprintf("//%s%s", v.host, reversed)
 			}
@@ -809,7 +871,7 @@ func (app *App) reverseHandler(name string, args []interface{}) (bool, string, e
 		}
 	}
 	for _, v := range app.included {
-		if found, s, err := v.app.reverseHandler(name, args); found {
+		if found, s, err := v.app.reverseHandler(name, args, lang); found {
 			return found, s, err
 		}
 	}
@@ -840,7 +902,9 @@ func (app *App) ListenAndServe() error {
 			signal.Emit(DID_LISTEN, app)
 		}
 	})
-	err = http.ListenAndServe(app.address+":"+strconv.Itoa(app.cfg.Port), app)
+	err = app.server().ListenAndServe()
+	// Don't lose the spans and logs from the last requests
+	app.flushTracing()
 	return err
 }
 
@@ -877,13 +941,18 @@ func (app *App) Orm() (*orm.Orm, error) {
 // prepareOrm must be called only in App instances without a
 // parent. If it doesn't fail, it sets the o field in the App.
 func (app *App) prepareOrm() error {
+	// Retrieve the query cache before locking, since
+	// initializing the cache also takes the lock.
+	qc, err := app.queryCache()
+	if err != nil {
+		return err
+	}
 	app.mu.Lock()
 	defer app.mu.Unlock()
 	if app.o != nil {
 		return nil
 	}
 	if app.parent != nil {
-		var err error
 		app.o, err = app.parent.orm()
 		return err
 	}
@@ -891,6 +960,11 @@ func (app *App) prepareOrm() error {
 	if err != nil {
 		return err
 	}
+	if qc != nil {
+		// Drivers which can't cache queries just
+		// ignore orm.Query.Cached
+		o.SetCache(qc)
+	}
 	if err := o.Initialize(); err != nil {
 		o.Close()
 		return err
@@ -969,7 +1043,7 @@ func (app *App) recover(ctx *Context) {
 }
 
 func (app *App) recoverErr(ctx *Context, err interface{}) {
-	if isIgnorable(err) {
+	if isIgnorable(err) || ctx.isClientGone(err) {
 		return
 	}
 	for _, v := range app.RecoverHandlers {
@@ -1118,11 +1192,34 @@ func (app *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		defer profile.End(0)
 	}
 	defer app.closeContext(ctx)
+	if app.tracer != nil {
+		app.startRequestSpan(ctx)
+		defer app.endRequestSpan(ctx)
+	}
 	defer app.recover(ctx)
+	if app.grpc != nil && IsGRPC(r) {
+		// Processors might reject the request (e.g. IP filters),
+		// so they must also run before serving gRPC.
+		if app.runProcessors(ctx) {
+			return
+		}
+		app.serveGRPC(ctx)
+		return
+	}
+	if app.canonical != nil && app.redirectCanonical(ctx) {
+		return
+	}
+	p := app.mountedPath(r.URL.Path)
+	if app.languagePrefix != nil {
+		var redirected bool
+		if p, redirected = app.routeLanguage(ctx, p); redirected {
+			return
+		}
+	}
 	if app.runProcessors(ctx) {
 		return
 	}
-	app.serveOrNotFound(r.URL.Path, ctx)
+	app.serveOrNotFound(p, ctx)
 }
 
 func (app *App) serveOrNotFound(path string, ctx *Context) {
@@ -1133,8 +1230,18 @@ func (app *App) serveOrNotFound(path string, ctx *Context) {
 }
 
 func (app *App) serve(path string, ctx *Context) bool {
-	if handler := app.matchHandler(path, ctx); handler != nil {
-		handler(ctx)
+	if info := app.matchHandler(path, ctx); info != nil {
+		if ctx.span != nil {
+			setSpanRoute(ctx.span, ctx, info)
+		}
+		if app.limitBody(ctx, info.maxBodySize) && app.decompressBody(ctx, info.maxBodySize) {
+			ctx.cachePolicy = info.cache
+			if len(info.earlyHints) > 0 {
+				ctx.sendEarlyHints(info.earlyHints)
+			}
+			info.handler(ctx)
+			ctx.flushCachePolicy()
+		}
 		return true
 	}
 
@@ -1150,7 +1257,7 @@ func (app *App) serve(path string, ctx *Context) bool {
 	return false
 }
 
-func (app *App) matchHandler(path string, ctx *Context) Handler {
+func (app *App) matchHandler(path string, ctx *Context) *handlerInfo {
 	for _, v := range app.handlers {
 		if v.host != "" && v.host != ctx.R.Host {
 			continue
@@ -1159,7 +1266,7 @@ func (app *App) matchHandler(path string, ctx *Context) Handler {
 			if v.path == path {
 				ctx.reProvider.reset(v.re, path, v.pathMatch)
 				ctx.handlerName = v.name
-				return v.handler
+				return v
 			}
 		} else {
 			// Use FindStringSubmatchIndex, since this way we can
@@ -1167,7 +1274,7 @@ func (app *App) matchHandler(path string, ctx *Context) Handler {
 			if m := v.re.FindStringSubmatchIndex(path); m != nil {
 				ctx.reProvider.reset(v.re, path, m)
 				ctx.handlerName = v.name
-				return v.handler
+				return v
 			}
 		}
 	}
@@ -1402,6 +1509,11 @@ func (app *App) Prepare() error {
 			return err
 		}
 	}
+	if app.parent == nil {
+		if err := app.prepareTracer(); err != nil {
+			return err
+		}
+	}
 	signal.Emit(WILL_PREPARE, app)
 	if s := app.cfg.Secret; s != "" && len(s) < 32 && os.Getenv("GONDOLA_ALLOW_SHORT_SECRET") == "" {
 		if os.Getenv("GONDOLA_IS_DEV_SERVER") != "" {
@@ -1420,6 +1532,7 @@ func (app *App) Prepare() error {
 		child.languageHandler = app.languageHandler
 		child.userFunc = app.userFunc
 		child.Logger = app.Logger
+		child.tracer = app.tracer
 	}
 	// Add hooks from each included app to all the other apps
 	for _, h := range app.hooks {
@@ -1440,6 +1553,9 @@ func (app *App) Prepare() error {
 			}
 		}
 	}
+	if app.parent == nil && app.cfg.TemplateLint {
+		app.logTemplateIssues()
+	}
 	app.prepared = true
 	signal.Emit(DID_PREPARE, app)
 	return nil
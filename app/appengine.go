@@ -27,6 +27,10 @@ func (app *App) cache() (*cache.Cache, error) {
 	return nil, errNoAppCache
 }
 
+func (app *App) queryCache() (*cache.Cache, error) {
+	return nil, nil
+}
+
 func (app *App) orm() (*orm.Orm, error) {
 	// When using GCSQL, there's no need for
 	// an appengine.Context to connect to the
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CachePolicy describes how responses might be cached by browsers and
+// proxies, setting the Cache-Control, Expires and Vary headers in a
+// consistent way. Policies are usually attached to handlers using
+// HandlerOptions.Cache, but they might also be used as a Transformer
+// with CachePolicy.Handler. Use CachePublic, CachePrivate,
+// CacheNoCache or CachePrivateNoStore to create a CachePolicy and
+// the CachePolicy methods to further customize it, e.g.:
+//
+//	App.HandleOptions("^/news/$", NewsHandler, &app.HandlerOptions{
+//		Cache: app.CachePublic(5 * time.Minute).Vary("Accept-Language"),
+//	})
+//
+// The headers are set when the response headers are sent, and only
+// for successful (2xx) and 304 Not Modified responses, so errors
+// are never cached. Handlers which set the Cache-Control header
+// themselves take precedence over the policy.
+type CachePolicy struct {
+	// Public allows shared caches (e.g. proxies) to store the
+	// response.
+	Public bool
+	// Private restricts caching to the client's browser.
+	Private bool
+	// NoCache requires revalidating the response with the
+	// server before using it.
+	NoCache bool
+	// NoStore forbids storing the response at all.
+	NoStore bool
+	// MaxAge is the time the response is considered fresh.
+	MaxAge time.Duration
+	// SharedMaxAge overrides MaxAge for shared caches (s-maxage).
+	SharedMaxAge time.Duration
+	// MustRevalidate forbids using stale responses.
+	MustRevalidate bool
+	// Immutable indicates that the response won't change while
+	// it's fresh, so browsers don't need to revalidate it on reloads.
+	Immutable bool
+	// StaleWhileRevalidate is the time a stale response might be
+	// used while it's revalidated in the background.
+	StaleWhileRevalidate time.Duration
+	// VaryHeaders are added to the Vary header.
+	VaryHeaders []string
+}
+
+// CachePublic returns a policy which allows any cache to store
+// the response for the given duration.
+func CachePublic(maxAge time.Duration) *CachePolicy {
+	return &CachePolicy{Public: true, MaxAge: maxAge}
+}
+
+// CachePrivate returns a policy which allows only the browser
+// to store the response for the given duration. It also adds
+// Cookie to the Vary header.
+func CachePrivate(maxAge time.Duration) *CachePolicy {
+	return &CachePolicy{Private: true, MaxAge: maxAge, VaryHeaders: []string{"Cookie"}}
+}
+
+// CacheNoCache returns a policy which allows storing the response,
+// but requires revalidating it every time it's used. It's usually
+// combined with ETags (see Context.NotModified).
+func CacheNoCache() *CachePolicy {
+	return &CachePolicy{NoCache: true}
+}
+
+// CachePrivateNoStore returns a policy which forbids storing the
+// response, useful for responses containing sensitive data.
+func CachePrivateNoStore() *CachePolicy {
+	return &CachePolicy{Private: true, NoStore: true}
+}
+
+// Vary adds the given headers to the Vary header and returns
+// the policy, so calls might be chained.
+func (p *CachePolicy) Vary(headers ...string) *CachePolicy {
+	p.VaryHeaders = append(p.VaryHeaders, headers...)
+	return p
+}
+
+// Shared sets SharedMaxAge and returns the policy.
+func (p *CachePolicy) Shared(maxAge time.Duration) *CachePolicy {
+	p.SharedMaxAge = maxAge
+	return p
+}
+
+// Revalidate sets MustRevalidate and returns the policy.
+func (p *CachePolicy) Revalidate() *CachePolicy {
+	p.MustRevalidate = true
+	return p
+}
+
+// Immutability sets Immutable and returns the policy.
+func (p *CachePolicy) Immutability() *CachePolicy {
+	p.Immutable = true
+	return p
+}
+
+// StaleFor sets StaleWhileRevalidate and returns the policy.
+func (p *CachePolicy) StaleFor(d time.Duration) *CachePolicy {
+	p.StaleWhileRevalidate = d
+	return p
+}
+
+// CacheControl returns the value for the Cache-Control header.
+func (p *CachePolicy) CacheControl() string {
+	var directives []string
+	add := func(d string, cond bool) {
+		if cond {
+			directives = append(directives, d)
+		}
+	}
+	seconds := func(d string, t time.Duration) {
+		if t > 0 || (d == "max-age" && (p.Public || p.Private) && !p.NoStore) {
+			directives = append(directives, d+"="+strconv.FormatInt(int64(t/time.Second), 10))
+		}
+	}
+	add("public", p.Public && !p.Private)
+	add("private", p.Private)
+	add("no-cache", p.NoCache)
+	add("no-store", p.NoStore)
+	if !p.NoStore {
+		seconds("max-age", p.MaxAge)
+		seconds("s-maxage", p.SharedMaxAge)
+		seconds("stale-while-revalidate", p.StaleWhileRevalidate)
+		add("immutable", p.Immutable)
+	}
+	add("must-revalidate", p.MustRevalidate)
+	return strings.Join(directives, ", ")
+}
+
+// Apply sets the Cache-Control, Expires and Vary headers in h.
+// Values previously set in the Cache-Control and Expires headers
+// are overwritten, while Vary values are merged.
+func (p *CachePolicy) Apply(h http.Header) {
+	h.Set("Cache-Control", p.CacheControl())
+	if p.NoStore || p.NoCache || p.MaxAge <= 0 {
+		// Expired in the past, for HTTP/1.0 caches
+		h.Set("Expires", time.Unix(0, 0).UTC().Format(http.TimeFormat))
+	} else {
+		h.Set("Expires", time.Now().Add(p.MaxAge).UTC().Format(http.TimeFormat))
+	}
+	if len(p.VaryHeaders) > 0 {
+		existing := make(map[string]bool)
+		for _, v := range h["Vary"] {
+			for _, name := range strings.Split(v, ",") {
+				existing[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+			}
+		}
+		for _, v := range p.VaryHeaders {
+			if key := http.CanonicalHeaderKey(v); !existing[key] {
+				existing[key] = true
+				h.Add("Vary", key)
+			}
+		}
+	}
+}
+
+// Handler returns a new Handler which applies the policy to the
+// responses of the given one. Its signature allows using it as a
+// Transformer.
+func (p *CachePolicy) Handler(handler Handler) Handler {
+	return func(ctx *Context) {
+		ctx.cachePolicy = p
+		handler(ctx)
+		ctx.flushCachePolicy()
+	}
+}
+
+// applyCachePolicy applies the policy for the current handler,
+// if any, once the status code is known. 304 responses must also
+// include the caching headers, since they update the stored ones.
+func (c *Context) applyCachePolicy(code int) {
+	p := c.cachePolicy
+	if p == nil {
+		return
+	}
+	c.cachePolicy = nil
+	if (code >= 200 && code < 300) || code == http.StatusNotModified {
+		if c.Header().Get("Cache-Control") == "" {
+			p.Apply(c.Header())
+		}
+	}
+}
+
+// flushCachePolicy is called after the handler returns. Handlers
+// might return without writing anything, which results in an empty
+// 200 response.
+func (c *Context) flushCachePolicy() {
+	if c.statusCode == 0 {
+		c.applyCachePolicy(http.StatusOK)
+	}
+	c.cachePolicy = nil
+}
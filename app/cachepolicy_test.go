@@ -0,0 +1,81 @@
+package app_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/app/tester"
+)
+
+func TestCacheControl(t *testing.T) {
+	cases := []struct {
+		policy *app.CachePolicy
+		expect string
+	}{
+		{app.CachePublic(5 * time.Minute), "public, max-age=300"},
+		{app.CachePublic(time.Hour).Shared(2 * time.Hour).Immutability(), "public, max-age=3600, s-maxage=7200, immutable"},
+		{app.CachePrivate(time.Minute).Revalidate(), "private, max-age=60, must-revalidate"},
+		{app.CachePrivate(0), "private, max-age=0"},
+		{app.CacheNoCache(), "no-cache"},
+		{app.CachePrivateNoStore(), "private, no-store"},
+		{app.CachePublic(time.Minute).StaleFor(time.Hour), "public, max-age=60, stale-while-revalidate=3600"},
+	}
+	for _, v := range cases {
+		if cc := v.policy.CacheControl(); cc != v.expect {
+			t.Errorf("expecting Cache-Control %q, got %q", v.expect, cc)
+		}
+	}
+}
+
+func TestCachePolicyVary(t *testing.T) {
+	h := make(http.Header)
+	h.Set("Vary", "Accept-Encoding, cookie")
+	app.CachePrivate(time.Minute).Vary("accept-encoding", "Accept-Language").Apply(h)
+	vary := h["Vary"]
+	expect := []string{"Accept-Encoding, cookie", "Accept-Language"}
+	if len(vary) != len(expect) {
+		t.Fatalf("expecting Vary %v, got %v", expect, vary)
+	}
+	for ii, v := range expect {
+		if vary[ii] != v {
+			t.Errorf("expecting Vary %v, got %v", expect, vary)
+		}
+	}
+}
+
+func TestCachePolicyHandler(t *testing.T) {
+	a := app.New()
+	a.HandleOptions("^/public/$", func(ctx *app.Context) {
+		ctx.WriteString("public")
+	}, &app.HandlerOptions{Cache: app.CachePublic(5 * time.Minute).Vary("Accept-Language")})
+	a.HandleOptions("^/secret/$", func(ctx *app.Context) {
+		ctx.WriteString("secret")
+	}, &app.HandlerOptions{Cache: app.CachePrivateNoStore()})
+	a.Handle("^/transformed/$", app.CachePrivate(time.Minute).Handler(func(ctx *app.Context) {
+		ctx.WriteString("transformed")
+	}))
+	a.HandleOptions("^/override/$", func(ctx *app.Context) {
+		ctx.Header().Set("Cache-Control", "no-cache")
+		ctx.WriteString("override")
+	}, &app.HandlerOptions{Cache: app.CachePublic(time.Hour)})
+	a.HandleOptions("^/error/$", func(ctx *app.Context) {
+		ctx.NotFound("not found")
+	}, &app.HandlerOptions{Cache: app.CachePublic(time.Hour)})
+	a.HandleOptions("^/empty/$", func(ctx *app.Context) {
+	}, &app.HandlerOptions{Cache: app.CachePublic(time.Hour)})
+	tt := tester.New(t, a)
+	tt.Get("/public/", nil).Expect(200).ExpectHeader("Cache-Control", "public, max-age=300").ExpectHeader("Vary", "Accept-Language")
+	tt.Get("/secret/", nil).Expect(200).ExpectHeader("Cache-Control", "private, no-store").ExpectHeader("Expires", "Thu, 01 Jan 1970 00:00:00 GMT")
+	tt.Get("/transformed/", nil).Expect(200).ExpectHeader("Cache-Control", "private, max-age=60").ExpectHeader("Vary", "Cookie")
+	tt.Get("/override/", nil).Expect(200).ExpectHeader("Cache-Control", "no-cache")
+	tt.Get("/error/", nil).Expect(404).ExpectHeader("Cache-Control", "")
+	// Handlers which don't write anything send an empty 200
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, httptest.NewRequest("GET", "/empty/", nil))
+	if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=3600" {
+		t.Errorf("expecting Cache-Control for empty response, got %q", cc)
+	}
+}
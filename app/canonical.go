@@ -0,0 +1,145 @@
+package app
+
+import (
+	"net"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// TrailingSlash indicates how trailing slashes in request
+// paths are normalized. See CanonicalOptions.
+type TrailingSlash int
+
+const (
+	// TrailingSlashKeep leaves the paths untouched.
+	TrailingSlashKeep TrailingSlash = iota
+	// TrailingSlashAdd redirects /foo to /foo/. Paths whose
+	// last segment contains a dot (e.g. /robots.txt) are
+	// not redirected, since they usually refer to files.
+	TrailingSlashAdd
+	// TrailingSlashRemove redirects /foo/ to /foo.
+	TrailingSlashRemove
+)
+
+// CanonicalOptions specify the canonical form of the URLs served
+// by an App. Requests to non-canonical URLs are redirected to their
+// canonical form before routing, using a 301 for GET and HEAD
+// requests and a 308 for the rest (so the method and body are
+// preserved). See App.SetCanonical.
+type CanonicalOptions struct {
+	// Host is the canonical host name, optionally including a port
+	// (e.g. www.example.com or example.com). If non-empty, requests
+	// to other hosts are redirected to it. Requests to localhost or
+	// to an IP address are never redirected, so the App keeps
+	// working during development.
+	Host string
+	// HTTPS redirects requests made over plain HTTP to HTTPS. Note
+	// that when the App runs behind a proxy which terminates TLS,
+	// SetTrustXHeaders must be enabled, so the App can know the
+	// scheme used by the client.
+	HTTPS bool
+	// Lowercase redirects paths containing uppercase characters
+	// to their lowercase version.
+	Lowercase bool
+	// TrailingSlash sets the policy for trailing slashes.
+	TrailingSlash TrailingSlash
+	// Exempt lists path prefixes (e.g. /api/) which are not
+	// subject to path normalization (Lowercase and
+	// TrailingSlash). Host and scheme redirects still apply.
+	Exempt []string
+}
+
+// Canonical returns the CanonicalOptions for the App, or nil
+// if no canonical URLs are enforced.
+func (app *App) Canonical() *CanonicalOptions {
+	return app.canonical
+}
+
+// SetCanonical sets the CanonicalOptions for the App. Passing
+// nil disables canonical URL enforcement, which is the default.
+func (app *App) SetCanonical(opts *CanonicalOptions) {
+	app.canonical = opts
+}
+
+// redirectCanonical redirects the request to its canonical URL if
+// it isn't already canonical, returning true iff it redirected.
+func (app *App) redirectCanonical(ctx *Context) bool {
+	opts := app.canonical
+	r := ctx.R
+	u := ctx.URL()
+	absolute := false
+	if opts.HTTPS && u.Scheme != "https" {
+		u.Scheme = "https"
+		absolute = true
+	}
+	if opts.Host != "" && !strings.EqualFold(u.Host, opts.Host) && !isDevelopmentHost(u.Host) {
+		u.Host = opts.Host
+		absolute = true
+	}
+	changed := absolute
+	if !opts.isExempt(u.Path) {
+		if p := opts.canonicalPath(u.Path); p != u.Path {
+			u.Path = p
+			// Avoid sending the original encoding
+			u.RawPath = ""
+			changed = true
+		}
+	}
+	if !changed {
+		return false
+	}
+	redir := u.RequestURI()
+	if absolute {
+		redir = u.String()
+	}
+	code := http.StatusMovedPermanently
+	if r.Method != "GET" && r.Method != "HEAD" {
+		code = http.StatusPermanentRedirect
+	}
+	http.Redirect(ctx, r, redir, code)
+	return true
+}
+
+func (opts *CanonicalOptions) isExempt(p string) bool {
+	for _, v := range opts.Exempt {
+		if strings.HasPrefix(p, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (opts *CanonicalOptions) canonicalPath(p string) string {
+	if strings.HasPrefix(p, "//") {
+		// Collapse leading slashes, otherwise the Location header
+		// would be interpreted as a protocol relative URL
+		// (e.g. //evil.com/) pointing to another host.
+		p = "/" + strings.TrimLeft(p, "/")
+	}
+	if opts.Lowercase {
+		p = strings.ToLower(p)
+	}
+	switch opts.TrailingSlash {
+	case TrailingSlashAdd:
+		if !strings.HasSuffix(p, "/") && !strings.Contains(path.Base(p), ".") {
+			p += "/"
+		}
+	case TrailingSlashRemove:
+		if p != "/" {
+			p = strings.TrimRight(p, "/")
+			if p == "" {
+				p = "/"
+			}
+		}
+	}
+	return p
+}
+
+func isDevelopmentHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	return host == "" || host == "localhost" || net.ParseIP(host) != nil
+}
@@ -0,0 +1,58 @@
+package app_test
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gnd.la/app"
+	"gnd.la/app/tester"
+)
+
+func TestCanonical(t *testing.T) {
+	a := app.New()
+	a.SetCanonical(&app.CanonicalOptions{
+		Lowercase:     true,
+		TrailingSlash: app.TrailingSlashAdd,
+		Exempt:        []string{"/api/"},
+	})
+	a.Handle("^/about/$", func(ctx *app.Context) { ctx.WriteString("about") })
+	a.Handle("^/api/Items$", func(ctx *app.Context) { ctx.WriteString("items") })
+	tt := tester.New(t, a)
+	tt.Get("/about/", nil).Expect("about")
+	tt.Get("/About", nil).Expect(http.StatusMovedPermanently).ExpectHeader("Location", "/about/")
+	tt.Get("/robots.txt", nil).Expect(404)
+	tt.Post("/About/", nil).Expect(http.StatusPermanentRedirect)
+	tt.Get("/api/Items", nil).Expect("items")
+}
+
+func TestCanonicalLeadingSlashes(t *testing.T) {
+	a := app.New()
+	a.SetCanonical(&app.CanonicalOptions{
+		Lowercase:     true,
+		TrailingSlash: app.TrailingSlashAdd,
+	})
+	// Leading slashes must not produce a protocol relative URL
+	for _, v := range []string{"//evil.com", "///Evil.com"} {
+		r, err := http.ReadRequest(bufio.NewReader(strings.NewReader("GET " + v + " HTTP/1.1\r\nHost: example.com\r\n\r\n")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, r)
+		if loc := w.Header().Get("Location"); w.Code != http.StatusMovedPermanently || loc != "/evil.com" {
+			t.Errorf("expecting redirect from %s to /evil.com, got %d to %q", v, w.Code, loc)
+		}
+	}
+}
+
+func TestCanonicalHost(t *testing.T) {
+	a := app.New()
+	a.SetCanonical(&app.CanonicalOptions{Host: "www.example.com", HTTPS: true})
+	a.Handle("^/$", func(ctx *app.Context) { ctx.WriteString("index") })
+	tt := tester.New(t, a)
+	tt.Get("/?q=1", nil).AddHeader("Host", "example.com").Expect(http.StatusMovedPermanently).
+		ExpectHeader("Location", "https://www.example.com/?q=1")
+}
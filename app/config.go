@@ -15,6 +15,10 @@ type Config struct {
 	// are not bundled and templates are recompiled each
 	// time they are loaded.
 	TemplateDebug bool `help:"Enable template debug mode. This disables asset bundling and template caching"`
+	// TemplateLint makes the app check all its templates for
+	// common mistakes when it starts, logging any issues found.
+	// See App.LintTemplates for more information.
+	TemplateLint bool `help:"Check templates for common mistakes at startup (see also the vet-templates command)"`
 	// Language indicates the language used for
 	// translating strings when there's no LanguageHandler
 	// or when it returns an empty string.
@@ -33,11 +37,70 @@ type Config struct {
 	// app for, among other things, encrypted cookies. It should
 	// be a random string of 16 or 24 or 32 characters.
 	EncryptionKey string `help:"Key used for encryption (e.g. encrypted cookies)"`
+	// MaxBodySize is the default maximum size in bytes for
+	// request bodies. It can be overridden for each handler
+	// using HandlerOptions.MaxBodySize. Zero means no limit.
+	MaxBodySize int64 `help:"Default maximum request body size in bytes, 0 means no limit"`
+	// DecompressBody enables transparently decompressing request
+	// bodies sent with a Content-Encoding (gzip and deflate by
+	// default, see RegisterDecoder) before they're read by the
+	// handlers. Both the compressed and the decompressed bodies
+	// are limited by MaxBodySize.
+	DecompressBody bool `help:"Decompress request bodies sent with a Content-Encoding"`
+	// MaxDecompressionRatio is the maximum ratio between the size
+	// of a decompressed request body and its compressed size, which
+	// protects the App from decompression bombs. Zero means no limit.
+	MaxDecompressionRatio int `default:"100" help:"Maximum expansion ratio for decompressed request bodies, 0 means no limit"`
+	// HeaderTimeout is the maximum number of seconds to wait
+	// for the client to send the request headers, which protects
+	// the server from clients which open connections and then
+	// send the headers very slowly.
+	HeaderTimeout int `default:"10" help:"Timeout in seconds for reading request headers, 0 means no timeout"`
+	// ReadTimeout, WriteTimeout and IdleTimeout correspond to
+	// the timeouts with the same names in net/http.Server, in
+	// seconds. Zero means no timeout. Note that WriteTimeout
+	// also limits the time spent by the handlers.
+	ReadTimeout  int `help:"Timeout in seconds for reading the whole request, 0 means no timeout"`
+	WriteTimeout int `help:"Timeout in seconds for writing the response, 0 means no timeout"`
+	IdleTimeout  int `default:"120" help:"Timeout in seconds for idle keep-alive connections, 0 means no timeout"`
+	// MaxHeaderBytes is the maximum size of the request headers,
+	// including the request line. Zero uses the net/http default
+	// (1MB).
+	MaxHeaderBytes int `help:"Maximum size in bytes of request headers, 0 uses the net/http default"`
+	// Tracing is the OTLP/HTTP endpoint of an OpenTelemetry
+	// collector (e.g. http://localhost:4318). When it's non-empty,
+	// the App starts a span for each request and exports it,
+	// together with its child spans (e.g. ORM queries), to the
+	// collector. See gnd.la/trace for more details.
+	Tracing string `help:"OTLP/HTTP endpoint for exporting request traces, empty disables tracing"`
+	// TracingLogs makes the App also export the messages logged
+	// with its Logger to the Tracing collector, correlating the
+	// ones logged while serving a request with its trace. It has
+	// no effect when Tracing is empty.
+	TracingLogs bool `help:"Export log messages to the OTLP/HTTP endpoint set in Tracing"`
+	// Environment is the name of the environment the App is
+	// deployed to (e.g. production or staging). Some built-in
+	// handlers behave differently outside of production (see
+	// RobotsHandler).
+	Environment string `default:"production" help:"Environment the app is deployed to (e.g. production or staging)"`
+	// Experiments defines the A/B tests run by the App. See
+	// Experiment and Context.Variant for more details.
+	Experiments []Experiment `help:"Experiments as name:variant=weight|variant=weight, separated by commas"`
+}
+
+// Production returns true iff the Environment is
+// production or empty.
+func (c *Config) Production() bool {
+	return c.Environment == "" || c.Environment == "production"
 }
 
 var (
 	defaultConfig = Config{
-		Port: 8888,
+		Port:                  8888,
+		HeaderTimeout:         10,
+		IdleTimeout:           120,
+		MaxDecompressionRatio: 100,
+		Environment:           "production",
 	}
 )
 
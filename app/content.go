@@ -0,0 +1,72 @@
+package app
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ServeContent replies to the request using the content in the given
+// io.ReadSeeker. It handles Range and If-Range requests, as well as
+// HEAD requests and the If-Modified-Since, If-Unmodified-Since,
+// If-Match and If-None-Match conditional headers. If the response
+// has an ETag header set before calling ServeContent, it's used for
+// evaluating the conditional headers.
+//
+// If the Content-Type header is not set, it's determined from the
+// extension in name or, if that fails, by sniffing the first bytes
+// of the content. If modtime is not the zero time.Time, it's sent
+// in the Last-Modified header.
+//
+// Use this function for serving content with the same semantics
+// as files on disk, like blobstore files (see ServeBlob) or files
+// generated on the fly.
+func (c *Context) ServeContent(name string, modtime time.Time, content io.ReadSeeker) {
+	http.ServeContent(c, c.R, name, modtime, content)
+}
+
+// ServeFile serves the file at the given path using ServeContent.
+// If the file does not exist or it's a directory, it responds with
+// a 404. Note that, unlike net/http.ServeFile, it doesn't generate
+// listings for directories nor redirects to canonical paths, so it's
+// safe to use with paths derived from the request, as long as they
+// have been sanitized to not contain references to parent directories.
+func (c *Context) ServeFile(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.NotFound("file not found")
+			return
+		}
+		panic(err)
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		panic(err)
+	}
+	if st.IsDir() {
+		c.NotFound("file not found")
+		return
+	}
+	c.ServeContent(filepath.Base(path), st.ModTime(), f)
+}
+
+// ServeBlob serves the blobstore file with the given id, using
+// ServeContent. Since blobstore files are immutable, the id is
+// used as the ETag. If the file does not exist, it responds
+// with a 404.
+func (c *Context) ServeBlob(id string) {
+	f, err := c.Blobstore().Open(id)
+	if err != nil {
+		c.NotFound("file not found")
+		return
+	}
+	defer f.Close()
+	if c.Header().Get("ETag") == "" {
+		c.Header().Set("ETag", ETag([]byte(id)))
+	}
+	c.ServeContent(id, time.Time{}, f)
+}
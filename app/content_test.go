@@ -0,0 +1,29 @@
+package app_test
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/app/tester"
+)
+
+func TestServeContent(t *testing.T) {
+	content := []byte("0123456789")
+	modtime := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := app.New()
+	a.Handle("^/content.txt$", func(ctx *app.Context) {
+		ctx.ServeContent("content.txt", modtime, bytes.NewReader(content))
+	})
+	tt := tester.New(t, a)
+	tt.Get("/content.txt", nil).Expect(200).ExpectHeader("Content-Type", "text/plain; charset=utf-8").Expect(string(content))
+	tt.Get("/content.txt", nil).AddHeader("Range", "bytes=2-4").Expect(http.StatusPartialContent).Expect("234")
+	tt.Get("/content.txt", nil).AddHeader("Range", "bytes=20-").Expect(http.StatusRequestedRangeNotSatisfiable)
+	tt.Get("/content.txt", nil).AddHeader("If-Modified-Since", modtime.Format(http.TimeFormat)).Expect(http.StatusNotModified)
+	// If-Range with an older date ignores the Range header
+	older := modtime.Add(-time.Hour).Format(http.TimeFormat)
+	tt.Get("/content.txt", nil).AddHeader("Range", "bytes=2-4").AddHeader("If-Range", older).Expect(200).Expect(string(content))
+	tt.Request("HEAD", "/content.txt", nil).Expect(200).ExpectHeader("Content-Length", "10")
+}
@@ -21,6 +21,7 @@ import (
 	"gnd.la/log"
 	"gnd.la/net/urlutil"
 	"gnd.la/orm"
+	"gnd.la/trace"
 	"gnd.la/util/types"
 )
 
@@ -47,10 +48,20 @@ type Context struct {
 	cookies         *cookies.Cookies
 	user            User
 	translations    *table.Table
+	requestOrm      *orm.Orm
+	span            *trace.Span
 	hasTranslations bool
 	background      bool
 	wg              *sync.WaitGroup
 	values          map[string]interface{}
+	query           *QueryParams
+	flashes         []*Flash
+	flashesLoaded   bool
+	hasFlashCookie  bool
+	renderingEmail  bool
+	language        string
+	cachePolicy     *CachePolicy
+	contextLog      contextLog
 }
 
 func (c *Context) reset() {
@@ -61,8 +72,18 @@ func (c *Context) reset() {
 	c.cookies = nil
 	c.user = nil
 	c.translations = nil
+	c.requestOrm = nil
+	c.span = nil
 	c.hasTranslations = false
 	c.values = nil
+	c.query = nil
+	c.flashes = nil
+	c.flashesLoaded = false
+	c.hasFlashCookie = false
+	c.renderingEmail = false
+	c.language = ""
+	c.cachePolicy = nil
+	c.contextLog = contextLog{}
 }
 
 // Count returns the number of elements captured
@@ -272,9 +293,11 @@ func (c *Context) MustReverse(name string, args ...interface{}) string {
 // value than App.Reverse for host-specific handlers, since App.Reverse will
 // return a protocol-relative URL (e.g. //www.gondolaweb.com) while Context.Reverse
 // can return an absolute URL (e.g. http://www.gondolaweb.com) if the Context
-// has a Request associated with it.
+// has a Request associated with it. When language prefixes are enabled
+// (see App.SetLanguagePrefix), the returned URL includes the language
+// of the current request.
 func (c *Context) Reverse(name string, args ...interface{}) (string, error) {
-	r, err := c.app.Reverse(name, args...)
+	r, err := c.app.reverseLanguage(name, args, c.language)
 	if err == nil && strings.HasPrefix(r, "//") {
 		if s := c.requestScheme(); s != "" {
 			r = s + ":" + r
@@ -377,9 +400,36 @@ func (c *Context) Blobstore() *blobstore.Blobstore {
 }
 
 // Orm is a shorthand for ctx.App().Orm(), but panics in case
-// of error, rather than returning it.
+// of error, rather than returning it. When serving a request, the
+// returned Orm uses the request context.Context, so running queries
+// are cancelled if the client goes away (see orm.Orm.WithContext).
+// If the App has ORM comments enabled, the returned Orm also adds the
+// request information to its queries (see App.SetOrmComments).
 func (c *Context) Orm() *orm.Orm {
-	return c.orm()
+	if c.R == nil || (c.background && !c.app.ormComments) {
+		return c.orm()
+	}
+	if c.requestOrm == nil {
+		o := c.orm()
+		if !c.background {
+			// Background contexts outlive the request,
+			// so they can't use its context.
+			o = o.WithContext(c.R.Context())
+		}
+		if c.app.ormComments {
+			route := c.handlerName
+			if route == "" {
+				route = c.R.URL.Path
+			}
+			values := map[string]string{"route": route}
+			if id := c.R.Header.Get("X-Request-Id"); id != "" {
+				values["request_id"] = id
+			}
+			o = o.WithComment(values)
+		}
+		c.requestOrm = o
+	}
+	return c.requestOrm
 }
 
 // Execute loads the template with the given name using the
@@ -588,6 +638,7 @@ func (c *Context) WriteHeader(code int) {
 		code = -c.statusCode
 	}
 	c.statusCode = code
+	c.applyCachePolicy(code)
 	if profile.On && profile.Profiling() {
 		header := profileHeader(c)
 		c.Header().Set(profile.HeaderName, header)
@@ -599,12 +650,19 @@ func (c *Context) WriteString(s string) (int, error) {
 	return c.Write([]byte(s))
 }
 
+// Write writes the given data to the response, sending the headers
+// if they haven't been sent yet. If the client has disconnected, a
+// *ClientGoneError is returned.
 func (c *Context) Write(data []byte) (int, error) {
+	if err := c.clientGoneErr(); err != nil {
+		return 0, &ClientGoneError{Err: err}
+	}
 	if c.statusCode <= 0 {
 		// code will be overriden if < 0
 		c.WriteHeader(http.StatusOK)
 	}
-	return c.ResponseWriter.Write(data)
+	n, err := c.ResponseWriter.Write(data)
+	return n, c.writeError(err)
 }
 
 func urlHost(u string) string {
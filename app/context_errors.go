@@ -22,6 +22,7 @@ var (
 		http.StatusGone:                         i18n.String("gone"),
 		http.StatusLengthRequired:               i18n.String("length required"),
 		http.StatusPreconditionFailed:           i18n.String("precondition failed"),
+		http.StatusPreconditionRequired:         i18n.String("precondition required"),
 		http.StatusRequestEntityTooLarge:        i18n.String("request entity too large"),
 		http.StatusRequestURITooLong:            i18n.String("request URI too long"),
 		http.StatusUnsupportedMediaType:         i18n.String("unsupported media type"),
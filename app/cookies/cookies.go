@@ -15,6 +15,7 @@ import (
 	"gnd.la/encoding/base64"
 	"gnd.la/encoding/codec"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -208,9 +209,23 @@ func (c *Cookies) GetCookie(name string) (*http.Cookie, error) {
 	return c.r.Cookie(name)
 }
 
-// SetCookie sets the given *http.Cookie.
+// SetCookie sets the given *http.Cookie. If a cookie with
+// the same name was already set in the response, it's replaced.
 func (c *Cookies) SetCookie(cookie *http.Cookie) {
 	if c.w != nil {
+		h := c.w.Header()
+		prefix := cookie.Name + "="
+		values := h["Set-Cookie"][:0]
+		for _, v := range h["Set-Cookie"] {
+			if !strings.HasPrefix(v, prefix) {
+				values = append(values, v)
+			}
+		}
+		if len(values) > 0 {
+			h["Set-Cookie"] = values
+		} else {
+			delete(h, "Set-Cookie")
+		}
 		http.SetCookie(c.w, cookie)
 	}
 }
@@ -0,0 +1,194 @@
+package app
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Decoder returns a reader which decompresses the data read from r.
+type Decoder func(r io.Reader) (io.ReadCloser, error)
+
+var decoders = struct {
+	sync.RWMutex
+	m map[string]Decoder
+}{
+	m: map[string]Decoder{
+		"gzip": func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+		// The deflate Content-Encoding is the zlib format
+		// (RFC 1950), not raw DEFLATE data.
+		"deflate": func(r io.Reader) (io.ReadCloser, error) {
+			return zlib.NewReader(r)
+		},
+	},
+}
+
+// RegisterDecoder registers a Decoder for request bodies sent with the
+// given Content-Encoding, so it's accepted when Config.DecompressBody
+// is enabled. Only gzip and deflate are supported by default, since
+// the standard library has no decoders for other encodings (e.g. br
+// or zstd). They can be added using third party packages, e.g. zstd
+// using github.com/klauspost/compress/zstd:
+//
+//	app.RegisterDecoder("zstd", func(r io.Reader) (io.ReadCloser, error) {
+//		d, err := zstd.NewReader(r)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return d.IOReadCloser(), nil
+//	})
+func RegisterDecoder(encoding string, dec Decoder) {
+	decoders.Lock()
+	defer decoders.Unlock()
+	decoders.m[strings.ToLower(encoding)] = dec
+}
+
+func decoder(encoding string) Decoder {
+	decoders.RLock()
+	defer decoders.RUnlock()
+	return decoders.m[encoding]
+}
+
+// UnsupportedEncodingError is returned when the request body uses a
+// Content-Encoding without a registered Decoder (see RegisterDecoder).
+// Its status code is 415.
+type UnsupportedEncodingError struct {
+	Encoding string
+}
+
+func (e *UnsupportedEncodingError) StatusCode() int {
+	return http.StatusUnsupportedMediaType
+}
+
+func (e *UnsupportedEncodingError) Error() string {
+	return fmt.Sprintf("Unsupported request Content-Encoding %q", e.Encoding)
+}
+
+// DecompressionRatioError is returned when reading a compressed
+// request body which expands more than the maximum ratio allowed
+// by Config.MaxDecompressionRatio. Its status code is 413.
+type DecompressionRatioError struct {
+	Ratio int
+}
+
+func (e *DecompressionRatioError) StatusCode() int {
+	return http.StatusRequestEntityTooLarge
+}
+
+func (e *DecompressionRatioError) Error() string {
+	return fmt.Sprintf("Request body expands more than %d times when decompressed", e.Ratio)
+}
+
+// decompressBody replaces the request body with a decompressed
+// one when the App has Config.DecompressBody enabled and the request
+// has a Content-Encoding. The decompressed body is limited to the
+// same size as the compressed one (see limitBody). If the encoding
+// is not supported, it replies with a 415 and returns false.
+func (app *App) decompressBody(ctx *Context, limit int64) bool {
+	if !app.cfg.DecompressBody || ctx.R == nil || ctx.R.Body == nil {
+		return true
+	}
+	encoding := strings.ToLower(strings.TrimSpace(ctx.R.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" {
+		return true
+	}
+	dec := decoder(encoding)
+	if dec == nil {
+		app.handleError(ctx, &UnsupportedEncodingError{Encoding: encoding})
+		return false
+	}
+	counter := &countingReader{r: ctx.R.Body}
+	body, err := dec(counter)
+	if err != nil {
+		app.handleError(ctx, &badEncodingError{err: err})
+		return false
+	}
+	ctx.R.Body = &decompressedBody{
+		ReadCloser: body,
+		compressed: ctx.R.Body,
+		counter:    counter,
+		ratio:      int64(app.cfg.MaxDecompressionRatio),
+	}
+	ctx.R.Header.Del("Content-Encoding")
+	ctx.R.Header.Del("Content-Length")
+	ctx.R.ContentLength = -1
+	if limit == 0 {
+		limit = app.cfg.MaxBodySize
+	}
+	if limit > 0 {
+		ctx.R.Body = &limitedBody{ReadCloser: ctx.R.Body, limit: limit, remaining: limit}
+	}
+	return true
+}
+
+// minRatioBase is the minimum number of compressed bytes used for
+// checking the decompression ratio, so small bodies which compress
+// very well (e.g. a few KB of repeated JSON) are not rejected.
+const minRatioBase = 1024
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type decompressedBody struct {
+	io.ReadCloser
+	compressed io.Closer
+	counter    *countingReader
+	ratio      int64
+	n          int64
+}
+
+func (b *decompressedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if b.ratio > 0 {
+		base := b.counter.n
+		if base < minRatioBase {
+			base = minRatioBase
+		}
+		if b.n > base*b.ratio {
+			return n, &DecompressionRatioError{Ratio: int(b.ratio)}
+		}
+	}
+	if err != nil && err != io.EOF {
+		if _, ok := err.(*RequestEntityTooLargeError); !ok {
+			err = &badEncodingError{err: err}
+		}
+	}
+	return n, err
+}
+
+func (b *decompressedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if cerr := b.compressed.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// badEncodingError is returned when the request body can't be
+// decompressed. Its status code is 400.
+type badEncodingError struct {
+	err error
+}
+
+func (e *badEncodingError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+func (e *badEncodingError) Error() string {
+	return fmt.Sprintf("Error decompressing request body: %s", e.err)
+}
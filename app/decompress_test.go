@@ -0,0 +1,81 @@
+package app_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gnd.la/app"
+)
+
+func gzipped(t *testing.T, data []byte) []byte {
+	return compressed(t, data, func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })
+}
+
+func deflated(t *testing.T, data []byte) []byte {
+	return compressed(t, data, func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) })
+}
+
+func compressed(t *testing.T, data []byte, f func(io.Writer) io.WriteCloser) []byte {
+	var buf bytes.Buffer
+	w := f(&buf)
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompressBody(t *testing.T) {
+	a := app.New()
+	a.Config().DecompressBody = true
+	a.Config().MaxBodySize = 1 << 20
+	a.Handle("^/$", func(ctx *app.Context) {
+		data, err := ioutil.ReadAll(ctx.R.Body)
+		if err != nil {
+			panic(err)
+		}
+		ctx.Write(data)
+	})
+	payload := []byte(`{"items": [1, 2, 3]}`)
+	bomb := bytes.Repeat([]byte("a"), 512<<10)
+	cases := []struct {
+		body     []byte
+		encoding string
+		status   int
+		expect   []byte
+	}{
+		{gzipped(t, payload), "gzip", http.StatusOK, payload},
+		{deflated(t, payload), "deflate", http.StatusOK, payload},
+		{payload, "", http.StatusOK, payload},
+		{payload, "br", http.StatusUnsupportedMediaType, nil},
+		{payload, "gzip", http.StatusBadRequest, nil},
+		{gzipped(t, bomb), "gzip", http.StatusRequestEntityTooLarge, nil},
+	}
+	for _, v := range cases {
+		r, err := http.NewRequest("POST", "/", bytes.NewReader(v.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.encoding != "" {
+			r.Header.Set("Content-Encoding", v.encoding)
+		}
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, r)
+		if w.Code != v.status {
+			t.Errorf("expecting status %d for encoding %q, got %d", v.status, v.encoding, w.Code)
+			continue
+		}
+		if v.expect != nil && !bytes.Equal(w.Body.Bytes(), v.expect) {
+			t.Errorf("expecting body %q, got %q", v.expect, strings.TrimSpace(w.Body.String()))
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"errors"
+)
+
+// ClientGoneError is returned by Context.Write when the client has
+// disconnected, so the response can't be delivered anymore. Handlers
+// doing long-running work (e.g. exports or event streams) should stop
+// as soon as they receive this error. Panics with a ClientGoneError
+// are not logged by the App.
+type ClientGoneError struct {
+	// Err is the underlying error, either the error from the
+	// request context or the error returned by the connection.
+	Err error
+}
+
+func (e *ClientGoneError) Error() string {
+	return "client disconnected: " + e.Err.Error()
+}
+
+func (e *ClientGoneError) Unwrap() error {
+	return e.Err
+}
+
+// IsClientGone returns true iff err is a *ClientGoneError.
+func IsClientGone(err error) bool {
+	var gerr *ClientGoneError
+	return errors.As(err, &gerr)
+}
+
+// Done returns a channel which is closed when the client disconnects
+// or the request finishes, whatever happens first. Long running
+// handlers might use it to stop working as soon as the response
+// can't be delivered:
+//
+//	for {
+//		select {
+//		case <-ctx.Done():
+//			return
+//		case ev := <-events:
+//			...
+//		}
+//	}
+//
+// For contexts not serving a request (e.g. tasks) and for
+// background contexts, Done returns nil, which blocks forever.
+func (c *Context) Done() <-chan struct{} {
+	if c.R == nil || c.background {
+		return nil
+	}
+	return c.R.Context().Done()
+}
+
+// ClientGone returns true iff the Context is serving a request
+// and the client has already disconnected.
+func (c *Context) ClientGone() bool {
+	return c.clientGoneErr() != nil
+}
+
+func (c *Context) clientGoneErr() error {
+	if c.R == nil || c.background {
+		return nil
+	}
+	return c.R.Context().Err()
+}
+
+// writeError converts errors produced while writing the response
+// into a *ClientGoneError when the client has disconnected.
+func (c *Context) writeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*ClientGoneError); ok {
+		return err
+	}
+	if c.clientGoneErr() != nil || isIgnorable(err) {
+		return &ClientGoneError{Err: err}
+	}
+	return err
+}
+
+// isClientGone returns true iff err indicates that the request was
+// aborted because the client disconnected.
+func (c *Context) isClientGone(err interface{}) bool {
+	e, ok := err.(error)
+	if !ok {
+		return false
+	}
+	if IsClientGone(e) {
+		return true
+	}
+	return errors.Is(e, context.Canceled) && c.clientGoneErr() != nil
+}
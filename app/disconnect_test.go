@@ -0,0 +1,43 @@
+package app_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gnd.la/app"
+)
+
+func TestClientGone(t *testing.T) {
+	var writeErr error
+	var done bool
+	a := app.New()
+	a.Handle("^/$", func(ctx *app.Context) {
+		select {
+		case <-ctx.Done():
+			done = true
+		default:
+		}
+		_, writeErr = ctx.WriteString("hello")
+		// Must not be logged nor produce an error page
+		panic(writeErr)
+	})
+	r, err := http.NewRequest("GET", "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r.WithContext(rctx))
+	if !done {
+		t.Error("Done() was not closed after the client disconnected")
+	}
+	if !app.IsClientGone(writeErr) {
+		t.Errorf("expecting a *ClientGoneError, got %v", writeErr)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expecting no response body, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"net/http"
+
+	"gnd.la/log"
+)
+
+// Preloads returns the Link header values for preloading the CSS
+// and Javascript assets used by the template. See also Context.EarlyHints.
+func (t *Template) Preloads() []string {
+	return t.tmpl.Preloads()
+}
+
+// EarlyHints adds Link headers for preloading the assets used by the
+// given templates and sends them in a 103 Early Hints response, so
+// browsers can start fetching the assets while the handler is still
+// producing the response. The Link headers are also kept for the
+// final response. Hints are not sent if the response has already
+// started or the client does not support HTTP/1.1.
+//
+// Rather than calling EarlyHints manually, most handlers will set
+// HandlerOptions.EarlyHints to the templates they render.
+func (c *Context) EarlyHints(templates ...string) error {
+	if c.statusCode > 0 || c.R == nil || !c.R.ProtoAtLeast(1, 1) {
+		return nil
+	}
+	header := c.Header()
+	existing := make(map[string]bool)
+	for _, v := range header["Link"] {
+		existing[v] = true
+	}
+	added := false
+	for _, name := range templates {
+		tmpl, err := c.app.LoadTemplate(name)
+		if err != nil {
+			return err
+		}
+		for _, v := range tmpl.Preloads() {
+			if !existing[v] {
+				existing[v] = true
+				header.Add("Link", v)
+				added = true
+			}
+		}
+	}
+	if added {
+		// Don't use c.WriteHeader, since this is not the final
+		// status code for the response. Wrappers (e.g. the ones
+		// used by gnd.la/cache/layer) might not expect it either,
+		// so send it directly to the http.ResponseWriter from
+		// net/http.
+		underlyingWriter(c.ResponseWriter).WriteHeader(http.StatusEarlyHints)
+	}
+	return nil
+}
+
+// underlyingWriter returns the innermost http.ResponseWriter wrapped
+// by w, following the Unwrap convention used by http.ResponseController.
+func underlyingWriter(w http.ResponseWriter) http.ResponseWriter {
+	for {
+		u, ok := w.(interface {
+			Unwrap() http.ResponseWriter
+		})
+		if !ok {
+			return w
+		}
+		w = u.Unwrap()
+	}
+}
+
+func (c *Context) sendEarlyHints(templates []string) {
+	if err := c.EarlyHints(templates...); err != nil {
+		log.Warningf("error sending early hints for %s: %s", c.R.URL.Path, err)
+	}
+}
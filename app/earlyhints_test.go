@@ -0,0 +1,91 @@
+package app_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"gnd.la/app"
+	"gnd.la/template/assets"
+
+	"gopkgs.com/vfs.v1"
+)
+
+// wrappedWriter records the status codes it receives, like
+// the wrappers used for caching responses do.
+type wrappedWriter struct {
+	http.ResponseWriter
+	codes []int
+}
+
+func (w *wrappedWriter) WriteHeader(code int) {
+	w.codes = append(w.codes, code)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *wrappedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestEarlyHints(t *testing.T) {
+	fs, err := vfs.Map(map[string]*vfs.File{
+		"page.html": &vfs.File{Data: []byte("{{/*\n  styles: style.css\n*/}}<html><head></head><body>page</body></html>")},
+		"style.css": &vfs.File{Data: []byte("body { color: red; }")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := app.New()
+	a.SetTemplatesFS(fs)
+	a.SetAssetsManager(assets.New(fs, "/assets/"))
+	a.HandleOptions("^/$", func(ctx *app.Context) {
+		ctx.MustExecute("page.html", nil)
+	}, &app.HandlerOptions{EarlyHints: []string{"page.html"}})
+	var wrapped *wrappedWriter
+	a.Handle("^/wrapped/$", func(ctx *app.Context) {
+		wrapped = &wrappedWriter{ResponseWriter: ctx.ResponseWriter}
+		ctx.ResponseWriter = wrapped
+		if err := ctx.EarlyHints("page.html"); err != nil {
+			t.Error(err)
+		}
+		ctx.MustExecute("page.html", nil)
+	})
+	srv := httptest.NewServer(a)
+	defer srv.Close()
+	for _, path := range []string{"/", "/wrapped/"} {
+		var hints []string
+		trace := &httptrace.ClientTrace{
+			Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+				if code == http.StatusEarlyHints {
+					hints = append(hints, header["Link"]...)
+				}
+				return nil
+			},
+		}
+		req, err := http.NewRequest("GET", srv.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: expecting status %d, got %d", path, http.StatusOK, resp.StatusCode)
+		}
+		if len(hints) != 1 || !strings.HasSuffix(hints[0], "; rel=preload; as=style") {
+			t.Errorf("%s: expecting a style preload in 103 response, got %v", path, hints)
+		}
+		if link := resp.Header.Get("Link"); link == "" {
+			t.Errorf("%s: expecting Link header in final response", path)
+		}
+	}
+	if wrapped == nil || len(wrapped.codes) != 1 || wrapped.codes[0] != http.StatusOK {
+		t.Errorf("expecting only status 200 written to the wrapper, got %+v", wrapped)
+	}
+}
@@ -58,6 +58,22 @@ func (i *InvalidParameterTypeError) Error() string {
 	return fmt.Sprintf("Required parameter %q must be of type %v", i.Name, i.Type)
 }
 
+// Indicates that the request body exceeds the maximum size
+// allowed by the handler (see HandlerOptions.MaxBodySize). It's
+// returned when reading the body past the limit. Its status code
+// is 413.
+type RequestEntityTooLargeError struct {
+	Limit int64
+}
+
+func (r *RequestEntityTooLargeError) StatusCode() int {
+	return http.StatusRequestEntityTooLarge
+}
+
+func (r *RequestEntityTooLargeError) Error() string {
+	return fmt.Sprintf("Request body exceeds the maximum size of %d bytes", r.Limit)
+}
+
 // NotFound panics with NotFoundError.
 func NotFound() {
 	KindNotFound("")
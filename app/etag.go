@@ -0,0 +1,119 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gnd.la/app/serialize"
+)
+
+// ETag returns a strong entity tag for the given data, suitable
+// for using as the value of the ETag header. It's computed from
+// the SHA-1 of the data, so it changes whenever the data does.
+func ETag(data []byte) string {
+	sum := sha1.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// VersionETag returns a strong entity tag derived from a version
+// number, like the ones stored in a column which is incremented
+// on every update. This allows checking If-Match headers without
+// serializing the resource.
+func VersionETag(version int64) string {
+	return `"v` + strconv.FormatInt(version, 10) + `"`
+}
+
+// etagMatches returns true iff the given If-Match or If-None-Match
+// header value matches etag. Weak tags only match when using weak
+// comparison, as mandated by RFC 7232.
+func etagMatches(header string, etag string, weak bool) bool {
+	if weak {
+		etag = strings.TrimPrefix(etag, "W/")
+	}
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimSpace(v)
+		if v == "*" {
+			return true
+		}
+		if strings.HasPrefix(v, "W/") {
+			if !weak {
+				continue
+			}
+			v = v[2:]
+		}
+		if v == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// NotModified sets the ETag header to the given entity tag and, if the
+// request is a GET or a HEAD with an If-None-Match header matching it,
+// replies with a 304 (Not Modified) and returns true. Handlers should
+// stop processing the request when it returns true. e.g.
+//
+//	etag := app.VersionETag(article.Version)
+//	if ctx.NotModified(etag) {
+//		return
+//	}
+//	ctx.WriteJSON(article)
+func (c *Context) NotModified(etag string) bool {
+	c.SetHeader("ETag", etag)
+	if c.R == nil || (c.R.Method != "GET" && c.R.Method != "HEAD") {
+		return false
+	}
+	if inm := c.GetHeader("If-None-Match"); inm != "" && etagMatches(inm, etag, true) {
+		c.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// WriteJSONETag works like WriteJSON, but it also sets a strong ETag
+// computed from the serialized data (see ETag) and replies with a
+// 304 (Not Modified) without any body when the client already has
+// the current representation (see NotModified).
+func (c *Context) WriteJSONETag(data interface{}) (int, error) {
+	var buf bytes.Buffer
+	if _, err := serialize.WriteJSON(&buf, data); err != nil {
+		return 0, err
+	}
+	if c.NotModified(ETag(buf.Bytes())) {
+		return 0, nil
+	}
+	return serialize.WriteJSON(c, buf.Bytes())
+}
+
+// CheckIfMatch implements optimistic concurrency control for requests
+// which modify a resource (e.g. PUT or PATCH), using the entity tag of
+// its current representation. If the request has no If-Match header,
+// it replies with a 428 (Precondition Required), while if the header
+// does not match etag it replies with a 412 (Precondition Failed). In
+// both cases, it returns false and the handler should stop processing
+// the request. e.g.
+//
+//	if !ctx.CheckIfMatch(app.VersionETag(article.Version)) {
+//		return
+//	}
+//	// Update the article, incrementing its version
+//
+// Note that the update should still be conditional on the version
+// (e.g. with orm.Eq("Version", article.Version)), since another request
+// might modify the resource after the check.
+func (c *Context) CheckIfMatch(etag string) bool {
+	im := c.GetHeader("If-Match")
+	if im == "" {
+		c.Error(http.StatusPreconditionRequired)
+		return false
+	}
+	if !etagMatches(im, etag, false) {
+		c.Error(http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,36 @@
+package app_test
+
+import (
+	"testing"
+
+	"gnd.la/app"
+	"gnd.la/app/tester"
+)
+
+func TestETag(t *testing.T) {
+	data := map[string]int{"version": 1}
+	a := app.New()
+	a.Handle("/resource", func(ctx *app.Context) {
+		switch ctx.R.Method {
+		case "GET":
+			ctx.WriteJSONETag(data)
+		case "PUT":
+			if !ctx.CheckIfMatch(app.VersionETag(int64(data["version"]))) {
+				return
+			}
+			data["version"]++
+			ctx.WriteString("updated")
+		}
+	})
+	tt := tester.New(t, a)
+	etag := app.ETag([]byte(`{"version":1}`))
+	tt.Get("/resource", nil).Expect(200).ExpectHeader("ETag", etag)
+	tt.Get("/resource", nil).AddHeader("If-None-Match", etag).Expect(304)
+	tt.Get("/resource", nil).AddHeader("If-None-Match", "W/"+etag).Expect(304)
+	tt.Get("/resource", nil).AddHeader("If-None-Match", `"other"`).Expect(200)
+	tt.Request("PUT", "/resource", nil).Expect(428)
+	tt.Request("PUT", "/resource", nil).AddHeader("If-Match", `"v2"`).Expect(412)
+	tt.Request("PUT", "/resource", nil).AddHeader("If-Match", "W/"+app.VersionETag(1)).Expect(412)
+	tt.Request("PUT", "/resource", nil).AddHeader("If-Match", app.VersionETag(1)).Expect("updated")
+	tt.Request("PUT", "/resource", nil).AddHeader("If-Match", app.VersionETag(1)).Expect(412)
+}
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"gnd.la/util/stringutil"
+)
+
+const (
+	// The name of the cookie used to store the random id which
+	// assigns variants to the client. See Context.Variant.
+	EXPERIMENTS_COOKIE_NAME = "experiments"
+
+	experimentsIdLength     = 16
+	experimentsContextKey   = "__experiments_exposed"
+	experimentsIdContextKey = "__experiments_id"
+)
+
+// Variant is one of the alternatives in an Experiment. Its
+// Weight is relative to the sum of the weights of all the
+// variants in the experiment.
+type Variant struct {
+	Name   string
+	Weight int
+}
+
+// Experiment represents an A/B test, which assigns one of its
+// variants to each client. Experiments are usually defined in
+// the Experiments field of the App Config, using the form:
+//
+//	experiments = new-header:control=1|bold=1, checkout:old=3|new=1
+//
+// Weights might be omitted, giving all the variants the
+// same weight.
+type Experiment struct {
+	Name     string
+	Variants []Variant
+}
+
+// Parse implements the gnd.la/form/input.Parser interface.
+func (e *Experiment) Parse(s string) error {
+	sep := strings.IndexByte(s, ':')
+	if sep < 0 {
+		return fmt.Errorf("invalid experiment %q, must be name:variant1|variant2...", s)
+	}
+	name := strings.TrimSpace(s[:sep])
+	if name == "" {
+		return fmt.Errorf("invalid experiment %q, empty name", s)
+	}
+	var variants []Variant
+	for _, v := range strings.Split(s[sep+1:], "|") {
+		variant := Variant{Name: strings.TrimSpace(v), Weight: 1}
+		if eq := strings.IndexByte(v, '='); eq >= 0 {
+			variant.Name = strings.TrimSpace(v[:eq])
+			w, err := strconv.Atoi(strings.TrimSpace(v[eq+1:]))
+			if err != nil || w < 0 {
+				return fmt.Errorf("invalid weight for variant %q in experiment %q", variant.Name, name)
+			}
+			variant.Weight = w
+		}
+		if variant.Name == "" {
+			return fmt.Errorf("empty variant name in experiment %q", name)
+		}
+		variants = append(variants, variant)
+	}
+	e.Name = name
+	e.Variants = variants
+	return nil
+}
+
+// assign returns the variant for the client with the given id. The
+// same id always gets the same variant, as long as the variants
+// and their weights don't change.
+func (e *Experiment) assign(id string) string {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+	if total == 0 {
+		return ""
+	}
+	h := fnv.New32a()
+	h.Write([]byte(e.Name))
+	h.Write([]byte{0})
+	h.Write([]byte(id))
+	n := int(h.Sum32() % uint32(total))
+	for _, v := range e.Variants {
+		if n < v.Weight {
+			return v.Name
+		}
+		n -= v.Weight
+	}
+	return ""
+}
+
+// ExposureFunc is called the first time a request retrieves its
+// variant for an experiment, so exposures can be recorded for later
+// analysis. The default one logs the exposure.
+type ExposureFunc func(ctx *Context, experiment string, variant string)
+
+func logExposure(ctx *Context, experiment string, variant string) {
+	ctx.Logger().Infof("experiment %s: client %s exposed to variant %s", experiment, ctx.experimentsId(), variant)
+}
+
+func (app *App) rootApp() *App {
+	for app.parent != nil {
+		app = app.parent
+	}
+	return app
+}
+
+// AddExperiment adds an experiment to the App, in addition to the
+// ones in its Config. Experiments are shared with any included apps.
+func (app *App) AddExperiment(e *Experiment) {
+	root := app.rootApp()
+	root.experimentsMutex.Lock()
+	root.cfg.Experiments = append(root.cfg.Experiments, *e)
+	root.experimentsMutex.Unlock()
+}
+
+// SetExposureFunc sets the function called when a request is exposed
+// to an experiment. Use nil to restore the default, which logs the
+// exposure.
+func (app *App) SetExposureFunc(f ExposureFunc) {
+	app.rootApp().exposureFunc = f
+}
+
+func (app *App) experiment(name string) *Experiment {
+	root := app.rootApp()
+	root.experimentsMutex.RLock()
+	defer root.experimentsMutex.RUnlock()
+	exps := root.cfg.Experiments
+	for ii := range exps {
+		if exps[ii].Name == name {
+			e := exps[ii]
+			return &e
+		}
+	}
+	return nil
+}
+
+// experimentsId returns the random id for the current client, setting
+// the cookie for new clients. The id is stored in the Context, so new
+// clients get the same id during the whole request.
+func (c *Context) experimentsId() string {
+	if id, _ := c.Get(experimentsIdContextKey).(string); id != "" {
+		return id
+	}
+	var id string
+	if err := c.Cookies().Get(EXPERIMENTS_COOKIE_NAME, &id); err != nil || id == "" {
+		id = stringutil.Random(experimentsIdLength)
+		if err := c.Cookies().Set(EXPERIMENTS_COOKIE_NAME, id); err != nil {
+			c.Logger().Errorf("error setting experiments cookie: %s", err)
+		}
+	}
+	c.Set(experimentsIdContextKey, id)
+	return id
+}
+
+// Variant returns the name of the variant assigned to the current
+// client for the given experiment, or the empty string if there's
+// no such experiment. Clients are identified with a random id stored
+// in a cookie, so they keep their variants across requests. Note that
+// changing the variants or weights in an experiment might reassign
+// existing clients.
+//
+// The first time a request retrieves its variant for an experiment,
+// the App ExposureFunc is called (see App.SetExposureFunc). Templates
+// can use the experiment function, which calls this method, e.g.
+//
+//	{{ if eq (experiment "new-header") "bold" }}
+//		<h1><strong>{{ .Title }}</strong></h1>
+//	{{ else }}
+//		<h1>{{ .Title }}</h1>
+//	{{ end }}
+func (c *Context) Variant(experiment string) string {
+	e := c.app.experiment(experiment)
+	if e == nil {
+		return ""
+	}
+	variant := e.assign(c.experimentsId())
+	exposed, _ := c.Get(experimentsContextKey).(map[string]bool)
+	if !exposed[experiment] {
+		if exposed == nil {
+			exposed = make(map[string]bool)
+			c.Set(experimentsContextKey, exposed)
+		}
+		exposed[experiment] = true
+		f := c.app.rootApp().exposureFunc
+		if f == nil {
+			f = logExposure
+		}
+		f(c, experiment, variant)
+	}
+	return variant
+}
+
+func template_experiment(ctx *Context, name string) string {
+	return ctx.Variant(name)
+}
@@ -0,0 +1,116 @@
+package app_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gnd.la/app"
+	"gnd.la/app/tester"
+
+	"gopkgs.com/vfs.v1"
+)
+
+func TestParseExperiment(t *testing.T) {
+	var e app.Experiment
+	if err := e.Parse("new-header: control=3 | bold=1"); err != nil {
+		t.Fatal(err)
+	}
+	if e.Name != "new-header" || len(e.Variants) != 2 ||
+		e.Variants[0] != (app.Variant{Name: "control", Weight: 3}) ||
+		e.Variants[1] != (app.Variant{Name: "bold", Weight: 1}) {
+		t.Errorf("unexpected experiment %+v", e)
+	}
+	if err := e.Parse("checkout:a|b"); err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Variants) != 2 || e.Variants[0].Weight != 1 || e.Variants[1].Weight != 1 {
+		t.Errorf("expecting equal weights, got %+v", e.Variants)
+	}
+	for _, v := range []string{"", "foo", ":a|b", "foo:a=x", "foo:a=-1", "foo:a||b"} {
+		if err := e.Parse(v); err == nil {
+			t.Errorf("expecting an error parsing %q", v)
+		}
+	}
+}
+
+func TestVariant(t *testing.T) {
+	var variant string
+	exposures := 0
+	a := app.New()
+	a.AddExperiment(&app.Experiment{
+		Name:     "color",
+		Variants: []app.Variant{{Name: "red", Weight: 1}, {Name: "blue", Weight: 1}},
+	})
+	a.AddExperiment(&app.Experiment{
+		Name:     "always",
+		Variants: []app.Variant{{Name: "off", Weight: 0}, {Name: "on", Weight: 1}},
+	})
+	a.SetExposureFunc(func(ctx *app.Context, experiment string, v string) {
+		exposures++
+	})
+	a.Handle("^/$", func(ctx *app.Context) {
+		variant = ctx.Variant("color")
+		if v := ctx.Variant("color"); v != variant {
+			t.Errorf("variant changed within the same request: %q != %q", v, variant)
+		}
+		if v := ctx.Variant("always"); v != "on" {
+			t.Errorf("expecting variant on, got %q", v)
+		}
+		if v := ctx.Variant("unknown"); v != "" {
+			t.Errorf("expecting no variant for unknown experiment, got %q", v)
+		}
+	})
+	get := func(cookies []*http.Cookie) *httptest.ResponseRecorder {
+		r, err := http.NewRequest("GET", "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range cookies {
+			r.AddCookie(v)
+		}
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, r)
+		return w
+	}
+	w := get(nil)
+	if exposures != 2 {
+		t.Errorf("expecting 2 exposures, got %d", exposures)
+	}
+	cookies := (&http.Response{Header: w.Header()}).Cookies()
+	if len(cookies) == 0 {
+		t.Fatal("no experiments cookie set")
+	}
+	first := variant
+	for ii := 0; ii < 10; ii++ {
+		get(cookies)
+		if variant != first {
+			t.Fatalf("variant not sticky: %q != %q", variant, first)
+		}
+	}
+	seen := make(map[string]bool)
+	for ii := 0; ii < 100; ii++ {
+		get(nil)
+		seen[variant] = true
+	}
+	if !seen["red"] || !seen["blue"] {
+		t.Errorf("expecting both variants to be assigned, got %v", seen)
+	}
+}
+
+func TestExperimentTemplate(t *testing.T) {
+	fs, err := vfs.Map(map[string]*vfs.File{
+		"experiment.html": &vfs.File{Data: []byte(`{{ experiment "always" }}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := app.New()
+	a.SetTemplatesFS(fs)
+	a.AddExperiment(&app.Experiment{
+		Name:     "always",
+		Variants: []app.Variant{{Name: "off", Weight: 0}, {Name: "on", Weight: 1}},
+	})
+	a.Handle("^/$", func(ctx *app.Context) { ctx.MustExecute("experiment.html", nil) })
+	tester.New(t, a).Get("/", nil).Expect("on")
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"gnd.la/i18n"
+)
+
+const (
+	// The name of the cookie used to store the pending flash
+	// messages. The cookie is signed using the gnd.la/app.App secret.
+	FLASH_COOKIE_NAME = "flash"
+)
+
+var (
+	// MaxFlashes is the maximum number of pending flash messages
+	// stored for a client. When more messages are added, the
+	// oldest ones are discarded, to keep the cookie small.
+	MaxFlashes = 10
+)
+
+// FlashLevel indicates the severity of a Flash. Its value
+// is suitable for using it as a CSS class.
+type FlashLevel string
+
+const (
+	FlashSuccess FlashLevel = "success"
+	FlashInfo    FlashLevel = "info"
+	FlashError   FlashLevel = "error"
+)
+
+// Flash is a one-time message stored for the current client
+// which is displayed in the next page it renders. Flash messages
+// are typically used for informing about the result of an action
+// which ends with a redirect (e.g. a form submission).
+type Flash struct {
+	Level   FlashLevel
+	Message string
+}
+
+func (c *Context) loadFlashes() []*Flash {
+	if !c.flashesLoaded {
+		c.flashesLoaded = true
+		if c.R != nil && c.Cookies().Has(FLASH_COOKIE_NAME) {
+			c.hasFlashCookie = true
+			if err := c.Cookies().GetSecure(FLASH_COOKIE_NAME, &c.flashes); err != nil {
+				c.flashes = nil
+			}
+		}
+	}
+	return c.flashes
+}
+
+// AddFlash adds a flash message with the given level for the current
+// client. The message is translated into the current language and
+// then formatted with the given arguments, like i18n.Sprintf does.
+// Flash messages are stored in a signed cookie until they're retrieved
+// with Flashes, so they survive redirects.
+func (c *Context) AddFlash(level FlashLevel, format string, args ...interface{}) {
+	flashes := append(c.loadFlashes(), &Flash{
+		Level:   level,
+		Message: i18n.Sprintf(c, format, args...),
+	})
+	if MaxFlashes > 0 && len(flashes) > MaxFlashes {
+		flashes = flashes[len(flashes)-MaxFlashes:]
+	}
+	c.flashes = flashes
+	if err := c.Cookies().SetSecure(FLASH_COOKIE_NAME, flashes); err != nil {
+		c.Logger().Errorf("error storing flash messages: %s", err)
+		return
+	}
+	c.hasFlashCookie = true
+}
+
+// FlashSuccess is a shorthand for AddFlash(FlashSuccess, format, args...).
+func (c *Context) FlashSuccess(format string, args ...interface{}) {
+	c.AddFlash(FlashSuccess, format, args...)
+}
+
+// FlashInfo is a shorthand for AddFlash(FlashInfo, format, args...).
+func (c *Context) FlashInfo(format string, args ...interface{}) {
+	c.AddFlash(FlashInfo, format, args...)
+}
+
+// FlashError is a shorthand for AddFlash(FlashError, format, args...).
+func (c *Context) FlashError(format string, args ...interface{}) {
+	c.AddFlash(FlashError, format, args...)
+}
+
+// Flashes returns the pending flash messages for the current client,
+// in the order they were added, and removes them, so every message
+// is returned only once. Templates can use the flashes function,
+// which calls this method, e.g.
+//
+//	{{ range flashes }}
+//		<div class="flash flash-{{ .Level }}">{{ .Message }}</div>
+//	{{ end }}
+func (c *Context) Flashes() []*Flash {
+	flashes := c.loadFlashes()
+	c.flashes = nil
+	if c.hasFlashCookie {
+		c.Cookies().Delete(FLASH_COOKIE_NAME)
+		c.hasFlashCookie = false
+	}
+	return flashes
+}
+
+func template_flashes(ctx *Context) []*Flash {
+	return ctx.Flashes()
+}
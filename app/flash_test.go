@@ -0,0 +1,54 @@
+package app_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gnd.la/app"
+)
+
+func TestFlash(t *testing.T) {
+	var flashes []*app.Flash
+	a := app.New()
+	a.Config().Secret = "0123456789abcdef0123456789abcdef"
+	a.Handle("^/add/$", func(ctx *app.Context) {
+		ctx.FlashSuccess("saved %d items", 3)
+		ctx.FlashError("something failed")
+		ctx.Redirect("/show/", false)
+	})
+	a.Handle("^/show/$", func(ctx *app.Context) {
+		flashes = ctx.Flashes()
+	})
+	get := func(path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		r, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range cookies {
+			r.AddCookie(v)
+		}
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, r)
+		return w
+	}
+	w := get("/add/", nil)
+	cookies := (&http.Response{Header: w.Header()}).Cookies()
+	if len(cookies) == 0 {
+		t.Fatal("no flash cookie set")
+	}
+	get("/show/", cookies)
+	if len(flashes) != 2 {
+		t.Fatalf("expecting 2 flashes, got %d", len(flashes))
+	}
+	if flashes[0].Level != app.FlashSuccess || flashes[0].Message != "saved 3 items" {
+		t.Errorf("unexpected first flash %+v", flashes[0])
+	}
+	if flashes[1].Level != app.FlashError || flashes[1].Message != "something failed" {
+		t.Errorf("unexpected second flash %+v", flashes[1])
+	}
+	get("/show/", nil)
+	if len(flashes) != 0 {
+		t.Errorf("expecting no flashes without cookie, got %d", len(flashes))
+	}
+}
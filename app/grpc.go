@@ -0,0 +1,66 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// HandleGRPC makes the App serve gRPC requests using the given
+// http.Handler, usually a *grpc.Server from google.golang.org/grpc
+// (which implements http.Handler), so gRPC services can be served
+// alongside the App handlers on the same port. Requests are
+// considered gRPC requests when they use HTTP/2 and their
+// Content-Type starts with application/grpc (see IsGRPC).
+//
+// gRPC requests are not subject to routing nor canonical URLs, but
+// ContextProcessors are run for them before the gRPC handler, so
+// processors which reject requests (e.g. IP filters) also apply to
+// gRPC. They get a *Context which is finalized and logged like the
+// rest of the requests and which can be retrieved from the gRPC
+// handlers and interceptors using FromContext, so they can share the
+// user and authentication facilities with the App handlers:
+//
+//	func authInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+//		if c := app.FromContext(ctx); c == nil || c.User() == nil {
+//			return nil, status.Error(codes.Unauthenticated, "not signed in")
+//		}
+//		return handler(ctx, req)
+//	}
+//
+// When a gRPC handler is set, the server started by ListenAndServe
+// also accepts HTTP/2 without TLS (h2c), since that's what most gRPC
+// clients use when TLS is terminated by a proxy. Pass nil to stop
+// serving gRPC requests.
+func (app *App) HandleGRPC(h http.Handler) {
+	app.grpc = h
+}
+
+// GRPCHandler returns the handler set with HandleGRPC, or nil.
+func (app *App) GRPCHandler() http.Handler {
+	return app.grpc
+}
+
+// IsGRPC returns true iff the given request is a gRPC request.
+func IsGRPC(r *http.Request) bool {
+	return r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// FromContext returns the *Context stored in the given context.Context,
+// or nil if there's none. The App stores the *Context in the context
+// of the gRPC requests it serves (see App.HandleGRPC).
+func FromContext(ctx context.Context) *Context {
+	c, _ := ctx.Value(contextKey{}).(*Context)
+	return c
+}
+
+func (app *App) serveGRPC(ctx *Context) {
+	r := ctx.R.WithContext(context.WithValue(ctx.R.Context(), contextKey{}, ctx))
+	ctx.R = r
+	// gRPC always uses 200 for the HTTP status, errors are
+	// sent in the trailers.
+	ctx.statusCode = http.StatusOK
+	app.grpc.ServeHTTP(ctx.ResponseWriter, r)
+}
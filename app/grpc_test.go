@@ -0,0 +1,70 @@
+package app_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gnd.la/app"
+)
+
+func TestGRPC(t *testing.T) {
+	a := app.New()
+	a.Handle("^/", func(ctx *app.Context) {
+		ctx.WriteString("http")
+	})
+	var fromContext *app.Context
+	a.HandleGRPC(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fromContext = app.FromContext(r.Context())
+		w.Write([]byte("grpc"))
+	}))
+	serve := func(protoMajor int, contentType string) string {
+		r, err := http.NewRequest("POST", "/helloworld.Greeter/SayHello", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.ProtoMajor = protoMajor
+		r.Header.Set("Content-Type", contentType)
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+	if s := serve(2, "application/grpc+proto"); s != "grpc" {
+		t.Errorf("expecting gRPC handler, got %q", s)
+	}
+	if fromContext == nil {
+		t.Error("FromContext() returned nil in gRPC handler")
+	}
+	if s := serve(2, "application/json"); s != "http" {
+		t.Errorf("expecting HTTP handler for non gRPC content type, got %q", s)
+	}
+	if s := serve(1, "application/grpc"); s != "http" {
+		t.Errorf("expecting HTTP handler for HTTP/1 request, got %q", s)
+	}
+}
+
+func TestGRPCProcessors(t *testing.T) {
+	a := app.New()
+	served := false
+	a.HandleGRPC(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served = true
+	}))
+	a.AddContextProcessor(func(ctx *app.Context) bool {
+		ctx.Forbidden()
+		return true
+	})
+	r, err := http.NewRequest("POST", "/helloworld.Greeter/SayHello", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.ProtoMajor = 2
+	r.Header.Set("Content-Type", "application/grpc")
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+	if served {
+		t.Error("gRPC handler called after a processor stopped the request")
+	}
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expecting status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
@@ -19,6 +19,20 @@ type HandlerOptions struct {
 	// Host specifies the host the Handler will match. If non-empty,
 	// only requests to this specific host will match the Handler.
 	Host string
+	// MaxBodySize indicates the maximum size in bytes for the body
+	// of the requests served by this Handler. Zero means using the
+	// default value from the App Config, while negative values
+	// disable the limit. Requests exceeding the limit receive a 413
+	// response (see RequestEntityTooLargeError).
+	MaxBodySize int64
+	// Cache sets the CachePolicy for the responses served by
+	// this Handler. See CachePolicy for details.
+	Cache *CachePolicy
+	// EarlyHints lists the templates rendered by this Handler.
+	// If non-empty, a 103 Early Hints response with Link headers
+	// for preloading their assets is sent before calling the
+	// Handler. See Context.EarlyHints.
+	EarlyHints []string
 }
 
 type HandlerInfo struct {
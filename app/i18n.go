@@ -6,6 +6,9 @@ import (
 )
 
 func (c *Context) Language() string {
+	if c.language != "" {
+		return c.language
+	}
 	if c.app.languageHandler != nil {
 		return c.app.languageHandler(c)
 	}
@@ -0,0 +1,290 @@
+// Package ipfilter implements allow and deny lists for client IP
+// addresses, optionally blocking requests by country.
+//
+// Filters run as an app.ContextProcessor, so they're evaluated before
+// the request is routed to any handler. e.g.
+//
+//	f := ipfilter.New()
+//	f.MustDeny("203.0.113.0/24")
+//	f.DenyCountries = []string{"XX"}
+//	// Country lookup requires a GeoIP database outside of App Engine
+//	geoip.MustLoad(a, "GeoLite2-Country.mmdb.gz")
+//	a.AddContextProcessor(f.Processor())
+//
+// The decision taken for each request, including its reason, can be
+// retrieved with Get (e.g. for logging it from an app.ContextFinalizer).
+//
+// Note that when running behind a proxy or a load balancer, the App
+// must trust the X headers (see app.App.SetTrustXHeaders) in order to
+// see the client address rather than the proxy one.
+package ipfilter
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"gnd.la/app"
+	"gnd.la/util/geoip"
+)
+
+const decisionKey = "__gondola_ipfilter_decision"
+
+// Reason indicates why a request was allowed or denied.
+type Reason string
+
+const (
+	// ReasonDefault indicates that no rule matched the request, so
+	// it was allowed.
+	ReasonDefault Reason = "default"
+	// ReasonIPAllowed indicates that the client IP is in the allow list.
+	ReasonIPAllowed Reason = "ip_allowed"
+	// ReasonIPDenied indicates that the client IP is in the deny list.
+	ReasonIPDenied Reason = "ip_denied"
+	// ReasonIPNotAllowed indicates that the filter has an allow list,
+	// but the client IP is not in it.
+	ReasonIPNotAllowed Reason = "ip_not_allowed"
+	// ReasonCountryAllowed indicates that the client country is in the
+	// allowed countries.
+	ReasonCountryAllowed Reason = "country_allowed"
+	// ReasonCountryDenied indicates that the client country is in the
+	// denied countries.
+	ReasonCountryDenied Reason = "country_denied"
+	// ReasonCountryNotAllowed indicates that the filter has allowed
+	// countries, but the client country is unknown or not in them.
+	ReasonCountryNotAllowed Reason = "country_not_allowed"
+	// ReasonInvalidIP indicates that the client IP could not be
+	// determined, so the request was denied.
+	ReasonInvalidIP Reason = "invalid_ip"
+)
+
+// Decision represents the result of evaluating a Filter
+// for a request.
+type Decision struct {
+	// Allowed is true iff the request was allowed.
+	Allowed bool
+	// Reason indicates which rule determined the decision.
+	Reason Reason
+	// IP is the client IP, nil if it couldn't be parsed.
+	IP net.IP
+	// Country is the client country as an ISO code, only
+	// set when the filter has country rules.
+	Country string
+}
+
+func (d *Decision) String() string {
+	verb := "denied"
+	if d.Allowed {
+		verb = "allowed"
+	}
+	return fmt.Sprintf("%s (%s)", verb, d.Reason)
+}
+
+// Filter allows or denies requests based on the client IP address
+// and its country. Rules are evaluated in the following order:
+//
+//   - IPs in the deny list are denied.
+//   - IPs in the allow list are allowed, regardless of their country.
+//   - Countries in DenyCountries are denied.
+//   - If AllowCountries is not empty, countries in it are allowed and
+//     any other countries, including unknown ones, are denied.
+//   - If the allow list is not empty, any other IPs are denied.
+//   - Otherwise, the request is allowed.
+//
+// Filters must be fully configured before they start serving requests,
+// since they're not safe for concurrent modification.
+type Filter struct {
+	// AllowCountries lists the ISO codes of the countries which
+	// are allowed (e.g. "ES"). See the Filter documentation.
+	AllowCountries []string
+	// DenyCountries lists the ISO codes of the countries which
+	// are denied.
+	DenyCountries []string
+	// Country returns the country for the request, as an ISO
+	// code. If nil, gnd.la/util/geoip.Country is used, which
+	// requires a loaded GeoIP database outside of App Engine.
+	Country func(*app.Context) string
+	// StatusCode is the status code sent to denied requests. If
+	// zero, http.StatusForbidden is used.
+	StatusCode int
+
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// New returns a new empty Filter, which allows all requests.
+func New() *Filter {
+	return &Filter{}
+}
+
+// Allow adds the given IP addresses or CIDR networks
+// (e.g. "10.0.0.0/8") to the allow list.
+func (f *Filter) Allow(addrs ...string) error {
+	nets, err := parseNets(addrs)
+	if err != nil {
+		return err
+	}
+	f.allow = append(f.allow, nets...)
+	return nil
+}
+
+// MustAllow works like Allow, but panics if there's an error.
+func (f *Filter) MustAllow(addrs ...string) {
+	if err := f.Allow(addrs...); err != nil {
+		panic(err)
+	}
+}
+
+// Deny adds the given IP addresses or CIDR networks
+// to the deny list.
+func (f *Filter) Deny(addrs ...string) error {
+	nets, err := parseNets(addrs)
+	if err != nil {
+		return err
+	}
+	f.deny = append(f.deny, nets...)
+	return nil
+}
+
+// MustDeny works like Deny, but panics if there's an error.
+func (f *Filter) MustDeny(addrs ...string) {
+	if err := f.Deny(addrs...); err != nil {
+		panic(err)
+	}
+}
+
+// Decide evaluates the Filter for the given request and returns its
+// Decision, which is also stored in the context (see Get).
+func (f *Filter) Decide(ctx *app.Context) *Decision {
+	ip := parseIP(ctx.RemoteAddress())
+	d := f.decide(ip, func() string {
+		if f.Country != nil {
+			return f.Country(ctx)
+		}
+		return geoip.Country(ctx)
+	})
+	ctx.Set(decisionKey, d)
+	return d
+}
+
+func (f *Filter) decide(ip net.IP, country func() string) *Decision {
+	d := &Decision{IP: ip}
+	if ip == nil {
+		d.Reason = ReasonInvalidIP
+		return d
+	}
+	if contains(f.deny, ip) {
+		d.Reason = ReasonIPDenied
+		return d
+	}
+	if contains(f.allow, ip) {
+		d.Allowed = true
+		d.Reason = ReasonIPAllowed
+		return d
+	}
+	if len(f.AllowCountries) > 0 || len(f.DenyCountries) > 0 {
+		d.Country = country()
+		if d.Country != "" && hasCountry(f.DenyCountries, d.Country) {
+			d.Reason = ReasonCountryDenied
+			return d
+		}
+		if len(f.AllowCountries) > 0 {
+			if d.Country != "" && hasCountry(f.AllowCountries, d.Country) {
+				d.Allowed = true
+				d.Reason = ReasonCountryAllowed
+			} else {
+				d.Reason = ReasonCountryNotAllowed
+			}
+			return d
+		}
+	}
+	if len(f.allow) > 0 {
+		d.Reason = ReasonIPNotAllowed
+		return d
+	}
+	d.Allowed = true
+	d.Reason = ReasonDefault
+	return d
+}
+
+// Processor returns an app.ContextProcessor which evaluates the
+// Filter for every request, replying to the denied ones with
+// its StatusCode.
+func (f *Filter) Processor() app.ContextProcessor {
+	return func(ctx *app.Context) bool {
+		d := f.Decide(ctx)
+		if d.Allowed {
+			return false
+		}
+		code := f.StatusCode
+		if code == 0 {
+			code = http.StatusForbidden
+		}
+		ctx.Logger().Infof("ipfilter: %s %s", d.IP, d)
+		ctx.Error(code)
+		return true
+	}
+}
+
+// Get returns the Decision taken by a Filter for the given
+// request, or nil if no Filter has been evaluated for it.
+func Get(ctx *app.Context) *Decision {
+	d, _ := ctx.Get(decisionKey).(*Decision)
+	return d
+}
+
+func parseNets(addrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(addrs))
+	for _, v := range addrs {
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", v)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+				bits = 8 * net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// parseIP parses the client address, which might contain a
+// list of addresses when it comes from X-Forwarded-For. In that
+// case, the last one is used, since it's the one added by the
+// proxy in front of the App. The rest are sent by the client
+// (or by other proxies) and can't be trusted.
+func parseIP(addr string) net.IP {
+	if pos := strings.LastIndexByte(addr, ','); pos >= 0 {
+		addr = addr[pos+1:]
+	}
+	return net.ParseIP(strings.TrimSpace(addr))
+}
+
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	for _, v := range nets {
+		if v.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasCountry(countries []string, country string) bool {
+	for _, v := range countries {
+		if strings.EqualFold(v, country) {
+			return true
+		}
+	}
+	return false
+}
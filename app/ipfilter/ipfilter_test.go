@@ -0,0 +1,55 @@
+package ipfilter
+
+import (
+	"net"
+	"testing"
+)
+
+func TestDecide(t *testing.T) {
+	f := New()
+	f.MustAllow("10.0.0.1", "192.168.0.0/16")
+	f.MustDeny("192.168.1.0/24", "2001:db8::/32")
+	f.DenyCountries = []string{"xx"}
+	countries := map[string]string{
+		"8.8.8.8": "XX",
+		"1.1.1.1": "ES",
+	}
+	cases := []struct {
+		ip      string
+		allowed bool
+		reason  Reason
+	}{
+		{"10.0.0.1", true, ReasonIPAllowed},
+		{"192.168.2.1", true, ReasonIPAllowed},
+		{"192.168.1.1", false, ReasonIPDenied},
+		{"2001:db8::1", false, ReasonIPDenied},
+		{"8.8.8.8", false, ReasonCountryDenied},
+		{"1.1.1.1", false, ReasonIPNotAllowed},
+		{"", false, ReasonInvalidIP},
+	}
+	for _, v := range cases {
+		ip := parseIP(v.ip)
+		d := f.decide(ip, func() string { return countries[v.ip] })
+		if d.Allowed != v.allowed || d.Reason != v.reason {
+			t.Errorf("expecting %v (%s) for %q, got %s", v.allowed, v.reason, v.ip, d)
+		}
+	}
+	f = New()
+	f.AllowCountries = []string{"ES"}
+	for ip, allowed := range map[string]bool{"1.1.1.1": true, "8.8.8.8": false, "9.9.9.9": false} {
+		d := f.decide(net.ParseIP(ip), func() string { return countries[ip] })
+		if d.Allowed != allowed {
+			t.Errorf("expecting allowed = %v for %s, got %s", allowed, ip, d)
+		}
+	}
+}
+
+func TestParseIP(t *testing.T) {
+	// The first addresses in X-Forwarded-For are set by the client
+	if ip := parseIP("10.0.0.1, 203.0.113.7"); !ip.Equal(net.ParseIP("203.0.113.7")) {
+		t.Errorf("expecting last IP in list, got %v", ip)
+	}
+	if err := New().Allow("300.0.0.1"); err == nil {
+		t.Error("expecting an error with an invalid IP")
+	}
+}
@@ -0,0 +1,290 @@
+package app
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguageAlternate is the value for LanguageAlternate.Language
+// used for the URL without a language prefix, which redirects
+// to the language negotiated with the client.
+const DefaultLanguageAlternate = "x-default"
+
+var languagePrefixExempt = []string{
+	"/robots.txt",
+	"/favicon.ico",
+	"/.well-known/",
+	assetsPrefix,
+	devStatusPage,
+	monitorPage,
+	monitorAPIPage,
+}
+
+// LanguagePrefixOptions enable routing localized URLs by
+// prefixing them with the language code (e.g. /en/about/ and
+// /es/about/). Handlers are registered and matched without the
+// prefix, which is stripped before routing and determines the
+// language for the request (see Context.Language). Requests to
+// URLs without a prefix are redirected to the language negotiated
+// from the Accept-Language header. See App.SetLanguagePrefix.
+type LanguagePrefixOptions struct {
+	// Languages lists the language codes which might be used as
+	// prefixes (e.g. "en", "es", "pt-br"). The first one is used
+	// when none of the languages accepted by the client is available.
+	Languages []string
+	// Exempt lists path prefixes (e.g. /api/) which are not
+	// localized. Requests to them are not redirected and
+	// reversed URLs for them don't include the language.
+	Exempt []string
+}
+
+// LanguageAlternate represents a localized version of a page,
+// intended for generating hreflang annotations.
+type LanguageAlternate struct {
+	// Language is the language code or DefaultLanguageAlternate.
+	Language string
+	// URL is the URL for the page in the given Language.
+	URL string
+}
+
+// LanguagePrefix returns the LanguagePrefixOptions for the App, or nil
+// if URLs are not prefixed with the language.
+func (app *App) LanguagePrefix() *LanguagePrefixOptions {
+	for app.parent != nil {
+		app = app.parent
+	}
+	return app.languagePrefix
+}
+
+// SetLanguagePrefix sets the LanguagePrefixOptions for the App. Passing nil
+// disables language prefixes, which is the default. Note that the options
+// apply to the App and all the apps included into it, so this function
+// should be called on the root App.
+func (app *App) SetLanguagePrefix(opts *LanguagePrefixOptions) {
+	app.languagePrefix = opts
+}
+
+// ReverseLanguage works like Reverse, but returns the URL for the given
+// language. If language prefixes are not enabled (see SetLanguagePrefix),
+// it's equivalent to Reverse.
+func (app *App) ReverseLanguage(lang string, name string, args ...interface{}) (string, error) {
+	return app.reverseLanguage(name, args, lang)
+}
+
+// LanguageAlternates returns the URLs for the handler with the given name
+// and arguments in all the available languages, followed by the URL without
+// a language, intended for generating sitemaps with hreflang annotations.
+// If language prefixes are not enabled (see SetLanguagePrefix), it
+// returns nil.
+func (app *App) LanguageAlternates(name string, args ...interface{}) ([]*LanguageAlternate, error) {
+	opts := app.LanguagePrefix()
+	if opts == nil {
+		return nil, nil
+	}
+	alternates := make([]*LanguageAlternate, 0, len(opts.Languages)+1)
+	for _, v := range opts.alternateLanguages() {
+		u, err := app.reverseLanguage(name, args, v)
+		if err != nil {
+			return nil, err
+		}
+		if v == "" {
+			v = DefaultLanguageAlternate
+		}
+		alternates = append(alternates, &LanguageAlternate{Language: v, URL: u})
+	}
+	return alternates, nil
+}
+
+// LanguageAlternates returns the absolute URLs for the current request
+// in all the available languages, followed by the URL without a language.
+// If language prefixes are not enabled or the request path is not
+// localized, it returns nil.
+func (c *Context) LanguageAlternates() []*LanguageAlternate {
+	opts := c.app.LanguagePrefix()
+	if opts == nil || c.R == nil {
+		return nil
+	}
+	p := c.app.mountedPath(c.R.URL.Path)
+	if c.language != "" {
+		p = strings.TrimPrefix(p, "/"+c.language)
+		if p == "" {
+			p = "/"
+		}
+	}
+	if !opts.isLocalized(p) {
+		return nil
+	}
+	u := c.URL()
+	u.RawPath = ""
+	u.Fragment = ""
+	alternates := make([]*LanguageAlternate, 0, len(opts.Languages)+1)
+	for _, v := range opts.alternateLanguages() {
+		u.Path = c.app.mount + languagePath(v, p)
+		lang := v
+		if lang == "" {
+			lang = DefaultLanguageAlternate
+		}
+		alternates = append(alternates, &LanguageAlternate{Language: lang, URL: u.String()})
+	}
+	return alternates
+}
+
+// routeLanguage strips the language prefix from the given path, setting
+// the language for the Context, or redirects the request to the negotiated
+// language when the path has no prefix. It returns the path to route and
+// true iff it redirected.
+func (app *App) routeLanguage(ctx *Context, p string) (string, bool) {
+	opts := app.languagePrefix
+	if lang, rem := opts.splitLanguage(p); lang != "" {
+		ctx.language = lang
+		return rem, false
+	}
+	if !opts.isLocalized(p) || len(opts.Languages) == 0 {
+		return p, false
+	}
+	lang := negotiateLanguage(ctx.R.Header.Get("Accept-Language"), opts.Languages)
+	u := *ctx.R.URL
+	u.Path = app.mount + languagePath(lang, p)
+	u.RawPath = ""
+	code := http.StatusFound
+	if ctx.R.Method != "GET" && ctx.R.Method != "HEAD" {
+		code = http.StatusTemporaryRedirect
+	}
+	ctx.Header().Add("Vary", "Accept-Language")
+	http.Redirect(ctx, ctx.R, u.RequestURI(), code)
+	return p, true
+}
+
+// splitLanguage returns the language prefix in p and the rest of the
+// path. If p has no language prefix, the returned language is empty.
+func (opts *LanguagePrefixOptions) splitLanguage(p string) (string, string) {
+	for _, v := range opts.Languages {
+		if rem := strings.TrimPrefix(p, "/"+v); rem != p {
+			if rem == "" {
+				return v, "/"
+			}
+			if rem[0] == '/' {
+				return v, rem
+			}
+		}
+	}
+	return "", p
+}
+
+// alternateLanguages returns the available languages followed by
+// the empty language, used for the URLs without a prefix.
+func (opts *LanguagePrefixOptions) alternateLanguages() []string {
+	return append(append([]string(nil), opts.Languages...), "")
+}
+
+func (opts *LanguagePrefixOptions) isLocalized(p string) bool {
+	for _, v := range opts.Exempt {
+		if strings.HasPrefix(p, v) {
+			return false
+		}
+	}
+	for _, v := range languagePrefixExempt {
+		if strings.HasPrefix(p, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func languagePath(lang string, p string) string {
+	if lang == "" {
+		return p
+	}
+	return "/" + lang + p
+}
+
+type acceptedLanguage struct {
+	tag string
+	q   float64
+}
+
+type acceptedLanguages []acceptedLanguage
+
+func (a acceptedLanguages) Len() int           { return len(a) }
+func (a acceptedLanguages) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a acceptedLanguages) Less(i, j int) bool { return a[i].q > a[j].q }
+
+func normalizeLanguage(lang string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(lang), "_", "-", -1))
+}
+
+func baseLanguage(lang string) string {
+	if p := strings.IndexByte(lang, '-'); p >= 0 {
+		return lang[:p]
+	}
+	return lang
+}
+
+// negotiateLanguage returns the language in available which best matches
+// the given Accept-Language header. Exact matches are preferred over
+// matches by the base language (e.g. es-ES matches es). If there are no
+// matches, the first available language is returned.
+func negotiateLanguage(header string, available []string) string {
+	var accepted acceptedLanguages
+	for _, v := range strings.Split(header, ",") {
+		tag := v
+		q := 1.0
+		if p := strings.IndexByte(v, ';'); p >= 0 {
+			tag = v[:p]
+			param := strings.TrimSpace(v[p+1:])
+			if strings.HasPrefix(param, "q=") {
+				if val, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = val
+				}
+			}
+		}
+		tag = normalizeLanguage(tag)
+		if tag == "" || tag == "*" || q <= 0 {
+			continue
+		}
+		accepted = append(accepted, acceptedLanguage{tag: tag, q: q})
+	}
+	sort.Stable(accepted)
+	for _, v := range accepted {
+		for _, lang := range available {
+			if normalizeLanguage(lang) == v.tag {
+				return lang
+			}
+		}
+		base := baseLanguage(v.tag)
+		for _, lang := range available {
+			if baseLanguage(normalizeLanguage(lang)) == base {
+				return lang
+			}
+		}
+	}
+	if len(available) > 0 {
+		return available[0]
+	}
+	return ""
+}
+
+func template_hreflang(ctx *Context) template.HTML {
+	var buf []byte
+	for _, v := range ctx.LanguageAlternates() {
+		buf = append(buf, `<link rel="alternate" hreflang="`...)
+		buf = append(buf, template.HTMLEscapeString(v.Language)...)
+		buf = append(buf, `" href="`...)
+		buf = append(buf, template.HTMLEscapeString(v.URL)...)
+		buf = append(buf, `">`...)
+	}
+	return template.HTML(buf)
+}
+
+// reverseContext is passed as the reverse template function when
+// language prefixes are enabled, so the reversed URLs include
+// the current language.
+func (t *Template) reverseContext(ctx *Context, name string, args ...interface{}) (string, error) {
+	if ctx == nil {
+		return t.app.reverse(name, args)
+	}
+	return ctx.Reverse(name, args...)
+}
@@ -0,0 +1,67 @@
+package app_test
+
+import (
+	"net/http"
+	"testing"
+
+	"gnd.la/app"
+	"gnd.la/app/tester"
+)
+
+func TestLanguagePrefix(t *testing.T) {
+	a := app.New()
+	a.SetLanguagePrefix(&app.LanguagePrefixOptions{
+		Languages: []string{"en", "es", "pt-br"},
+		Exempt:    []string{"/api/"},
+	})
+	a.HandleNamed("^/about/$", func(ctx *app.Context) {
+		ctx.WriteString(ctx.Language() + " " + ctx.MustReverse("about"))
+	}, "about")
+	a.HandleNamed("^/api/items$", func(ctx *app.Context) {
+		ctx.WriteString(ctx.Language() + " " + ctx.MustReverse("items"))
+	}, "items")
+	tt := tester.New(t, a)
+	tt.Get("/es/about/", nil).Expect("es /es/about/")
+	tt.Get("/pt-br/about/", nil).Expect("pt-br /pt-br/about/")
+	tt.Get("/about/", nil).AddHeader("Accept-Language", "fr;q=0.9, es-ES;q=0.8, en;q=0.5").
+		Expect(http.StatusFound).ExpectHeader("Location", "/es/about/").ExpectHeader("Vary", "Accept-Language")
+	tt.Get("/about/?q=1", nil).AddHeader("Accept-Language", "pt-BR").
+		Expect(http.StatusFound).ExpectHeader("Location", "/pt-br/about/?q=1")
+	tt.Get("/about/", nil).AddHeader("Accept-Language", "de").
+		Expect(http.StatusFound).ExpectHeader("Location", "/en/about/")
+	tt.Post("/about/", nil).Expect(http.StatusTemporaryRedirect)
+	tt.Get("/api/items", nil).Expect(" /api/items")
+	tt.Get("/de/about/", nil).Expect(http.StatusFound).ExpectHeader("Location", "/en/de/about/")
+	if u := a.MustReverse("about"); u != "/about/" {
+		t.Errorf("expecting App.Reverse to return /about/, got %q", u)
+	}
+	if u, err := a.ReverseLanguage("es", "about"); err != nil || u != "/es/about/" {
+		t.Errorf("expecting App.ReverseLanguage to return /es/about/, got %q (err %v)", u, err)
+	}
+}
+
+func TestLanguageAlternates(t *testing.T) {
+	a := app.New()
+	a.SetLanguagePrefix(&app.LanguagePrefixOptions{Languages: []string{"en", "es"}})
+	a.HandleNamed("^/about/$", func(ctx *app.Context) {
+		for _, v := range ctx.LanguageAlternates() {
+			ctx.WriteString(v.Language + "=" + v.URL + ";")
+		}
+	}, "about")
+	tt := tester.New(t, a)
+	tt.Get("/es/about/", nil).AddHeader("Host", "example.com").
+		Expect("en=http://example.com/en/about/;es=http://example.com/es/about/;x-default=http://example.com/about/;")
+	alternates, err := a.LanguageAlternates("about")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"en", "/en/about/", "es", "/es/about/", app.DefaultLanguageAlternate, "/about/"}
+	if len(alternates) != len(expect)/2 {
+		t.Fatalf("expecting %d alternates, got %d", len(expect)/2, len(alternates))
+	}
+	for ii, v := range alternates {
+		if v.Language != expect[ii*2] || v.URL != expect[ii*2+1] {
+			t.Errorf("expecting alternate %d = %s %s, got %s %s", ii, expect[ii*2], expect[ii*2+1], v.Language, v.URL)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"regexp"
+)
+
+const (
+	// LazyPrefix is the prefix for the URLs of the handlers
+	// registered by App.HandleLazy.
+	LazyPrefix = "/_lazy/"
+
+	lazyHandlerPrefix   = "gondola-lazy-"
+	lazyLoaderKey       = "__gondola_lazy_loader"
+	lazyPlaceholderHTML = `<div class="gondola-lazy" data-lazy-src="%s"></div>`
+	// lazyLoaderHTML fetches the lazy blocks once the DOM is
+	// ready and replaces their placeholders with them.
+	lazyLoaderHTML = `<script>(function(){function load(){` +
+		`var els=document.querySelectorAll("[data-lazy-src]");` +
+		`for(var i=0;i<els.length;i++){(function(el){` +
+		`var x=new XMLHttpRequest();x.open("GET",el.getAttribute("data-lazy-src"));` +
+		`el.removeAttribute("data-lazy-src");` +
+		`x.setRequestHeader("X-Requested-With","XMLHttpRequest");` +
+		`x.onload=function(){if(x.status==200){el.outerHTML=x.responseText}else{el.className+=" gondola-lazy-error"}};` +
+		`x.onerror=function(){el.className+=" gondola-lazy-error"};` +
+		`x.send()})(els[i])}}` +
+		`if(document.readyState=="loading"){document.addEventListener("DOMContentLoaded",load)}else{load()}})();</script>`
+)
+
+var (
+	lazyNameRe = regexp.MustCompile(`^[\w\-]+$`)
+)
+
+// LazyFunc returns the data used for rendering a lazy block. It
+// receives the arguments passed to the lazy template function as
+// form values.
+type LazyFunc func(ctx *Context) (interface{}, error)
+
+// HandleLazy registers a block which is rendered asynchronously, so slow
+// fragments of a page (e.g. recommendations or statistics) don't delay
+// the rest of it. name identifies the block and tmpl and block indicate
+// the template file and the block inside it which renders it, using the
+// data returned by f.
+//
+// Templates include lazy blocks using the lazy function, which receives the
+// name of the block followed by an optional list of key-value pairs, e.g.
+//
+//	{{ lazy "recommendations" "product" .Product.Id }}
+//
+// This renders an empty placeholder (a div with the gondola-lazy class,
+// which might be styled to show a loading indicator) and a small script
+// which requests the block once the page is loaded and then replaces the
+// placeholder with it. If the request fails, the gondola-lazy-error class
+// is added to the placeholder. The key-value pairs are sent as query
+// parameters, so f can retrieve them with Context.FormValue or
+// Context.Query.
+//
+// The handler for the block is registered at LazyPrefix followed by
+// its name.
+func (app *App) HandleLazy(name string, tmpl string, block string, f LazyFunc) {
+	if !lazyNameRe.MatchString(name) {
+		panic(fmt.Errorf("invalid lazy block name %q, must contain only letters, numbers, _ and -", name))
+	}
+	if f == nil {
+		panic(fmt.Errorf("LazyFunc for lazy block %q can't be nil", name))
+	}
+	app.lazyBlocks = append(app.lazyBlocks, block)
+	handler := func(ctx *Context) {
+		data, err := f(ctx)
+		if err != nil {
+			panic(err)
+		}
+		t, err := ctx.app.LoadTemplate(tmpl)
+		if err != nil {
+			panic(err)
+		}
+		if err := t.ExecuteBlock(ctx, block, data); err != nil {
+			panic(err)
+		}
+	}
+	app.HandleNamed("^"+LazyPrefix+regexp.QuoteMeta(name)+"/$", handler, lazyHandlerPrefix+name)
+}
+
+func template_lazy(ctx *Context, name string, args ...interface{}) (template.HTML, error) {
+	if ctx.renderingEmail {
+		return "", errors.New("lazy blocks can't be used in emails")
+	}
+	if len(args)%2 != 0 {
+		return "", errors.New("lazy requires an even number of arguments after the block name")
+	}
+	u, err := ctx.Reverse(lazyHandlerPrefix + name)
+	if err != nil {
+		return "", fmt.Errorf("no lazy block named %q, register it with App.HandleLazy: %s", name, err)
+	}
+	if len(args) > 0 {
+		values := make(url.Values)
+		for ii := 0; ii < len(args); ii += 2 {
+			values.Add(fmt.Sprint(args[ii]), fmt.Sprint(args[ii+1]))
+		}
+		u += "?" + values.Encode()
+	}
+	html := fmt.Sprintf(lazyPlaceholderHTML, template.HTMLEscapeString(u))
+	if loaded, _ := ctx.Get(lazyLoaderKey).(bool); !loaded {
+		ctx.Set(lazyLoaderKey, true)
+		html += lazyLoaderHTML
+	}
+	return template.HTML(html), nil
+}
@@ -0,0 +1,28 @@
+package app_test
+
+import (
+	"testing"
+
+	"gnd.la/app"
+	"gnd.la/app/tester"
+
+	"gopkgs.com/vfs.v1"
+)
+
+func TestLazy(t *testing.T) {
+	fs, err := vfs.Map(map[string]*vfs.File{
+		"page.html": &vfs.File{Data: []byte(`{{ lazy "stats" "id" 7 }}{{ define "stats" }}n={{ . }}{{ end }}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := app.New()
+	a.SetTemplatesFS(fs)
+	a.HandleLazy("stats", "page.html", "stats", func(ctx *app.Context) (interface{}, error) {
+		return ctx.FormValue("id"), nil
+	})
+	a.Handle("^/$", func(ctx *app.Context) { ctx.MustExecute("page.html", nil) })
+	tt := tester.New(t, a)
+	tt.Get("/", nil).Contains(`data-lazy-src="/_lazy/stats/?id=7"`).Contains("<script>")
+	tt.Get("/_lazy/stats/", map[string]interface{}{"id": 7}).Expect("n=7")
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// server returns the *http.Server used by ListenAndServe,
+// with the timeouts and limits from the App Config.
+func (app *App) server() *http.Server {
+	seconds := func(s int) time.Duration {
+		return time.Duration(s) * time.Second
+	}
+	srv := &http.Server{
+		Addr:              app.address + ":" + strconv.Itoa(app.cfg.Port),
+		Handler:           app,
+		ReadHeaderTimeout: seconds(app.cfg.HeaderTimeout),
+		ReadTimeout:       seconds(app.cfg.ReadTimeout),
+		WriteTimeout:      seconds(app.cfg.WriteTimeout),
+		IdleTimeout:       seconds(app.cfg.IdleTimeout),
+		MaxHeaderBytes:    app.cfg.MaxHeaderBytes,
+	}
+	if app.grpc != nil {
+		// Accept gRPC clients without TLS (h2c)
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetHTTP2(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+	return srv
+}
+
+// limitBody limits the request body to the given number of bytes
+// or, if limit is zero, to the default limit in the App Config. If
+// the request declares a body bigger than the limit, it replies with
+// a 413 and returns false, so the handler is never called. Otherwise,
+// reading past the limit returns a *RequestEntityTooLargeError.
+func (app *App) limitBody(ctx *Context, limit int64) bool {
+	if limit == 0 {
+		limit = app.cfg.MaxBodySize
+	}
+	if limit <= 0 || ctx.R == nil || ctx.R.Body == nil {
+		return true
+	}
+	if ctx.R.ContentLength > limit {
+		// Don't let the server keep reading the body
+		ctx.SetHeader("Connection", "close")
+		app.handleError(ctx, &RequestEntityTooLargeError{Limit: limit})
+		return false
+	}
+	ctx.R.Body = &limitedBody{ReadCloser: ctx.R.Body, limit: limit, remaining: limit}
+	return true
+}
+
+type limitedBody struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, &RequestEntityTooLargeError{Limit: b.limit}
+	}
+	// Read one more byte than allowed, to detect
+	// bodies exceeding the limit.
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = -1
+		return n, &RequestEntityTooLargeError{Limit: b.limit}
+	}
+	b.remaining -= int64(n)
+	return n, err
+}
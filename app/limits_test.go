@@ -0,0 +1,26 @@
+package app_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"gnd.la/app"
+	"gnd.la/app/tester"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	a := app.New()
+	echo := func(ctx *app.Context) {
+		data, err := ioutil.ReadAll(ctx.R.Body)
+		if err != nil {
+			panic(err)
+		}
+		ctx.Write(data)
+	}
+	a.HandleOptions("^/limited$", echo, &app.HandlerOptions{MaxBodySize: 4})
+	a.Handle("^/unlimited$", echo)
+	tt := tester.New(t, a)
+	tt.Post("/limited", "abcd").Expect(200).Expect("abcd")
+	tt.Post("/limited", "abcdef").Expect(413)
+	tt.Post("/unlimited", "abcdef").Expect("abcdef")
+}
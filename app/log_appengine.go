@@ -28,6 +28,9 @@ func (g *gaeLogger) Warningf(format string, args ...interface{}) { g.c.Warningf(
 func (g *gaeLogger) Error(args ...interface{})                 { g.c.Errorf("%s", fmt.Sprint(args...)) }
 func (g *gaeLogger) Errorf(format string, args ...interface{}) { g.c.Errorf(format, args...) }
 
+// contextLog is only used by the non-GAE loggers
+type contextLog struct{}
+
 func (c *Context) logger() log.Interface {
 	if c.R == nil {
 		return nullLogger{}
@@ -2,11 +2,52 @@
 
 package app
 
-import "gnd.la/log"
+import (
+	"net/http"
+
+	"gnd.la/log"
+)
+
+// contextLog caches the logger returned by Context.logger
+// for the request and handler it was created for.
+type contextLog struct {
+	r       *http.Request
+	handler string
+	logger  log.Interface
+}
 
 func (c *Context) logger() log.Interface {
 	if c.app.Logger == nil {
 		return nullLogger{}
 	}
-	return c.app.Logger
+	if c.R == nil || !c.app.Logger.HasContextWriters() {
+		// No need to build the fields, they would be ignored
+		return c.app.Logger
+	}
+	if c.contextLog.logger == nil || c.contextLog.r != c.R || c.contextLog.handler != c.handlerName {
+		// Let writers correlate the messages with the request
+		// (and its trace, if any)
+		c.contextLog = contextLog{
+			r:       c.R,
+			handler: c.handlerName,
+			logger:  c.app.Logger.WithContext(log.WithFields(c.R.Context(), c.logFields())),
+		}
+	}
+	return c.contextLog.logger
+}
+
+// logFields returns the fields passed to the log.ContextWriters
+// for the messages logged from this Context.
+func (c *Context) logFields() log.Fields {
+	fields := log.Fields{}
+	if name := c.app.Name(); name != "" {
+		fields[log.FieldModule] = name
+	}
+	if c.handlerName != "" {
+		fields["handler"] = c.handlerName
+	}
+	if id := c.R.Header.Get("X-Request-Id"); id != "" {
+		fields[log.FieldRequestId] = id
+	}
+	return fields
 }
@@ -0,0 +1,42 @@
+package app_test
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"gnd.la/app"
+	"gnd.la/log"
+)
+
+func TestContextLogger(t *testing.T) {
+	a := app.New()
+	a.SetName("logs")
+	a.Logger = log.New(log.NewIOWriter(ioutil.Discard, log.LDebug), 0, log.LDebug)
+	var same bool
+	handler := func(ctx *app.Context) {
+		logger := ctx.Logger()
+		same = logger == ctx.Logger()
+		logger.Error("logged")
+	}
+	a.HandleNamed("^/$", handler, "index")
+	a.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if !same {
+		t.Error("expecting the same logger without ContextWriters")
+	}
+	w := log.NewCaptureWriter(log.LWarning, 10)
+	a.Logger.AddWriter(w)
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Request-Id", "abc")
+	a.ServeHTTP(httptest.NewRecorder(), r)
+	if !same {
+		t.Error("expecting the logger to be reused within the same request")
+	}
+	records := w.Records(nil)
+	if len(records) != 1 {
+		t.Fatalf("expecting 1 record, got %d", len(records))
+	}
+	if rec := records[0]; rec.Module() != "logs" || rec.RequestId() != "abc" || rec.Fields["handler"] != "index" {
+		t.Errorf("unexpected record fields %v", rec.Fields)
+	}
+}
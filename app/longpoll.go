@@ -0,0 +1,71 @@
+package app
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrWaitTimeout is returned by Context.WaitFor when no
+	// notification is published before the timeout expires.
+	ErrWaitTimeout = errors.New("timeout waiting for notification")
+)
+
+// Notify publishes a notification with the given data on key, waking
+// up all the requests waiting for it with Context.WaitFor. Notifications
+// are delivered using the App cache, so they reach every process sharing
+// it when its driver supports notifications (e.g. redis). Otherwise,
+// they only reach the requests served by the current process. See
+// gnd.la/cache.Cache.Publish for more details.
+func (app *App) Notify(key string, data []byte) error {
+	c, err := app.Cache()
+	if err != nil {
+		return err
+	}
+	return c.Publish(key, data)
+}
+
+// WaitFor blocks until a notification is published on key (see
+// App.Notify) and returns its data. If no notification is received
+// before the timeout expires, ErrWaitTimeout is returned. If the client
+// disconnects while waiting, a *ClientGoneError is returned. This
+// allows writing simple long polling endpoints:
+//
+//	func EventsHandler(ctx *app.Context) {
+//		data, err := ctx.WaitFor("events", 30*time.Second)
+//		if err == app.ErrWaitTimeout {
+//			ctx.WriteHeader(http.StatusNoContent)
+//			return
+//		}
+//		if err != nil {
+//			panic(err)
+//		}
+//		ctx.Write(data)
+//	}
+//
+// A non-positive timeout waits until a notification is received
+// or the client disconnects.
+func (c *Context) WaitFor(key string, timeout time.Duration) ([]byte, error) {
+	sub, err := c.Cache().Subscribe(key)
+	if err != nil {
+		return nil, err
+	}
+	defer sub.Close()
+	var expired <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		expired = t.C
+	}
+	select {
+	case data, ok := <-sub.C:
+		if !ok {
+			return nil, errors.New("notification subscription closed")
+		}
+		return data, nil
+	case <-expired:
+		return nil, ErrWaitTimeout
+	case <-c.Done():
+		return nil, &ClientGoneError{Err: c.clientGoneErr()}
+	}
+}
@@ -0,0 +1,47 @@
+package app_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gnd.la/app"
+)
+
+func TestWaitFor(t *testing.T) {
+	a := app.New()
+	waiting := make(chan struct{})
+	a.Handle("^/wait/$", func(ctx *app.Context) {
+		close(waiting)
+		data, err := ctx.WaitFor("events", 5*time.Second)
+		if err != nil {
+			panic(err)
+		}
+		ctx.Write(data)
+	})
+	a.Handle("^/timeout/$", func(ctx *app.Context) {
+		if _, err := ctx.WaitFor("nothing", 10*time.Millisecond); err != app.ErrWaitTimeout {
+			t.Errorf("expecting ErrWaitTimeout, got %v", err)
+		}
+	})
+	go func() {
+		<-waiting
+		// Give WaitFor some time to subscribe
+		time.Sleep(50 * time.Millisecond)
+		if err := a.Notify("events", []byte("hello")); err != nil {
+			t.Error(err)
+		}
+	}()
+	for _, v := range []string{"/wait/", "/timeout/"} {
+		r, err := http.NewRequest("GET", v, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, r)
+		if v == "/wait/" && w.Body.String() != "hello" {
+			t.Errorf("expecting notification data %q, got %q", "hello", w.Body.String())
+		}
+	}
+}
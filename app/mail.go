@@ -13,6 +13,13 @@ import (
 // the TextBody field is used. Note that if template is empty, the msg is
 // passed unmodified to mail.Send(). Other Message fields are never altered.
 //
+// HTML templates are rendered using an email profile: helpers which
+// require Javascript (like lazy) return an error, and the result is
+// passed to gnd.la/net/mail.PrepareHTML, which removes scripts, inlines
+// the CSS and makes relative URLs absolute, using the mail BaseURL config
+// key or, if it's empty, the scheme and host of the current request. Any
+// email client incompatibilities found are logged as warnings.
+//
 // Note: mail.Send does not work on App Engine, users must always use this function instead.
 func (c *Context) SendMail(template string, data interface{}, msg *mail.Message) error {
 	if template != "" {
@@ -23,12 +30,23 @@ func (c *Context) SendMail(template string, data interface{}, msg *mail.Message)
 		if msg == nil {
 			msg = &mail.Message{}
 		}
+		isHTML := strings.Contains(t.tmpl.ContentType(), "/html")
 		var buf bytes.Buffer
-		if err := t.ExecuteTo(&buf, c, data); err != nil {
+		c.renderingEmail = isHTML
+		err = t.ExecuteTo(&buf, c, data)
+		c.renderingEmail = false
+		if err != nil {
 			return err
 		}
-		if strings.Contains(t.tmpl.ContentType(), "/html") {
-			msg.HTMLBody = buf.String()
+		if isHTML {
+			body, warnings, err := mail.PrepareHTML(buf.String(), &mail.HTMLOptions{BaseURL: c.mailBaseURL()})
+			if err != nil {
+				return err
+			}
+			for _, v := range warnings {
+				c.Logger().Warningf("email template %s: %s", template, v)
+			}
+			msg.HTMLBody = body
 		} else {
 			msg.TextBody = buf.String()
 		}
@@ -37,6 +55,17 @@ func (c *Context) SendMail(template string, data interface{}, msg *mail.Message)
 	return mail.Send(msg)
 }
 
+// mailBaseURL returns the base URL for HTML emails when the
+// mail BaseURL config key is empty.
+func (c *Context) mailBaseURL() string {
+	if mail.Config.BaseURL == "" {
+		if u := c.URL(); u != nil && u.Host != "" {
+			return u.Scheme + "://" + u.Host + "/"
+		}
+	}
+	return ""
+}
+
 // MustSendMail works like SendMail, but panics if there's an error.
 func (c *Context) MustSendMail(template string, data interface{}, msg *mail.Message) {
 	if err := c.SendMail(template, data, msg); err != nil {
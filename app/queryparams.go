@@ -0,0 +1,223 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryParams provides typed accessors for the query parameters of
+// the request. Accessors return the given default value when the
+// parameter is missing or empty and record an error when it can't be
+// parsed, so all the errors can be reported at once using Check:
+//
+//	q := ctx.Query()
+//	page := q.Int("page", 1)
+//	since := q.Time("since", time.RFC3339, time.Time{})
+//	sort := q.Enum("sort", "asc", "desc")
+//	q.Check()
+//
+// Use Context.Query to obtain the QueryParams for a request.
+type QueryParams struct {
+	values url.Values
+	errors []*ParameterError
+}
+
+// Query returns the QueryParams for the current request. Since
+// parse errors are accumulated, all the calls to Query during a
+// request return the same *QueryParams.
+func (c *Context) Query() *QueryParams {
+	if c.query == nil {
+		var values url.Values
+		if c.R != nil {
+			values = c.R.URL.Query()
+		}
+		c.query = &QueryParams{values: values}
+	}
+	return c.query
+}
+
+// Has returns true iff the parameter is present and non-empty.
+func (q *QueryParams) Has(name string) bool {
+	return q.value(name) != ""
+}
+
+// String returns the value of the parameter, or def if it's
+// missing or empty.
+func (q *QueryParams) String(name string, def string) string {
+	if v := q.value(name); v != "" {
+		return v
+	}
+	return def
+}
+
+// Int returns the value of the parameter as an int.
+func (q *QueryParams) Int(name string, def int) int {
+	v := q.value(name)
+	if v == "" {
+		return def
+	}
+	val, err := strconv.Atoi(v)
+	if err != nil {
+		q.invalid(name, v, "must be an integer")
+		return def
+	}
+	return val
+}
+
+// Int64 returns the value of the parameter as an int64.
+func (q *QueryParams) Int64(name string, def int64) int64 {
+	v := q.value(name)
+	if v == "" {
+		return def
+	}
+	val, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		q.invalid(name, v, "must be an integer")
+		return def
+	}
+	return val
+}
+
+// Float64 returns the value of the parameter as a float64.
+func (q *QueryParams) Float64(name string, def float64) float64 {
+	v := q.value(name)
+	if v == "" {
+		return def
+	}
+	val, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		q.invalid(name, v, "must be a number")
+		return def
+	}
+	return val
+}
+
+// Bool returns the value of the parameter as a bool. Besides the
+// values accepted by strconv.ParseBool, "yes", "no", "on" and "off"
+// are also accepted.
+func (q *QueryParams) Bool(name string, def bool) bool {
+	v := q.value(name)
+	if v == "" {
+		return def
+	}
+	switch strings.ToLower(v) {
+	case "yes", "on":
+		return true
+	case "no", "off":
+		return false
+	}
+	val, err := strconv.ParseBool(v)
+	if err != nil {
+		q.invalid(name, v, "must be a boolean")
+		return def
+	}
+	return val
+}
+
+// Time returns the value of the parameter parsed as a time.Time
+// using the given layout (see time.Parse).
+func (q *QueryParams) Time(name string, layout string, def time.Time) time.Time {
+	v := q.value(name)
+	if v == "" {
+		return def
+	}
+	val, err := time.Parse(layout, v)
+	if err != nil {
+		q.invalid(name, v, fmt.Sprintf("must be a time with the format %q", layout))
+		return def
+	}
+	return val
+}
+
+// Duration returns the value of the parameter parsed as a
+// time.Duration (see time.ParseDuration).
+func (q *QueryParams) Duration(name string, def time.Duration) time.Duration {
+	v := q.value(name)
+	if v == "" {
+		return def
+	}
+	val, err := time.ParseDuration(v)
+	if err != nil {
+		q.invalid(name, v, "must be a duration")
+		return def
+	}
+	return val
+}
+
+// Enum returns the value of the parameter, which must be one of the
+// allowed values. If the parameter is missing or empty, the first
+// allowed value is returned.
+func (q *QueryParams) Enum(name string, allowed ...string) string {
+	if len(allowed) == 0 {
+		panic(fmt.Errorf("no allowed values for parameter %q", name))
+	}
+	v := q.value(name)
+	if v == "" {
+		return allowed[0]
+	}
+	for _, a := range allowed {
+		if v == a {
+			return v
+		}
+	}
+	q.invalid(name, v, fmt.Sprintf("must be one of %s", strings.Join(allowed, ", ")))
+	return allowed[0]
+}
+
+// Err returns a *ParametersError with all the errors found while
+// parsing the parameters, or nil if there were no errors.
+func (q *QueryParams) Err() error {
+	if len(q.errors) == 0 {
+		return nil
+	}
+	return &ParametersError{Errors: q.errors}
+}
+
+// Check panics with the error returned by Err, if any, which makes
+// the App reply with a 400 listing all the invalid parameters.
+func (q *QueryParams) Check() {
+	if err := q.Err(); err != nil {
+		panic(err)
+	}
+}
+
+func (q *QueryParams) value(name string) string {
+	return strings.TrimSpace(q.values.Get(name))
+}
+
+func (q *QueryParams) invalid(name string, value string, reason string) {
+	q.errors = append(q.errors, &ParameterError{Name: name, Value: value, Reason: reason})
+}
+
+// ParameterError describes a parameter which couldn't be parsed.
+type ParameterError struct {
+	Name   string
+	Value  string
+	Reason string
+}
+
+func (p *ParameterError) Error() string {
+	return fmt.Sprintf("Invalid value %q for parameter %q: %s", p.Value, p.Name, p.Reason)
+}
+
+// ParametersError is returned by QueryParams.Err when any of the
+// parameters couldn't be parsed. Its status code is 400.
+type ParametersError struct {
+	Errors []*ParameterError
+}
+
+func (p *ParametersError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+func (p *ParametersError) Error() string {
+	msgs := make([]string, len(p.Errors))
+	for ii, v := range p.Errors {
+		msgs[ii] = v.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
@@ -0,0 +1,52 @@
+package app_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gnd.la/app"
+)
+
+func TestQueryParams(t *testing.T) {
+	var page int
+	var since time.Time
+	var sort string
+	var verbose bool
+	a := app.New()
+	a.Handle("^/$", func(ctx *app.Context) {
+		q := ctx.Query()
+		page = q.Int("page", 1)
+		since = q.Time("since", "2006-01-02", time.Time{})
+		sort = q.Enum("sort", "asc", "desc")
+		verbose = q.Bool("verbose", false)
+		q.Check()
+	})
+	get := func(query string) *httptest.ResponseRecorder {
+		r, err := http.NewRequest("GET", "/?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, r)
+		return w
+	}
+	if w := get(""); w.Code != http.StatusOK || page != 1 || !since.IsZero() || sort != "asc" || verbose {
+		t.Errorf("unexpected defaults: status %d, page %d, since %v, sort %q, verbose %v", w.Code, page, since, sort, verbose)
+	}
+	if w := get("page=3&since=2020-05-01&sort=desc&verbose=yes"); w.Code != http.StatusOK || page != 3 ||
+		!since.Equal(time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)) || sort != "desc" || !verbose {
+		t.Errorf("unexpected values: status %d, page %d, since %v, sort %q, verbose %v", w.Code, page, since, sort, verbose)
+	}
+	w := get("page=foo&since=yesterday&sort=up")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expecting status 400, got %d", w.Code)
+	}
+	for _, v := range []string{"page", "since", "sort"} {
+		if !strings.Contains(w.Body.String(), v) {
+			t.Errorf("expecting error for parameter %q in %q", v, w.Body.String())
+		}
+	}
+}
@@ -8,8 +8,9 @@ import (
 	"encoding/xml"
 	"io"
 	"net/http"
-	"runtime"
 	"strconv"
+
+	"gnd.la/internal/pool"
 )
 
 // Format indicates the format used
@@ -36,29 +37,12 @@ type JSONWriter interface {
 
 const bufSize = 4096
 
-var (
-	buffers = make(chan *bytes.Buffer, runtime.GOMAXPROCS(0))
-)
-
 func getBuffer() *bytes.Buffer {
-	var buf *bytes.Buffer
-	select {
-	case buf = <-buffers:
-		buf.Reset()
-	default:
-		buf = new(bytes.Buffer)
-		buf.Grow(bufSize)
-	}
-	return buf
+	return pool.Get(bufSize)
 }
 
 func putBuffer(buf *bytes.Buffer) {
-	if buf.Len() <= bufSize {
-		select {
-		case buffers <- buf:
-		default:
-		}
-	}
+	pool.Put(buf)
 }
 
 // Write serializes value using the Format f and writes it
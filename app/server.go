@@ -0,0 +1,190 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Server composes several independent Apps into a single process,
+// each one of them mounted at a different path prefix. Unlike included
+// apps (see App.Include), mounted Apps are completely isolated: each
+// one keeps its own Config, templates, assets, handler names and ORM,
+// so Apps developed separately can be served together without any
+// changes. They still share the process, so the infrastructure drivers
+// (database, cache, blobstore...) registered by imported packages are
+// available to all of them.
+//
+//	s := app.NewServer()
+//	s.Mount("/blog", blogApp)
+//	s.Mount("/", mainApp)
+//	s.MustListenAndServe(":8888")
+//
+// Requests are routed to the App with the longest prefix matching
+// the request path. Handlers in mounted Apps see their patterns
+// matched against the path without the prefix, while URLs returned
+// by App.Reverse and the assets URLs include it.
+type Server struct {
+	mu     sync.RWMutex
+	mounts []*mountedApp
+}
+
+type mountedApp struct {
+	prefix string
+	app    *App
+}
+
+// NewServer returns a new empty Server.
+func NewServer() *Server {
+	return &Server{}
+}
+
+// Mount mounts the given App at prefix. Mounting an App which has
+// been already mounted or included or reusing a prefix is considered
+// a programming error and will result in a panic.
+func (s *Server) Mount(prefix string, a *App) {
+	if err := s.mount(prefix, a); err != nil {
+		panic(err)
+	}
+}
+
+func (s *Server) mount(prefix string, a *App) error {
+	if a.parent != nil {
+		return fmt.Errorf("can't mount app %s, it has been already included", a.name)
+	}
+	if a.mount != "" {
+		return fmt.Errorf("app %s has been already mounted at %s", a.name, a.mount)
+	}
+	// Prefix must start with / and end without /, like
+	// the ones used for included apps. The root prefix
+	// is stored as an empty string.
+	if prefix == "" || prefix[0] != '/' {
+		prefix = "/" + prefix
+	}
+	prefix = strings.TrimRight(prefix, "/")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range s.mounts {
+		if v.prefix == prefix {
+			return fmt.Errorf("can't mount app at prefix %q, app %s is already using it", prefix+"/", v.app.name)
+		}
+		if v.app == a {
+			return fmt.Errorf("app %s is already mounted at %q", a.name, v.prefix+"/")
+		}
+	}
+	if prefix != "" {
+		a.mount = prefix
+		if m := a.assetsManager; m != nil && strings.HasPrefix(m.Prefix(), "/") {
+			// Assets handler has been already added using
+			// the old prefix, which is matched against the
+			// path without the mount prefix.
+			m.SetPrefix(prefix + m.Prefix())
+		}
+	}
+	s.mounts = append(s.mounts, &mountedApp{prefix: prefix, app: a})
+	return nil
+}
+
+// Apps returns the Apps mounted in the Server, in the
+// same order they were mounted.
+func (s *Server) Apps() []*App {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	apps := make([]*App, len(s.mounts))
+	for ii, v := range s.mounts {
+		apps[ii] = v.app
+	}
+	return apps
+}
+
+// Prepare prepares all the mounted Apps. See App.Prepare.
+func (s *Server) Prepare() error {
+	for _, v := range s.Apps() {
+		if err := v.Prepare(); err != nil {
+			return fmt.Errorf("error preparing app %s: %s", v.name, err)
+		}
+	}
+	return nil
+}
+
+// ListenAndServe prepares all the mounted Apps and starts listening
+// on the given address (e.g. :8888). The timeouts and limits for the
+// server are taken from the Config of the first mounted App.
+func (s *Server) ListenAndServe(addr string) error {
+	apps := s.Apps()
+	if len(apps) == 0 {
+		return fmt.Errorf("no apps mounted in server")
+	}
+	if err := s.Prepare(); err != nil {
+		return err
+	}
+	srv := apps[0].server()
+	srv.Addr = addr
+	srv.Handler = s
+	return srv.ListenAndServe()
+}
+
+// MustListenAndServe works like ListenAndServe, but panics
+// if there's an error.
+func (s *Server) MustListenAndServe(addr string) {
+	if err := s.ListenAndServe(addr); err != nil {
+		panic(err)
+	}
+}
+
+// ServeHTTP routes the request to the mounted App with the
+// longest prefix matching the request path.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if a := s.match(r.URL.Path); a != nil {
+		a.ServeHTTP(w, r)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (s *Server) match(p string) *App {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var best *mountedApp
+	for _, v := range s.mounts {
+		if v.prefix == "" || p == v.prefix || strings.HasPrefix(p, v.prefix+"/") {
+			if best == nil || len(v.prefix) > len(best.prefix) {
+				best = v
+			}
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return best.app
+}
+
+// Mount returns the prefix the App has been mounted at using
+// Server.Mount, or an empty string if the App hasn't been mounted
+// or it's mounted at the root.
+func (app *App) Mount() string {
+	return app.mount
+}
+
+// mountedPath returns the given request path without
+// the prefix the App has been mounted at.
+func (app *App) mountedPath(p string) string {
+	if app.mount == "" {
+		return p
+	}
+	p = strings.TrimPrefix(p, app.mount)
+	if p == "" {
+		return "/"
+	}
+	return p
+}
+
+// mountPrefix returns the prefix for the URLs generated by the
+// App, which is the prefix its root App has been mounted at.
+func (app *App) mountPrefix() string {
+	for app.parent != nil {
+		app = app.parent
+	}
+	return app.mount
+}
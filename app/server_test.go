@@ -0,0 +1,67 @@
+package app_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gnd.la/app"
+)
+
+func TestServerMount(t *testing.T) {
+	blog := app.New()
+	blog.HandleNamed("^/$", func(ctx *app.Context) {
+		ctx.WriteString("blog index")
+	}, "index")
+	blog.HandleNamed("^/post/(\\d+)/$", func(ctx *app.Context) {
+		ctx.WriteString(ctx.MustReverse("post", ctx.IndexValue(0)))
+	}, "post")
+	main := app.New()
+	main.HandleNamed("^/$", func(ctx *app.Context) {
+		ctx.WriteString("main index")
+	}, "index")
+	s := app.NewServer()
+	s.Mount("/blog/", blog)
+	s.Mount("/", main)
+	if blog.Mount() != "/blog" {
+		t.Errorf("expecting mount /blog, got %q", blog.Mount())
+	}
+	if rev := blog.MustReverse("index"); rev != "/blog/" {
+		t.Errorf("expecting /blog/ reversing index in blog, got %q", rev)
+	}
+	if rev := main.MustReverse("index"); rev != "/" {
+		t.Errorf("expecting / reversing index in main, got %q", rev)
+	}
+	cases := []struct {
+		path   string
+		code   int
+		expect string
+	}{
+		{"/", 200, "main index"},
+		{"/blog/", 200, "blog index"},
+		{"/blog/post/42/", 200, "/blog/post/42/"},
+		{"/blogroll/", 404, ""},
+		{"/post/42/", 404, ""},
+	}
+	for _, v := range cases {
+		r, err := http.NewRequest("GET", v.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		if w.Code != v.code {
+			t.Errorf("expecting code %d for %s, got %d", v.code, v.path, w.Code)
+			continue
+		}
+		if v.expect != "" && w.Body.String() != v.expect {
+			t.Errorf("expecting %q for %s, got %q", v.expect, v.path, w.Body.String())
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expecting a panic when mounting two apps at the same prefix")
+		}
+	}()
+	s.Mount("/blog", app.New())
+}
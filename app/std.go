@@ -14,23 +14,34 @@ import (
 // Methods that need to be redefined on appengine
 
 func (app *App) cache() (*cache.Cache, error) {
-	if app.c == nil {
-		var err error
-		app.locked(func() {
-			if app.c != nil {
-				return
-			}
+	// The cache might be initialized concurrently (e.g. by
+	// Notify and WaitFor), so app.c must be only read with
+	// the lock held.
+	var c *cache.Cache
+	var err error
+	app.locked(func() {
+		if app.c == nil {
 			if app.parent != nil {
 				app.c, err = app.parent.cache()
-				return
+			} else {
+				app.c, err = cache.New(app.cfg.Cache)
 			}
-			app.c, err = cache.New(app.cfg.Cache)
-		})
-		if err != nil {
-			return nil, err
 		}
+		c = app.c
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// queryCache returns the cache used by the ORM for caching
+// query results, or nil if the App has no cache configured.
+func (app *App) queryCache() (*cache.Cache, error) {
+	if app.parent != nil || app.cfg.Cache == nil {
+		return nil, nil
 	}
-	return app.c, nil
+	return app.cache()
 }
 
 func (app *App) orm() (*orm.Orm, error) {
@@ -75,12 +86,11 @@ func (app *App) checkPort() error {
 }
 
 func (c *Context) cache() *cache.Cache {
-	if c.app.c == nil {
-		if _, err := c.app.Cache(); err != nil {
-			panic(err)
-		}
+	cache, err := c.app.cache()
+	if err != nil {
+		panic(err)
 	}
-	return c.app.c
+	return cache
 }
 
 func (c *Context) orm() *orm.Orm {
@@ -20,11 +20,15 @@ var (
 	errNoLoadedTemplate   = errors.New("this template was not loaded from App.LoadTemplate nor NewTemplate")
 
 	templateFuncs = template.FuncMap{
-		"!t":   template_t,
-		"!tn":  template_tn,
-		"!tc":  template_tc,
-		"!tnc": template_tnc,
-		"app":  nop,
+		"!t":                                template_t,
+		"!tn":                               template_tn,
+		"!tc":                               template_tc,
+		"!tnc":                              template_tnc,
+		"!flashes":                          template_flashes,
+		"!experiment":                       template_experiment,
+		"!lazy":                             template_lazy,
+		"!hreflang":                         template_hreflang,
+		"app":                               nop,
 		templateutil.BeginTranslatableBlock: nop,
 		templateutil.EndTranslatableBlock:   nop,
 	}
@@ -96,18 +100,36 @@ func (t *Template) Execute(ctx *Context, data interface{}) error {
 // ExecuteTo works like Execute, but allows writing the template result
 // to an arbitraty io.Writer rather than the current *Context.
 func (t *Template) ExecuteTo(w io.Writer, ctx *Context, data interface{}) error {
+	tvars, err := t.vars(ctx)
+	if err != nil {
+		return err
+	}
+	return t.tmpl.ExecuteContext(w, data, ctx, tvars)
+}
+
+// ExecuteBlock works like Execute, but executes only the block (a template
+// defined with {{ define }} or {{ block }}) with the given name.
+func (t *Template) ExecuteBlock(ctx *Context, block string, data interface{}) error {
+	tvars, err := t.vars(ctx)
+	if err != nil {
+		return err
+	}
+	return t.tmpl.ExecuteTemplateContext(ctx, block, data, ctx, tvars)
+}
+
+func (t *Template) vars(ctx *Context) (map[string]interface{}, error) {
 	var tvars map[string]interface{}
-	var err error
 	if t.app.namespace != nil {
+		var err error
 		tvars, err = t.app.namespace.eval(ctx)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	} else {
 		tvars = make(map[string]interface{})
 	}
 	tvars["Ctx"] = ctx
-	return t.tmpl.ExecuteContext(w, data, ctx, tvars)
+	return tvars, nil
 }
 
 func template_t(ctx *Context, str string) string {
@@ -132,6 +154,9 @@ func newTemplate(app *App, fs vfs.VFS, manager *assets.Manager) *Template {
 		t.tmpl.Debug = app.cfg.TemplateDebug
 	}
 	t.tmpl.Funcs(templateFuncs).Funcs(template.FuncMap{"#reverse": t.reverse})
+	if app.LanguagePrefix() != nil {
+		t.tmpl.Funcs(template.FuncMap{"!reverse": t.reverseContext})
+	}
 	return t
 }
 
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"os"
+	"reflect"
+
+	"gnd.la/template"
+
+	"gopkgs.com/vfs.v1"
+)
+
+var (
+	lintTypes = map[string]reflect.Type{
+		"Ctx": reflect.TypeOf((*Context)(nil)),
+		"App": reflect.TypeOf((*App)(nil)),
+	}
+)
+
+// LintTemplates loads all the templates in the App and its included
+// apps and checks them for common mistakes, like references to undefined
+// variables or @Ctx fields, unused blocks or calls to undefined functions
+// (see gnd.la/template.Template.Lint for the full list). Templates which
+// fail to load are also reported as issues. The returned error is only
+// non-nil when the templates can't be listed.
+//
+// If the TemplateLint Config field is enabled, the App runs this function
+// when it starts and logs the issues found. Templates can also be checked
+// with the vet-templates command.
+func (app *App) LintTemplates() ([]*template.LintIssue, error) {
+	issues, err := app.lintTemplates()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range app.included {
+		childIssues, err := v.app.lintTemplates()
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, childIssues...)
+	}
+	return issues, nil
+}
+
+func (app *App) lintTemplates() ([]*template.LintIssue, error) {
+	var issues []*template.LintIssue
+	// Templates extending or including other templates
+	// contain their blocks, so issues might be repeated.
+	seen := make(map[string]bool)
+	opts := &template.LintOptions{Types: lintTypes, Blocks: app.lazyBlocks}
+	err := vfs.Walk(app.TemplatesFS(), "/", func(fs vfs.VFS, p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || p == "" || p[0] == '.' {
+			return err
+		}
+		tmpl, err := app.LoadTemplate(p)
+		if err != nil {
+			issues = append(issues, &template.LintIssue{Template: p, Message: err.Error()})
+			return nil
+		}
+		for _, v := range tmpl.tmpl.Lint(opts) {
+			if s := v.String(); !seen[s] {
+				seen[s] = true
+				issues = append(issues, v)
+			}
+		}
+		return nil
+	})
+	return issues, err
+}
+
+func (app *App) logTemplateIssues() {
+	if app.Logger == nil {
+		return
+	}
+	issues, err := app.LintTemplates()
+	if err != nil {
+		app.Logger.Errorf("error checking templates: %s", err)
+		return
+	}
+	for _, v := range issues {
+		app.Logger.Warningf("template issue: %s", v)
+	}
+}
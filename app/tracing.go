@@ -0,0 +1,130 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gnd.la/log"
+	"gnd.la/trace"
+)
+
+// Tracer returns the *trace.Tracer used by the App, or nil
+// if tracing is disabled.
+func (app *App) Tracer() *trace.Tracer {
+	return app.tracer
+}
+
+// SetTracer sets the *trace.Tracer used for starting a span for
+// each request. Passing nil disables tracing. Note that when
+// the Tracing field in the App Config is non-empty, a Tracer
+// which exports the spans using OTLP is automatically set up
+// when the App is prepared.
+func (app *App) SetTracer(t *trace.Tracer) {
+	app.tracer = t
+}
+
+func (app *App) prepareTracer() error {
+	if app.tracer != nil || app.cfg.Tracing == "" {
+		return nil
+	}
+	exporter, err := trace.NewOTLPExporter(app.cfg.Tracing)
+	if err != nil {
+		return err
+	}
+	service := app.name
+	if service == "" {
+		service = "gondola"
+	}
+	app.tracer = trace.NewTracer(service, exporter)
+	if app.cfg.TracingLogs && app.Logger != nil {
+		w, err := trace.NewOTLPLogWriter(service, tracingLogsEndpoint(app.cfg.Tracing), log.LInfo)
+		if err != nil {
+			return err
+		}
+		app.Logger.AddWriter(w)
+		app.tracingLogs = w
+	}
+	return nil
+}
+
+// tracingLogsEndpoint returns the endpoint for exporting the logs
+// to the same collector as the traces, even if the endpoint was
+// specified with the traces path.
+func tracingLogsEndpoint(endpoint string) string {
+	if strings.HasSuffix(endpoint, "/v1/traces") {
+		return strings.TrimSuffix(endpoint, "/v1/traces") + "/v1/logs"
+	}
+	return endpoint
+}
+
+// flushTracing exports the spans and log records which
+// haven't been sent to the collector yet.
+func (app *App) flushTracing() {
+	if app.tracer != nil {
+		if err := app.tracer.Flush(); err != nil && app.Logger != nil {
+			app.Logger.Errorf("error exporting spans: %s", err)
+		}
+	}
+	if app.tracingLogs != nil {
+		if err := app.tracingLogs.Flush(); err != nil && app.Logger != nil {
+			app.Logger.Errorf("error exporting log records: %s", err)
+		}
+	}
+}
+
+// Span returns the span for the current request, or nil if
+// tracing is disabled. Child spans can be started using
+// trace.Start with the request context (c.R.Context()).
+func (c *Context) Span() *trace.Span {
+	return c.span
+}
+
+// startRequestSpan starts the span for the request, continuing the
+// trace in its traceparent header, if any. The span is stored in the
+// request context, so operations started from it (e.g. ORM queries)
+// create child spans.
+func (app *App) startRequestSpan(ctx *Context) {
+	r := ctx.R
+	rctx, span := app.tracer.Start(r.Context(), r.Method, trace.KindServer, trace.Extract(r.Header))
+	span.SetAttribute("http.method", r.Method)
+	span.SetAttribute("http.target", r.URL.RequestURI())
+	span.SetAttribute("http.host", r.Host)
+	if ip := ctx.RemoteAddress(); ip != "" {
+		span.SetAttribute("net.peer.ip", ip)
+	}
+	ctx.R = r.WithContext(rctx)
+	ctx.span = span
+}
+
+func (app *App) endRequestSpan(ctx *Context) {
+	span := ctx.span
+	code := ctx.statusCode
+	if code < 0 {
+		code = -code
+	}
+	if code == 0 {
+		code = http.StatusOK
+	}
+	span.SetAttribute("http.status_code", code)
+	if code >= 500 {
+		span.SetError(errors.New(strconv.Itoa(code) + " " + http.StatusText(code)))
+	}
+	span.End()
+}
+
+// setSpanRoute names the span after the route template
+// of the matched handler, rather than using the path, so
+// requests to the same handler can be grouped together.
+func setSpanRoute(span *trace.Span, ctx *Context, info *handlerInfo) {
+	route := info.path
+	if route == "" && info.re != nil {
+		route = info.re.String()
+	}
+	span.SetName(ctx.R.Method + " " + route)
+	span.SetAttribute("http.route", route)
+	if info.name != "" {
+		span.SetAttribute("gondola.handler", info.name)
+	}
+}
@@ -0,0 +1,19 @@
+package app
+
+import (
+	"testing"
+)
+
+func TestTracingLogsEndpoint(t *testing.T) {
+	cases := map[string]string{
+		"http://localhost:4318":                "http://localhost:4318",
+		"http://localhost:4318/v1/traces":      "http://localhost:4318/v1/logs",
+		"https://example.com/otlp/v1/traces":   "https://example.com/otlp/v1/logs",
+		"https://example.com/otlp/v1/traces/x": "https://example.com/otlp/v1/traces/x",
+	}
+	for k, v := range cases {
+		if e := tracingLogsEndpoint(k); e != v {
+			t.Errorf("expecting logs endpoint %q for %q, got %q", v, k, e)
+		}
+	}
+}
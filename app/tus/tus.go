@@ -0,0 +1,524 @@
+// Package tus implements a handler for the tus resumable upload
+// protocol (see https://tus.io), storing the uploads in the blobstore.
+//
+// Uploads are created empty and then grown with Blobstore.Append as
+// the client sends its chunks, so clients can resume interrupted
+// uploads by asking the server for the current offset. e.g.
+//
+//	h := tus.New()
+//	h.MaxSize = 1 << 30 // 1GiB
+//	h.OnComplete = func(ctx *app.Context, u *tus.Upload) {
+//		// u.Id is the blobstore file id
+//	}
+//	a.Handle("^/uploads/(.*)$", h.Handle)
+//
+// Besides the core protocol, the handler supports the creation,
+// expiration, checksum and termination extensions. Uploads which
+// are not completed before they expire are removed when they're
+// accessed again or by calling Handler.RemoveExpired, e.g. from
+// a periodic task.
+package tus
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/blobstore"
+)
+
+const (
+	// Version is the version of the tus protocol
+	// implemented by this package.
+	Version = "1.0.0"
+	// DefaultExpiration is the expiration used for uploads when
+	// Handler.Expiration is zero.
+	DefaultExpiration = 24 * time.Hour
+
+	offsetContentType = "application/offset+octet-stream"
+	extensions        = "creation,expiration,checksum,termination"
+	// defined by the checksum extension
+	statusChecksumMismatch = 460
+)
+
+var (
+	checksums = map[string]func() hash.Hash{
+		"md5":    md5.New,
+		"sha1":   sha1.New,
+		"sha256": sha256.New,
+	}
+)
+
+// Upload represents an upload which has been completed.
+// See Handler.OnComplete.
+type Upload struct {
+	// Id is the id of the file in the blobstore.
+	Id string
+	// Length is the size of the upload in bytes.
+	Length int64
+	// Metadata contains the key/value pairs sent by the client
+	// in the Upload-Metadata header when creating the upload.
+	Metadata map[string]string
+}
+
+// meta is stored as the blobstore file metadata. Tus is always
+// true, which allows distinguishing uploads from other files.
+type meta struct {
+	Tus      bool
+	Length   int64
+	Metadata string
+	Expires  int64
+}
+
+func (m *meta) expires() time.Time {
+	return time.Unix(m.Expires, 0).UTC()
+}
+
+func (m *meta) expired(offset int64) bool {
+	return offset < m.Length && time.Now().Unix() >= m.Expires
+}
+
+// Handler implements the tus protocol. Use New to initialize a
+// Handler and Handler.Handle to add it to an App. The handler
+// must be added with a pattern which captures the upload id as
+// its first group, matching also the empty id (e.g. "^/uploads/(.*)$"),
+// since that's the endpoint used for creating uploads. Handlers
+// must be fully configured before they start serving requests.
+//
+// Concurrent requests writing to the same upload are rejected, but
+// this lock is only held in memory by each Handler. When running
+// several processes which share the same blobstore, requests for a
+// given upload must be always routed to the same process (e.g. by
+// hashing the upload URL in the load balancer).
+type Handler struct {
+	// MaxSize is the maximum upload size in bytes. If zero,
+	// the size of the uploads is not limited.
+	MaxSize int64
+	// Expiration is the time clients have to complete an upload
+	// after creating it. If zero, DefaultExpiration is used.
+	Expiration time.Duration
+	// Blobstore returns the blobstore used for storing the
+	// uploads. If nil, Context.Blobstore is used.
+	Blobstore func(*app.Context) *blobstore.Blobstore
+	// OnComplete is called, if non-nil, after the last chunk of
+	// an upload has been stored and before responding to the
+	// request which sent it.
+	OnComplete func(*app.Context, *Upload)
+
+	mu   sync.Mutex
+	busy map[string]bool
+}
+
+// New returns a new Handler with no size limit and the
+// default expiration.
+func New() *Handler {
+	return &Handler{}
+}
+
+// Handle is the app.Handler which implements the protocol.
+func (h *Handler) Handle(ctx *app.Context) {
+	ctx.Header().Set("Tus-Resumable", Version)
+	if ctx.R.Method == "OPTIONS" {
+		h.options(ctx)
+		return
+	}
+	if v := ctx.R.Header.Get("Tus-Resumable"); v != Version {
+		ctx.Header().Set("Tus-Version", Version)
+		h.error(ctx, http.StatusPreconditionFailed, "unsupported tus version %q", v)
+		return
+	}
+	id := ctx.IndexValue(0)
+	if id == "" {
+		if ctx.R.Method != "POST" {
+			h.error(ctx, http.StatusMethodNotAllowed, "method %s not allowed", ctx.R.Method)
+			return
+		}
+		h.create(ctx)
+		return
+	}
+	switch ctx.R.Method {
+	case "HEAD":
+		h.head(ctx, id)
+	case "PATCH":
+		h.patch(ctx, id)
+	case "DELETE":
+		h.delete(ctx, id)
+	default:
+		h.error(ctx, http.StatusMethodNotAllowed, "method %s not allowed", ctx.R.Method)
+	}
+}
+
+// RemoveExpired removes the uploads in the given blobstore which
+// have expired without being completed, returning the number of
+// removed uploads. If the blobstore driver does not support
+// iteration, blobstore.ErrNotIterable is returned.
+func (h *Handler) RemoveExpired(store *blobstore.Blobstore) (int, error) {
+	iter, err := store.Iter()
+	if err != nil {
+		return 0, err
+	}
+	var expired []string
+	var id string
+	for iter.Next(&id) {
+		if strings.HasSuffix(id, ".meta") {
+			continue
+		}
+		m, offset, err := h.stat(store, id)
+		if err != nil || m == nil {
+			continue
+		}
+		if m.expired(offset) {
+			expired = append(expired, id)
+		}
+	}
+	err = iter.Err()
+	iter.Close()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, v := range expired {
+		if h.lock(v) {
+			err := store.Remove(v)
+			h.unlock(v)
+			if err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (h *Handler) options(ctx *app.Context) {
+	header := ctx.Header()
+	header.Set("Tus-Version", Version)
+	header.Set("Tus-Extension", extensions)
+	if h.MaxSize > 0 {
+		header.Set("Tus-Max-Size", strconv.FormatInt(h.MaxSize, 10))
+	}
+	algorithms := make([]string, 0, len(checksums))
+	for k := range checksums {
+		algorithms = append(algorithms, k)
+	}
+	sort.Strings(algorithms)
+	header.Set("Tus-Checksum-Algorithm", strings.Join(algorithms, ","))
+	ctx.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) create(ctx *app.Context) {
+	length, err := strconv.ParseInt(ctx.R.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		h.error(ctx, http.StatusBadRequest, "invalid Upload-Length")
+		return
+	}
+	if h.MaxSize > 0 && length > h.MaxSize {
+		h.error(ctx, http.StatusRequestEntityTooLarge, "upload length %d exceeds maximum size %d", length, h.MaxSize)
+		return
+	}
+	metadata := ctx.R.Header.Get("Upload-Metadata")
+	if _, err := parseMetadata(metadata); err != nil {
+		h.error(ctx, http.StatusBadRequest, "invalid Upload-Metadata: %s", err)
+		return
+	}
+	expiration := h.Expiration
+	if expiration == 0 {
+		expiration = DefaultExpiration
+	}
+	m := &meta{
+		Tus:      true,
+		Length:   length,
+		Metadata: metadata,
+		Expires:  time.Now().Add(expiration).Unix(),
+	}
+	store := h.blobstore(ctx)
+	f, err := store.Create()
+	if err != nil {
+		panic(err)
+	}
+	if err := f.SetMeta(m); err != nil {
+		panic(err)
+	}
+	if err := f.Close(); err != nil {
+		panic(err)
+	}
+	if length == 0 {
+		h.complete(ctx, f.Id(), m)
+	}
+	header := ctx.Header()
+	header.Set("Location", strings.TrimSuffix(ctx.R.URL.Path, "/")+"/"+f.Id())
+	header.Set("Upload-Expires", m.expires().Format(http.TimeFormat))
+	ctx.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) head(ctx *app.Context, id string) {
+	store := h.blobstore(ctx)
+	m, offset := h.open(ctx, store, id)
+	if m == nil {
+		return
+	}
+	header := ctx.Header()
+	header.Set("Cache-Control", "no-store")
+	header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	header.Set("Upload-Length", strconv.FormatInt(m.Length, 10))
+	if m.Metadata != "" {
+		header.Set("Upload-Metadata", m.Metadata)
+	}
+	if offset < m.Length {
+		header.Set("Upload-Expires", m.expires().Format(http.TimeFormat))
+	}
+	ctx.WriteHeader(http.StatusOK)
+}
+
+func (h *Handler) patch(ctx *app.Context, id string) {
+	if ct := ctx.R.Header.Get("Content-Type"); ct != offsetContentType {
+		h.error(ctx, http.StatusUnsupportedMediaType, "invalid Content-Type %q, must be %s", ct, offsetContentType)
+		return
+	}
+	offset, err := strconv.ParseInt(ctx.R.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		h.error(ctx, http.StatusBadRequest, "invalid Upload-Offset")
+		return
+	}
+	var sum []byte
+	var sumHash hash.Hash
+	if checksum := ctx.R.Header.Get("Upload-Checksum"); checksum != "" {
+		if sumHash, sum, err = parseChecksum(checksum); err != nil {
+			h.error(ctx, http.StatusBadRequest, "invalid Upload-Checksum: %s", err)
+			return
+		}
+	}
+	if !h.lock(id) {
+		h.error(ctx, http.StatusLocked, "upload %s is being written by another request", id)
+		return
+	}
+	defer h.unlock(id)
+	store := h.blobstore(ctx)
+	m, current := h.open(ctx, store, id)
+	if m == nil {
+		return
+	}
+	if offset != current {
+		h.error(ctx, http.StatusConflict, "Upload-Offset %d does not match current offset %d", offset, current)
+		return
+	}
+	body := &bodyReader{r: ctx.R.Body}
+	var r io.Reader = io.LimitReader(body, m.Length-current)
+	if sumHash != nil {
+		// Data can't be appended until the checksum is
+		// verified, so it must be stored temporarily.
+		tmp, err := ioutil.TempFile("", "tus-chunk")
+		if err != nil {
+			panic(err)
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		if _, err := io.Copy(io.MultiWriter(tmp, sumHash), r); err != nil {
+			if body.err != nil {
+				// Client went away, nothing was appended so
+				// it might resume from the current offset.
+				return
+			}
+			panic(err)
+		}
+		if !bytes.Equal(sumHash.Sum(nil), sum) {
+			h.error(ctx, statusChecksumMismatch, "checksum mismatch")
+			return
+		}
+		if _, err := tmp.Seek(0, os.SEEK_SET); err != nil {
+			panic(err)
+		}
+		r = tmp
+	}
+	// Append keeps any data read before an error, so if the client
+	// went away it can resume the upload after checking the new
+	// offset. Errors writing to the blobstore are still reported.
+	n, err := store.Append(id, r)
+	if err != nil && body.err == nil {
+		panic(err)
+	}
+	current += n
+	// Empty PATCHes on a finished upload must not complete it again
+	if n > 0 && current == m.Length {
+		h.complete(ctx, id, m)
+	}
+	header := ctx.Header()
+	header.Set("Upload-Offset", strconv.FormatInt(current, 10))
+	if current < m.Length {
+		header.Set("Upload-Expires", m.expires().Format(http.TimeFormat))
+	}
+	ctx.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) delete(ctx *app.Context, id string) {
+	if !h.lock(id) {
+		h.error(ctx, http.StatusLocked, "upload %s is being written by another request", id)
+		return
+	}
+	defer h.unlock(id)
+	store := h.blobstore(ctx)
+	if m, _ := h.open(ctx, store, id); m == nil {
+		return
+	}
+	if err := store.Remove(id); err != nil {
+		panic(err)
+	}
+	ctx.WriteHeader(http.StatusNoContent)
+}
+
+// open returns the upload metadata and its current offset. If the
+// upload does not exist or has expired, it responds to the request
+// and returns a nil meta.
+func (h *Handler) open(ctx *app.Context, store *blobstore.Blobstore, id string) (*meta, int64) {
+	m, offset, err := h.stat(store, id)
+	if err != nil || m == nil {
+		h.error(ctx, http.StatusNotFound, "upload %s not found", id)
+		return nil, 0
+	}
+	if m.expired(offset) {
+		store.Remove(id)
+		h.error(ctx, http.StatusGone, "upload %s has expired", id)
+		return nil, 0
+	}
+	return m, offset
+}
+
+// stat returns the upload metadata and its current offset. If the file
+// exists but it's not an upload, it returns a nil meta.
+func (h *Handler) stat(store *blobstore.Blobstore, id string) (*meta, int64, error) {
+	f, err := store.Open(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	var m meta
+	if err := f.GetMeta(&m); err != nil {
+		return nil, 0, err
+	}
+	if !m.Tus {
+		return nil, 0, nil
+	}
+	size, err := f.Size()
+	if err != nil {
+		return nil, 0, err
+	}
+	return &m, int64(size), nil
+}
+
+func (h *Handler) complete(ctx *app.Context, id string, m *meta) {
+	if h.OnComplete != nil {
+		// Metadata was already validated when creating the upload
+		metadata, _ := parseMetadata(m.Metadata)
+		h.OnComplete(ctx, &Upload{Id: id, Length: m.Length, Metadata: metadata})
+	}
+}
+
+func (h *Handler) blobstore(ctx *app.Context) *blobstore.Blobstore {
+	if h.Blobstore != nil {
+		return h.Blobstore(ctx)
+	}
+	return ctx.Blobstore()
+}
+
+func (h *Handler) lock(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.busy[id] {
+		return false
+	}
+	if h.busy == nil {
+		h.busy = make(map[string]bool)
+	}
+	h.busy[id] = true
+	return true
+}
+
+func (h *Handler) unlock(id string) {
+	h.mu.Lock()
+	delete(h.busy, id)
+	h.mu.Unlock()
+}
+
+func (h *Handler) error(ctx *app.Context, code int, format string, args ...interface{}) {
+	ctx.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	ctx.WriteHeader(code)
+	if ctx.R.Method != "HEAD" {
+		fmt.Fprintf(ctx, format, args...)
+	}
+}
+
+// bodyReader wraps a request body, recording the errors
+// returned while reading it. This allows telling apart errors
+// caused by the client (e.g. disconnecting in the middle of a
+// chunk) from errors writing to the blobstore.
+type bodyReader struct {
+	r   io.Reader
+	err error
+}
+
+func (b *bodyReader) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err != nil && err != io.EOF {
+		b.err = err
+	}
+	return n, err
+}
+
+// parseMetadata parses the value of the Upload-Metadata header, which
+// contains comma separated pairs of keys and base64 encoded values,
+// separated by a space. Values might be omitted.
+func parseMetadata(s string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if s == "" {
+		return metadata, nil
+	}
+	for _, v := range strings.Split(s, ",") {
+		fields := strings.Fields(v)
+		switch len(fields) {
+		case 1:
+			metadata[fields[0]] = ""
+		case 2:
+			value, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for key %q: %s", fields[0], err)
+			}
+			metadata[fields[0]] = string(value)
+		default:
+			return nil, fmt.Errorf("invalid pair %q", v)
+		}
+	}
+	return metadata, nil
+}
+
+// parseChecksum parses the value of the Upload-Checksum header, which
+// contains the algorithm name and the base64 encoded checksum, separated
+// by a space.
+func parseChecksum(s string) (hash.Hash, []byte, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return nil, nil, fmt.Errorf("invalid checksum %q", s)
+	}
+	fn := checksums[fields[0]]
+	if fn == nil {
+		return nil, nil, fmt.Errorf("unsupported checksum algorithm %q", fields[0])
+	}
+	sum, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return nil, nil, err
+	}
+	return fn(), sum, nil
+}
@@ -0,0 +1,192 @@
+package tus
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/blobstore"
+	"gnd.la/config"
+)
+
+func newTestApp(t *testing.T) (*app.App, *Handler, *blobstore.Blobstore, func()) {
+	dir, err := ioutil.TempDir("", "tus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := blobstore.New(config.MustParseURL("file://" + dir))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	h := New()
+	h.Blobstore = func(_ *app.Context) *blobstore.Blobstore { return store }
+	a := app.New()
+	a.Handle("^/uploads/(.*)$", h.Handle)
+	return a, h, store, func() {
+		store.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func doRequest(t *testing.T, a *app.App, method string, path string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+	r, err := http.NewRequest(method, path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Tus-Resumable", Version)
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+	return w
+}
+
+func patchHeaders(offset int) map[string]string {
+	return map[string]string{
+		"Content-Type":  offsetContentType,
+		"Upload-Offset": strconv.Itoa(offset),
+	}
+}
+
+func TestUpload(t *testing.T) {
+	a, h, store, cleanup := newTestApp(t)
+	defer cleanup()
+	var completed *Upload
+	var completions int
+	h.OnComplete = func(_ *app.Context, u *Upload) {
+		completed = u
+		completions++
+	}
+	data := []byte("hello resumable world")
+	w := doRequest(t, a, "POST", "/uploads/", nil, map[string]string{
+		"Upload-Length":   strconv.Itoa(len(data)),
+		"Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte("hello.txt")),
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expecting status %d creating upload, got %d", http.StatusCreated, w.Code)
+	}
+	loc := w.Header().Get("Location")
+	w = doRequest(t, a, "PATCH", loc, data[:5], patchHeaders(0))
+	if w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != "5" {
+		t.Fatalf("expecting offset 5, got status %d and offset %q", w.Code, w.Header().Get("Upload-Offset"))
+	}
+	if w = doRequest(t, a, "PATCH", loc, data[5:], patchHeaders(3)); w.Code != http.StatusConflict {
+		t.Errorf("expecting status %d with wrong offset, got %d", http.StatusConflict, w.Code)
+	}
+	if w = doRequest(t, a, "HEAD", loc, nil, nil); w.Header().Get("Upload-Offset") != "5" {
+		t.Errorf("expecting HEAD offset 5, got %q", w.Header().Get("Upload-Offset"))
+	}
+	headers := patchHeaders(5)
+	headers["Upload-Checksum"] = "sha1 " + base64.StdEncoding.EncodeToString([]byte("invalid"))
+	if w = doRequest(t, a, "PATCH", loc, data[5:], headers); w.Code != statusChecksumMismatch {
+		t.Errorf("expecting status %d with checksum mismatch, got %d", statusChecksumMismatch, w.Code)
+	}
+	sum := sha1.Sum(data[5:])
+	headers["Upload-Checksum"] = "sha1 " + base64.StdEncoding.EncodeToString(sum[:])
+	if w = doRequest(t, a, "PATCH", loc, data[5:], headers); w.Code != http.StatusNoContent {
+		t.Fatalf("expecting status %d completing upload, got %d", http.StatusNoContent, w.Code)
+	}
+	if completed == nil {
+		t.Fatal("OnComplete was not called")
+	}
+	if completed.Metadata["filename"] != "hello.txt" {
+		t.Errorf("expecting filename metadata hello.txt, got %q", completed.Metadata["filename"])
+	}
+	stored, err := store.ReadAll(completed.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(stored, data) {
+		t.Errorf("expecting stored data %q, got %q", data, stored)
+	}
+	// Empty PATCHes after completing don't complete it again
+	if w = doRequest(t, a, "PATCH", loc, nil, patchHeaders(len(data))); w.Code != http.StatusNoContent {
+		t.Errorf("expecting status %d with empty PATCH, got %d", http.StatusNoContent, w.Code)
+	}
+	if completions != 1 {
+		t.Errorf("expecting OnComplete to be called once, got %d", completions)
+	}
+}
+
+type disconnectedReader struct {
+	data []byte
+}
+
+func (r *disconnectedReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestDisconnect(t *testing.T) {
+	a, _, _, cleanup := newTestApp(t)
+	defer cleanup()
+	w := doRequest(t, a, "POST", "/uploads/", nil, map[string]string{"Upload-Length": "10"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expecting status %d creating upload, got %d", http.StatusCreated, w.Code)
+	}
+	loc := w.Header().Get("Location")
+	r, err := http.NewRequest("PATCH", loc, &disconnectedReader{data: []byte("hello")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set("Tus-Resumable", Version)
+	for k, v := range patchHeaders(0) {
+		r.Header.Set(k, v)
+	}
+	w = httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+	if w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != "5" {
+		t.Errorf("expecting offset 5 after disconnecting, got status %d and offset %q", w.Code, w.Header().Get("Upload-Offset"))
+	}
+	if w = doRequest(t, a, "HEAD", loc, nil, nil); w.Header().Get("Upload-Offset") != "5" {
+		t.Errorf("expecting HEAD offset 5, got %q", w.Header().Get("Upload-Offset"))
+	}
+}
+
+func TestExpiration(t *testing.T) {
+	a, h, store, cleanup := newTestApp(t)
+	defer cleanup()
+	h.Expiration = -time.Second
+	w := doRequest(t, a, "POST", "/uploads/", nil, map[string]string{"Upload-Length": "10"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expecting status %d creating upload, got %d", http.StatusCreated, w.Code)
+	}
+	n, err := h.RemoveExpired(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expecting 1 expired upload, got %d", n)
+	}
+	if w = doRequest(t, a, "HEAD", w.Header().Get("Location"), nil, nil); w.Code != http.StatusNotFound {
+		t.Errorf("expecting status %d for removed upload, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestParseMetadata(t *testing.T) {
+	m, err := parseMetadata("name Zm9v,empty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["name"] != "foo" || m["empty"] != "" || len(m) != 2 {
+		t.Errorf("unexpected metadata %v", m)
+	}
+	if _, err := parseMetadata("name !!!"); err == nil {
+		t.Error("expecting an error with invalid base64")
+	}
+}
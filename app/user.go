@@ -43,6 +43,14 @@ func (c *Context) User() User {
 	return c.user
 }
 
+// SetUser sets the current user for this request, without setting
+// any cookies. It's intended to be used by authentication methods
+// which don't rely on cookies (e.g. API keys), where the user is
+// identified again in every request.
+func (c *Context) SetUser(user User) {
+	c.user = user
+}
+
 // SignIn sets the cookie for signin in the given user. The default
 // cookie options for the App are used.
 func (c *Context) SignIn(user User) error {
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// RobotsPattern is the pattern used by App.HandleRobots.
+	RobotsPattern = "^/robots\\.txt$"
+	// SecurityTxtPattern is the pattern used by App.HandleSecurityTxt.
+	SecurityTxtPattern = "^/\\.well-known/security\\.txt$"
+)
+
+// Robots contains the rules served in robots.txt by RobotsHandler.
+// Rules apply to all user agents.
+type Robots struct {
+	// Allow and Disallow contain the paths crawlers are
+	// allowed and not allowed to crawl, respectively.
+	Allow    []string
+	Disallow []string
+	// Sitemaps contains the URLs for the sitemaps. Relative
+	// URLs are made absolute using the request host.
+	Sitemaps []string
+}
+
+// RobotsHandler returns a Handler which serves robots.txt using the
+// given rules. When the App is not running in production (see
+// Config.Environment), all crawling is disallowed regardless of the
+// rules, so staging deployments don't get indexed by accident. If the
+// App has a template named robots.txt, it's executed with the Robots
+// as its data instead of generating the file from the rules.
+func RobotsHandler(r *Robots) Handler {
+	if r == nil {
+		r = &Robots{}
+	}
+	return func(ctx *Context) {
+		if !ctx.App().Config().Production() {
+			writeWellKnown(ctx, []byte("User-agent: *\nDisallow: /\n"))
+			return
+		}
+		if executeWellKnown(ctx, "robots.txt", r) {
+			return
+		}
+		var buf bytes.Buffer
+		buf.WriteString("User-agent: *\n")
+		for _, v := range r.Allow {
+			fmt.Fprintf(&buf, "Allow: %s\n", v)
+		}
+		for _, v := range r.Disallow {
+			fmt.Fprintf(&buf, "Disallow: %s\n", v)
+		}
+		if len(r.Allow) == 0 && len(r.Disallow) == 0 {
+			// Empty Disallow allows everything
+			buf.WriteString("Disallow:\n")
+		}
+		for _, v := range r.Sitemaps {
+			if u, err := ctx.URL().Parse(v); err == nil {
+				v = u.String()
+			}
+			fmt.Fprintf(&buf, "Sitemap: %s\n", v)
+		}
+		writeWellKnown(ctx, buf.Bytes())
+	}
+}
+
+// HandleRobots adds a handler for /robots.txt using RobotsHandler.
+// Note that HandleAssets also serves /robots.txt from the assets
+// directory, so this function must be called before HandleAssets
+// to take precedence.
+func (app *App) HandleRobots(r *Robots) {
+	app.Handle(RobotsPattern, RobotsHandler(r))
+}
+
+// SecurityTxt contains the fields served in security.txt by
+// SecurityTxtHandler. See RFC 9116 for their meaning.
+type SecurityTxt struct {
+	// Contact is required and contains the URIs (e.g.
+	// mailto:security@example.com) for reporting vulnerabilities.
+	Contact []string
+	// Expires is the date after which the data should be
+	// considered stale. If zero, it's set to one year after
+	// the handler is created.
+	Expires            time.Time
+	Encryption         []string
+	Acknowledgments    []string
+	PreferredLanguages []string
+	Canonical          []string
+	Policy             []string
+	Hiring             []string
+}
+
+// SecurityTxtHandler returns a Handler which serves security.txt
+// using the given fields. If the App has a template named security.txt,
+// it's executed with the SecurityTxt as its data instead. Passing a
+// SecurityTxt without any Contact is considered a programming error
+// and will result in a panic.
+func SecurityTxtHandler(s *SecurityTxt) Handler {
+	if s == nil || len(s.Contact) == 0 {
+		panic(fmt.Errorf("security.txt requires at least one contact"))
+	}
+	expires := s.Expires
+	if expires.IsZero() {
+		expires = time.Now().AddDate(1, 0, 0)
+	}
+	var buf bytes.Buffer
+	fields := []struct {
+		name   string
+		values []string
+	}{
+		{"Contact", s.Contact},
+		{"Expires", []string{expires.UTC().Format(time.RFC3339)}},
+		{"Encryption", s.Encryption},
+		{"Acknowledgments", s.Acknowledgments},
+		{"Canonical", s.Canonical},
+		{"Policy", s.Policy},
+		{"Hiring", s.Hiring},
+	}
+	for _, f := range fields {
+		for _, v := range f.values {
+			fmt.Fprintf(&buf, "%s: %s\n", f.name, v)
+		}
+	}
+	if len(s.PreferredLanguages) > 0 {
+		// Must appear at most once, with a comma separated list
+		fmt.Fprintf(&buf, "Preferred-Languages: %s\n", strings.Join(s.PreferredLanguages, ", "))
+	}
+	data := buf.Bytes()
+	return func(ctx *Context) {
+		if executeWellKnown(ctx, "security.txt", s) {
+			return
+		}
+		writeWellKnown(ctx, data)
+	}
+}
+
+// HandleSecurityTxt adds a handler for /.well-known/security.txt
+// using SecurityTxtHandler.
+func (app *App) HandleSecurityTxt(s *SecurityTxt) {
+	app.Handle(SecurityTxtPattern, SecurityTxtHandler(s))
+}
+
+// executeWellKnown executes the template with the given name if
+// the App has it, returning true iff the template was found.
+func executeWellKnown(ctx *Context, name string, data interface{}) bool {
+	fs := ctx.App().TemplatesFS()
+	if fs == nil {
+		return false
+	}
+	if _, err := fs.Stat(name); err != nil {
+		return false
+	}
+	ctx.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	ctx.MustExecute(name, data)
+	return true
+}
+
+func writeWellKnown(ctx *Context, data []byte) {
+	ctx.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	ctx.Write(data)
+}
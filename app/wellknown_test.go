@@ -0,0 +1,56 @@
+package app_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gnd.la/app"
+)
+
+func getWellKnown(t *testing.T, a *app.App, path string) string {
+	r, err := http.NewRequest("GET", "http://example.com"+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expecting status 200 for %s, got %d", path, w.Code)
+	}
+	return w.Body.String()
+}
+
+func TestRobots(t *testing.T) {
+	a := app.New()
+	a.HandleRobots(&app.Robots{
+		Disallow: []string{"/admin/"},
+		Sitemaps: []string{"/sitemap.xml"},
+	})
+	body := getWellKnown(t, a, "/robots.txt")
+	if !strings.Contains(body, "Disallow: /admin/\n") {
+		t.Errorf("expecting /admin/ to be disallowed, got %q", body)
+	}
+	if !strings.Contains(body, "Sitemap: http://example.com/sitemap.xml\n") {
+		t.Errorf("expecting absolute sitemap URL, got %q", body)
+	}
+	a.Config().Environment = "staging"
+	if body := getWellKnown(t, a, "/robots.txt"); body != "User-agent: *\nDisallow: /\n" {
+		t.Errorf("expecting everything to be disallowed on staging, got %q", body)
+	}
+}
+
+func TestSecurityTxt(t *testing.T) {
+	a := app.New()
+	a.HandleSecurityTxt(&app.SecurityTxt{
+		Contact:            []string{"mailto:security@example.com"},
+		PreferredLanguages: []string{"en", "es"},
+	})
+	body := getWellKnown(t, a, "/.well-known/security.txt")
+	for _, v := range []string{"Contact: mailto:security@example.com\n", "Expires: ", "Preferred-Languages: en, es\n"} {
+		if !strings.Contains(body, v) {
+			t.Errorf("expecting %q in security.txt, got %q", v, body)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package admin
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gnd.la/app"
+	"gnd.la/orm"
+	"gnd.la/util/stringutil"
+)
+
+// Action represents an operation performed on a model
+// from the admin. It's passed to permission functions,
+// so they can allow or deny it.
+type Action int
+
+const (
+	// View allows listing, searching and viewing objects.
+	View Action = iota + 1
+	// Create allows creating new objects.
+	Create
+	// Edit allows modifying existing objects.
+	Edit
+	// Delete allows deleting objects.
+	Delete
+)
+
+func (a Action) String() string {
+	switch a {
+	case View:
+		return "view"
+	case Create:
+		return "create"
+	case Edit:
+		return "edit"
+	case Delete:
+		return "delete"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// PermissionFunc is a function which returns if the given action
+// is allowed. obj is nil for the View and Create actions, and the
+// object being modified for Edit and Delete.
+type PermissionFunc func(ctx *app.Context, action Action, obj interface{}) bool
+
+var (
+	// DefaultPermission is used for models without a Permission
+	// function in their Options. By default, it only allows
+	// signed in users which are admins.
+	DefaultPermission PermissionFunc = isAdmin
+
+	// DefaultPerPage is the number of objects shown per page in
+	// the list view for models which don't specify it in their
+	// Options.
+	DefaultPerPage = 50
+
+	// MaxRelationChoices is the maximum number of objects in a
+	// referenced model for a relation to be edited using a
+	// select element. Relations pointing to models with more
+	// objects are edited using their primary key.
+	MaxRelationChoices = 100
+
+	registry struct {
+		sync.RWMutex
+		models []*Model
+	}
+)
+
+// Options specify the per model options for the admin. All
+// the fields are optional.
+type Options struct {
+	// Name is used to build the model URLs. If empty,
+	// the type name in lowercase is used.
+	Name string
+	// Label is the name displayed to the users. If empty,
+	// it's derived from the type name.
+	Label string
+	// ListFields indicates the fields displayed in the list
+	// view. If empty, all the fields with a type which can
+	// be displayed inline are used.
+	ListFields []string
+	// SearchFields indicates the string fields used when searching.
+	// If empty, searching is disabled for the model.
+	SearchFields []string
+	// Fields indicates the fields displayed in the create and edit
+	// forms. If empty, all the fields are included.
+	Fields []string
+	// ReadOnly lists fields which are displayed in the edit form
+	// but can't be modified. Auto increment primary keys are
+	// always read only.
+	ReadOnly []string
+	// Redacted lists fields (by their qualified name, e.g. User.Email)
+	// whose values are not stored in the audit log, in addition to
+	// the ones matching RedactedFields.
+	Redacted []string
+	// Sort indicates the field used to sort the list view. Prefix
+	// it with - to sort in descending order. If empty, objects
+	// are sorted by their primary key, in descending order.
+	Sort string
+	// PerPage indicates the number of objects displayed per page in
+	// the list view. If zero, DefaultPerPage is used.
+	PerPage int
+	// Permission overrides DefaultPermission for this model.
+	Permission PermissionFunc
+	// BeforeSave is called after the object has been populated from
+	// the submitted form and before it's saved. If it returns a non-nil
+	// error, the object is not saved and the error is shown to the user.
+	BeforeSave func(ctx *app.Context, obj interface{}, created bool) error
+	// AfterSave is called after the object has been saved.
+	AfterSave func(ctx *app.Context, obj interface{}, created bool)
+	// BeforeDelete is called before deleting an object. If it returns
+	// a non-nil error, the object is not deleted.
+	BeforeDelete func(ctx *app.Context, obj interface{}) error
+	// AfterDelete is called after the object has been deleted.
+	AfterDelete func(ctx *app.Context, obj interface{})
+}
+
+// Model represents a model registered with the admin.
+type Model struct {
+	typ     reflect.Type
+	name    string
+	label   string
+	options Options
+}
+
+// Name returns the model name, used to build its URLs.
+func (m *Model) Name() string {
+	return m.name
+}
+
+// Label returns the model name displayed to the users.
+func (m *Model) Label() string {
+	return m.label
+}
+
+// Type returns the model type.
+func (m *Model) Type() reflect.Type {
+	return m.typ
+}
+
+// Options returns the options the model was registered with.
+func (m *Model) Options() *Options {
+	return &m.options
+}
+
+// Table returns the ORM table for the model, or nil if the
+// type is not registered with the ORM.
+func (m *Model) Table(ctx *app.Context) *orm.Table {
+	return ctx.Orm().TypeTable(m.typ)
+}
+
+// Can returns true iff the given action is allowed for
+// the current user.
+func (m *Model) Can(ctx *app.Context, action Action, obj interface{}) bool {
+	perm := m.options.Permission
+	if perm == nil {
+		perm = DefaultPermission
+	}
+	return perm(ctx, action, obj)
+}
+
+func (m *Model) perPage() int {
+	if m.options.PerPage > 0 {
+		return m.options.PerPage
+	}
+	return DefaultPerPage
+}
+
+func (m *Model) sort() (string, orm.Sort) {
+	if s := m.options.Sort; s != "" {
+		if s[0] == '-' {
+			return s[1:], orm.DESC
+		}
+		return s, orm.ASC
+	}
+	return "", orm.DESC
+}
+
+// Register adds the given model to the admin. The model type
+// must also be registered with the ORM (see gnd.la/orm.Register).
+// Only models with a non-composite primary key are supported. As
+// with gnd.la/orm.Register, this function should be called from
+// an init() function.
+//
+//	func init() {
+//		orm.Register(&Article{}, nil)
+//		admin.Register(&Article{}, &admin.Options{
+//			ListFields:   []string{"Id", "Title", "Created"},
+//			SearchFields: []string{"Title"},
+//		})
+//	}
+func Register(t interface{}, opts *Options) *Model {
+	var typ reflect.Type
+	if tt, ok := t.(reflect.Type); ok {
+		typ = tt
+	} else {
+		typ = reflect.TypeOf(t)
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		panic(fmt.Errorf("admin can only register structs, not %s", typ))
+	}
+	m := &Model{typ: typ}
+	if opts != nil {
+		m.options = *opts
+	}
+	m.name = m.options.Name
+	if m.name == "" {
+		m.name = strings.ToLower(typ.Name())
+	}
+	m.label = m.options.Label
+	if m.label == "" {
+		m.label = stringutil.CamelCaseToWords(typ.Name(), " ")
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	for _, v := range registry.models {
+		if v.name == m.name {
+			panic(fmt.Errorf("duplicate admin model name %q (%s and %s)", m.name, v.typ, typ))
+		}
+		if v.typ == typ {
+			panic(fmt.Errorf("duplicate admin model type %s", typ))
+		}
+	}
+	registry.models = append(registry.models, m)
+	return m
+}
+
+// Models returns all the models registered with the admin,
+// in registration order.
+func Models() []*Model {
+	registry.RLock()
+	defer registry.RUnlock()
+	models := make([]*Model, len(registry.models))
+	copy(models, registry.models)
+	return models
+}
+
+// NamedModel returns the model registered with the given
+// name, or nil if there's no such model.
+func NamedModel(name string) *Model {
+	registry.RLock()
+	defer registry.RUnlock()
+	for _, v := range registry.models {
+		if v.name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// TypeModel returns the model registered with the given
+// type, or nil if there's no such model.
+func TypeModel(typ reflect.Type) *Model {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	registry.RLock()
+	defer registry.RUnlock()
+	for _, v := range registry.models {
+		if v.typ == typ {
+			return v
+		}
+	}
+	return nil
+}
+
+func isAdmin(ctx *app.Context, action Action, obj interface{}) bool {
+	user := ctx.User()
+	return user != nil && user.IsAdmin()
+}
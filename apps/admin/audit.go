@@ -0,0 +1,350 @@
+package admin
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/html/paginator"
+	"gnd.la/orm"
+	"gnd.la/orm/query"
+	"gnd.la/signal"
+	"gnd.la/tasks"
+)
+
+const (
+	AuditHandlerName = "admin-audit"
+
+	auditRetentionTaskName = "admin-audit-retention"
+
+	redactedValue = "[redacted]"
+)
+
+var (
+	// AuditRetention is the time audit events are kept for. Older
+	// events are deleted by a task which runs every AuditRetentionInterval.
+	// If zero, events are never deleted.
+	AuditRetention = 90 * 24 * time.Hour
+	// AuditRetentionInterval is the interval between runs of the task
+	// which deletes old audit events. It must be set before the app
+	// is prepared.
+	AuditRetentionInterval = 24 * time.Hour
+	// RedactedFields are the case insensitive substrings which make a
+	// field be redacted in the audit log. Changes to redacted fields
+	// are recorded, but their values are replaced with [redacted].
+	// Use Options.Redacted to redact other fields.
+	RedactedFields = []string{"password", "secret", "token", "hash"}
+
+	AuditHandler = app.NamedHandler(AuditHandlerName, app.SignedIn(auditHandler))
+
+	auditEventType     = reflect.TypeOf(AuditEvent{})
+	auditRetentionOnce sync.Once
+)
+
+// Change represents a field modified by an audited action.
+type Change struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// AuditEvent represents an audited action. Objects created, modified
+// or deleted from the admin are automatically audited and handlers might
+// record their own events using Audit. To enable auditing, AuditEvent must
+// be registered with the ORM e.g.
+//
+//	orm.Register(&admin.AuditEvent{}, nil)
+type AuditEvent struct {
+	Id   int64     `orm:",primary_key,auto_increment" json:"id"`
+	Time time.Time `orm:",index" json:"time"`
+	// ActorId is the id of the user who performed the action, or
+	// zero if there was no signed in user.
+	ActorId int64  `orm:",index" json:"actor"`
+	Address string `orm:",omitempty,nullempty" json:"address"`
+	Action  string `orm:",index" json:"action"`
+	// Object is the kind of the affected object. For events
+	// recorded by the admin, it's the model name.
+	Object   string `orm:",index" json:"object"`
+	ObjectId string `orm:",index" json:"object_id"`
+	// Message is an optional description of the event.
+	Message string    `orm:",omitempty,nullempty" json:"message"`
+	Changes []*Change `orm:",codec=json" json:"changes"`
+}
+
+func auditEventTable(o *orm.Orm) *orm.Table {
+	return o.TypeTable(auditEventType)
+}
+
+// Audit records the given event, setting its Time, ActorId and Address
+// fields from the current time, user and request when they're empty.
+// If AuditEvent is not registered with the ORM, Audit does nothing.
+func Audit(ctx *app.Context, event *AuditEvent) error {
+	o := ctx.Orm()
+	if auditEventTable(o) == nil {
+		return nil
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	if event.ActorId == 0 {
+		if user := ctx.User(); user != nil {
+			event.ActorId = user.Id()
+		}
+	}
+	if event.Address == "" && ctx.R != nil {
+		event.Address = ctx.RemoteAddress()
+	}
+	_, err := o.Insert(event)
+	return err
+}
+
+// Diff returns the changes between before and after, which must be
+// structs or pointers to structs of the same type. Either of them
+// might be nil, to represent a created or a deleted object. Only
+// exported fields are compared. Fields of embedded structs are
+// compared individually, using their qualified name (e.g. User.Email).
+func Diff(before interface{}, after interface{}) []*Change {
+	bv := reflect.Indirect(reflect.ValueOf(before))
+	av := reflect.Indirect(reflect.ValueOf(after))
+	var typ reflect.Type
+	switch {
+	case av.IsValid():
+		typ = av.Type()
+	case bv.IsValid():
+		typ = bv.Type()
+	default:
+		return nil
+	}
+	if typ.Kind() != reflect.Struct || (bv.IsValid() && av.IsValid() && bv.Type() != av.Type()) {
+		panic(fmt.Errorf("can't diff %T and %T, they must be structs of the same type", before, after))
+	}
+	var changes []*Change
+	diffStruct(&changes, "", typ, bv, av)
+	return changes
+}
+
+func diffStruct(changes *[]*Change, prefix string, typ reflect.Type, bv reflect.Value, av reflect.Value) {
+	for ii := 0; ii < typ.NumField(); ii++ {
+		field := typ.Field(ii)
+		if field.PkgPath != "" {
+			continue
+		}
+		var bf, af reflect.Value
+		if bv.IsValid() {
+			bf = bv.Field(ii)
+		}
+		if av.IsValid() {
+			af = av.Field(ii)
+		}
+		name := prefix + field.Name
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			diffStruct(changes, name+".", field.Type, bf, af)
+			continue
+		}
+		c := &Change{Field: name}
+		var bi, ai interface{}
+		if bf.IsValid() {
+			bi = bf.Interface()
+			c.Before = fmt.Sprint(bi)
+		}
+		if af.IsValid() {
+			ai = af.Interface()
+			c.After = fmt.Sprint(ai)
+		}
+		if bf.IsValid() && af.IsValid() && reflect.DeepEqual(bi, ai) {
+			continue
+		}
+		*changes = append(*changes, c)
+	}
+}
+
+// redact replaces the values of the redacted fields
+// in the given changes.
+func (m *Model) redact(changes []*Change) {
+	for _, v := range changes {
+		if m.isRedacted(v.Field) {
+			if v.Before != "" {
+				v.Before = redactedValue
+			}
+			if v.After != "" {
+				v.After = redactedValue
+			}
+		}
+	}
+}
+
+// isRedacted returns true iff the values of the given field
+// must not be stored in the audit log.
+func (m *Model) isRedacted(field string) bool {
+	for _, v := range m.options.Redacted {
+		if v == field {
+			return true
+		}
+	}
+	name := strings.ToLower(field[strings.LastIndexByte(field, '.')+1:])
+	for _, v := range RedactedFields {
+		if strings.Contains(name, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// auditObject records an action performed on an object from the
+// admin. Errors are logged rather than returned, so a failure to
+// audit doesn't prevent the action.
+func auditObject(ctx *app.Context, m *Model, action Action, id string, before interface{}, after interface{}) {
+	changes := Diff(before, after)
+	m.redact(changes)
+	event := &AuditEvent{
+		Action:   action.String(),
+		Object:   m.name,
+		ObjectId: id,
+		Changes:  changes,
+	}
+	if err := Audit(ctx, event); err != nil {
+		ctx.Logger().Errorf("error auditing %s of %s %s: %s", action, m.typ, id, err)
+	}
+}
+
+// PruneAuditEvents deletes the audit events older than the given time.
+func PruneAuditEvents(ctx *app.Context, before time.Time) error {
+	o := ctx.Orm()
+	tbl := auditEventTable(o)
+	if tbl == nil {
+		return nil
+	}
+	_, err := o.DeleteFrom(tbl, orm.Lt("Time", before.UTC()))
+	return err
+}
+
+func auditRetentionTask(ctx *app.Context) {
+	if AuditRetention <= 0 {
+		return
+	}
+	if err := PruneAuditEvents(ctx, time.Now().Add(-AuditRetention)); err != nil {
+		panic(err)
+	}
+}
+
+// auditPager generates the URLs for the audit view,
+// preserving the filters.
+type auditPager struct {
+	ctx    *app.Context
+	values url.Values
+}
+
+func (p *auditPager) URL(page int) string {
+	var u string
+	if page == 1 {
+		u = p.ctx.MustReverse(AuditHandlerName)
+	} else {
+		u = p.ctx.MustReverse(AuditHandlerName, page)
+	}
+	if len(p.values) > 0 {
+		u += "?" + p.values.Encode()
+	}
+	return u
+}
+
+func auditHandler(ctx *app.Context) {
+	if !DefaultPermission(ctx, View, nil) {
+		ctx.Forbidden()
+		return
+	}
+	o := ctx.Orm()
+	tbl := auditEventTable(o)
+	if tbl == nil {
+		ctx.NotFound("audit log is not enabled")
+		return
+	}
+	values := make(url.Values)
+	var conditions []query.Q
+	for _, v := range []string{"Action", "Object", "ObjectId"} {
+		param := strings.ToLower(v)
+		if value := strings.TrimSpace(ctx.FormValue(param)); value != "" {
+			conditions = append(conditions, orm.Eq(v, value))
+			values.Set(param, value)
+		}
+	}
+	if actor := strings.TrimSpace(ctx.FormValue("actor")); actor != "" {
+		id, err := strconv.ParseInt(actor, 10, 64)
+		if err != nil {
+			ctx.BadRequest("invalid actor")
+			return
+		}
+		conditions = append(conditions, orm.Eq("ActorId", id))
+		values.Set("actor", actor)
+	}
+	search := strings.TrimSpace(ctx.FormValue(searchParameterName))
+	if search != "" {
+		conditions = append(conditions, orm.Or(
+			orm.Contains("Message", search),
+			orm.Contains("Object", search),
+			orm.Contains("ObjectId", search),
+		))
+		values.Set(searchParameterName, search)
+	}
+	q := o.Table(tbl)
+	if len(conditions) > 0 {
+		q = q.Filter(orm.And(conditions...))
+	}
+	count, err := q.Count()
+	if err != nil {
+		panic(err)
+	}
+	perPage := DefaultPerPage
+	var page int
+	ctx.ParseParamValue("page", &page)
+	if page <= 0 {
+		page = 1
+	}
+	pages := (int(count) + perPage - 1) / perPage
+	if page > 1 && page > pages {
+		ctx.NotFound("page not found")
+		return
+	}
+	var events []*AuditEvent
+	if err := q.Sort("Time", orm.DESC).Limit(perPage).Offset((page - 1) * perPage).All(&events); err != nil {
+		panic(err)
+	}
+	data := map[string]interface{}{
+		"Events":    events,
+		"Count":     count,
+		"Search":    search,
+		"Action":    values.Get("action"),
+		"Object":    values.Get("object"),
+		"ObjectId":  values.Get("objectid"),
+		"Actor":     values.Get("actor"),
+		"Paginator": paginator.New(pages, page, &auditPager{ctx: ctx, values: values}),
+	}
+	ctx.MustExecute("audit.html", data)
+}
+
+// scheduleAuditRetention schedules the task which deletes old audit
+// events for the given app, if it has no parent and AuditEvent is
+// registered with its ORM.
+func scheduleAuditRetention(a *app.App) {
+	if a.Parent() != nil || AuditRetentionInterval <= 0 {
+		return
+	}
+	o, err := a.Orm()
+	if err != nil || auditEventTable(o) == nil {
+		return
+	}
+	auditRetentionOnce.Do(func() {
+		opts := &tasks.Options{Name: auditRetentionTaskName, MaxInstances: 1}
+		tasks.Schedule(a, auditRetentionTask, opts, AuditRetentionInterval, false)
+	})
+}
+
+func init() {
+	signal.Listen(app.DID_PREPARE, func(_ string, obj interface{}) {
+		scheduleAuditRetention(obj.(*app.App))
+	})
+}
@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"reflect"
+	"testing"
+)
+
+type AuditedAccount struct {
+	Username string
+	Password string
+}
+
+type auditedUser struct {
+	AuditedAccount
+	Id     int64
+	Emails []string
+	Notes  string
+}
+
+func TestDiff(t *testing.T) {
+	before := &auditedUser{AuditedAccount: AuditedAccount{"foo", "h1"}, Id: 1, Emails: []string{"a@example.com"}}
+	after := deepCopy(reflect.ValueOf(before)).Interface().(*auditedUser)
+	// Modify the slice in place, the copy must not change
+	after.Emails[0] = "b@example.com"
+	after.Password = "h2"
+	if before.Emails[0] != "a@example.com" {
+		t.Fatalf("deepCopy shares the Emails slice")
+	}
+	m := &Model{options: Options{Redacted: []string{"Notes"}}}
+	after.Notes = "private"
+	changes := Diff(before, after)
+	m.redact(changes)
+	expect := map[string]*Change{
+		"AuditedAccount.Password": {Before: redactedValue, After: redactedValue},
+		"Emails":                  {Before: "[a@example.com]", After: "[b@example.com]"},
+		"Notes":                   {Before: "", After: redactedValue},
+	}
+	if len(changes) != len(expect) {
+		t.Fatalf("expecting %d changes, got %d", len(expect), len(changes))
+	}
+	for _, v := range changes {
+		e := expect[v.Field]
+		if e == nil {
+			t.Errorf("unexpected change in field %s", v.Field)
+			continue
+		}
+		if v.Before != e.Before || v.After != e.After {
+			t.Errorf("expecting %s to change from %q to %q, got %q to %q", v.Field, e.Before, e.After, v.Before, v.After)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package admin
+
+import (
+	"gnd.la/app"
+	"gnd.la/internal/csrf"
+)
+
+const (
+	// CSRF_COOKIE_NAME is the name of the cookie used to store
+	// the CSRF token for the admin forms. The cookie is signed
+	// using the gnd.la/app.App secret.
+	CSRF_COOKIE_NAME = "admin-csrf"
+
+	csrfParameterName = "csrf"
+)
+
+// csrfToken returns the CSRF token for the current user,
+// generating a new one if it doesn't have one yet.
+func csrfToken(ctx *app.Context) string {
+	return csrf.Token(ctx, CSRF_COOKIE_NAME)
+}
+
+// checkCSRF returns true iff the submitted CSRF token matches
+// the one in the user cookie.
+func checkCSRF(ctx *app.Context) bool {
+	return csrf.Check(ctx, CSRF_COOKIE_NAME, ctx.FormValue(csrfParameterName))
+}
@@ -0,0 +1,366 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/log"
+	"gnd.la/tasks"
+)
+
+const (
+	DashboardEventsHandlerName = "admin-dashboard-events"
+)
+
+var (
+	// Version is the version of the application, displayed in the
+	// dashboard. It's usually set at build time e.g.
+	//
+	//	go build -ldflags "-X gnd.la/apps/admin.Version=1.2.3"
+	Version string
+	// BuildTime is the time the application was built, displayed
+	// in the dashboard. Like Version, it's usually set at build time.
+	BuildTime string
+
+	// DashboardRefreshInterval is the interval between the status
+	// updates sent to the dashboard.
+	DashboardRefreshInterval = 5 * time.Second
+	// BlobstoreUsageInterval is the minimum interval between blobstore
+	// usage calculations. Calculating the usage requires iterating over
+	// all the files, so it's done in the background and cached.
+	BlobstoreUsageInterval = 10 * time.Minute
+	// MaxRecentErrors is the maximum number of errors from the app
+	// logger kept for displaying in the dashboard.
+	MaxRecentErrors = 20
+
+	DashboardEventsHandler = app.NamedHandler(DashboardEventsHandlerName, app.SignedIn(dashboardEventsHandler))
+
+	started = time.Now()
+
+	blobstoreUsage struct {
+		sync.Mutex
+		status   *BlobstoreStatus
+		updating bool
+	}
+)
+
+// Status represents the status of the app subsystems, as
+// displayed in the dashboard.
+type Status struct {
+	Time      time.Time        `json:"time"`
+	Build     *BuildStatus     `json:"build"`
+	Runtime   *RuntimeStatus   `json:"runtime"`
+	Orm       *OrmStatus       `json:"orm,omitempty"`
+	Cache     *CacheStatus     `json:"cache,omitempty"`
+	Tasks     []*TaskStatus    `json:"tasks"`
+	Blobstore *BlobstoreStatus `json:"blobstore,omitempty"`
+	Errors    []*LogEntry      `json:"errors"`
+}
+
+// BuildStatus contains the build and version information.
+type BuildStatus struct {
+	Version   string `json:"version"`
+	BuildTime string `json:"build_time"`
+	Module    string `json:"module"`
+	Revision  string `json:"revision"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+	Hostname  string `json:"hostname"`
+}
+
+// RuntimeStatus contains information about the running process.
+type RuntimeStatus struct {
+	Started    time.Time `json:"started"`
+	Uptime     string    `json:"uptime"`
+	Goroutines int       `json:"goroutines"`
+	CPUs       int       `json:"cpus"`
+	Alloc      uint64    `json:"alloc"`
+	Sys        uint64    `json:"sys"`
+	NumGC      uint32    `json:"num_gc"`
+}
+
+// OrmStatus contains the connection pool stats for the ORM. It's
+// only available when the ORM is using a database/sql driver.
+type OrmStatus struct {
+	Backend           string `json:"backend"`
+	MaxOpen           int    `json:"max_open"`
+	Open              int    `json:"open"`
+	InUse             int    `json:"in_use"`
+	Idle              int    `json:"idle"`
+	WaitCount         int64  `json:"wait_count"`
+	WaitDuration      string `json:"wait_duration"`
+	MaxIdleClosed     int64  `json:"max_idle_closed"`
+	MaxLifetimeClosed int64  `json:"max_lifetime_closed"`
+	MaxIdleTimeClosed int64  `json:"max_idle_time_closed"`
+}
+
+// CacheStatus contains the cache operation counters.
+type CacheStatus struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Sets    uint64 `json:"sets"`
+	Deletes uint64 `json:"deletes"`
+	Errors  uint64 `json:"errors"`
+	// HitRatio is expressed as a percentage.
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// TaskStatus contains the status of a registered task.
+type TaskStatus struct {
+	Name     string `json:"name"`
+	Interval string `json:"interval"`
+	Running  int    `json:"running"`
+	Queued   int    `json:"queued"`
+}
+
+// BlobstoreStatus contains the blobstore usage. Since it's expensive to
+// calculate, it's updated in the background every BlobstoreUsageInterval.
+type BlobstoreStatus struct {
+	Files   int          `json:"files"`
+	Size    uint64       `json:"size"`
+	Dedup   *DedupStatus `json:"dedup,omitempty"`
+	Error   string       `json:"error,omitempty"`
+	Updated time.Time    `json:"updated"`
+}
+
+// DedupStatus contains the deduplication statistics for the blobstore.
+// It's only available when the blobstore driver deduplicates data.
+type DedupStatus struct {
+	LogicalBytes  uint64  `json:"logical_bytes"`
+	PhysicalBytes uint64  `json:"physical_bytes"`
+	SavedBytes    uint64  `json:"saved_bytes"`
+	Chunks        int     `json:"chunks"`
+	References    int     `json:"references"`
+	Ratio         float64 `json:"ratio"`
+	// Reuse is the histogram of references per chunk.
+	Reuse []*ReuseBucket `json:"reuse"`
+}
+
+// ReuseBucket is a bucket in the chunk reuse histogram.
+type ReuseBucket struct {
+	// References is the range of references per chunk
+	// in this bucket (e.g. 4-7).
+	References string `json:"references"`
+	Chunks     int    `json:"chunks"`
+}
+
+func reuseBuckets(reuse []int) []*ReuseBucket {
+	buckets := make([]*ReuseBucket, len(reuse))
+	for ii, v := range reuse {
+		refs := strconv.Itoa(ii)
+		if ii > 1 {
+			refs = fmt.Sprintf("%d-%d", 1<<uint(ii-1), 1<<uint(ii)-1)
+		}
+		buckets[ii] = &ReuseBucket{References: refs, Chunks: v}
+	}
+	return buckets
+}
+
+func buildStatus() *BuildStatus {
+	s := &BuildStatus{
+		Version:   Version,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	s.Hostname, _ = os.Hostname()
+	if info, ok := debug.ReadBuildInfo(); ok {
+		s.Module = info.Main.Path
+		if s.Version == "" && info.Main.Version != "(devel)" {
+			s.Version = info.Main.Version
+		}
+		for _, v := range info.Settings {
+			switch v.Key {
+			case "vcs.revision":
+				s.Revision = v.Value
+			case "vcs.time":
+				if s.BuildTime == "" {
+					s.BuildTime = v.Value
+				}
+			}
+		}
+	}
+	return s
+}
+
+func runtimeStatus() *RuntimeStatus {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	return &RuntimeStatus{
+		Started:    started,
+		Uptime:     time.Since(started).Truncate(time.Second).String(),
+		Goroutines: runtime.NumGoroutine(),
+		CPUs:       runtime.NumCPU(),
+		Alloc:      mem.Alloc,
+		Sys:        mem.Sys,
+		NumGC:      mem.NumGC,
+	}
+}
+
+func ormStatus(ctx *app.Context) *OrmStatus {
+	a := ctx.App()
+	o, err := a.Orm()
+	if err != nil || o.SqlDB() == nil {
+		return nil
+	}
+	db := o.SqlDB()
+	stats := db.DB().Stats()
+	return &OrmStatus{
+		Backend:           db.Backend().Name(),
+		MaxOpen:           stats.MaxOpenConnections,
+		Open:              stats.OpenConnections,
+		InUse:             stats.InUse,
+		Idle:              stats.Idle,
+		WaitCount:         stats.WaitCount,
+		WaitDuration:      stats.WaitDuration.String(),
+		MaxIdleClosed:     stats.MaxIdleClosed,
+		MaxLifetimeClosed: stats.MaxLifetimeClosed,
+		MaxIdleTimeClosed: stats.MaxIdleTimeClosed,
+	}
+}
+
+func cacheStatus(ctx *app.Context) *CacheStatus {
+	c, err := ctx.App().Cache()
+	if err != nil {
+		return nil
+	}
+	stats := c.Stats()
+	return &CacheStatus{
+		Hits:     stats.Hits,
+		Misses:   stats.Misses,
+		Sets:     stats.Sets,
+		Deletes:  stats.Deletes,
+		Errors:   stats.Errors,
+		HitRatio: stats.HitRatio() * 100,
+	}
+}
+
+func tasksStatus() []*TaskStatus {
+	registered := tasks.Tasks()
+	status := make([]*TaskStatus, len(registered))
+	for ii, v := range registered {
+		var interval string
+		if v.Interval > 0 {
+			interval = v.Interval.String()
+		}
+		status[ii] = &TaskStatus{
+			Name:     v.Name(),
+			Interval: interval,
+			Running:  v.Running(),
+			Queued:   v.Queued(),
+		}
+	}
+	return status
+}
+
+// blobstoreStatus returns the last calculated blobstore usage, starting
+// a new calculation in the background if it's older than
+// BlobstoreUsageInterval.
+func blobstoreStatus(ctx *app.Context) *BlobstoreStatus {
+	store, err := ctx.App().Blobstore()
+	if err != nil {
+		return nil
+	}
+	blobstoreUsage.Lock()
+	defer blobstoreUsage.Unlock()
+	status := blobstoreUsage.status
+	if !blobstoreUsage.updating && (status == nil || time.Since(status.Updated) > BlobstoreUsageInterval) {
+		blobstoreUsage.updating = true
+		go func() {
+			s := &BlobstoreStatus{}
+			if usage, err := store.UsageStats(); err != nil {
+				s.Error = err.Error()
+			} else {
+				s.Files = usage.Files
+				s.Size = usage.Size
+				if d := usage.Dedup; d != nil {
+					s.Dedup = &DedupStatus{
+						LogicalBytes:  d.LogicalBytes,
+						PhysicalBytes: d.PhysicalBytes,
+						SavedBytes:    d.Saved(),
+						Chunks:        d.Chunks,
+						References:    d.References,
+						Ratio:         d.Ratio(),
+						Reuse:         reuseBuckets(d.Reuse),
+					}
+				}
+			}
+			s.Updated = time.Now()
+			blobstoreUsage.Lock()
+			blobstoreUsage.status = s
+			blobstoreUsage.updating = false
+			blobstoreUsage.Unlock()
+		}()
+	}
+	return status
+}
+
+// CurrentStatus returns the current status of the app subsystems.
+// Subsystems which are not configured are omitted.
+func CurrentStatus(ctx *app.Context) *Status {
+	return &Status{
+		Time:      time.Now(),
+		Build:     buildStatus(),
+		Runtime:   runtimeStatus(),
+		Orm:       ormStatus(ctx),
+		Cache:     cacheStatus(ctx),
+		Tasks:     tasksStatus(),
+		Blobstore: blobstoreStatus(ctx),
+		Errors:    captured.Entries(&log.RecordFilter{Level: log.LError, Limit: MaxRecentErrors}),
+	}
+}
+
+func writeStatusEvent(ctx *app.Context) error {
+	data, err := json.Marshal(CurrentStatus(ctx))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(ctx, "event: status\ndata: %s\n\n", data)
+	return err
+}
+
+// dashboardEventsHandler streams the app status using server sent
+// events, sending an update every DashboardRefreshInterval.
+func dashboardEventsHandler(ctx *app.Context) {
+	if !DefaultPermission(ctx, View, nil) {
+		ctx.Forbidden()
+		return
+	}
+	h := ctx.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	retry := int(DashboardRefreshInterval / time.Millisecond)
+	fmt.Fprintf(ctx, "retry: %d\n", retry)
+	flusher, ok := ctx.ResponseWriter.(http.Flusher)
+	if !ok {
+		// Can't stream, send just one event and let the
+		// client reconnect after the retry interval.
+		if err := writeStatusEvent(ctx); err != nil {
+			panic(err)
+		}
+		return
+	}
+	ticker := time.NewTicker(DashboardRefreshInterval)
+	defer ticker.Stop()
+	done := ctx.R.Context().Done()
+	for {
+		if err := writeStatusEvent(ctx); err != nil {
+			return
+		}
+		flusher.Flush()
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
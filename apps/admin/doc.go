@@ -0,0 +1,61 @@
+// Package admin implements an app which generates pages for listing,
+// searching, creating, editing and deleting objects of models registered
+// with the ORM.
+//
+// Models must be explicitly added to the admin using Register, usually
+// right after registering them with the ORM:
+//
+//	func init() {
+//		orm.Register(&Article{}, nil)
+//		admin.Register(&Article{}, &admin.Options{
+//			ListFields:   []string{"Id", "Title", "Created"},
+//			SearchFields: []string{"Title", "Body"},
+//			Sort:         "-Created",
+//		})
+//	}
+//
+// Then, include the admin app into your app:
+//
+//	App.Include("/admin/", admin.App, "admin-base.html")
+//
+// Fields are displayed and edited according to their type: strings and
+// numbers use text inputs, bools use checkboxes and time.Time fields use
+// datetime inputs. Fields with a codec, as well as slices, maps and structs
+// are edited as JSON. Fields which reference another model are edited using
+// a select element when the referenced model has no more than
+// MaxRelationChoices objects, and link to the referenced object when its
+// model is also registered with the admin.
+//
+// By default, only admins (see gnd.la/app.User) can access the admin. This
+// can be changed globally by setting DefaultPermission or per model using
+// Options.Permission. Options also allows setting hooks which are called
+// before and after saving or deleting objects.
+//
+// Users with the default permission also get a dashboard in the admin
+// index, which shows the build information, the runtime, ORM, cache,
+// task and blobstore status as well as the most recent errors logged by
+// the app. The dashboard is refreshed every DashboardRefreshInterval using
+// server sent events. The version and build time can be set at build time
+// with e.g.
+//
+//	go build -ldflags "-X gnd.la/apps/admin.Version=1.2.3 -X gnd.la/apps/admin.BuildTime=2015-01-02"
+//
+// The last MaxCapturedRecords messages logged by the app at the warning
+// level or above are kept in memory and can be retrieved as JSON from
+// LogsHandler, filtered by level, module (the name of the app which
+// logged them) and request id (from the X-Request-Id header).
+//
+// The tasks page lists the tasks registered with gnd.la/tasks, including
+// their schedule and the result of their last run. From there, tasks can
+// be started manually with parameters (available in the task handler via
+// Context.ParamValue) and scheduled tasks can be paused and resumed. Register
+// TaskState with the ORM to keep paused tasks paused across restarts.
+//
+// When AuditEvent is registered with the ORM, objects created, edited or
+// deleted from the admin are recorded in the audit log, including the
+// changed fields. Handlers might also record their own events using Audit.
+// The audit log can be searched from the admin and events older than
+// AuditRetention are periodically deleted.
+//
+// Note that only models with a non-composite primary key are supported.
+package admin
@@ -0,0 +1,396 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/form/input"
+	"gnd.la/i18n"
+	"gnd.la/orm"
+	"gnd.la/orm/driver"
+	"gnd.la/util/stringutil"
+	"gnd.la/util/types"
+)
+
+// FieldType indicates how a field is displayed and edited.
+type FieldType int
+
+const (
+	// Text fields are edited using a text input.
+	Text FieldType = iota + 1
+	// Number fields are edited using a numeric input.
+	Number
+	// Checkbox is used for bool fields.
+	Checkbox
+	// DateTime is used for time.Time fields.
+	DateTime
+	// Encoded fields use a codec or have a type which can't be
+	// edited inline (like slices or maps). They're edited as JSON.
+	Encoded
+	// Relation fields reference another model.
+	Relation
+	// Binary fields ([]byte without a codec) can't be edited.
+	Binary
+)
+
+const (
+	dateTimeFormat = "2006-01-02T15:04:05"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	dateTimeForm = []string{dateTimeFormat, "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+)
+
+// Choice represents a possible value for a Relation field.
+type Choice struct {
+	Value    string
+	Label    string
+	Selected bool
+}
+
+// Field represents a model field in the admin. Fields are
+// created for every request, so they can hold the values
+// and the errors for the current object.
+type Field struct {
+	// Name is the qualified field name (e.g. Foo.Bar).
+	Name string
+	// Label is the name displayed to the users.
+	Label string
+	// Type indicates how the field is displayed and edited.
+	Type FieldType
+	// ReadOnly is true for fields which can't be modified.
+	ReadOnly bool
+	// Optional is true for pointer fields, which are set
+	// to nil when an empty value is submitted.
+	Optional bool
+	// Value is the field value, formatted as a string.
+	Value string
+	// Choices are only available for Relation fields pointing
+	// to models with no more than MaxRelationChoices objects.
+	Choices []*Choice
+	// Related is the admin model referenced by a Relation field,
+	// if it's registered with the admin.
+	Related *Model
+	// Error is the error for the submitted value, if any.
+	Error error
+	pos   int
+	typ   reflect.Type
+	ref   *driver.Reference
+}
+
+// Input returns the HTML input type for the field.
+func (f *Field) Input() string {
+	switch f.Type {
+	case Number:
+		return "number"
+	case Checkbox:
+		return "checkbox"
+	case DateTime:
+		return "datetime-local"
+	}
+	return "text"
+}
+
+// Checked returns true iff the field is a checkbox
+// with a true value.
+func (f *Field) Checked() bool {
+	return f.Type == Checkbox && f.Value == "true"
+}
+
+// IsText returns true iff the field should be edited
+// using a textarea.
+func (f *Field) IsText() bool {
+	return f.Type == Encoded
+}
+
+// fieldType returns the FieldType for the field at the given
+// position, or 0 if the field can't be handled by the admin.
+func fieldType(fields *driver.Fields, pos int) FieldType {
+	qname := fields.QNames[pos]
+	if _, ok := fields.References[qname]; ok {
+		return Relation
+	}
+	if fields.Tags[pos].CodecName() != "" {
+		return Encoded
+	}
+	typ := fields.Types[pos]
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == timeType {
+		return DateTime
+	}
+	switch types.Kind(typ.Kind()) {
+	case types.Int, types.Uint, types.Float:
+		return Number
+	case types.Bool:
+		return Checkbox
+	case types.String:
+		return Text
+	}
+	switch typ.Kind() {
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return Binary
+		}
+		return Encoded
+	case reflect.Map, reflect.Struct:
+		return Encoded
+	}
+	return 0
+}
+
+func contains(names []string, name string) bool {
+	for _, v := range names {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// makeFields returns the fields for the model. If names is empty,
+// all the fields which can be handled by the admin are returned.
+func (m *Model) makeFields(tbl *orm.Table, names []string) ([]*Field, error) {
+	fields := tbl.Fields()
+	if len(names) == 0 {
+		names = fields.QNames
+	}
+	var result []*Field
+	for _, name := range names {
+		pos, ok := fields.QNameMap[name]
+		if !ok {
+			return nil, fmt.Errorf("model %s has no field named %q", m.typ, name)
+		}
+		ft := fieldType(fields, pos)
+		if ft == 0 {
+			continue
+		}
+		label := fields.Tags[pos].Value("label")
+		if label == "" {
+			label = stringutil.CamelCaseToWords(strings.Replace(name, ".", "", -1), " ")
+		}
+		field := &Field{
+			Name:     name,
+			Label:    label,
+			Type:     ft,
+			ReadOnly: ft == Binary || contains(m.options.ReadOnly, name) || (pos == fields.PrimaryKey && fields.AutoincrementPk),
+			Optional: fields.Types[pos].Kind() == reflect.Ptr,
+			pos:      pos,
+			typ:      fields.Types[pos],
+			ref:      fields.References[name],
+		}
+		if field.ref != nil {
+			field.Related = TypeModel(field.ref.Model.Type())
+		}
+		result = append(result, field)
+	}
+	return result, nil
+}
+
+// listFields returns the fields displayed in the list view.
+func (m *Model) listFields(tbl *orm.Table) ([]*Field, error) {
+	fields, err := m.makeFields(tbl, m.options.ListFields)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.options.ListFields) > 0 {
+		return fields, nil
+	}
+	var inline []*Field
+	for _, v := range fields {
+		if v.Type != Encoded && v.Type != Binary {
+			inline = append(inline, v)
+		}
+	}
+	return inline, nil
+}
+
+// formFields returns the fields displayed in the create and edit forms.
+func (m *Model) formFields(tbl *orm.Table) ([]*Field, error) {
+	return m.makeFields(tbl, m.options.Fields)
+}
+
+// fieldValue returns the value for the field at the given position,
+// or an invalid reflect.Value if the field is inside a nil pointer
+// to an embedded struct. If create is true, nil pointers to embedded
+// structs are allocated.
+func fieldValue(fields *driver.Fields, obj reflect.Value, pos int, create bool) reflect.Value {
+	for obj.Kind() == reflect.Ptr {
+		obj = obj.Elem()
+	}
+	for _, idx := range fields.Indexes[pos] {
+		if obj.Kind() == reflect.Ptr {
+			if obj.IsNil() {
+				if !create {
+					return reflect.Value{}
+				}
+				obj.Set(reflect.New(obj.Type().Elem()))
+			}
+			obj = obj.Elem()
+		}
+		obj = obj.Field(idx)
+	}
+	return obj
+}
+
+// formatValue returns the given value as a string
+// to be displayed or edited in a form.
+func formatValue(ft FieldType, val reflect.Value) string {
+	if !val.IsValid() {
+		return ""
+	}
+	if ft == Encoded {
+		if val.Kind() == reflect.Ptr && val.IsNil() {
+			return ""
+		}
+		data, err := json.MarshalIndent(val.Interface(), "", "  ")
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return ""
+		}
+		val = val.Elem()
+	}
+	switch ft {
+	case DateTime:
+		t := val.Interface().(time.Time)
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(dateTimeFormat)
+	case Binary:
+		return fmt.Sprintf("%d bytes", val.Len())
+	case Checkbox:
+		if val.Bool() {
+			return "true"
+		}
+		return "false"
+	}
+	return types.ToString(val.Interface())
+}
+
+// load sets the field value from the given object.
+func (f *Field) load(fields *driver.Fields, obj reflect.Value) {
+	f.Value = formatValue(f.Type, fieldValue(fields, obj, f.pos, false))
+}
+
+// parse sets the field value in the object from the given string.
+func (f *Field) parse(fields *driver.Fields, obj reflect.Value, s string) error {
+	if err := f.set(fieldValue(fields, obj, f.pos, true), s); err != nil {
+		// Keep the submitted value, so the user can fix it
+		f.Value = s
+		return err
+	}
+	f.load(fields, obj)
+	return nil
+}
+
+func (f *Field) set(val reflect.Value, s string) error {
+	typ := f.typ
+	if f.Type == Encoded {
+		if strings.TrimSpace(s) == "" {
+			val.Set(reflect.Zero(typ))
+			return nil
+		}
+		nv := reflect.New(typ)
+		if err := json.Unmarshal([]byte(s), nv.Interface()); err != nil {
+			return i18n.Errorf("invalid JSON: %s", err)
+		}
+		val.Set(nv.Elem())
+		return nil
+	}
+	if typ.Kind() == reflect.Ptr {
+		if s == "" {
+			val.Set(reflect.Zero(typ))
+			return nil
+		}
+		nv := reflect.New(typ.Elem())
+		if err := parseValue(f.Type, s, nv.Elem()); err != nil {
+			return err
+		}
+		val.Set(nv)
+		return nil
+	}
+	return parseValue(f.Type, s, val)
+}
+
+func parseValue(ft FieldType, s string, val reflect.Value) error {
+	if ft == DateTime {
+		if s == "" {
+			val.Set(reflect.Zero(val.Type()))
+			return nil
+		}
+		for _, layout := range dateTimeForm {
+			if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+				val.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return i18n.Errorf("invalid date %q", s)
+	}
+	return input.Parse(s, val.Addr().Interface())
+}
+
+// loadChoices loads the available choices for a Relation field, if the
+// referenced model has no more than MaxRelationChoices objects.
+func (f *Field) loadChoices(ctx *app.Context) error {
+	if f.ref == nil || f.ReadOnly {
+		return nil
+	}
+	o := ctx.Orm()
+	tbl := o.TypeTable(f.ref.Model.Type())
+	if tbl == nil {
+		return nil
+	}
+	count, err := o.Count(tbl, nil)
+	if err != nil {
+		return err
+	}
+	if count > uint64(MaxRelationChoices) {
+		return nil
+	}
+	fields := tbl.Fields()
+	pos, ok := fields.QNameMap[f.ref.Field]
+	if !ok {
+		return nil
+	}
+	objs := reflect.New(reflect.SliceOf(reflect.PtrTo(tbl.Type())))
+	if err := o.Table(tbl).All(objs.Interface()); err != nil {
+		return err
+	}
+	choices := []*Choice{}
+	if f.Optional {
+		choices = append(choices, &Choice{Label: "---", Selected: f.Value == ""})
+	}
+	for ii := 0; ii < objs.Elem().Len(); ii++ {
+		obj := objs.Elem().Index(ii)
+		value := formatValue(Text, fieldValue(fields, obj, pos, false))
+		choices = append(choices, &Choice{
+			Value:    value,
+			Label:    objectLabel(obj.Interface(), value),
+			Selected: value == f.Value,
+		})
+	}
+	f.Choices = choices
+	return nil
+}
+
+// objectLabel returns the label for the given object. If
+// the object implements fmt.Stringer, its String method
+// is used. Otherwise, its primary key is returned.
+func objectLabel(obj interface{}, pk string) string {
+	if s, ok := obj.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return "#" + pk
+}
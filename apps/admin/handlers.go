@@ -0,0 +1,441 @@
+package admin
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"gnd.la/app"
+	"gnd.la/form/input"
+	"gnd.la/html/paginator"
+	"gnd.la/i18n"
+	"gnd.la/orm"
+	"gnd.la/orm/query"
+)
+
+const (
+	IndexHandlerName  = "admin-index"
+	ListHandlerName   = "admin-list"
+	CreateHandlerName = "admin-create"
+	EditHandlerName   = "admin-edit"
+	DeleteHandlerName = "admin-delete"
+
+	searchParameterName = "q"
+)
+
+var (
+	IndexHandler  = app.NamedHandler(IndexHandlerName, app.SignedIn(indexHandler))
+	ListHandler   = app.NamedHandler(ListHandlerName, app.SignedIn(listHandler))
+	CreateHandler = app.NamedHandler(CreateHandlerName, app.SignedIn(createHandler))
+	EditHandler   = app.NamedHandler(EditHandlerName, app.SignedIn(editHandler))
+	DeleteHandler = app.NamedHandler(DeleteHandlerName, app.SignedIn(deleteHandler))
+)
+
+// Row represents an object in the list view.
+type Row struct {
+	// Id is the object primary key, formatted as a string.
+	Id string
+	// Values contains the values for the list fields.
+	Values []string
+	// Object is the object itself.
+	Object interface{}
+}
+
+// listPager generates the URLs for the list view, preserving
+// the search query.
+type listPager struct {
+	ctx    *app.Context
+	model  *Model
+	search string
+}
+
+func (p *listPager) URL(page int) string {
+	var u string
+	if page == 1 {
+		u = p.ctx.MustReverse(ListHandlerName, p.model.name)
+	} else {
+		u = p.ctx.MustReverse(ListHandlerName, p.model.name, page)
+	}
+	if p.search != "" {
+		u += "?" + searchParameterName + "=" + url.QueryEscape(p.search)
+	}
+	return u
+}
+
+// contextModel returns the model indicated by the model parameter
+// and its table. If the model can't be found, it sends a 404 and
+// returns nil.
+func contextModel(ctx *app.Context) (*Model, *orm.Table) {
+	m := NamedModel(ctx.ParamValue("model"))
+	if m == nil {
+		ctx.NotFound("model not found")
+		return nil, nil
+	}
+	tbl := m.Table(ctx)
+	if tbl == nil {
+		ctx.Logger().Errorf("admin model %s is not registered with the ORM", m.typ)
+		ctx.NotFound("model not found")
+		return nil, nil
+	}
+	if tbl.Fields().PrimaryKey < 0 {
+		ctx.Logger().Errorf("admin model %s has no primary key", m.typ)
+		ctx.NotFound("model not found")
+		return nil, nil
+	}
+	return m, tbl
+}
+
+// contextObject loads the object indicated by the id parameter. If
+// the object can't be found, it sends a 404 and returns an invalid
+// reflect.Value.
+func contextObject(ctx *app.Context, tbl *orm.Table) reflect.Value {
+	fields := tbl.Fields()
+	pk := reflect.New(fields.Types[fields.PrimaryKey])
+	if err := input.Parse(ctx.ParamValue("id"), pk.Interface()); err != nil {
+		ctx.NotFound("object not found")
+		return reflect.Value{}
+	}
+	obj := reflect.New(tbl.Type())
+	ok, err := ctx.Orm().Table(tbl).Filter(orm.Eq(fields.QNames[fields.PrimaryKey], pk.Elem().Interface())).One(obj.Interface())
+	if err != nil {
+		panic(err)
+	}
+	if !ok {
+		ctx.NotFound("object not found")
+		return reflect.Value{}
+	}
+	return obj
+}
+
+func primaryKey(tbl *orm.Table, obj reflect.Value) string {
+	fields := tbl.Fields()
+	return formatValue(Text, fieldValue(fields, obj, fields.PrimaryKey, false))
+}
+
+func indexHandler(ctx *app.Context) {
+	var models []*Model
+	for _, v := range Models() {
+		if v.Table(ctx) != nil && v.Can(ctx, View, nil) {
+			models = append(models, v)
+		}
+	}
+	dashboard := DefaultPermission(ctx, View, nil)
+	if len(models) == 0 && !dashboard {
+		ctx.Forbidden()
+		return
+	}
+	data := map[string]interface{}{
+		"Models": models,
+	}
+	if dashboard {
+		data["Status"] = CurrentStatus(ctx)
+		data["Audit"] = auditEventTable(ctx.Orm()) != nil
+	}
+	ctx.MustExecute("index.html", data)
+}
+
+func listHandler(ctx *app.Context) {
+	m, tbl := contextModel(ctx)
+	if m == nil {
+		return
+	}
+	if !m.Can(ctx, View, nil) {
+		ctx.Forbidden()
+		return
+	}
+	fields, err := m.listFields(tbl)
+	if err != nil {
+		panic(err)
+	}
+	q := ctx.Orm().Table(tbl)
+	search := strings.TrimSpace(ctx.FormValue(searchParameterName))
+	if search != "" && len(m.options.SearchFields) > 0 {
+		conditions := make([]query.Q, len(m.options.SearchFields))
+		for ii, v := range m.options.SearchFields {
+			conditions[ii] = orm.Contains(v, search)
+		}
+		q = q.Filter(orm.Or(conditions...))
+	}
+	count, err := q.Count()
+	if err != nil {
+		panic(err)
+	}
+	perPage := m.perPage()
+	var page int
+	ctx.ParseParamValue("page", &page)
+	if page <= 0 {
+		page = 1
+	}
+	pages := (int(count) + perPage - 1) / perPage
+	if page > 1 && page > pages {
+		ctx.NotFound("page not found")
+		return
+	}
+	sortField, dir := m.sort()
+	if sortField == "" {
+		sortField = tbl.Fields().QNames[tbl.Fields().PrimaryKey]
+	}
+	objs := reflect.New(reflect.SliceOf(reflect.PtrTo(tbl.Type())))
+	if err := q.Sort(sortField, dir).Limit(perPage).Offset((page - 1) * perPage).All(objs.Interface()); err != nil {
+		panic(err)
+	}
+	rows := make([]*Row, objs.Elem().Len())
+	for ii := range rows {
+		obj := objs.Elem().Index(ii)
+		row := &Row{
+			Id:     primaryKey(tbl, obj),
+			Values: make([]string, len(fields)),
+			Object: obj.Interface(),
+		}
+		for jj, f := range fields {
+			f.load(tbl.Fields(), obj)
+			row.Values[jj] = f.Value
+		}
+		rows[ii] = row
+	}
+	data := map[string]interface{}{
+		"Model":      m,
+		"Fields":     fields,
+		"Rows":       rows,
+		"Count":      count,
+		"Search":     search,
+		"Searchable": len(m.options.SearchFields) > 0,
+		"CanCreate":  m.Can(ctx, Create, nil),
+		"Paginator":  paginator.New(pages, page, &listPager{ctx: ctx, model: m, search: search}),
+	}
+	ctx.MustExecute("list.html", data)
+}
+
+func createHandler(ctx *app.Context) {
+	m, tbl := contextModel(ctx)
+	if m == nil {
+		return
+	}
+	if !m.Can(ctx, Create, nil) {
+		ctx.Forbidden()
+		return
+	}
+	editObject(ctx, m, tbl, reflect.New(tbl.Type()), true)
+}
+
+func editHandler(ctx *app.Context) {
+	m, tbl := contextModel(ctx)
+	if m == nil {
+		return
+	}
+	obj := contextObject(ctx, tbl)
+	if !obj.IsValid() {
+		return
+	}
+	if !m.Can(ctx, Edit, obj.Interface()) {
+		ctx.Forbidden()
+		return
+	}
+	editObject(ctx, m, tbl, obj, false)
+}
+
+func editObject(ctx *app.Context, m *Model, tbl *orm.Table, obj reflect.Value, created bool) {
+	fields, err := m.formFields(tbl)
+	if err != nil {
+		panic(err)
+	}
+	df := tbl.Fields()
+	var pk interface{}
+	var before interface{}
+	if !created {
+		// Don't allow changing the primary key, since
+		// it's used to find the object to update.
+		for _, f := range fields {
+			if f.pos == df.PrimaryKey {
+				f.ReadOnly = true
+			}
+		}
+		pk = fieldValue(df, obj, df.PrimaryKey, false).Interface()
+		// Keep a copy of the object before modifying it, for
+		// recording the changes in the audit log. It must be a
+		// deep copy, since parsing the form might modify slices
+		// and maps in place.
+		before = deepCopy(obj).Interface()
+	}
+	var formError error
+	if ctx.R.Method == "POST" {
+		if !checkCSRF(ctx) {
+			ctx.Forbidden("invalid CSRF token")
+			return
+		}
+		valid := true
+		for _, f := range fields {
+			if f.ReadOnly {
+				f.load(df, obj)
+				continue
+			}
+			if err := f.parse(df, obj, ctx.FormValue(f.Name)); err != nil {
+				f.Error = i18n.TranslatedError(err, ctx)
+				valid = false
+			}
+		}
+		if valid {
+			formError = saveObject(ctx, m, tbl, obj, pk, before, created)
+			if formError == nil {
+				ctx.MustRedirectReverse(false, ListHandlerName, m.name)
+				return
+			}
+		}
+	} else {
+		for _, f := range fields {
+			f.load(df, obj)
+		}
+	}
+	for _, f := range fields {
+		if err := f.loadChoices(ctx); err != nil {
+			panic(err)
+		}
+	}
+	var id string
+	if !created {
+		id = primaryKey(tbl, obj)
+	}
+	data := map[string]interface{}{
+		"Model":     m,
+		"Fields":    fields,
+		"Object":    obj.Interface(),
+		"Id":        id,
+		"Created":   created,
+		"Error":     formError,
+		"CanDelete": !created && m.Can(ctx, Delete, obj.Interface()),
+		"CSRF":      csrfToken(ctx),
+	}
+	ctx.MustExecute("edit.html", data)
+}
+
+func saveObject(ctx *app.Context, m *Model, tbl *orm.Table, obj reflect.Value, pk interface{}, before interface{}, created bool) error {
+	o := ctx.Orm()
+	iface := obj.Interface()
+	if m.options.BeforeSave != nil {
+		if err := m.options.BeforeSave(ctx, iface, created); err != nil {
+			return i18n.TranslatedError(err, ctx)
+		}
+	}
+	var err error
+	if created {
+		_, err = o.Insert(iface)
+	} else {
+		df := tbl.Fields()
+		_, err = o.Update(orm.Eq(df.QNames[df.PrimaryKey], pk), iface)
+	}
+	if err != nil {
+		ctx.Logger().Errorf("error saving %s: %s", m.typ, err)
+		return err
+	}
+	action := Edit
+	if created {
+		action = Create
+	}
+	auditObject(ctx, m, action, primaryKey(tbl, obj), before, iface)
+	if m.options.AfterSave != nil {
+		m.options.AfterSave(ctx, iface, created)
+	}
+	return nil
+}
+
+func deleteHandler(ctx *app.Context) {
+	m, tbl := contextModel(ctx)
+	if m == nil {
+		return
+	}
+	obj := contextObject(ctx, tbl)
+	if !obj.IsValid() {
+		return
+	}
+	iface := obj.Interface()
+	if !m.Can(ctx, Delete, iface) {
+		ctx.Forbidden()
+		return
+	}
+	id := primaryKey(tbl, obj)
+	var deleteError error
+	if ctx.R.Method == "POST" {
+		if !checkCSRF(ctx) {
+			ctx.Forbidden("invalid CSRF token")
+			return
+		}
+		if m.options.BeforeDelete != nil {
+			deleteError = m.options.BeforeDelete(ctx, iface)
+		}
+		if deleteError == nil {
+			if err := ctx.Orm().Delete(iface); err != nil {
+				panic(err)
+			}
+			auditObject(ctx, m, Delete, id, iface, nil)
+			if m.options.AfterDelete != nil {
+				m.options.AfterDelete(ctx, iface)
+			}
+			ctx.MustRedirectReverse(false, ListHandlerName, m.name)
+			return
+		}
+		deleteError = i18n.TranslatedError(deleteError, ctx)
+	} else if ctx.R.Method != "GET" && ctx.R.Method != "HEAD" {
+		ctx.Error(http.StatusMethodNotAllowed)
+		return
+	}
+	data := map[string]interface{}{
+		"Model":  m,
+		"Object": iface,
+		"Id":     id,
+		"Label":  objectLabel(iface, id),
+		"Error":  deleteError,
+		"CSRF":   csrfToken(ctx),
+	}
+	ctx.MustExecute("delete.html", data)
+}
+
+// deepCopy returns a copy of v which doesn't share any
+// pointers, slices nor maps with it. Unexported fields
+// are copied shallowly.
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopy(v.Elem()))
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for ii := 0; ii < v.Len(); ii++ {
+			c.Index(ii).Set(deepCopy(v.Index(ii)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMap(v.Type())
+		for _, k := range v.MapKeys() {
+			c.SetMapIndex(k, deepCopy(v.MapIndex(k)))
+		}
+		return c
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for ii := 0; ii < c.NumField(); ii++ {
+			if f := c.Field(ii); f.CanSet() {
+				f.Set(deepCopy(v.Field(ii)))
+			}
+		}
+		return c
+	}
+	return v
+}
@@ -0,0 +1,306 @@
+package admin
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/config"
+	"gnd.la/orm"
+	_ "gnd.la/orm/driver/sqlite"
+	"gnd.la/signal"
+	"gnd.la/tasks"
+
+	"gopkgs.com/vfs.v1"
+)
+
+// AdminArticle is the model used for testing the handlers.
+type AdminArticle struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Title string
+	Body  string
+}
+
+// testUser is an admin when its id is 1.
+type testUser int64
+
+func (u testUser) Id() int64     { return int64(u) }
+func (u testUser) IsAdmin() bool { return u == 1 }
+
+var (
+	// testApp includes the admin App, which can only
+	// be included once, so it's shared by all the tests.
+	testApp *app.App
+	csrfRe  = regexp.MustCompile(`name="csrf" value="([^"]+)"`)
+)
+
+func TestMain(m *testing.M) {
+	orm.Register(&AdminArticle{}, &orm.Options{Table: "test_admin_article"})
+	orm.Register(&AuditEvent{}, &orm.Options{Table: "test_admin_audit"})
+	orm.Register(&TaskState{}, &orm.Options{Table: "test_admin_task_state"})
+	Register(&AdminArticle{}, &Options{SearchFields: []string{"Title"}})
+	f, err := ioutil.TempFile("", "admin-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	f.Close()
+	testApp = app.New()
+	testApp.Config().Secret = "0123456789abcdef0123456789abcdef"
+	testApp.Config().Database = config.MustParseURL("sqlite://" + f.Name())
+	testApp.SetUserFunc(func(ctx *app.Context, id int64) app.User { return testUser(id) })
+	testApp.HandleNamed("^/sign-in/$", func(ctx *app.Context) {
+		var id int64
+		ctx.ParseFormValue("id", &id)
+		ctx.MustSignIn(testUser(id))
+	}, app.SignInHandlerName)
+	fs, err := vfs.Map(map[string]*vfs.File{
+		"admin-base.html": &vfs.File{Data: []byte("<html><body>{{ app }}</body></html>")},
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	testApp.SetTemplatesFS(fs)
+	testApp.Include("/admin/", App, "admin-base.html")
+	if err := testApp.Prepare(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	if o, err := testApp.Orm(); err == nil {
+		o.Close()
+	}
+	os.Remove(f.Name())
+	os.Exit(code)
+}
+
+// testClient keeps the cookies set by the responses,
+// like a browser would do.
+type testClient struct {
+	t       *testing.T
+	cookies map[string]*http.Cookie
+}
+
+func newTestClient(t *testing.T, userId int64) *testClient {
+	c := &testClient{t: t, cookies: make(map[string]*http.Cookie)}
+	if userId != 0 {
+		c.do("GET", "/sign-in/?id="+strconv.FormatInt(userId, 10), nil)
+	}
+	return c
+}
+
+func (c *testClient) do(method string, path string, form url.Values) *httptest.ResponseRecorder {
+	var r *http.Request
+	if form != nil {
+		r = httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		r = httptest.NewRequest(method, path, nil)
+	}
+	for _, v := range c.cookies {
+		r.AddCookie(v)
+	}
+	w := httptest.NewRecorder()
+	testApp.ServeHTTP(w, r)
+	for _, v := range (&http.Response{Header: w.Header()}).Cookies() {
+		c.cookies[v.Name] = v
+	}
+	return w
+}
+
+func (c *testClient) expect(method string, path string, form url.Values, code int) string {
+	w := c.do(method, path, form)
+	if w.Code != code {
+		c.t.Errorf("%s %s: expecting status %d, got %d", method, path, code, w.Code)
+	}
+	return w.Body.String()
+}
+
+func (c *testClient) csrf(path string) string {
+	m := csrfRe.FindStringSubmatch(c.expect("GET", path, nil, http.StatusOK))
+	if m == nil {
+		c.t.Fatalf("no CSRF token in %s", path)
+	}
+	return m[1]
+}
+
+func loadArticles(t *testing.T) []*AdminArticle {
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	var articles []*AdminArticle
+	if err := ctx.Orm().Table(NamedModel("adminarticle").Table(ctx)).Sort("Id", orm.ASC).All(&articles); err != nil {
+		t.Fatal(err)
+	}
+	return articles
+}
+
+func TestPermissions(t *testing.T) {
+	user := newTestClient(t, 2)
+	user.expect("GET", "/admin/", nil, http.StatusForbidden)
+	user.expect("GET", "/admin/adminarticle/", nil, http.StatusForbidden)
+	user.expect("GET", "/admin/adminarticle/new/", nil, http.StatusForbidden)
+	admin := newTestClient(t, 1)
+	if body := admin.expect("GET", "/admin/", nil, http.StatusOK); !strings.Contains(body, "Admin Article") {
+		t.Errorf("expecting model in index, got %s", body)
+	}
+	admin.expect("GET", "/admin/nope/", nil, http.StatusNotFound)
+	admin.expect("GET", "/admin/adminarticle/edit/1000/", nil, http.StatusNotFound)
+	admin.expect("GET", "/admin/adminarticle/edit/bad/", nil, http.StatusNotFound)
+}
+
+func TestCRUD(t *testing.T) {
+	admin := newTestClient(t, 1)
+	const (
+		list   = "/admin/adminarticle/"
+		create = "/admin/adminarticle/new/"
+	)
+	token := admin.csrf(create)
+	admin.expect("POST", create, url.Values{"Title": {"Hello"}}, http.StatusForbidden)
+	admin.expect("POST", create, url.Values{"Title": {"Hello"}, "csrf": {"bad"}}, http.StatusForbidden)
+	admin.expect("POST", create, url.Values{"Title": {"Hello"}, "Body": {"World"}, "csrf": {token}}, http.StatusFound)
+	admin.expect("POST", create, url.Values{"Title": {"Other"}, "csrf": {token}}, http.StatusFound)
+	articles := loadArticles(t)
+	if len(articles) != 2 || articles[0].Title != "Hello" || articles[0].Body != "World" {
+		t.Fatalf("unexpected articles after creating %+v", articles)
+	}
+	id := strconv.FormatInt(articles[0].Id, 10)
+	if body := admin.expect("GET", list, nil, http.StatusOK); !strings.Contains(body, "Hello") || !strings.Contains(body, "Other") {
+		t.Errorf("expecting both articles in list, got %s", body)
+	}
+	if body := admin.expect("GET", list+"?q=hell", nil, http.StatusOK); !strings.Contains(body, "Hello") || strings.Contains(body, "Other") {
+		t.Errorf("expecting only Hello in search results, got %s", body)
+	}
+	admin.expect("GET", list+"2/", nil, http.StatusNotFound)
+	// Edit
+	edit := list + "edit/" + id + "/"
+	if body := admin.expect("GET", edit, nil, http.StatusOK); !strings.Contains(body, "World") {
+		t.Errorf("expecting article body in edit form, got %s", body)
+	}
+	admin.expect("POST", edit, url.Values{"Title": {"Bye"}, "Body": {"World"}, "Id": {"1000"}, "csrf": {token}}, http.StatusFound)
+	if articles = loadArticles(t); articles[0].Title != "Bye" || strconv.FormatInt(articles[0].Id, 10) != id {
+		t.Errorf("expecting title to be updated without changing the id, got %+v", articles[0])
+	}
+	// Delete
+	del := list + "delete/" + id + "/"
+	admin.expect("GET", del, nil, http.StatusOK)
+	admin.expect("PUT", del, nil, http.StatusMethodNotAllowed)
+	admin.expect("POST", del, url.Values{}, http.StatusForbidden)
+	admin.expect("POST", del, url.Values{"csrf": {token}}, http.StatusFound)
+	if articles = loadArticles(t); len(articles) != 1 || articles[0].Title != "Other" {
+		t.Errorf("expecting only Other after deleting, got %+v", articles)
+	}
+	// Audit log
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	var events []*AuditEvent
+	if err := ctx.Orm().Table(auditEventTable(ctx.Orm())).Sort("Id", orm.ASC).All(&events); err != nil {
+		t.Fatal(err)
+	}
+	var actions []string
+	for _, v := range events {
+		if v.ActorId != 1 {
+			t.Errorf("expecting actor 1, got %d", v.ActorId)
+		}
+		actions = append(actions, v.Action)
+	}
+	if expect := []string{"create", "create", "edit", "delete"}; strings.Join(actions, " ") != strings.Join(expect, " ") {
+		t.Errorf("expecting audit actions %v, got %v", expect, actions)
+	}
+}
+
+func loadTaskState(t *testing.T, name string) *TaskState {
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	var state *TaskState
+	if _, err := ctx.Orm().Table(taskStateTable(ctx.Orm())).Filter(orm.Eq("Name", name)).One(&state); err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func TestTasks(t *testing.T) {
+	params := make(chan string, 1)
+	task := tasks.Schedule(testApp, func(ctx *app.Context) {
+		params <- ctx.ParamValue("name")
+	}, &tasks.Options{Name: "test-admin-task"}, time.Hour, false)
+	defer task.Delete()
+	unscheduled := tasks.Register(testApp, func(ctx *app.Context) {}, &tasks.Options{Name: "test-admin-unscheduled"})
+	defer unscheduled.Delete()
+	user := newTestClient(t, 2)
+	user.expect("GET", "/admin/tasks/", nil, http.StatusForbidden)
+	user.expect("POST", "/admin/tasks/run/", url.Values{"task": {task.Name()}}, http.StatusForbidden)
+	admin := newTestClient(t, 1)
+	if body := admin.expect("GET", "/admin/tasks/", nil, http.StatusOK); !strings.Contains(body, task.Name()) {
+		t.Errorf("expecting task in tasks view, got %s", body)
+	}
+	token := admin.csrf("/admin/tasks/")
+	// Run
+	admin.expect("GET", "/admin/tasks/run/", nil, http.StatusMethodNotAllowed)
+	admin.expect("POST", "/admin/tasks/run/", url.Values{"task": {task.Name()}}, http.StatusForbidden)
+	admin.expect("POST", "/admin/tasks/run/", url.Values{"task": {task.Name()}, "csrf": {"bad"}}, http.StatusForbidden)
+	admin.expect("POST", "/admin/tasks/run/", url.Values{"task": {"nope"}, "csrf": {token}}, http.StatusNotFound)
+	if body := admin.expect("POST", "/admin/tasks/run/", url.Values{"task": {task.Name()}, "params": {"bad"}, "csrf": {token}}, http.StatusOK); !strings.Contains(body, "must be in the form name=value") {
+		t.Errorf("expecting invalid parameters error, got %s", body)
+	}
+	admin.expect("POST", "/admin/tasks/run/", url.Values{"task": {task.Name()}, "params": {"name = value\n# comment"}, "csrf": {token}}, http.StatusFound)
+	select {
+	case p := <-params:
+		if p != "value" {
+			t.Errorf("expecting task parameter value, got %q", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("task did not run")
+	}
+	// Pause and resume
+	admin.expect("POST", "/admin/tasks/pause/", url.Values{"task": {task.Name()}}, http.StatusForbidden)
+	admin.expect("POST", "/admin/tasks/pause/", url.Values{"task": {unscheduled.Name()}, "csrf": {token}}, http.StatusBadRequest)
+	admin.expect("POST", "/admin/tasks/pause/", url.Values{"task": {task.Name()}, "csrf": {token}}, http.StatusFound)
+	if task.IsScheduled() {
+		t.Error("expecting task to be paused")
+	}
+	if state := loadTaskState(t, task.Name()); state == nil || !state.Paused || state.UpdatedBy != 1 {
+		t.Errorf("expecting paused state updated by 1, got %+v", state)
+	}
+	admin.expect("POST", "/admin/tasks/resume/", url.Values{"task": {task.Name()}, "csrf": {token}}, http.StatusFound)
+	if !task.IsScheduled() {
+		t.Error("expecting task to be resumed")
+	}
+	if state := loadTaskState(t, task.Name()); state == nil || state.Paused {
+		t.Errorf("expecting resumed state, got %+v", state)
+	}
+}
+
+func TestRestoreTaskStates(t *testing.T) {
+	paused := tasks.Schedule(testApp, func(ctx *app.Context) {}, &tasks.Options{Name: "test-admin-restore-paused"}, time.Hour, false)
+	defer paused.Delete()
+	resumed := tasks.Schedule(testApp, func(ctx *app.Context) {}, &tasks.Options{Name: "test-admin-restore-resumed"}, time.Hour, false)
+	defer resumed.Delete()
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	for _, v := range []*TaskState{
+		{Name: paused.Name(), Paused: true},
+		{Name: resumed.Name(), Paused: false},
+	} {
+		if _, err := ctx.Orm().Upsert(orm.Eq("Name", v.Name), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Paused tasks are stopped when the app is prepared
+	signal.Emit(app.DID_PREPARE, testApp)
+	if paused.IsScheduled() {
+		t.Errorf("expecting task %s to be paused after preparing the app", paused.Name())
+	}
+	if !resumed.IsScheduled() {
+		t.Errorf("expecting task %s to be scheduled after preparing the app", resumed.Name())
+	}
+}
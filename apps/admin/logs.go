@@ -0,0 +1,125 @@
+package admin
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/log"
+	"gnd.la/signal"
+)
+
+const (
+	LogsHandlerName = "admin-logs"
+)
+
+var (
+	// MaxCapturedRecords is the maximum number of records with the
+	// warning level or above from the app logger kept in memory,
+	// which can be inspected with LogsHandler. It must be set before
+	// the app is prepared.
+	MaxCapturedRecords = 500
+
+	LogsHandler = app.NamedHandler(LogsHandlerName, app.SignedIn(logsHandler))
+
+	captured = &logCapture{}
+)
+
+// LogEntry represents a message logged by the app.
+type LogEntry struct {
+	Time      time.Time         `json:"time"`
+	Level     string            `json:"level"`
+	Module    string            `json:"module,omitempty"`
+	RequestId string            `json:"request_id,omitempty"`
+	Message   string            `json:"message"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+// logCapture keeps the last MaxCapturedRecords records logged
+// at the warning level or above by the hooked loggers.
+type logCapture struct {
+	mu      sync.Mutex
+	w       *log.CaptureWriter
+	loggers map[*log.Logger]bool
+}
+
+// hook adds the capture writer to the given logger, unless
+// it was already added.
+func (c *logCapture) hook(logger *log.Logger) {
+	if logger == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loggers[logger] {
+		return
+	}
+	if c.w == nil {
+		c.w = log.NewCaptureWriter(log.LWarning, MaxCapturedRecords)
+	}
+	if c.loggers == nil {
+		c.loggers = make(map[*log.Logger]bool)
+	}
+	c.loggers[logger] = true
+	logger.AddWriter(c.w)
+}
+
+// Entries returns the captured records matching f, most
+// recent first.
+func (c *logCapture) Entries(f *log.RecordFilter) []*LogEntry {
+	c.mu.Lock()
+	w := c.w
+	c.mu.Unlock()
+	entries := []*LogEntry{}
+	if w == nil {
+		return entries
+	}
+	for _, v := range w.Records(f) {
+		entries = append(entries, &LogEntry{
+			Time:      v.Time,
+			Level:     v.Level.String(),
+			Module:    v.Module(),
+			RequestId: v.RequestId(),
+			Message:   v.Message,
+			Fields:    v.Fields,
+		})
+	}
+	return entries
+}
+
+// logsHandler returns the captured records as JSON, most recent
+// first. They might be filtered with the level (minimum level,
+// by name or initial), module, request_id and limit parameters.
+func logsHandler(ctx *app.Context) {
+	if !DefaultPermission(ctx, View, nil) {
+		ctx.Forbidden()
+		return
+	}
+	f := &log.RecordFilter{
+		Level:     log.LWarning,
+		Module:    strings.TrimSpace(ctx.FormValue("module")),
+		RequestId: strings.TrimSpace(ctx.FormValue("request_id")),
+	}
+	if level := strings.TrimSpace(ctx.FormValue("level")); level != "" {
+		l, err := log.ParseLevel(level)
+		if err != nil {
+			ctx.BadRequest(err.Error())
+			return
+		}
+		f.Level = l
+	}
+	if ctx.FormValue("limit") != "" && (!ctx.ParseFormValue("limit", &f.Limit) || f.Limit < 0) {
+		ctx.BadRequest("invalid limit")
+		return
+	}
+	if _, err := ctx.WriteJSON(map[string]interface{}{"records": captured.Entries(f)}); err != nil {
+		panic(err)
+	}
+}
+
+func init() {
+	signal.Listen(app.DID_PREPARE, func(_ string, obj interface{}) {
+		captured.hook(obj.(*app.App).Logger)
+	})
+}
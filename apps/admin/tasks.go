@@ -0,0 +1,301 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/html/paginator"
+	"gnd.la/orm"
+	"gnd.la/signal"
+	"gnd.la/tasks"
+)
+
+const (
+	TasksHandlerName       = "admin-tasks"
+	TaskRunHandlerName     = "admin-task-run"
+	TaskPauseHandlerName   = "admin-task-pause"
+	TaskResumeHandlerName  = "admin-task-resume"
+	TaskHistoryHandlerName = "admin-task-history"
+
+	taskParameterName       = "task"
+	taskParamsParameterName = "params"
+)
+
+var (
+	TasksHandler       = app.NamedHandler(TasksHandlerName, app.SignedIn(tasksHandler))
+	TaskRunHandler     = app.NamedHandler(TaskRunHandlerName, app.SignedIn(taskRunHandler))
+	TaskPauseHandler   = app.NamedHandler(TaskPauseHandlerName, app.SignedIn(taskPauseHandler))
+	TaskResumeHandler  = app.NamedHandler(TaskResumeHandlerName, app.SignedIn(taskResumeHandler))
+	TaskHistoryHandler = app.NamedHandler(TaskHistoryHandlerName, app.SignedIn(taskHistoryHandler))
+
+	taskStateType     = reflect.TypeOf(TaskState{})
+	taskExecutionType = reflect.TypeOf(tasks.Execution{})
+)
+
+// TaskState stores the scheduling changes made to a task from
+// the admin, so they're preserved across restarts. Tasks paused
+// from the admin are stopped again when the app starts. To persist
+// the changes, TaskState must be registered with the ORM e.g.
+//
+//	orm.Register(&admin.TaskState{}, nil)
+//
+// Otherwise, pausing and resuming tasks only affects the running
+// process.
+type TaskState struct {
+	// Name is the task name, as returned by gnd.la/tasks.Task.Name.
+	Name   string `orm:",primary_key"`
+	Paused bool   `orm:",default=false"`
+	// UpdatedBy is the id of the user who made the last change.
+	UpdatedBy int64
+	Updated   time.Time
+}
+
+// TaskInfo represents a task in the tasks view.
+type TaskInfo struct {
+	Name     string
+	Interval time.Duration
+	// Paused is true when the task has an interval
+	// but it's not currently scheduled.
+	Paused  bool
+	Next    time.Time
+	Running int
+	Queued  int
+	Last    *tasks.Result
+	// Params are the typed parameters accepted by the task, if any.
+	Params []*tasks.Param
+	CanRun bool
+}
+
+func taskStateTable(o *orm.Orm) *orm.Table {
+	return o.TypeTable(taskStateType)
+}
+
+// parseTaskParams parses the parameters entered in the
+// task run form, which must be formatted as name=value,
+// one per line. Empty lines and lines starting with #
+// are ignored.
+func parseTaskParams(s string) (map[string]string, error) {
+	var params map[string]string
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid parameter %q, must be in the form name=value", line)
+		}
+		if params == nil {
+			params = make(map[string]string)
+		}
+		params[strings.TrimSpace(line[:eq])] = strings.TrimSpace(line[eq+1:])
+	}
+	return params, nil
+}
+
+// contextTask returns the task indicated by the task form value,
+// if the current user is allowed to run it. Otherwise, it sends
+// the appropriate error and returns nil.
+func contextTask(ctx *app.Context) *tasks.Task {
+	if ctx.R.Method != "POST" {
+		ctx.Error(http.StatusMethodNotAllowed)
+		return nil
+	}
+	if !checkCSRF(ctx) {
+		ctx.Forbidden("invalid CSRF token")
+		return nil
+	}
+	task := tasks.Lookup(ctx.FormValue(taskParameterName))
+	if task == nil {
+		ctx.NotFound("task not found")
+		return nil
+	}
+	if !DefaultPermission(ctx, Edit, task) {
+		ctx.Forbidden()
+		return nil
+	}
+	return task
+}
+
+// renderTasks renders the tasks view. If the parameters for running
+// a task were not valid, failed must be the name of the task.
+func renderTasks(ctx *app.Context, failed string, params string, runError error) {
+	if !DefaultPermission(ctx, View, nil) {
+		ctx.Forbidden()
+		return
+	}
+	registered := tasks.Tasks()
+	infos := make([]*TaskInfo, len(registered))
+	for ii, v := range registered {
+		scheduled := v.IsScheduled()
+		infos[ii] = &TaskInfo{
+			Name:     v.Name(),
+			Interval: v.Interval,
+			Paused:   v.Interval > 0 && !scheduled,
+			Next:     v.Next(),
+			Running:  v.Running(),
+			Queued:   v.Queued(),
+			Last:     v.LastResult(),
+			Params:   v.Params(),
+			CanRun:   DefaultPermission(ctx, Edit, v),
+		}
+	}
+	data := map[string]interface{}{
+		"Tasks":      infos,
+		"Persistent": taskStateTable(ctx.Orm()) != nil,
+		"History":    ctx.Orm().TypeTable(taskExecutionType) != nil,
+		"Failed":     failed,
+		"Params":     params,
+		"Error":      runError,
+		"CSRF":       csrfToken(ctx),
+	}
+	ctx.MustExecute("tasks.html", data)
+}
+
+func tasksHandler(ctx *app.Context) {
+	renderTasks(ctx, "", "", nil)
+}
+
+func taskRunHandler(ctx *app.Context) {
+	task := contextTask(ctx)
+	if task == nil {
+		return
+	}
+	value := ctx.FormValue(taskParamsParameterName)
+	params, err := parseTaskParams(value)
+	if err != nil {
+		renderTasks(ctx, task.Name(), value, fmt.Errorf("can't run task %s: %s", task.Name(), err))
+		return
+	}
+	task.Start(params)
+	ctx.MustRedirectReverse(false, TasksHandlerName)
+}
+
+func taskPauseHandler(ctx *app.Context) {
+	setTaskPaused(ctx, true)
+}
+
+func taskResumeHandler(ctx *app.Context) {
+	setTaskPaused(ctx, false)
+}
+
+func setTaskPaused(ctx *app.Context, paused bool) {
+	task := contextTask(ctx)
+	if task == nil {
+		return
+	}
+	if task.Interval <= 0 {
+		ctx.BadRequest("task is not scheduled")
+		return
+	}
+	if paused {
+		task.Stop()
+	} else {
+		task.Resume(false)
+	}
+	o := ctx.Orm()
+	if taskStateTable(o) != nil {
+		state := &TaskState{
+			Name:    task.Name(),
+			Paused:  paused,
+			Updated: time.Now().UTC(),
+		}
+		if user := ctx.User(); user != nil {
+			state.UpdatedBy = user.Id()
+		}
+		if _, err := o.Upsert(orm.Eq("Name", state.Name), state); err != nil {
+			panic(err)
+		}
+	}
+	ctx.MustRedirectReverse(false, TasksHandlerName)
+}
+
+// historyPager generates the URLs for the task history
+// view, preserving the task filter.
+type historyPager struct {
+	ctx  *app.Context
+	task string
+}
+
+func (p *historyPager) URL(page int) string {
+	var u string
+	if page == 1 {
+		u = p.ctx.MustReverse(TaskHistoryHandlerName)
+	} else {
+		u = p.ctx.MustReverse(TaskHistoryHandlerName, page)
+	}
+	if p.task != "" {
+		u += "?" + taskParameterName + "=" + url.QueryEscape(p.task)
+	}
+	return u
+}
+
+func taskHistoryHandler(ctx *app.Context) {
+	if !DefaultPermission(ctx, View, nil) {
+		ctx.Forbidden()
+		return
+	}
+	task := ctx.FormValue(taskParameterName)
+	count, err := tasks.HistoryCount(ctx, task)
+	if err != nil {
+		panic(err)
+	}
+	perPage := DefaultPerPage
+	var page int
+	ctx.ParseParamValue("page", &page)
+	if page <= 0 {
+		page = 1
+	}
+	pages := (count + perPage - 1) / perPage
+	if page > 1 && page > pages {
+		ctx.NotFound("page not found")
+		return
+	}
+	executions, err := tasks.History(ctx, task, (page-1)*perPage, perPage)
+	if err != nil {
+		panic(err)
+	}
+	data := map[string]interface{}{
+		"Task":       task,
+		"Executions": executions,
+		"Count":      count,
+		"Paginator":  paginator.New(pages, page, &historyPager{ctx: ctx, task: task}),
+	}
+	ctx.MustExecute("task-history.html", data)
+}
+
+// restoreTaskStates stops the tasks belonging to the given app
+// which were paused from the admin.
+func restoreTaskStates(a *app.App) {
+	o, err := a.Orm()
+	if err != nil {
+		return
+	}
+	tbl := taskStateTable(o)
+	if tbl == nil {
+		return
+	}
+	var states []*TaskState
+	if err := o.Table(tbl).Filter(orm.Eq("Paused", true)).All(&states); err != nil {
+		if a.Logger != nil {
+			a.Logger.Errorf("error loading admin task states: %s", err)
+		}
+		return
+	}
+	for _, v := range states {
+		if task := tasks.Lookup(v.Name); task != nil && task.App == a {
+			task.Stop()
+		}
+	}
+}
+
+func init() {
+	signal.Listen(app.DID_PREPARE, func(_ string, obj interface{}) {
+		restoreTaskStates(obj.(*app.App))
+	})
+}
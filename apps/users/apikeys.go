@@ -0,0 +1,405 @@
+package users
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/crypto/hashutil"
+	"gnd.la/i18n"
+	"gnd.la/orm"
+	"gnd.la/orm/operation"
+	"gnd.la/util/stringutil"
+)
+
+const (
+	// APIKeyHeaderName is the header which might be used to send
+	// an API key. Alternatively, keys might also be sent using the
+	// Authorization header with the Bearer scheme.
+	APIKeyHeaderName = "X-API-Key"
+	// APIKeyAllScopes is a scope which grants access to all the
+	// scopes.
+	APIKeyAllScopes = "*"
+
+	apiKeyPrefixLength = 8
+	apiKeySecretLength = 32
+	apiKeyContextKey   = "__users_api_key"
+)
+
+var (
+	// APIKeyRateLimit is the default number of requests per minute
+	// allowed for new API keys. Zero means no limit.
+	APIKeyRateLimit = 0
+	// MaxAPIKeysPerUser is the maximum number of non-revoked keys
+	// a user might have. Zero means no limit.
+	MaxAPIKeysPerUser = 10
+
+	ErrInvalidAPIKey  = i18n.NewError("invalid API key")
+	ErrTooManyAPIKeys = i18n.NewError("too many API keys")
+
+	apiKeyType = reflect.TypeOf(APIKey{})
+
+	APIKeysHandler      = app.NamedHandler(APIKeysHandlerName, app.SignedIn(apiKeysHandler))
+	APIKeyRevokeHandler = app.NamedHandler(APIKeyRevokeHandlerName, app.SignedIn(apiKeyRevokeHandler))
+)
+
+const (
+	APIKeysHandlerName      = "users-api-keys"
+	APIKeyRevokeHandlerName = "users-api-key-revoke"
+)
+
+// APIKey represents an API key which can be used to authenticate
+// a user without cookies. The key itself is never stored, only its
+// hash. To use API keys, the APIKey type must be registered with the
+// ORM e.g.
+//
+//	orm.Register(&users.APIKey{}, nil)
+type APIKey struct {
+	Id     int64 `orm:",primary_key,auto_increment" json:"id"`
+	UserId int64 `orm:",index" json:"user"`
+	// Name is an optional user provided name, to help users
+	// identify their keys.
+	Name string `json:"name"`
+	// Prefix is the non-secret part of the key, used to look it
+	// up without storing the key itself.
+	Prefix string `orm:",unique" json:"prefix"`
+	// Hash is the SHA256 hash of the full key.
+	Hash   string   `json:"-"`
+	Scopes []string `orm:",codec=json" json:"scopes"`
+	// RateLimit is the number of requests per minute which
+	// can be made with this key. Zero means no limit.
+	RateLimit   int       `json:"rate_limit"`
+	Created     time.Time `json:"created"`
+	Expires     time.Time `orm:",omitempty,nullempty" json:"expires"`
+	LastUsed    time.Time `orm:",omitempty,nullempty" json:"last_used"`
+	LastAddress string    `orm:",omitempty,nullempty" json:"last_address"`
+	Uses        int64     `orm:",default=0" json:"uses"`
+	Revoked     bool      `orm:",default=false" json:"revoked"`
+}
+
+// HasScope returns true iff the key has been granted
+// the given scope.
+func (k *APIKey) HasScope(scope string) bool {
+	for _, v := range k.Scopes {
+		if v == scope || v == APIKeyAllScopes {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired returns true iff the key has an expiration
+// time and it has already passed.
+func (k *APIKey) IsExpired() bool {
+	return !k.Expires.IsZero() && time.Now().After(k.Expires)
+}
+
+// IsValid returns true iff the key is not revoked nor expired.
+func (k *APIKey) IsValid() bool {
+	return !k.Revoked && !k.IsExpired()
+}
+
+func apiKeyTable(ctx *app.Context) *orm.Table {
+	tbl := ctx.Orm().TypeTable(apiKeyType)
+	if tbl == nil {
+		panic(fmt.Errorf("API key type %s is not registered with the orm - add orm.Register(&users.APIKey{}, nil) somewhere in your app", apiKeyType))
+	}
+	return tbl
+}
+
+func hashAPIKey(key string) string {
+	return hashutil.Sha256(key)
+}
+
+// NewAPIKey creates a new API key for the given user, with the given
+// name, scopes and expiration time (use the zero time.Time for keys
+// which never expire). The returned string is the key which must be
+// given to the user. Note that it can't be recovered afterwards, since
+// only its hash is stored.
+func NewAPIKey(ctx *app.Context, userId int64, name string, scopes []string, expires time.Time) (*APIKey, string, error) {
+	tbl := apiKeyTable(ctx)
+	o := ctx.Orm()
+	if MaxAPIKeysPerUser > 0 {
+		count, err := o.Count(tbl, orm.And(orm.Eq("UserId", userId), orm.Eq("Revoked", false)))
+		if err != nil {
+			return nil, "", err
+		}
+		if count >= uint64(MaxAPIKeysPerUser) {
+			return nil, "", ErrTooManyAPIKeys
+		}
+	}
+	prefix := stringutil.Random(apiKeyPrefixLength)
+	key := prefix + "." + stringutil.Random(apiKeySecretLength)
+	apiKey := &APIKey{
+		UserId:    userId,
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      hashAPIKey(key),
+		Scopes:    scopes,
+		RateLimit: APIKeyRateLimit,
+		Created:   time.Now().UTC(),
+	}
+	if !expires.IsZero() {
+		apiKey.Expires = expires.UTC()
+	}
+	if _, err := o.Insert(apiKey); err != nil {
+		return nil, "", err
+	}
+	return apiKey, key, nil
+}
+
+// APIKeys returns all the API keys for the given user,
+// including the revoked ones.
+func APIKeys(ctx *app.Context, userId int64) ([]*APIKey, error) {
+	var keys []*APIKey
+	q := ctx.Orm().Table(apiKeyTable(ctx)).Filter(orm.Eq("UserId", userId)).Sort("Id", orm.DESC)
+	if err := q.All(&keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes the API key with the given id. If userId is
+// non-zero, the key is only revoked if it belongs to that user. The
+// returned boolean indicates if a key was revoked.
+func RevokeAPIKey(ctx *app.Context, id int64, userId int64) (bool, error) {
+	q := orm.Eq("Id", id)
+	if userId != 0 {
+		q = orm.And(q, orm.Eq("UserId", userId))
+	}
+	res, err := ctx.Orm().Operate(apiKeyTable(ctx), q, operation.Set("Revoked", true))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// SetAPIKeyRateLimit changes the rate limit (in requests per minute)
+// for the given key. Zero means no limit.
+func SetAPIKeyRateLimit(ctx *app.Context, id int64, limit int) error {
+	_, err := ctx.Orm().Operate(apiKeyTable(ctx), orm.Eq("Id", id), operation.Set("RateLimit", limit))
+	return err
+}
+
+// FindAPIKey returns the APIKey for the given key, if it exists
+// and it's valid (not revoked nor expired).
+func FindAPIKey(ctx *app.Context, key string) (*APIKey, error) {
+	dot := strings.IndexByte(key, '.')
+	if dot != apiKeyPrefixLength {
+		return nil, ErrInvalidAPIKey
+	}
+	var apiKey *APIKey
+	ok, err := ctx.Orm().Table(apiKeyTable(ctx)).Filter(orm.Eq("Prefix", key[:dot])).One(&apiKey)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || subtle.ConstantTimeCompare([]byte(apiKey.Hash), []byte(hashAPIKey(key))) != 1 || !apiKey.IsValid() {
+		return nil, ErrInvalidAPIKey
+	}
+	return apiKey, nil
+}
+
+// CurrentAPIKey returns the API key used to authenticate the current
+// request, or nil if the request was not authenticated with an API key.
+func CurrentAPIKey(ctx *app.Context) *APIKey {
+	k, _ := ctx.Get(apiKeyContextKey).(*APIKey)
+	return k
+}
+
+func requestAPIKey(ctx *app.Context) string {
+	if key := ctx.GetHeader(APIKeyHeaderName); key != "" {
+		return key
+	}
+	fields := strings.SplitN(ctx.GetHeader("Authorization"), " ", 2)
+	if len(fields) == 2 && fields[0] == "Bearer" {
+		return strings.TrimSpace(fields[1])
+	}
+	return ""
+}
+
+// apiKeyRateLimited increments the request counter for the current
+// minute and returns true if the key has exceeded its rate limit.
+func apiKeyRateLimited(ctx *app.Context, k *APIKey) bool {
+	if k.RateLimit <= 0 {
+		return false
+	}
+	minute := time.Now().Unix() / 60
+	key := "users-api-key-rate-" + strconv.FormatInt(k.Id, 36) + "-" + strconv.FormatInt(minute, 36)
+	count, err := ctx.Cache().Inc(key, 1, 60)
+	if err != nil {
+		ctx.Logger().Errorf("error storing rate limit for API key %d: %s", k.Id, err)
+		return false
+	}
+	return count > int64(k.RateLimit)
+}
+
+func touchAPIKey(ctx *app.Context, k *APIKey) {
+	now := time.Now().UTC()
+	addr := ctx.RemoteAddress()
+	_, err := ctx.Orm().Operate(apiKeyTable(ctx), orm.Eq("Id", k.Id),
+		operation.Set("LastUsed", now), operation.Set("LastAddress", addr), operation.Inc("Uses"))
+	if err != nil {
+		ctx.Logger().Errorf("error updating API key %d usage: %s", k.Id, err)
+		return
+	}
+	k.LastUsed = now
+	k.LastAddress = addr
+	k.Uses++
+}
+
+// APIKeyRequired returns a new Handler which requires the request
+// to be authenticated with a valid API key, sent either in the
+// X-API-Key header or using the Bearer scheme in the Authorization
+// header. The key must have been granted all the given scopes.
+// If the key is valid, its owner is set as the current user for
+// the request (see gnd.la/app.Context.User) and the key can be
+// retrieved with CurrentAPIKey.
+//
+// Requests without a valid key receive a 401 response, while keys
+// without the required scopes receive a 403 and keys which exceed
+// their rate limit receive a 429.
+func APIKeyRequired(handler app.Handler, scopes ...string) app.Handler {
+	return func(ctx *app.Context) {
+		h := ctx.Header()
+		h.Add("Vary", "Authorization")
+		h.Add("Vary", APIKeyHeaderName)
+		h.Add("Cache-Control", "private")
+		key := requestAPIKey(ctx)
+		if key == "" {
+			h.Set("WWW-Authenticate", "Bearer")
+			ctx.Error(http.StatusUnauthorized)
+			return
+		}
+		apiKey, err := FindAPIKey(ctx, key)
+		if err != nil {
+			if err != ErrInvalidAPIKey {
+				panic(err)
+			}
+			h.Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			ctx.Error(http.StatusUnauthorized, ErrInvalidAPIKey.TranslatedError(ctx))
+			return
+		}
+		for _, v := range scopes {
+			if !apiKey.HasScope(v) {
+				ctx.Forbiddenf("API key does not have the %q scope", v)
+				return
+			}
+		}
+		if apiKeyRateLimited(ctx, apiKey) {
+			h.Set("Retry-After", strconv.FormatInt(60-time.Now().Unix()%60, 10))
+			ctx.Error(http.StatusTooManyRequests)
+			return
+		}
+		user, userVal := newEmptyUser()
+		if !ctx.Orm().MustOne(ById(apiKey.UserId), userVal) {
+			ctx.Error(http.StatusUnauthorized, ErrInvalidAPIKey.TranslatedError(ctx))
+			return
+		}
+		touchAPIKey(ctx, apiKey)
+		ctx.Set(apiKeyContextKey, apiKey)
+		ctx.SetUser(asGondolaUser(user))
+		handler(ctx)
+	}
+}
+
+// apiKeysUserId returns the user id whose keys are being managed.
+// Admins might manage other users' keys using the user parameter.
+func apiKeysUserId(ctx *app.Context) (int64, bool) {
+	user := ctx.User()
+	if v := ctx.FormValue("user"); v != "" {
+		if !user.IsAdmin() {
+			return 0, false
+		}
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return id, true
+	}
+	return user.Id(), true
+}
+
+// apiKeysHandler lists the current user's keys for GET requests and
+// creates a new key for POST requests. Only the response for the
+// creation includes the key itself. Creating a key requires the
+// CSRF token (see CSRFToken).
+func apiKeysHandler(ctx *app.Context) {
+	userId, ok := apiKeysUserId(ctx)
+	if !ok {
+		ctx.Forbidden()
+		return
+	}
+	if ctx.R.Method == "POST" {
+		if !checkCSRF(ctx) {
+			ctx.Forbidden("invalid CSRF token")
+			return
+		}
+		var scopes []string
+		for _, v := range strings.Split(ctx.FormValue("scopes"), ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				scopes = append(scopes, v)
+			}
+		}
+		var expires time.Time
+		var days int
+		if ctx.ParseFormValue("expires", &days) && days > 0 {
+			expires = time.Now().Add(time.Duration(days) * 24 * time.Hour)
+		}
+		apiKey, key, err := NewAPIKey(ctx, userId, ctx.FormValue("name"), scopes, expires)
+		if err != nil {
+			if err == ErrTooManyAPIKeys {
+				ctx.BadRequest(ErrTooManyAPIKeys.TranslatedError(ctx))
+				return
+			}
+			panic(err)
+		}
+		ctx.WriteJSON(map[string]interface{}{
+			"key":     key,
+			"api_key": apiKey,
+		})
+		return
+	}
+	keys, err := APIKeys(ctx, userId)
+	if err != nil {
+		panic(err)
+	}
+	ctx.WriteJSON(keys)
+}
+
+func apiKeyRevokeHandler(ctx *app.Context) {
+	if ctx.R.Method != "POST" {
+		ctx.Error(http.StatusMethodNotAllowed)
+		return
+	}
+	if !checkCSRF(ctx) {
+		ctx.Forbidden("invalid CSRF token")
+		return
+	}
+	var id int64
+	if !ctx.ParseFormValue("id", &id) {
+		ctx.BadRequest()
+		return
+	}
+	var userId int64
+	if !ctx.User().IsAdmin() {
+		userId = ctx.User().Id()
+	}
+	revoked, err := RevokeAPIKey(ctx, id, userId)
+	if err != nil {
+		panic(err)
+	}
+	if !revoked {
+		ctx.NotFound()
+		return
+	}
+	ctx.WriteJSON(map[string]interface{}{"revoked": id})
+}
@@ -0,0 +1,39 @@
+package users
+
+import (
+	"gnd.la/app"
+	"gnd.la/internal/csrf"
+)
+
+const (
+	// CSRF_COOKIE_NAME is the name of the cookie used to store
+	// the CSRF token for the users handlers which change state.
+	// The cookie is signed using the gnd.la/app.App secret.
+	CSRF_COOKIE_NAME = "users-csrf"
+
+	// CSRFHeaderName is the header which might be used to submit
+	// the CSRF token instead of the csrf form parameter, e.g. from
+	// JavaScript.
+	CSRFHeaderName = "X-CSRF-Token"
+
+	csrfParameterName = "csrf"
+)
+
+// CSRFToken returns the CSRF token for the current user, generating
+// a new one if it doesn't have one yet. Requests to the handlers which
+// change state (e.g. revoking a session or an API key) must submit it
+// either as the csrf form parameter or in the X-CSRF-Token header. It's
+// available in templates as @CSRF.
+func CSRFToken(ctx *app.Context) string {
+	return csrf.Token(ctx, CSRF_COOKIE_NAME)
+}
+
+// checkCSRF returns true iff the submitted CSRF token matches
+// the one in the user cookie.
+func checkCSRF(ctx *app.Context) bool {
+	submitted := ctx.GetHeader(CSRFHeaderName)
+	if submitted == "" {
+		submitted = ctx.FormValue(csrfParameterName)
+	}
+	return csrf.Check(ctx, CSRF_COOKIE_NAME, submitted)
+}
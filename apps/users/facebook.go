@@ -58,7 +58,7 @@ func signInFacebookTokenHandler(ctx *app.Context, client *oauth2.Client, token *
 	if err != nil {
 		panic(err)
 	}
-	ctx.MustSignIn(asGondolaUser(user))
+	mustSignIn(ctx, asGondolaUser(user))
 	redirectToFrom(ctx)
 }
 
@@ -77,7 +77,7 @@ func jsSignInFacebookHandler(ctx *app.Context) {
 	if err != nil {
 		panic(err)
 	}
-	ctx.MustSignIn(asGondolaUser(user))
+	mustSignIn(ctx, asGondolaUser(user))
 	writeJSONEncoded(ctx, user)
 }
 
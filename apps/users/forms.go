@@ -58,6 +58,9 @@ type SignIn struct {
 }
 
 func (s *SignIn) ValidateUsername(ctx *app.Context) error {
+	if err := checkSignInAddress(ctx, s.Username); err != nil {
+		return err
+	}
 	norm := Normalize(s.Username)
 	_, userVal := newEmptyUser()
 	var ok bool
@@ -73,8 +76,12 @@ func (s *SignIn) ValidateUsername(ctx *app.Context) error {
 		}
 	}
 	if !ok {
+		signInFailed(ctx, 0, s.Username)
 		return ErrNoUser
 	}
+	if err := checkSignInAccount(ctx, asGondolaUser(reflect.ValueOf(userVal)).Id(), s.Username); err != nil {
+		return err
+	}
 	s.User = userVal
 	return nil
 }
@@ -86,8 +93,15 @@ func (s *SignIn) ValidatePassword(ctx *app.Context) error {
 			return ErrNoPassword
 		}
 		if pw.Check(s.Password) != nil {
+			signInFailed(ctx, asGondolaUser(reflect.ValueOf(s.User)).Id(), s.Username)
 			return ErrInvalidPassword
 		}
 	}
 	return nil
 }
+
+// signedIn records a successful sign in and clears the
+// failed attempts for the user account.
+func (s *SignIn) signedIn(ctx *app.Context) {
+	signInSucceeded(ctx, asGondolaUser(reflect.ValueOf(s.User)).Id(), s.Username)
+}
@@ -17,6 +17,11 @@ var (
 func Func(ctx *app.Context, id int64) app.User {
 	user, _ := Get(ctx, id)
 	if user != nil {
+		if !checkSession(ctx, id) {
+			ctx.SignOut()
+			ctx.Cookies().Delete(SESSION_COOKIE_NAME)
+			return nil
+		}
 		return user
 	}
 	return nil
@@ -25,30 +25,44 @@ func init() {
 	App.SetAssetsManager(manager)
 	App.Handle("^"+prefix, app.HandlerFromHTTPFunc(manager.Handler()))
 	App.AddTemplateVars(map[string]interface{}{
-		"SignInFacebook":      SignInFacebookHandlerName,
-		"User":                Current,
-		"SocialTypes":         enabledSocialTypes,
-		"JSSignInGoogle":      JSSignInGoogleHandlerName,
-		"SignIn":              func() string { return SignInHandlerName },
-		"TwitterApp":          func() interface{} { return TwitterApp },
-		"JSSignIn":            JSSignInHandlerName,
-		"JSSignInFacebook":    JSSignInFacebookHandlerName,
-		"Reset":               ResetHandlerName,
-		"SignInGoogle":        SignInGoogleHandlerName,
-		"SignInGithub":        SignInGithubHandlerName,
-		"FacebookPermissions": func() []string { return FacebookPermissions },
-		"GoogleScopes":        func() []string { return GoogleScopes },
-		"AllowUserSignIn":     func() bool { return AllowUserSignIn },
-		"SignOut":             SignOutHandlerName,
-		"FacebookChannel":     FacebookChannelHandlerName,
-		"FacebookApp":         func() interface{} { return FacebookApp },
-		"SignUp":              SignUpHandlerName,
-		"GithubApp":           func() interface{} { return GithubApp },
-		"JSSignUp":            JSSignUpHandlerName,
-		"Forgot":              ForgotHandlerName,
-		"SignInTwitter":       SignInTwitterHandlerName,
-		"SiteName":            func() string { return SiteName },
-		"GoogleApp":           func() interface{} { return GoogleApp },
+		"SignInFacebook":       SignInFacebookHandlerName,
+		"User":                 Current,
+		"SocialTypes":          enabledSocialTypes,
+		"JSSignInGoogle":       JSSignInGoogleHandlerName,
+		"SignIn":               func() string { return SignInHandlerName },
+		"TwitterApp":           func() interface{} { return TwitterApp },
+		"JSSignIn":             JSSignInHandlerName,
+		"JSSignInFacebook":     JSSignInFacebookHandlerName,
+		"Reset":                ResetHandlerName,
+		"SignInGoogle":         SignInGoogleHandlerName,
+		"SignInGithub":         SignInGithubHandlerName,
+		"FacebookPermissions":  func() []string { return FacebookPermissions },
+		"GoogleScopes":         func() []string { return GoogleScopes },
+		"AllowUserSignIn":      func() bool { return AllowUserSignIn },
+		"SignOut":              SignOutHandlerName,
+		"FacebookChannel":      FacebookChannelHandlerName,
+		"FacebookApp":          func() interface{} { return FacebookApp },
+		"SignUp":               SignUpHandlerName,
+		"GithubApp":            func() interface{} { return GithubApp },
+		"JSSignUp":             JSSignUpHandlerName,
+		"Forgot":               ForgotHandlerName,
+		"SignInTwitter":        SignInTwitterHandlerName,
+		"SiteName":             func() string { return SiteName },
+		"GoogleApp":            func() interface{} { return GoogleApp },
+		"APIKeys":              APIKeysHandlerName,
+		"APIKeyRevoke":         APIKeyRevokeHandlerName,
+		"Impersonate":          ImpersonateHandlerName,
+		"StopImpersonate":      StopImpersonateHandlerName,
+		"Sessions":             SessionsHandlerName,
+		"SessionRevoke":        SessionRevokeHandlerName,
+		"Impersonator":         Impersonator,
+		"ImpersonationBanner":  ImpersonationBanner,
+		"CSRF":                 CSRFToken,
+		"Notifications":        NotificationsHandlerName,
+		"NotificationsRead":    NotificationsReadHandlerName,
+		"NotificationsEvents":  NotificationsEventsHandlerName,
+		"CurrentNotifications": currentNotifications,
+		"UnreadNotifications":  currentUnreadNotifications,
 	})
 	App.HandleOptions("^/sign-in/$", SignInHandler.Handler, SignInHandler.Options)
 	App.HandleOptions("^/sign-in/facebook/$", SignInFacebookHandler.Handler, SignInFacebookHandler.Options)
@@ -65,6 +79,15 @@ func init() {
 	App.HandleOptions("^/sign-out/$", SignOutHandler.Handler, SignOutHandler.Options)
 	App.HandleOptions("^/forgot/$", ForgotHandler.Handler, ForgotHandler.Options)
 	App.HandleOptions("^/reset/$", ResetHandler.Handler, ResetHandler.Options)
+	App.HandleOptions("^/api-keys/$", APIKeysHandler.Handler, APIKeysHandler.Options)
+	App.HandleOptions("^/api-keys/revoke/$", APIKeyRevokeHandler.Handler, APIKeyRevokeHandler.Options)
+	App.HandleOptions("^/impersonate/$", ImpersonateHandler.Handler, ImpersonateHandler.Options)
+	App.HandleOptions("^/impersonate/stop/$", StopImpersonateHandler.Handler, StopImpersonateHandler.Options)
+	App.HandleOptions("^/sessions/$", SessionsHandler.Handler, SessionsHandler.Options)
+	App.HandleOptions("^/sessions/revoke/$", SessionRevokeHandler.Handler, SessionRevokeHandler.Options)
+	App.HandleOptions("^/notifications/$", NotificationsHandler.Handler, NotificationsHandler.Options)
+	App.HandleOptions("^/notifications/read/$", NotificationsReadHandler.Handler, NotificationsReadHandler.Options)
+	App.HandleOptions("^/notifications/events/$", NotificationsEventsHandler.Handler, NotificationsEventsHandler.Options)
 	template.AddFuncs(template.FuncMap{
 		"__users_get_social": getSocial,
 		"user_image":         Image,
@@ -52,7 +52,7 @@ func signInGoogleTokenHandler(ctx *app.Context, client *oauth2.Client, token *oa
 	if err != nil {
 		panic(err)
 	}
-	ctx.MustSignIn(asGondolaUser(user))
+	mustSignIn(ctx, asGondolaUser(user))
 	redirectToFrom(ctx)
 }
 
@@ -67,7 +67,7 @@ func jsSignInGoogleHandler(ctx *app.Context) {
 	if err != nil {
 		panic(err)
 	}
-	ctx.MustSignIn(asGondolaUser(user))
+	mustSignIn(ctx, asGondolaUser(user))
 	writeJSONEncoded(ctx, user)
 }
 
@@ -59,7 +59,7 @@ var (
 	SignInTwitterHandler    = app.NamedHandler(SignInTwitterHandlerName, app.Anonymous(signInTwitterHandler))
 	SignInGithubHandler     = app.NamedHandler(SignInGithubHandlerName, app.Anonymous(signInGithubHandler))
 	SignUpHandler           = app.NamedHandler(SignUpHandlerName, app.Anonymous(signUpHandler))
-	SignOutHandler          = app.NamedHandler(SignOutHandlerName, app.SignOutHandler)
+	SignOutHandler          = app.NamedHandler(SignOutHandlerName, signOutHandler)
 	ForgotHandler           = app.NamedHandler(ForgotHandlerName, app.Anonymous(forgotHandler))
 	ResetHandler            = app.NamedHandler(ResetHandlerName, resetHandler)
 	JSSignInHandler         = app.NamedHandler(JSSignInHandlerName, app.Anonymous(jsSignInHandler))
@@ -82,7 +82,8 @@ func signInHandler(ctx *app.Context) {
 	signIn := SignIn{From: from}
 	form := form.New(ctx, &signIn)
 	if AllowUserSignIn && form.Submitted() && form.IsValid() {
-		ctx.MustSignIn(asGondolaUser(reflect.ValueOf(signIn.User)))
+		signIn.signedIn(ctx)
+		mustSignIn(ctx, asGondolaUser(reflect.ValueOf(signIn.User)))
 		ctx.RedirectBack()
 		return
 	}
@@ -110,8 +111,9 @@ func jsSignInHandler(ctx *app.Context) {
 	signIn := SignIn{}
 	form := form.New(ctx, &signIn)
 	if form.Submitted() && form.IsValid() {
+		signIn.signedIn(ctx)
 		user := reflect.ValueOf(signIn.User)
-		ctx.MustSignIn(asGondolaUser(user))
+		mustSignIn(ctx, asGondolaUser(user))
 		writeJSONEncoded(ctx, user)
 		return
 	}
@@ -286,7 +288,7 @@ func resetHandler(ctx *app.Context) {
 		f = form.New(ctx, passwordForm)
 		if f.Submitted() && f.IsValid() {
 			ctx.Orm().MustSave(user.Interface())
-			ctx.MustSignIn(asGondolaUser(user))
+			mustSignIn(ctx, asGondolaUser(user))
 			done = true
 		}
 	}
@@ -318,7 +320,7 @@ func saveNewUser(ctx *app.Context, user reflect.Value) {
 	setUserValue(user, "Password", password.New(string(getUserValue(user, "Password").(password.Password))))
 	setUserValue(user, "Created", time.Now().UTC())
 	ctx.Orm().MustInsert(user.Interface())
-	ctx.MustSignIn(asGondolaUser(user))
+	mustSignIn(ctx, asGondolaUser(user))
 }
 
 func delayedHandler(f func() app.Handler) app.Handler {
@@ -343,7 +345,7 @@ func delayedHandler(f func() app.Handler) app.Handler {
 func windowCallbackHandler(ctx *app.Context, user reflect.Value, callback string) {
 	inWindow := ctx.FormValue("window") != ""
 	if user.IsValid() {
-		ctx.MustSignIn(asGondolaUser(user))
+		mustSignIn(ctx, asGondolaUser(user))
 	}
 	if inWindow {
 		var payload []byte
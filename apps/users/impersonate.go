@@ -0,0 +1,225 @@
+package users
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"reflect"
+
+	"gnd.la/app"
+	"gnd.la/i18n"
+)
+
+const (
+	// IMPERSONATOR_COOKIE_NAME is the name of the cookie used to store
+	// the real operator while impersonating another user. The cookie is
+	// signed using the gnd.la/app.App secret.
+	IMPERSONATOR_COOKIE_NAME = "users-impersonator"
+
+	ImpersonateHandlerName     = "users-impersonate"
+	StopImpersonateHandlerName = "users-impersonate-stop"
+
+	impersonatorContextKey = "__users_impersonator"
+)
+
+var (
+	// AllowImpersonatingAdmins indicates if admins can impersonate
+	// other admins. It's false by default.
+	AllowImpersonatingAdmins = false
+
+	ErrCantImpersonate = i18n.NewError("this user can't be impersonated")
+
+	ImpersonateHandler     = app.NamedHandler(ImpersonateHandlerName, app.SignedIn(impersonateHandler))
+	StopImpersonateHandler = app.NamedHandler(StopImpersonateHandlerName, app.SignedIn(stopImpersonateHandler))
+)
+
+// impersonation is stored in the impersonator cookie. The
+// impersonated user id is also stored, so the impersonation
+// is only considered active while that user is signed in.
+type impersonation struct {
+	Operator int64
+	User     int64
+}
+
+// impersonatorCache is stored in the context, to avoid
+// looking up the impersonator multiple times per request.
+type impersonatorCache struct {
+	operator app.User
+}
+
+func currentImpersonation(ctx *app.Context) *impersonation {
+	var imp impersonation
+	if err := ctx.Cookies().GetSecure(IMPERSONATOR_COOKIE_NAME, &imp); err != nil {
+		return nil
+	}
+	if user := ctx.User(); user == nil || user.Id() != imp.User {
+		return nil
+	}
+	return &imp
+}
+
+// Impersonator returns the real user (the operator) when the current
+// user is being impersonated by an admin. Otherwise, it returns nil.
+func Impersonator(ctx *app.Context) app.User {
+	if c, ok := ctx.Get(impersonatorContextKey).(*impersonatorCache); ok {
+		return c.operator
+	}
+	c := &impersonatorCache{}
+	if imp := currentImpersonation(ctx); imp != nil {
+		if user, err := Get(ctx, imp.Operator); err == nil && user.IsAdmin() {
+			c.operator = user
+		}
+	}
+	ctx.Set(impersonatorContextKey, c)
+	return c.operator
+}
+
+// IsImpersonating returns true iff the current user is being
+// impersonated by an admin.
+func IsImpersonating(ctx *app.Context) bool {
+	return Impersonator(ctx) != nil
+}
+
+// Impersonate switches the session of the current user, which must be an
+// admin, to the user with the given id. The real user is recorded, so it
+// can be restored by calling StopImpersonating.
+func Impersonate(ctx *app.Context, id int64) error {
+	operator := ctx.User()
+	if operator == nil || !operator.IsAdmin() || IsImpersonating(ctx) || operator.Id() == id {
+		return ErrCantImpersonate
+	}
+	target, err := Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if target.IsAdmin() && !AllowImpersonatingAdmins {
+		return ErrCantImpersonate
+	}
+	imp := &impersonation{Operator: operator.Id(), User: target.Id()}
+	if err := ctx.Cookies().SetSecure(IMPERSONATOR_COOKIE_NAME, imp); err != nil {
+		return err
+	}
+	if err := ctx.SignIn(target); err != nil {
+		return err
+	}
+	ctx.Set(impersonatorContextKey, &impersonatorCache{operator})
+	recordSecurityEvent(ctx, &SecurityEvent{Type: SecurityEventImpersonationStart, UserId: target.Id(), OperatorId: operator.Id()})
+	return nil
+}
+
+// StopImpersonating restores the session of the real user. If
+// the current user is not being impersonated, it does nothing.
+func StopImpersonating(ctx *app.Context) error {
+	operator := Impersonator(ctx)
+	if operator == nil {
+		return nil
+	}
+	userId := ctx.User().Id()
+	clearImpersonation(ctx)
+	if err := ctx.SignIn(operator); err != nil {
+		return err
+	}
+	recordSecurityEvent(ctx, &SecurityEvent{Type: SecurityEventImpersonationStop, UserId: userId, OperatorId: operator.Id()})
+	return nil
+}
+
+// clearImpersonation removes the impersonator cookie and
+// the cached impersonator for the current request.
+func clearImpersonation(ctx *app.Context) {
+	ctx.Cookies().Delete(IMPERSONATOR_COOKIE_NAME)
+	ctx.Set(impersonatorContextKey, &impersonatorCache{})
+}
+
+// AuditImpersonation is a gnd.la/app.ContextFinalizer which records
+// a security event for every request made while impersonating a user.
+// To enable it, add it to your App e.g.
+//
+//	App.AddContextFinalizer(users.AuditImpersonation)
+func AuditImpersonation(ctx *app.Context) {
+	if ctx.R == nil {
+		return
+	}
+	if operator := Impersonator(ctx); operator != nil {
+		recordSecurityEvent(ctx, &SecurityEvent{
+			Type:       SecurityEventImpersonatedRequest,
+			UserId:     ctx.User().Id(),
+			OperatorId: operator.Id(),
+			Request:    ctx.R.Method + " " + ctx.R.URL.String(),
+		})
+	}
+}
+
+// ImpersonationBanner returns a banner to be displayed at the top of
+// the page while the current user is being impersonated, or an empty
+// string otherwise. It's available in templates as @ImpersonationBanner.
+func ImpersonationBanner(ctx *app.Context) template.HTML {
+	operator := Impersonator(ctx)
+	if operator == nil {
+		return ""
+	}
+	stop, err := ctx.Reverse(StopImpersonateHandlerName)
+	if err != nil {
+		return ""
+	}
+	message := fmt.Sprintf(ctx.T("You're viewing the site as %s."), displayName(ctx.User()))
+	return template.HTML(fmt.Sprintf(`<div class="users-impersonation-banner">%s <form method="post" action="%s"><input type="hidden" name="%s" value="%s"><button type="submit">%s</button></form></div>`,
+		template.HTMLEscapeString(message), template.HTMLEscapeString(stop), csrfParameterName, template.HTMLEscapeString(CSRFToken(ctx)),
+		template.HTMLEscapeString(ctx.T("Stop impersonating"))))
+}
+
+func displayName(user app.User) string {
+	if u, ok := getUserValue(reflect.ValueOf(user), "User").(User); ok {
+		return u.Username
+	}
+	return fmt.Sprintf("#%d", user.Id())
+}
+
+// impersonateHandler starts impersonating the user indicated by
+// the user parameter. Both impersonateHandler and stopImpersonateHandler
+// only accept POST requests with a valid CSRF token (see CSRFToken).
+func impersonateHandler(ctx *app.Context) {
+	if ctx.R.Method != "POST" {
+		ctx.Error(http.StatusMethodNotAllowed)
+		return
+	}
+	if !checkCSRF(ctx) {
+		ctx.Forbidden("invalid CSRF token")
+		return
+	}
+	if !ctx.User().IsAdmin() {
+		ctx.Forbidden()
+		return
+	}
+	var id int64
+	if !ctx.ParseFormValue("user", &id) {
+		ctx.BadRequest()
+		return
+	}
+	if err := Impersonate(ctx, id); err != nil {
+		if err == errNoSuchUser {
+			ctx.NotFound()
+			return
+		}
+		if err == ErrCantImpersonate {
+			ctx.Forbidden(ErrCantImpersonate.TranslatedError(ctx))
+			return
+		}
+		panic(err)
+	}
+	redirectToFrom(ctx)
+}
+
+func stopImpersonateHandler(ctx *app.Context) {
+	if ctx.R.Method != "POST" {
+		ctx.Error(http.StatusMethodNotAllowed)
+		return
+	}
+	if !checkCSRF(ctx) {
+		ctx.Forbidden("invalid CSRF token")
+		return
+	}
+	if err := StopImpersonating(ctx); err != nil {
+		panic(err)
+	}
+	redirectToFrom(ctx)
+}
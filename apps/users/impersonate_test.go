@@ -0,0 +1,103 @@
+package users
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func newImpersonateTest(t *testing.T) (admin int64, other int64, user int64) {
+	return newSiteUser(t, t.Name()+"-admin", true),
+		newSiteUser(t, t.Name()+"-other", true),
+		newSiteUser(t, t.Name()+"-user", false)
+}
+
+func impersonateForm(c *testClient, id int64) url.Values {
+	return url.Values{"user": {strconv.FormatInt(id, 10)}, "csrf": {c.csrf()}}
+}
+
+func TestImpersonatePermissions(t *testing.T) {
+	admin, other, user := newImpersonateTest(t)
+	c := newTestClient(t, user)
+	c.expect("POST", "/impersonate/", impersonateForm(c, admin), http.StatusForbidden)
+	if u, op := c.whoami(); u != user || op != 0 {
+		t.Errorf("expecting user %d without impersonator, got %d and %d", user, u, op)
+	}
+	c = newTestClient(t, admin)
+	c.expect("POST", "/impersonate/", impersonateForm(c, admin), http.StatusForbidden)
+	c.expect("POST", "/impersonate/", impersonateForm(c, other), http.StatusForbidden)
+	if u, op := c.whoami(); u != admin || op != 0 {
+		t.Errorf("expecting user %d without impersonator, got %d and %d", admin, u, op)
+	}
+	AllowImpersonatingAdmins = true
+	defer func() { AllowImpersonatingAdmins = false }()
+	c.expect("POST", "/impersonate/", impersonateForm(c, other), http.StatusFound)
+	if u, op := c.whoami(); u != other || op != admin {
+		t.Errorf("expecting user %d impersonated by %d, got %d and %d", other, admin, u, op)
+	}
+	// Can't impersonate while impersonating
+	c.expect("POST", "/impersonate/", impersonateForm(c, user), http.StatusForbidden)
+}
+
+func TestImpersonate(t *testing.T) {
+	admin, _, user := newImpersonateTest(t)
+	c := newTestClient(t, admin)
+	c.expect("POST", "/impersonate/", impersonateForm(c, 1<<40), http.StatusNotFound)
+	c.expect("POST", "/impersonate/", impersonateForm(c, user), http.StatusFound)
+	if u, op := c.whoami(); u != user || op != admin {
+		t.Fatalf("expecting user %d impersonated by %d, got %d and %d", user, admin, u, op)
+	}
+	c.expect("POST", "/impersonate/stop/", url.Values{"csrf": {c.csrf()}}, http.StatusFound)
+	if u, op := c.whoami(); u != admin || op != 0 {
+		t.Errorf("expecting user %d without impersonator after stopping, got %d and %d", admin, u, op)
+	}
+}
+
+func TestImpersonateCSRF(t *testing.T) {
+	admin, _, user := newImpersonateTest(t)
+	c := newTestClient(t, admin)
+	id := strconv.FormatInt(user, 10)
+	c.expect("GET", "/impersonate/?user="+id, nil, http.StatusMethodNotAllowed)
+	c.expect("POST", "/impersonate/", url.Values{"user": {id}}, http.StatusForbidden)
+	c.expect("POST", "/impersonate/", url.Values{"user": {id}, "csrf": {"bad"}}, http.StatusForbidden)
+	if u, op := c.whoami(); u != admin || op != 0 {
+		t.Fatalf("expecting user %d without impersonator, got %d and %d", admin, u, op)
+	}
+	c.expect("POST", "/impersonate/", impersonateForm(c, user), http.StatusFound)
+	c.expect("POST", "/impersonate/stop/", url.Values{}, http.StatusForbidden)
+	c.expect("POST", "/impersonate/stop/", url.Values{"csrf": {"bad"}}, http.StatusForbidden)
+	if u, op := c.whoami(); u != user || op != admin {
+		t.Errorf("expecting user %d impersonated by %d, got %d and %d", user, admin, u, op)
+	}
+}
+
+func TestImpersonateStaleCookie(t *testing.T) {
+	admin, _, user := newImpersonateTest(t)
+	c := newTestClient(t, admin)
+	c.expect("POST", "/impersonate/", impersonateForm(c, user), http.StatusFound)
+	// Signing out must end the impersonation, so signing in
+	// again as the impersonated user doesn't restore it.
+	c.do("GET", "/sign-out/", nil)
+	if _, ok := c.cookies[IMPERSONATOR_COOKIE_NAME]; ok {
+		t.Error("impersonator cookie not removed after signing out")
+	}
+	c.signIn(user)
+	if u, op := c.whoami(); u != user || op != 0 {
+		t.Errorf("expecting user %d without impersonator after signing out, got %d and %d", user, u, op)
+	}
+	// Same for a fresh sign in without signing out
+	c = newTestClient(t, admin)
+	c.expect("POST", "/impersonate/", impersonateForm(c, user), http.StatusFound)
+	stale := c.cookies[IMPERSONATOR_COOKIE_NAME]
+	c.signIn(user)
+	if u, op := c.whoami(); u != user || op != 0 {
+		t.Errorf("expecting user %d without impersonator after signing in, got %d and %d", user, u, op)
+	}
+	// Even if the client keeps the old cookie
+	c.cookies[IMPERSONATOR_COOKIE_NAME] = stale
+	c.expect("POST", "/impersonate/stop/", url.Values{"csrf": {c.csrf()}}, http.StatusFound)
+	if u, _ := c.whoami(); u == admin {
+		t.Errorf("stale impersonator cookie restored the session of user %d", admin)
+	}
+}
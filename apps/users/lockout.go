@@ -0,0 +1,176 @@
+package users
+
+import (
+	"strconv"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/i18n"
+)
+
+var (
+	// MaxSignInFailures is the number of failed sign in attempts allowed
+	// for a given account before it's temporarily locked. Setting it to
+	// zero disables per account lockouts.
+	MaxSignInFailures = 5
+	// MaxAddressSignInFailures is the number of failed sign in attempts
+	// allowed from a given IP address, regardless of the account, before
+	// sign ins from that address are temporarily blocked. Setting it to
+	// zero disables per address lockouts.
+	MaxAddressSignInFailures = 20
+	// SignInFailureWindow is the time after which failed sign in attempts
+	// are forgotten.
+	SignInFailureWindow = time.Hour
+	// SignInLockout is the duration of the first lockout. Every subsequent
+	// lockout doubles the previous one, up to MaxSignInLockout.
+	SignInLockout = time.Minute
+	// MaxSignInLockout is the maximum duration of a lockout.
+	MaxSignInLockout = 24 * time.Hour
+
+	ErrLockedOut = i18n.NewError("too many failed sign in attempts, please try again later")
+)
+
+// signInFailures is stored in the cache to keep track of
+// the lockouts for an account or address. The failed attempts
+// themselves are counted with Cache.Inc, see failuresCountKey.
+type signInFailures struct {
+	Lockouts int
+	Last     time.Time
+	Until    time.Time
+}
+
+func (f *signInFailures) locked() bool {
+	return !f.Until.IsZero() && time.Now().Before(f.Until)
+}
+
+func accountFailuresKey(userId int64) string {
+	return "users-sign-in-failures-account-" + strconv.FormatInt(userId, 36)
+}
+
+func addressFailuresKey(addr string) string {
+	return "users-sign-in-failures-address-" + addr
+}
+
+// failuresCountKey returns the key for the counter of failed
+// attempts associated with the given failures key.
+func failuresCountKey(key string) string {
+	return key + "-count"
+}
+
+func loadSignInFailures(ctx *app.Context, key string) *signInFailures {
+	var f signInFailures
+	if err := ctx.Cache().Get(key, &f); err != nil {
+		return nil
+	}
+	return &f
+}
+
+// signInLockedUntil returns the time when the lockout for the
+// given key expires, or the zero time.Time if it's not locked.
+func signInLockedUntil(ctx *app.Context, key string) time.Time {
+	if f := loadSignInFailures(ctx, key); f != nil && f.locked() {
+		return f.Until
+	}
+	return time.Time{}
+}
+
+// recordSignInFailure increments the failure count for the given
+// key and returns the time the lockout expires if the failure
+// caused a new lockout.
+func recordSignInFailure(ctx *app.Context, key string, max int) time.Time {
+	countKey := failuresCountKey(key)
+	// The counter expires SignInFailureWindow after the first failure
+	count, err := ctx.Cache().Inc(countKey, 1, int(SignInFailureWindow/time.Second))
+	if err != nil {
+		ctx.Logger().Errorf("error counting sign in failures for %s: %s", key, err)
+		return time.Time{}
+	}
+	// Inc is atomic, so only one of the concurrent failures
+	// reaches max and triggers the lockout.
+	if count != int64(max) {
+		return time.Time{}
+	}
+	if err := ctx.Cache().Delete(countKey); err != nil {
+		ctx.Logger().Errorf("error clearing sign in failures for %s: %s", key, err)
+	}
+	now := time.Now()
+	f := loadSignInFailures(ctx, key)
+	if f == nil {
+		f = &signInFailures{}
+	}
+	lockout := SignInLockout << uint(f.Lockouts)
+	if lockout <= 0 || lockout > MaxSignInLockout {
+		lockout = MaxSignInLockout
+	}
+	f.Last = now
+	f.Until = now.Add(lockout)
+	f.Lockouts++
+	// Keep the record around long enough to apply
+	// exponential backoff on the next lockout.
+	timeout := int((SignInFailureWindow + MaxSignInLockout) / time.Second)
+	if err := ctx.Cache().Set(key, f, timeout); err != nil {
+		ctx.Logger().Errorf("error storing sign in lockout for %s: %s", key, err)
+	}
+	return f.Until
+}
+
+// checkSignInAddress returns ErrLockedOut if sign ins from the
+// current remote address are temporarily blocked.
+func checkSignInAddress(ctx *app.Context, username string) error {
+	if MaxAddressSignInFailures > 0 {
+		if until := signInLockedUntil(ctx, addressFailuresKey(ctx.RemoteAddress())); !until.IsZero() {
+			recordSecurityEvent(ctx, &SecurityEvent{Type: SecurityEventSignInBlocked, Username: username, Until: until})
+			return ErrLockedOut
+		}
+	}
+	return nil
+}
+
+// checkSignInAccount returns ErrLockedOut if the account with
+// the given id is temporarily locked.
+func checkSignInAccount(ctx *app.Context, userId int64, username string) error {
+	if MaxSignInFailures > 0 {
+		if until := signInLockedUntil(ctx, accountFailuresKey(userId)); !until.IsZero() {
+			recordSecurityEvent(ctx, &SecurityEvent{Type: SecurityEventSignInBlocked, UserId: userId, Username: username, Until: until})
+			return ErrLockedOut
+		}
+	}
+	return nil
+}
+
+// signInFailed records a failed sign in attempt. userId might
+// be zero if the user could not be found.
+func signInFailed(ctx *app.Context, userId int64, username string) {
+	recordSecurityEvent(ctx, &SecurityEvent{Type: SecurityEventSignInFailed, UserId: userId, Username: username})
+	if MaxAddressSignInFailures > 0 {
+		if until := recordSignInFailure(ctx, addressFailuresKey(ctx.RemoteAddress()), MaxAddressSignInFailures); !until.IsZero() {
+			recordSecurityEvent(ctx, &SecurityEvent{Type: SecurityEventAddressLockout, Username: username, Until: until})
+		}
+	}
+	if MaxSignInFailures > 0 && userId != 0 {
+		if until := recordSignInFailure(ctx, accountFailuresKey(userId), MaxSignInFailures); !until.IsZero() {
+			recordSecurityEvent(ctx, &SecurityEvent{Type: SecurityEventLockout, UserId: userId, Username: username, Until: until})
+		}
+	}
+}
+
+// resetSignInFailures clears the failure count for the given key,
+// but keeps the number of previous lockouts, so the backoff still
+// applies if the failures start again.
+func resetSignInFailures(ctx *app.Context, key string) {
+	if err := ctx.Cache().Delete(failuresCountKey(key)); err != nil {
+		ctx.Logger().Errorf("error clearing sign in failures for %s: %s", key, err)
+	}
+}
+
+// signInSucceeded clears the failure count for the given account
+// and records the sign in. Note that failures for the remote address
+// are not cleared, otherwise an attacker with a valid account could
+// use it to reset the address counter. Previous lockouts are kept too,
+// so successful sign ins don't reset the backoff.
+func signInSucceeded(ctx *app.Context, userId int64, username string) {
+	if MaxSignInFailures > 0 {
+		resetSignInFailures(ctx, accountFailuresKey(userId))
+	}
+	recordSecurityEvent(ctx, &SecurityEvent{Type: SecurityEventSignIn, UserId: userId, Username: username})
+}
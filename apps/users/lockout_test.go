@@ -0,0 +1,102 @@
+package users
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"gnd.la/app"
+)
+
+func newAddressContext(addr string) *app.Context {
+	ctx := testApp.NewContext(nil)
+	ctx.R, _ = http.NewRequest("POST", "/", nil)
+	ctx.R.RemoteAddr = addr
+	return ctx
+}
+
+// signInFailureCount returns the current failure count for the given key.
+func signInFailureCount(t *testing.T, ctx *app.Context, key string) int64 {
+	count, err := ctx.Cache().Inc(failuresCountKey(key), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestSignInLockout(t *testing.T) {
+	ctx := newAddressContext("192.0.2.1:1234")
+	defer testApp.CloseContext(ctx)
+	const victim, attacker = 100, 101
+	key := accountFailuresKey(victim)
+	for ii := 0; ii < MaxSignInFailures-1; ii++ {
+		signInFailed(ctx, victim, "victim")
+	}
+	if err := checkSignInAccount(ctx, victim, "victim"); err != nil {
+		t.Fatalf("account locked before reaching %d failures", MaxSignInFailures)
+	}
+	// Signing in with another account must not affect the victim
+	signInSucceeded(ctx, attacker, "attacker")
+	signInFailed(ctx, victim, "victim")
+	if err := checkSignInAccount(ctx, victim, "victim"); err != ErrLockedOut {
+		t.Fatalf("expecting ErrLockedOut after %d failures, got %v", MaxSignInFailures, err)
+	}
+	if err := checkSignInAccount(ctx, attacker, "attacker"); err != nil {
+		t.Errorf("expecting other account not to be locked, got %v", err)
+	}
+	f := loadSignInFailures(ctx, key)
+	if f == nil || f.Lockouts != 1 {
+		t.Fatalf("expecting 1 lockout, got %+v", f)
+	}
+	if d := f.Until.Sub(f.Last); d != SignInLockout {
+		t.Errorf("expecting lockout of %s, got %s", SignInLockout, d)
+	}
+	if c := signInFailureCount(t, ctx, key); c != 0 {
+		t.Errorf("expecting count reset after lockout, got %d", c)
+	}
+	// A successful sign in resets the count, but not the backoff
+	f.Until = time.Time{}
+	if err := ctx.Cache().Set(key, f, 0); err != nil {
+		t.Fatal(err)
+	}
+	signInFailed(ctx, victim, "victim")
+	signInFailed(ctx, victim, "victim")
+	if c := signInFailureCount(t, ctx, key); c != 2 {
+		t.Fatalf("expecting 2 failures, got %d", c)
+	}
+	signInSucceeded(ctx, victim, "victim")
+	if c := signInFailureCount(t, ctx, key); c != 0 {
+		t.Errorf("expecting count reset after signing in, got %d", c)
+	}
+	if f = loadSignInFailures(ctx, key); f == nil || f.Lockouts != 1 {
+		t.Fatalf("expecting 1 lockout, got %+v", f)
+	}
+	for ii := 0; ii < MaxSignInFailures; ii++ {
+		signInFailed(ctx, victim, "victim")
+	}
+	if f = loadSignInFailures(ctx, key); f == nil || f.Lockouts != 2 {
+		t.Fatalf("expecting 2 lockouts, got %+v", f)
+	}
+	if d := f.Until.Sub(f.Last); d != 2*SignInLockout {
+		t.Errorf("expecting lockout of %s, got %s", 2*SignInLockout, d)
+	}
+}
+
+func TestSignInAddressLockout(t *testing.T) {
+	ctx := newAddressContext("192.0.2.2:1234")
+	defer testApp.CloseContext(ctx)
+	for ii := 0; ii < MaxAddressSignInFailures; ii++ {
+		// Unknown users and alternating valid sign ins
+		// must still count towards the address limit.
+		signInFailed(ctx, 0, "nobody")
+		signInSucceeded(ctx, 200, "valid")
+	}
+	if err := checkSignInAddress(ctx, "nobody"); err != ErrLockedOut {
+		t.Errorf("expecting ErrLockedOut after %d failures, got %v", MaxAddressSignInFailures, err)
+	}
+	other := newAddressContext("192.0.2.3:1234")
+	defer testApp.CloseContext(other)
+	if err := checkSignInAddress(other, "nobody"); err != nil {
+		t.Errorf("expecting other address not to be blocked, got %v", err)
+	}
+}
@@ -0,0 +1,399 @@
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/cache"
+	"gnd.la/i18n"
+	"gnd.la/orm"
+	"gnd.la/orm/operation"
+)
+
+const (
+	NotificationsHandlerName       = "users-notifications"
+	NotificationsReadHandlerName   = "users-notifications-read"
+	NotificationsEventsHandlerName = "users-notifications-events"
+
+	defaultNotificationsLimit = 20
+)
+
+var (
+	// NotificationsKeepAlive is the interval between the keep alive
+	// comments sent by NotificationsEventsHandler, which prevent proxies
+	// from closing idle connections.
+	NotificationsKeepAlive = 30 * time.Second
+
+	notificationType = reflect.TypeOf(Notification{})
+	notificationsHub = &notificationHub{}
+
+	NotificationsHandler       = app.NamedHandler(NotificationsHandlerName, app.SignedIn(notificationsHandler))
+	NotificationsReadHandler   = app.NamedHandler(NotificationsReadHandlerName, app.SignedIn(notificationsReadHandler))
+	NotificationsEventsHandler = app.NamedHandler(NotificationsEventsHandlerName, app.SignedIn(notificationsEventsHandler))
+)
+
+// Notification is a persistent message for a user, which is kept until
+// the user marks it as read. Notifications are only available when the
+// Notification type is registered with the ORM e.g.
+//
+//	orm.Register(&users.Notification{}, nil)
+//
+// Unlike flash messages (see gnd.la/app.Context.AddFlash), notifications
+// might be created for users other than the current one, so their message
+// is stored untranslated and translated when it's displayed. Declare the
+// messages as i18n.String to make them available for translation.
+type Notification struct {
+	Id      int64          `orm:",primary_key,auto_increment" json:"id"`
+	UserId  int64          `orm:",index" json:"-"`
+	Level   app.FlashLevel `json:"level"`
+	Message string         `json:"message"`
+	URL     string         `json:"url,omitempty"`
+	Read    bool           `orm:",default=false" json:"read"`
+	Created time.Time      `json:"created"`
+}
+
+// Text returns the notification message translated into the language
+// returned by lang.
+func (n *Notification) Text(lang i18n.Languager) string {
+	return i18n.T(lang, n.Message)
+}
+
+// notificationTable returns the table for the Notification type or
+// nil if notifications are not enabled.
+func notificationTable(ctx *app.Context) *orm.Table {
+	return ctx.Orm().TypeTable(notificationType)
+}
+
+func notificationsKey(userId int64) string {
+	return "users-notifications-" + strconv.FormatInt(userId, 36)
+}
+
+// Notify creates a notification for the given user and delivers it
+// to all the clients connected to NotificationsEventsHandler for that
+// user. If notifications are not enabled, it returns an error.
+func Notify(ctx *app.Context, userId int64, level app.FlashLevel, message string, url string) (*Notification, error) {
+	if notificationTable(ctx) == nil {
+		return nil, fmt.Errorf("notifications are not enabled - add orm.Register(&users.Notification{}, nil) somewhere in your app")
+	}
+	n := &Notification{
+		UserId:  userId,
+		Level:   level,
+		Message: message,
+		URL:     url,
+		Created: time.Now().UTC(),
+	}
+	if _, err := ctx.Orm().Insert(n); err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(n); err == nil {
+		if err := ctx.App().Notify(notificationsKey(userId), data); err != nil {
+			ctx.Logger().Errorf("error delivering notification %d to user %d: %s", n.Id, userId, err)
+		}
+	}
+	return n, nil
+}
+
+// Notifications returns up to limit notifications for the given user,
+// most recent first. If unread is true, only unread notifications are
+// returned. A non-positive limit returns all of them.
+func Notifications(ctx *app.Context, userId int64, unread bool, limit int) ([]*Notification, error) {
+	tbl := notificationTable(ctx)
+	if tbl == nil {
+		return nil, nil
+	}
+	q := orm.Eq("UserId", userId)
+	if unread {
+		q = orm.And(q, orm.Eq("Read", false))
+	}
+	query := ctx.Orm().Table(tbl).Filter(q).Sort("Id", orm.DESC)
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var notifications []*Notification
+	if err := query.All(&notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// UnreadNotifications returns the number of unread notifications
+// for the given user.
+func UnreadNotifications(ctx *app.Context, userId int64) (int, error) {
+	tbl := notificationTable(ctx)
+	if tbl == nil {
+		return 0, nil
+	}
+	count, err := ctx.Orm().Table(tbl).Filter(orm.And(orm.Eq("UserId", userId), orm.Eq("Read", false))).Count()
+	return int(count), err
+}
+
+// MarkNotificationsRead marks the notifications with the given ids
+// which belong to the given user as read. If no ids are provided,
+// all the notifications for the user are marked as read. It returns
+// the number of notifications which were updated.
+func MarkNotificationsRead(ctx *app.Context, userId int64, ids ...int64) (int, error) {
+	tbl := notificationTable(ctx)
+	if tbl == nil {
+		return 0, nil
+	}
+	q := orm.And(orm.Eq("UserId", userId), orm.Eq("Read", false))
+	if len(ids) > 0 {
+		q = orm.And(q, orm.In("Id", ids))
+	}
+	res, err := ctx.Orm().Operate(tbl, q, operation.Set("Read", true))
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// currentUnreadNotifications is used from templates, where errors
+// are just logged.
+func currentUnreadNotifications(ctx *app.Context) int {
+	user := ctx.User()
+	if user == nil {
+		return 0
+	}
+	count, err := UnreadNotifications(ctx, user.Id())
+	if err != nil {
+		ctx.Logger().Errorf("error counting notifications for user %d: %s", user.Id(), err)
+	}
+	return count
+}
+
+// currentNotifications is used from templates, where errors
+// are just logged.
+func currentNotifications(ctx *app.Context) []*Notification {
+	user := ctx.User()
+	if user == nil {
+		return nil
+	}
+	notifications, err := Notifications(ctx, user.Id(), false, defaultNotificationsLimit)
+	if err != nil {
+		ctx.Logger().Errorf("error loading notifications for user %d: %s", user.Id(), err)
+	}
+	return notifications
+}
+
+func notificationsHandler(ctx *app.Context) {
+	userId := ctx.User().Id()
+	limit := defaultNotificationsLimit
+	ctx.ParseFormValue("limit", &limit)
+	notifications, err := Notifications(ctx, userId, ctx.FormValue("unread") != "", limit)
+	if err != nil {
+		panic(err)
+	}
+	unread, err := UnreadNotifications(ctx, userId)
+	if err != nil {
+		panic(err)
+	}
+	for _, v := range notifications {
+		v.Message = v.Text(ctx)
+	}
+	ctx.WriteJSON(map[string]interface{}{
+		"notifications": notifications,
+		"unread":        unread,
+		"csrf":          CSRFToken(ctx),
+	})
+}
+
+// notificationsReadHandler marks the notifications indicated by the
+// id parameter (which might be repeated) as read or, if the all
+// parameter is non-empty, all of them. The CSRF token is returned
+// by notificationsHandler.
+func notificationsReadHandler(ctx *app.Context) {
+	if ctx.R.Method != "POST" {
+		ctx.Error(http.StatusMethodNotAllowed)
+		return
+	}
+	if !checkCSRF(ctx) {
+		ctx.Forbidden("invalid CSRF token")
+		return
+	}
+	var ids []int64
+	if ctx.FormValue("all") == "" {
+		ctx.R.ParseForm()
+		for _, v := range ctx.R.Form["id"] {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				ctx.BadRequest()
+				return
+			}
+			ids = append(ids, id)
+		}
+		if len(ids) == 0 {
+			ctx.BadRequest()
+			return
+		}
+	}
+	userId := ctx.User().Id()
+	updated, err := MarkNotificationsRead(ctx, userId, ids...)
+	if err != nil {
+		panic(err)
+	}
+	unread, err := UnreadNotifications(ctx, userId)
+	if err != nil {
+		panic(err)
+	}
+	ctx.WriteJSON(map[string]interface{}{
+		"updated": updated,
+		"unread":  unread,
+	})
+}
+
+// notificationHub shares a single cache subscription among all the
+// clients connected to NotificationsEventsHandler for the same user
+// in the current process.
+type notificationHub struct {
+	mu   sync.Mutex
+	subs map[notificationHubKey]*notificationSub
+}
+
+type notificationHubKey struct {
+	cache *cache.Cache
+	key   string
+}
+
+type notificationSub struct {
+	sub     *cache.Subscription
+	clients map[chan []byte]struct{}
+}
+
+// subscribe returns a channel which receives the notifications
+// published on key and a function which stops receiving them.
+func (h *notificationHub) subscribe(c *cache.Cache, key string) (<-chan []byte, func(), error) {
+	k := notificationHubKey{c, key}
+	ch := make(chan []byte, 1)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.subs[k]
+	if s == nil {
+		sub, err := c.Subscribe(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		s = &notificationSub{sub: sub, clients: make(map[chan []byte]struct{})}
+		if h.subs == nil {
+			h.subs = make(map[notificationHubKey]*notificationSub)
+		}
+		h.subs[k] = s
+		go h.deliver(k, s)
+	}
+	s.clients[ch] = struct{}{}
+	return ch, func() {
+		h.mu.Lock()
+		if _, ok := s.clients[ch]; !ok {
+			h.mu.Unlock()
+			return
+		}
+		delete(s.clients, ch)
+		close(ch)
+		last := len(s.clients) == 0 && h.subs[k] == s
+		if last {
+			delete(h.subs, k)
+		}
+		h.mu.Unlock()
+		if last {
+			// Close without holding the lock, since the
+			// cache might wait for deliver to drain the
+			// subscription.
+			s.sub.Close()
+		}
+	}, nil
+}
+
+func (h *notificationHub) deliver(k notificationHubKey, s *notificationSub) {
+	for data := range s.sub.C {
+		h.mu.Lock()
+		for ch := range s.clients {
+			select {
+			case ch <- data:
+			default:
+				// Client is not keeping up, drop the
+				// notification. It can still retrieve it
+				// from NotificationsHandler.
+			}
+		}
+		h.mu.Unlock()
+	}
+	// Subscription was closed, either because the last client
+	// left or because the cache closed it. In the latter case,
+	// disconnect the clients, so they reconnect.
+	h.mu.Lock()
+	if h.subs[k] == s {
+		delete(h.subs, k)
+	}
+	for ch := range s.clients {
+		delete(s.clients, ch)
+		close(ch)
+	}
+	h.mu.Unlock()
+}
+
+func writeNotificationEvent(ctx *app.Context, event string, data []byte) error {
+	_, err := fmt.Fprintf(ctx, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+// notificationsEventsHandler streams the notifications for the current
+// user using server sent events. It sends an unread event with the
+// number of unread notifications when the client connects, followed
+// by a notification event for every new notification.
+func notificationsEventsHandler(ctx *app.Context) {
+	flusher, ok := ctx.ResponseWriter.(http.Flusher)
+	if !ok {
+		ctx.Error(http.StatusNotImplemented)
+		return
+	}
+	userId := ctx.User().Id()
+	ch, cancel, err := notificationsHub.subscribe(ctx.Cache(), notificationsKey(userId))
+	if err != nil {
+		panic(err)
+	}
+	defer cancel()
+	unread, err := UnreadNotifications(ctx, userId)
+	if err != nil {
+		panic(err)
+	}
+	h := ctx.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	if err := writeNotificationEvent(ctx, "unread", []byte(strconv.Itoa(unread))); err != nil {
+		return
+	}
+	flusher.Flush()
+	ticker := time.NewTicker(NotificationsKeepAlive)
+	defer ticker.Stop()
+	done := ctx.R.Context().Done()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if _, err := ctx.WriteString(": keep-alive\n\n"); err != nil {
+				return
+			}
+		case data, ok := <-ch:
+			if !ok {
+				return
+			}
+			var n Notification
+			if err := json.Unmarshal(data, &n); err == nil {
+				n.Message = n.Text(ctx)
+				data, _ = json.Marshal(&n)
+			}
+			if err := writeNotificationEvent(ctx, "notification", data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
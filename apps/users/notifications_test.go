@@ -0,0 +1,152 @@
+package users
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/cache"
+	"gnd.la/config"
+	"gnd.la/orm"
+)
+
+func TestNotifications(t *testing.T) {
+	a := testApp
+	ctx := a.NewContext(nil)
+	defer a.CloseContext(ctx)
+	if _, err := ctx.Orm().DeleteFrom(notificationTable(ctx), orm.In("UserId", []int64{1, 2})); err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, v := range []string{"first", "second"} {
+		n, err := Notify(ctx, 1, app.FlashInfo, v, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, n.Id)
+	}
+	if _, err := Notify(ctx, 2, app.FlashInfo, "other", ""); err != nil {
+		t.Fatal(err)
+	}
+	notifications, err := Notifications(ctx, 1, false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notifications) != 2 || notifications[0].Message != "second" || notifications[1].Message != "first" {
+		t.Fatalf("unexpected notifications %+v", notifications)
+	}
+	if n, err := UnreadNotifications(ctx, 1); err != nil || n != 2 {
+		t.Errorf("expecting 2 unread notifications, got %d (error %v)", n, err)
+	}
+	if n, err := MarkNotificationsRead(ctx, 1, ids[0], ids[0]); err != nil || n != 1 {
+		t.Errorf("expecting 1 notification marked as read, got %d (error %v)", n, err)
+	}
+	if unread, err := Notifications(ctx, 1, true, 0); err != nil || len(unread) != 1 || unread[0].Id != ids[1] {
+		t.Errorf("expecting notification %d to be unread, got %+v (error %v)", ids[1], unread, err)
+	}
+	if n, err := MarkNotificationsRead(ctx, 1); err != nil || n != 1 {
+		t.Errorf("expecting 1 notification marked as read, got %d (error %v)", n, err)
+	}
+	if n, err := UnreadNotifications(ctx, 1); err != nil || n != 0 {
+		t.Errorf("expecting no unread notifications, got %d (error %v)", n, err)
+	}
+	// Notifications for other users are not affected
+	if n, err := UnreadNotifications(ctx, 2); err != nil || n != 1 {
+		t.Errorf("expecting 1 unread notification for user 2, got %d (error %v)", n, err)
+	}
+}
+
+func TestNotificationsRead(t *testing.T) {
+	a := testApp
+	a.Handle("^/read/$", func(ctx *app.Context) {
+		ctx.SetUser(testUser(3))
+		notificationsReadHandler(ctx)
+	})
+	ctx := a.NewContext(nil)
+	if _, err := Notify(ctx, 3, app.FlashInfo, "hello", ""); err != nil {
+		t.Fatal(err)
+	}
+	a.CloseContext(ctx)
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, httptest.NewRequest("GET", "/token/", nil))
+	token := w.Body.String()
+	cookies := (&http.Response{Header: w.Header()}).Cookies()
+	post := func(values url.Values) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/read/", strings.NewReader(values.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		for _, v := range cookies {
+			r.AddCookie(v)
+		}
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, r)
+		return w
+	}
+	if w := post(url.Values{"all": {"1"}}); w.Code != http.StatusForbidden {
+		t.Errorf("expecting status %d without CSRF token, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := post(url.Values{"all": {"1"}, "csrf": {"bad"}}); w.Code != http.StatusForbidden {
+		t.Errorf("expecting status %d with an invalid CSRF token, got %d", http.StatusForbidden, w.Code)
+	}
+	w = post(url.Values{"all": {"1"}, "csrf": {token}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expecting status %d, got %d", http.StatusOK, w.Code)
+	}
+	var resp struct {
+		Updated int
+		Unread  int
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Updated != 1 || resp.Unread != 0 {
+		t.Errorf("expecting 1 updated and 0 unread, got %+v", resp)
+	}
+}
+
+func TestNotificationHub(t *testing.T) {
+	c, err := cache.New(config.MustParseURL("memory://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	var hub notificationHub
+	key := notificationsKey(1)
+	ch1, cancel1, err := hub.subscribe(c, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch2, cancel2, err := hub.subscribe(c, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hub.subs) != 1 {
+		t.Errorf("expecting 1 shared subscription, got %d", len(hub.subs))
+	}
+	data := []byte("hello")
+	if err := c.Publish(key, data); err != nil {
+		t.Fatal(err)
+	}
+	for _, ch := range []<-chan []byte{ch1, ch2} {
+		select {
+		case recv := <-ch:
+			if string(recv) != string(data) {
+				t.Errorf("expecting notification %q, got %q", data, recv)
+			}
+		case <-time.After(time.Second):
+			t.Error("notification not received")
+		}
+	}
+	cancel1()
+	if _, ok := <-ch1; ok {
+		t.Error("channel not closed after cancelling")
+	}
+	cancel2()
+	if len(hub.subs) != 0 {
+		t.Errorf("expecting no subscriptions after cancelling, got %d", len(hub.subs))
+	}
+}
@@ -0,0 +1,123 @@
+package users
+
+import (
+	"fmt"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/signal"
+)
+
+const (
+	// SECURITY_EVENT is emitted every time a security relevant event
+	// (successful or failed sign ins, lockouts, etc...) happens. The
+	// signal object is a *SecurityEvent.
+	SECURITY_EVENT = "gnd.la/apps/users.security-event"
+)
+
+// SecurityEventType indicates the type of a SecurityEvent.
+type SecurityEventType int
+
+const (
+	// SecurityEventSignIn is recorded when a user signs in.
+	SecurityEventSignIn SecurityEventType = iota + 1
+	// SecurityEventSignInFailed is recorded when a sign in fails,
+	// either because the user does not exist or the password
+	// does not match.
+	SecurityEventSignInFailed
+	// SecurityEventSignInBlocked is recorded when a sign in is
+	// rejected because the account or the address are locked.
+	SecurityEventSignInBlocked
+	// SecurityEventLockout is recorded when an account gets locked.
+	SecurityEventLockout
+	// SecurityEventAddressLockout is recorded when an IP address
+	// gets blocked.
+	SecurityEventAddressLockout
+	// SecurityEventImpersonationStart is recorded when an admin
+	// starts impersonating another user.
+	SecurityEventImpersonationStart
+	// SecurityEventImpersonationStop is recorded when an admin
+	// stops impersonating another user.
+	SecurityEventImpersonationStop
+	// SecurityEventImpersonatedRequest is recorded for every
+	// request made while impersonating a user. See AuditImpersonation.
+	SecurityEventImpersonatedRequest
+)
+
+func (t SecurityEventType) String() string {
+	switch t {
+	case SecurityEventSignIn:
+		return "sign-in"
+	case SecurityEventSignInFailed:
+		return "sign-in-failed"
+	case SecurityEventSignInBlocked:
+		return "sign-in-blocked"
+	case SecurityEventLockout:
+		return "lockout"
+	case SecurityEventAddressLockout:
+		return "address-lockout"
+	case SecurityEventImpersonationStart:
+		return "impersonation-start"
+	case SecurityEventImpersonationStop:
+		return "impersonation-stop"
+	case SecurityEventImpersonatedRequest:
+		return "impersonated-request"
+	}
+	return fmt.Sprintf("SecurityEventType(%d)", int(t))
+}
+
+// SecurityEvent represents an event which might be of interest for
+// auditing purposes. Security events are logged using the Context
+// logger and then emitted using the SECURITY_EVENT signal.
+type SecurityEvent struct {
+	Type SecurityEventType
+	// UserId is the id of the user involved in the event, or
+	// zero if there's no known user.
+	UserId int64
+	// OperatorId is the id of the admin impersonating the user
+	// identified by UserId, or zero if there's no impersonation.
+	OperatorId int64
+	// Username contains the username or email entered by the user,
+	// if any.
+	Username string
+	// Address is the remote address which originated the event.
+	Address string
+	// Time is the time when the event happened.
+	Time time.Time
+	// Until is the time when the lockout expires. It's only set
+	// for SecurityEventLockout and SecurityEventAddressLockout.
+	Until time.Time
+	// Request contains the method and the URL of the request. It's
+	// only set for SecurityEventImpersonatedRequest.
+	Request string
+}
+
+func (e *SecurityEvent) String() string {
+	s := fmt.Sprintf("%s user=%d username=%q address=%s", e.Type, e.UserId, e.Username, e.Address)
+	if e.OperatorId != 0 {
+		s += fmt.Sprintf(" operator=%d", e.OperatorId)
+	}
+	if !e.Until.IsZero() {
+		s += " until=" + e.Until.Format(time.RFC3339)
+	}
+	if e.Request != "" {
+		s += fmt.Sprintf(" request=%q", e.Request)
+	}
+	return s
+}
+
+func recordSecurityEvent(ctx *app.Context, ev *SecurityEvent) {
+	if ev.Address == "" {
+		ev.Address = ctx.RemoteAddress()
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	switch ev.Type {
+	case SecurityEventLockout, SecurityEventAddressLockout:
+		ctx.Logger().Warningf("security event: %s", ev)
+	default:
+		ctx.Logger().Infof("security event: %s", ev)
+	}
+	signal.Emit(SECURITY_EVENT, ev)
+}
@@ -0,0 +1,350 @@
+package users
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/orm"
+	"gnd.la/orm/operation"
+	"gnd.la/orm/query"
+	"gnd.la/util/stringutil"
+)
+
+const (
+	// SESSION_COOKIE_NAME is the name of the cookie used to store the
+	// session key and version. The cookie is signed using the
+	// gnd.la/app.App secret.
+	SESSION_COOKIE_NAME = "users-session"
+
+	SessionsHandlerName      = "users-sessions"
+	SessionRevokeHandlerName = "users-session-revoke"
+
+	sessionKeyLength  = 32
+	sessionContextKey = "__users_session"
+)
+
+var (
+	// SessionTouchInterval is the minimum interval between updates
+	// of the LastSeen field of a session. Sessions are also checked
+	// against the database at least once per interval.
+	SessionTouchInterval = 5 * time.Minute
+
+	sessionType = reflect.TypeOf(Session{})
+
+	SessionsHandler      = app.NamedHandler(SessionsHandlerName, app.SignedIn(sessionsHandler))
+	SessionRevokeHandler = app.NamedHandler(SessionRevokeHandlerName, app.SignedIn(sessionRevokeHandler))
+)
+
+// Session represents an active sign in for a user. Sessions are only
+// tracked when the Session type is registered with the ORM e.g.
+//
+//	orm.Register(&users.Session{}, nil)
+//
+// Every user has a session version counter, which is incremented every
+// time one of its sessions is revoked. The counter is stored in the
+// session cookie, so revoked sessions are detected on the next request
+// without querying the database on every request.
+type Session struct {
+	Id        int64     `orm:",primary_key,auto_increment" json:"id"`
+	UserId    int64     `orm:",index" json:"user"`
+	Key       string    `orm:",unique" json:"-"`
+	UserAgent string    `json:"user_agent"`
+	Address   string    `json:"address"`
+	Created   time.Time `json:"created"`
+	LastSeen  time.Time `json:"last_seen"`
+	Revoked   bool      `orm:",default=false" json:"-"`
+}
+
+// sessionCookie is stored in the session cookie.
+type sessionCookie struct {
+	Key     string
+	Version int64
+	Seen    int64
+}
+
+// sessionTable returns the table for the Session type or
+// nil if sessions are not enabled.
+func sessionTable(ctx *app.Context) *orm.Table {
+	return ctx.Orm().TypeTable(sessionType)
+}
+
+func sessionVersionKey(userId int64) string {
+	return "users-session-version-" + strconv.FormatInt(userId, 36)
+}
+
+// sessionVersion returns the current session version for the given
+// user. If the version is not known (e.g. it was evicted from the
+// cache), it returns -1, forcing a check against the database.
+func sessionVersion(ctx *app.Context, userId int64) int64 {
+	var version int64
+	if err := ctx.Cache().Get(sessionVersionKey(userId), &version); err != nil {
+		return -1
+	}
+	return version
+}
+
+func incSessionVersion(ctx *app.Context, userId int64) {
+	version := sessionVersion(ctx, userId)
+	if version < 0 {
+		// Use the current time, so the new value is
+		// different from any previous one.
+		version = time.Now().UnixNano()
+	} else {
+		version++
+	}
+	if err := ctx.Cache().Set(sessionVersionKey(userId), version, 0); err != nil {
+		ctx.Logger().Errorf("error storing session version for user %d: %s", userId, err)
+	}
+}
+
+func setSessionCookie(ctx *app.Context, sess *Session) {
+	version := sessionVersion(ctx, sess.UserId)
+	if version < 0 {
+		version = time.Now().UnixNano()
+		if err := ctx.Cache().Set(sessionVersionKey(sess.UserId), version, 0); err != nil {
+			ctx.Logger().Errorf("error storing session version for user %d: %s", sess.UserId, err)
+		}
+	}
+	c := &sessionCookie{Key: sess.Key, Version: version, Seen: sess.LastSeen.Unix()}
+	if err := ctx.Cookies().SetSecure(SESSION_COOKIE_NAME, c); err != nil {
+		ctx.Logger().Errorf("error setting session cookie: %s", err)
+	}
+}
+
+func newSession(ctx *app.Context, tbl *orm.Table, userId int64) (*Session, error) {
+	now := time.Now().UTC()
+	sess := &Session{
+		UserId:    userId,
+		Key:       stringutil.Random(sessionKeyLength),
+		UserAgent: ctx.GetHeader("User-Agent"),
+		Address:   ctx.RemoteAddress(),
+		Created:   now,
+		LastSeen:  now,
+	}
+	if _, err := ctx.Orm().Insert(sess); err != nil {
+		return nil, err
+	}
+	setSessionCookie(ctx, sess)
+	ctx.Set(sessionContextKey, sess)
+	return sess, nil
+}
+
+func signIn(ctx *app.Context, user app.User) error {
+	if err := ctx.SignIn(user); err != nil {
+		return err
+	}
+	// A fresh sign in ends any previous impersonation, otherwise a
+	// stale cookie would make the impersonated user an impersonator.
+	clearImpersonation(ctx)
+	if tbl := sessionTable(ctx); tbl != nil {
+		if _, err := newSession(ctx, tbl, user.Id()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mustSignIn(ctx *app.Context, user app.User) {
+	if err := signIn(ctx, user); err != nil {
+		panic(err)
+	}
+}
+
+func signOutHandler(ctx *app.Context) {
+	if sess := CurrentSession(ctx); sess != nil {
+		if _, err := RevokeSession(ctx, sess.UserId, sess.Id); err != nil {
+			ctx.Logger().Errorf("error revoking session %d: %s", sess.Id, err)
+		}
+	}
+	ctx.Cookies().Delete(SESSION_COOKIE_NAME)
+	clearImpersonation(ctx)
+	app.SignOutHandler(ctx)
+}
+
+// checkSession is called from Func after the user has been loaded
+// and returns false if the session has been revoked.
+func checkSession(ctx *app.Context, userId int64) bool {
+	tbl := sessionTable(ctx)
+	if tbl == nil {
+		return true
+	}
+	// While impersonating, the session belongs to the operator
+	owner := userId
+	var imp impersonation
+	if err := ctx.Cookies().GetSecure(IMPERSONATOR_COOKIE_NAME, &imp); err == nil && imp.User == userId {
+		owner = imp.Operator
+	}
+	var c sessionCookie
+	if err := ctx.Cookies().GetSecure(SESSION_COOKIE_NAME, &c); err != nil {
+		// If the user has sessions, the cookie was removed or
+		// tampered with, so the session can't be checked and must
+		// be considered revoked.
+		has, err := ctx.Orm().Table(tbl).Filter(orm.Eq("UserId", owner)).Exists()
+		if err != nil {
+			ctx.Logger().Errorf("error checking sessions for user %d: %s", owner, err)
+			return true
+		}
+		if has {
+			return false
+		}
+		// Users signed in before sessions were enabled, create a
+		// session for them.
+		if owner == userId {
+			if _, err := newSession(ctx, tbl, userId); err != nil {
+				ctx.Logger().Errorf("error creating session for user %d: %s", userId, err)
+			}
+		}
+		return true
+	}
+	now := time.Now()
+	if c.Version == sessionVersion(ctx, owner) && now.Sub(time.Unix(c.Seen, 0)) < SessionTouchInterval {
+		return true
+	}
+	var sess *Session
+	ok, err := ctx.Orm().Table(tbl).Filter(orm.Eq("Key", c.Key)).One(&sess)
+	if err != nil {
+		ctx.Logger().Errorf("error loading session for user %d: %s", owner, err)
+		// Don't sign out users due to transient errors
+		return true
+	}
+	if !ok || sess.Revoked || sess.UserId != owner {
+		return false
+	}
+	sess.LastSeen = now.UTC()
+	sess.Address = ctx.RemoteAddress()
+	_, err = ctx.Orm().Operate(tbl, orm.Eq("Id", sess.Id), operation.Set("LastSeen", sess.LastSeen), operation.Set("Address", sess.Address))
+	if err != nil {
+		ctx.Logger().Errorf("error updating session %d: %s", sess.Id, err)
+	}
+	setSessionCookie(ctx, sess)
+	ctx.Set(sessionContextKey, sess)
+	return true
+}
+
+// CurrentSession returns the Session for the current request, or nil if
+// there's no signed in user or sessions are not enabled.
+func CurrentSession(ctx *app.Context) *Session {
+	if sess, ok := ctx.Get(sessionContextKey).(*Session); ok {
+		return sess
+	}
+	tbl := sessionTable(ctx)
+	if tbl == nil || ctx.User() == nil {
+		return nil
+	}
+	var c sessionCookie
+	if err := ctx.Cookies().GetSecure(SESSION_COOKIE_NAME, &c); err != nil {
+		return nil
+	}
+	var sess *Session
+	if ok, err := ctx.Orm().Table(tbl).Filter(orm.Eq("Key", c.Key)).One(&sess); err != nil || !ok || sess.Revoked {
+		return nil
+	}
+	ctx.Set(sessionContextKey, sess)
+	return sess
+}
+
+// Sessions returns the active (non revoked) sessions for
+// the given user, most recently used first.
+func Sessions(ctx *app.Context, userId int64) ([]*Session, error) {
+	tbl := sessionTable(ctx)
+	if tbl == nil {
+		return nil, nil
+	}
+	var sessions []*Session
+	q := ctx.Orm().Table(tbl).Filter(orm.And(orm.Eq("UserId", userId), orm.Eq("Revoked", false))).Sort("LastSeen", orm.DESC)
+	if err := q.All(&sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSession revokes the session with the given id which belongs
+// to the given user. Revoked sessions are signed out on their next
+// request. The returned boolean indicates if a session was revoked.
+func RevokeSession(ctx *app.Context, userId int64, id int64) (bool, error) {
+	return revokeSessions(ctx, userId, orm.And(orm.Eq("Id", id), orm.Eq("UserId", userId)))
+}
+
+// RevokeSessions revokes all the sessions for the given user, except
+// the one with the given id (use 0 to revoke all of them).
+func RevokeSessions(ctx *app.Context, userId int64, except int64) (bool, error) {
+	q := orm.Eq("UserId", userId)
+	if except != 0 {
+		q = orm.And(q, orm.Neq("Id", except))
+	}
+	return revokeSessions(ctx, userId, q)
+}
+
+func revokeSessions(ctx *app.Context, userId int64, q query.Q) (bool, error) {
+	tbl := sessionTable(ctx)
+	if tbl == nil {
+		return false, nil
+	}
+	res, err := ctx.Orm().Operate(tbl, orm.And(q, orm.Eq("Revoked", false)), operation.Set("Revoked", true))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n > 0 {
+		incSessionVersion(ctx, userId)
+	}
+	return n > 0, nil
+}
+
+func sessionsHandler(ctx *app.Context) {
+	sessions, err := Sessions(ctx, ctx.User().Id())
+	if err != nil {
+		panic(err)
+	}
+	var current int64
+	if sess := CurrentSession(ctx); sess != nil {
+		current = sess.Id
+	}
+	ctx.WriteJSON(map[string]interface{}{
+		"sessions": sessions,
+		"current":  current,
+		"csrf":     CSRFToken(ctx),
+	})
+}
+
+// sessionRevokeHandler revokes the session indicated by the
+// id parameter or, if the all parameter is non-empty, all the
+// sessions except the current one. The CSRF token is returned
+// by sessionsHandler.
+func sessionRevokeHandler(ctx *app.Context) {
+	if ctx.R.Method != "POST" {
+		ctx.Error(http.StatusMethodNotAllowed)
+		return
+	}
+	if !checkCSRF(ctx) {
+		ctx.Forbidden("invalid CSRF token")
+		return
+	}
+	userId := ctx.User().Id()
+	var revoked bool
+	var err error
+	if ctx.FormValue("all") != "" {
+		var current int64
+		if sess := CurrentSession(ctx); sess != nil {
+			current = sess.Id
+		}
+		revoked, err = RevokeSessions(ctx, userId, current)
+	} else {
+		var id int64
+		if !ctx.ParseFormValue("id", &id) {
+			ctx.BadRequest()
+			return
+		}
+		revoked, err = RevokeSession(ctx, userId, id)
+	}
+	if err != nil {
+		panic(err)
+	}
+	ctx.WriteJSON(map[string]interface{}{"revoked": revoked})
+}
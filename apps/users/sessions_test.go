@@ -0,0 +1,138 @@
+package users
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"gnd.la/orm"
+	"gnd.la/orm/operation"
+)
+
+// sessions returns the active sessions for the user signed
+// in the client, as well as the current one.
+func (c *testClient) sessions() ([]*Session, int64) {
+	var resp struct {
+		Sessions []*Session
+		Current  int64
+	}
+	if err := json.Unmarshal([]byte(c.expect("GET", "/sessions/", nil, http.StatusOK)), &resp); err != nil {
+		c.t.Fatal(err)
+	}
+	return resp.Sessions, resp.Current
+}
+
+func TestCheckSession(t *testing.T) {
+	user := newSiteUser(t, t.Name()+"-user", false)
+	c := newTestClient(t, user)
+	sessions, current := c.sessions()
+	if len(sessions) != 1 || sessions[0].Id != current {
+		t.Fatalf("expecting current session %d, got %+v", current, sessions)
+	}
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	// Revoking the session without changing the version is not
+	// noticed until the session needs to be touched.
+	if _, err := ctx.Orm().Operate(sessionTable(ctx), orm.Eq("Id", current), operation.Set("Revoked", true)); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := c.whoami(); u != user {
+		t.Errorf("expecting user %d with the same session version, got %d", user, u)
+	}
+	incSessionVersion(ctx, user)
+	if u, _ := c.whoami(); u != 0 {
+		t.Errorf("expecting no user after changing the session version, got %d", u)
+	}
+	// A missing cookie is considered revoked, since the user has sessions
+	c = newTestClient(t, user)
+	delete(c.cookies, SESSION_COOKIE_NAME)
+	if u, _ := c.whoami(); u != 0 {
+		t.Errorf("expecting no user without session cookie, got %d", u)
+	}
+	// Users signed in before sessions were enabled get one
+	other := newSiteUser(t, t.Name()+"-other", false)
+	c = newTestClient(t, other)
+	delete(c.cookies, SESSION_COOKIE_NAME)
+	if _, err := ctx.Orm().DeleteFrom(sessionTable(ctx), orm.Eq("UserId", other)); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := c.whoami(); u != other {
+		t.Errorf("expecting user %d without sessions, got %d", other, u)
+	}
+	if _, ok := c.cookies[SESSION_COOKIE_NAME]; !ok {
+		t.Error("session cookie not set for user without sessions")
+	}
+}
+
+func TestCheckSessionImpersonating(t *testing.T) {
+	admin := newSiteUser(t, t.Name()+"-admin", true)
+	user := newSiteUser(t, t.Name()+"-user", false)
+	c := newTestClient(t, admin)
+	c.expect("POST", "/impersonate/", impersonateForm(c, user), http.StatusFound)
+	// The session belongs to the operator, revoking the sessions of the
+	// impersonated user doesn't affect it.
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	if _, err := RevokeSessions(ctx, user, 0); err != nil {
+		t.Fatal(err)
+	}
+	incSessionVersion(ctx, user)
+	if u, op := c.whoami(); u != user || op != admin {
+		t.Fatalf("expecting user %d impersonated by %d, got %d and %d", user, admin, u, op)
+	}
+	if ok, err := RevokeSessions(ctx, admin, 0); err != nil || !ok {
+		t.Fatalf("expecting admin sessions to be revoked, got %v (error %v)", ok, err)
+	}
+	if u, _ := c.whoami(); u != 0 {
+		t.Errorf("expecting no user after revoking the operator session, got %d", u)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	user := newSiteUser(t, t.Name()+"-user", false)
+	other := newSiteUser(t, t.Name()+"-other", false)
+	c1 := newTestClient(t, user)
+	c2 := newTestClient(t, user)
+	c3 := newTestClient(t, user)
+	_, current2 := c2.sessions()
+	_, current3 := c3.sessions()
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	// Sessions can only be revoked by their owner
+	if ok, err := RevokeSession(ctx, other, current2); err != nil || ok {
+		t.Errorf("expecting session %d not to be revoked by user %d, got %v (error %v)", current2, other, ok, err)
+	}
+	id := strconv.FormatInt(current2, 10)
+	c1.expect("POST", "/sessions/revoke/", url.Values{"id": {id}}, http.StatusForbidden)
+	c1.expect("POST", "/sessions/revoke/", url.Values{"id": {id}, "csrf": {"bad"}}, http.StatusForbidden)
+	if u, _ := c2.whoami(); u != user {
+		t.Fatalf("expecting user %d before revoking the session, got %d", user, u)
+	}
+	var resp struct{ Revoked bool }
+	body := c1.expect("POST", "/sessions/revoke/", url.Values{"id": {id}, "csrf": {c1.csrf()}}, http.StatusOK)
+	if err := json.Unmarshal([]byte(body), &resp); err != nil || !resp.Revoked {
+		t.Errorf("expecting session %d to be revoked, got %s", current2, body)
+	}
+	if u, _ := c2.whoami(); u != 0 {
+		t.Errorf("expecting no user after revoking the session, got %d", u)
+	}
+	if u, _ := c3.whoami(); u != user {
+		t.Errorf("expecting user %d in other session, got %d", user, u)
+	}
+	// Revoke all, except the current one
+	body = c1.expect("POST", "/sessions/revoke/", url.Values{"all": {"1"}, "csrf": {c1.csrf()}}, http.StatusOK)
+	if err := json.Unmarshal([]byte(body), &resp); err != nil || !resp.Revoked {
+		t.Errorf("expecting session %d to be revoked, got %s", current3, body)
+	}
+	if u, _ := c3.whoami(); u != 0 {
+		t.Errorf("expecting no user after revoking all the sessions, got %d", u)
+	}
+	if u, _ := c1.whoami(); u != user {
+		t.Errorf("expecting user %d in current session, got %d", user, u)
+	}
+	if sessions, current := c1.sessions(); len(sessions) != 1 || sessions[0].Id != current {
+		t.Errorf("expecting only current session %d, got %+v", current, sessions)
+	}
+}
@@ -0,0 +1,166 @@
+package users
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gnd.la/app"
+	"gnd.la/config"
+	"gnd.la/orm"
+	_ "gnd.la/orm/driver/sqlite"
+)
+
+// testApp is shared by all the tests, since the models are
+// registered in the default ORM registry.
+var testApp *app.App
+
+type testUser int64
+
+func (u testUser) Id() int64     { return int64(u) }
+func (u testUser) IsAdmin() bool { return false }
+
+// siteUser is the user type registered with the ORM, used
+// by the tests which need to sign in users.
+type siteUser struct {
+	User
+}
+
+func TestMain(m *testing.M) {
+	orm.Register(&Notification{}, &orm.Options{Table: "test_notifications"})
+	orm.Register(&Session{}, &orm.Options{Table: "test_sessions"})
+	orm.Register(&siteUser{}, &orm.Options{Table: "test_users"})
+	SetType(&siteUser{})
+	f, err := ioutil.TempFile("", "users-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	f.Close()
+	testApp = app.New()
+	testApp.Config().Secret = "0123456789abcdef0123456789abcdef"
+	testApp.Config().Database = config.MustParseURL("sqlite://" + f.Name())
+	testApp.Config().Cache = config.MustParseURL("memory://")
+	testApp.SetUserFunc(Func)
+	testApp.HandleNamed("^/sign-in/$", func(ctx *app.Context) {
+		var id int64
+		ctx.ParseFormValue("id", &id)
+		user, err := Get(ctx, id)
+		if err != nil {
+			panic(err)
+		}
+		mustSignIn(ctx, user)
+	}, app.SignInHandlerName)
+	testApp.Handle("^/sign-out/$", signOutHandler)
+	for _, v := range []struct {
+		pattern string
+		handler *app.HandlerInfo
+	}{
+		{"^/impersonate/$", ImpersonateHandler},
+		{"^/impersonate/stop/$", StopImpersonateHandler},
+		{"^/sessions/$", SessionsHandler},
+		{"^/sessions/revoke/$", SessionRevokeHandler},
+	} {
+		testApp.HandleOptions(v.pattern, v.handler.Handler, v.handler.Options)
+	}
+	testApp.Handle("^/token/$", func(ctx *app.Context) {
+		ctx.WriteString(CSRFToken(ctx))
+	})
+	// whoami writes the ids of the current user and
+	// the impersonator, using 0 when there's none.
+	testApp.Handle("^/whoami/$", func(ctx *app.Context) {
+		var user, operator int64
+		if u := ctx.User(); u != nil {
+			user = u.Id()
+		}
+		if op := Impersonator(ctx); op != nil {
+			operator = op.Id()
+		}
+		fmt.Fprintf(ctx, "%d %d", user, operator)
+	})
+	code := m.Run()
+	if o, err := testApp.Orm(); err == nil {
+		o.Close()
+	}
+	os.Remove(f.Name())
+	os.Exit(code)
+}
+
+// newSiteUser inserts a new user into the database
+// and returns its id.
+func newSiteUser(t *testing.T, username string, admin bool) int64 {
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	user := &siteUser{User: User{Username: username, Email: username + "@example.com", Admin: admin}}
+	if _, err := ctx.Orm().Insert(user); err != nil {
+		t.Fatal(err)
+	}
+	return user.UserId
+}
+
+// testClient keeps the cookies set by the responses,
+// like a browser would do.
+type testClient struct {
+	t       *testing.T
+	cookies map[string]*http.Cookie
+}
+
+func newTestClient(t *testing.T, userId int64) *testClient {
+	c := &testClient{t: t, cookies: make(map[string]*http.Cookie)}
+	if userId != 0 {
+		c.signIn(userId)
+	}
+	return c
+}
+
+func (c *testClient) do(method string, path string, form url.Values) *httptest.ResponseRecorder {
+	var r *http.Request
+	if form != nil {
+		r = httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		r = httptest.NewRequest(method, path, nil)
+	}
+	for _, v := range c.cookies {
+		r.AddCookie(v)
+	}
+	w := httptest.NewRecorder()
+	testApp.ServeHTTP(w, r)
+	for _, v := range (&http.Response{Header: w.Header()}).Cookies() {
+		if v.MaxAge < 0 {
+			delete(c.cookies, v.Name)
+			continue
+		}
+		c.cookies[v.Name] = v
+	}
+	return w
+}
+
+func (c *testClient) expect(method string, path string, form url.Values, code int) string {
+	w := c.do(method, path, form)
+	if w.Code != code {
+		c.t.Errorf("%s %s: expecting status %d, got %d", method, path, code, w.Code)
+	}
+	return w.Body.String()
+}
+
+func (c *testClient) signIn(userId int64) {
+	c.do("GET", "/sign-in/?id="+strconv.FormatInt(userId, 10), nil)
+}
+
+func (c *testClient) csrf() string {
+	return c.expect("GET", "/token/", nil, http.StatusOK)
+}
+
+// whoami returns the ids of the current user and its impersonator.
+func (c *testClient) whoami() (int64, int64) {
+	var user, operator int64
+	fmt.Sscanf(c.expect("GET", "/whoami/", nil, http.StatusOK), "%d %d", &user, &operator)
+	return user, operator
+}
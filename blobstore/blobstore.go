@@ -4,10 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"reflect"
 	"strings"
+	"sync"
 
 	"gnd.la/blobstore/driver"
 	_ "gnd.la/blobstore/driver/file"
@@ -24,6 +26,9 @@ var (
 	// ErrNotIterable indicates that the current blobstore driver
 	// does not support iteration.
 	ErrNotIterable = errors.New("the blobstore driver does not support iteration")
+	// ErrAppending is returned by Blobstore.Append when there's
+	// another append in progress for the same file.
+	ErrAppending = errors.New("the file is being appended to")
 )
 
 const (
@@ -58,6 +63,8 @@ type Blobstore struct {
 	srv       driver.Server
 	drvName   string
 	drvNoMeta bool
+	appendMu  sync.Mutex
+	appending map[string]bool
 }
 
 // New returns a new *Blobstore using the given url as its configure
@@ -164,6 +171,103 @@ func (s *Blobstore) StoreId(id string, b []byte, meta interface{}) (string, erro
 	return f.Id(), nil
 }
 
+// Append reads data from r until EOF and appends it to the file with
+// the given id, preserving its metadata, and returns the number of bytes
+// appended. Drivers implementing gnd.la/blobstore/driver.Appender (file
+// and leveldb) append the data without rewriting the file, while with
+// other drivers the current data is copied to a temporary file and then
+// written again followed by the new data. If reading from r fails, the
+// data appended so far is kept (so uploads can be resumed by checking
+// the file size) and the error is returned.
+//
+// Concurrent appends to the same file from the same Blobstore return
+// ErrAppending. Appends from different processes are not detected and
+// will corrupt the file, so callers must serialize them.
+func (s *Blobstore) Append(id string, r io.Reader) (int64, error) {
+	if !s.lockAppend(id) {
+		return 0, ErrAppending
+	}
+	defer s.unlockAppend(id)
+	f, err := s.Open(id)
+	if err != nil {
+		return 0, err
+	}
+	if err := f.decodeMeta(); err != nil {
+		f.Close()
+		return 0, err
+	}
+	w := &WFile{
+		id:       id,
+		metadata: f.metadataData,
+		store:    s,
+	}
+	if appender, ok := s.drv.(driver.Appender); ok {
+		f.Close()
+		if w.dataHash, err = resumeHash(f.dataHash); err != nil {
+			return 0, err
+		}
+		w.dataLength = f.dataLength
+		if w.file, err = appender.Append(id, int64(f.dataLength)); err != nil {
+			return 0, err
+		}
+		w.appending = true
+	} else {
+		// The driver might not allow reading from and writing
+		// to the same file at once, so copy the data first.
+		tmp, err := ioutil.TempFile("", "blobstore-append")
+		if err != nil {
+			f.Close()
+			return 0, err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		_, err = io.Copy(tmp, f)
+		f.Close()
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tmp.Seek(0, os.SEEK_SET); err != nil {
+			return 0, err
+		}
+		w.dataHash = newHash()
+		if w.file, err = s.drv.Create(id); err != nil {
+			return 0, err
+		}
+		if _, err := io.Copy(w, tmp); err != nil {
+			// Don't close w.file, since it would replace
+			// the file with the partially copied data.
+			if a, ok := w.file.(driver.Aborter); ok {
+				a.Abort()
+			}
+			return 0, err
+		}
+	}
+	n, err := io.Copy(w, r)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+func (s *Blobstore) lockAppend(id string) bool {
+	s.appendMu.Lock()
+	defer s.appendMu.Unlock()
+	if s.appending[id] {
+		return false
+	}
+	if s.appending == nil {
+		s.appending = make(map[string]bool)
+	}
+	s.appending[id] = true
+	return true
+}
+
+func (s *Blobstore) unlockAppend(id string) {
+	s.appendMu.Lock()
+	delete(s.appending, id)
+	s.appendMu.Unlock()
+}
+
 // Remove deletes the file with the given id.
 func (s *Blobstore) Remove(id string) error {
 	s.drv.Remove(s.metaName(id))
@@ -229,6 +333,86 @@ func (s *Blobstore) Iter() (Iter, error) {
 	return nil, ErrNotIterable
 }
 
+// Usage represents the space used by a blobstore, as returned
+// by Blobstore.UsageStats.
+type Usage struct {
+	// Files is the number of files in the blobstore.
+	Files int
+	// Size is the total size of the files, without including
+	// their metadata.
+	Size uint64
+	// Dedup contains the deduplication statistics for the whole
+	// blobstore. It's only non-nil when the driver deduplicates
+	// the stored data (e.g. leveldb).
+	Dedup *driver.DedupStats
+}
+
+// Usage returns the number of files in the blobstore and their
+// total size in bytes, without including the metadata. Note that
+// this function needs to iterate over all the files, so it might
+// take a long time with big blobstores. If the underlying driver
+// does not support iteration, ErrNotIterable will be returned.
+func (s *Blobstore) Usage() (files int, size uint64, err error) {
+	usage, err := s.usage()
+	if err != nil {
+		return 0, 0, err
+	}
+	return usage.Files, usage.Size, nil
+}
+
+// UsageStats works like Usage, but it also returns the deduplication
+// statistics for the whole blobstore when the driver supports them.
+func (s *Blobstore) UsageStats() (*Usage, error) {
+	usage, err := s.usage()
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := s.drv.(driver.Deduplicator); ok {
+		if usage.Dedup, err = d.ScanStats(); err != nil {
+			return nil, err
+		}
+	}
+	return usage, nil
+}
+
+func (s *Blobstore) usage() (*Usage, error) {
+	iter, err := s.Iter()
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	usage := new(Usage)
+	var id string
+	for iter.Next(&id) {
+		f, err := s.Open(id)
+		if err != nil {
+			return nil, err
+		}
+		sz, err := f.Size()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		usage.Files++
+		usage.Size += sz
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// DedupStats returns the deduplication statistics for the data
+// written since the blobstore was opened. In contrast with Usage,
+// these are updated incrementally, so calling this function is
+// cheap. If the driver does not deduplicate data, it returns nil.
+func (s *Blobstore) DedupStats() *driver.DedupStats {
+	if d, ok := s.drv.(driver.Deduplicator); ok {
+		return d.WriteStats()
+	}
+	return nil
+}
+
 // Close closes the connection to the Blobstore.
 func (s *Blobstore) Close() error {
 	return s.drv.Close()
@@ -0,0 +1,71 @@
+package driver
+
+import (
+	"math/bits"
+)
+
+// DedupStats contains the deduplication statistics for the
+// chunked data in a blobstore. Note that data stored inline
+// (e.g. files smaller than a chunk) is not included.
+type DedupStats struct {
+	// LogicalBytes is the size of the data referenced by the files,
+	// counting each chunk every time it's referenced.
+	LogicalBytes uint64 `json:"logical_bytes"`
+	// PhysicalBytes is the size of the stored chunks.
+	PhysicalBytes uint64 `json:"physical_bytes"`
+	// Chunks is the number of stored chunks.
+	Chunks int `json:"chunks"`
+	// References is the number of chunk references from files.
+	References int `json:"references"`
+	// Reuse is a histogram of the number of references per chunk,
+	// using power of two buckets: Reuse[0] counts the chunks with no
+	// references, Reuse[1] the ones with 1 reference, Reuse[2] 2-3
+	// references, Reuse[3] 4-7 references and so on. It's only
+	// populated by scans.
+	Reuse []int `json:"reuse,omitempty"`
+}
+
+// Ratio returns the deduplication ratio, calculated as the
+// logical size divided by the physical size. If there's no
+// stored data, it returns 1.
+func (s *DedupStats) Ratio() float64 {
+	if s.PhysicalBytes == 0 {
+		return 1
+	}
+	return float64(s.LogicalBytes) / float64(s.PhysicalBytes)
+}
+
+// Saved returns the number of bytes saved by deduplication.
+func (s *DedupStats) Saved() uint64 {
+	if s.LogicalBytes < s.PhysicalBytes {
+		return 0
+	}
+	return s.LogicalBytes - s.PhysicalBytes
+}
+
+// AddChunk adds a chunk with the given size and number of
+// references to the stats, updating the Reuse histogram.
+func (s *DedupStats) AddChunk(size int, refs int) {
+	s.Chunks++
+	s.PhysicalBytes += uint64(size)
+	s.References += refs
+	s.LogicalBytes += uint64(size) * uint64(refs)
+	bucket := bits.Len(uint(refs))
+	for len(s.Reuse) <= bucket {
+		s.Reuse = append(s.Reuse, 0)
+	}
+	s.Reuse[bucket]++
+}
+
+// Deduplicator is the interface implemented by drivers which
+// split the files into content addressed chunks and store each
+// distinct chunk only once.
+type Deduplicator interface {
+	// WriteStats returns the statistics for the chunks written since
+	// the driver was opened. They're updated incrementally on each
+	// write, so it's cheap to call. Reuse is not populated.
+	WriteStats() *DedupStats
+	// ScanStats iterates over all the stored files and chunks and
+	// returns the statistics for the whole blobstore.
+	ScanStats() (*DedupStats, error)
+}
@@ -35,6 +35,28 @@ type Iterable interface {
 	Iter() (Iter, error)
 }
 
+// Appender is the interface implemented by drivers which can append
+// data to an existing file without rewriting it.
+type Appender interface {
+	// Append returns a WFile which writes at the end of the existing
+	// file with the given id. Calling SetMetadata on it replaces the
+	// metadata of the file. Drivers should make the new data and
+	// metadata visible atomically when the WFile is closed, whenever
+	// their backend allows it. Size is the length of the file data
+	// recorded in its metadata. Drivers which don't store the data
+	// and the metadata together must discard any data past it, which
+	// might have been left by an interrupted append.
+	Append(id string, size int64) (WFile, error)
+}
+
+// Aborter is the interface implemented by WFiles which can discard
+// the data written to them without creating or replacing the file.
+type Aborter interface {
+	// Abort discards the data written so far and releases the
+	// WFile, which must not be used again.
+	Abort() error
+}
+
 type Range interface {
 	IsValid() bool
 	Range() (*int64, *int64)
@@ -9,4 +9,21 @@
 //
 //  file:///var/data/files - absolute path
 //  file://storage - relative path, files are stored in the storage dir relative to the binary
+//
+// Files are sharded into nested directories named after
+// pairs of characters taken from the end of their ids
+// (e.g. the file 5a1b2c3d is stored at 3d/2c/5a1b), to avoid
+// storing a huge number of files in a single directory. The
+// number of nested directories might be set with the levels
+// option (e.g. file://storage#levels=3), which defaults to
+// DefaultLevels. Files stored with a different number of levels,
+// like the ones stored by previous versions, which always used 1
+// level, are moved to the current layout when they're opened.
+// See Migrate for moving all of them at once.
+//
+// Files are written to a temporary directory and synced to disk
+// before being moved to their final location, so a crash never
+// leaves a partially written file. Additionally, the fsync option
+// (e.g. file://storage#fsync=1) also syncs the directory entries
+// after moving a file, at the cost of slower writes.
 package file
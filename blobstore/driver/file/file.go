@@ -2,7 +2,6 @@ package file
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
@@ -14,9 +13,20 @@ import (
 	"gnd.la/util/pathutil"
 )
 
+const (
+	// DefaultLevels is the default number of nested directories
+	// used for sharding the files.
+	DefaultLevels = 2
+	// MaxLevels is the maximum number of nested directories
+	// used for sharding the files.
+	MaxLevels = 3
+)
+
 type fsDriver struct {
-	dir    string
-	tmpDir string
+	dir      string
+	tmpDir   string
+	levels   int
+	syncDirs bool
 }
 
 type rfile os.File
@@ -42,30 +52,185 @@ func (f *fsDriver) tmp(id string) string {
 }
 
 func (f *fsDriver) path(id string) string {
-	// Use the last two bytes as the dirname, since the
-	// two first increase monotonically with time
+	return shardPath(f.dir, id, f.levels)
+}
+
+// shardPath returns the path for the file with the given id using
+// the given number of nested directories. Directory names are formed
+// by taking 2 characters at a time from the end of the id, since the
+// ones at the beginning increase monotonically with time.
+func shardPath(dir string, id string, levels int) string {
 	ext := path.Ext(id)
 	if ext != "" {
 		id = id[:len(id)-len(ext)]
 	}
-	sep := len(id) - 2
-	return filepath.Join(f.dir, id[sep:], id[:sep]+ext)
+	parts := []string{dir}
+	for ii := 0; ii < levels && len(id) > 2; ii++ {
+		sep := len(id) - 2
+		parts = append(parts, id[sep:])
+		id = id[:sep]
+	}
+	parts = append(parts, id+ext)
+	return filepath.Join(parts...)
+}
+
+// shardId returns the id for the file with the given name, stored
+// in the given directories relative to the root, in order.
+func shardId(dirs []string, name string) string {
+	ext := path.Ext(name)
+	id := name[:len(name)-len(ext)]
+	for ii := len(dirs) - 1; ii >= 0; ii-- {
+		id += dirs[ii]
+	}
+	return id + ext
+}
+
+// migrate moves the file with the given id and its metadata, if any,
+// from the path it would have with a different number of levels to
+// the current one. Versions prior to sharding support always used
+// 1 level.
+func (f *fsDriver) migrate(id string) error {
+	moved := false
+	for levels := 1; levels <= MaxLevels; levels++ {
+		if levels == f.levels {
+			continue
+		}
+		ids := []string{id}
+		if !strings.HasSuffix(id, ".meta") {
+			ids = append(ids, id+".meta")
+		}
+		for _, v := range ids {
+			if err := moveFile(shardPath(f.dir, v, levels), f.path(v), f.syncDirs); err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return err
+			}
+			moved = true
+		}
+		if moved {
+			return nil
+		}
+	}
+	return os.ErrNotExist
+}
+
+// openMigrating opens the file with the given id using the open
+// function, migrating it first when it's stored using a different
+// number of levels. Note that the open is retried even when the
+// migration fails, since the file might have been migrated by
+// another goroutine or process in the meantime.
+func (f *fsDriver) openMigrating(id string, open func(string) (*os.File, error)) (*os.File, error) {
+	fp, err := open(f.path(id))
+	if os.IsNotExist(err) {
+		merr := f.migrate(id)
+		fp, err = open(f.path(id))
+		if err != nil && merr != nil && !os.IsNotExist(merr) {
+			err = merr
+		}
+	}
+	return fp, err
+}
+
+// moveFile renames src to dst, creating the required directories.
+// If syncDir is true, the directory entries for dst, for the
+// directories created to hold it and for src are synced too.
+func moveFile(src string, dst string, syncDir bool) error {
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+	dir := filepath.Dir(dst)
+	existing := dir
+	if syncDir {
+		for !fileutil.DirExists(existing) {
+			parent := filepath.Dir(existing)
+			if parent == existing {
+				break
+			}
+			existing = parent
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return err
+	}
+	if syncDir {
+		// Sync up to the first directory which already existed,
+		// since the entries for the new ones live in their parents.
+		for d := dir; ; d = filepath.Dir(d) {
+			if err := fsyncDir(d); err != nil {
+				return err
+			}
+			if d == existing || d == filepath.Dir(d) {
+				break
+			}
+		}
+		if srcDir := filepath.Dir(src); srcDir != dir {
+			return fsyncDir(srcDir)
+		}
+	}
+	return nil
+}
+
+// fsyncDir flushes the directory entries in dir to
+// stable storage.
+func fsyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
 }
 
 func (f *fsDriver) Create(id string) (driver.WFile, error) {
-	tmp := filepath.Join(f.tmpDir, id)
-	fp, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE, 0644)
+	fp, err := os.OpenFile(f.tmp(id), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
 	if err != nil {
 		return nil, err
 	}
 	return &wfile{
-		File: fp,
-		path: f.path(id),
+		File:    fp,
+		path:    f.path(id),
+		syncDir: f.syncDirs,
 	}, nil
 }
 
+// Append opens the file with the given id for appending. Since the
+// metadata is stored in a separate file, written after the appended
+// data has been flushed, a crash in between leaves some data past
+// size, which is truncated here.
+func (f *fsDriver) Append(id string, size int64) (driver.WFile, error) {
+	fp, err := f.openMigrating(id, func(p string) (*os.File, error) {
+		return os.OpenFile(p, os.O_WRONLY|os.O_APPEND, 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+	st, err := fp.Stat()
+	if err != nil {
+		fp.Close()
+		return nil, err
+	}
+	if st.Size() < size {
+		fp.Close()
+		return nil, fmt.Errorf("file %s has %d bytes, expecting at least %d", id, st.Size(), size)
+	}
+	if st.Size() > size {
+		if err := fp.Truncate(size); err != nil {
+			fp.Close()
+			return nil, err
+		}
+	}
+	return &afile{File: fp}, nil
+}
+
 func (f *fsDriver) Open(id string) (driver.RFile, error) {
-	r, err := os.Open(f.path(id))
+	r, err := f.openMigrating(id, os.Open)
 	if err != nil {
 		return nil, err
 	}
@@ -76,16 +241,29 @@ func (f *fsDriver) Open(id string) (driver.RFile, error) {
 	// data in legacy format.
 	if !strings.HasSuffix(id, ".meta") {
 		metaPath := f.path(id + ".meta")
-		_, err := os.Stat(metaPath)
-		if err != nil {
-			return readLegacyFile(r)
+		if _, err := os.Stat(metaPath); err != nil {
+			// As in openMigrating, check again if the migration
+			// fails, since it might have been done concurrently.
+			if f.migrate(id+".meta") != nil && !fileutil.FileExists(metaPath) {
+				return readLegacyFile(r)
+			}
 		}
 	}
 	return (*rfile)(r), err
 }
 
 func (f *fsDriver) Remove(id string) error {
-	return os.Remove(f.path(id))
+	err := os.Remove(f.path(id))
+	if os.IsNotExist(err) {
+		for levels := 1; levels <= MaxLevels; levels++ {
+			if levels != f.levels {
+				if rerr := os.Remove(shardPath(f.dir, id, levels)); rerr == nil {
+					return nil
+				}
+			}
+		}
+	}
+	return err
 }
 
 func (f *fsDriver) Close() error {
@@ -93,20 +271,10 @@ func (f *fsDriver) Close() error {
 }
 
 func (f *fsDriver) Iter() (driver.Iter, error) {
-	res, err := ioutil.ReadDir(f.dir)
-	if err != nil {
+	if _, err := os.Stat(f.dir); err != nil {
 		return nil, err
 	}
-	var dirs []string
-	for _, v := range res {
-		if v.IsDir() {
-			name := v.Name()
-			if name != "tmp" || name[0] != '.' {
-				dirs = append(dirs, filepath.Join(f.dir, name))
-			}
-		}
-	}
-	return &fsIter{dirs: dirs}, nil
+	return &fsIter{root: f.dir, pending: []*fsIterDir{{path: f.dir}}}, nil
 }
 
 func fsOpener(url *config.URL) (driver.Driver, error) {
@@ -121,18 +289,35 @@ func fsOpener(url *config.URL) (driver.Driver, error) {
 	if err := os.MkdirAll(tmpDir, 0755); err != nil {
 		return nil, err
 	}
+	levels := DefaultLevels
+	if l := url.Fragment.Get("levels"); l != "" {
+		val, ok := url.Fragment.Int("levels")
+		if !ok || val < 1 || val > MaxLevels {
+			return nil, fmt.Errorf("invalid levels %q, must be an integer between 1 and %d", l, MaxLevels)
+		}
+		levels = val
+	}
 	return &fsDriver{
-		dir:    value,
-		tmpDir: tmpDir,
+		dir:      value,
+		tmpDir:   tmpDir,
+		levels:   levels,
+		syncDirs: url.Fragment.Get("fsync") != "",
 	}, nil
 }
 
+type fsIterDir struct {
+	path string
+	// dirs relative to the root
+	dirs []string
+}
+
+// fsIter walks the directories lazily, so it works with any number
+// of levels, including mixed ones while migrating.
 type fsIter struct {
-	dirs     []string
-	base     string
-	dirIndex int
-	names    []string
-	err      error
+	root    string
+	pending []*fsIterDir
+	ids     []string
+	err     error
 }
 
 func (f *fsIter) Next(id *string) bool {
@@ -140,34 +325,54 @@ func (f *fsIter) Next(id *string) bool {
 		var discard string
 		id = &discard
 	}
-	for len(f.names) == 0 {
-		if f.dirIndex >= len(f.dirs) {
+	for len(f.ids) == 0 {
+		if len(f.pending) == 0 || f.err != nil {
 			*id = ""
 			return false
 		}
-		cur := f.dirs[f.dirIndex]
-		dir, err := os.Open(cur)
-		if err != nil {
+		cur := f.pending[len(f.pending)-1]
+		f.pending = f.pending[:len(f.pending)-1]
+		if err := f.readDir(cur); err != nil {
 			f.err = err
 			return false
 		}
-		names, err := dir.Readdirnames(-1)
-		dir.Close()
-		if err != nil {
-			f.err = err
-			return false
+	}
+	*id = f.ids[0]
+	f.ids = f.ids[1:]
+	return true
+}
+
+func (f *fsIter) readDir(d *fsIterDir) error {
+	dir, err := os.Open(d.path)
+	if err != nil {
+		return err
+	}
+	infos, err := dir.Readdir(-1)
+	dir.Close()
+	if err != nil {
+		return err
+	}
+	for _, v := range infos {
+		name := v.Name()
+		if name[0] == '.' {
+			continue
 		}
-		for _, v := range names {
-			if filepath.Ext(v) != ".meta" {
-				f.names = append(f.names, v)
+		if v.IsDir() {
+			if len(d.dirs) == 0 && name == "tmp" {
+				continue
 			}
+			dirs := make([]string, len(d.dirs)+1)
+			copy(dirs, d.dirs)
+			dirs[len(d.dirs)] = name
+			f.pending = append(f.pending, &fsIterDir{path: filepath.Join(d.path, name), dirs: dirs})
+			continue
+		}
+		if len(d.dirs) == 0 || filepath.Ext(name) == ".meta" {
+			continue
 		}
-		f.base = filepath.Base(cur)
-		f.dirIndex++
+		f.ids = append(f.ids, shardId(d.dirs, name))
 	}
-	*id = f.names[0] + f.base
-	f.names = f.names[1:]
-	return true
+	return nil
 }
 
 func (f *fsIter) Err() error {
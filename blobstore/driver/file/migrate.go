@@ -0,0 +1,43 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Migrate moves the files in the file based blobstore at dir to the
+// layout with the given number of levels (see the package documentation).
+// Files stored with any other number of levels, including the single
+// level used by versions prior to sharding support, are moved. Note
+// that the blobstore also migrates files lazily when they're opened,
+// so calling this function is only required to finish the migration
+// at once, and that it must not run while the blobstore is open with
+// a different number of levels.
+func Migrate(dir string, levels int, fsync bool) error {
+	tmpDir := filepath.Join(dir, "tmp")
+	return filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if p == tmpDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(rel, string(filepath.Separator))
+		if len(parts) < 2 || strings.HasPrefix(parts[len(parts)-1], ".") {
+			return nil
+		}
+		id := shardId(parts[:len(parts)-1], parts[len(parts)-1])
+		if dst := shardPath(dir, id, levels); dst != p {
+			return moveFile(p, dst, fsync)
+		}
+		return nil
+	})
+}
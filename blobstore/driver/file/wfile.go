@@ -2,14 +2,14 @@ package file
 
 import (
 	"os"
-	"path/filepath"
 
 	"gnd.la/blobstore/driver"
 )
 
 type wfile struct {
 	*os.File
-	path string
+	path    string
+	syncDir bool
 }
 
 func (f *wfile) SetMetadata(_ []byte) error {
@@ -17,17 +17,48 @@ func (f *wfile) SetMetadata(_ []byte) error {
 }
 
 func (f *wfile) Close() error {
-	// Close the file
-	if err := f.File.Close(); err != nil {
+	// Flush the data before moving the file, otherwise
+	// a crash might leave an empty or truncated file
+	// at its final destination.
+	if err := f.File.Sync(); err != nil {
+		f.File.Close()
+		os.Remove(f.Name())
 		return err
 	}
-	// Create dirs if needed
-	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.Name())
 		return err
 	}
-	// Move the file to its final destination
-	if err := os.Rename(f.Name(), f.path); err != nil {
+	// Move the file to its final destination, creating
+	// dirs if needed.
+	if err := moveFile(f.Name(), f.path, f.syncDir); err != nil {
+		os.Remove(f.Name())
 		return err
 	}
 	return nil
 }
+
+func (f *wfile) Abort() error {
+	err := f.File.Close()
+	os.Remove(f.Name())
+	return err
+}
+
+// afile is a file opened for appending. Data is written
+// directly to the file, since copying it to a temporary
+// file would defeat the purpose of appending.
+type afile struct {
+	*os.File
+}
+
+func (f *afile) SetMetadata(_ []byte) error {
+	return driver.ErrMetadataNotHandled
+}
+
+func (f *afile) Close() error {
+	if err := f.File.Sync(); err != nil {
+		f.File.Close()
+		return err
+	}
+	return f.File.Close()
+}
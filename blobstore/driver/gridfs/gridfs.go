@@ -67,6 +67,15 @@ func (w *wfile) Close() error {
 	return (*mgo.GridFile)(w).Close()
 }
 
+func (w *wfile) Abort() error {
+	f := (*mgo.GridFile)(w)
+	f.Abort()
+	// Close removes the chunks written so far and
+	// always returns an error after aborting.
+	f.Close()
+	return nil
+}
+
 type gridfsDriver struct {
 	fs      *mgo.GridFS
 	session *mgo.Session
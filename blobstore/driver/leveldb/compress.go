@@ -0,0 +1,146 @@
+package leveldb
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+type compression byte
+
+const (
+	compressNone compression = iota
+	compressSnappy
+	compressZstd
+)
+
+const (
+	// chunkKeyPrefix is prepended to the SHA1 of the chunks
+	// stored with a header. Chunks written before compression
+	// was supported use just their SHA1 as the key and have
+	// no header.
+	chunkKeyPrefix = 'c'
+	// Header is the compression (1 byte) followed by the
+	// uncompressed size (uint32).
+	chunkHeaderSize = 5
+)
+
+var (
+	compressions = map[string]compression{
+		"":       compressNone,
+		"none":   compressNone,
+		"snappy": compressSnappy,
+		"zstd":   compressZstd,
+	}
+
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+func parseCompression(s string) (compression, error) {
+	c, ok := compressions[s]
+	if !ok {
+		return compressNone, fmt.Errorf("invalid leveldb compression %q, must be snappy, zstd or none", s)
+	}
+	return c, nil
+}
+
+func zstdCodec() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		if zstdEncoder, zstdErr = zstd.NewWriter(nil); zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+	return zstdEncoder, zstdDecoder, zstdErr
+}
+
+// chunkKey returns the key for storing a chunk with a header
+// from its SHA1.
+func chunkKey(hash []byte) []byte {
+	key := make([]byte, 1+len(hash))
+	key[0] = chunkKeyPrefix
+	copy(key[1:], hash)
+	return key
+}
+
+// hasHeader returns true iff the chunk stored with the given
+// key starts with a header.
+func hasHeader(key []byte) bool {
+	return len(key) != sha1.Size
+}
+
+// encodeChunk returns the value stored for the given data, compressed
+// with c. If compression doesn't reduce its size, data is stored as is.
+func encodeChunk(c compression, data []byte) ([]byte, error) {
+	var compressed []byte
+	switch c {
+	case compressSnappy:
+		compressed = snappy.Encode(nil, data)
+	case compressZstd:
+		enc, _, err := zstdCodec()
+		if err != nil {
+			return nil, err
+		}
+		compressed = enc.EncodeAll(data, nil)
+	}
+	if c == compressNone || len(compressed) >= len(data) {
+		c = compressNone
+		compressed = data
+	}
+	value := make([]byte, chunkHeaderSize+len(compressed))
+	value[0] = byte(c)
+	littleEndian.PutUint32(value[1:], uint32(len(data)))
+	copy(value[chunkHeaderSize:], compressed)
+	return value, nil
+}
+
+// chunkLen returns the uncompressed size of the chunk stored
+// with the given key and value.
+func chunkLen(key []byte, value []byte) int {
+	if !hasHeader(key) || len(value) < chunkHeaderSize {
+		return len(value)
+	}
+	return int(littleEndian.Uint32(value[1:]))
+}
+
+// decodeChunk returns the data for the chunk stored with the
+// given key and value.
+func decodeChunk(key []byte, value []byte) ([]byte, error) {
+	if !hasHeader(key) {
+		return value, nil
+	}
+	if len(value) < chunkHeaderSize {
+		return nil, fmt.Errorf("chunk %x is too short (%d bytes)", key, len(value))
+	}
+	size := int(littleEndian.Uint32(value[1:]))
+	payload := value[chunkHeaderSize:]
+	var data []byte
+	var err error
+	switch compression(value[0]) {
+	case compressNone:
+		data = payload
+	case compressSnappy:
+		data, err = snappy.Decode(make([]byte, size), payload)
+	case compressZstd:
+		_, dec, zerr := zstdCodec()
+		if zerr != nil {
+			return nil, zerr
+		}
+		data, err = dec.DecodeAll(payload, make([]byte, 0, size))
+	default:
+		return nil, fmt.Errorf("chunk %x has unknown compression %d", key, value[0])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing chunk %x: %v", key, err)
+	}
+	if len(data) != size {
+		return nil, fmt.Errorf("chunk %x has %d bytes, expecting %d", key, len(data), size)
+	}
+	return data, nil
+}
@@ -13,4 +13,25 @@
 //
 //  leveldb:///var/data/files - absolute path
 //  leveldb://storage - relative path, files are stored in the storage dir relative to the binary
+//
+// Files are split into 256KiB chunks, which are stored by their SHA1
+// hash, so identical chunks are only stored once. The deduplication
+// statistics are available from gnd.la/blobstore.Blobstore.DedupStats
+// (for the data written since the blobstore was opened) and from
+// gnd.la/blobstore.Blobstore.Usage (for the whole blobstore). Note that
+// chunks are never removed, so removing files will leave unreferenced
+// chunks, reported in the first bucket of DedupStats.Reuse.
+//
+// Chunks might also be compressed individually by setting the compress
+// option in the fragment to snappy or zstd (zstd usually achieves better
+// ratios for text) e.g.
+//
+//  leveldb:///var/data/files#compress=zstd
+//
+// Each chunk is stored with a header indicating its compression, so the
+// option might be changed at any time and chunks written with a different
+// one (or before compression was supported) are still read transparently.
+// Chunks which don't shrink when compressed are stored uncompressed. Note
+// that leveldb compresses its blocks with snappy too, unless the nocompress
+// option is set, so compress=snappy is mostly useful along with it.
 package leveldb
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"path/filepath"
+	"sync"
 
 	"gnd.la/blobstore/driver"
 	"gnd.la/config"
@@ -20,18 +21,69 @@ import (
 var (
 	syncOptions       = &opt.WriteOptions{Sync: true}
 	checkChunkOptions = &opt.ReadOptions{DontFillCache: true, Strict: opt.NoStrict}
+	scanOptions       = &opt.ReadOptions{DontFillCache: true}
 )
 
 type leveldbDriver struct {
-	files  *leveldb.DB
-	chunks *leveldb.DB
-	dir    string
+	files       *leveldb.DB
+	chunks      *leveldb.DB
+	dir         string
+	compression compression
+	statsMu     sync.Mutex
+	stats       driver.DedupStats
 }
 
 func (d *leveldbDriver) Create(id string) (driver.WFile, error) {
 	return newWFile(d, id), nil
 }
 
+// Append returns a wfile which starts with the chunks of the file
+// with the given id. If the last chunk is not full, it's removed from
+// the chunk list and its data is written again, so the appended data
+// gets split into the same chunks as if it had been written at once.
+// The chunk list is only replaced when the wfile is closed. Since the
+// chunk list and the metadata are stored in the same record, they're
+// always consistent and size can be ignored.
+func (d *leveldbDriver) Append(id string, _ int64) (driver.WFile, error) {
+	value, err := d.files.Get(internal.StringToBytes(id), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil, fmt.Errorf("file %s not found", id)
+		}
+		return nil, err
+	}
+	metadata, keys, last, err := decodeFile(id, value)
+	if err != nil {
+		return nil, err
+	}
+	w := newWFile(d, id)
+	w.metadata = metadata
+	w.chunks = keys
+	if count := len(keys); count > 0 {
+		key := w.chunks[count-1]
+		if last, err = d.chunks.Get(key, nil); err != nil {
+			if err == leveldb.ErrNotFound {
+				return nil, fmt.Errorf("chunk %s in file %s not found", hex.EncodeToString(key), id)
+			}
+			return nil, err
+		}
+		if last, err = decodeChunk(key, last); err != nil {
+			return nil, err
+		}
+		if len(last) == chunkSize {
+			last = nil
+		} else {
+			w.chunks = w.chunks[:count-1]
+		}
+	}
+	if len(last) > 0 {
+		if _, err := w.Chunker.Write(last); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
 func (d *leveldbDriver) Open(id string) (driver.RFile, error) {
 	value, err := d.files.Get(internal.StringToBytes(id), nil)
 	if err != nil {
@@ -40,22 +92,15 @@ func (d *leveldbDriver) Open(id string) (driver.RFile, error) {
 		}
 		return nil, err
 	}
-	metaLen := int(littleEndian.Uint32(value))
-	value = value[4:]
-	metadata := value[:metaLen]
-	value = value[metaLen:]
-	count := int(littleEndian.Uint32(value))
-	value = value[4:]
-	if count == 0 {
-		// Data is inline
-		return &rfile{metadata: metadata, chunks: [][]byte{value}}, nil
-	}
-	pos := 0
-	chunks := make([][]byte, count)
-	for ii := 0; ii < count; ii++ {
-		size := int(littleEndian.Uint32(value[pos:]))
-		pos += 4
-		key := value[pos : pos+size]
+	metadata, keys, inline, err := decodeFile(id, value)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return &rfile{metadata: metadata, chunks: [][]byte{inline}}, nil
+	}
+	chunks := make([][]byte, len(keys))
+	for ii, key := range keys {
 		chunk, err := d.chunks.Get(key, nil)
 		if err != nil {
 			if err == leveldb.ErrNotFound {
@@ -63,8 +108,9 @@ func (d *leveldbDriver) Open(id string) (driver.RFile, error) {
 			}
 			return nil, err
 		}
-		chunks[ii] = chunk
-		pos += size
+		if chunks[ii], err = decodeChunk(key, chunk); err != nil {
+			return nil, err
+		}
 	}
 	return &rfile{metadata: metadata, chunks: chunks}, nil
 }
@@ -88,11 +134,114 @@ func (d *leveldbDriver) Iter() (driver.Iter, error) {
 	return &leveldbIter{iter: iter}, nil
 }
 
+// addChunk updates the write stats after writing a chunk
+// with the given size, which might be already stored.
+func (d *leveldbDriver) addChunk(size int, stored bool) {
+	d.statsMu.Lock()
+	d.stats.LogicalBytes += uint64(size)
+	d.stats.References++
+	if !stored {
+		d.stats.PhysicalBytes += uint64(size)
+		d.stats.Chunks++
+	}
+	d.statsMu.Unlock()
+}
+
+func (d *leveldbDriver) WriteStats() *driver.DedupStats {
+	d.statsMu.Lock()
+	stats := d.stats
+	d.statsMu.Unlock()
+	return &stats
+}
+
+func (d *leveldbDriver) ScanStats() (*driver.DedupStats, error) {
+	type chunkInfo struct {
+		size int
+		refs int
+	}
+	chunks := make(map[string]*chunkInfo)
+	iter := d.chunks.NewIterator(nil, scanOptions)
+	for iter.Next() {
+		chunks[string(iter.Key())] = &chunkInfo{size: chunkLen(iter.Key(), iter.Value())}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	iter = d.files.NewIterator(nil, scanOptions)
+	for iter.Next() {
+		_, keys, _, err := decodeFile(string(iter.Key()), iter.Value())
+		if err != nil {
+			iter.Release()
+			return nil, err
+		}
+		for _, v := range keys {
+			if info := chunks[string(v)]; info != nil {
+				info.refs++
+			}
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	stats := new(driver.DedupStats)
+	for _, v := range chunks {
+		stats.AddChunk(v.size, v.refs)
+	}
+	return stats, nil
+}
+
+// decodeFile decodes a value stored in the files database, returning
+// its metadata and either its chunk keys or its inline data. The
+// returned slices point into value.
+func decodeFile(id string, value []byte) (metadata []byte, keys [][]byte, inline []byte, err error) {
+	corrupted := fmt.Errorf("file %s is corrupted", id)
+	if len(value) < 4 {
+		return nil, nil, nil, corrupted
+	}
+	metaLen := uint64(littleEndian.Uint32(value))
+	value = value[4:]
+	if uint64(len(value)) < metaLen+4 {
+		return nil, nil, nil, corrupted
+	}
+	metadata = value[:metaLen]
+	value = value[metaLen:]
+	count := int(littleEndian.Uint32(value))
+	value = value[4:]
+	if count == 0 {
+		// Data is inline
+		return metadata, nil, value, nil
+	}
+	// Each chunk needs at least its 4 bytes length
+	if count > len(value)/4 {
+		return nil, nil, nil, corrupted
+	}
+	keys = make([][]byte, count)
+	for ii := range keys {
+		if len(value) < 4 {
+			return nil, nil, nil, corrupted
+		}
+		size := uint64(littleEndian.Uint32(value))
+		value = value[4:]
+		if uint64(len(value)) < size {
+			return nil, nil, nil, corrupted
+		}
+		keys[ii] = value[:size]
+		value = value[size:]
+	}
+	return metadata, keys, nil, nil
+}
+
 func leveldbOpener(url *config.URL) (driver.Driver, error) {
 	value := url.Value
 	if !filepath.IsAbs(value) {
 		value = pathutil.Relative(value)
 	}
+	comp, err := parseCompression(url.Fragment["compress"])
+	if err != nil {
+		return nil, err
+	}
 	opts := &opt.Options{}
 	if url.Fragment["nocompress"] != "" {
 		opts.Compression = opt.NoCompression
@@ -113,9 +262,10 @@ func leveldbOpener(url *config.URL) (driver.Driver, error) {
 		return nil, err
 	}
 	return &leveldbDriver{
-		files:  files,
-		chunks: chunks,
-		dir:    value,
+		files:       files,
+		chunks:      chunks,
+		dir:         value,
+		compression: comp,
 	}, nil
 }
 
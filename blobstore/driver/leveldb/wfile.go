@@ -29,28 +29,47 @@ type wfile struct {
 	chunks    [][]byte
 	batch     *leveldb.Batch
 	batchSize int
-	metadata  []byte
+	// hashes of the chunks in batch
+	pending  map[[sha1.Size]byte]bool
+	metadata []byte
 	chunk.Chunker
 }
 
 func (f *wfile) WriteChunk(data []byte) error {
 	h := sha1.Sum(data)
-	hash := h[:]
-	f.chunks = append(f.chunks, hash)
-	if ch, err := f.drv.chunks.Get(hash, nil); err == nil {
-		if len(ch) != len(data) {
-			return errors.New("hash collision")
-		}
-		// Chunk already known. Ignore errors != nil here, since
-		// the worst thing that could happen could be overwriting
-		// an existing chunk with the same data. If there was an error
-		// reading the db, we'll get an error when putting the data
-		// a few lines later.
+	key := chunkKey(h[:])
+	f.chunks = append(f.chunks, key)
+	if f.pending[h] {
+		// Chunk repeated in the same batch
+		f.drv.addChunk(len(data), true)
 		return nil
 	}
+	// Chunks written before compression was supported are
+	// stored by their SHA1, reuse them too.
+	for _, k := range [][]byte{key, h[:]} {
+		if ch, err := f.drv.chunks.Get(k, nil); err == nil {
+			if chunkLen(k, ch) != len(data) {
+				return errors.New("hash collision")
+			}
+			// Chunk already known. Ignore errors != nil here, since
+			// the worst thing that could happen could be overwriting
+			// an existing chunk with the same data. If there was an error
+			// reading the db, we'll get an error when putting the data
+			// a few lines later.
+			f.chunks[len(f.chunks)-1] = k
+			f.drv.addChunk(len(data), true)
+			return nil
+		}
+	}
+	value, err := encodeChunk(f.drv.compression, data)
+	if err != nil {
+		return err
+	}
 	// Not found, put it into the writing queue
-	f.batch.Put(hash, data)
-	f.batchSize += len(data)
+	f.drv.addChunk(len(data), false)
+	f.pending[h] = true
+	f.batch.Put(key, value)
+	f.batchSize += len(value)
 	if f.batchSize >= maxBatchSize {
 		return f.flushBatch()
 	}
@@ -61,6 +80,9 @@ func (f *wfile) flushBatch() error {
 	err := f.drv.chunks.Write(f.batch, nil)
 	f.batchSize = 0
 	f.batch.Reset()
+	for k := range f.pending {
+		delete(f.pending, k)
+	}
 	return err
 }
 
@@ -90,8 +112,11 @@ func (f *wfile) Close() error {
 	if err := f.flushBatch(); err != nil {
 		return err
 	}
-	// Reserve uint32 + len(metadata) + n sha1 hashes + n uint32 + 1 uint32 (for the chunk count)
-	total := 4 + len(f.metadata) + (len(f.chunks) * (sha1.Size + 4)) + 4
+	// Reserve uint32 + len(metadata) + n keys + n uint32 + 1 uint32 (for the chunk count)
+	total := 4 + len(f.metadata) + 4
+	for _, chunk := range f.chunks {
+		total += 4 + len(chunk)
+	}
 	data := make([]byte, total)
 	out := putMetadata(data, f.metadata)
 	littleEndian.PutUint32(out, uint32(len(f.chunks)))
@@ -117,7 +142,12 @@ func newWFile(drv *leveldbDriver, id string) *wfile {
 		w.Chunker.Reset()
 		return w
 	}
-	w := &wfile{drv: drv, id: id, batch: new(leveldb.Batch)}
+	w := &wfile{
+		drv:     drv,
+		id:      id,
+		batch:   new(leveldb.Batch),
+		pending: make(map[[sha1.Size]byte]bool),
+	}
 	w.Chunker = fixed.New(w, chunkSize)
 	return w
 }
@@ -63,6 +63,11 @@ func (w *wfile) Close() error {
 	return w.bucket.Put(w.id, w.buf.Bytes(), "", s3.Private)
 }
 
+func (w *wfile) Abort() error {
+	w.buf.Reset()
+	return nil
+}
+
 type s3Driver struct {
 	bucket *s3.Bucket
 }
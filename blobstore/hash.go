@@ -1,6 +1,8 @@
 package blobstore
 
 import (
+	"encoding"
+	"encoding/binary"
 	"hash"
 	"hash/fnv"
 )
@@ -8,3 +10,18 @@ import (
 func newHash() hash.Hash64 {
 	return fnv.New64a()
 }
+
+// resumeHash returns a hash which continues from the given sum,
+// as if the data which produced it had been written to it. This
+// works because the state of fnv64a is just its current sum.
+func resumeHash(sum uint64) (hash.Hash64, error) {
+	h := newHash()
+	// See the marshaled state format in hash/fnv
+	state := make([]byte, 12)
+	copy(state, "fnv\x04")
+	binary.BigEndian.PutUint64(state[4:], sum)
+	if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
@@ -1,6 +1,8 @@
 package blobstore
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"hash/adler32"
 	"io"
@@ -9,14 +11,19 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
-	_ "gnd.la/blobstore/driver/file"
+	"gnd.la/blobstore/driver"
+	"gnd.la/blobstore/driver/file"
 	_ "gnd.la/blobstore/driver/gridfs"
 	_ "gnd.la/blobstore/driver/leveldb"
 	_ "gnd.la/blobstore/driver/s3"
 	"gnd.la/config"
+
+	"github.com/syndtr/goleveldb/leveldb"
 )
 
 const (
@@ -169,6 +176,153 @@ func TestFileStoreMeta(t *testing.T) {
 	testStore(t, &Meta{Foo: 5}, cfg)
 }
 
+func TestFileStoreLevels(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pool-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testStore(t, &Meta{Foo: 5}, "file://"+dir+"#levels=3&fsync=1")
+}
+
+func TestFileStoreMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pool-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	openStore := func(cfg string) *Blobstore {
+		store, err := New(config.MustParseURL(cfg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	}
+	// Store files using the layout from previous versions
+	store := openStore("file://" + dir + "#levels=1")
+	data := make(map[string][]byte)
+	for ii := 0; ii < 5; ii++ {
+		b := randData(1024)
+		id, err := store.Store(b, &Meta{Foo: ii})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data[id] = b
+	}
+	store.Close()
+	check := func(store *Blobstore) {
+		ids := make(map[string]bool)
+		iter, err := store.Iter()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var id string
+		for iter.Next(&id) {
+			ids[id] = true
+		}
+		if err := iter.Err(); err != nil {
+			t.Fatal(err)
+		}
+		iter.Close()
+		if len(ids) != len(data) {
+			t.Errorf("expecting %d files from Iter, got %d", len(data), len(ids))
+		}
+		for id, b := range data {
+			if !ids[id] {
+				t.Errorf("file %s not returned by Iter", id)
+			}
+			f, err := store.Open(id)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			var m Meta
+			if err := f.GetMeta(&m); err != nil {
+				t.Errorf("error loading metadata from %s: %s", id, err)
+			}
+			got, err := f.ReadAll()
+			f.Close()
+			if err != nil {
+				t.Error(err)
+			} else if string(got) != string(b) {
+				t.Errorf("invalid data for file %s", id)
+			}
+		}
+	}
+	// Files are migrated lazily
+	store = openStore("file://" + dir)
+	check(store)
+	store.Close()
+	if err := file.Migrate(dir, 3, false); err != nil {
+		t.Fatal(err)
+	}
+	store = openStore("file://" + dir + "#levels=3")
+	check(store)
+	store.Close()
+	for id := range data {
+		if _, err := os.Stat(filepath.Join(dir, id[len(id)-2:], id[len(id)-4:len(id)-2], id[len(id)-6:len(id)-4], id[:len(id)-6])); err != nil {
+			t.Errorf("file %s was not migrated: %s", id, err)
+		}
+	}
+}
+
+func TestFileStoreConcurrentMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pool-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	old, err := New(config.MustParseURL("file://" + dir + "#levels=1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make(map[string][]byte)
+	for ii := 0; ii < 20; ii++ {
+		b := randData(1024)
+		id, err := old.Store(b, &Meta{Foo: ii})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data[id] = b
+	}
+	old.Close()
+	store, err := New(config.MustParseURL("file://" + dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	// All the readers must get the files, even if another
+	// one migrates them while they're trying to open them.
+	const readers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, readers*len(data))
+	start := make(chan struct{})
+	for id, b := range data {
+		for ii := 0; ii < readers; ii++ {
+			wg.Add(1)
+			go func(id string, b []byte) {
+				defer wg.Done()
+				<-start
+				f, err := store.Open(id)
+				if err != nil {
+					errs <- err
+					return
+				}
+				defer f.Close()
+				if got, err := f.ReadAll(); err != nil || !bytes.Equal(got, b) {
+					errs <- fmt.Errorf("invalid data for file %s (error %v)", id, err)
+				}
+			}(id, b)
+		}
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
 func TestGridfs(t *testing.T) {
 	if !testPort(27017) {
 		t.Skip("mongodb is not running. start mongodb on localhost to run this test")
@@ -195,6 +349,326 @@ func TestLevelDB(t *testing.T) {
 	testStore(t, &Meta{Foo: 5}, cfg)
 }
 
+func TestLevelDBDedup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pool-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := New(config.MustParseURL("leveldb://" + dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	const chunkSize = 256 * 1024
+	data := randData(4 * chunkSize)
+	zeros := make([]byte, 2*chunkSize)
+	var ids []string
+	for _, v := range [][]byte{data, data, zeros} {
+		id, err := store.Store(v, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	// 4 distinct chunks stored twice, 1 chunk repeated in the same file
+	ws := store.DedupStats()
+	if ws == nil {
+		t.Fatal("expecting dedup stats from leveldb driver")
+	}
+	if ws.References != 10 || ws.Chunks != 5 {
+		t.Errorf("expecting 10 references to 5 chunks, got %d to %d", ws.References, ws.Chunks)
+	}
+	if exp := uint64(10 * chunkSize); ws.LogicalBytes != exp {
+		t.Errorf("expecting %d logical bytes, got %d", exp, ws.LogicalBytes)
+	}
+	if exp := uint64(5 * chunkSize); ws.PhysicalBytes != exp {
+		t.Errorf("expecting %d physical bytes, got %d", exp, ws.PhysicalBytes)
+	}
+	if r := ws.Ratio(); r != 2 {
+		t.Errorf("expecting ratio 2, got %v", r)
+	}
+	files, size, err := store.Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files != 3 || size != uint64(10*chunkSize) {
+		t.Errorf("expecting 3 files with %d bytes, got %d with %d", 10*chunkSize, files, size)
+	}
+	usage, err := store.UsageStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Files != 3 || usage.Size != uint64(10*chunkSize) {
+		t.Errorf("expecting 3 files with %d bytes, got %d with %d", 10*chunkSize, usage.Files, usage.Size)
+	}
+	if d := usage.Dedup; d == nil {
+		t.Error("expecting dedup stats in usage")
+	} else {
+		if d.References != ws.References || d.Chunks != ws.Chunks || d.LogicalBytes != ws.LogicalBytes || d.PhysicalBytes != ws.PhysicalBytes {
+			t.Errorf("scanned stats %+v do not match write stats %+v", d, ws)
+		}
+		if exp := []int{0, 0, 5}; !reflect.DeepEqual(d.Reuse, exp) {
+			t.Errorf("expecting reuse histogram %v, got %v", exp, d.Reuse)
+		}
+	}
+	// Chunks are not removed, so the zeros chunk is left unreferenced
+	if err := store.Remove(ids[2]); err != nil {
+		t.Fatal(err)
+	}
+	usage, err = store.UsageStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []int{1, 0, 4}; !reflect.DeepEqual(usage.Dedup.Reuse, exp) {
+		t.Errorf("expecting reuse histogram %v after removing, got %v", exp, usage.Dedup.Reuse)
+	}
+}
+
+// noAppendDriver hides the driver.Appender implementation
+// from the wrapped driver, to test the emulated appends.
+type noAppendDriver struct {
+	driver.Driver
+}
+
+type failingReader struct {
+	data []byte
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, errors.New("read failed")
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// blockingReader sends to ch when it's first read and then
+// waits for a value from ch before returning io.EOF.
+type blockingReader struct {
+	ch chan struct{}
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	r.ch <- struct{}{}
+	<-r.ch
+	return 0, io.EOF
+}
+
+func testAppend(t *testing.T, cfg string, emulate bool) {
+	store, err := New(config.MustParseURL(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if emulate {
+		store.drv = noAppendDriver{store.drv}
+	}
+	expected := randData(300 * 1024)
+	id, err := store.Store(expected, &Meta{Foo: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func() {
+		f, err := store.Open(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := f.Check(); err != nil {
+			t.Errorf("file is corrupted after appending: %s", err)
+		}
+		var m Meta
+		if err := f.GetMeta(&m); err != nil || m.Foo != 7 {
+			t.Errorf("expecting metadata to be preserved, got %+v (error %v)", m, err)
+		}
+		if size, err := f.Size(); err != nil || size != uint64(len(expected)) {
+			t.Errorf("expecting size %d, got %d (error %v)", len(expected), size, err)
+		}
+		data, err := f.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, expected) {
+			t.Errorf("invalid data after appending (%d bytes vs %d expected)", len(data), len(expected))
+		}
+	}
+	for _, size := range []int{100, 600 * 1024, 1} {
+		data := randData(size)
+		n, err := store.Append(id, bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != int64(size) {
+			t.Errorf("expecting %d bytes appended, got %d", size, n)
+		}
+		expected = append(expected, data...)
+		check()
+	}
+	// Data read before an error is kept
+	data := randData(1000)
+	if _, err := store.Append(id, &failingReader{data: data}); err == nil {
+		t.Error("expecting an error from a failing reader")
+	}
+	expected = append(expected, data...)
+	check()
+	if stats := store.DedupStats(); stats != nil {
+		// Appended data must be chunked like data written at once
+		chunks := stats.Chunks
+		if _, err := store.Store(expected, nil); err != nil {
+			t.Fatal(err)
+		}
+		if stats := store.DedupStats(); stats.Chunks != chunks {
+			t.Errorf("appended data was chunked differently, %d new chunks", stats.Chunks-chunks)
+		}
+	}
+	if appender, ok := store.drv.(driver.Appender); ok && store.drvNoMeta {
+		// Simulate a crash after flushing the appended data but
+		// before updating the metadata. The next append must
+		// discard the data which was not recorded.
+		w, err := appender.Append(id, int64(len(expected)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(randData(100)); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		data = randData(10)
+		if _, err := store.Append(id, bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+		expected = append(expected, data...)
+		check()
+	}
+	// Concurrent appends to the same file are rejected
+	br := &blockingReader{ch: make(chan struct{})}
+	done := make(chan error)
+	go func() {
+		_, err := store.Append(id, br)
+		done <- err
+	}()
+	<-br.ch
+	if _, err := store.Append(id, bytes.NewReader(data)); err != ErrAppending {
+		t.Errorf("expecting ErrAppending with a concurrent append, got %v", err)
+	}
+	br.ch <- struct{}{}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Append("doesnotexist", bytes.NewReader(data)); err == nil {
+		t.Error("expecting an error when appending to a non-existing file")
+	}
+}
+
+func TestFileStoreAppend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pool-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testAppend(t, "file://"+dir, false)
+	testAppend(t, "file://"+dir, true)
+}
+
+func TestLevelDBCompression(t *testing.T) {
+	for _, v := range []string{"snappy", "zstd"} {
+		dir, err := ioutil.TempDir("", "pool-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		cfg := "leveldb://" + dir + "#compress=" + v
+		testStore(t, &Meta{Foo: 5}, cfg)
+		testAppend(t, cfg, false)
+		text := bytes.Repeat([]byte("some highly compressible text "), 50000)
+		store, err := New(config.MustParseURL(cfg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, err := store.Store(text, nil)
+		store.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Chunks must be readable and deduplicated without compression
+		store, err = New(config.MustParseURL("leveldb://" + dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer store.Close()
+		data, err := store.ReadAll(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, text) {
+			t.Errorf("%s: data does not match after decompressing", v)
+		}
+		if _, err := store.Store(text, nil); err != nil {
+			t.Fatal(err)
+		}
+		if ws := store.DedupStats(); ws.Chunks != 0 {
+			t.Errorf("%s: expecting compressed chunks to be reused, got %d new chunks", v, ws.Chunks)
+		}
+	}
+	if _, err := New(config.MustParseURL("leveldb:///tmp/leveldb-invalid#compress=lzma")); err == nil {
+		t.Error("expecting an error with an invalid compression")
+	}
+}
+
+func TestLevelDBAppend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pool-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	testAppend(t, "leveldb://"+dir, false)
+}
+
+func TestLevelDBCorrupted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pool-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := leveldb.OpenFile(filepath.Join(dir, "files"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string][]byte{
+		"empty":    nil,
+		"metadata": {0xff, 0, 0, 0, 1},
+		"count":    {0, 0, 0, 0, 0xff, 0xff, 0, 0},
+		"chunk":    {0, 0, 0, 0, 1, 0, 0, 0, 0xff, 0, 0, 0, 1},
+	}
+	for k, v := range values {
+		if err := db.Put([]byte(k), v, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+	store, err := New(config.MustParseURL("leveldb://" + dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	for k := range values {
+		if f, err := store.Open(k); err == nil {
+			f.Close()
+			t.Errorf("expecting an error opening corrupted file %s", k)
+		}
+		if _, err := store.Append(k, strings.NewReader("foo")); err == nil {
+			t.Errorf("expecting an error appending to corrupted file %s", k)
+		}
+	}
+	if _, _, err := store.Usage(); err == nil {
+		t.Error("expecting an error computing usage with corrupted files")
+	}
+}
+
 const (
 	modeR  = 1 << 0
 	modeW  = 1 << 1
@@ -11,13 +11,19 @@ import (
 // WFile represents a file in the blobstore
 // opened for writing.
 type WFile struct {
-	id         string
-	file       driver.WFile
-	meta       interface{}
+	id   string
+	file driver.WFile
+	meta interface{}
+	// metadata is used instead of meta when the latter is nil,
+	// to preserve the encoded metadata when appending.
+	metadata   []byte
 	dataHash   hash.Hash64
 	dataLength uint64
 	store      *Blobstore
 	closed     bool
+	// appending is true when file was returned by
+	// driver.Appender.Append.
+	appending bool
 }
 
 // Id returns the unique file identifier as a string.
@@ -42,6 +48,16 @@ func (w *WFile) SetMeta(meta interface{}) error {
 // might not be used again.
 func (w *WFile) Close() error {
 	if !w.closed {
+		if w.appending && w.store.drvNoMeta {
+			// Flush the appended data before updating the
+			// .meta file. Otherwise, a crash might leave a
+			// .meta file with a length the data never
+			// reached. See driver.Appender.
+			if err := w.file.Close(); err != nil {
+				return err
+			}
+			return w.putMeta()
+		}
 		if err := w.putMeta(); err != nil {
 			return err
 		}
@@ -97,6 +113,12 @@ func (w *WFile) writeMeta(out io.Writer) error {
 		h := newHash()
 		h.Write(metadata)
 		metadataHash = h.Sum64()
+	} else if len(w.metadata) > 0 {
+		metadata = w.metadata
+		metadataLength = uint64(len(metadata))
+		h := newHash()
+		h.Write(metadata)
+		metadataHash = h.Sum64()
 	}
 	// Metadata metadata
 	if err := bwrite(out, metadataLength); err != nil {
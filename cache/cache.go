@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"gnd.la/app/profile"
 	"gnd.la/cache/driver"
@@ -17,6 +19,7 @@ import (
 var (
 	ErrNotFound = errors.New("item not found in cache")
 	imports     = map[string]string{
+		"leveldb":  "gnd.la/cache/driver/leveldb",
 		"memcache": "gnd.la/cache/driver/memcache",
 		"redis":    "gnd.la/cache/driver/redis",
 	}
@@ -33,6 +36,9 @@ type Cache struct {
 	driver    driver.Driver
 	codec     *codec.Codec
 	pipe      *pipe.Pipe
+	stats     *stats
+	broker    *broker
+	incMu     sync.Mutex
 }
 
 func (c *Cache) backendKey(key string) string {
@@ -142,9 +148,11 @@ func (c *Cache) GetMulti(out map[string]interface{}, typer Typer) error {
 	for ii, k := range keys {
 		value := data[qkeys[ii]]
 		if value == nil {
+			atomic.AddUint64(&c.stats.misses, 1)
 			delete(out, k)
 			continue
 		}
+		atomic.AddUint64(&c.stats.hits, 1)
 		typ := typer.Type(k)
 		if typ == nil {
 			derr := &cacheError{
@@ -202,6 +210,7 @@ func (c *Cache) SetBytes(key string, b []byte, timeout int) error {
 		c.error(serr)
 		return serr
 	}
+	atomic.AddUint64(&c.stats.sets, 1)
 	c.debugf("Set key %s (%d bytes), expiring in %d", k, len(b), timeout)
 	return nil
 }
@@ -222,8 +231,10 @@ func (c *Cache) GetBytes(key string) ([]byte, error) {
 		return nil, gerr
 	}
 	if b == nil {
+		atomic.AddUint64(&c.stats.misses, 1)
 		return nil, ErrNotFound
 	}
+	atomic.AddUint64(&c.stats.hits, 1)
 	if c.pipe != nil {
 		b, err = c.pipe.Decode(b)
 		if err != nil {
@@ -256,6 +267,7 @@ func (c *Cache) Delete(key string) error {
 		c.error(derr)
 		return derr
 	}
+	atomic.AddUint64(&c.stats.deletes, 1)
 	return nil
 }
 
@@ -292,6 +304,7 @@ func (c *Cache) warningf(format string, arg ...interface{}) {
 }
 
 func (c *Cache) error(err *cacheError) {
+	atomic.AddUint64(&c.stats.errors, 1)
 	if c.Logger != nil {
 		c.Logger.Error(err)
 	}
@@ -300,6 +313,8 @@ func (c *Cache) error(err *cacheError) {
 func newConfig(conf *config.URL) (*Cache, error) {
 	cache := &Cache{
 		Logger: log.Std,
+		stats:  &stats{},
+		broker: &broker{},
 	}
 
 	if codecName := conf.Fragment.Get("codec"); codecName != "" {
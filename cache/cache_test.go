@@ -2,12 +2,17 @@ package cache
 
 import (
 	"encoding/gob"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
 
+	_ "gnd.la/cache/driver/leveldb"
 	_ "gnd.la/cache/driver/memcache"
 	_ "gnd.la/cache/driver/redis"
 	"gnd.la/config"
@@ -30,6 +35,8 @@ var (
 		testSetExpires,
 		testDelete,
 		testBytes,
+		testPublish,
+		testInc,
 	}
 	benchmarks = []func(T, *Cache){
 		testSetGet,
@@ -274,6 +281,66 @@ func testBytes(t T, c *Cache) {
 	}
 }
 
+func testPublish(t T, c *Cache) {
+	sub, err := c.Subscribe("n")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer sub.Close()
+	other, err := c.Subscribe("other")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer other.Close()
+	// Give drivers with remote subscriptions
+	// some time to start listening.
+	time.Sleep(50 * time.Millisecond)
+	data := []byte("hello")
+	if err := c.Publish("n", data); err != nil {
+		t.Error(err)
+	}
+	select {
+	case recv := <-sub.C:
+		if !deepEqual(recv, data) {
+			t.Errorf("expecting notification %q, got %q", data, recv)
+		}
+	case <-time.After(time.Second):
+		t.Error("notification not received")
+	}
+	select {
+	case recv := <-other.C:
+		t.Errorf("unexpected notification %q", recv)
+	default:
+	}
+	if err := sub.Close(); err != nil {
+		t.Error(err)
+	}
+	if _, ok := <-sub.C; ok {
+		t.Error("subscription channel not closed")
+	}
+}
+
+func testInc(t T, c *Cache) {
+	key := "counter"
+	c.Delete(key)
+	for ii := int64(1); ii <= 3; ii++ {
+		value, err := c.Inc(key, 1, 60)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if value != ii {
+			t.Errorf("expecting counter = %d, got %d", ii, value)
+		}
+	}
+	if value, err := c.Inc(key, -3, 60); err != nil || value != 0 {
+		t.Errorf("expecting counter = 0, got %d (error %v)", value, err)
+	}
+	c.Delete(key)
+}
+
 func testCache(t *testing.T, url string) {
 	if testing.Verbose() {
 		log.SetLevel(log.LDebug)
@@ -282,6 +349,7 @@ func testCache(t *testing.T, url string) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer c.Close()
 	for _, v := range tests {
 		v(t, c)
 	}
@@ -344,6 +412,66 @@ func TestRedis(t *testing.T) {
 	testCache(t, "redis://127.0.0.1")
 }
 
+func TestLevelDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache-leveldb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	url := "leveldb://" + filepath.ToSlash(dir)
+	testCache(t, url)
+	c, err := newCache(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetBytes("persistent", []byte("value"), 0); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	// Items should survive reopening the cache
+	c, err = newCache(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	b, err := c.GetBytes("persistent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "value" {
+		t.Errorf("expecting persistent value %q, got %q", "value", string(b))
+	}
+}
+
+func TestLevelDBMaxSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache-leveldb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c, err := newCache("leveldb://" + filepath.ToSlash(dir) + "#max_size=1K")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	data1 := make([]byte, 256)
+	data2 := make([]byte, 512)
+	c.SetBytes("k1", data1, 0)
+	c.SetBytes("k2", data2, 0)
+	// Make k1 the most recently used item
+	c.GetBytes("k1")
+	c.SetBytes("k3", data2, 0)
+	// Should have evicted k2, which is the least recently used
+	if _, err := c.GetBytes("k2"); err != ErrNotFound {
+		t.Errorf("should have evicted k2, got error %v", err)
+	}
+	for _, v := range []string{"k1", "k3"} {
+		if _, err := c.GetBytes(v); err != nil {
+			t.Errorf("error getting %s: %s", v, err)
+		}
+	}
+}
+
 func TestMemoryCacheMaxSize(t *testing.T) {
 	c, err := newCache("memory://#max_size=1K")
 	if err != nil {
@@ -372,6 +500,127 @@ func TestMemoryCacheMaxSize(t *testing.T) {
 	}
 }
 
+func TestNamespace(t *testing.T) {
+	c, err := newCache("memory://")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ns := c.Namespace("templates")
+	other := c.Namespace("other")
+	if err := ns.Set("k1", 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Set("k1", 2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("k1", 3, 0); err != nil {
+		t.Fatal(err)
+	}
+	var v int
+	if err := ns.Get("k1", &v); err != nil || v != 1 {
+		t.Errorf("expecting 1 from namespace, got %v (err %v)", v, err)
+	}
+	out := map[string]interface{}{"k1": 0, "k2": 0}
+	if err := ns.GetMulti(out, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 || out["k1"] != 1 {
+		t.Errorf("expecting k1 = 1 from GetMulti, got %v", out)
+	}
+	if err := ns.Bump(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ns.Get("k1", &v); err != ErrNotFound {
+		t.Errorf("expecting ErrNotFound after Bump, got %v", err)
+	}
+	// Other namespaces and keys shouldn't be affected
+	if err := other.Get("k1", &v); err != nil || v != 2 {
+		t.Errorf("expecting 2 from other namespace, got %v (err %v)", v, err)
+	}
+	if err := c.Get("k1", &v); err != nil || v != 3 {
+		t.Errorf("expecting 3 from cache, got %v (err %v)", v, err)
+	}
+	if err := ns.Set("k1", 4, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := ns.Get("k1", &v); err != nil || v != 4 {
+		t.Errorf("expecting 4 from namespace after Bump, got %v (err %v)", v, err)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	c, err := newCache("memory://")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	calls := 0
+	f := func() (interface{}, error) {
+		calls++
+		time.Sleep(time.Millisecond)
+		return calls, nil
+	}
+	opts := &FetchOptions{Timeout: 60, Beta: DefaultBeta}
+	var v int
+	for ii := 0; ii < 2; ii++ {
+		if err := c.Fetch("fetch", &v, f, opts); err != nil {
+			t.Fatal(err)
+		}
+		if v != 1 || calls != 1 {
+			t.Errorf("expecting value 1 with 1 call, got %d with %d calls", v, calls)
+		}
+	}
+	// Make the random number small enough to trigger
+	// an early recomputation.
+	defer func(r func() float64) { fetchRand = r }(fetchRand)
+	fetchRand = func() float64 { return 1e-300 }
+	opts.Beta = 1e6
+	if err := c.Fetch("fetch", &v, f, opts); err != nil {
+		t.Fatal(err)
+	}
+	if v != 2 || calls != 2 {
+		t.Errorf("expecting value 2 with 2 calls after early expiration, got %d with %d calls", v, calls)
+	}
+	// Errors during early recomputations return the cached value
+	if err := c.Fetch("fetch", &v, func() (interface{}, error) { return nil, errors.New("failed") }, opts); err != nil {
+		t.Fatal(err)
+	}
+	if v != 2 {
+		t.Errorf("expecting cached value 2 after failed recomputation, got %d", v)
+	}
+	if err := c.Fetch("missing", &v, func() (interface{}, error) { return nil, errors.New("failed") }, opts); err == nil {
+		t.Error("expecting an error when fetching a missing item fails")
+	}
+}
+
+func TestStats(t *testing.T) {
+	c, err := newCache("memory://")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("k1", 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	var v int
+	if err := c.Get("k1", &v); err != nil {
+		t.Error(err)
+	}
+	if err := c.Get("k2", &v); err != ErrNotFound {
+		t.Errorf("expecting ErrNotFound, got %v", err)
+	}
+	if err := c.Delete("k1"); err != nil {
+		t.Error(err)
+	}
+	s := c.Stats()
+	if s.Hits != 1 || s.Misses != 1 || s.Sets != 1 || s.Deletes != 1 || s.Errors != 0 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	if r := s.HitRatio(); r != 0.5 {
+		t.Errorf("expecting hit ratio 0.5, got %v", r)
+	}
+}
+
 func benchmarkCache(b *testing.B, config string) {
 	c, err := newCache(config)
 	if err != nil {
@@ -19,4 +19,5 @@
 //  memcache://localhost#codec=json&pipe=zlib
 //  memory://#max_size=1.5G
 //  file://cache#max_size=512M
+//  leveldb://cache#max_size=1G
 package cache
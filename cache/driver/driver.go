@@ -52,6 +52,33 @@ type Driver interface {
 	Flush() error
 }
 
+// Notifier is an optional interface which might be implemented by
+// drivers which can deliver notifications to all the processes sharing
+// the same cache backend. When a driver does not implement Notifier,
+// the cache delivers notifications only within the current process.
+type Notifier interface {
+	// Publish sends data to all the subscribers of the given channel.
+	// Drivers shouldn't return an error if there are no subscribers.
+	Publish(channel string, data []byte) error
+	// Subscribe starts listening for notifications in the given
+	// channel. It returns a channel which receives the data for
+	// each notification and a function which stops listening and
+	// closes the returned channel.
+	Subscribe(channel string) (<-chan []byte, func() error, error)
+}
+
+// Incrementer is an optional interface which might be implemented by
+// drivers which can atomically increment a counter. When a driver does
+// not implement Incrementer, the cache increments counters atomically
+// only within the current process.
+type Incrementer interface {
+	// Inc atomically adds delta to the counter stored at the given key
+	// and returns its new value. Missing keys must be treated as zero
+	// and their timeout set as in Set. Counters are stored as decimal
+	// numbers.
+	Inc(key string, delta int64, timeout int) (int64, error)
+}
+
 // Register registers a new cache driver with the
 // given protocol and opener function. This function
 // is not thread safe, as it's only intended to be
@@ -0,0 +1,279 @@
+// Package leveldb implements a persistent Gondola cache driver using
+// leveldb, intended for single node deployments which want to keep
+// the cache across restarts without running a cache server.
+//
+// The URL format for this driver is:
+//
+//   - leveldb://path[#max_size={size}&nocompress=1]
+//
+// Paths which don't start with a / are interpreted as relative to the
+// application binary (using gnd.la/util/pathutil.Relative). Note that
+// leveldb databases can't be opened by more than one process at the same
+// time, so each process requires its own path.
+//
+// When max_size is provided, the least recently used items are evicted
+// when the size of the stored keys and values exceeds it, until it falls
+// below 90% of max_size. Sizes admit the same suffixes as the memory and
+// file drivers (see gnd.la/cache/driver). Recency is only tracked while
+// the cache is open, so after opening it items are considered in key order.
+//
+// Compression is enabled by default and can be disabled with nocompress.
+package leveldb
+
+import (
+	"container/list"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gnd.la/cache/driver"
+	"gnd.la/config"
+	"gnd.la/util/parseutil"
+	"gnd.la/util/pathutil"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+// Stored values are prefixed by their expiration
+// time as an int64 in little endian order.
+const headerSize = 8
+
+type entry struct {
+	key     string
+	size    uint64
+	expires int64
+}
+
+type leveldbDriver struct {
+	db      *leveldb.DB
+	maxSize uint64
+	mu      sync.Mutex
+	size    uint64
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+func (d *leveldbDriver) Set(key string, b []byte, timeout int) error {
+	var expires int64
+	if timeout != 0 {
+		expires = time.Now().Unix() + int64(timeout)
+	}
+	value := make([]byte, headerSize+len(b))
+	binary.LittleEndian.PutUint64(value, uint64(expires))
+	copy(value[headerSize:], b)
+	if err := d.db.Put([]byte(key), value, nil); err != nil {
+		return err
+	}
+	if d.lru == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.track(key, uint64(len(key)+len(value)), expires)
+	if d.size > d.maxSize {
+		return d.evict()
+	}
+	return nil
+}
+
+func (d *leveldbDriver) Get(key string) ([]byte, error) {
+	value, err := d.db.Get([]byte(key), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(value) < headerSize {
+		return nil, fmt.Errorf("invalid value for key %q", key)
+	}
+	expires := int64(binary.LittleEndian.Uint64(value))
+	if expires > 0 && expires < time.Now().Unix() {
+		return nil, d.Delete(key)
+	}
+	if d.lru != nil {
+		d.mu.Lock()
+		if elem := d.entries[key]; elem != nil {
+			d.lru.MoveToFront(elem)
+		}
+		d.mu.Unlock()
+	}
+	return value[headerSize:], nil
+}
+
+func (d *leveldbDriver) GetMulti(keys []string) (map[string][]byte, error) {
+	values := make(map[string][]byte, len(keys))
+	for _, k := range keys {
+		b, err := d.Get(k)
+		if err != nil {
+			return nil, err
+		}
+		if b != nil {
+			values[k] = b
+		}
+	}
+	return values, nil
+}
+
+func (d *leveldbDriver) Delete(key string) error {
+	if err := d.db.Delete([]byte(key), nil); err != nil {
+		return err
+	}
+	if d.lru != nil {
+		d.mu.Lock()
+		d.untrack(key)
+		d.mu.Unlock()
+	}
+	return nil
+}
+
+func (d *leveldbDriver) Close() error {
+	return d.db.Close()
+}
+
+func (d *leveldbDriver) Connection() interface{} {
+	return d.db
+}
+
+func (d *leveldbDriver) Flush() error {
+	iter := d.db.NewIterator(nil, nil)
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		batch.Delete(append([]byte(nil), iter.Key()...))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if err := d.db.Write(batch, nil); err != nil {
+		return err
+	}
+	if d.lru != nil {
+		d.mu.Lock()
+		d.lru.Init()
+		d.entries = make(map[string]*list.Element)
+		d.size = 0
+		d.mu.Unlock()
+	}
+	return nil
+}
+
+// track records the given key as the most recently used one.
+// d.mu must be held by the caller.
+func (d *leveldbDriver) track(key string, size uint64, expires int64) {
+	d.untrack(key)
+	d.entries[key] = d.lru.PushFront(&entry{key: key, size: size, expires: expires})
+	d.size += size
+}
+
+// untrack removes the given key from the LRU list.
+// d.mu must be held by the caller.
+func (d *leveldbDriver) untrack(key string) {
+	if elem := d.entries[key]; elem != nil {
+		d.lru.Remove(elem)
+		delete(d.entries, key)
+		d.size -= elem.Value.(*entry).size
+	}
+}
+
+// evict removes expired items and then the least recently
+// used ones until the size falls below 90% of the maximum.
+// d.mu must be held by the caller.
+func (d *leveldbDriver) evict() error {
+	threshold := uint64(float64(d.maxSize) * 0.9)
+	batch := new(leveldb.Batch)
+	now := time.Now().Unix()
+	for elem := d.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if e := elem.Value.(*entry); e.expires > 0 && e.expires < now {
+			batch.Delete([]byte(e.key))
+			d.untrack(e.key)
+		}
+		elem = prev
+	}
+	for d.size >= threshold {
+		elem := d.lru.Back()
+		if elem == nil {
+			break
+		}
+		key := elem.Value.(*entry).key
+		batch.Delete([]byte(key))
+		d.untrack(key)
+	}
+	return d.db.Write(batch, nil)
+}
+
+// load populates the LRU list from the items in the database,
+// removing the expired ones.
+func (d *leveldbDriver) load() error {
+	iter := d.db.NewIterator(nil, &opt.ReadOptions{DontFillCache: true})
+	defer iter.Release()
+	batch := new(leveldb.Batch)
+	now := time.Now().Unix()
+	for iter.Next() {
+		key := iter.Key()
+		value := iter.Value()
+		var expires int64
+		if len(value) >= headerSize {
+			expires = int64(binary.LittleEndian.Uint64(value))
+		}
+		if len(value) < headerSize || (expires > 0 && expires < now) {
+			batch.Delete(append([]byte(nil), key...))
+			continue
+		}
+		d.track(string(key), uint64(len(key)+len(value)), expires)
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if err := d.db.Write(batch, nil); err != nil {
+		return err
+	}
+	if d.size > d.maxSize {
+		return d.evict()
+	}
+	return nil
+}
+
+func leveldbOpener(url *config.URL) (driver.Driver, error) {
+	value := filepath.FromSlash(url.Value)
+	if value == "" {
+		return nil, fmt.Errorf("no path provided for leveldb cache")
+	}
+	if !filepath.IsAbs(value) {
+		value = pathutil.Relative(value)
+	}
+	opts := &opt.Options{}
+	if url.Fragment.Get("nocompress") != "" {
+		opts.Compression = opt.NoCompression
+	}
+	var maxSize uint64
+	if ms := url.Fragment.Get("max_size"); ms != "" {
+		var err error
+		maxSize, err = parseutil.Size(ms)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_size %q", ms)
+		}
+	}
+	db, err := leveldb.OpenFile(value, opts)
+	if err != nil {
+		return nil, err
+	}
+	d := &leveldbDriver{db: db, maxSize: maxSize}
+	if maxSize > 0 {
+		d.lru = list.New()
+		d.entries = make(map[string]*list.Element)
+		if err := d.load(); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func init() {
+	driver.Register("leveldb", leveldbOpener)
+}
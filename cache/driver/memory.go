@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"runtime"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -89,6 +90,39 @@ func (d *MemoryDriver) Set(key string, b []byte, timeout int) error {
 	return nil
 }
 
+func (d *MemoryDriver) Inc(key string, delta int64, timeout int) (int64, error) {
+	now := time.Now().Unix()
+	cache.Lock()
+	defer cache.Unlock()
+	var value int64
+	var expires int64
+	var prevSize uint64
+	found := false
+	if prev := cache.items[key]; prev != nil {
+		prevSize = uint64(len(prev.data))
+		if prev.expires == 0 || prev.expires >= now {
+			v, err := strconv.ParseInt(string(prev.data), 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("value for key %q is not a counter", key)
+			}
+			value = v
+			expires = prev.expires
+			found = true
+		}
+	}
+	if !found && timeout != 0 {
+		expires = now + int64(timeout)
+	}
+	value += delta
+	b := []byte(strconv.FormatInt(value, 10))
+	cache.items[key] = &item{
+		data:    b,
+		expires: expires,
+	}
+	cache.size += uint64(len(b)) - prevSize
+	return value, nil
+}
+
 func (d *MemoryDriver) Get(key string) ([]byte, error) {
 	cache.RLock()
 	item := cache.items[key]
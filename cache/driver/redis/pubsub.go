@@ -0,0 +1,124 @@
+package redis
+
+import (
+	"sync"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+// pubSub shares a single connection in subscribed state among all
+// the subscriptions of a driver, delivering the received messages to
+// the subscribers of each channel. The connection is opened with the
+// first subscription and closed when the last one is cancelled.
+type pubSub struct {
+	mu    sync.Mutex
+	pool  *redis.Pool
+	psc   *redis.PubSubConn
+	chans map[string]map[chan []byte]struct{}
+}
+
+func (p *pubSub) subscribe(channel string) (<-chan []byte, func() error, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.psc == nil {
+		// Use a dedicated connection, since connections in
+		// subscribed state can't be returned to the pool.
+		conn, err := p.pool.Dial()
+		if err != nil {
+			return nil, nil, err
+		}
+		p.psc = &redis.PubSubConn{Conn: conn}
+		p.chans = make(map[string]map[chan []byte]struct{})
+		go p.receive(p.psc)
+	}
+	subs := p.chans[channel]
+	if subs == nil {
+		if err := p.psc.Subscribe(channel); err != nil {
+			// Connection is broken, receive will
+			// close the other subscriptions.
+			p.psc.Close()
+			return nil, nil, err
+		}
+		subs = make(map[chan []byte]struct{})
+		p.chans[channel] = subs
+	}
+	ch := make(chan []byte, 1)
+	subs[ch] = struct{}{}
+	return ch, func() error {
+		return p.cancel(channel, ch)
+	}, nil
+}
+
+func (p *pubSub) cancel(channel string, ch chan []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	subs := p.chans[channel]
+	if _, ok := subs[ch]; !ok {
+		// Already closed by receive
+		return nil
+	}
+	delete(subs, ch)
+	close(ch)
+	if len(subs) > 0 {
+		return nil
+	}
+	delete(p.chans, channel)
+	if len(p.chans) == 0 {
+		return p.reset()
+	}
+	return p.psc.Unsubscribe(channel)
+}
+
+func (p *pubSub) receive(psc *redis.PubSubConn) {
+	for {
+		switch v := psc.Receive().(type) {
+		case redis.Message:
+			p.mu.Lock()
+			if p.psc == psc {
+				for ch := range p.chans[v.Channel] {
+					select {
+					case ch <- v.Data:
+					default:
+						// Subscriber is not keeping up, drop
+						// the notification rather than blocking
+						// the other subscribers.
+					}
+				}
+			}
+			p.mu.Unlock()
+		case error:
+			// Connection was closed, either by reset or
+			// because of an error. In the latter case, close
+			// the subscriptions so their owners notice it.
+			p.mu.Lock()
+			if p.psc == psc {
+				p.reset()
+			}
+			p.mu.Unlock()
+			return
+		}
+	}
+}
+
+// reset closes the connection and all the subscriptions.
+// It must be called with p.mu held.
+func (p *pubSub) reset() error {
+	for _, subs := range p.chans {
+		for ch := range subs {
+			close(ch)
+		}
+	}
+	p.chans = nil
+	err := p.psc.Close()
+	p.psc = nil
+	return err
+}
+
+func (p *pubSub) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.psc == nil {
+		return nil
+	}
+	return p.reset()
+}
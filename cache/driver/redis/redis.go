@@ -32,8 +32,18 @@ const (
 	DefaultIdleTimeout = 300
 )
 
+// incScript increments the counter and sets its timeout
+// only when it didn't have one, so the whole operation is
+// atomic.
+var incScript = redis.NewScript(1, `local v = redis.call("INCRBY", KEYS[1], ARGV[1])
+if tonumber(ARGV[2]) > 0 and redis.call("TTL", KEYS[1]) == -1 then
+	redis.call("EXPIRE", KEYS[1], ARGV[2])
+end
+return v`)
+
 type redisDriver struct {
-	pool *redis.Pool
+	pool   *redis.Pool
+	pubSub pubSub
 }
 
 func (r *redisDriver) Set(key string, b []byte, timeout int) error {
@@ -93,6 +103,13 @@ func (r *redisDriver) GetMulti(keys []string) (map[string][]byte, error) {
 	return ret, nil
 }
 
+func (r *redisDriver) Inc(key string, delta int64, timeout int) (int64, error) {
+	conn := r.pool.Get()
+	value, err := redis.Int64(incScript.Do(conn, key, delta, timeout))
+	conn.Close()
+	return value, err
+}
+
 func (r *redisDriver) Delete(key string) error {
 	conn := r.pool.Get()
 	_, err := conn.Do("DEL", key)
@@ -105,6 +122,7 @@ func (r *redisDriver) Connection() interface{} {
 }
 
 func (r *redisDriver) Close() error {
+	r.pubSub.close()
 	return r.pool.Close()
 }
 
@@ -115,6 +133,21 @@ func (r *redisDriver) Flush() error {
 	return err
 }
 
+func (r *redisDriver) Publish(channel string, data []byte) error {
+	conn := r.pool.Get()
+	_, err := conn.Do("PUBLISH", channel, data)
+	conn.Close()
+	return err
+}
+
+// Subscribe starts listening for notifications in the given channel.
+// All the subscriptions share the same connection, so notifications
+// are dropped for subscribers which don't keep up with them, rather
+// than blocking the rest.
+func (r *redisDriver) Subscribe(channel string) (<-chan []byte, func() error, error) {
+	return r.pubSub.subscribe(channel)
+}
+
 func redisOpener(url *config.URL) (driver.Driver, error) {
 	password := url.Fragment.Get("password")
 	db := -1
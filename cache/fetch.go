@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Items stored by Fetch are prefixed by their expiration time
+// and the time it took to compute them, both as int64 nanoseconds
+// in little endian order.
+const fetchHeaderSize = 16
+
+// DefaultBeta is the recommended value for FetchOptions.Beta.
+const DefaultBeta = 1.0
+
+// Overridden in tests
+var fetchRand = rand.Float64
+
+// FetchOptions specify the options for Cache.Fetch.
+type FetchOptions struct {
+	// Timeout is the number of seconds until the item expires,
+	// with the same semantics as in Cache.Set.
+	Timeout int
+	// Beta enables probabilistic early expiration (also known as
+	// x-fetch) when it's greater than zero. Each Fetch might then
+	// recompute the item before it expires, with a probability which
+	// increases as the expiration approaches and with the time it
+	// took to compute it. This spreads recomputations over time,
+	// rather than having all the clients recomputing the item at once
+	// when it expires. Values greater than 1 favor earlier
+	// recomputations. See DefaultBeta.
+	Beta float64
+}
+
+// Fetch retrieves the item with the given key into obj, which must be
+// addressable. If the item is not found or it has expired (see
+// FetchOptions.Beta), f is called to compute it and its result is
+// stored in the cache and then decoded into obj. If f returns an error
+// while recomputing an item before its expiration, the cached item is
+// used instead.
+//
+// Items stored by Fetch include some metadata, so they must only be
+// retrieved using Fetch.
+func (c *Cache) Fetch(key string, obj interface{}, f func() (interface{}, error), opts *FetchOptions) error {
+	var o FetchOptions
+	if opts != nil {
+		o = *opts
+	}
+	b, err := c.GetBytes(key)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+	var cached []byte
+	if len(b) >= fetchHeaderSize {
+		expires := int64(binary.LittleEndian.Uint64(b))
+		delta := int64(binary.LittleEndian.Uint64(b[8:]))
+		cached = b[fetchHeaderSize:]
+		if !fetchExpired(expires, delta, o.Beta) {
+			return c.decodeFetched(key, cached, obj)
+		}
+		c.debugf("Recomputing key %s before its expiration", key)
+	}
+	start := time.Now()
+	value, err := f()
+	if err != nil {
+		if cached != nil {
+			c.warningf("error recomputing key %s, using cached value: %s", key, err)
+			return c.decodeFetched(key, cached, obj)
+		}
+		return err
+	}
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		eerr := &cacheError{
+			op:    "encoding object",
+			key:   key,
+			codec: true,
+			err:   err,
+		}
+		c.error(eerr)
+		return eerr
+	}
+	now := time.Now()
+	var expires int64
+	if o.Timeout > 0 {
+		expires = now.Add(time.Duration(o.Timeout) * time.Second).UnixNano()
+	}
+	b = make([]byte, fetchHeaderSize+len(data))
+	binary.LittleEndian.PutUint64(b, uint64(expires))
+	binary.LittleEndian.PutUint64(b[8:], uint64(now.Sub(start)))
+	copy(b[fetchHeaderSize:], data)
+	if err := c.SetBytes(key, b, o.Timeout); err != nil {
+		return err
+	}
+	return c.decodeFetched(key, data, obj)
+}
+
+func (c *Cache) decodeFetched(key string, data []byte, obj interface{}) error {
+	if err := c.codec.Decode(data, obj); err != nil {
+		derr := &cacheError{
+			op:    "decoding object",
+			key:   key,
+			codec: true,
+			err:   err,
+		}
+		c.error(derr)
+		return derr
+	}
+	return nil
+}
+
+// fetchExpired implements the x-fetch algorithm, returning true
+// iff now - delta * beta * log(rand()) >= expires.
+func fetchExpired(expires int64, delta int64, beta float64) bool {
+	if expires == 0 {
+		return false
+	}
+	now := time.Now().UnixNano()
+	if beta <= 0 {
+		return now >= expires
+	}
+	r := fetchRand()
+	if r <= 0 {
+		// log(0) is -Inf
+		return true
+	}
+	early := -float64(delta) * beta * math.Log(r)
+	return float64(now)+early >= float64(expires)
+}
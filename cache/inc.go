@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"strconv"
+
+	"gnd.la/app/profile"
+	"gnd.la/cache/driver"
+)
+
+// Inc atomically adds delta to the counter stored at the given key and
+// returns its new value. If the key doesn't exist, the counter starts at
+// zero and expires after timeout seconds (see Set). Counters are not
+// encoded with the cache codec, so they must only be accessed using Inc
+// (use a zero delta to read the current value).
+//
+// If the cache driver supports atomic increments (e.g. memory or redis),
+// the operation is atomic among every process using the same cache.
+// Otherwise, it's only atomic within the current process.
+func (c *Cache) Inc(key string, delta int64, timeout int) (int64, error) {
+	if profile.On && profile.Profiling() {
+		defer profile.Start(cache).Note("INC", key).End()
+	}
+	if inc, ok := c.driver.(driver.Incrementer); ok {
+		value, err := inc.Inc(c.backendKey(key), delta, timeout)
+		if err != nil {
+			ierr := &cacheError{
+				op:  "incrementing key",
+				key: key,
+				err: err,
+			}
+			c.error(ierr)
+			return 0, ierr
+		}
+		return value, nil
+	}
+	c.incMu.Lock()
+	defer c.incMu.Unlock()
+	var value int64
+	b, err := c.GetBytes(key)
+	switch err {
+	case nil:
+		value, err = strconv.ParseInt(string(b), 10, 64)
+		if err != nil {
+			ierr := &cacheError{
+				op:  "parsing counter",
+				key: key,
+				err: err,
+			}
+			c.error(ierr)
+			return 0, ierr
+		}
+	case ErrNotFound:
+	default:
+		return 0, err
+	}
+	value += delta
+	if err := c.SetBytes(key, []byte(strconv.FormatInt(value, 10)), timeout); err != nil {
+		return 0, err
+	}
+	return value, nil
+}
@@ -24,6 +24,11 @@ func (w *writer) copyHeaders() {
 	}
 }
 
+// Unwrap returns the wrapped http.ResponseWriter.
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func (w *writer) WriteHeader(code int) {
 	w.statusCode = code
 	w.ResponseWriter.WriteHeader(code)
@@ -0,0 +1,208 @@
+package cache
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+)
+
+const namespacePrefix = "ns:"
+
+// Namespace groups keys which can be invalidated at once (e.g. all
+// the cached templates after a deploy), without enumerating or deleting
+// them. Keys in a Namespace are stored with the namespace version mixed
+// into them, so incrementing the version with Bump makes all the
+// previously stored items unreachable. They're eventually removed from
+// the cache by its expiration and eviction policies. Use Cache.Namespace
+// to obtain a Namespace.
+//
+// Note that each operation on a Namespace requires an additional
+// trip to the cache to retrieve the current version.
+type Namespace struct {
+	cache *Cache
+	name  string
+}
+
+// Namespace returns the Namespace with the given name. Namespaces
+// are lightweight, so they might be created as needed.
+func (c *Cache) Namespace(name string) *Namespace {
+	return &Namespace{cache: c, name: name}
+}
+
+// Name returns the Namespace name.
+func (n *Namespace) Name() string {
+	return n.name
+}
+
+func (n *Namespace) versionKey() string {
+	return n.cache.backendKey(namespacePrefix + n.name)
+}
+
+func (n *Namespace) setVersion(version int64) error {
+	k := n.versionKey()
+	if err := n.cache.driver.Set(k, []byte(strconv.FormatInt(version, 10)), 0); err != nil {
+		serr := &cacheError{
+			op:  "setting namespace version",
+			key: k,
+			err: err,
+		}
+		n.cache.error(serr)
+		return serr
+	}
+	return nil
+}
+
+// version returns the current version for the namespace and
+// true if it exists in the cache.
+func (n *Namespace) version() (int64, bool, error) {
+	k := n.versionKey()
+	b, err := n.cache.driver.Get(k)
+	if err != nil {
+		gerr := &cacheError{
+			op:  "getting namespace version",
+			key: k,
+			err: err,
+		}
+		n.cache.error(gerr)
+		return 0, false, gerr
+	}
+	if b == nil {
+		return 0, false, nil
+	}
+	version, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		// Treat it as missing, it will be reset
+		n.cache.warningf("invalid version %q for namespace %s", string(b), n.name)
+		return 0, false, nil
+	}
+	return version, true, nil
+}
+
+// Version returns the current version of the Namespace. If the Namespace
+// doesn't have a version stored in the cache (e.g. the first time it's
+// used or if it was evicted), a new one is initialized from the current
+// time, so items stored with previous versions are never reachable again.
+func (n *Namespace) Version() (int64, error) {
+	version, ok, err := n.version()
+	if err != nil || ok {
+		return version, err
+	}
+	version = time.Now().UnixNano()
+	if err := n.setVersion(version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// Bump increments the Namespace version, invalidating all the items
+// stored in it.
+func (n *Namespace) Bump() error {
+	version, ok, err := n.version()
+	if err != nil {
+		return err
+	}
+	if ok {
+		version++
+	} else {
+		version = time.Now().UnixNano()
+	}
+	if err := n.setVersion(version); err != nil {
+		return err
+	}
+	n.cache.debugf("Bumped namespace %s to version %d", n.name, version)
+	return nil
+}
+
+// Key returns the key used to store the given key in the Namespace
+// with its current version.
+func (n *Namespace) Key(key string) (string, error) {
+	version, err := n.Version()
+	if err != nil {
+		return "", err
+	}
+	return namespacePrefix + n.name + ":" + strconv.FormatInt(version, 10) + ":" + key, nil
+}
+
+// Set works like Cache.Set, but stores the object in the Namespace.
+func (n *Namespace) Set(key string, object interface{}, timeout int) error {
+	k, err := n.Key(key)
+	if err != nil {
+		return err
+	}
+	return n.cache.Set(k, object, timeout)
+}
+
+// Get works like Cache.Get, but retrieves the object from the Namespace.
+func (n *Namespace) Get(key string, obj interface{}) error {
+	k, err := n.Key(key)
+	if err != nil {
+		return err
+	}
+	return n.cache.Get(k, obj)
+}
+
+// SetBytes works like Cache.SetBytes, but stores the data in the Namespace.
+func (n *Namespace) SetBytes(key string, b []byte, timeout int) error {
+	k, err := n.Key(key)
+	if err != nil {
+		return err
+	}
+	return n.cache.SetBytes(k, b, timeout)
+}
+
+// GetBytes works like Cache.GetBytes, but retrieves the data from the
+// Namespace.
+func (n *Namespace) GetBytes(key string) ([]byte, error) {
+	k, err := n.Key(key)
+	if err != nil {
+		return nil, err
+	}
+	return n.cache.GetBytes(k)
+}
+
+// GetMulti works like Cache.GetMulti, but retrieves the objects from
+// the Namespace. The Typer, if any, receives the keys without the
+// namespace.
+func (n *Namespace) GetMulti(out map[string]interface{}, typer Typer) error {
+	prefix, err := n.Key("")
+	if err != nil {
+		return err
+	}
+	nsOut := make(map[string]interface{}, len(out))
+	for k, v := range out {
+		nsOut[prefix+k] = v
+	}
+	var nsTyper Typer
+	if typer != nil {
+		nsTyper = &namespaceTyper{typer: typer, prefixLen: len(prefix)}
+	}
+	if err := n.cache.GetMulti(nsOut, nsTyper); err != nil {
+		return err
+	}
+	for k := range out {
+		if v, ok := nsOut[prefix+k]; ok {
+			out[k] = v
+		} else {
+			delete(out, k)
+		}
+	}
+	return nil
+}
+
+// Delete works like Cache.Delete, but removes the key from the Namespace.
+func (n *Namespace) Delete(key string) error {
+	k, err := n.Key(key)
+	if err != nil {
+		return err
+	}
+	return n.cache.Delete(k)
+}
+
+type namespaceTyper struct {
+	typer     Typer
+	prefixLen int
+}
+
+func (t *namespaceTyper) Type(key string) reflect.Type {
+	return t.typer.Type(key[t.prefixLen:])
+}
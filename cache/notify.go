@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"sync"
+
+	"gnd.la/cache/driver"
+)
+
+// Subscription represents a subscription to the notifications
+// published on a key. Use Cache.Subscribe to create a Subscription.
+type Subscription struct {
+	// C receives the data for each notification published
+	// on the key. It's closed after the Subscription is closed.
+	C     <-chan []byte
+	close func() error
+	once  sync.Once
+}
+
+// Close stops listening for notifications.
+func (s *Subscription) Close() error {
+	var err error
+	s.once.Do(func() {
+		err = s.close()
+	})
+	return err
+}
+
+// Publish sends a notification with the given data to all the
+// subscribers of the given key. If the cache driver supports
+// notifications (e.g. redis), they're delivered to every process
+// using the same cache. Otherwise, they're only delivered to the
+// subscribers in the current process. Notifications are not stored,
+// so subscribers only receive the ones published while they're
+// subscribed.
+func (c *Cache) Publish(key string, data []byte) error {
+	k := c.backendKey(key)
+	if n, ok := c.driver.(driver.Notifier); ok {
+		if err := n.Publish(k, data); err != nil {
+			c.error(&cacheError{op: "publishing", key: k, err: err})
+			return err
+		}
+		return nil
+	}
+	c.broker.publish(k, data)
+	return nil
+}
+
+// Subscribe starts listening for the notifications published on the
+// given key (see Publish). The returned Subscription must be closed
+// when it's not needed anymore.
+func (c *Cache) Subscribe(key string) (*Subscription, error) {
+	k := c.backendKey(key)
+	if n, ok := c.driver.(driver.Notifier); ok {
+		ch, cancel, err := n.Subscribe(k)
+		if err != nil {
+			c.error(&cacheError{op: "subscribing", key: k, err: err})
+			return nil, err
+		}
+		return &Subscription{C: ch, close: cancel}, nil
+	}
+	ch, cancel := c.broker.subscribe(k)
+	return &Subscription{C: ch, close: cancel}, nil
+}
+
+// broker delivers notifications within the current process, for
+// drivers which don't implement driver.Notifier.
+type broker struct {
+	mu   sync.Mutex
+	subs map[string]map[chan []byte]struct{}
+}
+
+func (b *broker) publish(key string, data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs[key] {
+		select {
+		case ch <- data:
+		default:
+			// Subscriber is not keeping up, drop
+			// the notification rather than blocking
+			// the publisher.
+		}
+	}
+}
+
+func (b *broker) subscribe(key string) (<-chan []byte, func() error) {
+	ch := make(chan []byte, 1)
+	b.mu.Lock()
+	if b.subs == nil {
+		b.subs = make(map[string]map[chan []byte]struct{})
+	}
+	if b.subs[key] == nil {
+		b.subs[key] = make(map[chan []byte]struct{})
+	}
+	b.subs[key][ch] = struct{}{}
+	b.mu.Unlock()
+	return ch, func() error {
+		b.mu.Lock()
+		delete(b.subs[key], ch)
+		if len(b.subs[key]) == 0 {
+			delete(b.subs, key)
+		}
+		b.mu.Unlock()
+		close(ch)
+		return nil
+	}
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"sync/atomic"
+)
+
+// Stats contains the operation counters for a Cache since it
+// was created. Use Cache.Stats to obtain them.
+type Stats struct {
+	// Hits is the number of keys retrieved which were found.
+	Hits uint64
+	// Misses is the number of keys retrieved which were not found.
+	Misses uint64
+	// Sets is the number of keys stored.
+	Sets uint64
+	// Deletes is the number of keys deleted.
+	Deletes uint64
+	// Errors is the number of failed operations.
+	Errors uint64
+}
+
+// HitRatio returns the ratio of hits over the total number
+// of retrieved keys, between 0 and 1. If no keys have been
+// retrieved, it returns 0.
+func (s *Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+type stats struct {
+	hits    uint64
+	misses  uint64
+	sets    uint64
+	deletes uint64
+	errors  uint64
+}
+
+func (s *stats) snapshot() *Stats {
+	return &Stats{
+		Hits:    atomic.LoadUint64(&s.hits),
+		Misses:  atomic.LoadUint64(&s.misses),
+		Sets:    atomic.LoadUint64(&s.sets),
+		Deletes: atomic.LoadUint64(&s.deletes),
+		Errors:  atomic.LoadUint64(&s.errors),
+	}
+}
+
+// Stats returns a snapshot of the operation counters
+// for this Cache.
+func (c *Cache) Stats() *Stats {
+	return c.stats.snapshot()
+}
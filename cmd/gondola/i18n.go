@@ -1,11 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"gnd.la/i18n/messages"
+	"gnd.la/i18n/mt"
 	"gnd.la/i18n/po"
 	"gnd.la/log"
 )
@@ -65,3 +67,51 @@ func compileMessagesCommand(opts *compileMessagesOptions) error {
 	copts := &messages.CompileOptions{DefaultContext: opts.Context}
 	return messages.Compile(opts.Out, pos, copts)
 }
+
+type mtFillOptions struct {
+	Provider string `name:"provider" help:"Machine translation provider. Valid values are deepl and google."`
+	Key      string `name:"key" help:"API key for the machine translation provider."`
+	Source   string `name:"source" help:"Language of the untranslated messages."`
+	Target   string `name:"target" help:"Language to translate the messages into. If empty, the Language from each po file is used."`
+	Messages string `name:"messages" help:"Message files (.po) directory."`
+}
+
+func mtFillCommand(opts *mtFillOptions) error {
+	var provider mt.Provider
+	switch strings.ToLower(opts.Provider) {
+	case "deepl":
+		provider = &mt.DeepL{Key: opts.Key}
+	case "google":
+		provider = &mt.Google{Key: opts.Key}
+	default:
+		return fmt.Errorf("invalid provider %q, must be deepl or google", opts.Provider)
+	}
+	if opts.Key == "" {
+		return fmt.Errorf("no API key provided, use -key")
+	}
+	fopts := &mt.FillOptions{Source: opts.Source, Target: opts.Target}
+	return filepath.Walk(opts.Messages, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.ToLower(filepath.Ext(path)) != ".po" {
+			return nil
+		}
+		p, err := po.ParseFile(path)
+		if err != nil {
+			return err
+		}
+		res, err := mt.Fill(p, provider, fopts)
+		if err != nil {
+			return fmt.Errorf("error filling %s: %s", path, err)
+		}
+		for _, v := range res.Skipped {
+			log.Warningf("%s: skipped %q, translation didn't preserve its format verbs", path, v.Singular)
+		}
+		log.Infof("%s: filled %d messages, skipped %d", path, len(res.Filled), len(res.Skipped))
+		if len(res.Filled) == 0 {
+			return nil
+		}
+		return p.WriteFile(path)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"gnd.la/i18n/mt"
 	"gnd.la/log"
 
 	"gopkgs.com/command.v1"
@@ -85,6 +86,12 @@ var (
 			Func:    compileMessagesCommand,
 			Options: &compileMessagesOptions{Out: "messages.go", Messages: "_messages"},
 		},
+		{
+			Name:    "mt-fill",
+			Help:    "Fills untranslated messages in the po files from the current directory and its subdirectories using machine translation, marking them as fuzzy",
+			Func:    mtFillCommand,
+			Options: &mtFillOptions{Provider: "deepl", Source: mt.DefaultSource, Messages: "_messages"},
+		},
 		{
 			Name:    "gen",
 			Help:    "Perform code generation in the current directory according the rules in the config file",
@@ -10,6 +10,7 @@ import (
 
 	"gnd.la/app"
 	"gnd.la/log"
+	"gnd.la/tasks"
 
 	"gopkgs.com/vfs.v1"
 )
@@ -108,7 +109,78 @@ func renderTemplate(ctx *app.Context) {
 	}
 }
 
+func vetTemplates(ctx *app.Context) {
+	issues, err := ctx.App().LintTemplates()
+	if err != nil {
+		panic(err)
+	}
+	for _, v := range issues {
+		fmt.Println(v)
+	}
+	if len(issues) > 0 {
+		Errorf("%d template issues found", len(issues))
+	}
+}
+
+// runTask runs the task named by the first argument and prints its
+// result. Errors from the task are returned rather than panicking, so
+// they're reported like any other command error.
+func runTask(ctx *app.Context) error {
+	name := ctx.RequireIndexValue(0)
+	task := tasks.Lookup(name)
+	if task == nil {
+		return fmt.Errorf("there's no task registered with the name %q", name)
+	}
+	var args []string
+	for ii := 1; ii < ctx.Count(); ii++ {
+		args = append(args, ctx.IndexValue(ii))
+	}
+	params, err := task.ParseFlags(args)
+	if err != nil {
+		return usageError(err.Error())
+	}
+	value, err := task.Call(ctx, params)
+	if err != nil {
+		return fmt.Errorf("task %s failed: %s", name, err)
+	}
+	if value != nil {
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	}
+	return nil
+}
+
+func listTasks(ctx *app.Context) {
+	for _, v := range tasks.Tasks() {
+		fmt.Println(v.Name())
+		for _, p := range v.Params() {
+			fmt.Printf("  -%s (%s)", p.Name, p.Type)
+			if p.Default != "" {
+				fmt.Printf(" default: %s", p.Default)
+			}
+			if p.Required {
+				fmt.Print(" required")
+			}
+			if p.Help != "" {
+				fmt.Printf(" - %s", p.Help)
+			}
+			fmt.Print("\n")
+		}
+	}
+}
+
 func init() {
+	Register(errorHandler(runTask), &Options{
+		Name:  "run-task",
+		Usage: "<task> [-param=value ...]",
+		Help:  "Runs a registered task, parsing its parameters from the given flags",
+	})
+	Register(listTasks, &Options{
+		Help: "Lists the registered tasks and their parameters",
+	})
 	Register(catFile, &Options{
 		Help:  "Prints a file from the blobstore to the stdout",
 		Flags: Flags(BoolFlag("meta", false, "Print file metatada instead of file data")),
@@ -116,6 +188,9 @@ func init() {
 	Register(makeAssets, &Options{
 		Help: "Pre-compile and bundle all app assets",
 	})
+	Register(vetTemplates, &Options{
+		Help: "Check all templates for common mistakes, like undefined variables or unused blocks",
+	})
 	Register(printResources, &Options{Name: "_print-resources"})
 	Register(renderTemplate, &Options{
 		Name:  "_render-template",
@@ -106,6 +106,16 @@ func UsageErrorf(format string, args ...interface{}) {
 	usageErrors(fmt.Sprintf(format, args...))
 }
 
+// errorHandler adapts a function returning an error to an
+// app.Handler, stopping the command with the returned error.
+func errorHandler(f func(*app.Context) error) app.Handler {
+	return func(ctx *app.Context) {
+		if err := f(ctx); err != nil {
+			panic(err)
+		}
+	}
+}
+
 type usageError string
 
 func (e usageError) Error() string {
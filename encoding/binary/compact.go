@@ -0,0 +1,327 @@
+package binary
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sync"
+)
+
+// compactField describes how a struct field is
+// encoded by AppendCompact.
+type compactField struct {
+	index  int
+	varint bool
+}
+
+var compactFields struct {
+	sync.RWMutex
+	cache map[reflect.Type][]compactField
+}
+
+func structCompactFields(typ reflect.Type) []compactField {
+	compactFields.RLock()
+	fields, ok := compactFields.cache[typ]
+	compactFields.RUnlock()
+	if ok {
+		return fields
+	}
+	for ii := 0; ii < typ.NumField(); ii++ {
+		field := typ.Field(ii)
+		tag := field.Tag.Get("binary")
+		if field.PkgPath != "" || tag == "-" {
+			continue
+		}
+		fields = append(fields, compactField{index: ii, varint: tag == "varint"})
+	}
+	compactFields.Lock()
+	if compactFields.cache == nil {
+		compactFields.cache = make(map[reflect.Type][]compactField)
+	}
+	compactFields.cache[typ] = fields
+	compactFields.Unlock()
+	return fields
+}
+
+// AppendCompact appends the compact encoding of v, which must be a struct,
+// a pointer to a struct or any other supported type, to buf and returns the
+// extended buffer. The compact encoding works like Write, with the following
+// differences:
+//
+//   - Integer fields tagged with `binary:"varint"` are encoded as varints
+//     (using ZigZag for signed integers), so small values take less space.
+//     Otherwise, they're encoded with a fixed size using the given byte order.
+//     Note that int and uint always use 8 bytes when encoded with a fixed size.
+//   - Strings and slices are supported, encoded as their length (as an unsigned
+//     varint) followed by their elements.
+//   - Fields tagged with `binary:"-"` and unexported fields are ignored.
+//
+// Use DecodeCompact to decode the data.
+func AppendCompact(buf []byte, order *ByteOrder, v interface{}) ([]byte, error) {
+	return appendCompact(buf, order, reflect.Indirect(reflect.ValueOf(v)), false)
+}
+
+// MarshalCompact returns the compact encoding of v. See AppendCompact
+// for the details.
+func MarshalCompact(order *ByteOrder, v interface{}) ([]byte, error) {
+	return AppendCompact(nil, order, v)
+}
+
+func appendFixed(buf []byte, order *ByteOrder, size int, x uint64) []byte {
+	var tmp [8]byte
+	switch size {
+	case 1:
+		tmp[0] = byte(x)
+	case 2:
+		order.PutUint16(tmp[:], uint16(x))
+	case 4:
+		order.PutUint32(tmp[:], uint32(x))
+	default:
+		size = 8
+		order.PutUint64(tmp[:], x)
+	}
+	return append(buf, tmp[:size]...)
+}
+
+func appendCompact(buf []byte, order *ByteOrder, v reflect.Value, varint bool) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if varint {
+			return AppendVarint(buf, v.Int()), nil
+		}
+		return appendFixed(buf, order, fixedSize(v.Kind()), uint64(v.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if varint {
+			return AppendUvarint(buf, v.Uint()), nil
+		}
+		return appendFixed(buf, order, fixedSize(v.Kind()), v.Uint()), nil
+	case reflect.Float32:
+		return appendFixed(buf, order, 4, uint64(math.Float32bits(float32(v.Float())))), nil
+	case reflect.Float64:
+		return appendFixed(buf, order, 8, math.Float64bits(v.Float())), nil
+	case reflect.String:
+		s := v.String()
+		buf = AppendUvarint(buf, uint64(len(s)))
+		return append(buf, s...), nil
+	case reflect.Slice:
+		buf = AppendUvarint(buf, uint64(v.Len()))
+		if v.Type().Elem().Kind() == reflect.Uint8 && !varint {
+			return append(buf, v.Bytes()...), nil
+		}
+		fallthrough
+	case reflect.Array:
+		var err error
+		for ii := 0; ii < v.Len(); ii++ {
+			if buf, err = appendCompact(buf, order, v.Index(ii), varint); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case reflect.Struct:
+		var err error
+		for _, f := range structCompactFields(v.Type()) {
+			if buf, err = appendCompact(buf, order, v.Field(f.index), f.varint); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	}
+	if !v.IsValid() {
+		return nil, errors.New("binary: can't encode nil value")
+	}
+	return nil, fmt.Errorf("binary: can't encode type %s", v.Type())
+}
+
+func fixedSize(k reflect.Kind) int {
+	switch k {
+	case reflect.Int8, reflect.Uint8:
+		return 1
+	case reflect.Int16, reflect.Uint16:
+		return 2
+	case reflect.Int32, reflect.Uint32:
+		return 4
+	}
+	return 8
+}
+
+// DecodeCompact decodes the data encoded by AppendCompact into v,
+// which must be a non-nil pointer. It returns the number of bytes
+// read from data, which might be less than its length.
+func DecodeCompact(data []byte, order *ByteOrder, v interface{}) (int, error) {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return 0, fmt.Errorf("binary: can't decode into %T, need a non-nil pointer", v)
+	}
+	d := &compactDecoder{data: data, order: order}
+	if err := d.decode(val.Elem(), false); err != nil {
+		return d.pos, err
+	}
+	return d.pos, nil
+}
+
+// UnmarshalCompact works like DecodeCompact, but returns an
+// error if data contains trailing bytes.
+func UnmarshalCompact(data []byte, order *ByteOrder, v interface{}) error {
+	n, err := DecodeCompact(data, order, v)
+	if err == nil && n != len(data) {
+		err = fmt.Errorf("binary: %d trailing bytes after compact data", len(data)-n)
+	}
+	return err
+}
+
+type compactDecoder struct {
+	data  []byte
+	pos   int
+	order *ByteOrder
+}
+
+func (d *compactDecoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *compactDecoder) fixed(size int) (uint64, error) {
+	b, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(d.order.Uint16(b)), nil
+	case 4:
+		return uint64(d.order.Uint32(b)), nil
+	}
+	return d.order.Uint64(b), nil
+}
+
+func (d *compactDecoder) uvarint() (uint64, error) {
+	x, n := Uvarint(d.data[d.pos:])
+	if n == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if n < 0 {
+		return 0, overflow
+	}
+	d.pos += n
+	return x, nil
+}
+
+func (d *compactDecoder) length() (int, error) {
+	n, err := d.uvarint()
+	if err != nil {
+		return 0, err
+	}
+	// Every element takes at least 1 byte, so
+	// this also guards against huge allocations.
+	if n > uint64(len(d.data)-d.pos) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	return int(n), nil
+}
+
+func (d *compactDecoder) decode(v reflect.Value, varint bool) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		x, err := d.fixed(1)
+		if err != nil {
+			return err
+		}
+		v.SetBool(x != 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var x int64
+		if varint {
+			ux, err := d.uvarint()
+			if err != nil {
+				return err
+			}
+			x = UnZigZag(ux)
+		} else {
+			size := fixedSize(v.Kind())
+			ux, err := d.fixed(size)
+			if err != nil {
+				return err
+			}
+			// Sign extend
+			shift := uint(64 - 8*size)
+			x = int64(ux<<shift) >> shift
+		}
+		if v.OverflowInt(x) {
+			return fmt.Errorf("binary: value %d overflows %s", x, v.Type())
+		}
+		v.SetInt(x)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var x uint64
+		var err error
+		if varint {
+			x, err = d.uvarint()
+		} else {
+			x, err = d.fixed(fixedSize(v.Kind()))
+		}
+		if err != nil {
+			return err
+		}
+		if v.OverflowUint(x) {
+			return fmt.Errorf("binary: value %d overflows %s", x, v.Type())
+		}
+		v.SetUint(x)
+	case reflect.Float32:
+		x, err := d.fixed(4)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(float64(math.Float32frombits(uint32(x))))
+	case reflect.Float64:
+		x, err := d.fixed(8)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(math.Float64frombits(x))
+	case reflect.String:
+		n, err := d.length()
+		if err != nil {
+			return err
+		}
+		b, _ := d.next(n)
+		v.SetString(string(b))
+	case reflect.Slice:
+		n, err := d.length()
+		if err != nil {
+			return err
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 && !varint {
+			b, _ := d.next(n)
+			v.SetBytes(append([]byte(nil), b...))
+			return nil
+		}
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+		fallthrough
+	case reflect.Array:
+		for ii := 0; ii < v.Len(); ii++ {
+			if err := d.decode(v.Index(ii), varint); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for _, f := range structCompactFields(v.Type()) {
+			if err := d.decode(v.Field(f.index), f.varint); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("binary: can't decode type %s", v.Type())
+	}
+	return nil
+}
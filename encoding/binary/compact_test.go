@@ -0,0 +1,122 @@
+package binary
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type compactChunk struct {
+	Offset   int64  `binary:"varint"`
+	Length   uint32 `binary:"varint"`
+	Flags    uint8
+	Checksum uint64
+	Delta    int16 `binary:"varint"`
+	Fixed    int32
+	Name     string
+	Data     []byte
+	Sizes    []uint32 `binary:"varint"`
+	Ratio    float64
+	Hash     [4]byte
+	Ignored  string `binary:"-"`
+	private  int
+}
+
+func TestZigZag(t *testing.T) {
+	cases := map[int64]uint64{
+		0:                    0,
+		-1:                   1,
+		1:                    2,
+		-2:                   3,
+		2:                    4,
+		-9223372036854775808: 18446744073709551615,
+	}
+	for k, v := range cases {
+		if z := ZigZag(k); z != v {
+			t.Errorf("ZigZag(%d) = %d, want %d", k, z, v)
+		}
+		if u := UnZigZag(v); u != k {
+			t.Errorf("UnZigZag(%d) = %d, want %d", v, u, k)
+		}
+	}
+}
+
+func TestAppendVarint(t *testing.T) {
+	for _, x := range []int64{-1 << 63, -1 << 20, -129, -1, 0, 1, 127, 128, 1 << 40, 1<<63 - 1} {
+		buf := make([]byte, MaxVarintLen64)
+		n := PutVarint(buf, x)
+		if b := AppendVarint(nil, x); !bytes.Equal(b, buf[:n]) {
+			t.Errorf("AppendVarint(%d) = %v, want %v", x, b, buf[:n])
+		}
+		var w bytes.Buffer
+		if err := WriteVarint(&w, x); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(w.Bytes(), buf[:n]) {
+			t.Errorf("WriteVarint(%d) = %v, want %v", x, w.Bytes(), buf[:n])
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	in := &compactChunk{
+		Offset:   -12,
+		Length:   300,
+		Flags:    7,
+		Checksum: 0xdeadbeefcafe,
+		Delta:    -3,
+		Fixed:    -70000,
+		Name:     "chunk",
+		Data:     []byte{1, 2, 3},
+		Sizes:    []uint32{1, 200, 70000},
+		Ratio:    0.25,
+		Hash:     [4]byte{9, 8, 7, 6},
+		Ignored:  "ignored",
+		private:  3,
+	}
+	for _, order := range []*ByteOrder{BigEndian, LittleEndian} {
+		data, err := MarshalCompact(order, in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out compactChunk
+		if err := UnmarshalCompact(data, order, &out); err != nil {
+			t.Fatal(err)
+		}
+		exp := *in
+		exp.Ignored = ""
+		exp.private = 0
+		if !reflect.DeepEqual(out, exp) {
+			t.Errorf("%s: decoded %+v, want %+v", order, out, exp)
+		}
+		// Truncated data must fail
+		for ii := 0; ii < len(data); ii++ {
+			if _, err := DecodeCompact(data[:ii], order, &out); err == nil {
+				t.Errorf("%s: expecting an error decoding %d bytes of %d", order, ii, len(data))
+			}
+		}
+		if err := UnmarshalCompact(append(data, 0), order, &out); err == nil {
+			t.Errorf("%s: expecting an error with trailing data", order)
+		}
+	}
+}
+
+func TestCompactSize(t *testing.T) {
+	type fixed struct {
+		A, B, C uint32
+	}
+	type compact struct {
+		A, B, C uint32 `binary:"varint"`
+	}
+	f, err := MarshalCompact(BigEndian, &fixed{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := MarshalCompact(BigEndian, &compact{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f) != 12 || len(c) != 3 {
+		t.Errorf("unexpected sizes fixed = %d, compact = %d", len(f), len(c))
+	}
+}
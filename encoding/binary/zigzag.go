@@ -0,0 +1,47 @@
+package binary
+
+import (
+	"io"
+)
+
+// ZigZag maps a signed integer to an unsigned one using "zig-zag"
+// encoding, so numbers with a small absolute value are mapped to small
+// numbers: 0 => 0, -1 => 1, 1 => 2, -2 => 3, etc... This is the
+// transformation used by PutVarint before encoding the value.
+func ZigZag(x int64) uint64 {
+	return uint64(x<<1) ^ uint64(x>>63)
+}
+
+// UnZigZag reverses the transformation performed by ZigZag.
+func UnZigZag(ux uint64) int64 {
+	return int64(ux>>1) ^ -int64(ux&1)
+}
+
+// AppendUvarint appends the varint encoding of x to buf and
+// returns the extended buffer.
+func AppendUvarint(buf []byte, x uint64) []byte {
+	for x >= 0x80 {
+		buf = append(buf, byte(x)|0x80)
+		x >>= 7
+	}
+	return append(buf, byte(x))
+}
+
+// AppendVarint appends the varint encoding of x to buf and
+// returns the extended buffer.
+func AppendVarint(buf []byte, x int64) []byte {
+	return AppendUvarint(buf, ZigZag(x))
+}
+
+// WriteUvarint writes the varint encoding of x to w.
+func WriteUvarint(w io.Writer, x uint64) error {
+	var buf [MaxVarintLen64]byte
+	n := PutUvarint(buf[:], x)
+	_, err := w.Write(buf[:n])
+	return err
+}
+
+// WriteVarint writes the varint encoding of x to w.
+func WriteVarint(w io.Writer, x int64) error {
+	return WriteUvarint(w, ZigZag(x))
+}
@@ -6,8 +6,10 @@
 //
 // This package provides the "gob" and "json" codecs, which encode
 // the data using encoding/gob and encoding/json, respectivelly.
-// Check gnd.la/cache and gnd.la/orm to learn how to use codecs with
-// Gondola's cache and ORM.
+// Binary compact encodings are provided by gnd.la/encoding/codec/msgpack
+// and gnd.la/encoding/codec/protobuf, which register the "msgpack" and
+// "protobuf" codecs when imported. Check gnd.la/cache and gnd.la/orm
+// to learn how to use codecs with Gondola's cache and ORM.
 //
 // Users might define their own codecs by implementing a Codec
 // struct and registering it with Register().
@@ -20,7 +22,8 @@ import (
 var (
 	codecs  = map[string]*Codec{}
 	imports = map[string]string{
-		"msgpack": "gnd.la/encoding/codec/msgpack",
+		"msgpack":  "gnd.la/encoding/codec/msgpack",
+		"protobuf": "gnd.la/encoding/codec/protobuf",
 	}
 )
 
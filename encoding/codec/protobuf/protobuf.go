@@ -0,0 +1,62 @@
+// Package protobuf provides a codec implementation using protocol
+// buffers. Only types implementing proto.Message (or whose pointer
+// implements it) can be encoded and decoded with this codec.
+//
+// To enable it in your app, import it like:
+//
+//	import (
+//		_ "gnd.la/encoding/codec/protobuf"
+//	)
+package protobuf
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/golang/protobuf/proto"
+	"gnd.la/encoding/codec"
+)
+
+var (
+	protobufCodec = &codec.Codec{Encode: protobufMarshal, Decode: protobufUnmarshal, Binary: true}
+)
+
+func protobufMarshal(in interface{}) ([]byte, error) {
+	if m, ok := in.(proto.Message); ok {
+		return proto.Marshal(m)
+	}
+	// Non-pointer struct fields are passed by value, but
+	// messages are implemented by their pointers.
+	val := reflect.ValueOf(in)
+	if val.IsValid() {
+		ptr := reflect.New(val.Type())
+		ptr.Elem().Set(val)
+		if m, ok := ptr.Interface().(proto.Message); ok {
+			return proto.Marshal(m)
+		}
+	}
+	return nil, fmt.Errorf("can't encode %T with protobuf, it does not implement proto.Message", in)
+}
+
+func protobufUnmarshal(data []byte, out interface{}) error {
+	if m, ok := out.(proto.Message); ok {
+		return proto.Unmarshal(data, m)
+	}
+	// Pointer fields are received as a pointer to the
+	// pointer, which might be nil.
+	val := reflect.ValueOf(out)
+	if val.Kind() == reflect.Ptr && val.Elem().Kind() == reflect.Ptr {
+		elem := val.Elem()
+		if _, ok := reflect.Zero(elem.Type()).Interface().(proto.Message); ok {
+			if elem.IsNil() {
+				elem.Set(reflect.New(elem.Type().Elem()))
+			}
+			return proto.Unmarshal(data, elem.Interface().(proto.Message))
+		}
+	}
+	return fmt.Errorf("can't decode into %T with protobuf, it does not implement proto.Message", out)
+}
+
+func init() {
+	codec.Register("protobuf", protobufCodec)
+}
@@ -0,0 +1,78 @@
+package protobuf
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"gnd.la/encoding/codec"
+)
+
+type testMessage struct {
+	Name  string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Count int64  `protobuf:"varint,2,opt,name=count" json:"count,omitempty"`
+}
+
+func (m *testMessage) Reset()         { *m = testMessage{} }
+func (m *testMessage) String() string { return proto.CompactTextString(m) }
+func (*testMessage) ProtoMessage()    {}
+
+func TestProtobuf(t *testing.T) {
+	c := codec.Get("protobuf")
+	if c == nil {
+		t.Fatal("protobuf codec not registered")
+	}
+	msg := testMessage{Name: "gondola", Count: 42}
+	// Struct fields are encoded by value
+	data, err := c.Encode(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var val testMessage
+	if err := c.Decode(data, &val); err != nil {
+		t.Fatal(err)
+	}
+	if val != msg {
+		t.Errorf("expecting %+v decoding value, got %+v", msg, val)
+	}
+	// Pointer fields are encoded as pointers and decoded
+	// into a pointer to them, which might be nil.
+	if data, err = c.Encode(&msg); err != nil {
+		t.Fatal(err)
+	}
+	var ptr *testMessage
+	if err := c.Decode(data, &ptr); err != nil {
+		t.Fatal(err)
+	}
+	if ptr == nil || *ptr != msg {
+		t.Errorf("expecting %+v decoding nil pointer, got %+v", msg, ptr)
+	}
+	prev := ptr
+	if err := c.Decode(data, &ptr); err != nil {
+		t.Fatal(err)
+	}
+	if ptr != prev || *ptr != msg {
+		t.Errorf("expecting pointer to be reused decoding, got %p and %p", prev, ptr)
+	}
+}
+
+func TestProtobufNonMessage(t *testing.T) {
+	c := codec.Get("protobuf")
+	type notMessage struct {
+		Name string
+	}
+	for _, v := range []interface{}{nil, 42, notMessage{}, &notMessage{}} {
+		if _, err := c.Encode(v); err == nil {
+			t.Errorf("expecting an error encoding %T", v)
+		}
+	}
+	var n int
+	var nm *notMessage
+	for _, v := range []interface{}{nil, &n, &nm, notMessage{}} {
+		if err := c.Decode(nil, v); err == nil {
+			t.Errorf("expecting an error decoding into %T", v)
+		}
+	}
+	if nm != nil {
+		t.Error("expecting pointer not to be allocated decoding a non message")
+	}
+}
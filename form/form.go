@@ -83,7 +83,7 @@ func (f *Form) validate() {
 				v.value.Set(reflect.ValueOf(value))
 			}
 		} else {
-			if err := input.InputNamed(label, inp, v.SettableValue(), v.Tag(), true); err != nil {
+			if err := input.InputNamedLanguage(f.ctx, label, inp, v.SettableValue(), v.Tag(), true); err != nil {
 				v.err = i18n.TranslatedError(err, f.ctx)
 				continue
 			}
@@ -39,11 +39,18 @@ func RequiredInputError(name string) error {
 // Finally, the required parameter indicates if the value should be considered required
 // or optional in absence of the "required" and "optional" tag fields.
 func InputNamed(name string, input string, out interface{}, tag *structs.Tag, required bool) error {
+	return InputNamedLanguage(nil, name, input, out, tag, required)
+}
+
+// InputNamedLanguage works like InputNamed, but parses the input using
+// ParseLanguage with the given language, so numbers and dates might be
+// typed in the format used by it.
+func InputNamedLanguage(lang i18n.Languager, name string, input string, out interface{}, tag *structs.Tag, required bool) error {
 	v, err := types.SettableValue(out)
 	if err != nil {
 		return err
 	}
-	if err := parse(input, v); err != nil {
+	if err := parse(lang, input, v); err != nil {
 		return err
 	}
 	if v.Type().Kind() != reflect.Bool && tag != nil {
@@ -8,10 +8,12 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
 var (
 	parserInterface = reflect.TypeOf((*Parser)(nil)).Elem()
+	timeType        = reflect.TypeOf(time.Time{})
 )
 
 // Parser is the interface implemented by types
@@ -27,12 +29,21 @@ type Parser interface {
 //     Parse("27.5", &f)
 //     var width uint
 //     Parse("57", &width)
-// Supported types are: string, bool, u?int(8|16|32|64)? and float(32|64). If
-// the parsed value would overflow the given type, the maximum value
-// (or minimum, if it's negative) for the type will be set.
+// Supported types are: string, bool, u?int(8|16|32|64)?, float(32|64) and
+// time.Time (in ISO 8601 format, e.g. 2006-01-02). If the parsed value
+// would overflow the given type, the maximum value (or minimum, if it's
+// negative) for the type will be set.
 // If arg implements the Parser interface, its Parse method will
 // be used instead.
 func Parse(val string, arg interface{}) error {
+	return ParseLanguage(nil, val, arg)
+}
+
+// ParseLanguage works like Parse, but also accepts numbers and dates
+// in the format used by the given language (e.g. "1.234,5" or 31/12/2006
+// in Spanish). See i18n.ParseNumber and i18n.ParseTime for details. If
+// lang is nil, it's equivalent to Parse.
+func ParseLanguage(lang i18n.Languager, val string, arg interface{}) error {
 	if parser, ok := arg.(Parser); ok {
 		return parser.Parse(val)
 	}
@@ -40,10 +51,10 @@ func Parse(val string, arg interface{}) error {
 	if err != nil {
 		return err
 	}
-	return parse(val, v)
+	return parse(lang, val, v)
 }
 
-func parse(val string, v reflect.Value) error {
+func parse(lang i18n.Languager, val string, v reflect.Value) error {
 	var err error
 	p := v
 	// Get Pointer methods
@@ -65,6 +76,14 @@ func parse(val string, v reflect.Value) error {
 		v.Set(reflect.Zero(v.Type()))
 		return nil
 	}
+	if v.Type() == timeType {
+		t, err := i18n.ParseTime(lang, val)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
 	switch v.Type().Kind() {
 	case reflect.Bool:
 		res := false
@@ -79,6 +98,11 @@ func parse(val string, v reflect.Value) error {
 		return nil
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		res, err := strconv.ParseInt(val, 0, 64)
+		if err != nil && lang != nil {
+			if n, nerr := i18n.NormalizeNumber(lang, val); nerr == nil {
+				res, err = strconv.ParseInt(n, 10, 64)
+			}
+		}
 		if err != nil {
 			return err
 		}
@@ -93,6 +117,11 @@ func parse(val string, v reflect.Value) error {
 		return nil
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		res, err := strconv.ParseUint(val, 0, 64)
+		if err != nil && lang != nil {
+			if n, nerr := i18n.NormalizeNumber(lang, val); nerr == nil {
+				res, err = strconv.ParseUint(n, 10, 64)
+			}
+		}
 		if err != nil {
 			return err
 		}
@@ -102,7 +131,17 @@ func parse(val string, v reflect.Value) error {
 		v.SetUint(res)
 		return nil
 	case reflect.Float32, reflect.Float64:
-		res, err := strconv.ParseFloat(val, 64)
+		var res float64
+		var err error
+		// Try the localized format first, since a number like
+		// "1.234" is valid in both formats, but has a different
+		// value.
+		if lang != nil {
+			res, err = i18n.ParseNumber(lang, val)
+		}
+		if lang == nil || err != nil {
+			res, err = strconv.ParseFloat(val, 64)
+		}
 		if err != nil {
 			return err
 		}
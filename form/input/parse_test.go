@@ -3,8 +3,18 @@ package input
 import (
 	"reflect"
 	"testing"
+	"time"
+
+	// Separators for formatting and parsing numbers
+	_ "gnd.la/util/formatutil"
 )
 
+type Languager string
+
+func (l Languager) Language() string {
+	return string(l)
+}
+
 type ParseCase struct {
 	Value    string
 	Type     reflect.Type
@@ -40,3 +50,45 @@ func TestParse(t *testing.T) {
 		t.Logf("Parsed %q as %v", v.Value, result)
 	}
 }
+
+func TestParseLanguage(t *testing.T) {
+	date := time.Date(2006, 12, 31, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		Lang Languager
+		ParseCase
+	}{
+		{"en", ParseCase{"1,234.5", reflect.TypeOf(float64(0)), 1234.5}},
+		{"en", ParseCase{"1,234", reflect.TypeOf(int(0)), 1234}},
+		{"en", ParseCase{"12/31/2006", reflect.TypeOf(time.Time{}), date}},
+		{"es", ParseCase{"1.234,5", reflect.TypeOf(float64(0)), 1234.5}},
+		{"es", ParseCase{"-0,25", reflect.TypeOf(float64(0)), -0.25}},
+		{"es", ParseCase{"1.234", reflect.TypeOf(float64(0)), float64(1234)}},
+		// Not a valid number with grouping, parsed as 1.5
+		{"es", ParseCase{"1.5", reflect.TypeOf(float64(0)), 1.5}},
+		{"es", ParseCase{"1.000.000", reflect.TypeOf(uint32(0)), uint32(1000000)}},
+		{"es", ParseCase{"31/12/2006", reflect.TypeOf(time.Time{}), date}},
+		{"es_ES", ParseCase{"31/12/2006 10:30", reflect.TypeOf(time.Time{}), date.Add(10*time.Hour + 30*time.Minute)}},
+		{"es", ParseCase{"2006-12-31", reflect.TypeOf(time.Time{}), date}},
+		{"de", ParseCase{"31.12.2006", reflect.TypeOf(time.Time{}), date}},
+	}
+	for _, v := range cases {
+		val := reflect.New(v.Type)
+		err := ParseLanguage(v.Lang, v.Value, val.Interface())
+		if err != nil {
+			t.Errorf("error parsing %q (%s): %s", v.Value, v.Lang, err)
+			continue
+		}
+		result := val.Elem().Interface()
+		if !reflect.DeepEqual(result, v.Expected) {
+			t.Errorf("error parsing %q (%s). Want %v, got %v.", v.Value, v.Lang, v.Expected, result)
+		}
+	}
+	invalid := []string{"1.23.4", "1,2,3", "12/31/2006"}
+	for _, v := range invalid {
+		var f float64
+		var d time.Time
+		if ParseLanguage(Languager("es"), v, &f) == nil && ParseLanguage(Languager("es"), v, &d) == nil {
+			t.Errorf("expecting an error when parsing %q", v)
+		}
+	}
+}
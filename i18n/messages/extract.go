@@ -25,6 +25,10 @@ func DefaultFunctions() []*Function {
 		{Name: "gnd.la/i18n.Sprintf", Start: 1},
 		{Name: "gnd.la/i18n.NewError"},
 		{Name: "gnd.la/app.Context.T"},
+		{Name: "gnd.la/app.Context.AddFlash", Start: 1},
+		{Name: "gnd.la/app.Context.FlashSuccess"},
+		{Name: "gnd.la/app.Context.FlashInfo"},
+		{Name: "gnd.la/app.Context.FlashError"},
 		{Name: "t", Template: true},
 		// Singular functions with context
 		{Name: "gnd.la/i18n.Tc", Context: true, Start: 1},
@@ -0,0 +1,79 @@
+package mt
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gnd.la/net/httpclient"
+)
+
+const (
+	deepLURL     = "https://api.deepl.com/v2/translate"
+	deepLFreeURL = "https://api-free.deepl.com/v2/translate"
+)
+
+// DeepL is a Provider which uses the DeepL API. See
+// https://www.deepl.com/docs-api for more information.
+type DeepL struct {
+	// Key is the DeepL authentication key. Keys for the free
+	// API (ending with :fx) are automatically sent to the
+	// free API endpoint.
+	Key string
+	// Client is the client used for performing the requests.
+	// If nil, a new one is created.
+	Client *httpclient.Client
+}
+
+func (d *DeepL) Translate(source string, target string, texts []string) ([]string, error) {
+	u := deepLURL
+	if strings.HasSuffix(d.Key, ":fx") {
+		u = deepLFreeURL
+	}
+	form := url.Values{
+		"source_lang": {deepLLanguage(source)},
+		"target_lang": {deepLLanguage(target)},
+		"text":        texts,
+	}
+	req, err := http.NewRequest("POST", u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "DeepL-Auth-Key "+d.Key)
+	resp, err := client(d.Client).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+	if !resp.IsOK() {
+		return nil, fmt.Errorf("DeepL returned status code %d", resp.StatusCode)
+	}
+	var res struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	if err := resp.UnmarshalJSON(&res); err != nil {
+		return nil, err
+	}
+	translations := make([]string, len(res.Translations))
+	for ii, v := range res.Translations {
+		translations[ii] = v.Text
+	}
+	return translations, nil
+}
+
+// deepLLanguage returns the language code used by DeepL
+// (e.g. pt_BR becomes PT-BR).
+func deepLLanguage(lang string) string {
+	return strings.ToUpper(strings.Replace(lang, "_", "-", -1))
+}
+
+func client(c *httpclient.Client) *httpclient.Client {
+	if c == nil {
+		return httpclient.New(nil)
+	}
+	return c
+}
@@ -0,0 +1,61 @@
+package mt
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"gnd.la/net/httpclient"
+)
+
+const (
+	googleURL = "https://translation.googleapis.com/language/translate/v2"
+)
+
+// Google is a Provider which uses the Google Cloud Translation API. See
+// https://cloud.google.com/translate/docs for more information.
+type Google struct {
+	// Key is the API key.
+	Key string
+	// Client is the client used for performing the requests.
+	// If nil, a new one is created.
+	Client *httpclient.Client
+}
+
+func (g *Google) Translate(source string, target string, texts []string) ([]string, error) {
+	form := url.Values{
+		"source": {googleLanguage(source)},
+		"target": {googleLanguage(target)},
+		"format": {"text"},
+		"q":      texts,
+	}
+	resp, err := client(g.Client).PostForm(googleURL+"?key="+url.QueryEscape(g.Key), form)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+	if !resp.IsOK() {
+		return nil, fmt.Errorf("Google Translate returned status code %d", resp.StatusCode)
+	}
+	var res struct {
+		Data struct {
+			Translations []struct {
+				TranslatedText string `json:"translatedText"`
+			} `json:"translations"`
+		} `json:"data"`
+	}
+	if err := resp.UnmarshalJSON(&res); err != nil {
+		return nil, err
+	}
+	translations := make([]string, len(res.Data.Translations))
+	for ii, v := range res.Data.Translations {
+		translations[ii] = v.TranslatedText
+	}
+	return translations, nil
+}
+
+// googleLanguage returns the language code used by Google
+// (e.g. pt_BR becomes pt-BR).
+func googleLanguage(lang string) string {
+	return strings.Replace(lang, "_", "-", -1)
+}
@@ -0,0 +1,159 @@
+// Package mt fills untranslated messages in a catalog using a machine
+// translation provider, to bootstrap new languages.
+//
+// Filled messages are marked as fuzzy, so translators can find and
+// review them. Messages with format verbs (e.g. %s or %d) are only
+// filled if the translation keeps the same verbs in the same order,
+// since machine translation might mangle them.
+//
+// Providers for DeepL (see DeepL) and Google Cloud Translation (see
+// Google) are included, while other services might be used by
+// implementing the Provider interface. See also the mt-fill
+// command in gnd.la/cmd/gondola.
+package mt
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gnd.la/i18n/po"
+)
+
+const (
+	// DefaultSource is the default source language
+	// used by Fill.
+	DefaultSource = "en"
+	// DefaultBatchSize is the default maximum number
+	// of strings translated in a single call to the
+	// Provider.
+	DefaultBatchSize = 50
+)
+
+var (
+	formatVerbRe  = regexp.MustCompile(`%(?:\[\d+\])?[-+# 0]*(?:\d+|\*)?(?:\.(?:\d+|\*))?[a-zA-Z%]`)
+	pluralFormsRe = regexp.MustCompile(`nplurals\s*=\s*(\d+)`)
+)
+
+// Provider is the interface implemented by machine translation services.
+type Provider interface {
+	// Translate translates the given texts from the source language
+	// to the target one, returning the translations in the same order.
+	// Languages are specified using their codes (e.g. "en" or "pt_BR").
+	Translate(source string, target string, texts []string) ([]string, error)
+}
+
+// FillOptions specify the options for Fill.
+type FillOptions struct {
+	// Source is the language of the untranslated messages. If
+	// empty, DefaultSource is used.
+	Source string
+	// Target is the language to translate the messages into. If
+	// empty, the Language attribute from the catalog is used.
+	Target string
+	// BatchSize is the maximum number of strings sent to the
+	// Provider in a single call. If zero, DefaultBatchSize
+	// is used.
+	BatchSize int
+}
+
+// FillResult contains the results of Fill.
+type FillResult struct {
+	// Filled contains the messages which were translated.
+	Filled []*po.Translation
+	// Skipped contains the messages which were not translated
+	// because the translation didn't preserve their format
+	// verbs.
+	Skipped []*po.Translation
+}
+
+// Fill translates the untranslated messages in the given catalog using
+// the provided Provider and marks them as fuzzy. Messages which already
+// have a translation, including fuzzy ones, are not modified. For messages
+// with plural forms, the singular is translated for the first form and the
+// plural for the rest of them, as indicated by the Plural-Forms attribute
+// of the catalog.
+func Fill(p *po.Po, provider Provider, opts *FillOptions) (*FillResult, error) {
+	var o FillOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Source == "" {
+		o.Source = DefaultSource
+	}
+	if o.Target == "" {
+		o.Target = p.Attrs["Language"]
+		if o.Target == "" {
+			return nil, fmt.Errorf("catalog has no Language attribute, please specify the target language")
+		}
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBatchSize
+	}
+	nplurals := 2
+	if m := pluralFormsRe.FindStringSubmatch(p.Attrs["Plural-Forms"]); m != nil {
+		nplurals, _ = strconv.Atoi(m[1])
+	}
+	var pending []*po.Translation
+	var texts []string
+	for _, v := range p.Messages {
+		if v.Singular == "" || v.IsTranslated() {
+			continue
+		}
+		pending = append(pending, v)
+		texts = append(texts, v.Singular)
+		if v.Plural != "" {
+			texts = append(texts, v.Plural)
+		}
+	}
+	translated := make([]string, 0, len(texts))
+	for len(translated) < len(texts) {
+		end := len(translated) + o.BatchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batch := texts[len(translated):end]
+		res, err := provider.Translate(o.Source, o.Target, batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(res) != len(batch) {
+			return nil, fmt.Errorf("provider returned %d translations for %d strings", len(res), len(batch))
+		}
+		translated = append(translated, res...)
+	}
+	result := &FillResult{}
+	for _, v := range pending {
+		singular := translated[0]
+		translated = translated[1:]
+		if v.Plural == "" {
+			if !sameVerbs(v.Singular, singular) {
+				result.Skipped = append(result.Skipped, v)
+				continue
+			}
+			v.Translations = []string{singular}
+		} else {
+			plural := translated[0]
+			translated = translated[1:]
+			if !sameVerbs(v.Singular, singular) || !sameVerbs(v.Plural, plural) {
+				result.Skipped = append(result.Skipped, v)
+				continue
+			}
+			v.Translations = make([]string, nplurals)
+			v.Translations[0] = singular
+			for ii := 1; ii < nplurals; ii++ {
+				v.Translations[ii] = plural
+			}
+		}
+		v.SetFuzzy()
+		result.Filled = append(result.Filled, v)
+	}
+	return result, nil
+}
+
+func sameVerbs(s1, s2 string) bool {
+	v1 := formatVerbRe.FindAllString(s1, -1)
+	v2 := formatVerbRe.FindAllString(s2, -1)
+	return strings.Join(v1, "\x00") == strings.Join(v2, "\x00")
+}
@@ -0,0 +1,70 @@
+package mt
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gnd.la/i18n/po"
+)
+
+type upperProvider struct {
+	calls int
+}
+
+func (p *upperProvider) Translate(source string, target string, texts []string) ([]string, error) {
+	p.calls++
+	res := make([]string, len(texts))
+	for ii, v := range texts {
+		// Mangle the verbs in strings with "world"
+		if strings.Contains(v, "worlds") {
+			v = strings.Replace(v, "%d", "% d", -1)
+		}
+		res[ii] = strings.ToUpper(v)
+	}
+	return res, nil
+}
+
+func TestFill(t *testing.T) {
+	p, err := po.ParseFile(filepath.Join("..", "po", "_test_data", "es.po"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var untranslated int
+	for _, v := range p.Messages {
+		if v.Singular != "" && !v.IsTranslated() {
+			untranslated++
+		}
+	}
+	provider := &upperProvider{}
+	res, err := Fill(p, provider, &FillOptions{BatchSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Filled)+len(res.Skipped) != untranslated {
+		t.Errorf("expecting %d filled or skipped messages, got %d", untranslated, len(res.Filled)+len(res.Skipped))
+	}
+	if len(res.Skipped) != 1 || res.Skipped[0].Plural != "Hello %d worlds" {
+		t.Errorf("expecting the message with mangled verbs to be skipped, got %v", res.Skipped)
+	}
+	if provider.calls < 2 {
+		t.Errorf("expecting multiple batches, got %d calls", provider.calls)
+	}
+	for _, v := range res.Filled {
+		if !v.IsFuzzy() {
+			t.Errorf("filled message %q is not fuzzy", v.Singular)
+		}
+		if v.Translations[0] != strings.ToUpper(v.Singular) {
+			t.Errorf("expecting translation %q for %q, got %q", strings.ToUpper(v.Singular), v.Singular, v.Translations[0])
+		}
+	}
+	// Filling again should not translate anything
+	provider.calls = 0
+	res, err = Fill(p, provider, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Filled) != 0 || len(res.Skipped) != 1 {
+		t.Errorf("expecting only the skipped message to be retried, got %d filled and %d skipped", len(res.Filled), len(res.Skipped))
+	}
+}
@@ -0,0 +1,184 @@
+package i18n
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// Layouts accepted by ParseTime for every language
+	isoLayouts = []string{
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02",
+	}
+	// Suffixes appended to the localized date layouts, to
+	// accept dates with times.
+	timeSuffixes = []string{"", " 15:04", " 15:04:05"}
+	dateLayouts  = map[string][]string{
+		"en":    {"1/2/2006"},
+		"en_AU": {"2/1/2006"},
+		"en_GB": {"2/1/2006"},
+		"en_IE": {"2/1/2006"},
+		"en_IN": {"2/1/2006"},
+		"en_NZ": {"2/1/2006"},
+		"ca":    {"2/1/2006"},
+		"cs":    {"2.1.2006", "2. 1. 2006"},
+		"da":    {"2.1.2006", "2-1-2006"},
+		"de":    {"2.1.2006"},
+		"el":    {"2/1/2006"},
+		"es":    {"2/1/2006", "2-1-2006"},
+		"fi":    {"2.1.2006"},
+		"fr":    {"2/1/2006"},
+		"gl":    {"2/1/2006"},
+		"it":    {"2/1/2006"},
+		"ja":    {"2006/1/2"},
+		"ko":    {"2006. 1. 2", "2006/1/2"},
+		"nb":    {"2.1.2006"},
+		"nl":    {"2-1-2006"},
+		"pl":    {"2.1.2006"},
+		"pt":    {"2/1/2006"},
+		"ru":    {"2.1.2006"},
+		"tr":    {"2.1.2006"},
+		"uk":    {"2.1.2006"},
+		"zh":    {"2006/1/2"},
+	}
+	dateLayoutsMu sync.RWMutex
+)
+
+// RegisterDateLayouts sets the date layouts, as accepted by time.Parse,
+// used by ParseTime for the given language. Language codes might include
+// the country (e.g. en_GB), which takes precedence over the layouts for
+// the language alone (e.g. en). Layouts should not include times, since
+// ParseTime also accepts each of them followed by a time in the
+// 15:04 or 15:04:05 formats.
+func RegisterDateLayouts(lang string, layouts ...string) {
+	dateLayoutsMu.Lock()
+	dateLayouts[lang] = layouts
+	dateLayoutsMu.Unlock()
+}
+
+func languageDateLayouts(lang Languager) []string {
+	if lang == nil {
+		return nil
+	}
+	code := lang.Language()
+	dateLayoutsMu.RLock()
+	defer dateLayoutsMu.RUnlock()
+	if layouts, ok := dateLayouts[code]; ok {
+		return layouts
+	}
+	if p := strings.IndexAny(code, "_-"); p >= 0 {
+		return dateLayouts[code[:p]]
+	}
+	return nil
+}
+
+// ParseTime parses a date, optionally including a time, typed by a user
+// in the language returned by lang, returning it in UTC. The date might
+// be in any of the formats registered for the language (see
+// RegisterDateLayouts) or in ISO 8601 (e.g. 2006-01-02 or
+// 2006-01-02T15:04), which is what browsers send from date inputs.
+func ParseTime(lang Languager, s string) (time.Time, error) {
+	return ParseTimeInLocation(lang, s, time.UTC)
+}
+
+// ParseTimeInLocation works like ParseTime, but interprets dates without
+// a time zone in the given location.
+func ParseTimeInLocation(lang Languager, s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range languageDateLayouts(lang) {
+		for _, suffix := range timeSuffixes {
+			if t, err := time.ParseInLocation(layout+suffix, s, loc); err == nil {
+				return t, nil
+			}
+		}
+	}
+	for _, layout := range isoLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, Errorf("invalid date %q", s)
+}
+
+// ParseNumber parses a number typed by a user in the language returned
+// by lang (e.g. "1.234,56" in Spanish or "1,234.56" in English). See
+// NormalizeNumber for the accepted formats.
+func ParseNumber(lang Languager, s string) (float64, error) {
+	n, err := NormalizeNumber(lang, s)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(n, 64)
+}
+
+// NormalizeNumber converts a number typed by a user in the language
+// returned by lang to the format accepted by the strconv package,
+// e.g. "1.234,56" becomes "1234.56" in Spanish. Thousands separators
+// are optional but, when present, they must separate groups of 3
+// digits, so numbers typed using the other convention are not
+// misinterpreted. The separators are the same ones used by
+// gnd.la/util/formatutil.Number, so numbers formatted by it are
+// always accepted.
+func NormalizeNumber(lang Languager, s string) (string, error) {
+	// Use the same strings as formatutil, so translations are shared.
+	tSep := Tc(lang, "formautil", ",")
+	dSep := Tc(lang, "formautil", ".")
+	n := strings.TrimSpace(s)
+	if strings.TrimSpace(tSep) == "" {
+		// Spaces used as thousands separators might be typed
+		// as any kind of space.
+		n = strings.NewReplacer(" ", tSep, "\u00a0", tSep, "\u202f", tSep).Replace(n)
+	}
+	var sign string
+	if n != "" && (n[0] == '-' || n[0] == '+') {
+		sign = n[:1]
+		n = n[1:]
+	}
+	integer := n
+	var decimal string
+	if p := strings.Index(n, dSep); p >= 0 {
+		integer = n[:p]
+		decimal = n[p+len(dSep):]
+		if decimal == "" || !isDigits(decimal) {
+			return "", Errorf("invalid number %q", s)
+		}
+		if integer == "" {
+			integer = "0"
+		}
+	}
+	if tSep != "" && strings.Contains(integer, tSep) {
+		groups := strings.Split(integer, tSep)
+		if len(groups[0]) == 0 || len(groups[0]) > 3 {
+			return "", Errorf("invalid number %q", s)
+		}
+		for _, v := range groups[1:] {
+			if len(v) != 3 {
+				return "", Errorf("invalid number %q", s)
+			}
+		}
+		integer = strings.Join(groups, "")
+	}
+	if integer == "" || !isDigits(integer) {
+		return "", Errorf("invalid number %q", s)
+	}
+	if decimal != "" {
+		return sign + integer + "." + decimal, nil
+	}
+	return sign + integer, nil
+}
+
+func isDigits(s string) bool {
+	for ii := 0; ii < len(s); ii++ {
+		if s[ii] < '0' || s[ii] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,8 +1,10 @@
 package po
 
 import (
+	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
@@ -14,15 +16,62 @@ const (
 )
 
 type Translation struct {
+	// Comments contains the comment lines preceding the translation,
+	// including the leading # (e.g. "#: file.go:12" or "#, fuzzy").
+	Comments     []string
 	Context      string
 	Singular     string
 	Plural       string
 	Translations []string
 }
 
+// IsTranslated returns true iff the Translation has at least
+// one non-empty translated string.
+func (t *Translation) IsTranslated() bool {
+	for _, v := range t.Translations {
+		if v != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFuzzy returns true iff the Translation has the fuzzy flag,
+// which indicates that it needs to be reviewed by a translator.
+func (t *Translation) IsFuzzy() bool {
+	for _, v := range t.Comments {
+		if strings.HasPrefix(v, "#,") {
+			for _, flag := range strings.Split(v[2:], ",") {
+				if strings.TrimSpace(flag) == "fuzzy" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// SetFuzzy adds the fuzzy flag to the Translation, if it
+// doesn't have it yet.
+func (t *Translation) SetFuzzy() {
+	if t.IsFuzzy() {
+		return
+	}
+	for ii, v := range t.Comments {
+		if strings.HasPrefix(v, "#,") {
+			t.Comments[ii] = "#, fuzzy," + strings.TrimPrefix(v[2:], " ")
+			return
+		}
+	}
+	t.Comments = append(t.Comments, "#, fuzzy")
+}
+
 type Po struct {
 	Attrs    map[string]string
 	Messages []*Translation
+	// TrailingComments contains the comment lines after the
+	// last message (e.g. obsolete messages).
+	TrailingComments []string
 }
 
 func (p *Po) addTranslation(t *Translation) {
@@ -53,10 +102,13 @@ type namer interface {
 }
 
 func parsePo(r io.Reader, filename string) (*Po, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
 	comment := false
 	s := new(scanner.Scanner)
-	var err error
-	s.Init(r)
+	s.Init(bytes.NewReader(data))
 	s.Filename = filename
 	s.Error = func(s *scanner.Scanner, msg string) {
 		if !comment {
@@ -67,14 +119,26 @@ func parsePo(r io.Reader, filename string) (*Po, error) {
 	tok := s.Scan()
 	po := &Po{Attrs: make(map[string]string)}
 	var trans *Translation
+	var comments []string
+	newTranslation := func() *Translation {
+		t := &Translation{Comments: comments}
+		comments = nil
+		return t
+	}
 	for tok != scanner.EOF && err == nil {
 		if tok == '#' {
-			// Skip until EOL
+			// Read until EOL
 			comment = true
+			start := s.Position.Offset
 			s.Whitespace = whitespace
 			for tok != '\n' && tok != scanner.EOF {
 				tok = s.Scan()
 			}
+			end := len(data)
+			if tok == '\n' {
+				end = s.Position.Offset
+			}
+			comments = append(comments, strings.TrimRight(string(data[start:end]), "\r"))
 			s.Whitespace = scanner.GoWhitespace
 			comment = false
 			tok = s.Scan()
@@ -94,7 +158,8 @@ func parsePo(r io.Reader, filename string) (*Po, error) {
 				}
 				po.addTranslation(trans)
 			}
-			trans = &Translation{Context: readString(s, &tok, &err)}
+			trans = newTranslation()
+			trans.Context = readString(s, &tok, &err)
 		case "msgid":
 			if trans != nil {
 				if len(trans.Translations) > 0 || trans.Singular != "" {
@@ -104,7 +169,8 @@ func parsePo(r io.Reader, filename string) (*Po, error) {
 					break
 				}
 			}
-			trans = &Translation{Singular: readString(s, &tok, &err)}
+			trans = newTranslation()
+			trans.Singular = readString(s, &tok, &err)
 		case "msgid_plural":
 			if trans == nil || trans.Plural != "" {
 				err = unexpected(s, tok)
@@ -138,6 +204,7 @@ func parsePo(r io.Reader, filename string) (*Po, error) {
 	if trans != nil {
 		po.addTranslation(trans)
 	}
+	po.TrailingComments = comments
 	if err != nil {
 		return nil, err
 	}
@@ -176,3 +243,81 @@ func ParseFile(filename string) (*Po, error) {
 	defer f.Close()
 	return parsePo(f, filename)
 }
+
+// Write writes the Po in the .po format to the given io.Writer.
+func (p *Po) Write(w io.Writer) error {
+	var buf bytes.Buffer
+	for ii, v := range p.Messages {
+		if ii > 0 {
+			buf.WriteByte('\n')
+		}
+		for _, c := range v.Comments {
+			buf.WriteString(c)
+			buf.WriteByte('\n')
+		}
+		if v.Context != "" {
+			writeString(&buf, "msgctxt", v.Context)
+		}
+		writeString(&buf, "msgid", v.Singular)
+		if v.Plural != "" {
+			writeString(&buf, "msgid_plural", v.Plural)
+			translations := v.Translations
+			if len(translations) == 0 {
+				translations = []string{""}
+			}
+			for jj, t := range translations {
+				writeString(&buf, fmt.Sprintf("msgstr[%d]", jj), t)
+			}
+		} else {
+			var t string
+			if len(v.Translations) > 0 {
+				t = v.Translations[0]
+			}
+			writeString(&buf, "msgstr", t)
+		}
+	}
+	if len(p.TrailingComments) > 0 {
+		buf.WriteByte('\n')
+		for _, c := range p.TrailingComments {
+			buf.WriteString(c)
+			buf.WriteByte('\n')
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteFile writes the Po to the given file, creating it if
+// it doesn't exist or truncating it if it does.
+func (p *Po) WriteFile(filename string) error {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := p.Write(f); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// writeString writes a keyword followed by its quoted value, splitting
+// values with multiple lines into one quoted string per line.
+func writeString(buf *bytes.Buffer, keyword string, value string) {
+	buf.WriteString(keyword)
+	lines := strings.SplitAfter(value, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > 1 {
+		buf.WriteString(" \"\"\n")
+		for _, v := range lines {
+			buf.WriteString(strconv.Quote(v))
+			buf.WriteByte('\n')
+		}
+		return
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(strconv.Quote(value))
+	buf.WriteByte('\n')
+}
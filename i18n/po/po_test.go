@@ -1,7 +1,9 @@
 package po
 
 import (
+	"bytes"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -34,3 +36,34 @@ func TestParsePo(t *testing.T) {
 		}
 	}
 }
+
+func TestWritePo(t *testing.T) {
+	matches, err := filepath.Glob("_test_data/*.po*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range matches {
+		po, err := ParseFile(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		po.Messages[1].SetFuzzy()
+		var buf bytes.Buffer
+		if err := po.Write(&buf); err != nil {
+			t.Fatal(err)
+		}
+		po2, err := Parse(&buf)
+		if err != nil {
+			t.Fatalf("error parsing written %s: %s", v, err)
+		}
+		if !reflect.DeepEqual(po, po2) {
+			t.Errorf("written %s does not match the original", v)
+		}
+		if !po2.Messages[1].IsFuzzy() {
+			t.Errorf("message %q in %s should be fuzzy", po2.Messages[1].Singular, v)
+		}
+		if po2.Messages[2].IsFuzzy() {
+			t.Errorf("message %q in %s should not be fuzzy", po2.Messages[2].Singular, v)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Package csrf implements the CSRF tokens shared by the apps
+// in gnd.la/apps.
+//
+// Tokens are stored in a cookie signed with the gnd.la/app.App
+// secret, so each app can use its own cookie name.
+package csrf
+
+import (
+	"crypto/subtle"
+
+	"gnd.la/app"
+	"gnd.la/util/stringutil"
+)
+
+const tokenLength = 32
+
+// Token returns the CSRF token stored in the cookie with the given
+// name, generating and setting a new one if there's none yet.
+func Token(ctx *app.Context, cookieName string) string {
+	var token string
+	if err := ctx.Cookies().GetSecure(cookieName, &token); err == nil && token != "" {
+		return token
+	}
+	token = stringutil.Random(tokenLength)
+	if err := ctx.Cookies().SetSecure(cookieName, token); err != nil {
+		panic(err)
+	}
+	return token
+}
+
+// Check returns true iff the submitted token matches the one
+// stored in the cookie with the given name.
+func Check(ctx *app.Context, cookieName string, submitted string) bool {
+	var token string
+	if err := ctx.Cookies().GetSecure(cookieName, &token); err != nil || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(submitted)) == 1
+}
@@ -37,6 +37,7 @@ type App struct {
 	Templates    *Templates             `yaml:"templates"`
 	Translations *Translations          `yaml:"translations"`
 	Assets       string                 `yaml:"assets"`
+	URLs         string                 `yaml:"urls"`
 }
 
 func (app *App) writeFS(buf *bytes.Buffer, dir string, release bool) error {
@@ -185,7 +186,10 @@ func (app *App) Gen(release bool) error {
 		}
 	}
 	buf.WriteString("}\n")
-	out := filepath.Join(pkg.Dir(), "gondola_app.go")
+	if err := app.writeURLs(&buf, pkg, handlerNames); err != nil {
+		return err
+	}
+	out := filepath.Join(pkg.Dir(), generatedFilename)
 	log.Debugf("Writing Gondola app to %s", out)
 	return genutil.WriteAutogen(out, buf.Bytes())
 }
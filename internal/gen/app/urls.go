@@ -0,0 +1,264 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gnd.la/internal/gen/genutil"
+	"gnd.la/log"
+
+	"code.google.com/p/go.tools/go/exact"
+	"code.google.com/p/go.tools/go/types"
+)
+
+const (
+	// defaultURLsName is the default name for the generated
+	// variable with the reverse functions.
+	defaultURLsName = "urls"
+	// generatedFilename is the file written by Gen.
+	generatedFilename = "gondola_app.go"
+)
+
+// urlParam is a parameter of a generated reverse function.
+type urlParam struct {
+	Name string
+	Type string
+}
+
+// reverseFunc is a generated reverse function.
+type reverseFunc struct {
+	Method  string
+	Name    string
+	Pattern string
+	Params  []*urlParam
+}
+
+// urlsNames returns the names of the generated variable with the reverse
+// functions and its type. The variable name can be set with the urls
+// key in the appfile, while the type name is always derived from it.
+func (app *App) urlsNames() (string, string) {
+	name := app.URLs
+	if name == "" {
+		name = defaultURLsName
+	}
+	return name, "gondola" + exportedIdent(name)
+}
+
+// writeURLs writes the urls variable, which contains a typed method
+// for reversing each named handler. Handlers are considered named
+// when their name can be determined at generation time, which
+// happens when they're declared using app.NamedHandler or a
+// *app.HandlerInfo literal with a constant name. Calling the
+// generated methods with the wrong number or type of arguments
+// causes a compilation error, rather than a panic at runtime.
+func (app *App) writeURLs(buf *bytes.Buffer, pkg *genutil.Package, handlerNames []string) error {
+	var funcs []*reverseFunc
+	defined := make(map[string]string)
+	for _, k := range handlerNames {
+		obj := pkg.Scope().Lookup(k)
+		name := handlerName(pkg, obj)
+		if name == "" {
+			continue
+		}
+		pattern := app.Handlers[k]
+		params, err := patternParams(pattern)
+		if err != nil {
+			log.Debugf("not generating reverse function for handler %s: %s", k, err)
+			continue
+		}
+		method := exportedIdent(name)
+		if method == "" {
+			continue
+		}
+		if prev := defined[method]; prev != "" {
+			return fmt.Errorf("handlers %s and %s both generate the reverse function %s", prev, k, method)
+		}
+		defined[method] = k
+		funcs = append(funcs, &reverseFunc{Method: method, Name: name, Pattern: pattern, Params: params})
+	}
+	if len(funcs) == 0 {
+		return nil
+	}
+	varName, typeName := app.urlsNames()
+	for _, v := range []string{varName, typeName} {
+		// Identifiers from a previous run are fine, since
+		// the generated file will be overwritten.
+		if obj := pkg.Scope().Lookup(v); obj != nil && filepath.Base(pkg.FileSet().Position(obj.Pos()).Filename) != generatedFilename {
+			return fmt.Errorf("generated identifier %s is already declared in package %s, set a different one using the urls key in %s", v, pkg.Name(), appFilename)
+		}
+	}
+	writeReverseFuncs(buf, varName, typeName, funcs)
+	return nil
+}
+
+// writeReverseFuncs writes the declarations for the variable
+// named varName, of type typeName, with the given functions
+// as its methods.
+func writeReverseFuncs(buf *bytes.Buffer, varName string, typeName string, funcs []*reverseFunc) {
+	fmt.Fprintf(buf, "// %s contains typed functions for reversing the named handlers.\n", varName)
+	fmt.Fprintf(buf, "var %s %s\n", varName, typeName)
+	fmt.Fprintf(buf, "type %s struct{}\n", typeName)
+	for _, f := range funcs {
+		var decl, args []string
+		for _, p := range f.Params {
+			decl = append(decl, p.Name+" "+p.Type)
+			args = append(args, p.Name)
+		}
+		fmt.Fprintf(buf, "// %s returns the URL for the handler %q (%s).\n", f.Method, f.Name, f.Pattern)
+		fmt.Fprintf(buf, "func (%s) %s(%s) string {\n", typeName, f.Method, strings.Join(decl, ", "))
+		if len(args) > 0 {
+			fmt.Fprintf(buf, "return App.MustReverse(%q, %s)\n}\n", f.Name, strings.Join(args, ", "))
+		} else {
+			fmt.Fprintf(buf, "return App.MustReverse(%q)\n}\n", f.Name)
+		}
+	}
+}
+
+// handlerName returns the handler name if it can be determined from
+// the initialization expression for obj, or an empty string otherwise.
+func handlerName(pkg *genutil.Package, obj types.Object) string {
+	info := pkg.Info()
+	for _, v := range info.InitOrder {
+		if len(v.Lhs) != 1 || v.Lhs[0] != obj {
+			continue
+		}
+		return handlerNameFromExpr(info, v.Rhs)
+	}
+	return ""
+}
+
+func handlerNameFromExpr(info *types.Info, expr ast.Expr) string {
+	switch x := expr.(type) {
+	case *ast.CallExpr:
+		// app.NamedHandler("name", ...)
+		if sel, ok := x.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "NamedHandler" && len(x.Args) > 0 {
+			if fn := info.Uses[sel.Sel]; fn != nil && fn.Pkg() != nil && fn.Pkg().Path() == "gnd.la/app" {
+				return constantString(info, x.Args[0])
+			}
+		}
+	case *ast.UnaryExpr:
+		if x.Op == token.AND {
+			return handlerNameFromExpr(info, x.X)
+		}
+	case *ast.CompositeLit:
+		// &app.HandlerInfo{Options: &app.HandlerOptions{Name: "name"}}
+		for _, elt := range x.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			key, ok := kv.Key.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			switch key.Name {
+			case "Options":
+				return handlerNameFromExpr(info, kv.Value)
+			case "Name":
+				return constantString(info, kv.Value)
+			}
+		}
+	}
+	return ""
+}
+
+func constantString(info *types.Info, expr ast.Expr) string {
+	if tv, ok := info.Types[expr]; ok && tv.Value != nil && tv.Value.Kind() == exact.String {
+		return exact.StringVal(tv.Value)
+	}
+	return ""
+}
+
+// patternParams returns the parameters required for reversing
+// the given pattern. Groups matching only digits are mapped to
+// int parameters, while the rest are mapped to strings. Patterns
+// with optional groups are not supported.
+func patternParams(pattern string) ([]*urlParam, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+	var params []*urlParam
+	used := make(map[string]bool)
+	var walk func(re *syntax.Regexp, optional bool) error
+	walk = func(re *syntax.Regexp, optional bool) error {
+		switch re.Op {
+		case syntax.OpCapture:
+			if optional {
+				return fmt.Errorf("optional group in pattern %q", pattern)
+			}
+			name := unexportedIdent(re.Name)
+			if name == "" || used[name] || token.Lookup(name).IsKeyword() {
+				name = fmt.Sprintf("arg%d", len(params)+1)
+			}
+			used[name] = true
+			typ := "string"
+			if isDigits(re.Sub[0]) {
+				typ = "int"
+			}
+			params = append(params, &urlParam{Name: name, Type: typ})
+			return nil
+		case syntax.OpQuest, syntax.OpStar, syntax.OpAlternate:
+			optional = true
+		case syntax.OpRepeat:
+			if re.Min == 0 {
+				optional = true
+			}
+		}
+		for _, sub := range re.Sub {
+			if err := walk(sub, optional); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(re, false); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// isDigits returns true iff re matches one or more digits
+// and nothing else e.g. \d+.
+func isDigits(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpPlus, syntax.OpRepeat:
+		return len(re.Sub) == 1 && isDigits(re.Sub[0])
+	case syntax.OpCharClass:
+		return len(re.Rune) == 2 && re.Rune[0] == '0' && re.Rune[1] == '9'
+	}
+	return false
+}
+
+// exportedIdent converts a handler name like "article-detail" into
+// an exported identifier like ArticleDetail.
+func exportedIdent(s string) string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var ident string
+	for _, f := range fields {
+		r, n := utf8.DecodeRuneInString(f)
+		ident += string(unicode.ToUpper(r)) + f[n:]
+	}
+	if r, _ := utf8.DecodeRuneInString(ident); !unicode.IsLetter(r) {
+		return ""
+	}
+	return ident
+}
+
+func unexportedIdent(s string) string {
+	ident := exportedIdent(s)
+	if ident == "" {
+		return ""
+	}
+	r, n := utf8.DecodeRuneInString(ident)
+	return string(unicode.ToLower(r)) + ident[n:]
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"bytes"
+	"go/format"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPatternParams(t *testing.T) {
+	cases := []struct {
+		pattern string
+		params  []*urlParam
+	}{
+		{`^/$`, nil},
+		{`^/article/(\d+)/$`, []*urlParam{{"arg1", "int"}}},
+		{`^/article/(?P<id>\d+)/(?P<slug>[\w\-]+)/$`, []*urlParam{{"id", "int"}, {"slug", "string"}}},
+		{`^/(?P<article_id>\d+)/(?P<type>\w+)/$`, []*urlParam{{"articleId", "int"}, {"arg2", "string"}}},
+		{`^/(?P<id>\d+)/(?P<id>\d+)/$`, []*urlParam{{"id", "int"}, {"arg2", "int"}}},
+		{`^/(?P<id>\d{2,4})/$`, []*urlParam{{"id", "int"}}},
+	}
+	for _, v := range cases {
+		params, err := patternParams(v.pattern)
+		if err != nil {
+			t.Errorf("error getting params for %q: %s", v.pattern, err)
+			continue
+		}
+		if !reflect.DeepEqual(params, v.params) {
+			t.Errorf("expecting params %v for %q, got %v", v.params, v.pattern, params)
+		}
+	}
+	for _, v := range []string{`^/(\d+)?$`, `^/(a)|(b)$`, `^/(?:(\d+)/)*$`, `^/(`} {
+		if _, err := patternParams(v); err == nil {
+			t.Errorf("expecting an error for pattern %q", v)
+		}
+	}
+}
+
+func TestIdents(t *testing.T) {
+	cases := []struct {
+		name       string
+		exported   string
+		unexported string
+	}{
+		{"article-detail", "ArticleDetail", "articleDetail"},
+		{"article_detail", "ArticleDetail", "articleDetail"},
+		{"Article.Detail2", "ArticleDetail2", "articleDetail2"},
+		{"éxito-ñu", "ÉxitoÑu", "éxitoÑu"},
+		{"2fa", "", ""},
+		{"--", "", ""},
+		{"", "", ""},
+	}
+	for _, v := range cases {
+		if id := exportedIdent(v.name); id != v.exported {
+			t.Errorf("expecting exported identifier %q for %q, got %q", v.exported, v.name, id)
+		}
+		if id := unexportedIdent(v.name); id != v.unexported {
+			t.Errorf("expecting unexported identifier %q for %q, got %q", v.unexported, v.name, id)
+		}
+	}
+}
+
+func TestURLsNames(t *testing.T) {
+	app := &App{}
+	if name, typ := app.urlsNames(); name != "urls" || typ != "gondolaUrls" {
+		t.Errorf("expecting default names urls and gondolaUrls, got %s and %s", name, typ)
+	}
+	app.URLs = "reverse"
+	if name, typ := app.urlsNames(); name != "reverse" || typ != "gondolaReverse" {
+		t.Errorf("expecting names reverse and gondolaReverse, got %s and %s", name, typ)
+	}
+}
+
+func TestWriteReverseFuncs(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("package test\n")
+	writeReverseFuncs(&buf, "urls", "gondolaUrls", []*reverseFunc{
+		{Method: "Index", Name: "index", Pattern: "^/$"},
+		{Method: "ArticleDetail", Name: "article-detail", Pattern: `^/article/(?P<id>\d+)/(?P<slug>\w+)/$`,
+			Params: []*urlParam{{"id", "int"}, {"slug", "string"}}},
+	})
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatalf("generated invalid code: %s\n%s", err, buf.String())
+	}
+	for _, v := range []string{
+		"var urls gondolaUrls\n",
+		"type gondolaUrls struct{}\n",
+		"func (gondolaUrls) Index() string {\n\treturn App.MustReverse(\"index\")\n}",
+		"func (gondolaUrls) ArticleDetail(id int, slug string) string {\n\treturn App.MustReverse(\"article-detail\", id, slug)\n}",
+	} {
+		if !strings.Contains(string(src), v) {
+			t.Errorf("expecting %q in generated code:\n%s", v, src)
+		}
+	}
+}
@@ -15,6 +15,7 @@ import (
 
 	"gnd.la/internal/gen/genutil"
 	"gnd.la/internal/gen/json"
+	"gnd.la/internal/gen/orm"
 	"gnd.la/internal/gen/strings"
 	"gnd.la/util/types"
 	"gnd.la/util/yaml"
@@ -59,6 +60,14 @@ func Gen(pkgName string, config string) error {
 			if err := strings.Gen(pkgName, opts); err != nil {
 				return err
 			}
+		case "orm":
+			opts, err := ormOptions(v)
+			if err != nil {
+				return err
+			}
+			if err := orm.Gen(pkgName, opts); err != nil {
+				return err
+			}
 		case "template":
 		}
 	}
@@ -206,6 +215,35 @@ func stringsOptions(val interface{}) (*strings.Options, error) {
 	return opts, nil
 }
 
+func ormOptions(val interface{}) (*orm.Options, error) {
+	m, ok := toMap(val)
+	if !ok {
+		return nil, fmt.Errorf("orm options must be a map, not %T", val)
+	}
+	opts := &orm.Options{}
+	for k, v := range m {
+		switch k {
+		case "include":
+			if val := types.ToString(v); val != "" {
+				include, err := regexp.Compile(val)
+				if err != nil {
+					return nil, err
+				}
+				opts.Include = include
+			}
+		case "exclude":
+			if val := types.ToString(v); val != "" {
+				exclude, err := regexp.Compile(val)
+				if err != nil {
+					return nil, err
+				}
+				opts.Exclude = exclude
+			}
+		}
+	}
+	return opts, nil
+}
+
 func toMap(val interface{}) (map[string]interface{}, bool) {
 	switch v := val.(type) {
 	case nil:
@@ -0,0 +1,148 @@
+// Package orm generates the table and field comments for ORM
+// models from the documentation of their types.
+package orm
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gnd.la/internal/gen/genutil"
+	"gnd.la/log"
+)
+
+type Options struct {
+	Include *regexp.Regexp
+	Exclude *regexp.Regexp
+}
+
+type typeDoc struct {
+	name   string
+	doc    string
+	fields map[string]string
+}
+
+// Gen generates a file which sets the comments for the exported
+// struct types in the given package using orm.SetComments. Type
+// comments are taken from the type documentation, while field
+// comments are taken from either the field documentation or the
+// comment at the end of the line declaring the field.
+func Gen(pkgName string, opts *Options) error {
+	pkg, err := genutil.NewPackage(pkgName)
+	if err != nil {
+		return err
+	}
+	docs := extractDocs(pkg.ASTFiles(), opts)
+	out := filepath.Join(pkg.Dir(), "gen_orm.go")
+	log.Debugf("Writing autogenerated ORM comments to %s", out)
+	return genutil.WriteAutogen(out, generate(pkg.Name(), docs))
+}
+
+// extractDocs returns the documentation for the exported struct
+// types declared in the given files, sorted by type name. Types
+// without any documentation are omitted.
+func extractDocs(files map[string]*ast.File, opts *Options) []*typeDoc {
+	var include *regexp.Regexp
+	var exclude *regexp.Regexp
+	if opts != nil {
+		include = opts.Include
+		exclude = opts.Exclude
+	}
+	var docs []*typeDoc
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok || !ts.Name.IsExported() {
+					continue
+				}
+				name := ts.Name.Name
+				if exclude != nil && exclude.MatchString(name) {
+					continue
+				}
+				if include != nil && !include.MatchString(name) {
+					continue
+				}
+				doc := ts.Doc
+				if doc == nil && len(gen.Specs) == 1 {
+					doc = gen.Doc
+				}
+				td := &typeDoc{name: name, doc: commentText(doc), fields: make(map[string]string)}
+				for _, field := range st.Fields.List {
+					text := commentText(field.Doc)
+					if text == "" {
+						text = commentText(field.Comment)
+					}
+					if text == "" {
+						continue
+					}
+					for _, n := range field.Names {
+						if n.IsExported() {
+							td.fields[n.Name] = text
+						}
+					}
+				}
+				if td.doc != "" || len(td.fields) > 0 {
+					docs = append(docs, td)
+				}
+			}
+		}
+	}
+	sort.Sort(typeDocs(docs))
+	return docs
+}
+
+// generate returns the source for a file in the given package
+// which sets the comments in docs.
+func generate(pkgName string, docs []*typeDoc) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("package %s\n\n", pkgName))
+	buf.WriteString(genutil.AutogenString())
+	buf.WriteString("import \"gnd.la/orm\"\n\n")
+	buf.WriteString("func init() {\n")
+	for _, v := range docs {
+		fmt.Fprintf(&buf, "orm.SetComments((*%s)(nil), %q, ", v.name, v.doc)
+		if len(v.fields) == 0 {
+			buf.WriteString("nil)\n")
+			continue
+		}
+		buf.WriteString("map[string]string{\n")
+		names := make([]string, 0, len(v.fields))
+		for k := range v.fields {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			fmt.Fprintf(&buf, "%q: %q,\n", n, v.fields[n])
+		}
+		buf.WriteString("})\n")
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// commentText returns the text in the comment group as
+// a single line, since most databases don't handle
+// multiline comments nicely.
+func commentText(c *ast.CommentGroup) string {
+	if c == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(c.Text()), " ")
+}
+
+type typeDocs []*typeDoc
+
+func (t typeDocs) Len() int           { return len(t) }
+func (t typeDocs) Less(i, j int) bool { return t[i].name < t[j].name }
+func (t typeDocs) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
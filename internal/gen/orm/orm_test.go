@@ -0,0 +1,62 @@
+package orm
+
+import (
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func parseFixture(t *testing.T) map[string]*ast.File {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), "testdata/models", nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkgs["models"].Files
+}
+
+func TestExtractDocs(t *testing.T) {
+	docs := extractDocs(parseFixture(t), &Options{Exclude: regexp.MustCompile("^Draft$")})
+	expect := []*typeDoc{
+		{"Article", "Article is a blog article.", map[string]string{"Title": "Title is shown in the listing.", "Body": "Article text"}},
+		{"Author", "Author is documented in the declaration.", map[string]string{}},
+		{"Tag", "Tag is documented in the spec.", map[string]string{}},
+	}
+	if !reflect.DeepEqual(docs, expect) {
+		for _, v := range docs {
+			t.Logf("%+v", v)
+		}
+		t.Fatalf("unexpected docs")
+	}
+	docs = extractDocs(parseFixture(t), &Options{Include: regexp.MustCompile("^A")})
+	if len(docs) != 2 || docs[0].name != "Article" || docs[1].name != "Author" {
+		t.Errorf("expecting only Article and Author, got %d types", len(docs))
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	docs := extractDocs(parseFixture(t), nil)
+	src, err := format.Source(generate("models", docs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := string(src)
+	for _, v := range []string{
+		`orm.SetComments((*Article)(nil), "Article is a blog article.", map[string]string{`,
+		`"Body":  "Article text",`,
+		`"Title": "Title is shown in the listing.",`,
+		`orm.SetComments((*Author)(nil), "Author is documented in the declaration.", nil)`,
+		`orm.SetComments((*Draft)(nil), "Draft is excluded by the options.", nil)`,
+	} {
+		if !strings.Contains(code, v) {
+			t.Errorf("expecting generated code to contain %q, got\n%s", v, code)
+		}
+	}
+	if strings.Contains(code, "Undocumented") || strings.Contains(code, "notExported") {
+		t.Errorf("expecting undocumented and unexported types to be omitted, got\n%s", code)
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+// Article is a blog article.
+type Article struct {
+	Id int64
+	// Title is shown
+	// in the listing.
+	Title string
+	Body  string // Article text
+	// Internal fields, not documented in the database
+	internal string
+}
+
+// Author is documented in the declaration.
+type Author struct {
+	Id   int64
+	Name string
+}
+
+type (
+	// Tag is documented in the spec.
+	Tag struct {
+		Name string
+	}
+	Undocumented struct {
+		Name string
+	}
+	// Draft is excluded by the options.
+	Draft struct {
+		Title string
+	}
+)
+
+// notExported is ignored.
+type notExported struct {
+	Name string // Name
+}
+
+// Count is not a struct.
+type Count int
@@ -0,0 +1,154 @@
+// Package pool implements a pool of byte buffers grouped
+// by size classes.
+//
+// Buffers are taken from the smallest class which can hold
+// the requested size and returned to the largest class their
+// capacity can satisfy, so buffers which grew while being used
+// are reused for bigger requests. Buffers larger than the
+// largest class are never retained.
+package pool
+
+import (
+	"bytes"
+	"runtime"
+	"sort"
+	"sync/atomic"
+)
+
+var (
+	// DefaultSizes are the size classes used by the
+	// default pool.
+	DefaultSizes = []int{256, 1024, 4096, 16384, 65536, 262144}
+
+	defaultPool = New(DefaultSizes, 4*runtime.GOMAXPROCS(0))
+)
+
+type class struct {
+	size    int
+	buffers chan *bytes.Buffer
+}
+
+// Stats contains the counters for a Pool. See Pool.Stats.
+type Stats struct {
+	// Gets is the number of buffers requested.
+	Gets uint64
+	// Reused is the number of buffers requested which
+	// were served from the pool.
+	Reused uint64
+	// Puts is the number of buffers returned to the pool.
+	Puts uint64
+	// Dropped is the number of buffers returned to the pool
+	// which were discarded, either because they were too
+	// big or because their class was full.
+	Dropped uint64
+}
+
+// ReuseRatio returns the fraction of the requested buffers
+// which were served from the pool, in the [0, 1] interval.
+func (s *Stats) ReuseRatio() float64 {
+	if s.Gets == 0 {
+		return 0
+	}
+	return float64(s.Reused) / float64(s.Gets)
+}
+
+// Pool is a pool of *bytes.Buffer grouped by size classes.
+// It's safe to use it from multiple goroutines.
+type Pool struct {
+	// stats must be the first field, so the
+	// counters are 64 bit aligned.
+	stats   Stats
+	classes []*class
+}
+
+// New returns a new Pool with the given size classes,
+// retaining up to maxRetained buffers for each class.
+func New(sizes []int, maxRetained int) *Pool {
+	sorted := make([]int, len(sizes))
+	copy(sorted, sizes)
+	sort.Ints(sorted)
+	p := &Pool{}
+	for _, v := range sorted {
+		p.classes = append(p.classes, &class{
+			size:    v,
+			buffers: make(chan *bytes.Buffer, maxRetained),
+		})
+	}
+	return p
+}
+
+// Get returns an empty buffer which can hold at least
+// size bytes without growing.
+func (p *Pool) Get(size int) *bytes.Buffer {
+	atomic.AddUint64(&p.stats.Gets, 1)
+	for _, c := range p.classes {
+		if c.size < size {
+			continue
+		}
+		select {
+		case buf := <-c.buffers:
+			atomic.AddUint64(&p.stats.Reused, 1)
+			buf.Reset()
+			return buf
+		default:
+		}
+		buf := new(bytes.Buffer)
+		buf.Grow(c.size)
+		return buf
+	}
+	// Bigger than the largest class
+	buf := new(bytes.Buffer)
+	buf.Grow(size)
+	return buf
+}
+
+// Put returns the given buffer to the pool. The buffer
+// must not be used after calling Put.
+func (p *Pool) Put(buf *bytes.Buffer) {
+	atomic.AddUint64(&p.stats.Puts, 1)
+	capacity := buf.Cap()
+	for ii := len(p.classes) - 1; ii >= 0; ii-- {
+		c := p.classes[ii]
+		if c.size > capacity {
+			continue
+		}
+		if ii == len(p.classes)-1 && capacity > 2*c.size {
+			// Too big, let the GC reclaim it
+			break
+		}
+		select {
+		case c.buffers <- buf:
+			return
+		default:
+		}
+		break
+	}
+	atomic.AddUint64(&p.stats.Dropped, 1)
+}
+
+// Stats returns a snapshot of the pool counters.
+func (p *Pool) Stats() Stats {
+	return Stats{
+		Gets:    atomic.LoadUint64(&p.stats.Gets),
+		Reused:  atomic.LoadUint64(&p.stats.Reused),
+		Puts:    atomic.LoadUint64(&p.stats.Puts),
+		Dropped: atomic.LoadUint64(&p.stats.Dropped),
+	}
+}
+
+// Get returns a buffer from the default pool.
+// See Pool.Get for details.
+func Get(size int) *bytes.Buffer {
+	return defaultPool.Get(size)
+}
+
+// Put returns a buffer to the default pool.
+// See Pool.Put for details.
+func Put(buf *bytes.Buffer) {
+	defaultPool.Put(buf)
+}
+
+// DefaultStats returns the counters for the default pool.
+func DefaultStats() Stats {
+	return defaultPool.Stats()
+}
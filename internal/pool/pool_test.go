@@ -0,0 +1,52 @@
+package pool
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	p := New([]int{1024, 64}, 2)
+	buf := p.Get(10)
+	if buf.Cap() < 64 {
+		t.Errorf("expecting capacity >= 64, got %d", buf.Cap())
+	}
+	buf.WriteString("hello")
+	p.Put(buf)
+	if b := p.Get(32); b != buf {
+		t.Error("expecting buffer to be reused")
+	} else if b.Len() != 0 {
+		t.Errorf("expecting empty buffer, got %d bytes", b.Len())
+	}
+	p.Put(buf)
+	// The buffer in the smallest class can't hold 512 bytes
+	if b := p.Get(512); b == buf || b.Cap() < 512 {
+		t.Errorf("expecting a new buffer with capacity >= 512, got %d", b.Cap())
+	}
+	// Buffers which grew go to the bigger class
+	big := p.Get(10)
+	big.Grow(1500)
+	p.Put(big)
+	if b := p.Get(1000); b != big {
+		t.Error("expecting grown buffer to be reused for a bigger size")
+	}
+	// Too big to be retained
+	huge := p.Get(10000)
+	p.Put(huge)
+	if b := p.Get(1000); b == huge {
+		t.Error("expecting huge buffer to be dropped")
+	}
+	// Only 2 buffers are retained per class
+	for ii := 0; ii < 3; ii++ {
+		b := new(bytes.Buffer)
+		b.Grow(64)
+		p.Put(b)
+	}
+	stats := p.Stats()
+	if stats.Gets != 7 || stats.Reused != 3 || stats.Puts != 7 || stats.Dropped != 2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if r := stats.ReuseRatio(); r != 3.0/7.0 {
+		t.Errorf("unexpected reuse ratio %v", r)
+	}
+}
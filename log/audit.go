@@ -0,0 +1,269 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	auditRecord = 'R'
+	auditAnchor = 'A'
+	// DefaultAuditAnchorInterval is the number of records between
+	// anchors when AuditWriter.AnchorInterval is zero.
+	DefaultAuditAnchorInterval = 100
+)
+
+var (
+	// ErrAuditTampered is returned by VerifyAudit when a line has
+	// been modified, inserted or removed.
+	ErrAuditTampered = errors.New("audit log has been tampered with")
+	// ErrAuditTruncated is returned by VerifyAudit when the log does
+	// not end with an anchor, which usually means that records were
+	// removed from its end.
+	ErrAuditTruncated = errors.New("audit log has been truncated")
+)
+
+// AuditWriter is a Writer for security sensitive logs, which allows
+// detecting tampering. Each record is written in a line together with
+// its sequence number and an HMAC-SHA256 digest, which covers the record
+// and the digest of the previous line, chaining all of them. Modifying,
+// inserting or removing any line breaks the chain, which is detected by
+// VerifyAudit.
+//
+// Every AnchorInterval records, an anchor line with the current time is
+// written. Since records removed from the end of the log can't be detected
+// by the chain itself, VerifyAudit requires the log to end with an anchor
+// (Close writes a final one). For stronger guarantees, use OnAnchor to
+// store the anchor digests outside of the log.
+//
+// Lines have the following format, with newlines, carriage returns and
+// backslashes in the records escaped as \n, \r and \\, respectively:
+//
+//	<sequence> <R|A> <hex digest> <record or anchor time>
+type AuditWriter struct {
+	// AnchorInterval is the number of records written between
+	// anchors. If zero, DefaultAuditAnchorInterval is used.
+	AnchorInterval int
+	// OnAnchor is called, if non-nil, after each anchor is
+	// written, with its sequence number and digest.
+	OnAnchor func(seq uint64, digest []byte)
+	mutex    sync.Mutex
+	out      io.Writer
+	level    LLevel
+	mac      hash.Hash
+	prev     []byte
+	seq      uint64
+	pending  int
+}
+
+// NewAuditWriter returns a new AuditWriter which writes the records
+// with at least the given level to out, using the given key for
+// computing the digests. The same key must be used for verifying
+// the log with VerifyAudit.
+func NewAuditWriter(out io.Writer, level LLevel, key []byte) *AuditWriter {
+	return &AuditWriter{out: out, level: level, mac: hmac.New(sha256.New, key)}
+}
+
+// ResumeAuditWriter works like NewAuditWriter, but continues the chain
+// of an existing log (e.g. after restarting the process), which must be
+// reopened for appending as out. The last argument must contain the last
+// line of the existing log, with or without the trailing newline. If it's
+// empty, a new log is started. Note that the rest of the existing log is
+// not verified, use VerifyAudit for that. If the last line can't be parsed,
+// an error wrapping ErrAuditTampered is returned.
+func ResumeAuditWriter(out io.Writer, level LLevel, key []byte, last []byte) (*AuditWriter, error) {
+	w := NewAuditWriter(out, level, key)
+	last = bytes.TrimRight(last, "\r\n")
+	if len(last) == 0 {
+		return w, nil
+	}
+	fields := bytes.SplitN(last, []byte{' '}, 4)
+	if len(fields) != 4 || len(fields[1]) != 1 {
+		return nil, fmt.Errorf("last line: %w", ErrAuditTampered)
+	}
+	seq, err := strconv.ParseUint(string(fields[0]), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("last line: %w", ErrAuditTampered)
+	}
+	digest, err := hex.DecodeString(string(fields[2]))
+	if err != nil || len(digest) != sha256.Size {
+		return nil, fmt.Errorf("last line: %w", ErrAuditTampered)
+	}
+	w.prev = digest
+	w.seq = seq + 1
+	return w, nil
+}
+
+func (w *AuditWriter) Write(level LLevel, flags int, b []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if err := w.writeLine(auditRecord, bytes.TrimRight(b, "\n")); err != nil {
+		return 0, err
+	}
+	w.pending++
+	interval := w.AnchorInterval
+	if interval <= 0 {
+		interval = DefaultAuditAnchorInterval
+	}
+	if w.pending >= interval {
+		if err := w.anchor(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (w *AuditWriter) Level() LLevel {
+	return w.level
+}
+
+// Anchor writes an anchor line.
+func (w *AuditWriter) Anchor() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.anchor()
+}
+
+// Close writes a final anchor. Note that it doesn't close
+// the underlying io.Writer.
+func (w *AuditWriter) Close() error {
+	return w.Anchor()
+}
+
+func (w *AuditWriter) anchor() error {
+	now := []byte(time.Now().UTC().Format(time.RFC3339Nano))
+	if err := w.writeLine(auditAnchor, now); err != nil {
+		return err
+	}
+	w.pending = 0
+	if w.OnAnchor != nil {
+		w.OnAnchor(w.seq-1, append([]byte(nil), w.prev...))
+	}
+	return nil
+}
+
+func (w *AuditWriter) writeLine(kind byte, payload []byte) error {
+	digest := auditDigest(w.mac, w.prev, w.seq, kind, payload)
+	var buf bytes.Buffer
+	buf.WriteString(strconv.FormatUint(w.seq, 10))
+	buf.WriteByte(' ')
+	buf.WriteByte(kind)
+	buf.WriteByte(' ')
+	buf.WriteString(hex.EncodeToString(digest))
+	buf.WriteByte(' ')
+	auditEscape(&buf, payload)
+	buf.WriteByte('\n')
+	if _, err := w.out.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	w.prev = digest
+	w.seq++
+	return nil
+}
+
+// VerifyAudit reads a log written by an AuditWriter from r and checks
+// its integrity using the given key, returning ErrAuditTampered or
+// ErrAuditTruncated (wrapped with the line number) when the checks
+// fail. Other errors are returned as is.
+func VerifyAudit(r io.Reader, key []byte) error {
+	mac := hmac.New(sha256.New, key)
+	var prev []byte
+	var seq uint64
+	anchored := true
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<24)
+	for sc.Scan() {
+		fields := bytes.SplitN(sc.Bytes(), []byte{' '}, 4)
+		if len(fields) != 4 || len(fields[1]) != 1 {
+			return fmt.Errorf("line %d: %w", seq+1, ErrAuditTampered)
+		}
+		n, err := strconv.ParseUint(string(fields[0]), 10, 64)
+		if err != nil || n != seq {
+			return fmt.Errorf("line %d: %w", seq+1, ErrAuditTampered)
+		}
+		kind := fields[1][0]
+		digest, err := hex.DecodeString(string(fields[2]))
+		if err != nil {
+			return fmt.Errorf("line %d: %w", seq+1, ErrAuditTampered)
+		}
+		payload, err := auditUnescape(fields[3])
+		if err != nil {
+			return fmt.Errorf("line %d: %w", seq+1, ErrAuditTampered)
+		}
+		if !hmac.Equal(digest, auditDigest(mac, prev, seq, kind, payload)) {
+			return fmt.Errorf("line %d: %w", seq+1, ErrAuditTampered)
+		}
+		anchored = kind == auditAnchor
+		prev = digest
+		seq++
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if !anchored {
+		return fmt.Errorf("line %d: %w", seq, ErrAuditTruncated)
+	}
+	return nil
+}
+
+func auditDigest(mac hash.Hash, prev []byte, seq uint64, kind byte, payload []byte) []byte {
+	var b [9]byte
+	binary.BigEndian.PutUint64(b[:8], seq)
+	b[8] = kind
+	mac.Reset()
+	mac.Write(prev)
+	mac.Write(b[:])
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func auditEscape(buf *bytes.Buffer, b []byte) {
+	for _, c := range b {
+		switch c {
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+}
+
+func auditUnescape(b []byte) ([]byte, error) {
+	out := make([]byte, 0, len(b))
+	for ii := 0; ii < len(b); ii++ {
+		if b[ii] != '\\' {
+			out = append(out, b[ii])
+			continue
+		}
+		ii++
+		if ii == len(b) {
+			return nil, errors.New("unterminated escape")
+		}
+		switch b[ii] {
+		case '\\':
+			out = append(out, '\\')
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		default:
+			return nil, fmt.Errorf("invalid escape \\%c", b[ii])
+		}
+	}
+	return out, nil
+}
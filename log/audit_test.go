@@ -0,0 +1,82 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestAuditWriter(t *testing.T) {
+	key := []byte("secret")
+	var buf bytes.Buffer
+	w := NewAuditWriter(&buf, LInfo, key)
+	w.AnchorInterval = 2
+	var anchors int
+	w.OnAnchor = func(seq uint64, digest []byte) {
+		anchors++
+	}
+	for _, v := range []string{"first", "second\nwith two lines", "third \\ with a backslash\r\nand CRLF"} {
+		if _, err := w.Write(LInfo, 0, []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := VerifyAudit(bytes.NewReader(buf.Bytes()), key); err == nil || !strings.Contains(err.Error(), ErrAuditTruncated.Error()) {
+		t.Errorf("expecting truncated log error, got %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if anchors != 2 {
+		t.Errorf("expecting 2 anchors, got %d", anchors)
+	}
+	for _, v := range strings.SplitAfter(buf.String(), "\n") {
+		if strings.Contains(strings.TrimSuffix(v, "\n"), "\r") {
+			t.Errorf("unescaped carriage return in line %q", v)
+		}
+	}
+	data := buf.Bytes()
+	if err := VerifyAudit(bytes.NewReader(data), key); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAudit(bytes.NewReader(data), []byte("other")); err == nil {
+		t.Error("expecting an error with a different key")
+	}
+	tampered := bytes.Replace(data, []byte("second"), []byte("sec0nd"), 1)
+	if err := VerifyAudit(bytes.NewReader(tampered), key); err == nil || !strings.Contains(err.Error(), ErrAuditTampered.Error()) {
+		t.Errorf("expecting tampered log error, got %v", err)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	removed := strings.Join(append(lines[:1:1], lines[2:]...), "")
+	if err := VerifyAudit(strings.NewReader(removed), key); err == nil {
+		t.Error("expecting an error with a removed line")
+	}
+}
+
+func TestResumeAuditWriter(t *testing.T) {
+	key := []byte("secret")
+	var buf bytes.Buffer
+	w := NewAuditWriter(&buf, LInfo, key)
+	if _, err := w.Write(LInfo, 0, []byte("before restart")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	w, err := ResumeAuditWriter(&buf, LInfo, key, []byte(lines[len(lines)-1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(LInfo, 0, []byte("after restart")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAudit(bytes.NewReader(buf.Bytes()), key); err != nil {
+		t.Error(err)
+	}
+	if _, err := ResumeAuditWriter(&buf, LInfo, key, []byte("garbage")); err == nil || !strings.Contains(err.Error(), ErrAuditTampered.Error()) {
+		t.Errorf("expecting tampered log error, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package log
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// FieldModule is the field which contains the module (e.g.
+	// the app) which logged the record.
+	FieldModule = "module"
+	// FieldRequestId is the field which contains the id of the
+	// request which generated the record.
+	FieldRequestId = "request_id"
+)
+
+type fieldsKey struct{}
+
+// Fields are structured values associated with the records logged
+// using a given context.Context. See WithFields.
+type Fields map[string]string
+
+// WithFields returns a new context.Context with the given fields
+// added to the ones already in ctx. ContextWriters (e.g. a
+// CaptureWriter) can retrieve them with FieldsFromContext.
+func WithFields(ctx context.Context, fields Fields) context.Context {
+	prev := FieldsFromContext(ctx)
+	merged := make(Fields, len(prev)+len(fields))
+	for k, v := range prev {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FieldsFromContext returns the fields associated with ctx, or
+// nil if there are none.
+func FieldsFromContext(ctx context.Context) Fields {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).(Fields)
+	return fields
+}
+
+// Record is a message captured by a CaptureWriter.
+type Record struct {
+	Time    time.Time
+	Level   LLevel
+	Message string
+	// Fields are the fields of the context.Context the message
+	// was logged with, if any.
+	Fields Fields
+}
+
+// Module returns the value of the FieldModule field.
+func (r *Record) Module() string {
+	return r.Fields[FieldModule]
+}
+
+// RequestId returns the value of the FieldRequestId field.
+func (r *Record) RequestId() string {
+	return r.Fields[FieldRequestId]
+}
+
+// RecordFilter is used to select the records returned by
+// CaptureWriter.Records. Empty fields match any record.
+type RecordFilter struct {
+	// Level is the minimum level of the records.
+	Level     LLevel
+	Module    string
+	RequestId string
+	// Limit is the maximum number of records returned.
+	Limit int
+}
+
+func (f *RecordFilter) match(r *Record) bool {
+	return r.Level >= f.Level &&
+		(f.Module == "" || f.Module == r.Module()) &&
+		(f.RequestId == "" || f.RequestId == r.RequestId())
+}
+
+// CaptureWriter is a ContextWriter which keeps the most recent
+// records in memory, using a ring buffer of fixed size, so they
+// can be inspected at runtime (e.g. gnd.la/apps/admin uses it to
+// display the recent errors).
+type CaptureWriter struct {
+	mu      sync.Mutex
+	level   LLevel
+	records []*Record
+	next    int
+	full    bool
+}
+
+// NewCaptureWriter returns a new CaptureWriter which keeps the last
+// size records with at least the given level.
+func NewCaptureWriter(level LLevel, size int) *CaptureWriter {
+	if size <= 0 {
+		size = 1
+	}
+	return &CaptureWriter{level: level, records: make([]*Record, size)}
+}
+
+func (w *CaptureWriter) Level() LLevel {
+	return w.level
+}
+
+func (w *CaptureWriter) Write(level LLevel, flags int, b []byte) (int, error) {
+	return w.WriteContext(nil, level, flags, b)
+}
+
+// WriteContext implements the ContextWriter interface. The fields
+// in ctx, if any, are stored with the record.
+func (w *CaptureWriter) WriteContext(ctx context.Context, level LLevel, flags int, b []byte) (int, error) {
+	r := &Record{
+		Time:    time.Now(),
+		Level:   level,
+		Message: strings.TrimSpace(string(b)),
+		Fields:  FieldsFromContext(ctx),
+	}
+	w.mu.Lock()
+	w.records[w.next] = r
+	w.next++
+	if w.next == len(w.records) {
+		w.next = 0
+		w.full = true
+	}
+	w.mu.Unlock()
+	return len(b), nil
+}
+
+// Records returns the captured records matching the given
+// filter, most recent first. If f is nil, all the records
+// are returned.
+func (w *CaptureWriter) Records(f *RecordFilter) []*Record {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	count := w.next
+	if w.full {
+		count = len(w.records)
+	}
+	var records []*Record
+	for ii := 0; ii < count; ii++ {
+		pos := w.next - ii - 1
+		if pos < 0 {
+			pos += len(w.records)
+		}
+		r := w.records[pos]
+		if f != nil {
+			if !f.match(r) {
+				continue
+			}
+			if f.Limit > 0 && len(records) == f.Limit {
+				break
+			}
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+// Reset removes all the captured records.
+func (w *CaptureWriter) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for ii := range w.records {
+		w.records[ii] = nil
+	}
+	w.next = 0
+	w.full = false
+}
@@ -0,0 +1,62 @@
+package log
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+)
+
+func TestCaptureWriter(t *testing.T) {
+	w := NewCaptureWriter(LWarning, 3)
+	logger := New(w, 0, LDebug)
+	ctx := WithFields(context.Background(), Fields{FieldModule: "admin"})
+	logger.Info("not captured")
+	logger.Warning("first")
+	logger.WithContext(WithFields(ctx, Fields{FieldRequestId: "abc"})).Error("second")
+	logger.WithContext(ctx).Warning("third")
+	logger.Error("fourth")
+	records := w.Records(nil)
+	if len(records) != 3 {
+		t.Fatalf("expecting 3 records, got %d", len(records))
+	}
+	for ii, v := range []string{"fourth", "third", "second"} {
+		if records[ii].Message != v {
+			t.Errorf("expecting record %d to be %q, got %q", ii, v, records[ii].Message)
+		}
+	}
+	if r := w.Records(&RecordFilter{RequestId: "abc"}); len(r) != 1 || r[0].Module() != "admin" || r[0].Level != LError {
+		t.Errorf("unexpected records with request id filter %v", r)
+	}
+	if r := w.Records(&RecordFilter{Module: "admin"}); len(r) != 2 {
+		t.Errorf("expecting 2 records with module filter, got %d", len(r))
+	}
+	if r := w.Records(&RecordFilter{Level: LError, Limit: 1}); len(r) != 1 || r[0].Message != "fourth" {
+		t.Errorf("unexpected records with level filter %v", r)
+	}
+	w.Reset()
+	if r := w.Records(nil); len(r) != 0 {
+		t.Errorf("expecting no records after Reset, got %d", len(r))
+	}
+}
+
+func TestHasContextWriters(t *testing.T) {
+	logger := New(NewIOWriter(ioutil.Discard, LDebug), 0, LDebug)
+	if logger.HasContextWriters() {
+		t.Error("IOWriter should not be a ContextWriter")
+	}
+	logger.AddWriter(NewCaptureWriter(LWarning, 1))
+	if !logger.HasContextWriters() {
+		t.Error("expecting a ContextWriter after adding a CaptureWriter")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, v := range []string{"error", "E", "Error"} {
+		if l, err := ParseLevel(v); err != nil || l != LError {
+			t.Errorf("expecting LError parsing %q, got %v (%v)", v, l, err)
+		}
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Error("expecting an error parsing an invalid level")
+	}
+}
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"context"
+	"fmt"
+)
+
+// ContextWriter is implemented by the Writers which use the
+// context.Context of the operation being logged, if any (e.g.
+// for correlating the records with the trace of the request
+// which generated them). See Logger.WithContext.
+type ContextWriter interface {
+	Writer
+	WriteContext(ctx context.Context, level LLevel, flags int, b []byte) (int, error)
+}
+
+// WithContext returns an Interface which logs using l, passing
+// ctx to the writers which implement ContextWriter. Other
+// writers receive the messages as usual.
+func (l *Logger) WithContext(ctx context.Context) Interface {
+	return &contextLogger{l: l, ctx: ctx}
+}
+
+// HasContextWriters returns true iff any of the writers
+// in l implements ContextWriter.
+func (l *Logger) HasContextWriters() bool {
+	for _, w := range l.writers {
+		if _, ok := w.(ContextWriter); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type contextLogger struct {
+	l   *Logger
+	ctx context.Context
+}
+
+func (c *contextLogger) log(level LLevel, v ...interface{}) {
+	c.l.writeContext(c.ctx, level, 4, v...)
+}
+
+func (c *contextLogger) logf(level LLevel, format string, v ...interface{}) {
+	if level >= c.l.level {
+		c.l.writeContext(c.ctx, level, 4, fmt.Sprintf(format, v...))
+	}
+}
+
+func (c *contextLogger) Debug(v ...interface{}) {
+	c.log(LDebug, v...)
+}
+
+func (c *contextLogger) Debugf(format string, v ...interface{}) {
+	c.logf(LDebug, format, v...)
+}
+
+func (c *contextLogger) Info(v ...interface{}) {
+	c.log(LInfo, v...)
+}
+
+func (c *contextLogger) Infof(format string, v ...interface{}) {
+	c.logf(LInfo, format, v...)
+}
+
+func (c *contextLogger) Warning(v ...interface{}) {
+	c.log(LWarning, v...)
+}
+
+func (c *contextLogger) Warningf(format string, v ...interface{}) {
+	c.logf(LWarning, format, v...)
+}
+
+func (c *contextLogger) Error(v ...interface{}) {
+	c.log(LError, v...)
+}
+
+func (c *contextLogger) Errorf(format string, v ...interface{}) {
+	c.logf(LError, format, v...)
+}
@@ -1,5 +1,10 @@
 package log
 
+import (
+	"fmt"
+	"strings"
+)
+
 type LLevel int
 
 const (
@@ -68,3 +73,15 @@ func (l LLevel) Colorcode() string {
 	}
 	return "1;37" // White
 }
+
+// ParseLevel returns the level with the given name or initial
+// (as returned by LLevel.String and LLevel.Initial), ignoring
+// case.
+func ParseLevel(s string) (LLevel, error) {
+	for l := LDebug; l <= LNone; l++ {
+		if strings.EqualFold(s, l.String()) || strings.EqualFold(s, l.Initial()) {
+			return l, nil
+		}
+	}
+	return LNone, fmt.Errorf("invalid log level %q", s)
+}
@@ -1,6 +1,7 @@
 package log
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"runtime"
@@ -172,12 +173,20 @@ func (l *Logger) Writeln(level LLevel, calldepth int, v ...interface{}) {
 }
 
 func (l *Logger) write(level LLevel, calldepth int, v ...interface{}) {
+	l.writeContext(nil, level, calldepth+1, v...)
+}
+
+func (l *Logger) writeContext(ctx context.Context, level LLevel, calldepth int, v ...interface{}) {
 	if level >= l.level {
 		s := fmt.Sprint(v...)
 		msg := l.FormatMessage(level, calldepth, s)
 		for _, w := range l.writers {
 			if level >= w.Level() {
-				w.Write(level, l.flags, msg)
+				if cw, ok := w.(ContextWriter); ok && ctx != nil {
+					cw.WriteContext(ctx, level, l.flags, msg)
+				} else {
+					w.Write(level, l.flags, msg)
+				}
 			}
 		}
 		if cap(msg) <= maxPoolCap {
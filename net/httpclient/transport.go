@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"net/url"
 	"time"
+
+	"gnd.la/trace"
 )
 
 // Transport is the interface used as a transport by *Client.
@@ -51,13 +53,14 @@ func NewTransport(ctx Context) Transport {
 }
 
 func newTransport(ctx Context) *transport {
-	tr := &transport{}
+	tr := &transport{ctx: ctx}
 	rt := newRoundTripper(ctx, tr)
 	tr.transport = rt
 	return tr
 }
 
 type transport struct {
+	ctx       Context
 	userAgent string
 	timeout   time.Duration
 	transport http.RoundTripper
@@ -65,6 +68,7 @@ type transport struct {
 
 func (t *transport) clone(ctx Context) *transport {
 	tc := *t
+	tc.ctx = ctx
 	tc.transport = newRoundTripper(ctx, &tc)
 	return &tc
 }
@@ -91,5 +95,24 @@ func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
 			req.Header.Add("User-Agent", t.userAgent)
 		}
 	}
+	if t.ctx != nil && req.Header != nil {
+		if r := t.ctx.Request(); r != nil {
+			// Propagate the trace from the in-flight request
+			_, span := trace.Start(r.Context(), req.Method+" "+req.URL.Host, trace.KindClient)
+			if span != nil {
+				defer span.End()
+				span.SetAttribute("http.method", req.Method)
+				span.SetAttribute("http.url", req.URL.String())
+				req.Header.Set(trace.TraceparentHeader, span.SpanContext().Traceparent())
+				resp, err := t.transport.RoundTrip(req)
+				if err != nil {
+					span.SetError(err)
+				} else {
+					span.SetAttribute("http.status_code", resp.StatusCode)
+				}
+				return resp, err
+			}
+		}
+	}
 	return t.transport.RoundTrip(req)
 }
@@ -11,6 +11,7 @@ var Config struct {
 	MailServer  string `default:"localhost:25" help:"Default mail server used by gnd.la/net/mail"`
 	DefaultFrom string `help:"Default From address when sending emails"`
 	AdminEmail  string `help:"When running in non-debug mode, any error messages will be emailed to this adddress"`
+	BaseURL     string `help:"Base URL for resolving relative URLs in HTML emails (e.g. http://www.example.com)"`
 }
 
 func init() {
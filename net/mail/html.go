@@ -0,0 +1,476 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"code.google.com/p/go.net/html"
+)
+
+const (
+	// Gmail clips messages with an HTML body bigger than this.
+	maxHTMLSize = 102 * 1024
+)
+
+var (
+	// Attributes which contain URLs that must be absolute
+	urlAttributes = map[string]bool{
+		"href":       true,
+		"src":        true,
+		"background": true,
+		"action":     true,
+		"poster":     true,
+	}
+	// Elements which are not supported by most email clients
+	unsupportedElements = map[string]string{
+		"form":   "forms",
+		"input":  "forms",
+		"button": "forms",
+		"select": "forms",
+		"iframe": "iframes",
+		"object": "plugins",
+		"embed":  "plugins",
+		"video":  "video",
+		"audio":  "audio",
+		"canvas": "canvas",
+		"svg":    "SVG",
+	}
+	// CSS properties which are not supported by most email clients
+	unsupportedProperties = []string{
+		"position", "transform", "animation", "transition", "flex", "grid",
+	}
+	cssURLRe            = regexp.MustCompile(`url\(\s*(['"]?)([^'")]*)(['"]?)\s*\)`)
+	cssCommentRe        = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	cssSimpleSelectorRe = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9]*)?((?:[.#][\w\-]+)*)$`)
+	cssSelectorPartRe   = regexp.MustCompile(`[.#][\w\-]+`)
+	attrEscaper         = strings.NewReplacer(`&`, "&amp;", `"`, "&#34;")
+)
+
+// HTMLOptions specifies the options for PrepareHTML.
+type HTMLOptions struct {
+	// BaseURL is used to resolve relative URLs. If empty, the
+	// BaseURL config key is used.
+	BaseURL string
+}
+
+// PrepareHTML adapts the given HTML document, which is usually the result
+// of rendering a template, for sending it as an email body. Since email
+// clients support a very limited subset of HTML and CSS, the following
+// transformations are performed:
+//
+//   - script elements are removed, as well as links to external stylesheets.
+//   - relative URLs in attributes (e.g. href or src) and CSS url() values are
+//     resolved against the base URL, since emails don't have one.
+//   - CSS rules in style elements using only simple selectors (e.g. p,
+//     .note, #footer or td.price) are inlined into the style attributes of
+//     the elements they match. Remaining rules (e.g. @media queries or rules
+//     with pseudo-classes) are kept in a style element.
+//
+// Additionally, the document is checked for features which are not supported
+// by common email clients (e.g. forms or CSS positioning). Any problems found
+// are returned as warnings, but they don't prevent the document from being
+// returned. Note that the HTML is only tokenized, not parsed into a tree, so
+// the document structure is kept as is (e.g. missing elements are not added).
+func PrepareHTML(body string, opts *HTMLOptions) (string, []string, error) {
+	p := &htmlPreparer{warnings: make(map[string]bool)}
+	baseURL := Config.BaseURL
+	if opts != nil && opts.BaseURL != "" {
+		baseURL = opts.BaseURL
+	}
+	if baseURL != "" {
+		base, err := url.Parse(baseURL)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid base URL %q: %s", baseURL, err)
+		}
+		if !base.IsAbs() {
+			return "", nil, fmt.Errorf("base URL %q is not absolute", baseURL)
+		}
+		p.base = base
+	}
+	tokens, err := tokenizeHTML(body)
+	if err != nil {
+		return "", nil, err
+	}
+	for ii, tok := range tokens {
+		if tok.typ == html.StartTagToken && tok.tag.Data == "style" && ii+1 < len(tokens) && tokens[ii+1].typ == html.TextToken {
+			p.parseCSS(tokens[ii+1].raw)
+		}
+	}
+	out := p.render(tokens)
+	if len(out) > maxHTMLSize {
+		p.warn(fmt.Sprintf("HTML body is %d bytes, some clients (e.g. Gmail) clip messages bigger than %d bytes", len(out), maxHTMLSize))
+	}
+	var warnings []string
+	for k := range p.warnings {
+		warnings = append(warnings, k)
+	}
+	sort.Strings(warnings)
+	return out, warnings, nil
+}
+
+// htmlToken is a token from the document. raw contains its original
+// text, which is written unchanged for everything but start tags. For
+// tags, tag holds the parsed tag, with its name and attribute names in
+// lowercase.
+type htmlToken struct {
+	typ html.TokenType
+	tag html.Token
+	raw string
+}
+
+func tokenizeHTML(s string) ([]*htmlToken, error) {
+	var tokens []*htmlToken
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); err != io.EOF {
+				return nil, err
+			}
+			return tokens, nil
+		}
+		// Copy the raw text before calling Token, since
+		// it lowercases the tag in the same buffer.
+		tok := &htmlToken{typ: tt, raw: string(z.Raw())}
+		switch tt {
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			tok.tag = z.Token()
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+func tagAttr(tag *html.Token, name string) *html.Attribute {
+	for ii := range tag.Attr {
+		if tag.Attr[ii].Key == name {
+			return &tag.Attr[ii]
+		}
+	}
+	return nil
+}
+
+func setTagAttr(tag *html.Token, name string, value string) {
+	if a := tagAttr(tag, name); a != nil {
+		a.Val = value
+		return
+	}
+	tag.Attr = append(tag.Attr, html.Attribute{Key: name, Val: value})
+}
+
+// renderTag renders a start tag. Unlike html.Token.String, it
+// only escapes & and " in attribute values, so CSS in style
+// attributes stays readable.
+func renderTag(tag *html.Token) string {
+	var buf bytes.Buffer
+	buf.WriteByte('<')
+	buf.WriteString(tag.Data)
+	for _, v := range tag.Attr {
+		buf.WriteByte(' ')
+		buf.WriteString(v.Key)
+		buf.WriteString(`="`)
+		buf.WriteString(attrEscaper.Replace(v.Val))
+		buf.WriteByte('"')
+	}
+	if tag.Type == html.SelfClosingTagToken {
+		buf.WriteString(" /")
+	}
+	buf.WriteByte('>')
+	return buf.String()
+}
+
+type cssDeclaration struct {
+	property string
+	value    string
+}
+
+type cssRule struct {
+	tag         string
+	ids         []string
+	classes     []string
+	specificity int
+	order       int
+	decls       []*cssDeclaration
+}
+
+func (r *cssRule) matches(tag *html.Token) bool {
+	if r.tag != "" && r.tag != tag.Data {
+		return false
+	}
+	if len(r.ids) > 0 {
+		id := tagAttr(tag, "id")
+		if id == nil {
+			return false
+		}
+		for _, v := range r.ids {
+			if v != id.Val {
+				return false
+			}
+		}
+	}
+	if len(r.classes) > 0 {
+		attr := tagAttr(tag, "class")
+		if attr == nil {
+			return false
+		}
+		classes := strings.Fields(attr.Val)
+		for _, v := range r.classes {
+			found := false
+			for _, c := range classes {
+				if c == v {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+type cssRulesBySpecificity []*cssRule
+
+func (r cssRulesBySpecificity) Len() int      { return len(r) }
+func (r cssRulesBySpecificity) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r cssRulesBySpecificity) Less(i, j int) bool {
+	if r[i].specificity != r[j].specificity {
+		return r[i].specificity < r[j].specificity
+	}
+	return r[i].order < r[j].order
+}
+
+type htmlPreparer struct {
+	base     *url.URL
+	rules    []*cssRule
+	kept     []string
+	warnings map[string]bool
+}
+
+func (p *htmlPreparer) warn(msg string) {
+	p.warnings[msg] = true
+}
+
+func (p *htmlPreparer) resolve(u string) string {
+	trimmed := strings.TrimSpace(u)
+	if trimmed == "" || trimmed[0] == '#' {
+		return u
+	}
+	ref, err := url.Parse(trimmed)
+	if err != nil || ref.IsAbs() {
+		return u
+	}
+	if p.base == nil {
+		p.warn(fmt.Sprintf("relative URL %q can't be resolved because there's no base URL", trimmed))
+		return u
+	}
+	return p.base.ResolveReference(ref).String()
+}
+
+func (p *htmlPreparer) resolveCSS(css string) string {
+	return cssURLRe.ReplaceAllStringFunc(css, func(s string) string {
+		m := cssURLRe.FindStringSubmatch(s)
+		return "url(" + m[1] + p.resolve(m[2]) + m[3] + ")"
+	})
+}
+
+func (p *htmlPreparer) checkDeclarations(decls []*cssDeclaration) {
+	for _, d := range decls {
+		for _, v := range unsupportedProperties {
+			if d.property == v || strings.HasPrefix(d.property, v+"-") {
+				p.warn(fmt.Sprintf("CSS property %q is not supported by most email clients", d.property))
+			}
+		}
+		if d.property == "display" {
+			if val := strings.ToLower(d.value); strings.Contains(val, "flex") || strings.Contains(val, "grid") {
+				p.warn(fmt.Sprintf("CSS display %q is not supported by most email clients", d.value))
+			}
+		}
+	}
+}
+
+func parseDeclarations(s string) []*cssDeclaration {
+	var decls []*cssDeclaration
+	for _, v := range strings.Split(s, ";") {
+		sep := strings.IndexByte(v, ':')
+		if sep < 0 {
+			continue
+		}
+		prop := strings.ToLower(strings.TrimSpace(v[:sep]))
+		value := strings.TrimSpace(v[sep+1:])
+		if prop != "" && value != "" {
+			decls = append(decls, &cssDeclaration{property: prop, value: value})
+		}
+	}
+	return decls
+}
+
+func formatDeclarations(decls []*cssDeclaration) string {
+	parts := make([]string, len(decls))
+	for ii, v := range decls {
+		parts[ii] = v.property + ": " + v.value
+	}
+	return strings.Join(parts, "; ")
+}
+
+// parseCSS adds the rules in the given CSS which can be inlined
+// to p.rules, while the rest of them are added to p.kept.
+func (p *htmlPreparer) parseCSS(css string) {
+	css = cssCommentRe.ReplaceAllString(css, "")
+	for len(css) > 0 {
+		open := strings.IndexByte(css, '{')
+		if open < 0 {
+			break
+		}
+		// Find the matching brace, since at-rules
+		// (e.g. @media) contain nested blocks.
+		depth := 0
+		end := len(css)
+		for ii := open; ii < len(css); ii++ {
+			if css[ii] == '{' {
+				depth++
+			} else if css[ii] == '}' {
+				depth--
+				if depth == 0 {
+					end = ii
+					break
+				}
+			}
+		}
+		selector := strings.TrimSpace(css[:open])
+		body := css[open+1 : end]
+		var rule string
+		if end < len(css) {
+			rule = strings.TrimSpace(css[:end+1])
+			css = css[end+1:]
+		} else {
+			rule = strings.TrimSpace(css) + "}"
+			css = ""
+		}
+		if strings.HasPrefix(selector, "@") {
+			p.checkDeclarations(parseDeclarations(strings.Replace(strings.Replace(body, "{", ";", -1), "}", ";", -1)))
+			p.kept = append(p.kept, rule)
+			continue
+		}
+		decls := parseDeclarations(body)
+		p.checkDeclarations(decls)
+		var rules []*cssRule
+		for _, sel := range strings.Split(selector, ",") {
+			sel = strings.TrimSpace(sel)
+			m := cssSimpleSelectorRe.FindStringSubmatch(sel)
+			if sel == "" || m == nil {
+				rules = nil
+				break
+			}
+			r := &cssRule{tag: strings.ToLower(m[1]), decls: decls}
+			if r.tag != "" {
+				r.specificity++
+			}
+			for _, part := range cssSelectorPartRe.FindAllString(m[2], -1) {
+				if part[0] == '#' {
+					r.ids = append(r.ids, part[1:])
+					r.specificity += 100
+				} else {
+					r.classes = append(r.classes, part[1:])
+					r.specificity += 10
+				}
+			}
+			rules = append(rules, r)
+		}
+		if rules == nil {
+			p.kept = append(p.kept, rule)
+			continue
+		}
+		for _, r := range rules {
+			r.order = len(p.rules)
+			p.rules = append(p.rules, r)
+		}
+	}
+}
+
+func (p *htmlPreparer) inline(tag *html.Token) {
+	var matched []*cssRule
+	for _, r := range p.rules {
+		if r.matches(tag) {
+			matched = append(matched, r)
+		}
+	}
+	var decls []*cssDeclaration
+	if len(matched) > 0 {
+		sort.Sort(cssRulesBySpecificity(matched))
+		for _, r := range matched {
+			decls = append(decls, r.decls...)
+		}
+	}
+	if style := tagAttr(tag, "style"); style != nil {
+		// Declarations in the style attribute take
+		// precedence, so they go last.
+		own := parseDeclarations(style.Val)
+		p.checkDeclarations(own)
+		decls = append(decls, own...)
+	}
+	if len(decls) > 0 {
+		setTagAttr(tag, "style", p.resolveCSS(formatDeclarations(decls)))
+	}
+}
+
+func (p *htmlPreparer) render(tokens []*htmlToken) string {
+	var buf bytes.Buffer
+	keptStyle := false
+	for ii := 0; ii < len(tokens); ii++ {
+		tok := tokens[ii]
+		tag := &tok.tag
+		if tok.typ == html.EndTagToken {
+			if tag.Data != "script" && tag.Data != "style" {
+				buf.WriteString(tok.raw)
+			}
+			continue
+		}
+		if tok.typ != html.StartTagToken && tok.typ != html.SelfClosingTagToken {
+			buf.WriteString(tok.raw)
+			continue
+		}
+		if what := unsupportedElements[tag.Data]; what != "" {
+			p.warn(fmt.Sprintf("%s (found <%s>) are not supported by most email clients", what, tag.Data))
+		}
+		switch tag.Data {
+		case "script", "style":
+			if tok.typ == html.StartTagToken && ii+1 < len(tokens) && tokens[ii+1].typ == html.TextToken {
+				// Skip contents
+				ii++
+			}
+			if tag.Data == "style" && !keptStyle && len(p.kept) > 0 {
+				keptStyle = true
+				buf.WriteString("<style type=\"text/css\">\n")
+				buf.WriteString(p.resolveCSS(strings.Join(p.kept, "\n")))
+				buf.WriteString("\n</style>")
+			}
+			continue
+		case "link":
+			if rel := tagAttr(tag, "rel"); rel != nil {
+				switch strings.ToLower(rel.Val) {
+				case "stylesheet":
+					if href := tagAttr(tag, "href"); href != nil {
+						p.warn(fmt.Sprintf("external stylesheet %q was removed, use a style element instead", href.Val))
+					}
+					continue
+				case "preload", "modulepreload", "prefetch", "dns-prefetch", "preconnect":
+					continue
+				}
+			}
+		}
+		for jj := range tag.Attr {
+			if v := &tag.Attr[jj]; urlAttributes[v.Key] {
+				v.Val = p.resolve(v.Val)
+			}
+		}
+		p.inline(tag)
+		buf.WriteString(renderTag(tag))
+	}
+	return buf.String()
+}
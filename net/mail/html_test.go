@@ -0,0 +1,77 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrepareHTML(t *testing.T) {
+	const body = `<!DOCTYPE html>
+<html>
+<head>
+<link rel="stylesheet" href="/assets/site.css">
+<style>
+p { color: red; margin: 0 }
+.note, #footer { color: blue }
+a:hover { color: green }
+@media (max-width: 600px) { p { font-size: 12px } }
+</style>
+<script src="/assets/app.js"></script>
+<script>alert("hi");</script>
+</head>
+<body>
+<p>Plain</p>
+<p class="note" style="margin: 2px">Note</p>
+<div id="footer" style="background: url('/img/bg.png')"><a href="/account/?id=1">Account</a> <a href="mailto:a@example.com">Mail</a> <a href="#top">Top</a></div>
+<img src="img/logo.png" alt="logo">
+<A HREF="/search/?q=a&amp;p=2">Search</A>
+<form action="/search/"></form>
+</body>
+</html>`
+	out, warnings, err := PrepareHTML(body, &HTMLOptions{BaseURL: "http://www.example.com/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []string{
+		`<p style="color: red; margin: 0">Plain</p>`,
+		`<p class="note" style="color: red; margin: 0; color: blue; margin: 2px">Note</p>`,
+		`<div id="footer" style="color: blue; background: url('http://www.example.com/img/bg.png')">`,
+		`<a href="http://www.example.com/account/?id=1">`,
+		`<a href="mailto:a@example.com">`,
+		`<a href="#top">`,
+		`<img src="http://www.example.com/img/logo.png" alt="logo">`,
+		`<a href="http://www.example.com/search/?q=a&amp;p=2">Search</A>`,
+		`a:hover { color: green }`,
+		`@media (max-width: 600px) { p { font-size: 12px } }`,
+	} {
+		if !strings.Contains(out, v) {
+			t.Errorf("expecting %q in prepared HTML", v)
+		}
+	}
+	for _, v := range []string{"<script", "alert", "site.css", "<link"} {
+		if strings.Contains(out, v) {
+			t.Errorf("unexpected %q in prepared HTML", v)
+		}
+	}
+	var hasForms, hasStylesheet bool
+	for _, v := range warnings {
+		hasForms = hasForms || strings.Contains(v, "forms")
+		hasStylesheet = hasStylesheet || strings.Contains(v, "site.css")
+	}
+	if !hasForms || !hasStylesheet {
+		t.Errorf("missing warnings, got %q", warnings)
+	}
+}
+
+func TestPrepareHTMLNoBase(t *testing.T) {
+	_, warnings, err := PrepareHTML(`<a href="/foo/">foo</a><a href="http://example.com/">bar</a>`, &HTMLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "/foo/") {
+		t.Errorf("expecting a warning about the relative URL, got %q", warnings)
+	}
+	if _, _, err := PrepareHTML("", &HTMLOptions{BaseURL: "/relative"}); err == nil {
+		t.Error("expecting an error with a relative base URL")
+	}
+}
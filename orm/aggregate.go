@@ -0,0 +1,293 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gnd.la/app/profile"
+	"gnd.la/orm/driver"
+	"gnd.la/orm/query"
+)
+
+// Aggregator is implemented by drivers which can compute aggregates
+// over groups of rows (the sql driver implements this interface).
+type Aggregator interface {
+	Aggregate(m driver.Model, q query.Q, groupBy []string, aggs []*driver.Aggregate, sort []driver.Sort, limit int, offset int) (driver.Rows, error)
+}
+
+var (
+	float64Type = reflect.TypeOf(float64(0))
+	int64Type   = reflect.TypeOf(int64(0))
+	mapType     = reflect.TypeOf(map[string]interface{}(nil))
+)
+
+// Aggregate represents an aggregate function over a field. Use
+// Sum, Avg, Min, Max or Count to create an Aggregate.
+type Aggregate struct {
+	fn       string
+	field    string
+	name     string
+	distinct bool
+}
+
+// Sum returns an Aggregate which adds up the values of the given field.
+func Sum(field string) *Aggregate {
+	return &Aggregate{fn: "SUM", field: field}
+}
+
+// Avg returns an Aggregate which averages the values of the given field.
+func Avg(field string) *Aggregate {
+	return &Aggregate{fn: "AVG", field: field}
+}
+
+// Min returns an Aggregate which returns the minimum value of the given field.
+func Min(field string) *Aggregate {
+	return &Aggregate{fn: "MIN", field: field}
+}
+
+// Max returns an Aggregate which returns the maximum value of the given field.
+func Max(field string) *Aggregate {
+	return &Aggregate{fn: "MAX", field: field}
+}
+
+// Count returns an Aggregate which counts the non-null values of the
+// given field or, if field is empty, the number of rows.
+func Count(field string) *Aggregate {
+	return &Aggregate{fn: "COUNT", field: field}
+}
+
+// CountDistinct returns an Aggregate which counts the distinct non-null
+// values of the given field. Its default name is CountDistinct followed
+// by the field name. Note that the driver must support the
+// driver.CAP_DISTINCT capability.
+func CountDistinct(field string) *Aggregate {
+	return &Aggregate{fn: "COUNT", field: field, distinct: true}
+}
+
+// As sets the name of the Aggregate, which is used to map it to a
+// struct field or a map key when loading the results. The default
+// name is the function name followed by the field name, with
+// qualifiers and dots removed (e.g. SumAmount, MaxAddressNumber).
+// For Count without a field, the default name is Count.
+func (a *Aggregate) As(name string) *Aggregate {
+	a.name = name
+	return a
+}
+
+// Name returns the Aggregate name. See As for details.
+func (a *Aggregate) Name() string {
+	if a.name != "" {
+		return a.name
+	}
+	name := strings.Title(strings.ToLower(a.fn))
+	if a.distinct {
+		name += "Distinct"
+	}
+	return name + resultName(a.field)
+}
+
+func (a *Aggregate) String() string {
+	field := a.field
+	if field == "" {
+		field = "*"
+	}
+	if a.distinct {
+		return fmt.Sprintf("%s(DISTINCT %s)", a.fn, field)
+	}
+	return fmt.Sprintf("%s(%s)", a.fn, field)
+}
+
+// resultName returns the name used for loading a field into
+// a result e.g. User|Address.City => AddressCity.
+func resultName(field string) string {
+	if sep := strings.IndexByte(field, '|'); sep >= 0 {
+		field = field[sep+1:]
+	}
+	return strings.Replace(field, ".", "", -1)
+}
+
+// AggregateQuery computes aggregates over the results of a Query.
+// Use Query.Aggregate to create an AggregateQuery.
+type AggregateQuery struct {
+	q       *Query
+	aggs    []*Aggregate
+	groupBy []string
+}
+
+// Aggregate returns an AggregateQuery which computes the given
+// aggregates over the objects matched by the query. Use GroupBy
+// to compute them for each group of objects. Sorting, limit and
+// offset set in the query are applied to the groups. The query
+// table must be set before calling Aggregate.
+//
+//	var totals []struct {
+//		UserId    int64
+//		SumAmount float64
+//	}
+//	err := o.Query(orm.Gt("Amount", 0)).Table(paymentTable).
+//		Aggregate(orm.Sum("Amount")).GroupBy("UserId").All(&totals)
+func (q *Query) Aggregate(aggs ...*Aggregate) *AggregateQuery {
+	return &AggregateQuery{q: q, aggs: aggs}
+}
+
+// GroupBy sets the fields used for grouping the objects. Each
+// result contains the values of these fields, which are loaded
+// into struct fields or map keys with the same name (with
+// qualifiers and dots removed, e.g. Address.City => AddressCity).
+func (a *AggregateQuery) GroupBy(fields ...string) *AggregateQuery {
+	a.groupBy = append(a.groupBy, fields...)
+	return a
+}
+
+// All runs the query and loads the results into out, which must be a
+// pointer to a slice of structs, pointers to structs or
+// map[string]interface{}. Struct fields and map keys are matched by name
+// with the GroupBy fields and the aggregates (see Aggregate.As). When
+// loading into structs, results without a matching field are ignored.
+// Aggregates which return NULL (e.g. Max over an empty group) leave
+// struct fields at their zero value and store nil in maps.
+func (a *AggregateQuery) All(out interface{}) error {
+	q := a.q
+	if q.err != nil {
+		return q.err
+	}
+	if q.model == nil {
+		return errors.New("no table selected, set one with Table() before calling Aggregate()")
+	}
+	if len(a.aggs) == 0 {
+		return errors.New("no aggregates specified")
+	}
+	agg, ok := q.orm.conn.(Aggregator)
+	if !ok {
+		return ErrNoAggregates
+	}
+	val := reflect.ValueOf(out)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("argument to All() must be a pointer to a slice, not %T", out)
+	}
+	slice := val.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct && elemType != mapType {
+		return fmt.Errorf("can't load aggregates into %v, must be a struct, a pointer to a struct or map[string]interface{}", elemType)
+	}
+	names, types, err := a.columns()
+	if err != nil {
+		return err
+	}
+	// Index of the struct field for each column, -1 when it's ignored
+	var fieldIndexes []int
+	if elemType != mapType {
+		fieldIndexes = make([]int, len(names))
+		for ii, n := range names {
+			fieldIndexes[ii] = -1
+			if f, ok := structType.FieldByName(n); ok && len(f.Index) == 1 && f.PkgPath == "" {
+				fieldIndexes[ii] = f.Index[0]
+				types[ii] = f.Type
+			}
+		}
+	}
+	daggs := make([]*driver.Aggregate, len(a.aggs))
+	for ii, v := range a.aggs {
+		if v.distinct && v.field == "" {
+			return fmt.Errorf("aggregate %s requires a field", v)
+		}
+		daggs[ii] = &driver.Aggregate{Func: v.fn, Field: v.field, Distinct: v.distinct}
+	}
+	if profile.On && profile.Profiling() {
+		defer profile.Start(orm).Note("aggregate", q.model.String()).End()
+	}
+	rows, err := agg.Aggregate(q.model, q.condition(), a.groupBy, daggs, q.sort, q.limit, q.offset)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		// Scan into pointers, so NULL values are supported
+		dest := make([]interface{}, len(types))
+		for ii, t := range types {
+			dest[ii] = reflect.New(reflect.PtrTo(t)).Interface()
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		var elem reflect.Value
+		if elemType == mapType {
+			m := make(map[string]interface{}, len(names))
+			for ii, n := range names {
+				var v interface{}
+				if p := reflect.ValueOf(dest[ii]).Elem(); !p.IsNil() {
+					v = p.Elem().Interface()
+				}
+				m[n] = v
+			}
+			elem = reflect.ValueOf(m)
+		} else {
+			sval := reflect.New(structType)
+			for ii, idx := range fieldIndexes {
+				if idx < 0 {
+					continue
+				}
+				if p := reflect.ValueOf(dest[ii]).Elem(); !p.IsNil() {
+					sval.Elem().Field(idx).Set(p.Elem())
+				}
+			}
+			if elemType.Kind() == reflect.Ptr {
+				elem = sval
+			} else {
+				elem = sval.Elem()
+			}
+		}
+		slice.Set(reflect.Append(slice, elem))
+	}
+	return rows.Err()
+}
+
+// MustAll works like All, but panics if there's an error.
+func (a *AggregateQuery) MustAll(out interface{}) {
+	if err := a.All(out); err != nil {
+		panic(err)
+	}
+}
+
+// columns returns the names and the default types for
+// loading each column in the results.
+func (a *AggregateQuery) columns() ([]string, []reflect.Type, error) {
+	m := a.q.model
+	var names []string
+	var types []reflect.Type
+	for _, v := range a.groupBy {
+		_, typ, err := m.Map(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		names = append(names, resultName(v))
+		types = append(types, typ)
+	}
+	for _, v := range a.aggs {
+		var typ reflect.Type
+		switch v.fn {
+		case "COUNT":
+			typ = int64Type
+		case "AVG":
+			typ = float64Type
+		default:
+			if v.field == "" {
+				return nil, nil, fmt.Errorf("aggregate %s requires a field", v)
+			}
+			_, ftyp, err := m.Map(v.field)
+			if err != nil {
+				return nil, nil, err
+			}
+			typ = ftyp
+		}
+		names = append(names, v.Name())
+		types = append(types, typ)
+	}
+	return names, types, nil
+}
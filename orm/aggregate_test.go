@@ -0,0 +1,69 @@
+package orm
+
+import (
+	"testing"
+)
+
+type AggregatePayment struct {
+	Id     int64 `orm:",primary_key,auto_increment"`
+	UserId int64
+	Amount float64
+}
+
+func testAggregate(t *testing.T, o *Orm) {
+	if _, ok := o.conn.(Aggregator); !ok {
+		t.Skip("driver does not support aggregates")
+	}
+	table := o.mustRegister((*AggregatePayment)(nil), &Options{
+		Table: "test_aggregate_payment",
+	})
+	o.mustInitialize()
+	for _, v := range []*AggregatePayment{
+		{UserId: 1, Amount: 10},
+		{UserId: 1, Amount: 5},
+		{UserId: 2, Amount: 7},
+		{UserId: 2, Amount: 1},
+		{UserId: 2, Amount: 4},
+	} {
+		o.MustInsert(v)
+	}
+	var totals []struct {
+		UserId    int64
+		SumAmount float64
+		Payments  int64
+		MaxAmount *float64
+	}
+	o.Query(nil).Table(table).Sort("UserId", ASC).
+		Aggregate(Sum("Amount"), Count("").As("Payments"), Max("Amount")).
+		GroupBy("UserId").MustAll(&totals)
+	if len(totals) != 2 {
+		t.Fatalf("expecting 2 groups, got %d", len(totals))
+	}
+	if totals[0].UserId != 1 || totals[0].SumAmount != 15 || totals[0].Payments != 2 || totals[0].MaxAmount == nil || *totals[0].MaxAmount != 10 {
+		t.Errorf("unexpected result for user 1: %+v", totals[0])
+	}
+	if totals[1].UserId != 2 || totals[1].SumAmount != 12 || totals[1].Payments != 3 {
+		t.Errorf("unexpected result for user 2: %+v", totals[1])
+	}
+	// Without GroupBy, into maps
+	var results []map[string]interface{}
+	o.Query(Gt("Amount", 4)).Table(table).Aggregate(Avg("Amount"), Min("Amount")).MustAll(&results)
+	if len(results) != 1 {
+		t.Fatalf("expecting 1 result, got %d", len(results))
+	}
+	if avg := results[0]["AvgAmount"]; avg != float64(22)/3 {
+		t.Errorf("expecting AvgAmount = %v, got %v", float64(22)/3, avg)
+	}
+	if min := results[0]["MinAmount"]; min != float64(5) {
+		t.Errorf("expecting MinAmount = 5, got %v", min)
+	}
+	// Aggregates over no rows return NULL
+	results = nil
+	o.Query(Gt("Amount", 100)).Table(table).Aggregate(Max("Amount")).MustAll(&results)
+	if len(results) != 1 || results[0]["MaxAmount"] != nil {
+		t.Errorf("expecting nil MaxAmount, got %v", results)
+	}
+	if err := o.Query(nil).Aggregate(Sum("Amount")).All(&results); err == nil {
+		t.Error("expecting an error without a table")
+	}
+}
@@ -0,0 +1,82 @@
+package orm
+
+import (
+	"reflect"
+	"testing"
+
+	"gnd.la/orm/driver"
+	"gnd.la/orm/driver/sql"
+)
+
+type Tagged struct {
+	Id      int64 `orm:",primary_key,auto_increment"`
+	Tags    []string
+	Scores  []int64
+	Flags   []bool
+	Counts  []int32
+	Ratios  []float32
+	Weights []float64
+}
+
+func testArray(t *testing.T, o *Orm) {
+	if o.Driver().Capabilities()&driver.CAP_ARRAY == 0 {
+		t.Skipf("driver %T does not support arrays", o.Driver())
+	}
+	table := o.mustRegister((*Tagged)(nil), nil)
+	o.mustInitialize()
+	items := []*Tagged{
+		{Tags: []string{"orm", "sql"}, Scores: []int64{1, 2, 3}, Flags: []bool{true, false},
+			Counts: []int32{4, 5}, Ratios: []float32{0.5, 1.5}, Weights: []float64{2.25}},
+		{Tags: []string{"web"}, Scores: []int64{3}},
+		{},
+	}
+	for _, v := range items {
+		o.MustInsert(v)
+	}
+	var item *Tagged
+	o.MustOne(Eq("Id", items[0].Id), &item)
+	if item == nil || !reflect.DeepEqual(item, items[0]) {
+		t.Errorf("expecting %+v, got %+v", items[0], item)
+	}
+	o.MustOne(Eq("Id", items[2].Id), &item)
+	if item == nil || len(item.Tags) != 0 || len(item.Scores) != 0 || len(item.Flags) != 0 ||
+		len(item.Counts) != 0 || len(item.Ratios) != 0 || len(item.Weights) != 0 {
+		t.Errorf("expecting empty arrays, got %+v", item)
+	}
+	tests := []struct {
+		field string
+		value interface{}
+		count uint64
+	}{
+		{"Tags", "orm", 1},
+		{"Tags", "web", 1},
+		{"Tags", "go", 0},
+		{"Scores", 3, 2},
+		{"Scores", 2, 1},
+	}
+	for _, v := range tests {
+		count, err := o.Query(Contains(v.field, v.value)).Table(table).Count()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != v.count {
+			t.Errorf("expecting %d items with %s containing %v, got %d", v.count, v.field, v.value, count)
+		}
+	}
+}
+
+func TestIsArray(t *testing.T) {
+	arrays := []interface{}{[]bool(nil), []int32(nil), []int64(nil), []float32(nil), []float64(nil), []string(nil)}
+	for _, v := range arrays {
+		if !sql.IsArray(reflect.TypeOf(v)) {
+			t.Errorf("expecting %T to be stored as an array", v)
+		}
+	}
+	// These can't be scanned back from array columns
+	others := []interface{}{[]byte(nil), []int(nil), []int16(nil), []uint16(nil), []uint32(nil), []Sort(nil)}
+	for _, v := range others {
+		if sql.IsArray(reflect.TypeOf(v)) {
+			t.Errorf("expecting %T not to be stored as an array", v)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package orm
+
+import (
+	"errors"
+	"testing"
+)
+
+type Batched struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Value int
+}
+
+func testBatches(t *testing.T, o *Orm) {
+	table := o.mustRegister((*Batched)(nil), &Options{Table: "batched"})
+	o.mustInitialize()
+	for ii := 0; ii < 25; ii++ {
+		o.MustInsert(&Batched{Value: ii})
+	}
+	var sizes []int
+	seen := 0
+	o.Query(Gte("Value", 3)).Table(table).MustBatches(10, func(batch []*Batched) error {
+		sizes = append(sizes, len(batch))
+		for _, v := range batch {
+			if v.Value != seen+3 {
+				t.Errorf("expecting value %d, got %d", seen+3, v.Value)
+			}
+			seen++
+			// Modifying the objects must not alter the iteration
+			v.Value += 100
+			o.MustSave(v)
+		}
+		return nil
+	})
+	if len(sizes) != 3 || sizes[0] != 10 || sizes[1] != 10 || sizes[2] != 2 {
+		t.Errorf("expecting batches of sizes [10 10 2], got %v", sizes)
+	}
+	// Model determined from the function argument
+	errStop := errors.New("stop")
+	calls := 0
+	err := o.All().Batches(5, func(batch []*Batched) error {
+		calls++
+		return errStop
+	})
+	if err != errStop || calls != 1 {
+		t.Errorf("expecting error %v after 1 call, got %v after %d calls", errStop, err, calls)
+	}
+	if err := o.All().Batches(5, func(batch []*Batched) {}); err == nil {
+		t.Error("expecting an error with an invalid function")
+	}
+}
@@ -0,0 +1,43 @@
+package orm
+
+import (
+	"testing"
+
+	"gnd.la/orm/query"
+)
+
+type Ranged struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Value int
+}
+
+func testBetweenNotIn(t *testing.T, o *Orm) {
+	table := o.mustRegister((*Ranged)(nil), &Options{Table: "ranged"})
+	o.mustInitialize()
+	for ii := 1; ii <= 10; ii++ {
+		o.MustInsert(&Ranged{Value: ii})
+	}
+	cases := []struct {
+		q     query.Q
+		count uint64
+	}{
+		{CBetween("Value", 3, 6), 4},
+		{CBetween("Value", 6, 3), 0},
+		{Between("Value", 3, 6), 2},
+		{NotIn("Value", []int{1, 2, 3}), 7},
+		{And(NotIn("Value", []int{5}), CBetween("Value", 4, 6)), 2},
+		{Or(CBetween("Value", 1, 2), CBetween("Value", 9, 10)), 4},
+	}
+	for _, v := range cases {
+		count, err := o.Query(v.q).Table(table).Count()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != v.count {
+			t.Errorf("expecting %d results for %v, got %d", v.count, v.q, count)
+		}
+	}
+	if _, err := o.Query(NotIn("Value", []int{})).Table(table).Count(); err == nil {
+		t.Error("expecting an error with an empty NotIn")
+	}
+}
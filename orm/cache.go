@@ -0,0 +1,60 @@
+package orm
+
+import (
+	"time"
+
+	"gnd.la/cache"
+)
+
+// QueryCacher is implemented by drivers which can cache
+// the results of the queries (the sql driver implements
+// this interface).
+type QueryCacher interface {
+	SetQueryCache(*cache.Cache)
+}
+
+// SetCache sets the cache used for storing the results of the queries
+// involving models with caching enabled (see Options.CacheTimeout).
+// Cached results are invalidated automatically when any of the tables
+// involved in the query is written to using the ORM. Passing nil
+// disables caching. If the driver does not implement QueryCacher,
+// ErrNoQueryCache is returned.
+func (o *Orm) SetCache(c *cache.Cache) error {
+	qc, ok := o.driver.(QueryCacher)
+	if !ok {
+		return ErrNoQueryCache
+	}
+	qc.SetQueryCache(c)
+	return nil
+}
+
+// Cached enables caching the results of the query for the given
+// duration, regardless of the CacheTimeout for the models involved in
+// it (see Options.CacheTimeout), so hot read-mostly queries can skip
+// the database. Results are keyed by the generated query and its
+// parameters and they're invalidated automatically when any of the
+// tables involved in the query is written to using the ORM. A ttl
+// smaller than one second caches the results until they're invalidated.
+// Caching requires a cache to be set with Orm.SetCache (Apps do this
+// automatically when they have a cache configured), otherwise this
+// method has no effect.
+func (q *Query) Cached(ttl time.Duration) *Query {
+	q.cached = int(ttl / time.Second)
+	if q.cached <= 0 {
+		q.cached = -1
+	}
+	return q
+}
+
+// cachedModel returns the model used for running the query, with
+// the cache timeout overridden when the query is cached.
+func (q *Query) cachedModel() *joinModel {
+	if q.cached == 0 || q.model == nil {
+		return q.model
+	}
+	m := q.model.clone()
+	for cur := m; cur != nil; cur = cur.nextModel() {
+		cur.cacheTimeout = q.cached
+	}
+	return m
+}
@@ -0,0 +1,99 @@
+package orm
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gnd.la/cache"
+	"gnd.la/config"
+)
+
+type Cached struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Value string
+}
+
+func testQueryCache(t *testing.T, o *Orm) {
+	c, err := cache.New(config.MustParseURL("memory://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.SetCache(c); err != nil {
+		t.Skip(err)
+	}
+	defer o.SetCache(nil)
+	table := o.mustRegister((*Cached)(nil), &Options{Table: "cached", CacheTimeout: 60})
+	o.mustInitialize()
+	o.MustInsert(&Cached{Value: "foo"})
+	count := func() int {
+		var objs []*Cached
+		o.Table(table).MustAll(&objs)
+		return len(objs)
+	}
+	if n := count(); n != 1 {
+		t.Fatalf("expecting 1 object, got %d", n)
+	}
+	// Bypass the ORM, so the cached results are not invalidated
+	if _, err := o.SqlDB().Exec(fmt.Sprintf("INSERT INTO %s (value) VALUES ('bar')", o.SqlDB().QuoteIdentifier("cached"))); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n != 1 {
+		t.Errorf("expecting 1 cached object, got %d", n)
+	}
+	var obj *Cached
+	if ok := o.Table(table).MustOne(&obj); !ok || obj.Value != "foo" {
+		t.Errorf("expecting object foo, got %+v", obj)
+	}
+	// Writing with the ORM invalidates the results
+	o.MustInsert(&Cached{Value: "baz"})
+	if n := count(); n != 3 {
+		t.Errorf("expecting 3 objects after invalidation, got %d", n)
+	}
+}
+
+type Uncached struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Value string
+}
+
+func testCachedQuery(t *testing.T, o *Orm) {
+	c, err := cache.New(config.MustParseURL("memory://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.SetCache(c); err != nil {
+		t.Skip(err)
+	}
+	defer o.SetCache(nil)
+	table := o.mustRegister((*Uncached)(nil), &Options{Table: "uncached"})
+	o.mustInitialize()
+	o.MustInsert(&Uncached{Value: "foo"})
+	count := func(cached bool) int {
+		var objs []*Uncached
+		q := o.Table(table)
+		if cached {
+			q = q.Cached(time.Minute)
+		}
+		q.MustAll(&objs)
+		return len(objs)
+	}
+	if n := count(true); n != 1 {
+		t.Fatalf("expecting 1 object, got %d", n)
+	}
+	if _, err := o.SqlDB().Exec(fmt.Sprintf("INSERT INTO %s (value) VALUES ('bar')", o.SqlDB().QuoteIdentifier("uncached"))); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(true); n != 1 {
+		t.Errorf("expecting 1 cached object, got %d", n)
+	}
+	// Queries without Cached() are not cached, since the
+	// model has no CacheTimeout
+	if n := count(false); n != 2 {
+		t.Errorf("expecting 2 uncached objects, got %d", n)
+	}
+	o.MustInsert(&Uncached{Value: "baz"})
+	if n := count(true); n != 3 {
+		t.Errorf("expecting 3 objects after invalidation, got %d", n)
+	}
+}
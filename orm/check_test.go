@@ -0,0 +1,37 @@
+package orm
+
+import (
+	"testing"
+
+	"gnd.la/orm/driver"
+)
+
+type Product struct {
+	Id       int64 `orm:",primary_key,auto_increment"`
+	Price    int   `orm:",check='{} >= 0'"`
+	Discount int   `orm:",default=0,check='{} <= {Price}'"`
+}
+
+type BadCheck struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Price int   `orm:",check='{Cost} > 0'"`
+}
+
+func testCheck(t *testing.T, o *Orm) {
+	if o.Driver().Capabilities()&driver.CAP_CHECK == 0 {
+		t.Skipf("driver %T does not support CHECK constraints", o.Driver())
+	}
+	o.mustRegister((*Product)(nil), nil)
+	o.mustInitialize()
+	o.MustInsert(&Product{Price: 10, Discount: 5})
+	if _, err := o.Insert(&Product{Price: -1}); err == nil {
+		t.Error("expecting an error when inserting a negative price")
+	}
+	if _, err := o.Insert(&Product{Price: 10, Discount: 20}); err == nil {
+		t.Error("expecting an error when inserting a discount greater than the price")
+	}
+	o.mustRegister((*BadCheck)(nil), &Options{Table: "test_check_bad"})
+	if err := o.Initialize(); err == nil {
+		t.Error("expecting an error when referencing an unknown field in check")
+	}
+}
@@ -0,0 +1,51 @@
+package orm
+
+import (
+	"reflect"
+	"sync"
+)
+
+type typeComments struct {
+	table  string
+	fields map[string]string
+}
+
+var comments struct {
+	sync.RWMutex
+	types map[reflect.Type]*typeComments
+}
+
+// SetComments sets the human readable comments for the table and
+// the fields of the given type, which might be either a struct, a
+// pointer to a struct or a reflect.Type. Field comments are keyed
+// by the qualified field name (i.e. the name of the field in the Go
+// struct). Comments set with Options.Comment or the comment tag take
+// precedence over the ones provided by this function.
+//
+// Most of the time, users should not call this function directly.
+// Instead, use gondola gen with the orm option, which extracts the
+// comments from the documentation of the types and their fields. See
+// gnd.la/internal/gen for more details.
+func SetComments(t interface{}, table string, fields map[string]string) {
+	var typ reflect.Type
+	if tt, ok := t.(reflect.Type); ok {
+		typ = tt
+	} else {
+		typ = reflect.TypeOf(t)
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	comments.Lock()
+	defer comments.Unlock()
+	if comments.types == nil {
+		comments.types = make(map[reflect.Type]*typeComments)
+	}
+	comments.types[typ] = &typeComments{table: table, fields: fields}
+}
+
+func commentsFor(typ reflect.Type) *typeComments {
+	comments.RLock()
+	defer comments.RUnlock()
+	return comments.types[typ]
+}
@@ -0,0 +1,88 @@
+package orm
+
+import (
+	"strings"
+	"testing"
+
+	"gnd.la/orm/driver/sql"
+)
+
+type CommentedArticle struct {
+	Id    int64  `orm:",primary_key,auto_increment"`
+	Title string `orm:",comment='Title shown in the listing'"`
+	Body  string
+	Draft bool
+}
+
+type CommentedAuthor struct {
+	Id   int64 `orm:",primary_key,auto_increment"`
+	Name string
+}
+
+func fieldComments(table *sql.Table) map[string]string {
+	comments := make(map[string]string)
+	for _, v := range table.Fields {
+		if v.Comment != "" {
+			comments[v.Name] = v.Comment
+		}
+	}
+	return comments
+}
+
+func testComments(t *testing.T, o *Orm) {
+	if o.SqlDB() == nil {
+		t.Skip("not a database/sql driver")
+	}
+	// Comments generated by gondola gen, the ones in Options
+	// and tags take precedence.
+	SetComments((*CommentedArticle)(nil), "Generated article", map[string]string{
+		"Title": "Generated title",
+		"Body":  "Article text",
+	})
+	SetComments((*CommentedAuthor)(nil), "Article authors", map[string]string{"Name": "Full name"})
+	article := o.mustRegister((*CommentedArticle)(nil), &Options{Table: "commented_article", Comment: "Blog articles"})
+	author := o.mustRegister((*CommentedAuthor)(nil), &Options{Table: "commented_author"})
+	o.mustInitialize()
+	schema, err := o.Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		table  string
+		doc    string
+		fields map[string]string
+	}{
+		{article.model.Table(), "Blog articles", map[string]string{"title": "Title shown in the listing", "body": "Article text"}},
+		{author.model.Table(), "Article authors", map[string]string{"name": "Full name"}},
+	}
+	for _, v := range cases {
+		table := schema.Model(v.table)
+		if table == nil {
+			t.Fatalf("model table %s not found in schema", v.table)
+		}
+		if table.Comment != v.doc {
+			t.Errorf("expecting table %s comment %q, got %q", v.table, v.doc, table.Comment)
+		}
+		comments := fieldComments(table)
+		if len(comments) != len(v.fields) {
+			t.Errorf("expecting field comments %v in table %s, got %v", v.fields, v.table, comments)
+		}
+		for k, c := range v.fields {
+			if comments[k] != c {
+				t.Errorf("expecting field %s.%s comment %q, got %q", v.table, k, c, comments[k])
+			}
+		}
+	}
+	ddl, err := schema.DDL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch o.SqlDB().Backend().Name() {
+	case "mysql", "postgres":
+		for _, v := range []string{"Blog articles", "Title shown in the listing", "Article authors", "Full name"} {
+			if !strings.Contains(ddl, v) {
+				t.Errorf("expecting DDL to contain comment %q, got %s", v, ddl)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package orm
+
+import (
+	"context"
+
+	"gnd.la/orm/driver"
+)
+
+// ContextConn is implemented by drivers which can use a
+// context.Context to cancel the operations they perform
+// (the sql driver implements this interface).
+type ContextConn interface {
+	WithContext(ctx context.Context) driver.Conn
+}
+
+// WithContext returns a copy of the Orm which performs all its
+// operations, including transactions started from it, using the
+// given context.Context. Running queries are cancelled as soon as
+// ctx is done (e.g. when the request which started them finishes or
+// its deadline is exceeded), returning ctx.Err(). If the driver does
+// not implement ContextConn, the returned Orm only passes ctx to the
+// model hooks (see BeforeSaver).
+//
+// gnd.la/app binds the ORM returned by Context.Orm to the context
+// of the request it's serving.
+func (o *Orm) WithContext(ctx context.Context) *Orm {
+	if ctx == nil {
+		return o
+	}
+	cpy := *o
+	cpy.ctx = ctx
+	cc, ok := o.conn.(ContextConn)
+	if !ok {
+		return &cpy
+	}
+	conn := cc.WithContext(ctx)
+	cpy.conn = conn
+	if driver.Conn(o.driver) == o.conn {
+		if drv, ok := conn.(driver.Driver); ok {
+			cpy.driver = drv
+		}
+	}
+	return &cpy
+}
+
+// Context returns the context.Context the Orm is bound to, or
+// context.Background() if there's none. See WithContext.
+func (o *Orm) Context() context.Context {
+	if o.ctx != nil {
+		return o.ctx
+	}
+	return context.Background()
+}
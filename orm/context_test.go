@@ -0,0 +1,44 @@
+package orm
+
+import (
+	"context"
+	"testing"
+)
+
+type ContextObject struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Value string
+}
+
+func testContext(t *testing.T, o *Orm) {
+	if o.SqlDB() == nil {
+		t.Skip("not a database/sql driver")
+	}
+	table := o.mustRegister((*ContextObject)(nil), &Options{
+		Table: "test_context_object",
+	})
+	o.mustInitialize()
+	ctx, cancel := context.WithCancel(context.Background())
+	co := o.WithContext(ctx)
+	co.MustInsert(&ContextObject{Value: "a"})
+	if n := co.Table(table).MustCount(); n != 1 {
+		t.Errorf("expecting 1 object, got %d", n)
+	}
+	tx := co.MustBegin()
+	tx.MustInsert(&ContextObject{Value: "b"})
+	tx.MustCommit()
+	cancel()
+	if _, err := co.Table(table).Count(); err != context.Canceled {
+		t.Errorf("expecting context.Canceled after cancelling, got %v", err)
+	}
+	if _, err := co.Insert(&ContextObject{Value: "c"}); err != context.Canceled {
+		t.Errorf("expecting context.Canceled when inserting, got %v", err)
+	}
+	if _, err := co.Begin(); err != context.Canceled {
+		t.Errorf("expecting context.Canceled when beginning a transaction, got %v", err)
+	}
+	// The original Orm must not be affected
+	if n := o.Table(table).MustCount(); n != 2 {
+		t.Errorf("expecting 2 objects, got %d", n)
+	}
+}
@@ -0,0 +1,36 @@
+package orm
+
+import (
+	"testing"
+
+	"gnd.la/util/decimal"
+)
+
+type Priced struct {
+	Id    int64           `orm:",primary_key,auto_increment"`
+	Price decimal.Decimal `orm:",precision=12,scale=2"`
+}
+
+func testDecimal(t *testing.T, o *Orm) {
+	table := o.mustRegister((*Priced)(nil), &Options{Table: "priced"})
+	o.mustInitialize()
+	for _, v := range []string{"0.10", "0.20", "19.99"} {
+		o.MustInsert(&Priced{Price: decimal.MustParse(v)})
+	}
+	var objs []*Priced
+	o.Query(Lt("Price", decimal.MustParse("1"))).Table(table).Sort("Id", ASC).MustAll(&objs)
+	if len(objs) != 2 {
+		t.Fatalf("expecting 2 objects with price < 1, got %d", len(objs))
+	}
+	sum := objs[0].Price.Add(objs[1].Price)
+	if !sum.Equal(decimal.MustParse("0.3")) {
+		t.Errorf("expecting 0.10 + 0.20 = 0.3, got %s", sum)
+	}
+	var obj *Priced
+	if ok := o.Query(Eq("Price", decimal.MustParse("19.99"))).Table(table).MustOne(&obj); !ok {
+		t.Fatal("object with price 19.99 not found")
+	}
+	if s := obj.Price.String(); s != "19.99" {
+		t.Errorf("expecting price 19.99, got %s", s)
+	}
+}
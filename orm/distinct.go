@@ -0,0 +1,69 @@
+package orm
+
+import (
+	"gnd.la/orm/driver"
+)
+
+// Distinct makes the query return only distinct rows. It's usually
+// combined with Fields, to retrieve the distinct values of some fields
+// (e.g. all the cities where there's at least one user), e.g.
+//
+//	var users []*User
+//	err := o.Query(nil).Table(userTable).Fields("City").Distinct().All(&users)
+//
+// When the query is sorted, some backends (e.g. PostgreSQL) require
+// the sort fields to be also selected. Count also takes Distinct into
+// account, returning the number of distinct rows. To count the distinct
+// values of a single field, see Query.CountDistinct. Note that the
+// driver must support the driver.CAP_DISTINCT capability, otherwise
+// ErrNoDistinct is returned when executing the query.
+func (q *Query) Distinct() *Query {
+	q.distinct = true
+	return q
+}
+
+// distinctModel returns a copy of m which makes the
+// driver return only distinct rows, if the query is
+// distinct. Otherwise, m is returned unmodified.
+func (q *Query) distinctModel(m *joinModel) (*joinModel, error) {
+	if !q.distinct || m == nil {
+		return m, nil
+	}
+	if q.orm.driver.Capabilities()&driver.CAP_DISTINCT == 0 {
+		return nil, ErrNoDistinct
+	}
+	m = m.clone()
+	m.distinct = true
+	return m, nil
+}
+
+// CountDistinct returns the number of distinct non-null values of the
+// given field in the objects matched by the query. Like Count, the table
+// must be set before calling CountDistinct.
+func (q *Query) CountDistinct(field string) (uint64, error) {
+	if err := q.ensureTable("CountDistinct"); err != nil {
+		return 0, err
+	}
+	if q.orm.driver.Capabilities()&driver.CAP_DISTINCT == 0 {
+		return 0, ErrNoDistinct
+	}
+	var res []struct {
+		Count int64
+	}
+	if err := q.Aggregate(CountDistinct(field).As("Count")).All(&res); err != nil {
+		return 0, err
+	}
+	if len(res) == 0 {
+		return 0, nil
+	}
+	return uint64(res[0].Count), nil
+}
+
+// MustCountDistinct works like CountDistinct, but panics if there's an error.
+func (q *Query) MustCountDistinct(field string) uint64 {
+	c, err := q.CountDistinct(field)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
@@ -0,0 +1,59 @@
+package orm
+
+import (
+	"testing"
+
+	"gnd.la/orm/driver"
+)
+
+type Visited struct {
+	Id      int64 `orm:",primary_key,auto_increment"`
+	City    string
+	Country string
+}
+
+func testDistinct(t *testing.T, o *Orm) {
+	if o.Driver().Capabilities()&driver.CAP_DISTINCT == 0 {
+		t.Skipf("driver %T does not support DISTINCT", o.Driver())
+	}
+	table := o.mustRegister((*Visited)(nil), &Options{Table: "visited"})
+	o.mustInitialize()
+	for _, v := range []*Visited{
+		{City: "Madrid", Country: "Spain"},
+		{City: "Madrid", Country: "Spain"},
+		{City: "Barcelona", Country: "Spain"},
+		{City: "Paris", Country: "France"},
+		{City: "Paris", Country: "France"},
+		{City: "Paris", Country: "France"},
+	} {
+		o.MustInsert(v)
+	}
+	var cities []*Visited
+	o.Query(nil).Table(table).Fields("City").Distinct().Sort("City", ASC).MustAll(&cities)
+	if len(cities) != 3 {
+		t.Fatalf("expecting 3 distinct cities, got %d", len(cities))
+	}
+	for ii, v := range []string{"Barcelona", "Madrid", "Paris"} {
+		if cities[ii].City != v {
+			t.Errorf("expecting city %q at %d, got %q", v, ii, cities[ii].City)
+		}
+	}
+	if c := o.Query(nil).Table(table).Fields("Country").Distinct().MustCount(); c != 2 {
+		t.Errorf("expecting 2 distinct countries, got %d", c)
+	}
+	if c := o.Query(nil).Table(table).MustCount(); c != 6 {
+		t.Errorf("expecting 6 visits, got %d", c)
+	}
+	if c := o.Query(Eq("Country", "Spain")).Table(table).MustCountDistinct("City"); c != 2 {
+		t.Errorf("expecting 2 distinct cities in Spain, got %d", c)
+	}
+	var counts []struct {
+		Country           string
+		CountDistinctCity int64
+	}
+	o.Query(nil).Table(table).Sort("Country", ASC).Aggregate(CountDistinct("City")).GroupBy("Country").MustAll(&counts)
+	if len(counts) != 2 || counts[0].Country != "France" || counts[0].CountDistinctCity != 1 ||
+		counts[1].Country != "Spain" || counts[1].CountDistinctCity != 2 {
+		t.Errorf("unexpected distinct counts %+v", counts)
+	}
+}
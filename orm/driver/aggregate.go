@@ -0,0 +1,26 @@
+package driver
+
+// Aggregate represents an aggregate function applied
+// to a field (e.g. SUM(Amount)).
+type Aggregate struct {
+	// Func is the function name, one of SUM, AVG, MIN, MAX
+	// or COUNT.
+	Func string
+	// Field is the qualified name of the field. It might
+	// be empty only for COUNT, to count all the rows.
+	Field string
+	// Distinct indicates that only distinct values of the
+	// field should be considered (e.g. COUNT(DISTINCT Field)).
+	Distinct bool
+}
+
+// Rows is the interface returned by drivers which implement
+// aggregations. Each row contains the group by fields followed
+// by the aggregates, in the same order they were requested.
+// Note that *database/sql.Rows implements this interface.
+type Rows interface {
+	Next() bool
+	Scan(dest ...interface{}) error
+	Err() error
+	Close() error
+}
@@ -31,4 +31,18 @@ const (
 	CAP_DEFAULTS
 	// Can have database level defaults for TEXT fields (unbounded strings).
 	CAP_DEFAULTS_TEXT
+	// Can create partial indexes (see index.Index.Where).
+	CAP_PARTIAL_INDEX
+	// Can create indexes on expressions (see index.Index.Expressions).
+	CAP_EXPRESSION_INDEX
+	// Can return only distinct rows and count distinct values.
+	CAP_DISTINCT
+	// Can query the contents of JSON encoded fields.
+	CAP_JSON
+	// Can store slices in native array columns and match
+	// their elements (see query.Contains).
+	CAP_ARRAY
+	// Enforces CHECK constraints declared with the check
+	// tag option (e.g. `orm:",check='{} > 0'"`).
+	CAP_CHECK
 )
@@ -0,0 +1,97 @@
+// Package cockroach implements a Gondola ORM backend for CockroachDB,
+// using its PostgreSQL wire protocol compatibility.
+//
+// The URL format for this driver is:
+//
+//   - cockroach://dbname=foo user=root host=localhost port=26257 sslmode=disable
+//
+// The parameters are passed as is to github.com/lib/pq. The backend
+// reuses most of the postgres backend, including the struct tag (models
+// might use `postgres:"..."` tags to override their options for both
+// databases), but handles the following differences:
+//
+//   - auto_increment fields use SERIAL8, which CockroachDB implements
+//     using unique_rowid(). Generated ids are unique and increasing,
+//     but not sequential, and they require 64 bit integer fields.
+//   - Inserts returning ids don't retry the statement without RETURNING
+//     when it fails, since any error aborts the current transaction.
+//   - Transaction retry errors (SQLSTATE 40001, "restart transaction")
+//     are reported as retryable, so gnd.la/orm.Orm.Transaction runs
+//     the transaction again. Using Orm.Transaction rather than Begin
+//     is recommended, since CockroachDB always uses serializable
+//     isolation and conflicts are common under contention.
+package cockroach
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gnd.la/config"
+	"gnd.la/orm/driver"
+	"gnd.la/orm/driver/postgres"
+	"gnd.la/orm/driver/sql"
+	"gnd.la/util/structs"
+)
+
+var (
+	cockroachBackend = &Backend{}
+)
+
+type Backend struct {
+	postgres.Backend
+}
+
+func (b *Backend) Check(db *sql.DB) error {
+	var version string
+	if err := db.QueryRow("SELECT version()").Scan(&version); err != nil {
+		return err
+	}
+	if !strings.Contains(version, "CockroachDB") {
+		return fmt.Errorf("database is not CockroachDB (version %q), use the postgres driver instead", version)
+	}
+	return nil
+}
+
+func (b *Backend) FieldType(typ reflect.Type, t *structs.Tag) (string, error) {
+	ft, err := b.Backend.FieldType(typ, t)
+	if err != nil {
+		return "", err
+	}
+	if t.Has("auto_increment") && ft != "SERIAL8" {
+		return "", fmt.Errorf("cockroach auto_increment fields must be 64 bit integers, not %v", typ)
+	}
+	return ft, nil
+}
+
+func (b *Backend) Insert(db *sql.DB, m driver.Model, query string, args ...interface{}) (driver.Result, error) {
+	fields := m.Fields()
+	if !fields.AutoincrementPk {
+		return db.Exec(query, args...)
+	}
+	q := query + " RETURNING " + fields.MNames[fields.PrimaryKey]
+	var id int64
+	if err := db.QueryRow(q, args...).Scan(&id); err != nil {
+		return nil, err
+	}
+	return insertResult(id), nil
+}
+
+type insertResult int64
+
+func (i insertResult) LastInsertId() (int64, error) {
+	return int64(i), nil
+}
+
+func (i insertResult) RowsAffected() (int64, error) {
+	return 1, nil
+}
+
+func cockroachOpener(url *config.URL) (driver.Driver, error) {
+	return sql.NewDriver(cockroachBackend, url)
+}
+
+func init() {
+	driver.Register("cockroach", cockroachOpener)
+	driver.Register("cockroachdb", cockroachOpener)
+}
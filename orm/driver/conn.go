@@ -16,3 +16,11 @@ type Conn interface {
 	Delete(m Model, q query.Q) (Result, error)
 	Connection() interface{}
 }
+
+// NativeUpserter is implemented by drivers which can only perform
+// some upserts in a single statement (see Driver.Upserts).
+// UpsertsNatively returns true iff Upsert will be performed in
+// one statement for the given model and query.
+type NativeUpserter interface {
+	UpsertsNatively(m Model, q query.Q) bool
+}
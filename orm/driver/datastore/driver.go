@@ -211,6 +211,10 @@ func (d *Driver) applyQuery(m driver.Model, dq *datastore.Query, q query.Q) (*da
 	case *query.Gte:
 		field = &x.Field
 		op = " >="
+	case *query.Between:
+		lo := &query.Gte{Field: x.Field}
+		hi := &query.Lte{Field: query.Field{Field: x.Field.Field, Value: x.End}}
+		return d.applyQuery(m, dq, &query.And{Combinator: query.Combinator{Conditions: []query.Q{lo, hi}}})
 	case *query.And:
 		var err error
 		for _, v := range x.Conditions {
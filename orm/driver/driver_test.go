@@ -4,6 +4,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"gnd.la/util/structs"
 )
 
 func TestZero(t *testing.T) {
@@ -20,3 +22,69 @@ func TestZero(t *testing.T) {
 		}
 	}
 }
+
+func TestTimeOptions(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skip(err)
+	}
+	local := time.Date(2014, 7, 1, 12, 0, 0, 0, madrid)
+	var nilOpts *TimeOptions
+	if out := nilOpts.Out(local); !out.Equal(local) || out.Location() != madrid {
+		t.Errorf("nil options should not change time, got %v", out)
+	}
+	opts := &TimeOptions{Location: madrid}
+	out := opts.Out(local)
+	if out.Location() != time.UTC || out.Hour() != 10 {
+		t.Errorf("expecting 10:00 UTC, got %v", out)
+	}
+	if in := opts.In(out); in.Location() != madrid || !in.Equal(local) {
+		t.Errorf("expecting %v, got %v", local, in)
+	}
+	naive := &TimeOptions{Location: madrid, Naive: true}
+	out = naive.Out(local)
+	if out.Location() != time.UTC || out.Hour() != 12 {
+		t.Errorf("expecting naive 12:00 UTC, got %v", out)
+	}
+	if in := naive.In(out); in.Location() != madrid || in.Hour() != 12 || !in.Equal(local) {
+		t.Errorf("expecting naive %v, got %v", local, in)
+	}
+	if !opts.In(time.Time{}).IsZero() || !naive.Out(time.Time{}).IsZero() {
+		t.Error("zero time should be left unchanged")
+	}
+}
+
+func TestFieldTimeOptions(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skip(err)
+	}
+	global := &TimeOptions{Location: madrid, Naive: true}
+	if opts, err := FieldTimeOptions(global, structs.MustParseTag("")); err != nil || opts != global {
+		t.Errorf("expecting global options for untagged field, got %v (%v)", opts, err)
+	}
+	opts, err := FieldTimeOptions(global, structs.MustParseTag(",utc,truncate=second"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := time.Date(2014, 7, 1, 12, 0, 0, 999999, madrid)
+	out := opts.Out(local)
+	if out.Location() != time.UTC || out.Hour() != 10 || out.Nanosecond() != 0 {
+		t.Errorf("expecting truncated 10:00 UTC, got %v", out)
+	}
+	if in := opts.In(out); in.Location() != time.UTC {
+		t.Errorf("expecting UTC, got %v", in)
+	}
+	opts, err = FieldTimeOptions(nil, structs.MustParseTag(",location=Europe/Madrid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in := opts.In(out); in.Location().String() != "Europe/Madrid" || in.Hour() != 12 {
+		t.Errorf("expecting 12:00 in Madrid, got %v", in)
+	}
+	for _, v := range []string{",truncate=day", ",location=Nowhere/Invalid"} {
+		if _, err := FieldTimeOptions(nil, structs.MustParseTag(v)); err == nil {
+			t.Errorf("expecting an error with tag %s", v)
+		}
+	}
+}
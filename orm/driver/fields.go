@@ -9,6 +9,12 @@ import (
 type Reference struct {
 	Model Model
 	Field string
+	// OnDelete and OnUpdate are the referential actions
+	// (e.g. CASCADE or SET NULL) to perform when the
+	// referenced row is deleted or updated. Empty means
+	// the database default.
+	OnDelete string
+	OnUpdate string
 }
 
 type Fields struct {
@@ -35,6 +41,8 @@ type Fields struct {
 	// which might be a reflect.Func with no arguments and one
 	// return value or simply a value assignable to the field.
 	Defaults map[int]reflect.Value
+	// Allowed values for enum fields. Key is field index.
+	Enums map[int][]string
 }
 
 func (f *Fields) IsSubfield(field, parent []int) bool {
@@ -0,0 +1,24 @@
+package driver
+
+import (
+	"context"
+	"time"
+)
+
+// Interceptor is the interface implemented by types which
+// want to be notified around every database operation performed
+// by a driver, e.g. for creating tracing spans or recording
+// timings. Interceptors must be safe for concurrent use.
+type Interceptor interface {
+	// BeforeQuery is called before executing the given query with
+	// the given arguments. The returned context is used for executing
+	// the query and passed to AfterQuery, so interceptors can use it
+	// to store their state (e.g. a span). Implementations which don't
+	// need to store any state should return ctx.
+	BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context
+	// AfterQuery is called after the query has been executed, receiving
+	// the context returned by BeforeQuery, the time elapsed executing
+	// the query and its error, if any. For queries returning rows,
+	// elapsed only includes the time until the first row is available.
+	AfterQuery(ctx context.Context, query string, args []interface{}, elapsed time.Duration, err error)
+}
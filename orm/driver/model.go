@@ -29,4 +29,20 @@ type Model interface {
 	Map(qname string) (string, reflect.Type, error)
 	Skip() bool
 	Join() Join
+	// Comment returns the human readable description of the
+	// model, or an empty string if there's none.
+	Comment() string
+	// FieldComment returns the human readable description of
+	// the field with the given qualified name, or an empty string
+	// if there's none.
+	FieldComment(qname string) string
+	// CacheTimeout returns the number of seconds the results of the
+	// queries involving this model might be cached. Zero disables
+	// caching while negative values indicate no expiration.
+	CacheTimeout() int
+	// Distinct returns true if the query involving this model
+	// must return only distinct rows (see Query.Distinct in
+	// gnd.la/orm). It's only called on the first model of the
+	// query.
+	Distinct() bool
 }
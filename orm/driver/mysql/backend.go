@@ -1,8 +1,10 @@
 package mysql
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -12,23 +14,53 @@ import (
 	"gnd.la/orm/driver"
 	"gnd.la/orm/driver/sql"
 	"gnd.la/orm/index"
+	"gnd.la/util/generic"
 	"gnd.la/util/structs"
 	"gnd.la/util/types"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
-const placeholders = "?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?"
+const (
+	placeholders = "?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?"
+	// DefaultCharset is the charset used for the connection and the
+	// tables when the URL doesn't specify one. Note that MySQL's utf8
+	// can't store characters outside of the BMP (e.g. emoji).
+	DefaultCharset = "utf8mb4"
+)
 
 var (
-	mysqlBackend     = &Backend{}
 	transformedTypes = []reflect.Type{
 		reflect.TypeOf((*time.Time)(nil)),
 	}
+	identifierRe = regexp.MustCompile(`^\w+$`)
+	rowFormats   = []string{"DEFAULT", "DYNAMIC", "FIXED", "COMPRESSED", "REDUNDANT", "COMPACT"}
 )
 
+// Backend implements the MySQL backend. Besides the parameters
+// supported by github.com/go-sql-driver/mysql, the following URL
+// parameters are used when creating tables and columns:
+//
+//   - charset: its first value is used as the default charset for
+//     tables and text columns. Defaults to DefaultCharset.
+//   - collation: the collation for tables and text columns, if any.
+//   - row_format: the ROW_FORMAT for new tables (e.g. DYNAMIC), if any.
+//
+// Note that existing tables and columns are not converted.
+//
+// With utf8mb4 each character might take up to 4 bytes, so indexes
+// on VARCHAR columns (e.g. the ones created for unique fields) are
+// limited to 191 characters (767 bytes) when using the COMPACT or
+// REDUNDANT row formats. Fields with a larger max_length require the
+// DYNAMIC or COMPRESSED row formats, which allow index keys up to 3072
+// bytes (the default since MySQL 5.7.9, while older versions also
+// require innodb_large_prefix), or a charset with fewer bytes per
+// character.
 type Backend struct {
 	sql.SqlBackend
+	charset   string
+	collation string
+	rowFormat string
 }
 
 func (b *Backend) Name() string {
@@ -40,7 +72,10 @@ func (b *Backend) Tag() string {
 }
 
 func (b *Backend) Capabilities() driver.Capability {
-	return driver.CAP_NONE
+	// Functional key parts require MySQL >= 8.0.13, while CHECK
+	// constraints are enforced since 8.0.16. MySQL does not support
+	// partial indexes.
+	return driver.CAP_EXPRESSION_INDEX | driver.CAP_JSON | driver.CAP_CHECK
 }
 
 func (b *Backend) DefaultValues() string {
@@ -48,14 +83,68 @@ func (b *Backend) DefaultValues() string {
 }
 
 func (b *Backend) Inspect(db *sql.DB, m driver.Model) (*sql.Table, error) {
-	var database string
-	if err := db.QueryRow("SELECT DATABASE() FROM DUAL").Scan(&database); err != nil {
+	return b.InspectTable(db, m.Table())
+}
+
+func (b *Backend) Tables(db *sql.DB) ([]string, error) {
+	database, err := b.database(db)
+	if err != nil {
+		return nil, err
+	}
+	return b.SqlBackend.Tables(db, database)
+}
+
+func (b *Backend) InspectTable(db *sql.DB, name string) (*sql.Table, error) {
+	database, err := b.database(db)
+	if err != nil {
 		return nil, err
 	}
-	return b.SqlBackend.Inspect(db, m, database)
+	return b.SqlBackend.InspectTable(db, name, database)
+}
+
+func (b *Backend) Indexes(db *sql.DB, name string) ([]*sql.Index, error) {
+	rows, err := db.Query("SELECT INDEX_NAME, NON_UNIQUE, COLUMN_NAME FROM INFORMATION_SCHEMA.STATISTICS "+
+		"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME != 'PRIMARY' "+
+		"ORDER BY INDEX_NAME, SEQ_IN_INDEX", name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var indexes []*sql.Index
+	var idx *sql.Index
+	for rows.Next() {
+		var idxName, field string
+		var nonUnique int
+		if err := rows.Scan(&idxName, &nonUnique, &field); err != nil {
+			return nil, err
+		}
+		if idx == nil || idx.Name != idxName {
+			idx = &sql.Index{Name: idxName, Unique: nonUnique == 0}
+			indexes = append(indexes, idx)
+		}
+		idx.Fields = append(idx.Fields, field)
+	}
+	return indexes, rows.Err()
+}
+
+func (b *Backend) database(db *sql.DB) (string, error) {
+	var database string
+	err := db.QueryRow("SELECT DATABASE() FROM DUAL").Scan(&database)
+	return database, err
 }
 
 func (b *Backend) DefineField(db *sql.DB, m driver.Model, table *sql.Table, field *sql.Field) (string, []string, error) {
+	if len(field.Enum) > 0 {
+		// Use a native ENUM rather than a CHECK constraint
+		enum := field.Copy()
+		enum.Type = fmt.Sprintf("ENUM(%s)", sql.EnumValues(db, field))
+		enum.Enum = nil
+		field = enum
+	} else if kind, _ := sql.TypeKind(field.Type); kind == sql.KindChar || kind == sql.KindVarchar || kind == sql.KindText {
+		text := field.Copy()
+		text.Type += b.charsetClause(" CHARACTER SET ", " COLLATE ")
+		field = text
+	}
 	def, cons, err := b.SqlBackend.DefineField(db, m, table, field)
 	if err != nil {
 		return "", nil, err
@@ -67,10 +156,41 @@ func (b *Backend) DefineField(db *sql.DB, m driver.Model, table *sql.Table, fiel
 		refTable := ref.References.Table()
 		refField := ref.References.Field()
 		fkName := db.QuoteIdentifier(fmt.Sprintf("%s_%s_%s_%s", m.Table(), field.Name, refTable, refField))
-		cons = append(cons, fmt.Sprintf("FOREIGN KEY %s(%s) REFERENCES %s(%s)", fkName, db.QuoteIdentifier(field.Name),
-			db.QuoteIdentifier(refTable), db.QuoteIdentifier(refField)))
+		cons = append(cons, fmt.Sprintf("FOREIGN KEY %s(%s) REFERENCES %s(%s)%s", fkName, db.QuoteIdentifier(field.Name),
+			db.QuoteIdentifier(refTable), db.QuoteIdentifier(refField), ref.Actions()))
+	}
+	def = strings.Replace(def, "AUTOINCREMENT", "AUTO_INCREMENT", -1)
+	if field.Comment != "" {
+		def += " COMMENT " + db.QuoteString(field.Comment)
+	}
+	return def, cons, nil
+}
+
+func (b *Backend) TableOptions(db *sql.DB, m driver.Model, table *sql.Table) (string, error) {
+	opts := b.charsetClause("DEFAULT CHARSET=", " COLLATE=")
+	if b.rowFormat != "" {
+		opts += " ROW_FORMAT=" + b.rowFormat
+	}
+	return opts, nil
+}
+
+// charsetClause returns the charset and, if set, the collation
+// prefixed by the given strings.
+func (b *Backend) charsetClause(charset string, collate string) string {
+	s := charset + b.charset
+	if b.collation != "" {
+		s += collate + b.collation
+	}
+	return s
+}
+
+func (b *Backend) Comment(db *sql.DB, m driver.Model, table *sql.Table, field *sql.Field) error {
+	if field != nil {
+		// Field comments are defined inline by DefineField
+		return nil
 	}
-	return strings.Replace(def, "AUTOINCREMENT", "AUTO_INCREMENT", -1), cons, nil
+	_, err := db.Exec(fmt.Sprintf("ALTER TABLE %s COMMENT = %s", db.QuoteIdentifier(m.Table()), db.QuoteString(table.Comment)))
+	return err
 }
 
 func (b *Backend) AlterField(db *sql.DB, m driver.Model, table *sql.Table, oldField *sql.Field, newField *sql.Field) error {
@@ -90,6 +210,63 @@ func (b *Backend) AlterField(db *sql.DB, m driver.Model, table *sql.Table, oldFi
 	return err
 }
 
+func (b *Backend) Upserts() bool {
+	return true
+}
+
+func (b *Backend) IsRetryable(err error) bool {
+	var merr *mysql.MySQLError
+	if errors.As(err, &merr) {
+		// ER_LOCK_DEADLOCK and ER_LOCK_WAIT_TIMEOUT
+		return merr.Number == 1213 || merr.Number == 1205
+	}
+	return false
+}
+
+// UpsertClause returns an ON DUPLICATE KEY UPDATE clause. Since MySQL
+// detects the conflict using any unique key, rather than just the conflict
+// fields, ErrUpsertNotSupported is returned when the model has any other
+// unique keys. The auto_increment primary key is ignored, since it's only
+// included in the INSERT when it's non-zero. Its value is passed to
+// LAST_INSERT_ID(), so the id is also reported when a row is updated.
+func (b *Backend) UpsertClause(db *sql.DB, m driver.Model, conflict []string, update []string) (string, error) {
+	fields := m.Fields()
+	var pk string
+	if fields.AutoincrementPk {
+		pk = fields.MNames[fields.PrimaryKey]
+	}
+	for _, v := range sql.UniqueKeys(m) {
+		if len(v) == 1 && v[0] == pk {
+			continue
+		}
+		if len(v) != len(conflict) {
+			return "", sql.ErrUpsertNotSupported
+		}
+		for _, f := range conflict {
+			if !generic.Contains(v, f) {
+				return "", sql.ErrUpsertNotSupported
+			}
+		}
+	}
+	var assignments []string
+	if pk != "" {
+		name := db.QuoteIdentifier(pk)
+		assignments = append(assignments, fmt.Sprintf("%s = LAST_INSERT_ID(%s)", name, name))
+	} else if len(update) == 0 {
+		// Assigning a column to itself makes the insert a no-op
+		name := db.QuoteIdentifier(conflict[0])
+		assignments = append(assignments, fmt.Sprintf("%s = %s", name, name))
+	}
+	for _, v := range update {
+		if v == pk {
+			continue
+		}
+		name := db.QuoteIdentifier(v)
+		assignments = append(assignments, fmt.Sprintf("%s = VALUES(%s)", name, name))
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", "), nil
+}
+
 func (b *Backend) HasIndex(db *sql.DB, m driver.Model, idx *index.Index, name string) (bool, error) {
 	rows, err := db.Query("SHOW INDEX FROM ? WHERE Key_name = ?", m.Table(), name)
 	if err != nil {
@@ -147,7 +324,24 @@ func (b *Backend) FieldType(typ reflect.Type, t *structs.Tag) (string, error) {
 		}
 	case reflect.Struct:
 		if typ.Name() == "Time" && typ.PkgPath() == "time" {
-			ft = "DATETIME"
+			// Keep microseconds, like the other backends do
+			if t.Has("timezone") {
+				// TIMESTAMP values are converted from the
+				// session time zone to UTC for storage. Note
+				// that they only cover from 1970 to 2038 (so
+				// zero time.Time values can't be stored) and,
+				// unless explicit_defaults_for_timestamp is
+				// enabled, the first TIMESTAMP column in a table
+				// is set to the current time on every UPDATE
+				// which doesn't assign it.
+				ft = "TIMESTAMP(6)"
+			} else {
+				ft = "DATETIME(6)"
+			}
+		} else if sql.IsDecimal(typ) {
+			// MySQL defaults to DECIMAL (10, 0), which would
+			// discard the fractional part.
+			ft = sql.DecimalType("DECIMAL", t, 19, 4)
 		}
 	}
 	if ft != "" {
@@ -196,13 +390,50 @@ func (b *Backend) TransformOutValue(val reflect.Value) (interface{}, error) {
 	return i, nil
 }
 
+func newBackend(url *config.URL) (*Backend, error) {
+	b := &Backend{
+		charset:   DefaultCharset,
+		collation: url.Query["collation"],
+	}
+	if charset := url.Query["charset"]; charset != "" {
+		// The driver accepts a list of charsets, using
+		// the first one supported by the server.
+		b.charset = strings.TrimSpace(strings.Split(charset, ",")[0])
+	}
+	if !identifierRe.MatchString(b.charset) {
+		return nil, fmt.Errorf("invalid MySQL charset %q", b.charset)
+	}
+	if b.collation != "" && !identifierRe.MatchString(b.collation) {
+		return nil, fmt.Errorf("invalid MySQL collation %q", b.collation)
+	}
+	if rowFormat := url.Query["row_format"]; rowFormat != "" {
+		b.rowFormat = strings.ToUpper(rowFormat)
+		valid := false
+		for _, v := range rowFormats {
+			valid = valid || v == b.rowFormat
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid MySQL row format %q, must be one of %s", rowFormat, strings.Join(rowFormats, ", "))
+		}
+		// Not a connection parameter, don't pass it to the driver
+		delete(url.Query, "row_format")
+	}
+	return b, nil
+}
+
 func mysqlOpener(url *config.URL) (driver.Driver, error) {
-	url.Query["charset"] = "UTF8"
+	b, err := newBackend(url)
+	if err != nil {
+		return nil, err
+	}
+	if url.Query["charset"] == "" {
+		url.Query["charset"] = DefaultCharset
+	}
 	url.Query["sql_mode"] = "ANSI"
 	url.Query["parseTime"] = "true"
 	url.Query["loc"] = "UTC"
 	url.Query["clientFoundRows"] = "true"
-	return sql.NewDriver(mysqlBackend, url)
+	return sql.NewDriver(b, url)
 }
 
 func init() {
@@ -0,0 +1,89 @@
+package mysql
+
+import (
+	"testing"
+
+	"gnd.la/config"
+	"gnd.la/orm/driver/sql"
+)
+
+func TestBackendOptions(t *testing.T) {
+	cases := []struct {
+		url       string
+		charset   string
+		collation string
+		rowFormat string
+		options   string
+	}{
+		{"mysql://gotest@/test", DefaultCharset, "", "", "DEFAULT CHARSET=utf8mb4"},
+		{"mysql://gotest@/test?charset=latin1,utf8", "latin1", "", "", "DEFAULT CHARSET=latin1"},
+		{"mysql://gotest@/test?collation=utf8mb4_bin", DefaultCharset, "utf8mb4_bin", "", "DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin"},
+		{"mysql://gotest@/test?row_format=dynamic", DefaultCharset, "", "DYNAMIC", "DEFAULT CHARSET=utf8mb4 ROW_FORMAT=DYNAMIC"},
+		{"mysql://gotest@/test?charset=utf8&collation=utf8_general_ci&row_format=Compressed", "utf8", "utf8_general_ci", "COMPRESSED",
+			"DEFAULT CHARSET=utf8 COLLATE=utf8_general_ci ROW_FORMAT=COMPRESSED"},
+	}
+	for _, v := range cases {
+		url := config.MustParseURL(v.url)
+		b, err := newBackend(url)
+		if err != nil {
+			t.Errorf("error parsing %s: %s", v.url, err)
+			continue
+		}
+		if b.charset != v.charset || b.collation != v.collation || b.rowFormat != v.rowFormat {
+			t.Errorf("expecting charset %q, collation %q and row format %q from %s, got %q, %q and %q",
+				v.charset, v.collation, v.rowFormat, v.url, b.charset, b.collation, b.rowFormat)
+		}
+		if _, ok := url.Query["row_format"]; ok {
+			t.Errorf("row_format not removed from %s", v.url)
+		}
+		opts, err := b.TableOptions(nil, nil, nil)
+		if err != nil {
+			t.Error(err)
+		} else if opts != v.options {
+			t.Errorf("expecting table options %q from %s, got %q", v.options, v.url, opts)
+		}
+	}
+}
+
+func TestInvalidBackendOptions(t *testing.T) {
+	invalid := []string{
+		"mysql://gotest@/test?charset=utf8%27--",
+		"mysql://gotest@/test?collation=utf8_bin%20x",
+		"mysql://gotest@/test?row_format=SPARSE",
+	}
+	for _, v := range invalid {
+		if _, err := newBackend(config.MustParseURL(v)); err == nil {
+			t.Errorf("expecting an error from %s", v)
+		}
+	}
+}
+
+func TestDefineTextField(t *testing.T) {
+	drv, err := mysqlOpener(config.MustParseURL("mysql://gotest@/test?collation=utf8mb4_bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer drv.Close()
+	db := drv.(*sql.Driver).DB()
+	b := db.Backend()
+	table := &sql.Table{Name: "test_mysql"}
+	cases := []struct {
+		field *sql.Field
+		def   string
+	}{
+		{&sql.Field{Name: "Name", Type: "VARCHAR (191)"}, `"Name" VARCHAR (191) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin`},
+		{&sql.Field{Name: "Code", Type: "CHAR (2)"}, `"Code" CHAR (2) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin`},
+		{&sql.Field{Name: "Body", Type: "TEXT"}, `"Body" TEXT CHARACTER SET utf8mb4 COLLATE utf8mb4_bin`},
+		{&sql.Field{Name: "Count", Type: "BIGINT"}, `"Count" BIGINT`},
+	}
+	for _, v := range cases {
+		def, _, err := b.DefineField(db, nil, table, v.field)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if def != v.def {
+			t.Errorf("expecting definition %q, got %q", v.def, def)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package mysql
+
+import (
+	"fmt"
+
+	"gnd.la/orm/driver/sql"
+	"gnd.la/util/geo"
+)
+
+// Near uses ST_Distance_Sphere, which requires MySQL >= 5.7.6.
+func (b *Backend) Near(db *sql.DB, lat string, lng string, p geo.Point, radius float64, n int) (string, []interface{}, error) {
+	cond := fmt.Sprintf("ST_Distance_Sphere(POINT(%s, %s), POINT(?, ?), %v) <= ?", lng, lat, geo.EarthRadius)
+	return cond, []interface{}{p.Lng, p.Lat, radius}, nil
+}
@@ -0,0 +1,12 @@
+package mysql
+
+import (
+	"fmt"
+
+	"gnd.la/orm/driver/sql"
+)
+
+// JSONContains uses JSON_CONTAINS, which requires MySQL >= 5.7.8.
+func (b *Backend) JSONContains(db *sql.DB, column string, doc string, n int) (string, []interface{}, error) {
+	return fmt.Sprintf("JSON_CONTAINS(%s, %s)", column, b.Placeholder(n)), []interface{}{doc}, nil
+}
@@ -0,0 +1,26 @@
+package driver
+
+import (
+	"strings"
+)
+
+// Plan is the execution plan for a query, as returned by
+// the database.
+type Plan struct {
+	// Columns contains the names of the columns returned
+	// by the database.
+	Columns []string
+	// Rows contains the returned rows, with a value for each
+	// column. NULL values are represented as empty strings.
+	Rows [][]string
+}
+
+// String returns the plan as text, with a line per row and
+// the row columns separated by tabs.
+func (p *Plan) String() string {
+	lines := make([]string, len(p.Rows))
+	for ii, v := range p.Rows {
+		lines[ii] = strings.Join(v, "\t")
+	}
+	return strings.Join(lines, "\n")
+}
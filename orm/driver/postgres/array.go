@@ -0,0 +1,14 @@
+package postgres
+
+import (
+	"fmt"
+
+	"gnd.la/orm/driver/sql"
+)
+
+// ArrayContains uses the ANY operator, which can't use indexes. Tables
+// which require fast queries on array elements should define a GIN
+// index and query them with the @> operator.
+func (b *Backend) ArrayContains(db *sql.DB, column string, operand string) (string, error) {
+	return fmt.Sprintf("%s = ANY(%s)", operand, column), nil
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -15,7 +16,7 @@ import (
 	"gnd.la/orm/index"
 	"gnd.la/util/structs"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 const placeholders = "$1 ,$2 ,$3 ,$4 ,$5 ,$6 ,$7 ,$8 ,$9 ,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,$26,$27,$28,$29,$30,$31,$32"
@@ -24,6 +25,13 @@ var (
 	postgresBackend  = &Backend{}
 	transformedTypes = []reflect.Type{
 		reflect.TypeOf((*time.Time)(nil)),
+		// Slices stored as arrays, must match sql.IsArray
+		reflect.TypeOf((*[]bool)(nil)),
+		reflect.TypeOf((*[]int32)(nil)),
+		reflect.TypeOf((*[]int64)(nil)),
+		reflect.TypeOf((*[]float32)(nil)),
+		reflect.TypeOf((*[]float64)(nil)),
+		reflect.TypeOf((*[]string)(nil)),
 	}
 )
 
@@ -39,6 +47,10 @@ func (b *Backend) Tag() string {
 	return b.Name()
 }
 
+func (b *Backend) Capabilities() driver.Capability {
+	return b.SqlBackend.Capabilities() | driver.CAP_JSON | driver.CAP_ARRAY
+}
+
 func (b *Backend) Placeholder(n int) string {
 	return "$" + strconv.Itoa(n+1)
 }
@@ -59,10 +71,55 @@ func (b *Backend) Func(fname string, retType reflect.Type) (string, error) {
 }
 
 func (b *Backend) Inspect(db *sql.DB, m driver.Model) (*sql.Table, error) {
-	return b.SqlBackend.Inspect(db, m, "public")
+	return b.InspectTable(db, m.Table())
+}
+
+func (b *Backend) Tables(db *sql.DB) ([]string, error) {
+	return b.SqlBackend.Tables(db, "public")
+}
+
+func (b *Backend) InspectTable(db *sql.DB, name string) (*sql.Table, error) {
+	return b.SqlBackend.InspectTable(db, name, "public")
+}
+
+func (b *Backend) Indexes(db *sql.DB, name string) ([]*sql.Index, error) {
+	rows, err := db.Query("SELECT i.relname, ix.indisunique, a.attname FROM pg_class t "+
+		"JOIN pg_index ix ON t.oid = ix.indrelid JOIN pg_class i ON i.oid = ix.indexrelid "+
+		"JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey) "+
+		"WHERE t.relkind = 'r' AND t.relname = ? AND NOT ix.indisprimary "+
+		"ORDER BY i.relname, array_position(ix.indkey::int2[], a.attnum)", name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var indexes []*sql.Index
+	var idx *sql.Index
+	for rows.Next() {
+		var idxName, field string
+		var unique bool
+		if err := rows.Scan(&idxName, &unique, &field); err != nil {
+			return nil, err
+		}
+		if idx == nil || idx.Name != idxName {
+			idx = &sql.Index{Name: idxName, Unique: unique}
+			indexes = append(indexes, idx)
+		}
+		idx.Fields = append(idx.Fields, field)
+	}
+	return indexes, rows.Err()
 }
 
 func (b *Backend) DefineField(db *sql.DB, m driver.Model, table *sql.Table, field *sql.Field) (string, []string, error) {
+	if len(field.Enum) > 0 {
+		typ, err := b.enumType(db, m, field)
+		if err != nil {
+			return "", nil, err
+		}
+		enum := field.Copy()
+		enum.Type = typ
+		enum.Enum = nil
+		field = enum
+	}
 	def, con, err := b.SqlBackend.DefineField(db, m, table, field)
 	if err != nil {
 		return "", nil, err
@@ -71,6 +128,16 @@ func (b *Backend) DefineField(db *sql.DB, m driver.Model, table *sql.Table, fiel
 	return strings.Replace(def, " AUTOINCREMENT", "", -1), con, nil
 }
 
+func (b *Backend) Comment(db *sql.DB, m driver.Model, table *sql.Table, field *sql.Field) error {
+	if field != nil {
+		_, err := db.Exec(fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", db.QuoteIdentifier(m.Table()),
+			db.QuoteIdentifier(field.Name), db.QuoteString(field.Comment)))
+		return err
+	}
+	_, err := db.Exec(fmt.Sprintf("COMMENT ON TABLE %s IS %s", db.QuoteIdentifier(m.Table()), db.QuoteString(table.Comment)))
+	return err
+}
+
 func (b *Backend) Insert(db *sql.DB, m driver.Model, query string, args ...interface{}) (driver.Result, error) {
 	fields := m.Fields()
 	if fields.AutoincrementPk {
@@ -87,6 +154,51 @@ func (b *Backend) Insert(db *sql.DB, m driver.Model, query string, args ...inter
 	return db.Exec(query, args...)
 }
 
+func (b *Backend) Upserts() bool {
+	return true
+}
+
+func (b *Backend) Returning() bool {
+	return true
+}
+
+func (b *Backend) IsRetryable(err error) bool {
+	var perr *pq.Error
+	if errors.As(err, &perr) {
+		// serialization_failure and deadlock_detected
+		return perr.Code == "40001" || perr.Code == "40P01"
+	}
+	return false
+}
+
+func (b *Backend) UpsertClause(db *sql.DB, m driver.Model, conflict []string, update []string) (string, error) {
+	var buf bytes.Buffer
+	buf.WriteString("ON CONFLICT (")
+	for ii, v := range conflict {
+		if ii > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(db.QuoteIdentifier(v))
+	}
+	buf.WriteByte(')')
+	if len(update) == 0 {
+		// Use a no-op update rather than DO NOTHING, so
+		// RETURNING still reports the id of the row.
+		update = conflict[:1]
+	}
+	buf.WriteString(" DO UPDATE SET ")
+	for ii, v := range update {
+		if ii > 0 {
+			buf.WriteByte(',')
+		}
+		name := db.QuoteIdentifier(v)
+		buf.WriteString(name)
+		buf.WriteString(" = EXCLUDED.")
+		buf.WriteString(name)
+	}
+	return buf.String(), nil
+}
+
 func (b *Backend) HasIndex(db *sql.DB, m driver.Model, idx *index.Index, name string) (bool, error) {
 	var exists int
 	err := db.QueryRow("SELECT 1 FROM pg_class WHERE relname = $1 AND relkind = 'i'", name).Scan(&exists)
@@ -98,10 +210,12 @@ func (b *Backend) HasIndex(db *sql.DB, m driver.Model, idx *index.Index, name st
 
 func (b *Backend) FieldType(typ reflect.Type, t *structs.Tag) (string, error) {
 	if c := codec.FromTag(t); c != nil {
-		// TODO: Use type JSON on Postgresql >= 9.2 for JSON encoded fields
 		if c.Binary || t.PipeName() != "" {
 			return "BYTEA", nil
 		}
+		if t.CodecName() == "json" {
+			return "JSONB", nil
+		}
 		return "TEXT", nil
 	}
 	var ft string
@@ -137,18 +251,25 @@ func (b *Backend) FieldType(typ reflect.Type, t *structs.Tag) (string, error) {
 		if etyp.Kind() == reflect.Uint8 {
 			// []byte
 			ft = "BYTEA"
-			// TODO: database/sql does not support array types. Enable this code
-			// if that changes in the future
-			//		} else if typ.Elem().Kind() != reflect.Struct {
-			//			et, err := b.FieldType(typ.Elem(), tag)
-			//			if err != nil {
-			//				return "", err
-			//			}
-			//			t = et + "[]"
+		} else if sql.IsArray(typ) {
+			et, err := b.FieldType(etyp, &structs.Tag{})
+			if err != nil {
+				return "", err
+			}
+			ft = et + "[]"
+		} else {
+			return "", fmt.Errorf("can't map field type %v to an array, use a codec or one of []bool, []int32, []int64, []float32, []float64 or []string", typ)
 		}
 	case reflect.Struct:
 		if typ.Name() == "Time" && typ.PkgPath() == "time" {
-			ft = "TIMESTAMP WITHOUT TIME ZONE"
+			if t.Has("timezone") {
+				ft = "TIMESTAMP WITH TIME ZONE"
+			} else {
+				ft = "TIMESTAMP WITHOUT TIME ZONE"
+			}
+		} else if sql.IsDecimal(typ) {
+			// NUMERIC without precision stores any value exactly
+			ft = sql.DecimalType("NUMERIC", t, 0, 0)
 		}
 	}
 	if t.Has("auto_increment") {
@@ -173,7 +294,22 @@ func (b *Backend) ScanTime(val *time.Time, goVal *reflect.Value, t *structs.Tag)
 	return nil
 }
 
+func (b *Backend) ScanByteSlice(val []byte, goVal *reflect.Value, t *structs.Tag) error {
+	if sql.IsArray(goVal.Type()) {
+		return pq.Array(goVal.Addr().Interface()).Scan(val)
+	}
+	return b.SqlBackend.ScanByteSlice(val, goVal, t)
+}
+
 func (b *Backend) TransformOutValue(val reflect.Value) (interface{}, error) {
+	if val.Kind() == reflect.Slice {
+		if val.IsNil() {
+			// Store nil slices as empty arrays, so
+			// they can be saved in NOT NULL columns.
+			val = reflect.MakeSlice(val.Type(), 0, 0)
+		}
+		return pq.Array(val.Interface()), nil
+	}
 	return val.Interface().(time.Time).UTC(), nil
 }
 
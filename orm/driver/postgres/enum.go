@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"fmt"
+
+	"gnd.la/orm/driver"
+	"gnd.la/orm/driver/sql"
+)
+
+// enumType returns the name of the ENUM type used for the given
+// field, named after its table and column, creating it if it does
+// not exist yet. Values missing from an existing type are added
+// to it, but values which are no longer used are never removed.
+func (b *Backend) enumType(db *sql.DB, m driver.Model, field *sql.Field) (string, error) {
+	name := fmt.Sprintf("%s_%s", m.Table(), field.Name)
+	quoted := db.QuoteIdentifier(name)
+	rows, err := db.Query("SELECT e.enumlabel FROM pg_enum e JOIN pg_type t ON e.enumtypid = t.oid WHERE t.typname = $1", name)
+	if err != nil {
+		return "", err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var label string
+		if err := rows.Scan(&label); err != nil {
+			rows.Close()
+			return "", err
+		}
+		existing[label] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	if len(existing) == 0 {
+		if _, err := db.Exec(fmt.Sprintf("CREATE TYPE %s AS ENUM (%s)", quoted, sql.EnumValues(db, field))); err != nil {
+			return "", err
+		}
+		return quoted, nil
+	}
+	for _, v := range field.Enum {
+		if !existing[v] {
+			if _, err := db.Exec(fmt.Sprintf("ALTER TYPE %s ADD VALUE %s", quoted, db.QuoteString(v))); err != nil {
+				return "", err
+			}
+		}
+	}
+	return quoted, nil
+}
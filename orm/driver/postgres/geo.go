@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	stdsql "database/sql"
+	"fmt"
+	"sync"
+
+	"gnd.la/orm/driver/sql"
+	"gnd.la/util/geo"
+)
+
+var postgis struct {
+	sync.Mutex
+	available map[*stdsql.DB]bool
+}
+
+// hasPostGIS returns true iff the PostGIS extension is
+// installed in the given database. The result is cached.
+func hasPostGIS(db *sql.DB) (bool, error) {
+	postgis.Lock()
+	defer postgis.Unlock()
+	if ok, found := postgis.available[db.DB()]; found {
+		return ok, nil
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM pg_extension WHERE extname = 'postgis'").Scan(&count); err != nil {
+		return false, err
+	}
+	if postgis.available == nil {
+		postgis.available = make(map[*stdsql.DB]bool)
+	}
+	postgis.available[db.DB()] = count > 0
+	return count > 0, nil
+}
+
+// Near uses ST_DWithin with the geography type when PostGIS is
+// available, falling back to the haversine formula otherwise.
+func (b *Backend) Near(db *sql.DB, lat string, lng string, p geo.Point, radius float64, n int) (string, []interface{}, error) {
+	ok, err := hasPostGIS(db)
+	if err != nil {
+		return "", nil, err
+	}
+	if !ok {
+		return b.SqlBackend.Near(db, lat, lng, p, radius, n)
+	}
+	cond := fmt.Sprintf("ST_DWithin(ST_SetSRID(ST_MakePoint(%s, %s), 4326)::geography, "+
+		"ST_SetSRID(ST_MakePoint(%s, %s), 4326)::geography, %s)",
+		lng, lat, b.Placeholder(n), b.Placeholder(n+1), b.Placeholder(n+2))
+	return cond, []interface{}{p.Lng, p.Lat, radius}, nil
+}
@@ -0,0 +1,14 @@
+package postgres
+
+import (
+	"fmt"
+
+	"gnd.la/orm/driver/sql"
+)
+
+// JSONContains uses the @> operator. The column is cast to JSONB,
+// so fields created as TEXT by previous versions can also be queried,
+// but only JSONB columns can use indexes.
+func (b *Backend) JSONContains(db *sql.DB, column string, doc string, n int) (string, []interface{}, error) {
+	return fmt.Sprintf("%s::jsonb @> %s::jsonb", column, b.Placeholder(n)), []interface{}{doc}, nil
+}
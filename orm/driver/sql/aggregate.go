@@ -0,0 +1,54 @@
+package sql
+
+import (
+	"fmt"
+
+	"gnd.la/orm/driver"
+	"gnd.la/orm/query"
+)
+
+// Aggregate runs a query which groups the rows matching q by the
+// given fields and computes the given aggregates for each group.
+// The returned rows contain the values of the groupBy fields
+// followed by the aggregates.
+func (d *Driver) Aggregate(m driver.Model, q query.Q, groupBy []string, aggs []*driver.Aggregate, sort []driver.Sort, limit int, offset int) (driver.Rows, error) {
+	var group []string
+	for _, v := range groupBy {
+		name, _, err := m.Map(v)
+		if err != nil {
+			return nil, err
+		}
+		group = append(group, name)
+	}
+	columns := append([]string(nil), group...)
+	for _, v := range aggs {
+		switch v.Func {
+		case "SUM", "AVG", "MIN", "MAX", "COUNT":
+		default:
+			return nil, fmt.Errorf("invalid aggregate function %q", v.Func)
+		}
+		if v.Field == "" {
+			if v.Func != "COUNT" {
+				return nil, fmt.Errorf("aggregate function %s requires a field", v.Func)
+			}
+			columns = append(columns, "COUNT(*)")
+			continue
+		}
+		name, _, err := m.Map(v.Field)
+		if err != nil {
+			return nil, err
+		}
+		if v.Distinct {
+			columns = append(columns, v.Func+"(DISTINCT "+name+")")
+		} else {
+			columns = append(columns, v.Func+"("+name+")")
+		}
+	}
+	buf, params, err := d.selectGroup(columns, false, m, q, group, sort, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	query := buftos(buf)
+	putBuffer(buf)
+	return d.query(m, query, params, limit)
+}
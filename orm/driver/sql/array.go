@@ -0,0 +1,77 @@
+package sql
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"gnd.la/encoding/codec"
+	"gnd.la/orm/driver"
+	"gnd.la/orm/query"
+)
+
+// ArrayContains returns an error, since there's no standard SQL
+// for arrays.
+func (b *SqlBackend) ArrayContains(db *DB, column string, operand string) (string, error) {
+	return "", fmt.Errorf("backend %s does not support arrays", db.Backend().Name())
+}
+
+// arrayTypes are the slice types which can be stored in
+// native array columns and scanned back from them.
+var arrayTypes = []reflect.Type{
+	reflect.TypeOf([]bool(nil)),
+	reflect.TypeOf([]int32(nil)),
+	reflect.TypeOf([]int64(nil)),
+	reflect.TypeOf([]float32(nil)),
+	reflect.TypeOf([]float64(nil)),
+	reflect.TypeOf([]string(nil)),
+}
+
+// IsArray returns true iff the given type is stored in a native array
+// column by backends with driver.CAP_ARRAY, unless it uses a codec.
+// Only []bool, []int32, []int64, []float32, []float64 and []string
+// are stored as arrays. Other slices (e.g. []int or slices of named
+// types) require a codec.
+func IsArray(typ reflect.Type) bool {
+	for _, v := range arrayTypes {
+		if v == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// isArray returns true iff the field with the given qualified
+// name is stored in an array column.
+func (d *Driver) isArray(m driver.Model, qname string) bool {
+	if d.backend.Capabilities()&driver.CAP_ARRAY == 0 {
+		return false
+	}
+	_, typ, err := m.Map(qname)
+	if err != nil || !IsArray(typ) {
+		return false
+	}
+	if fields := m.Fields(); fields != nil {
+		if idx, ok := fields.QNameMap[qname]; ok && codec.FromTag(fields.Tags[idx]) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *Driver) arrayContains(buf *bytes.Buffer, params *[]interface{}, m driver.Model, f *query.Field, begin int) error {
+	column, _, err := m.Map(f.Field)
+	if err != nil {
+		return err
+	}
+	op, err := d.operand(params, m, f.Field, f.Value, begin)
+	if err != nil {
+		return err
+	}
+	cond, err := d.backend.ArrayContains(d.db, column, op)
+	if err != nil {
+		return err
+	}
+	buf.WriteString(cond)
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"gnd.la/orm/driver"
 	"gnd.la/orm/index"
 	"gnd.la/util/generic"
+	"gnd.la/util/geo"
 	"gnd.la/util/structs"
 	"gnd.la/util/types"
 )
@@ -42,6 +43,13 @@ type Backend interface {
 	// Inspect returns the table as it exists in the database for the current model. If
 	// the table does not exist, the Backend is expected to return (nil, nil).
 	Inspect(*DB, driver.Model) (*Table, error)
+	// Tables returns the names of all the tables in the current database.
+	Tables(*DB) ([]string, error)
+	// InspectTable works like Inspect, but receives the table name rather than a model.
+	InspectTable(*DB, string) (*Table, error)
+	// Indexes returns the indexes defined for the table with the given name. Indexes created
+	// implicitly for PRIMARY KEY constraints should not be included.
+	Indexes(*DB, string) ([]*Index, error)
 	// HasIndex returns wheter an index exists using the provided model, index and name.
 	HasIndex(*DB, driver.Model, *index.Index, string) (bool, error)
 	// DefineField returns the complete field definition as a string, including name, type, options...
@@ -53,6 +61,30 @@ type Backend interface {
 	AddFields(db *DB, m driver.Model, prevTable *Table, newTable *Table, fields []*Field) error
 	// Alter field changes oldField to newField, potentially including the name.
 	AlterField(db *DB, m driver.Model, table *Table, oldField *Field, newField *Field) error
+	// Comment stores the comment for the given table or, if field is non-nil, for the given
+	// field. It's only called for tables and fields with a non-empty comment after they're
+	// created. Backends which define comments inline (e.g. in DefineField) or which don't
+	// support them should just return nil.
+	Comment(db *DB, m driver.Model, table *Table, field *Field) error
+	// TableOptions returns the options appended to the CREATE TABLE
+	// statement for the given table (e.g. its default charset), or
+	// an empty string if there are none.
+	TableOptions(db *DB, m driver.Model, table *Table) (string, error)
+	// Upserts returns true iff the backend supports performing upserts in
+	// a single statement. See UpsertClause.
+	Upserts() bool
+	// Returning returns true iff the backend supports RETURNING clauses
+	// in UPDATE and DELETE statements. See Driver.UpdateReturning.
+	Returning() bool
+	// UpsertClause returns the clause appended to an INSERT statement which
+	// updates the given fields when there's already a row with the same values
+	// for the conflict fields. update might be empty. It's only called when
+	// Upserts returns true and conflict matches one of the unique keys of the
+	// model (see UniqueKeys). Backends which can't perform the upsert in a
+	// single statement for the given model and fields must return
+	// ErrUpsertNotSupported, making Upsert fall back to an update followed
+	// by an insert.
+	UpsertClause(db *DB, m driver.Model, conflict []string, update []string) (string, error)
 	// Insert performs an insert on the given database for the given model fields.
 	// Most drivers should just return db.Exec(query, args...).
 	Insert(*DB, driver.Model, string, ...interface{}) (driver.Result, error)
@@ -74,6 +106,31 @@ type Backend interface {
 	ScanTime(val *time.Time, goVal *reflect.Value, t *structs.Tag) error
 	// Transform a value from Go to the database
 	TransformOutValue(reflect.Value) (interface{}, error)
+	// Near returns a condition which is true iff the point stored in the given
+	// latitude and longitude columns is within radius meters of p, as well as
+	// its parameters. The first parameter must use the n'th placeholder.
+	Near(db *DB, lat string, lng string, p geo.Point, radius float64, n int) (string, []interface{}, error)
+	// JSONContains returns a condition which is true iff the JSON document
+	// stored in the given column contains doc, as well as its parameters.
+	// The first parameter must use the n'th placeholder. Backends which don't
+	// support JSON queries should return an error.
+	JSONContains(db *DB, column string, doc string, n int) (string, []interface{}, error)
+	// ArrayContains returns a condition which is true iff the array stored
+	// in the given column contains the given operand, which is either a
+	// placeholder or an expression. It's only called by backends which
+	// declare driver.CAP_ARRAY.
+	ArrayContains(db *DB, column string, operand string) (string, error)
+	// Explain returns the statement prepended to a query in order to
+	// obtain its execution plan (e.g. EXPLAIN). If analyze is true, the
+	// returned statement should also execute the query and report the
+	// actual timings. Backends which can't analyze queries should return
+	// an error in that case.
+	Explain(db *DB, analyze bool) (string, error)
+	// IsRetryable returns true iff the given error indicates that
+	// the transaction it was produced in failed due to a transient
+	// conflict with another transaction (e.g. a serialization failure
+	// or a deadlock) and running it again might succeed.
+	IsRetryable(err error) bool
 }
 
 const placeholders = "?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?"
@@ -86,7 +143,7 @@ func (b *SqlBackend) Check(_ *DB) error {
 }
 
 func (b *SqlBackend) Capabilities() driver.Capability {
-	return driver.CAP_DEFAULTS_TEXT
+	return driver.CAP_DEFAULTS_TEXT | driver.CAP_PARTIAL_INDEX | driver.CAP_EXPRESSION_INDEX | driver.CAP_CHECK
 }
 
 func (b *SqlBackend) Placeholder(n int) string {
@@ -113,13 +170,51 @@ func (b *SqlBackend) Func(fname string, retType reflect.Type) (string, error) {
 	return "", ErrFuncNotSupported
 }
 
+func (b *SqlBackend) Upserts() bool {
+	return false
+}
+
+func (b *SqlBackend) IsRetryable(err error) bool {
+	return false
+}
+
+func (b *SqlBackend) UpsertClause(db *DB, m driver.Model, conflict []string, update []string) (string, error) {
+	return "", ErrUpsertNotSupported
+}
+
 func (b *SqlBackend) DefaultValues() string {
 	return "DEFAULT VALUES"
 }
 
+// Tables returns the names of the tables in the given schema, using
+// INFORMATION_SCHEMA.TABLES.
+func (b *SqlBackend) Tables(db *DB, schema string) ([]string, error) {
+	rows, err := db.Query("SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE "+
+		"TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME", schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
 func (b *SqlBackend) Inspect(db *DB, m driver.Model, schema string) (*Table, error) {
+	return b.InspectTable(db, m.Table(), schema)
+}
+
+// InspectTable returns the table with the given name in the given
+// schema, using INFORMATION_SCHEMA. Indexes are not included.
+func (b *SqlBackend) InspectTable(db *DB, tableName string, schema string) (*Table, error) {
 	var val int
-	name := db.QuoteString(m.Table())
+	name := db.QuoteString(tableName)
 	s := db.QuoteString(schema)
 	eq := fmt.Sprintf("SELECT 1 FROM INFORMATION_SCHEMA.TABLES WHERE "+
 		"TABLE_NAME = %s AND TABLE_SCHEMA = %s", name, s)
@@ -179,7 +274,7 @@ func (b *SqlBackend) Inspect(db *DB, m driver.Model, schema string) (*Table, err
 		}
 		field := fieldsByName[name]
 		if field == nil {
-			return nil, fmt.Errorf("table %s has constraint on non-existing field %s", m.Table(), name)
+			return nil, fmt.Errorf("table %s has constraint on non-existing field %s", tableName, name)
 		}
 		switch strings.ToLower(constraintType) {
 		case "primary key":
@@ -189,7 +284,7 @@ func (b *SqlBackend) Inspect(db *DB, m driver.Model, schema string) (*Table, err
 		case "unique":
 			field.AddConstraint(ConstraintUnique)
 		default:
-			return nil, fmt.Errorf("unknown constraint type %s on field %s in table %s", constraintType, name, m.Table())
+			return nil, fmt.Errorf("unknown constraint type %s on field %s in table %s", constraintType, name, tableName)
 		}
 	}
 	if len(foreignKeys) > 0 {
@@ -203,18 +298,18 @@ func (b *SqlBackend) Inspect(db *DB, m driver.Model, schema string) (*Table, err
 		defer rows.Close()
 		for rows.Next() {
 			var constraintName string
-			var tableName string
+			var refTable string
 			var columnName string
-			if err := rows.Scan(&constraintName, &tableName, &columnName); err != nil {
+			if err := rows.Scan(&constraintName, &refTable, &columnName); err != nil {
 				return nil, err
 			}
 			fieldName := foreignKeys[constraintName]
 			// Field was validated previously, won't be nil
 			field := fieldsByName[fieldName]
-			field.Constraints = append(field.Constraints, &Constraint{Type: ConstraintForeignKey, References: MakeReference(tableName, columnName)})
+			field.Constraints = append(field.Constraints, &Constraint{Type: ConstraintForeignKey, References: MakeReference(refTable, columnName)})
 		}
 	}
-	return &Table{Fields: fields}, nil
+	return &Table{Name: tableName, Fields: fields}, nil
 }
 
 func (b *SqlBackend) DefineField(db *DB, m driver.Model, table *Table, f *Field) (string, []string, error) {
@@ -234,9 +329,15 @@ func (b *SqlBackend) DefineField(db *DB, m driver.Model, table *Table, f *Field)
 	if f.Default != "" {
 		s += " DEFAULT " + f.Default
 	}
+	if len(f.Enum) > 0 {
+		s += fmt.Sprintf(" CHECK (%s IN (%s))", db.QuoteIdentifier(f.Name), EnumValues(db, f))
+	}
+	if f.Check != "" {
+		s += fmt.Sprintf(" CHECK (%s)", f.Check)
+	}
 	if ref := f.Constraint(ConstraintForeignKey); ref != nil {
-		s += fmt.Sprintf(" REFERENCES %s(%s)",
-			db.QuoteIdentifier(ref.References.Table()), db.QuoteIdentifier(ref.References.Field()))
+		s += fmt.Sprintf(" REFERENCES %s(%s)%s",
+			db.QuoteIdentifier(ref.References.Table()), db.QuoteIdentifier(ref.References.Field()), ref.Actions())
 	}
 	return s, nil, nil
 }
@@ -294,6 +395,14 @@ func (b *SqlBackend) AlterField(db *DB, m driver.Model, table *Table, oldField *
 	return fmt.Errorf("SQL backend %s can't ALTER fields", db.Backend().Name())
 }
 
+func (b *SqlBackend) Comment(db *DB, m driver.Model, table *Table, field *Field) error {
+	return nil
+}
+
+func (b *SqlBackend) TableOptions(db *DB, m driver.Model, table *Table) (string, error) {
+	return "", nil
+}
+
 func (b *SqlBackend) Insert(db *DB, m driver.Model, query string, args ...interface{}) (driver.Result, error) {
 	return db.Exec(query, args...)
 }
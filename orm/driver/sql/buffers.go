@@ -3,22 +3,16 @@ package sql
 import (
 	"bytes"
 
-	"gopkgs.com/pool.v1"
+	"gnd.la/internal/pool"
 )
 
-var (
-	bufferPool = pool.New(0)
-)
+// Most queries fit in this size
+const queryBufferSize = 1024
 
 func getBuffer() *bytes.Buffer {
-	if x := bufferPool.Get(); x != nil {
-		buf := x.(*bytes.Buffer)
-		buf.Reset()
-		return buf
-	}
-	return new(bytes.Buffer)
+	return pool.Get(queryBufferSize)
 }
 
 func putBuffer(buf *bytes.Buffer) {
-	bufferPool.Put(buf)
+	pool.Put(buf)
 }
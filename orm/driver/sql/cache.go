@@ -0,0 +1,306 @@
+package sql
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"gnd.la/cache"
+	"gnd.la/orm/driver"
+)
+
+const (
+	cacheQueryPrefix = "orm:q:"
+	cacheTablePrefix = "orm:t:"
+)
+
+var generationCounter uint64
+
+func init() {
+	// Cached rows might contain time.Time values
+	gob.Register(time.Time{})
+}
+
+// rows is the interface implemented by *sql.Rows, as well
+// as the types used for storing and retrieving rows from
+// the query cache.
+type rows interface {
+	Next() bool
+	Scan(dest ...interface{}) error
+	Err() error
+	Close() error
+}
+
+// cachingRows stores the raw values of the rows it reads
+// and saves them to the cache once all the rows have been
+// read.
+type cachingRows struct {
+	*sql.Rows
+	driver  *Driver
+	key     string
+	timeout int
+	// bounded is true when the query has a LIMIT, so
+	// the remaining rows are read on Close to store
+	// the results.
+	bounded bool
+	values  [][]interface{}
+	done    bool
+}
+
+func (r *cachingRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.store()
+	return false
+}
+
+func (r *cachingRows) Scan(dest ...interface{}) error {
+	raw, err := r.scanRaw(len(dest))
+	if err != nil {
+		return err
+	}
+	return scanValues(dest, raw)
+}
+
+func (r *cachingRows) scanRaw(count int) ([]interface{}, error) {
+	// Scanning into *interface{} makes database/sql
+	// copy any []byte, so they can be safely stored.
+	raw := make([]interface{}, count)
+	ptrs := make([]interface{}, count)
+	for ii := range raw {
+		ptrs[ii] = &raw[ii]
+	}
+	if err := r.Rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	r.values = append(r.values, raw)
+	return raw, nil
+}
+
+func (r *cachingRows) Close() error {
+	if !r.done && r.bounded && r.Rows.Err() == nil {
+		if cols, err := r.Rows.Columns(); err == nil {
+			for r.Rows.Next() {
+				if _, err := r.scanRaw(len(cols)); err != nil {
+					r.done = true
+					break
+				}
+			}
+			r.store()
+		}
+	}
+	return r.Rows.Close()
+}
+
+func (r *cachingRows) store() {
+	if r.done {
+		return
+	}
+	r.done = true
+	if r.Rows.Err() != nil {
+		return
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(r.values); err != nil {
+		if r.driver.logger != nil {
+			r.driver.logger.Errorf("error encoding cached rows for %s: %s", r.key, err)
+		}
+		return
+	}
+	r.driver.cache.SetBytes(r.key, buf.Bytes(), r.timeout)
+}
+
+// cachedRows returns the rows previously stored in the
+// cache by cachingRows.
+type cachedRows struct {
+	values [][]interface{}
+	pos    int
+}
+
+func (r *cachedRows) Next() bool {
+	if r.pos < len(r.values) {
+		r.pos++
+		return true
+	}
+	return false
+}
+
+func (r *cachedRows) Scan(dest ...interface{}) error {
+	return scanValues(dest, r.values[r.pos-1])
+}
+
+func (r *cachedRows) Err() error {
+	return nil
+}
+
+func (r *cachedRows) Close() error {
+	return nil
+}
+
+func scanValues(dest []interface{}, values []interface{}) error {
+	if len(dest) != len(values) {
+		return fmt.Errorf("expected %d destination arguments in Scan, not %d", len(values), len(dest))
+	}
+	for ii, v := range dest {
+		s, ok := v.(sql.Scanner)
+		if !ok {
+			return fmt.Errorf("can't scan cached value into %T", v)
+		}
+		if err := s.Scan(values[ii]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetQueryCache sets the cache used for storing the results of the
+// queries for the models which have caching enabled (see
+// gnd.la/orm.Options.CacheTimeout). Cached results are keyed by the
+// generated SQL and its parameters and they're invalidated when any
+// of the tables involved in the query is written to using this
+// driver. Note that writes performed outside of the ORM (e.g. using
+// DB directly) won't invalidate the cached results. Queries inside
+// transactions always bypass the cache. Passing nil disables the
+// query cache.
+func (d *Driver) SetQueryCache(c *cache.Cache) {
+	d.cache = c
+}
+
+// cacheTimeout returns the timeout for caching the results of
+// queries using the given model and true if they should be
+// cached. If any of the joined models has caching disabled,
+// the query is not cached.
+func (d *Driver) cacheTimeout(m driver.Model) (int, bool) {
+	if d.cache == nil || d.db.tx != nil {
+		return 0, false
+	}
+	timeout := 0
+	for cur := m; ; {
+		t := cur.CacheTimeout()
+		if t == 0 {
+			return 0, false
+		}
+		if t > 0 && (timeout == 0 || t < timeout) {
+			timeout = t
+		}
+		join := cur.Join()
+		if join == nil {
+			break
+		}
+		cur = join.Model()
+	}
+	return timeout, true
+}
+
+// cacheKey returns the key for caching the results of the given query,
+// which includes the current generation for each table in the query.
+func (d *Driver) cacheKey(m driver.Model, query string, params []interface{}) (string, error) {
+	h := sha1.New()
+	io.WriteString(h, query)
+	for _, v := range params {
+		fmt.Fprintf(h, "\x00%T:%v", v, v)
+	}
+	for cur := m; ; {
+		gen, err := d.tableGeneration(cur.Table())
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte{0})
+		h.Write(gen)
+		join := cur.Join()
+		if join == nil {
+			break
+		}
+		cur = join.Model()
+	}
+	return cacheQueryPrefix + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// tableGeneration returns the current generation for the given table,
+// which changes every time the table is written to.
+func (d *Driver) tableGeneration(table string) ([]byte, error) {
+	key := cacheTablePrefix + table
+	gen, err := d.cache.GetBytes(key)
+	if err == cache.ErrNotFound {
+		// Never use the same generation twice, otherwise
+		// stale results could become valid again after
+		// the generation is evicted from the cache.
+		gen = newGeneration()
+		err = d.cache.SetBytes(key, gen, 0)
+	}
+	return gen, err
+}
+
+// invalidate invalidates all the cached results which include the
+// given table. When called inside a transaction, the table is
+// invalidated again on commit, since other connections might
+// have cached the old data while the transaction was running.
+func (d *Driver) invalidate(m driver.Model) {
+	if d.cache == nil {
+		return
+	}
+	table := m.Table()
+	if d.db.tx != nil {
+		if d.written == nil {
+			d.written = make(map[string]struct{})
+		}
+		d.written[table] = struct{}{}
+	}
+	d.invalidateTable(table)
+}
+
+func (d *Driver) invalidateTable(table string) {
+	d.cache.SetBytes(cacheTablePrefix+table, newGeneration(), 0)
+}
+
+// query performs the given query, using the query cache if it's
+// enabled for the given model.
+func (d *Driver) query(m driver.Model, query string, params []interface{}, limit int) (rows, error) {
+	timeout, ok := d.cacheTimeout(m)
+	if !ok {
+		r, err := d.db.Query(d.commented(query), params...)
+		if err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	key, err := d.cacheKey(m, query, params)
+	if err != nil {
+		// Cache is not working, query the DB
+		r, err := d.db.Query(d.commented(query), params...)
+		if err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	if data, err := d.cache.GetBytes(key); err == nil {
+		var values [][]interface{}
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&values); err == nil {
+			return &cachedRows{values: values}, nil
+		}
+	}
+	r, err := d.db.Query(d.commented(query), params...)
+	if err != nil {
+		return nil, err
+	}
+	return &cachingRows{
+		Rows:    r,
+		driver:  d,
+		key:     key,
+		timeout: timeout,
+		bounded: limit >= 0,
+	}, nil
+}
+
+func newGeneration() []byte {
+	n := atomic.AddUint64(&generationCounter, 1)
+	return []byte(strconv.FormatInt(time.Now().UnixNano(), 36) + "." + strconv.FormatUint(n, 36))
+}
@@ -0,0 +1,32 @@
+package sql
+
+import (
+	"fmt"
+	"regexp"
+
+	"gnd.la/orm/driver"
+)
+
+var checkFieldRe = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// checkExpr returns the SQL expression for the check tag option of the
+// field at idx, replacing {} with the field column and {Name} with the
+// column of the field with the given qualified name.
+func (d *Driver) checkExpr(fields *driver.Fields, idx int, expr string) (string, error) {
+	var err error
+	s := checkFieldRe.ReplaceAllStringFunc(expr, func(m string) string {
+		name := m[1 : len(m)-1]
+		if name == "" {
+			return d.db.QuoteIdentifier(fields.MNames[idx])
+		}
+		ii, ok := fields.QNameMap[name]
+		if !ok {
+			if err == nil {
+				err = fmt.Errorf("check constraint for field %s references unknown field %q", fields.QNames[idx], name)
+			}
+			return m
+		}
+		return d.db.QuoteIdentifier(fields.MNames[ii])
+	})
+	return s, err
+}
@@ -0,0 +1,23 @@
+package sql
+
+import (
+	"gnd.la/orm/driver"
+)
+
+// WithComment returns a copy of the driver which prepends the given
+// comment, which must be a valid SQL comment (e.g. /* foo */), to all
+// the queries it performs. The copy shares the connection and the
+// transaction, if any, with d. Note that commented queries are never
+// prepared, since their text usually changes with every comment.
+func (d *Driver) WithComment(comment string) driver.Conn {
+	drv := *d
+	drv.comment = comment
+	return &drv
+}
+
+func (d *Driver) commented(query string) string {
+	if d.comment == "" {
+		return query
+	}
+	return d.comment + " " + query
+}
@@ -0,0 +1,27 @@
+package sql
+
+import (
+	"context"
+
+	"gnd.la/orm/driver"
+)
+
+// WithContext returns a copy of the driver which uses the given
+// context.Context for all the queries it performs, so they're
+// cancelled when the context is done. The copy shares the connection
+// and the transaction, if any, with d. Transactions started from the
+// copy also use ctx.
+func (d *Driver) WithContext(ctx context.Context) driver.Conn {
+	drv := *d
+	db := *d.db
+	db.ctx = ctx
+	db.driver = &drv
+	drv.db = &db
+	return &drv
+}
+
+// Context returns the context.Context used by the driver,
+// which is never nil.
+func (d *Driver) Context() context.Context {
+	return d.db.context()
+}
@@ -2,19 +2,23 @@ package sql
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
+	sqldriver "database/sql/driver"
 	"errors"
-	"hash/crc32"
 	"strings"
-	"sync"
+	"time"
 
-	"gnd.la/internal"
 	"gnd.la/orm/driver"
+	"gnd.la/trace"
 )
 
 var (
 	ErrNoRows           = sql.ErrNoRows
 	ErrFuncNotSupported = errors.New("function not supported")
+	// ErrUpsertNotSupported is returned by backends which
+	// can't perform upserts in a single statement.
+	ErrUpsertNotSupported = errors.New("upserts not supported")
 )
 
 type Queryier interface {
@@ -29,11 +33,9 @@ type Executor interface {
 type queryExecutor interface {
 	Queryier
 	Executor
-}
-
-type cacheEntry struct {
-	sql  string
-	stmt *sql.Stmt
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
 type DB struct {
@@ -47,8 +49,17 @@ type DB struct {
 	conn                 queryExecutor
 	driver               *Driver
 	replacesPlaceholders bool
-	mu                   sync.RWMutex
-	cache                map[uint32]cacheEntry
+	stmts                *stmtCache
+	// statements prepared for the current transaction,
+	// keyed by their SQL (see Begin)
+	txStmts map[string]*sql.Stmt
+	// ctx is used for all the operations, might
+	// be nil (see Driver.WithContext)
+	ctx context.Context
+	// non-nil when recording the executed
+	// statements (see Driver.Migrate)
+	statements *[]string
+	dryRun     bool
 }
 
 func (d *DB) replacePlaceholders(query string) string {
@@ -83,16 +94,31 @@ func (d *DB) replacePlaceholders(query string) string {
 }
 
 func (d *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if d.statements != nil {
+		d.record(query, args)
+		if d.dryRun {
+			return sqldriver.RowsAffected(0), nil
+		}
+	}
 	if d.replacesPlaceholders {
 		query = d.replacePlaceholders(query)
 	}
 	d.driver.debugq(query, args)
-	if len(args) > 0 {
-		if stmt := d.preparedStmt(query); stmt != nil {
-			return stmt.Exec(args...)
-		}
+	ctx, span := d.startSpan(query)
+	defer span.End()
+	defer d.driver.observe(query, args, time.Now())
+	ctx, done := d.intercept(ctx, query, args)
+	var res sql.Result
+	var err error
+	if stmt, release := d.stmt(query, args); stmt != nil {
+		defer release()
+		res, err = stmt.ExecContext(ctx, args...)
+	} else {
+		res, err = d.conn.ExecContext(ctx, query, args...)
 	}
-	return d.conn.Exec(query, args...)
+	done(err)
+	span.SetError(err)
+	return res, err
 }
 
 func (d *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
@@ -100,12 +126,21 @@ func (d *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
 		query = d.replacePlaceholders(query)
 	}
 	d.driver.debugq(query, args)
-	if len(args) > 0 {
-		if stmt := d.preparedStmt(query); stmt != nil {
-			return stmt.Query(args...)
-		}
+	ctx, span := d.startSpan(query)
+	defer span.End()
+	defer d.driver.observe(query, args, time.Now())
+	ctx, done := d.intercept(ctx, query, args)
+	var rows *sql.Rows
+	var err error
+	if stmt, release := d.stmt(query, args); stmt != nil {
+		defer release()
+		rows, err = stmt.QueryContext(ctx, args...)
+	} else {
+		rows, err = d.conn.QueryContext(ctx, query, args...)
 	}
-	return d.conn.Query(query, args...)
+	done(err)
+	span.SetError(err)
+	return rows, err
 }
 
 func (d *DB) QueryRow(query string, args ...interface{}) *sql.Row {
@@ -114,25 +149,67 @@ func (d *DB) QueryRow(query string, args ...interface{}) *sql.Row {
 	}
 	query = d.replacePlaceholders(query)
 	d.driver.debugq(query, args)
+	ctx, span := d.startSpan(query)
+	defer span.End()
+	defer d.driver.observe(query, args, time.Now())
+	ctx, done := d.intercept(ctx, query, args)
+	var row *sql.Row
+	if stmt, release := d.stmt(query, args); stmt != nil {
+		defer release()
+		row = stmt.QueryRowContext(ctx, args...)
+	} else {
+		row = d.conn.QueryRowContext(ctx, query, args...)
+	}
+	done(row.Err())
+	return row
+}
+
+// stmt returns the prepared statement for the given query,
+// or nil if the query should be executed without preparing it.
+// When the returned statement is non-nil, the returned function
+// must be called once the statement is not used anymore.
+func (d *DB) stmt(query string, args []interface{}) (*sql.Stmt, func()) {
 	if len(args) > 0 {
-		if stmt := d.preparedStmt(query); stmt != nil {
-			return stmt.QueryRow(args...)
+		return d.preparedStmt(query)
+	}
+	return nil, nil
+}
+
+// startSpan starts a child span of the span in the DB context,
+// if any. The returned *trace.Span might be nil, which is
+// safe to use.
+func (d *DB) startSpan(query string) (context.Context, *trace.Span) {
+	ctx, span := trace.Start(d.context(), "", trace.KindClient)
+	if span != nil {
+		op := query
+		if strings.HasPrefix(op, "/*") {
+			// Skip comments added by WithComment
+			if end := strings.Index(op, "*/"); end >= 0 {
+				op = strings.TrimSpace(op[end+2:])
+			}
+		}
+		if sp := strings.IndexAny(op, " \n\t"); sp > 0 {
+			op = op[:sp]
 		}
+		span.SetName(strings.ToUpper(op))
+		span.SetAttribute("db.system", d.driver.backend.Name())
+		span.SetAttribute("db.statement", query)
 	}
-	return d.conn.QueryRow(query, args...)
+	return ctx, span
 }
 
 func (d *DB) Begin() (*DB, error) {
 	if d.tx != nil {
 		return nil, driver.ErrInTransaction
 	}
-	tx, err := d.sqlDb.Begin()
+	tx, err := d.sqlDb.BeginTx(d.context(), nil)
 	if err != nil {
 		return nil, err
 	}
 	dc := *d
 	dc.tx = tx
 	dc.conn = tx
+	dc.txStmts = nil
 	return &dc, nil
 }
 
@@ -159,6 +236,7 @@ func (d *DB) Close() error {
 		}
 		return nil
 	}
+	d.stmts.clear()
 	return d.sqlDb.Close()
 }
 
@@ -181,32 +259,51 @@ func (d *DB) quoteWith(s string, q byte) string {
 	return qu + escaped + qu
 }
 
-func (d *DB) preparedStmt(s string) *sql.Stmt {
-	key := crc32.ChecksumIEEE(internal.StringToBytes(s))
-	d.mu.RLock()
-	cached, ok := d.cache[key]
-	d.mu.RUnlock()
-	if ok && cached.sql == s {
-		if d.tx != nil {
-			return d.tx.Stmt(cached.stmt)
-		}
-		return cached.stmt
+func (d *DB) preparedStmt(s string) (*sql.Stmt, func()) {
+	if d.stmts.size <= 0 || strings.HasPrefix(s, "/*") {
+		// Queries with comments (see Driver.WithComment) usually
+		// change on every request, don't fill the cache with them.
+		return nil, nil
 	}
-	stmt, _ := d.sqlDb.Prepare(s)
-	if stmt == nil {
-		// Let the non-prepared method report the error
-		return nil
+	if d.tx != nil {
+		if stmt := d.txStmts[s]; stmt != nil {
+			return stmt, noRelease
+		}
 	}
-	d.mu.Lock()
-	if d.cache == nil {
-		d.cache = make(map[uint32]cacheEntry)
+	entry := d.stmts.get(s)
+	if entry == nil {
+		stmt, _ := d.sqlDb.PrepareContext(d.context(), s)
+		if stmt == nil {
+			// Let the non-prepared method report the error
+			return nil, nil
+		}
+		entry = d.stmts.put(s, stmt)
 	}
-	d.cache[key] = cacheEntry{sql: s, stmt: stmt}
-	d.mu.Unlock()
+	release := func() { d.stmts.release(entry) }
 	if d.tx != nil {
-		return d.tx.Stmt(stmt)
+		// Statements created by Tx.Stmt are closed
+		// when the transaction ends, and they don't
+		// depend on the original one being open.
+		stmt := d.tx.StmtContext(d.context(), entry.stmt)
+		release()
+		if d.txStmts == nil {
+			d.txStmts = make(map[string]*sql.Stmt)
+		}
+		d.txStmts[s] = stmt
+		return stmt, noRelease
+	}
+	return entry.stmt, release
+}
+
+func noRelease() {}
+
+// context returns the context.Context used for
+// all the operations performed by d.
+func (d *DB) context() context.Context {
+	if d.ctx != nil {
+		return d.ctx
 	}
-	return stmt
+	return context.Background()
 }
 
 func (d *DB) DB() *sql.DB {
@@ -0,0 +1,35 @@
+package sql
+
+import (
+	"fmt"
+	"reflect"
+
+	"gnd.la/util/decimal"
+	"gnd.la/util/structs"
+)
+
+var decimalType = reflect.TypeOf(decimal.Decimal{})
+
+// IsDecimal returns true iff typ is gnd.la/util/decimal.Decimal.
+// Backends should map these fields to an exact numeric type.
+func IsDecimal(typ reflect.Type) bool {
+	return typ == decimalType
+}
+
+// DecimalType returns the database type for a decimal field, using
+// the given type name (e.g. NUMERIC) and the precision and scale
+// tags. If the tag doesn't specify them, the provided precision and
+// scale are used instead. If precision is <= 0 and the tag doesn't
+// specify it either, the type name is returned without any modifiers.
+func DecimalType(name string, t *structs.Tag, precision int, scale int) string {
+	if p, ok := t.IntValue("precision"); ok {
+		precision = p
+	}
+	if s, ok := t.IntValue("scale"); ok {
+		scale = s
+	}
+	if precision <= 0 {
+		return name
+	}
+	return fmt.Sprintf("%s (%d, %d)", name, precision, scale)
+}
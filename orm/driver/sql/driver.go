@@ -3,12 +3,16 @@ package sql
 import (
 	"bytes"
 	"database/sql"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"gnd.la/app/profile"
+	"gnd.la/cache"
 	"gnd.la/config"
 	"gnd.la/encoding/codec"
 	"gnd.la/encoding/pipe"
@@ -18,12 +22,18 @@ import (
 	"gnd.la/orm/index"
 	"gnd.la/orm/operation"
 	"gnd.la/orm/query"
+	"gnd.la/util/generic"
 	"gnd.la/util/structs"
 )
 
+// likeEscape declares query.LikeEscape as the escape character
+// for LIKE patterns.
+const likeEscape = " ESCAPE '" + string(query.LikeEscape) + "'"
+
 var (
 	stringType   = reflect.TypeOf("")
 	subqueryType = reflect.TypeOf(query.Subquery(""))
+	timeType     = reflect.TypeOf(time.Time{})
 )
 
 type Driver struct {
@@ -31,6 +41,14 @@ type Driver struct {
 	logger     *log.Logger
 	backend    Backend
 	transforms map[reflect.Type]struct{}
+	cache      *cache.Cache
+	times      *driver.TimeOptions
+	comment    string
+	// tables written to in the current transaction,
+	// invalidated again on commit.
+	written      map[string]struct{}
+	stats        *queryStats
+	interceptors []driver.Interceptor
 }
 
 func (d *Driver) Check() error {
@@ -95,6 +113,24 @@ func (d *Driver) createIndex(m driver.Model, idx *index.Index, name string) erro
 	if has {
 		return nil
 	}
+	sql, err := d.indexSQL(m, idx, name)
+	if err != nil {
+		return err
+	}
+	_, err = d.db.Exec(sql)
+	return err
+}
+
+// indexSQL returns the statement which creates the given index
+// with the given name.
+func (d *Driver) indexSQL(m driver.Model, idx *index.Index, name string) (string, error) {
+	caps := d.Capabilities()
+	if idx.Where != "" && caps&driver.CAP_PARTIAL_INDEX == 0 {
+		return "", fmt.Errorf("can't create index %s: backend %s does not support partial indexes", name, d.backend.Name())
+	}
+	if len(idx.Expressions) > 0 && caps&driver.CAP_EXPRESSION_INDEX == 0 {
+		return "", fmt.Errorf("can't create index %s: backend %s does not support expression indexes", name, d.backend.Name())
+	}
 
 	buf := getBuffer()
 	buf.WriteString("CREATE ")
@@ -110,7 +146,8 @@ func (d *Driver) createIndex(m driver.Model, idx *index.Index, name string) erro
 	for _, v := range idx.Fields {
 		name, _, err := fields.Map(v)
 		if err != nil {
-			return err
+			putBuffer(buf)
+			return "", err
 		}
 		buf.WriteByte('"')
 		buf.WriteString(name)
@@ -120,15 +157,26 @@ func (d *Driver) createIndex(m driver.Model, idx *index.Index, name string) erro
 		}
 		buf.WriteByte(',')
 	}
+	for _, v := range idx.Expressions {
+		// Always wrap expressions in parens, since some
+		// backends (e.g. MySQL) require them.
+		buf.WriteByte('(')
+		buf.WriteString(v)
+		buf.WriteString("),")
+	}
 	buf.Truncate(buf.Len() - 1)
 	buf.WriteString(")")
-	_, err = d.db.Exec(buftos(buf))
+	if idx.Where != "" {
+		buf.WriteString(" WHERE ")
+		buf.WriteString(idx.Where)
+	}
+	s := buf.String()
 	putBuffer(buf)
-	return err
+	return s, nil
 }
 
 func (d *Driver) indexName(m driver.Model, idx *index.Index) (string, error) {
-	if len(idx.Fields) == 0 {
+	if len(idx.Fields) == 0 && len(idx.Expressions) == 0 {
 		return "", fmt.Errorf("index on %v has no fields", m.Type())
 	}
 	buf := getBuffer()
@@ -146,6 +194,14 @@ func (d *Driver) indexName(m driver.Model, idx *index.Index) (string, error) {
 			buf.WriteString("_desc")
 		}
 	}
+	// Expressions and conditions might contain any characters,
+	// so use a hash to keep the names valid and deterministic.
+	if len(idx.Expressions) > 0 {
+		fmt.Fprintf(buf, "_expr_%08x", crc32.ChecksumIEEE([]byte(strings.Join(idx.Expressions, ","))))
+	}
+	if idx.Where != "" {
+		fmt.Fprintf(buf, "_where_%08x", crc32.ChecksumIEEE([]byte(idx.Where)))
+	}
 	s := buf.String()
 	putBuffer(buf)
 	return s, nil
@@ -156,7 +212,7 @@ func (d *Driver) Query(m driver.Model, q query.Q, sort []driver.Sort, limit int,
 	if err != nil {
 		return &Iter{err: err}
 	}
-	rows, err := d.db.Query(internal.BytesToString(query.Bytes()), params...)
+	rows, err := d.query(m, internal.BytesToString(query.Bytes()), params, limit)
 	if err != nil {
 		return &Iter{err: err}
 	}
@@ -165,11 +221,27 @@ func (d *Driver) Query(m driver.Model, q query.Q, sort []driver.Sort, limit int,
 
 func (d *Driver) Count(m driver.Model, q query.Q, limit int, offset int) (uint64, error) {
 	var count uint64
-	query, params, err := d.Select([]string{"COUNT(*)"}, false, m, q, nil, limit, offset)
-	if err != nil {
-		return 0, err
+	var query *bytes.Buffer
+	var params []interface{}
+	var err error
+	if m.Distinct() {
+		// Count the rows returned by SELECT DISTINCT
+		query, params, err = d.Select(nil, true, m, q, nil, limit, offset)
+		if err != nil {
+			return 0, err
+		}
+		inner := buftos(query)
+		query.Reset()
+		query.WriteString("SELECT COUNT(*) FROM (")
+		query.WriteString(inner)
+		query.WriteString(") AS \"distinct\"")
+	} else {
+		query, params, err = d.Select([]string{"COUNT(*)"}, false, m, q, nil, limit, offset)
+		if err != nil {
+			return 0, err
+		}
 	}
-	err = d.db.QueryRow(buftos(query), params...).Scan(&count)
+	err = d.db.QueryRow(d.commented(buftos(query)), params...).Scan(&count)
 	putBuffer(query)
 	return count, err
 }
@@ -180,7 +252,7 @@ func (d *Driver) Exists(m driver.Model, q query.Q) (bool, error) {
 		return false, err
 	}
 	var one uint64
-	err = d.db.QueryRow(buftos(query), params...).Scan(&one)
+	err = d.db.QueryRow(d.commented(buftos(query)), params...).Scan(&one)
 	putBuffer(query)
 	if err == sql.ErrNoRows {
 		err = nil
@@ -189,6 +261,13 @@ func (d *Driver) Exists(m driver.Model, q query.Q) (bool, error) {
 }
 
 func (d *Driver) Insert(m driver.Model, data interface{}) (driver.Result, error) {
+	return d.insert(m, data, nil)
+}
+
+// insert inserts the given data. If conflict is non-empty, the row
+// is updated instead when another row with the same values for the
+// conflict fields already exists.
+func (d *Driver) insert(m driver.Model, data interface{}, conflict []string) (driver.Result, error) {
 	_, fields, values, err := d.saveParameters(m, data)
 	if err != nil {
 		return nil, err
@@ -215,12 +294,40 @@ func (d *Driver) Insert(m driver.Model, data interface{}) (driver.Result, error)
 		buf.WriteByte(' ')
 		buf.WriteString(d.backend.DefaultValues())
 	}
-	res, err := d.backend.Insert(d.db, m, buftos(buf), values...)
+	if len(conflict) > 0 {
+		var update []string
+		for _, v := range fields {
+			if !generic.Contains(conflict, v) {
+				update = append(update, v)
+			}
+		}
+		clause, err := d.backend.UpsertClause(d.db, m, conflict, update)
+		if err != nil {
+			putBuffer(buf)
+			return nil, err
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(clause)
+	}
+	res, err := d.backend.Insert(d.db, m, d.commented(buftos(buf)), values...)
 	putBuffer(buf)
+	if err == nil {
+		d.invalidate(m)
+	}
 	return res, err
 }
 
 func (d *Driver) Operate(m driver.Model, q query.Q, ops []*operation.Operation) (driver.Result, error) {
+	buf, params, err := d.operateQuery(m, q, ops)
+	if err != nil {
+		return nil, err
+	}
+	return d.exec(m, buf, params)
+}
+
+// operateQuery returns the UPDATE statement performed by Operate.
+// The caller must call putBuffer on the returned buffer.
+func (d *Driver) operateQuery(m driver.Model, q query.Q, ops []*operation.Operation) (*bytes.Buffer, []interface{}, error) {
 	buf := getBuffer()
 	buf.WriteString("UPDATE ")
 	buf.WriteByte('"')
@@ -234,7 +341,8 @@ func (d *Driver) Operate(m driver.Model, q query.Q, ops []*operation.Operation)
 		}
 		dbName, _, err := m.Map(op.Field)
 		if err != nil {
-			return nil, err
+			putBuffer(buf)
+			return nil, nil, err
 		}
 		dbName = unquote(dbName)
 		buf.WriteByte('"')
@@ -255,32 +363,42 @@ func (d *Driver) Operate(m driver.Model, q query.Q, ops []*operation.Operation)
 			if f, ok := op.Value.(operation.Field); ok {
 				fieldName, _, err := m.Map(string(f))
 				if err != nil {
-					return nil, err
+					putBuffer(buf)
+					return nil, nil, err
 				}
 				buf.WriteString(unquote(fieldName))
 			} else {
 				buf.WriteString(d.backend.Placeholder(len(params)))
-				params = append(params, op.Value)
+				params = append(params, d.outParam(m, op.Field, op.Value))
 			}
 		default:
-			return nil, fmt.Errorf("operator %d is not supported", op.Operator)
+			putBuffer(buf)
+			return nil, nil, fmt.Errorf("operator %d is not supported", op.Operator)
 		}
 	}
 	qParams, err := d.where(buf, m, q, len(params))
 	if err != nil {
-		return nil, err
+		putBuffer(buf)
+		return nil, nil, err
 	}
-	params = append(params, qParams...)
-	res, err := d.db.Exec(buftos(buf), params...)
-	putBuffer(buf)
-	return res, err
+	return buf, append(params, qParams...), nil
 }
 
 func (d *Driver) Update(m driver.Model, q query.Q, data interface{}) (driver.Result, error) {
-	_, fields, values, err := d.saveParameters(m, data)
+	buf, params, err := d.updateQuery(m, q, data)
 	if err != nil {
 		return nil, err
 	}
+	return d.exec(m, buf, params)
+}
+
+// updateQuery returns the UPDATE statement performed by Update.
+// The caller must call putBuffer on the returned buffer.
+func (d *Driver) updateQuery(m driver.Model, q query.Q, data interface{}) (*bytes.Buffer, []interface{}, error) {
+	_, fields, values, err := d.saveParameters(m, data)
+	if err != nil {
+		return nil, nil, err
+	}
 	buf := getBuffer()
 	buf.WriteString("UPDATE ")
 	buf.WriteByte('"')
@@ -298,21 +416,177 @@ func (d *Driver) Update(m driver.Model, q query.Q, data interface{}) (driver.Res
 	// remove last ,
 	buf.Truncate(buf.Len() - 1)
 	qParams, err := d.where(buf, m, q, len(values))
+	if err != nil {
+		putBuffer(buf)
+		return nil, nil, err
+	}
+	return buf, append(values, qParams...), nil
+}
+
+// Upsert inserts the given data or, if there's already a row matching q,
+// updates it. When UpsertsNatively returns true for m and q, the upsert is
+// performed in one statement, using the fields in q to detect the conflict.
+// Note that, in this case, the values in data for those fields should match
+// the ones in q. Otherwise, Upsert performs an update and, if it didn't affect
+// any rows, an insert.
+func (d *Driver) Upsert(m driver.Model, q query.Q, data interface{}) (driver.Result, error) {
+	if conflict := d.upsertConflict(m, q); conflict != nil {
+		return d.insert(m, data, conflict)
+	}
+	res, err := d.Update(m, q, data)
 	if err != nil {
 		return nil, err
 	}
-	params := append(values, qParams...)
-	res, err := d.db.Exec(buftos(buf), params...)
-	putBuffer(buf)
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if aff == 0 {
+		res, err = d.Insert(m, data)
+	}
 	return res, err
 }
 
-func (d *Driver) Upsert(m driver.Model, q query.Q, data interface{}) (driver.Result, error) {
-	// TODO: MySql might be able to provide upserts
-	return nil, nil
+// UpsertsNatively returns true iff Upsert can be performed in a single
+// statement for the given model and query. This requires the backend
+// to support upserts, q to only contain equality conditions (either a
+// single Eq or an And of them) and the fields in q to match exactly
+// either the primary key or an unique key of the model (see UniqueKeys).
+// Backends might impose additional restrictions (see Backend.UpsertClause).
+func (d *Driver) UpsertsNatively(m driver.Model, q query.Q) bool {
+	return d.upsertConflict(m, q) != nil
+}
+
+// upsertConflict returns the database names of the fields used for
+// detecting the conflict when performing a native upsert, or nil if
+// the upsert can't be performed natively.
+func (d *Driver) upsertConflict(m driver.Model, q query.Q) []string {
+	if !d.backend.Upserts() {
+		return nil
+	}
+	conflict := eqFields(m, q)
+	if conflict == nil || !isUniqueKey(m, conflict) {
+		return nil
+	}
+	if _, err := d.backend.UpsertClause(d.db, m, conflict, nil); err != nil {
+		return nil
+	}
+	return conflict
+}
+
+// eqFields returns the database names of the fields which
+// are compared for equality in q, or nil if q contains any other
+// conditions.
+func eqFields(m driver.Model, q query.Q) []string {
+	var names []string
+	var walk func(query.Q) bool
+	walk = func(q query.Q) bool {
+		switch x := q.(type) {
+		case *query.Eq:
+			switch x.Value.(type) {
+			case nil, query.F, query.Subquery:
+				return false
+			}
+			if isNil(x.Value) {
+				return false
+			}
+			fields := m.Fields()
+			idx, ok := fields.QNameMap[x.Field.Field]
+			if !ok {
+				return false
+			}
+			names = append(names, fields.MNames[idx])
+			return true
+		case *query.And:
+			for _, v := range x.Conditions {
+				if !walk(v) {
+					return false
+				}
+			}
+			return len(x.Conditions) > 0
+		}
+		return false
+	}
+	if q == nil || !walk(q) {
+		return nil
+	}
+	return names
+}
+
+// isUniqueKey returns true iff names, in any order, form one of the
+// unique keys of m.
+func isUniqueKey(m driver.Model, names []string) bool {
+	for _, key := range UniqueKeys(m) {
+		if sameFields(key, names) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameFields(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, v := range a {
+		if !generic.Contains(b, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// UniqueKeys returns the database names of the fields forming each
+// unique key of the given model, starting with its primary key. Partial
+// and expression indexes are not included, since they can't be matched
+// just by listing their fields.
+func UniqueKeys(m driver.Model) [][]string {
+	fields := m.Fields()
+	var keys [][]string
+	if fields.PrimaryKey >= 0 {
+		keys = append(keys, []string{fields.MNames[fields.PrimaryKey]})
+	} else if len(fields.CompositePrimaryKey) > 0 {
+		key := make([]string, len(fields.CompositePrimaryKey))
+		for ii, v := range fields.CompositePrimaryKey {
+			key[ii] = fields.MNames[v]
+		}
+		keys = append(keys, key)
+	}
+	for ii, v := range fields.Tags {
+		if ii != fields.PrimaryKey && v.Has("unique") {
+			keys = append(keys, []string{fields.MNames[ii]})
+		}
+	}
+	for _, idx := range m.Indexes() {
+		if !idx.Unique || idx.Where != "" || len(idx.Expressions) > 0 {
+			continue
+		}
+		key := make([]string, 0, len(idx.Fields))
+		for _, v := range idx.Fields {
+			name, _, err := fields.Map(v)
+			if err != nil {
+				break
+			}
+			key = append(key, name)
+		}
+		if len(key) == len(idx.Fields) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
 }
 
 func (d *Driver) Delete(m driver.Model, q query.Q) (driver.Result, error) {
+	buf, params, err := d.deleteQuery(m, q)
+	if err != nil {
+		return nil, err
+	}
+	return d.exec(m, buf, params)
+}
+
+// deleteQuery returns the DELETE statement performed by Delete.
+// The caller must call putBuffer on the returned buffer.
+func (d *Driver) deleteQuery(m driver.Model, q query.Q) (*bytes.Buffer, []interface{}, error) {
 	buf := getBuffer()
 	buf.WriteString("DELETE FROM ")
 	buf.WriteByte('"')
@@ -320,10 +594,20 @@ func (d *Driver) Delete(m driver.Model, q query.Q) (driver.Result, error) {
 	buf.WriteByte('"')
 	params, err := d.where(buf, m, q, 0)
 	if err != nil {
-		return nil, err
+		putBuffer(buf)
+		return nil, nil, err
 	}
-	res, err := d.db.Exec(buftos(buf), params...)
+	return buf, params, nil
+}
+
+// exec executes the statement in buf, which modifies the
+// rows in m, and releases buf.
+func (d *Driver) exec(m driver.Model, buf *bytes.Buffer, params []interface{}) (driver.Result, error) {
+	res, err := d.db.Exec(d.commented(buftos(buf)), params...)
 	putBuffer(buf)
+	if err == nil {
+		d.invalidate(m)
+	}
 	return res, err
 }
 
@@ -332,7 +616,7 @@ func (d *Driver) Close() error {
 }
 
 func (d *Driver) Upserts() bool {
-	return false
+	return d.backend.Upserts()
 }
 
 func (d *Driver) Tags() []string {
@@ -396,6 +680,97 @@ func (d *Driver) fieldByIndex(val reflect.Value, indexes []int, alloc bool) refl
 	return val
 }
 
+// SetTimeOptions sets the options for storing and loading time.Time
+// values. See gnd.la/orm/driver.TimeOptions for the available options.
+func (d *Driver) SetTimeOptions(opts *driver.TimeOptions) {
+	d.times = opts
+}
+
+// outTime returns the value to be stored for f, applying the
+// TimeOptions and the time options in the field tag to time.Time
+// values.
+func (d *Driver) outTime(f reflect.Value, tag *structs.Tag) (reflect.Value, error) {
+	if f.Type() == timeType {
+		opts, err := driver.FieldTimeOptions(d.times, tag)
+		if err != nil {
+			return f, err
+		}
+		if opts != nil {
+			return reflect.ValueOf(opts.Out(f.Interface().(time.Time))), nil
+		}
+	}
+	return f, nil
+}
+
+// outParam works like outTime, but for query parameters compared
+// against the given field. When field is empty (e.g. in raw conditions)
+// or its tag can't be found, only the driver TimeOptions are applied.
+// time.Time values are also transformed like stored values, for
+// backends which don't store them natively (e.g. sqlite).
+func (d *Driver) outParam(m driver.Model, field string, v interface{}) interface{} {
+	t, ok := v.(time.Time)
+	if !ok {
+		return v
+	}
+	opts := d.times
+	if tag := fieldTag(m, field); tag != nil {
+		// Invalid options are reported when creating the tables
+		if fo, err := driver.FieldTimeOptions(d.times, tag); err == nil {
+			opts = fo
+		}
+	}
+	val := reflect.ValueOf(opts.Out(t))
+	if _, ok := d.transforms[timeType]; ok {
+		if tv, err := d.backend.TransformOutValue(val); err == nil {
+			return tv
+		}
+	}
+	return val.Interface()
+}
+
+// fieldTag returns the tag for the field with the given qualified
+// name in m or in any of its joined models, or nil if there's no
+// such field.
+func fieldTag(m driver.Model, qname string) *structs.Tag {
+	if qname == "" {
+		return nil
+	}
+	if sep := strings.IndexByte(qname, '|'); sep >= 0 {
+		qname = qname[sep+1:]
+	}
+	for m != nil {
+		if fields := m.Fields(); fields != nil {
+			if idx, ok := fields.QNameMap[qname]; ok {
+				return fields.Tags[idx]
+			}
+		}
+		join := m.Join()
+		if join == nil {
+			break
+		}
+		m = join.Model()
+	}
+	return nil
+}
+
+// encodeField encodes the given field value using its codec and pipe.
+// Text codecs without a pipe return a string, since some backends
+// store them in columns (e.g. JSONB) which don't accept binary
+// parameters.
+func encodeField(c *codec.Codec, tag *structs.Tag, f reflect.Value) (interface{}, error) {
+	data, err := c.Encode(f.Interface())
+	if err != nil {
+		return nil, err
+	}
+	if p := pipe.FromTag(tag); p != nil {
+		return p.Encode(data)
+	}
+	if !c.Binary {
+		return string(data), nil
+	}
+	return data, nil
+}
+
 func (d *Driver) saveParameters(m driver.Model, data interface{}) (reflect.Value, []string, []interface{}, error) {
 	// data is guaranteed to be of m.Type()
 	val := driver.Direct(reflect.ValueOf(data))
@@ -413,9 +788,12 @@ func (d *Driver) saveParameters(m driver.Model, data interface{}) (reflect.Value
 			if fields.OmitEmpty[ii] && driver.IsZero(f) {
 				continue
 			}
+			if f, err = d.outTime(f, fields.Tags[ii]); err != nil {
+				return val, nil, nil, err
+			}
 			ft := f.Type()
 			var fval interface{}
-			if _, ok := d.transforms[ft]; ok {
+			if _, ok := d.transforms[ft]; ok && codec.FromTag(fields.Tags[ii]) == nil {
 				fval, err = d.backend.TransformOutValue(f)
 				if err != nil {
 					return val, nil, nil, err
@@ -425,17 +803,10 @@ func (d *Driver) saveParameters(m driver.Model, data interface{}) (reflect.Value
 				}
 			} else if !fields.NullEmpty[ii] || !driver.IsZero(f) {
 				if c := codec.FromTag(fields.Tags[ii]); c != nil {
-					fval, err = c.Encode(f.Interface())
+					fval, err = encodeField(c, fields.Tags[ii], f)
 					if err != nil {
 						return val, nil, nil, err
 					}
-					if p := pipe.FromTag(fields.Tags[ii]); p != nil {
-						data, err := p.Encode(fval.([]byte))
-						if err != nil {
-							return val, nil, nil, err
-						}
-						fval = data
-					}
 				} else {
 					// Most sql drivers won't accept aliases for string type
 					if ft.Kind() == reflect.String && ft != stringType {
@@ -456,10 +827,13 @@ func (d *Driver) saveParameters(m driver.Model, data interface{}) (reflect.Value
 			if fields.OmitEmpty[ii] && driver.IsZero(f) {
 				continue
 			}
+			if f, err = d.outTime(f, fields.Tags[ii]); err != nil {
+				return val, nil, nil, err
+			}
 			var fval interface{}
 			if !fields.NullEmpty[ii] || !driver.IsZero(f) {
 				if c := codec.FromTag(fields.Tags[ii]); c != nil {
-					fval, err = c.Encode(&f)
+					fval, err = encodeField(c, fields.Tags[ii], f)
 					if err != nil {
 						return val, nil, nil, err
 					}
@@ -519,6 +893,15 @@ func (d *Driver) outValues(m driver.Model, out interface{}) (reflect.Value, *dri
 		field := d.fieldByIndex(val, v, true)
 		tag := fields.Tags[ii]
 		s := newScanner(&field, tag, d.backend)
+		if field.Type() == timeType {
+			times, err := driver.FieldTimeOptions(d.times, tag)
+			if err != nil {
+				return reflect.Value{}, nil, nil, nil, err
+			}
+			s.Times = times
+		} else {
+			s.Times = d.times
+		}
 		scanners[ii] = s
 		values[ii] = s
 	}
@@ -578,6 +961,7 @@ func (d *Driver) makeTable(m driver.Model) (*Table, error) {
 			Name:    v,
 			Type:    ft,
 			Default: def,
+			Comment: m.FieldComment(qnames[ii]),
 		}
 		if tag.Has("notnull") {
 			field.AddConstraint(ConstraintNotNull)
@@ -590,6 +974,12 @@ func (d *Driver) makeTable(m driver.Model) (*Table, error) {
 		if tag.Has("auto_increment") {
 			field.AddOption(OptionAutoIncrement)
 		}
+		field.Enum = fields.Enums[ii]
+		if check := tag.Value("check"); check != "" {
+			if field.Check, err = d.checkExpr(fields, ii, check); err != nil {
+				return nil, err
+			}
+		}
 		if ref := fields.References[qnames[ii]]; ref != nil {
 			fk, _, err := ref.Model.Fields().Map(ref.Field)
 			if err != nil {
@@ -598,11 +988,13 @@ func (d *Driver) makeTable(m driver.Model) (*Table, error) {
 			field.Constraints = append(field.Constraints, &Constraint{
 				Type:       ConstraintForeignKey,
 				References: MakeReference(ref.Model.Table(), fk),
+				OnDelete:   ref.OnDelete,
+				OnUpdate:   ref.OnUpdate,
 			})
 		}
 		dbFields[ii] = field
 	}
-	return &Table{Fields: dbFields}, nil
+	return &Table{Name: m.Table(), Fields: dbFields, Comment: m.Comment()}, nil
 }
 
 func (d *Driver) createTable(m driver.Model, table *Table) error {
@@ -610,8 +1002,26 @@ func (d *Driver) createTable(m driver.Model, table *Table) error {
 	if err != nil {
 		return err
 	}
-	_, err = d.db.Exec(sql)
-	return err
+	if _, err = d.db.Exec(sql); err != nil {
+		return err
+	}
+	if table.Comment != "" {
+		if err := d.backend.Comment(d.db, m, table, nil); err != nil {
+			return err
+		}
+	}
+	return d.commentFields(m, table, table.Fields)
+}
+
+func (d *Driver) commentFields(m driver.Model, table *Table, fields []*Field) error {
+	for _, v := range fields {
+		if v.Comment != "" {
+			if err := d.backend.Comment(d.db, m, table, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 func (d *Driver) mergeTable(m driver.Model, prevTable *Table, newTable *Table) error {
@@ -632,6 +1042,11 @@ func (d *Driver) mergeTable(m driver.Model, prevTable *Table, newTable *Table) e
 			}
 			missing = append(missing, v)
 		} else {
+			if len(v.Enum) > 0 {
+				// Backends might represent enums with their own
+				// types, which don't map to a Kind.
+				continue
+			}
 			if prev.Type != v.Type {
 				// Check the Kind
 				k1, len1 := TypeKind(prev.Type)
@@ -642,6 +1057,12 @@ func (d *Driver) mergeTable(m driver.Model, prevTable *Table, newTable *Table) e
 					}
 					continue
 				}
+				if (k1 == KindText && k2 == KindJSON) || (k1 == KindJSON && k2 == KindText) {
+					// JSON encoded fields used to be stored as TEXT. Keep
+					// existing columns, since both types can store them
+					// and JSON queries cast the column.
+					continue
+				}
 				// Check if we can transform the kind
 				fields := m.Fields()
 				idx := fields.MNameMap[v.Name]
@@ -656,6 +1077,7 @@ func (d *Driver) mergeTable(m driver.Model, prevTable *Table, newTable *Table) e
 		if err := d.backend.AddFields(d.db, m, prevTable, newTable, missing); err != nil {
 			return err
 		}
+		return d.commentFields(m, newTable, missing)
 	}
 	return nil
 }
@@ -688,7 +1110,17 @@ func (d *Driver) condition(buf *bytes.Buffer, params *[]interface{}, m driver.Mo
 			err = d.clause(buf, params, m, "%s != %s", &x.Field, begin)
 		}
 	case *query.Contains:
-		err = d.clause(buf, params, m, "%s LIKE '%%' || %s || '%%'", &x.Field, begin)
+		if d.isArray(m, x.Field.Field) {
+			err = d.arrayContains(buf, params, m, &x.Field, begin)
+		} else {
+			err = d.likeClause(buf, params, m, &x.Field, true, begin)
+		}
+	case *query.StartsWith:
+		err = d.likeClause(buf, params, m, &x.Field, false, begin)
+	case *query.Like:
+		err = d.clause(buf, params, m, "%s LIKE %s"+likeEscape, &x.Field, begin)
+	case *query.ILike:
+		err = d.clause(buf, params, m, "LOWER(%s) LIKE LOWER(%s)"+likeEscape, &x.Field, begin)
 	case *query.Lt:
 		err = d.clause(buf, params, m, "%s < %s", &x.Field, begin)
 	case *query.Lte:
@@ -700,33 +1132,27 @@ func (d *Driver) condition(buf *bytes.Buffer, params *[]interface{}, m driver.Mo
 	case *query.Operator:
 		err = d.clause(buf, params, m, "%s "+x.Operator+" %s", &x.Field, begin)
 	case *query.In:
-		dbName, _, err := m.Map(x.Field.Field)
+		err = d.inClause(buf, params, m, "IN", &x.Field, begin)
+	case *query.NotIn:
+		err = d.inClause(buf, params, m, "NOT IN", &x.Field, begin)
+	case *query.Between:
+		err = d.between(buf, params, m, x, begin)
+	case *query.Near:
+		cond, args, err := d.near(m, x, len(*params)+begin)
 		if err != nil {
 			return err
 		}
-		buf.WriteString(dbName)
-		buf.WriteString(" IN (")
-		value := reflect.ValueOf(x.Value)
-		switch {
-		case value.Type() == subqueryType:
-			buf.WriteString(value.String())
-		case value.Type().Kind() == reflect.Slice || value.Type().Kind() == reflect.Array:
-			vLen := value.Len()
-			if vLen == 0 {
-				return fmt.Errorf("empty IN (field %s)", x.Field.Field)
-			}
-			jj := len(*params) + begin
-			for ii := 0; ii < vLen; ii++ {
-				*params = append(*params, value.Index(ii).Interface())
-				buf.WriteString(d.backend.Placeholder(jj))
-				buf.WriteByte(',')
-				jj++
-			}
-			buf.Truncate(buf.Len() - 1)
-		default:
-			return fmt.Errorf("argument for IN must be slice or array or query.Subquery (field %s)", x.Field.Field)
+		buf.WriteString(cond)
+		*params = append(*params, args...)
+	case *query.JSONContains:
+		cond, args, err := d.jsonContains(m, x, len(*params)+begin)
+		if err != nil {
+			return err
 		}
-		buf.WriteByte(')')
+		buf.WriteString(cond)
+		*params = append(*params, args...)
+	case *query.RawSQL:
+		err = d.raw(buf, params, m, x, begin)
 	case *query.And:
 		err = d.conditions(buf, params, m, x.Conditions, " AND ", begin)
 	case *query.Or:
@@ -743,26 +1169,150 @@ func (d *Driver) clause(buf *bytes.Buffer, params *[]interface{}, m driver.Model
 		return err
 	}
 	if f.Value != nil {
-		if field, ok := f.Value.(query.F); ok {
-			fName, _, err := m.Map(string(field))
-			if err != nil {
-				return err
-			}
-			fmt.Fprintf(buf, format, dbName, fName)
-			return nil
-		}
-		if sq, ok := f.Value.(query.Subquery); ok {
-			fmt.Fprintf(buf, format, dbName, "("+string(sq)+")")
-			return nil
+		op, err := d.operand(params, m, f.Field, f.Value, begin)
+		if err != nil {
+			return err
 		}
-		fmt.Fprintf(buf, format, dbName, d.backend.Placeholder(len(*params)+begin))
-		*params = append(*params, f.Value)
+		fmt.Fprintf(buf, format, dbName, op)
 		return nil
 	}
 	fmt.Fprintf(buf, format, dbName)
 	return nil
 }
 
+// operand returns the SQL for using the given value in a condition with
+// the given field. Fields and subqueries are inlined, while other values
+// are added to params.
+func (d *Driver) operand(params *[]interface{}, m driver.Model, field string, value interface{}, begin int) (string, error) {
+	if field, ok := value.(query.F); ok {
+		fName, _, err := m.Map(string(field))
+		if err != nil {
+			return "", err
+		}
+		return fName, nil
+	}
+	if sq, ok := value.(query.Subquery); ok {
+		return "(" + string(sq) + ")", nil
+	}
+	placeholder := d.backend.Placeholder(len(*params) + begin)
+	*params = append(*params, d.outParam(m, field, value))
+	return placeholder, nil
+}
+
+func (d *Driver) between(buf *bytes.Buffer, params *[]interface{}, m driver.Model, b *query.Between, begin int) error {
+	dbName, _, err := m.Map(b.Field.Field)
+	if err != nil {
+		return err
+	}
+	lo, err := d.operand(params, m, b.Field.Field, b.Value, begin)
+	if err != nil {
+		return err
+	}
+	hi, err := d.operand(params, m, b.Field.Field, b.End, begin)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(buf, "%s BETWEEN %s AND %s", dbName, lo, hi)
+	return nil
+}
+
+// inClause generates an IN or NOT IN clause, as indicated by op, for
+// the given field. Its value must be a slice, an array or a query.Subquery.
+func (d *Driver) inClause(buf *bytes.Buffer, params *[]interface{}, m driver.Model, op string, f *query.Field, begin int) error {
+	dbName, _, err := m.Map(f.Field)
+	if err != nil {
+		return err
+	}
+	buf.WriteString(dbName)
+	buf.WriteByte(' ')
+	buf.WriteString(op)
+	buf.WriteString(" (")
+	if sel, ok := f.Value.(driver.Selector); ok {
+		sub, err := sel.Subselect()
+		if err != nil {
+			return err
+		}
+		if err := d.subselect(buf, params, sub, begin); err != nil {
+			return err
+		}
+		buf.WriteByte(')')
+		return nil
+	}
+	value := reflect.ValueOf(f.Value)
+	switch {
+	case !value.IsValid():
+		return fmt.Errorf("nil argument for %s (field %s)", op, f.Field)
+	case value.Type() == subqueryType:
+		buf.WriteString(value.String())
+	case value.Type().Kind() == reflect.Slice || value.Type().Kind() == reflect.Array:
+		vLen := value.Len()
+		if vLen == 0 {
+			return fmt.Errorf("empty %s (field %s)", op, f.Field)
+		}
+		jj := len(*params) + begin
+		for ii := 0; ii < vLen; ii++ {
+			*params = append(*params, d.outParam(m, f.Field, value.Index(ii).Interface()))
+			buf.WriteString(d.backend.Placeholder(jj))
+			buf.WriteByte(',')
+			jj++
+		}
+		buf.Truncate(buf.Len() - 1)
+	default:
+		return fmt.Errorf("argument for %s must be slice or array or query.Subquery (field %s)", op, f.Field)
+	}
+	buf.WriteByte(')')
+	return nil
+}
+
+// subselect writes the SQL for the given Subselect into buf, without
+// enclosing it in parentheses.
+func (d *Driver) subselect(buf *bytes.Buffer, params *[]interface{}, s *driver.Subselect, begin int) error {
+	if s.Model.Join() != nil {
+		return errors.New("subqueries can't have joins")
+	}
+	dbName, _, err := s.Model.Map(s.Field)
+	if err != nil {
+		return err
+	}
+	if err := d.SelectStmt(buf, params, []string{dbName}, false, s.Model); err != nil {
+		return err
+	}
+	if !isNil(s.Q) {
+		buf.WriteString(" WHERE ")
+		if err := d.condition(buf, params, s.Model, s.Q, begin); err != nil {
+			return err
+		}
+	}
+	return d.orderLimit(buf, s.Model, s.Sort, s.Limit, s.Offset)
+}
+
+// likeClause generates a LIKE clause which matches values starting with
+// the value in f or, if contains is true, containing it. Wildcards in the
+// value are escaped. When the value is a field or a subquery, it can't be
+// escaped and the wildcards are concatenated by the database instead.
+func (d *Driver) likeClause(buf *bytes.Buffer, params *[]interface{}, m driver.Model, f *query.Field, contains bool, begin int) error {
+	var value string
+	switch v := f.Value.(type) {
+	case query.F, query.Subquery:
+		format := "%s LIKE %s || '%%'"
+		if contains {
+			format = "%s LIKE '%%' || %s || '%%'"
+		}
+		return d.clause(buf, params, m, format, f, begin)
+	case string:
+		value = v
+	default:
+		value = fmt.Sprint(v)
+	}
+	pattern := query.EscapeLike(value) + "%"
+	if contains {
+		pattern = "%" + pattern
+	}
+	// Don't modify f, since the query might be reused
+	escaped := &query.Field{Field: f.Field, Value: pattern}
+	return d.clause(buf, params, m, "%s LIKE %s"+likeEscape, escaped, begin)
+}
+
 func (d *Driver) conditions(buf *bytes.Buffer, params *[]interface{}, m driver.Model, q []query.Q, sep string, begin int) error {
 	buf.WriteByte('(')
 	for _, v := range q {
@@ -779,6 +1329,9 @@ func (d *Driver) conditions(buf *bytes.Buffer, params *[]interface{}, m driver.M
 
 func (d *Driver) SelectStmt(buf *bytes.Buffer, params *[]interface{}, fields []string, quote bool, m driver.Model) error {
 	buf.WriteString("SELECT ")
+	if fields == nil && m.Distinct() {
+		buf.WriteString("DISTINCT ")
+	}
 	if fields != nil {
 		if quote {
 			for _, v := range fields {
@@ -839,6 +1392,10 @@ func (d *Driver) SelectStmt(buf *bytes.Buffer, params *[]interface{}, fields []s
 }
 
 func (d *Driver) Select(fields []string, quote bool, m driver.Model, q query.Q, sort []driver.Sort, limit int, offset int) (*bytes.Buffer, []interface{}, error) {
+	return d.selectGroup(fields, quote, m, q, nil, sort, limit, offset)
+}
+
+func (d *Driver) selectGroup(fields []string, quote bool, m driver.Model, q query.Q, groupBy []string, sort []driver.Sort, limit int, offset int) (*bytes.Buffer, []interface{}, error) {
 	buf := getBuffer()
 	var params []interface{}
 	if err := d.SelectStmt(buf, &params, fields, quote, m); err != nil {
@@ -849,12 +1406,24 @@ func (d *Driver) Select(fields []string, quote bool, m driver.Model, q query.Q,
 		return nil, nil, err
 	}
 	params = append(params, qParams...)
+	if len(groupBy) > 0 {
+		buf.WriteString(" GROUP BY ")
+		buf.WriteString(strings.Join(groupBy, ","))
+	}
+	if err := d.orderLimit(buf, m, sort, limit, offset); err != nil {
+		return nil, nil, err
+	}
+	return buf, params, nil
+}
+
+// orderLimit writes the ORDER BY, LIMIT and OFFSET clauses.
+func (d *Driver) orderLimit(buf *bytes.Buffer, m driver.Model, sort []driver.Sort, limit int, offset int) error {
 	if len(sort) > 0 {
 		buf.WriteString(" ORDER BY ")
 		for _, v := range sort {
 			dbName, _, err := m.Map(v.Field())
 			if err != nil {
-				return nil, nil, err
+				return err
 			}
 			buf.WriteString(dbName)
 			if v.Direction() == driver.DESC {
@@ -872,7 +1441,7 @@ func (d *Driver) Select(fields []string, quote bool, m driver.Model, q query.Q,
 		buf.WriteString(" OFFSET ")
 		buf.WriteString(strconv.Itoa(offset))
 	}
-	return buf, params, nil
+	return nil
 }
 
 func (d *Driver) Begin() (driver.Tx, error) {
@@ -882,12 +1451,22 @@ func (d *Driver) Begin() (driver.Tx, error) {
 	}
 	drv := *d
 	drv.db = tx
+	// Initialize written, so copies made by WithComment
+	// share it with the transaction driver.
+	drv.written = make(map[string]struct{})
 	tx.driver = &drv
 	return &drv, nil
 }
 
 func (d *Driver) Commit() error {
-	return d.db.Commit()
+	if err := d.db.Commit(); err != nil {
+		return err
+	}
+	for k := range d.written {
+		d.invalidateTable(k)
+	}
+	d.written = nil
+	return nil
 }
 
 func (d *Driver) Rollback() error {
@@ -898,10 +1477,16 @@ func (d *Driver) Transaction(f func(driver.Driver) error) error {
 	return nil
 }
 
+// IsRetryable returns true iff the given error was caused by a
+// transient conflict between transactions, according to the Backend.
+func (d *Driver) IsRetryable(err error) bool {
+	return d.backend.IsRetryable(err)
+}
+
 func (d *Driver) Capabilities() driver.Capability {
 	return driver.CAP_JOIN | driver.CAP_OR | driver.CAP_TRANSACTION | driver.CAP_BEGIN |
 		driver.CAP_AUTO_ID | driver.CAP_AUTO_INCREMENT | driver.CAP_PK |
-		driver.CAP_COMPOSITE_PK | driver.CAP_UNIQUE | driver.CAP_DEFAULTS |
+		driver.CAP_COMPOSITE_PK | driver.CAP_UNIQUE | driver.CAP_DEFAULTS | driver.CAP_DISTINCT |
 		d.backend.Capabilities()
 }
 
@@ -925,6 +1510,18 @@ func NewDriver(b Backend, url *config.URL) (*Driver, error) {
 	if mic, ok := url.Fragment.Int("max_idle_conns"); ok {
 		conn.SetMaxIdleConns(mic)
 	}
+	stmtCacheSize := DefaultStmtCacheSize
+	if sc, ok := url.Fragment.Int("stmt_cache"); ok {
+		stmtCacheSize = sc
+	}
+	var slowQuery time.Duration
+	if sq := url.Fragment.Get("slow_query"); sq != "" {
+		slowQuery, err = time.ParseDuration(sq)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("invalid slow_query %q: %s", sq, err)
+		}
+	}
 	var transforms map[reflect.Type]struct{}
 	if tt := b.Transforms(); len(tt) > 0 {
 		transforms = make(map[reflect.Type]struct{}, len(tt)*2)
@@ -933,8 +1530,8 @@ func NewDriver(b Backend, url *config.URL) (*Driver, error) {
 			transforms[v.Elem()] = struct{}{}
 		}
 	}
-	driver := &Driver{backend: b, transforms: transforms}
-	driver.db = &DB{sqlDb: conn, conn: conn, driver: driver, replacesPlaceholders: b.Placeholder(0) != "?"}
+	driver := &Driver{backend: b, transforms: transforms, stats: newQueryStats(slowQuery)}
+	driver.db = &DB{sqlDb: conn, conn: conn, driver: driver, replacesPlaceholders: b.Placeholder(0) != "?", stmts: newStmtCache(stmtCacheSize)}
 	return driver, nil
 }
 
@@ -0,0 +1,16 @@
+package sql
+
+import (
+	"strings"
+)
+
+// EnumValues returns the allowed values for an enum field
+// as a comma separated list of quoted strings, suitable for
+// using inside an ENUM type or an IN constraint.
+func EnumValues(db *DB, f *Field) string {
+	quoted := make([]string, len(f.Enum))
+	for ii, v := range f.Enum {
+		quoted[ii] = db.QuoteString(v)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,62 @@
+package sql
+
+import (
+	"database/sql"
+
+	"gnd.la/orm/driver"
+	"gnd.la/orm/query"
+)
+
+// Explain returns EXPLAIN or EXPLAIN ANALYZE, which are supported
+// by most backends.
+func (b *SqlBackend) Explain(db *DB, analyze bool) (string, error) {
+	if analyze {
+		return "EXPLAIN ANALYZE", nil
+	}
+	return "EXPLAIN", nil
+}
+
+// Explain returns the execution plan for the query which would be
+// performed by Query with the same arguments. If analyze is true, the
+// query is executed and the plan includes the actual timings, if the
+// backend supports it.
+func (d *Driver) Explain(m driver.Model, q query.Q, sort []driver.Sort, limit int, offset int, analyze bool) (*driver.Plan, error) {
+	explain, err := d.backend.Explain(d.db, analyze)
+	if err != nil {
+		return nil, err
+	}
+	query, params, err := d.Select(nil, true, m, q, sort, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := d.db.Query(explain+" "+buftos(query), params...)
+	putBuffer(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	plan := &driver.Plan{Columns: columns}
+	values := make([]sql.NullString, len(columns))
+	scanners := make([]interface{}, len(columns))
+	for ii := range values {
+		scanners[ii] = &values[ii]
+	}
+	for rows.Next() {
+		if err := rows.Scan(scanners...); err != nil {
+			return nil, err
+		}
+		row := make([]string, len(values))
+		for ii, v := range values {
+			row[ii] = v.String
+		}
+		plan.Rows = append(plan.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
@@ -0,0 +1,40 @@
+package sql
+
+import (
+	"fmt"
+
+	"gnd.la/orm/driver"
+	"gnd.la/orm/query"
+	"gnd.la/util/geo"
+)
+
+// Near returns a condition which uses the haversine formula to
+// check if the point stored in the lat and lng columns is within
+// radius meters of p. The database must support the RADIANS, SIN,
+// COS, ASIN, SQRT and POWER functions.
+func (b *SqlBackend) Near(db *DB, lat string, lng string, p geo.Point, radius float64, n int) (string, []interface{}, error) {
+	ph := db.Backend().Placeholder
+	cond := fmt.Sprintf("2 * %v * ASIN(SQRT(POWER(SIN(RADIANS(%s - %s) / 2), 2) + "+
+		"COS(RADIANS(%s)) * COS(RADIANS(%s)) * POWER(SIN(RADIANS(%s - %s) / 2), 2))) <= %s",
+		geo.EarthRadius, lat, ph(n), ph(n+1), lat, lng, ph(n+2), ph(n+3))
+	return cond, []interface{}{p.Lat, p.Lat, p.Lng, radius}, nil
+}
+
+func (d *Driver) near(m driver.Model, q *query.Near, n int) (string, []interface{}, error) {
+	p, ok := q.Value.(geo.Point)
+	if !ok {
+		return "", nil, fmt.Errorf("argument for Near must be geo.Point, not %T (field %s)", q.Value, q.Field.Field)
+	}
+	if !p.IsValid() {
+		return "", nil, fmt.Errorf("invalid point %v for Near (field %s)", p, q.Field.Field)
+	}
+	lat, _, err := m.Map(q.Field.Field + ".Lat")
+	if err != nil {
+		return "", nil, err
+	}
+	lng, _, err := m.Map(q.Field.Field + ".Lng")
+	if err != nil {
+		return "", nil, err
+	}
+	return d.backend.Near(d.db, lat, lng, p, q.Radius, n)
+}
@@ -0,0 +1,43 @@
+package sql
+
+import (
+	"context"
+	"time"
+
+	"gnd.la/orm/driver"
+)
+
+func noopInterceptorDone(error) {}
+
+// AddInterceptor adds an interceptor which is notified before and
+// after every query executed by the driver. Interceptors are called
+// in the order they were added for BeforeQuery and in the reverse
+// order for AfterQuery. Note that interceptors should be added before
+// the driver is used, since transactions use a copy of the driver.
+func (d *Driver) AddInterceptor(i driver.Interceptor) {
+	interceptors := make([]driver.Interceptor, len(d.interceptors), len(d.interceptors)+1)
+	copy(interceptors, d.interceptors)
+	d.interceptors = append(interceptors, i)
+}
+
+// intercept calls BeforeQuery on the driver interceptors and returns
+// the context the query should be executed with, as well as a function
+// which must be called with the query error once it's finished.
+func (d *DB) intercept(ctx context.Context, query string, args []interface{}) (context.Context, func(error)) {
+	interceptors := d.driver.interceptors
+	if len(interceptors) == 0 {
+		return ctx, noopInterceptorDone
+	}
+	ctxs := make([]context.Context, len(interceptors))
+	for ii, v := range interceptors {
+		ctx = v.BeforeQuery(ctx, query, args)
+		ctxs[ii] = ctx
+	}
+	started := time.Now()
+	return ctx, func(err error) {
+		elapsed := time.Since(started)
+		for ii := len(interceptors) - 1; ii >= 0; ii-- {
+			interceptors[ii].AfterQuery(ctxs[ii], query, args, elapsed, err)
+		}
+	}
+}
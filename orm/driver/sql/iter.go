@@ -9,7 +9,7 @@ import (
 type Iter struct {
 	model  driver.Model
 	driver *Driver
-	rows   *sql.Rows
+	rows   rows
 	err    error
 }
 
@@ -107,10 +107,13 @@ func (i *Iter) Close() error {
 func NewIter(m driver.Model, d driver.Driver, r *sql.Rows, err error) driver.Iter {
 	// TODO: Check for errors here?
 	drv, _ := d.(*Driver)
-	return &Iter{
+	iter := &Iter{
 		model:  m,
 		driver: drv,
-		rows:   r,
 		err:    err,
 	}
+	if r != nil {
+		iter.rows = r
+	}
+	return iter
 }
@@ -0,0 +1,27 @@
+package sql
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gnd.la/orm/driver"
+	"gnd.la/orm/query"
+)
+
+// JSONContains returns an error, since there's no standard SQL for
+// querying JSON documents.
+func (b *SqlBackend) JSONContains(db *DB, column string, doc string, n int) (string, []interface{}, error) {
+	return "", nil, fmt.Errorf("backend %s does not support JSON queries", db.Backend().Name())
+}
+
+func (d *Driver) jsonContains(m driver.Model, q *query.JSONContains, n int) (string, []interface{}, error) {
+	column, _, err := m.Map(q.Field.Field)
+	if err != nil {
+		return "", nil, err
+	}
+	doc, err := json.Marshal(q.Value)
+	if err != nil {
+		return "", nil, fmt.Errorf("can't encode argument for JSONContains (field %s): %s", q.Field.Field, err)
+	}
+	return d.backend.JSONContains(d.db, column, string(doc), n)
+}
@@ -0,0 +1,69 @@
+package sql
+
+import (
+	sqldriver "database/sql/driver"
+	"fmt"
+	"strings"
+
+	"gnd.la/orm/driver"
+)
+
+// Migrate works like Initialize, creating the missing tables, columns
+// and indexes for the given models, but it also returns the statements
+// executed to bring the schema up to date. If dryRun is true, the
+// statements are not executed, so the database is left untouched and
+// the returned statements represent the planned changes.
+func (d *Driver) Migrate(ms []driver.Model, dryRun bool) ([]string, error) {
+	var statements []string
+	if err := d.recorder(&statements, dryRun).Initialize(ms); err != nil {
+		return statements, err
+	}
+	return statements, nil
+}
+
+// recorder returns a copy of the driver which appends the statements
+// it executes to the given slice. If dryRun is true, the statements
+// are recorded but not executed.
+func (d *Driver) recorder(statements *[]string, dryRun bool) *Driver {
+	drv := *d
+	db := *d.db
+	db.driver = &drv
+	db.statements = statements
+	db.dryRun = dryRun
+	drv.db = &db
+	return &drv
+}
+
+// record appends the given statement to the recorded ones,
+// with its arguments interpolated.
+func (d *DB) record(query string, args []interface{}) {
+	if len(args) > 0 {
+		parts := strings.Split(query, "?")
+		if len(parts) == len(args)+1 {
+			var buf []string
+			for ii, v := range args {
+				buf = append(buf, parts[ii], d.literal(v))
+			}
+			buf = append(buf, parts[len(parts)-1])
+			query = strings.Join(buf, "")
+		}
+	}
+	*d.statements = append(*d.statements, query)
+}
+
+func (d *DB) literal(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return d.QuoteString(x)
+	case []byte:
+		return d.QuoteString(string(x))
+	case sqldriver.Valuer:
+		val, err := x.Value()
+		if err == nil {
+			return d.literal(val)
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
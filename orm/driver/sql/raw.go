@@ -0,0 +1,55 @@
+package sql
+
+import (
+	"bytes"
+	"fmt"
+
+	"gnd.la/orm/driver"
+	"gnd.la/orm/query"
+)
+
+// raw writes the SQL fragment in r to buf, replacing each ? outside of
+// quotes with the operand for the corresponding argument and ?? with a
+// literal ?. The fragment is enclosed in parentheses, so it can be safely
+// combined with other conditions.
+func (d *Driver) raw(buf *bytes.Buffer, params *[]interface{}, m driver.Model, r *query.RawSQL, begin int) error {
+	buf.WriteByte('(')
+	var quote byte
+	arg := 0
+	s := r.SQL
+	for ii := 0; ii < len(s); ii++ {
+		c := s[ii]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			if ii+1 < len(s) && s[ii+1] == '?' {
+				ii++
+				break
+			}
+			if arg >= len(r.Args) {
+				return fmt.Errorf("raw SQL %q has more placeholders than arguments (%d)", s, len(r.Args))
+			}
+			op, err := d.operand(params, m, "", r.Args[arg], begin)
+			if err != nil {
+				return err
+			}
+			buf.WriteString(op)
+			arg++
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	if quote != 0 {
+		return fmt.Errorf("raw SQL %q has an unterminated quote", s)
+	}
+	if arg != len(r.Args) {
+		return fmt.Errorf("raw SQL %q has %d placeholders, but %d arguments were provided", s, arg, len(r.Args))
+	}
+	buf.WriteByte(')')
+	return nil
+}
@@ -0,0 +1,114 @@
+package sql
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"gnd.la/orm/driver"
+	"gnd.la/orm/operation"
+	"gnd.la/orm/query"
+)
+
+// Returning returns false, since RETURNING is not
+// supported by all backends.
+func (b *SqlBackend) Returning() bool {
+	return false
+}
+
+// Returning returns true iff the backend supports returning the
+// primary keys of the rows affected by an UPDATE or a DELETE in
+// the same statement.
+func (d *Driver) Returning() bool {
+	return d.backend.Returning()
+}
+
+// UpdateReturning works like Update, but returns the primary keys
+// of the updated rows. See returning for the format of the keys.
+func (d *Driver) UpdateReturning(m driver.Model, q query.Q, data interface{}) ([]interface{}, error) {
+	buf, params, err := d.updateQuery(m, q, data)
+	if err != nil {
+		return nil, err
+	}
+	return d.returning(m, buf, params)
+}
+
+// OperateReturning works like Operate, but returns the primary keys
+// of the updated rows. See returning for the format of the keys.
+func (d *Driver) OperateReturning(m driver.Model, q query.Q, ops []*operation.Operation) ([]interface{}, error) {
+	buf, params, err := d.operateQuery(m, q, ops)
+	if err != nil {
+		return nil, err
+	}
+	return d.returning(m, buf, params)
+}
+
+// DeleteReturning works like Delete, but returns the primary keys
+// of the deleted rows. See returning for the format of the keys.
+func (d *Driver) DeleteReturning(m driver.Model, q query.Q) ([]interface{}, error) {
+	buf, params, err := d.deleteQuery(m, q)
+	if err != nil {
+		return nil, err
+	}
+	return d.returning(m, buf, params)
+}
+
+// returning executes the statement in buf with a RETURNING clause
+// for the primary key of m and releases buf. Each returned key has
+// the same type as the primary key field or, for composite primary
+// keys, it's an []interface{} with the values of its fields, in the
+// same order they were declared.
+func (d *Driver) returning(m driver.Model, buf *bytes.Buffer, params []interface{}) ([]interface{}, error) {
+	defer putBuffer(buf)
+	if !d.backend.Returning() {
+		return nil, fmt.Errorf("backend %s does not support RETURNING", d.backend.Name())
+	}
+	fields := m.Fields()
+	pks := fields.CompositePrimaryKey
+	if fields.PrimaryKey >= 0 {
+		pks = []int{fields.PrimaryKey}
+	}
+	if len(pks) == 0 {
+		return nil, fmt.Errorf("model %s does not have a primary key", m.Table())
+	}
+	buf.WriteString(" RETURNING ")
+	for ii, v := range pks {
+		if ii > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('"')
+		buf.WriteString(fields.MNames[v])
+		buf.WriteByte('"')
+	}
+	rows, err := d.db.Query(d.commented(buftos(buf)), params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	d.invalidate(m)
+	var keys []interface{}
+	values := make([]reflect.Value, len(pks))
+	scanners := make([]interface{}, len(pks))
+	for rows.Next() {
+		for ii, v := range pks {
+			values[ii] = reflect.New(fields.Types[v]).Elem()
+			scanners[ii] = newScanner(&values[ii], fields.Tags[v], d.backend)
+		}
+		if err := rows.Scan(scanners...); err != nil {
+			return nil, err
+		}
+		if len(values) == 1 {
+			keys = append(keys, values[0].Interface())
+			continue
+		}
+		key := make([]interface{}, len(values))
+		for ii, v := range values {
+			key[ii] = v.Interface()
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
@@ -7,6 +7,8 @@ import (
 
 	"gnd.la/encoding/codec"
 	"gnd.la/encoding/pipe"
+	"gnd.la/orm/driver"
+	"gnd.la/util/decimal"
 	"gnd.la/util/structs"
 
 	"gopkgs.com/pool.v1"
@@ -21,10 +23,26 @@ type scanner struct {
 	Tag     *structs.Tag
 	Nil     bool
 	Backend Backend
+	Times   *driver.TimeOptions
 }
 
-// Always assume the type is right
 func (s *scanner) Scan(src interface{}) error {
+	if err := s.scan(src); err != nil {
+		return err
+	}
+	if s.Times != nil && s.Out.Type() == timeType {
+		s.Out.Set(reflect.ValueOf(s.Times.In(s.Out.Interface().(time.Time))))
+	}
+	return nil
+}
+
+// Always assume the type is right
+func (s *scanner) scan(src interface{}) error {
+	if src != nil && s.Out.Type() == decimalType {
+		// Backends return NUMERIC as either []byte, string,
+		// int64 or float64, decimal.Decimal handles all of them.
+		return s.Out.Addr().Interface().(*decimal.Decimal).Scan(src)
+	}
 	switch x := src.(type) {
 	case nil:
 		// Assign zero to the type
@@ -52,6 +70,11 @@ func (s *scanner) Scan(src interface{}) error {
 
 		return s.Backend.ScanByteSlice(x, s.Out, s.Tag)
 	case string:
+		if c := codec.FromTag(s.Tag); c != nil && s.Tag.PipeName() == "" {
+			// Text codecs stored in text columns
+			s.Nil = len(x) == 0
+			return c.Decode([]byte(x), s.Out.Addr().Interface())
+		}
 		return s.Backend.ScanString(x, s.Out, s.Tag)
 	case time.Time:
 		return s.Backend.ScanTime(&x, s.Out, s.Tag)
@@ -66,6 +89,7 @@ func newScanner(val *reflect.Value, t *structs.Tag, backend Backend) *scanner {
 		s.Tag = t
 		s.Nil = false
 		s.Backend = backend
+		s.Times = nil
 		return s
 	}
 	return &scanner{Out: val, Tag: t, Backend: backend}
@@ -0,0 +1,146 @@
+package sql
+
+import (
+	"strings"
+
+	"gnd.la/orm/driver"
+)
+
+// Index represents an index as it exists in the database.
+type Index struct {
+	Name   string
+	Fields []string
+	Unique bool
+}
+
+// Schema represents the schema of a database, as returned
+// by Driver.Schema. Tables contains the tables as they exist
+// in the database, while Models contains the tables as they
+// are defined by the models, which might differ (e.g. when
+// a column was altered by hand or a migration is pending).
+type Schema struct {
+	Tables []*Table
+	Models []*Table
+	driver *Driver
+	models []driver.Model
+}
+
+// Table returns the table with the given name, or nil
+// if there's no such table.
+func (s *Schema) Table(name string) *Table {
+	for _, v := range s.Tables {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// Model returns the table defined by the model with the given
+// table name, or nil if there's no such model.
+func (s *Schema) Model(name string) *Table {
+	for _, v := range s.Models {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// DDL returns the statements which create the tables and indexes
+// in s.Models, separated by semicolons, so they can be reviewed
+// or applied by hand. The statements are generated by the backend,
+// but never executed.
+func (s *Schema) DDL() (string, error) {
+	if s.driver == nil {
+		return "", nil
+	}
+	var statements []string
+	drv := s.driver.recorder(&statements, true)
+	for _, m := range s.models {
+		table := s.Model(m.Table())
+		if table == nil {
+			continue
+		}
+		if err := drv.createTable(m, table); err != nil {
+			return "", err
+		}
+		for ii, idx := range m.Indexes() {
+			sql, err := drv.indexSQL(m, idx, table.Indexes[ii].Name)
+			if err != nil {
+				return "", err
+			}
+			statements = append(statements, sql)
+		}
+	}
+	var buf []string
+	for _, v := range statements {
+		buf = append(buf, strings.TrimSpace(v)+";\n")
+	}
+	return strings.Join(buf, "\n"), nil
+}
+
+// Schema inspects the database and returns all its tables, including
+// their fields, constraints and indexes. Note that tables which are not
+// managed by the ORM are also included. The tables defined by the given
+// models are returned in Schema.Models.
+func (d *Driver) Schema(ms []driver.Model) (*Schema, error) {
+	names, err := d.backend.Tables(d.db)
+	if err != nil {
+		return nil, err
+	}
+	schema := &Schema{driver: d}
+	for _, v := range names {
+		table, err := d.backend.InspectTable(d.db, v)
+		if err != nil {
+			return nil, err
+		}
+		if table == nil {
+			// Dropped while we were inspecting the DB
+			continue
+		}
+		table.Name = v
+		if table.Indexes, err = d.backend.Indexes(d.db, v); err != nil {
+			return nil, err
+		}
+		schema.Tables = append(schema.Tables, table)
+	}
+	for _, v := range ms {
+		table, err := d.modelTable(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(table.Fields) == 0 {
+			continue
+		}
+		schema.Models = append(schema.Models, table)
+		schema.models = append(schema.models, v)
+	}
+	return schema, nil
+}
+
+// modelTable returns the table defined by the given model,
+// including its indexes.
+func (d *Driver) modelTable(m driver.Model) (*Table, error) {
+	table, err := d.makeTable(m)
+	if err != nil {
+		return nil, err
+	}
+	fields := m.Fields()
+	for _, idx := range m.Indexes() {
+		name, err := d.indexName(m, idx)
+		if err != nil {
+			return nil, err
+		}
+		index := &Index{Name: name, Unique: idx.Unique}
+		for _, f := range idx.Fields {
+			col, _, err := fields.Map(f)
+			if err != nil {
+				return nil, err
+			}
+			index.Fields = append(index.Fields, col)
+		}
+		table.Indexes = append(table.Indexes, index)
+	}
+	return table, nil
+}
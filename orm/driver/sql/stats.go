@@ -0,0 +1,106 @@
+package sql
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"gnd.la/orm/driver"
+)
+
+// queryStats collects the timings for the queries executed
+// by a Driver. Queries slower than slow, if non-zero, are logged
+// using the Driver logger. The threshold can be set using the
+// slow_query option in the database URL
+// (e.g. postgres://dbname=foo#slow_query=200ms).
+//
+// Queries are keyed by their statement, without the comments
+// added by WithComment, and at most maxQueryStats different
+// statements are tracked. Once the limit is reached, timings for
+// new statements are accumulated under otherQueries.
+type queryStats struct {
+	mu      sync.Mutex
+	queries map[string]*driver.QueryStats
+	slow    time.Duration
+}
+
+const (
+	maxQueryStats = 1000
+	otherQueries  = "(other)"
+)
+
+func newQueryStats(slow time.Duration) *queryStats {
+	return &queryStats{queries: make(map[string]*driver.QueryStats), slow: slow}
+}
+
+func (s *queryStats) record(query string, elapsed time.Duration) {
+	query = normalizeQuery(query)
+	s.mu.Lock()
+	qs := s.queries[query]
+	if qs == nil {
+		if len(s.queries) >= maxQueryStats {
+			query = otherQueries
+			qs = s.queries[query]
+		}
+		if qs == nil {
+			qs = new(driver.QueryStats)
+			s.queries[query] = qs
+		}
+	}
+	qs.Count++
+	qs.Total += elapsed
+	if elapsed > qs.Max {
+		qs.Max = elapsed
+	}
+	s.mu.Unlock()
+}
+
+func (s *queryStats) copy() map[string]*driver.QueryStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queries := make(map[string]*driver.QueryStats, len(s.queries))
+	for k, v := range s.queries {
+		qs := *v
+		queries[k] = &qs
+	}
+	return queries
+}
+
+// normalizeQuery removes the leading comments added by WithComment,
+// since they usually change on every request.
+func normalizeQuery(query string) string {
+	for strings.HasPrefix(query, "/*") {
+		end := strings.Index(query, "*/")
+		if end < 0 {
+			break
+		}
+		query = strings.TrimSpace(query[end+2:])
+	}
+	return query
+}
+
+// observe records the time elapsed since started for the given
+// query and logs it if it exceeds the slow query threshold. The
+// query arguments are never logged, since they might contain
+// sensitive data.
+func (d *Driver) observe(query string, args []interface{}, started time.Time) {
+	elapsed := time.Since(started)
+	d.stats.record(query, elapsed)
+	if d.stats.slow > 0 && elapsed > d.stats.slow && d.logger != nil {
+		d.logger.Warningf("slow SQL (%s): %s (%d arguments)", elapsed, query, len(args))
+	}
+}
+
+// Stats returns the connection pool statistics and the timings
+// for the queries executed by the driver. Timings for queries
+// returning rows only include the time until the first row is
+// available, not the time spent iterating over them.
+func (d *Driver) Stats() *driver.Stats {
+	st := d.db.sqlDb.Stats()
+	return &driver.Stats{
+		OpenConnections: st.OpenConnections,
+		InUse:           st.InUse,
+		Idle:            st.Idle,
+		Queries:         d.stats.copy(),
+	}
+}
@@ -0,0 +1,114 @@
+package sql
+
+import (
+	"container/list"
+	"database/sql"
+	"sync"
+)
+
+const (
+	// DefaultStmtCacheSize is the default maximum number of
+	// prepared statements kept by each database connection
+	// pool. It can be changed using the stmt_cache option in
+	// the database URL (e.g. postgres://dbname=foo#stmt_cache=64).
+	// A size of zero disables the cache.
+	DefaultStmtCacheSize = 256
+)
+
+type cacheEntry struct {
+	sql  string
+	stmt *sql.Stmt
+	// number of callers using the statement, evicted
+	// statements are closed once it drops to zero
+	refs    int
+	evicted bool
+}
+
+// stmtCache is an LRU cache of prepared statements keyed by
+// their SQL, shared by all the copies of a DB. When a statement
+// is evicted, it's closed.
+type stmtCache struct {
+	mu      sync.Mutex
+	size    int
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{
+		size:    size,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached entry for the given SQL, or nil. Non-nil
+// entries must be passed to release once the caller is done with
+// the statement.
+func (c *stmtCache) get(s string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem := c.entries[s]; elem != nil {
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*cacheEntry)
+		entry.refs++
+		return entry
+	}
+	return nil
+}
+
+// put adds the statement to the cache and returns the entry
+// which should be used for s, since another goroutine might
+// have prepared it concurrently. As with get, the returned
+// entry must be passed to release.
+func (c *stmtCache) put(s string, stmt *sql.Stmt) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem := c.entries[s]; elem != nil {
+		stmt.Close()
+		c.lru.MoveToFront(elem)
+		entry := elem.Value.(*cacheEntry)
+		entry.refs++
+		return entry
+	}
+	entry := &cacheEntry{sql: s, stmt: stmt, refs: 1}
+	c.entries[s] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.size {
+		c.evict(c.lru.Back())
+	}
+	return entry
+}
+
+// release must be called with c.mu unlocked.
+func (c *stmtCache) release(entry *cacheEntry) {
+	c.mu.Lock()
+	entry.refs--
+	closing := entry.evicted && entry.refs == 0
+	c.mu.Unlock()
+	if closing {
+		entry.stmt.Close()
+	}
+}
+
+// evict must be called with c.mu locked.
+func (c *stmtCache) evict(elem *list.Element) {
+	entry := elem.Value.(*cacheEntry)
+	c.lru.Remove(elem)
+	delete(c.entries, entry.sql)
+	entry.evicted = true
+	if entry.refs == 0 {
+		// Rows still open from previous queries keep
+		// their connection, database/sql defers the
+		// actual close until they're closed.
+		entry.stmt.Close()
+	}
+}
+
+// clear closes and removes all the cached statements.
+func (c *stmtCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.lru.Len() > 0 {
+		c.evict(c.lru.Back())
+	}
+}
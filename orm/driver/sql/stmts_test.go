@@ -0,0 +1,141 @@
+package sql
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"gnd.la/config"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// testBackend implements the methods used by NewDriver
+// using the sqlite3 database/sql driver.
+type testBackend struct {
+	Backend
+}
+
+func (b *testBackend) Name() string               { return "sqlite3" }
+func (b *testBackend) Placeholder(n int) string   { return "?" }
+func (b *testBackend) Transforms() []reflect.Type { return nil }
+
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func prepare(t *testing.T, db *sql.DB, s string) *sql.Stmt {
+	stmt, err := db.Prepare(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stmt
+}
+
+func isClosed(stmt *sql.Stmt) bool {
+	_, err := stmt.Exec()
+	return err != nil
+}
+
+func TestStmtCache(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+	c := newStmtCache(2)
+	if c.get("SELECT 1") != nil {
+		t.Fatal("expecting no entry in empty cache")
+	}
+	s1 := prepare(t, db, "SELECT 1")
+	c.release(c.put("SELECT 1", s1))
+	s2 := prepare(t, db, "SELECT 2")
+	c.release(c.put("SELECT 2", s2))
+	// Move SELECT 1 to the front, so SELECT 2 is evicted
+	e1 := c.get("SELECT 1")
+	if e1 == nil || e1.stmt != s1 || e1.refs != 1 {
+		t.Fatalf("expecting entry for SELECT 1 with 1 ref, got %+v", e1)
+	}
+	c.release(e1)
+	s3 := prepare(t, db, "SELECT 3")
+	c.release(c.put("SELECT 3", s3))
+	if c.get("SELECT 2") != nil {
+		t.Error("expecting SELECT 2 to be evicted")
+	}
+	if !isClosed(s2) {
+		t.Error("expecting evicted statement to be closed")
+	}
+	for _, v := range []*sql.Stmt{s1, s3} {
+		if isClosed(v) {
+			t.Error("expecting cached statement to be open")
+		}
+	}
+	if n := c.lru.Len(); n != 2 || len(c.entries) != 2 {
+		t.Errorf("expecting 2 cached statements, got %d and %d", n, len(c.entries))
+	}
+	c.clear()
+	if c.lru.Len() != 0 || len(c.entries) != 0 {
+		t.Error("expecting empty cache after clearing")
+	}
+	for _, v := range []*sql.Stmt{s1, s3} {
+		if !isClosed(v) {
+			t.Error("expecting statement to be closed after clearing")
+		}
+	}
+}
+
+func TestStmtCacheInUse(t *testing.T) {
+	db := openTestDB(t)
+	defer db.Close()
+	c := newStmtCache(1)
+	s1 := prepare(t, db, "SELECT 1")
+	e1 := c.put("SELECT 1", s1)
+	// Preparing the same statement concurrently returns the
+	// cached entry and closes the new one.
+	dup := prepare(t, db, "SELECT 1")
+	if e := c.put("SELECT 1", dup); e != e1 || e.refs != 2 {
+		t.Errorf("expecting cached entry with 2 refs, got %+v", e)
+	}
+	if !isClosed(dup) {
+		t.Error("expecting duplicate statement to be closed")
+	}
+	c.release(e1)
+	// Evicted while in use, it's closed after releasing it
+	c.release(c.put("SELECT 2", prepare(t, db, "SELECT 2")))
+	if !e1.evicted || isClosed(s1) {
+		t.Fatal("expecting evicted statement in use to be open")
+	}
+	c.release(e1)
+	if !isClosed(s1) {
+		t.Error("expecting evicted statement to be closed after releasing it")
+	}
+}
+
+func TestPreparedStmt(t *testing.T) {
+	for _, v := range []struct {
+		url    string
+		cached bool
+	}{
+		{"sqlite3://:memory:", true},
+		{"sqlite3://:memory:#stmt_cache=0", false},
+	} {
+		drv, err := NewDriver(&testBackend{}, config.MustParseURL(v.url))
+		if err != nil {
+			t.Fatal(err)
+		}
+		db := drv.DB()
+		stmt, release := db.preparedStmt("SELECT 1")
+		if (stmt != nil) != v.cached {
+			t.Errorf("expecting cached statement = %v with %s, got %v", v.cached, v.url, stmt != nil)
+		}
+		if stmt != nil {
+			release()
+		}
+		// Commented queries are never prepared
+		if stmt, _ := db.preparedStmt("/* comment */ SELECT 1"); stmt != nil {
+			t.Errorf("expecting commented query not to be prepared with %s", v.url)
+		}
+		drv.Close()
+	}
+}
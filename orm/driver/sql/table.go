@@ -49,8 +49,17 @@ type Field struct {
 	Name        string
 	Type        string
 	Default     string
+	Comment     string
 	Options     []FieldOption
 	Constraints []*Constraint
+	// Enum contains the allowed values for enum fields. The
+	// SqlBackend uses a CHECK constraint to enforce them, while
+	// other backends might use a native ENUM type.
+	Enum []string
+	// Check is the expression for the CHECK constraint declared
+	// with the check tag option, with the field references
+	// already replaced by their quoted column names.
+	Check string
 }
 
 func (f *Field) AddOption(opt FieldOption) {
@@ -95,6 +104,24 @@ func (f *Field) Copy() *Field {
 type Constraint struct {
 	Type       ConstraintType
 	References Reference
+	// OnDelete and OnUpdate are only used for
+	// ConstraintForeignKey. See driver.Reference.
+	OnDelete string
+	OnUpdate string
+}
+
+// Actions returns the ON DELETE and ON UPDATE clauses for
+// a foreign key constraint, with a leading space, or an empty
+// string if the constraint has no actions.
+func (c *Constraint) Actions() string {
+	var s string
+	if c.OnDelete != "" {
+		s += " ON DELETE " + c.OnDelete
+	}
+	if c.OnUpdate != "" {
+		s += " ON UPDATE " + c.OnUpdate
+	}
+	return s
 }
 
 func (c *Constraint) String() string {
@@ -106,14 +133,18 @@ func (c *Constraint) String() string {
 	case ConstraintPrimaryKey:
 		return "PRIMARY_KEY"
 	case ConstraintForeignKey:
-		return fmt.Sprintf("FOREIGN_KEY %s", string(c.References))
+		return fmt.Sprintf("FOREIGN_KEY %s%s", string(c.References), c.Actions())
 	}
 	return fmt.Sprintf("unknown constraint type %d", int(c.Type))
 }
 
 type Table struct {
+	Name        string
 	Fields      []*Field
 	Constraints []*Constraint
+	Comment     string
+	// Indexes is only populated by Driver.Schema.
+	Indexes []*Index
 }
 
 func (t *Table) PrimaryKeys() []string {
@@ -163,6 +194,13 @@ func (t *Table) SQL(db *DB, b Backend, m driver.Model, name string) (string, err
 	// Postgres only allows superusers and the owner to
 	// inspect the database).
 	sql := fmt.Sprintf("\nCREATE TABLE IF NOT EXISTS %s (\n\t%s\n)", db.QuoteIdentifier(name), strings.Join(lines, ",\n\t"))
+	opts, err := b.TableOptions(db, m, t)
+	if err != nil {
+		return "", err
+	}
+	if opts != "" {
+		sql += " " + opts
+	}
 	return sql, nil
 }
 
@@ -178,6 +216,8 @@ const (
 	KindText
 	KindBlob
 	KindTime
+	KindJSON
+	KindArray
 )
 
 var (
@@ -196,17 +236,25 @@ func fieldLength(typ string) int {
 func TypeKind(typ string) (Kind, int) {
 	t := strings.ToUpper(typ)
 	switch {
+	case strings.HasSuffix(t, "[]") || t == "ARRAY":
+		// INFORMATION_SCHEMA reports arrays as ARRAY,
+		// without their element type.
+		return KindArray, 0
 	case strings.Contains(t, "INT") || strings.Contains(t, "SERIAL"):
 		return KindInteger, 0
 	case strings.HasPrefix(t, "VARCHAR") || strings.HasPrefix(t, "CHARACTER VARYING"):
 		return KindVarchar, fieldLength(t)
 	case strings.HasPrefix(t, "CHAR"):
 		return KindChar, fieldLength(t)
+	case strings.HasPrefix(t, "NUMERIC") || strings.HasPrefix(t, "DECIMAL"):
+		return KindDecimal, 0
 	case strings.HasPrefix(t, "BLOB"):
 		return KindBlob, 0
 	case strings.HasPrefix(t, "TEXT"):
 		return KindText, 0
-	case t == "DATETIME" || strings.Contains(t, "TIMESTAMP"):
+	case strings.HasPrefix(t, "JSON"):
+		return KindJSON, 0
+	case strings.HasPrefix(t, "DATETIME") || strings.Contains(t, "TIMESTAMP"):
 		return KindTime, 0
 	}
 	return KindInvalid, 0
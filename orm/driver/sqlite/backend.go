@@ -1,6 +1,8 @@
 package sqlite
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
@@ -14,8 +16,15 @@ import (
 	"gnd.la/util/generic"
 	"gnd.la/util/stringutil"
 	"gnd.la/util/structs"
+	"gnd.la/util/types"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	// upsertVersion is the first SQLite version supporting
+	// ON CONFLICT clauses, as returned by sqlite3.Version.
+	upsertVersion = 3024000
 )
 
 var (
@@ -45,8 +54,70 @@ func (b *Backend) Func(fname string, retType reflect.Type) (string, error) {
 	return b.SqlBackend.Func(fname, retType)
 }
 
+// Upserts returns true iff the linked SQLite library supports
+// ON CONFLICT clauses in INSERT statements (3.24.0 or later).
+func (b *Backend) Upserts() bool {
+	_, version, _ := sqlite3.Version()
+	return version >= upsertVersion
+}
+
+// UpsertClause returns an ON CONFLICT clause. Models with an
+// auto_increment primary key are not supported, because SQLite
+// doesn't report their id when the upsert updates an existing
+// row, returning ErrUpsertNotSupported.
+func (b *Backend) UpsertClause(db *sql.DB, m driver.Model, conflict []string, update []string) (string, error) {
+	if m.Fields().AutoincrementPk {
+		return "", sql.ErrUpsertNotSupported
+	}
+	var buf bytes.Buffer
+	buf.WriteString("ON CONFLICT (")
+	for ii, v := range conflict {
+		if ii > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(db.QuoteIdentifier(v))
+	}
+	buf.WriteByte(')')
+	if len(update) == 0 {
+		buf.WriteString(" DO NOTHING")
+		return buf.String(), nil
+	}
+	buf.WriteString(" DO UPDATE SET ")
+	for ii, v := range update {
+		if ii > 0 {
+			buf.WriteByte(',')
+		}
+		name := db.QuoteIdentifier(v)
+		buf.WriteString(name)
+		buf.WriteString(" = excluded.")
+		buf.WriteString(name)
+	}
+	return buf.String(), nil
+}
+
 func (b *Backend) Inspect(db *sql.DB, m driver.Model) (*sql.Table, error) {
-	name := db.QuoteString(m.Table())
+	return b.InspectTable(db, m.Table())
+}
+
+func (b *Backend) Tables(db *sql.DB) ([]string, error) {
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+func (b *Backend) InspectTable(db *sql.DB, tableName string) (*sql.Table, error) {
+	name := db.QuoteString(tableName)
 	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", name))
 	if err != nil {
 		return nil, err
@@ -97,11 +168,77 @@ func (b *Backend) Inspect(db *sql.DB, m driver.Model) (*sql.Table, error) {
 		return nil, err
 	}
 	if len(fields) > 0 {
-		return &sql.Table{Fields: fields}, nil
+		return &sql.Table{Name: tableName, Fields: fields}, nil
 	}
 	return nil, nil
 }
 
+func (b *Backend) Indexes(db *sql.DB, name string) ([]*sql.Index, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA index_list(%s)", db.QuoteString(name)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	// The number of columns returned by index_list depends
+	// on the SQLite version, so scan them by name.
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var indexes []*sql.Index
+	for rows.Next() {
+		values := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for ii := range values {
+			ptrs[ii] = &values[ii]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		idx := &sql.Index{}
+		var origin string
+		for ii, v := range cols {
+			switch v {
+			case "name":
+				idx.Name = pragmaString(values[ii])
+			case "unique":
+				unique, _ := types.ToInt(pragmaString(values[ii]))
+				idx.Unique = unique != 0
+			case "origin":
+				origin = pragmaString(values[ii])
+			}
+		}
+		if origin == "pk" {
+			continue
+		}
+		indexes = append(indexes, idx)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, v := range indexes {
+		rows, err := db.Query(fmt.Sprintf("PRAGMA index_info(%s)", db.QuoteString(v.Name)))
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var seqno, cid int
+			var field string
+			if err := rows.Scan(&seqno, &cid, &field); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			v.Fields = append(v.Fields, field)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return indexes, nil
+}
+
 func (b *Backend) HasIndex(db *sql.DB, m driver.Model, idx *index.Index, name string) (bool, error) {
 	rows, err := db.Query(fmt.Sprintf("PRAGMA index_info(%s)", name))
 	if err != nil {
@@ -193,6 +330,12 @@ func (b *Backend) FieldType(typ reflect.Type, t *structs.Tag) (string, error) {
 		if typ.Name() == "Time" && typ.PkgPath() == "time" {
 			return "INTEGER", nil
 		}
+		if sql.IsDecimal(typ) {
+			// Note that SQLite converts numbers with up to 15
+			// significant digits to REAL. Use TEXT if you need
+			// more precision (at the cost of numeric comparisons).
+			return "NUMERIC", nil
+		}
 	}
 	return "", fmt.Errorf("can't map field type %v to a database type", typ)
 }
@@ -215,6 +358,10 @@ func (b *Backend) ScanInt(val int64, goVal *reflect.Value, t *structs.Tag) error
 
 func (b *Backend) TransformOutValue(val reflect.Value) (interface{}, error) {
 	val = driver.Direct(val)
+	if !val.IsValid() {
+		// nil pointer, store it as NULL
+		return nil, nil
+	}
 	switch x := val.Interface().(type) {
 	case time.Time:
 		if x.IsZero() {
@@ -230,21 +377,47 @@ func (b *Backend) TransformOutValue(val reflect.Value) (interface{}, error) {
 	return nil, fmt.Errorf("can't transform type %v", val.Type())
 }
 
+// pragmaString converts a value returned by a PRAGMA
+// to a string. Text might be returned as []byte.
+func pragmaString(val interface{}) string {
+	if b, ok := val.([]byte); ok {
+		return string(b)
+	}
+	return types.ToString(val)
+}
+
 func (b *Backend) canAddField(f *sql.Field) bool {
 	// These are a supeset of the actual resctrictions, for
 	// simplicity. See https://www.sqlite.org/lang_altertable.html
 	// for more details.
-	return f.Constraint(sql.ConstraintPrimaryKey) != nil && f.Constraint(sql.ConstraintUnique) == nil && f.Default == ""
+	return f.Constraint(sql.ConstraintPrimaryKey) == nil && f.Constraint(sql.ConstraintUnique) == nil && f.Default == ""
+}
+
+// Explain returns EXPLAIN QUERY PLAN, since plain EXPLAIN returns the
+// bytecode for the query. SQLite can't analyze queries.
+func (b *Backend) Explain(db *sql.DB, analyze bool) (string, error) {
+	if analyze {
+		return "", fmt.Errorf("backend %s does not support EXPLAIN ANALYZE", b.Name())
+	}
+	return "EXPLAIN QUERY PLAN", nil
+}
+
+func (b *Backend) IsRetryable(err error) bool {
+	var serr sqlite3.Error
+	if errors.As(err, &serr) {
+		// Database is locked by another connection
+		return serr.Code == sqlite3.ErrBusy || serr.Code == sqlite3.ErrLocked
+	}
+	return false
 }
 
 func sqliteOpener(url *config.URL) (driver.Driver, error) {
-	drv, err := sql.NewDriver(sqliteBackend, url)
-	if err == nil {
-		if _, err := drv.DB().Exec("PRAGMA foreign_keys = on"); err != nil {
-			return nil, err
-		}
+	// PRAGMA foreign_keys only applies to the connection which
+	// executes it, so enable them for every connection in the pool.
+	if url.Query["_foreign_keys"] == "" && url.Query["_fk"] == "" {
+		url.Query["_foreign_keys"] = "1"
 	}
-	return drv, err
+	return sql.NewDriver(sqliteBackend, url)
 }
 
 func init() {
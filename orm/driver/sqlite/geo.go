@@ -0,0 +1,23 @@
+package sqlite
+
+import (
+	"fmt"
+	"math"
+
+	"gnd.la/orm/driver/sql"
+	"gnd.la/util/geo"
+)
+
+// Near uses an equirectangular approximation, since SQLite usually
+// lacks the trigonometric functions required by the haversine formula.
+// The cosine of the latitude is calculated in Go, so the condition
+// only requires basic arithmetic. The error is negligible for radii
+// up to a few hundred kilometers, but the approximation is not valid
+// for points near the poles or across the 180th meridian.
+func (b *Backend) Near(db *sql.DB, lat string, lng string, p geo.Point, radius float64, n int) (string, []interface{}, error) {
+	// Distances in degrees of latitude
+	k := math.Cos(p.Lat * math.Pi / 180)
+	r := radius * 180 / (math.Pi * geo.EarthRadius)
+	cond := fmt.Sprintf("((%s - ?) * ?) * ((%s - ?) * ?) + (%s - ?) * (%s - ?) <= ?", lng, lng, lat, lat)
+	return cond, []interface{}{p.Lng, k, p.Lng, k, p.Lat, p.Lat, r * r}, nil
+}
@@ -0,0 +1,38 @@
+package driver
+
+import (
+	"time"
+)
+
+// Stats contains the connection pool statistics and the
+// query timings collected by a driver.
+type Stats struct {
+	// OpenConnections is the number of established connections,
+	// both in use and idle.
+	OpenConnections int
+	// InUse is the number of connections currently in use.
+	InUse int
+	// Idle is the number of idle connections.
+	Idle int
+	// Queries contains the timings for each executed query,
+	// keyed by the query itself (e.g. its SQL).
+	Queries map[string]*QueryStats
+}
+
+// QueryStats contains the timings for a query.
+type QueryStats struct {
+	// Count is the number of times the query has been executed.
+	Count int
+	// Total is the total time spent executing the query.
+	Total time.Duration
+	// Max is the longest execution time for the query.
+	Max time.Duration
+}
+
+// Average returns the average execution time for the query.
+func (s *QueryStats) Average() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
@@ -0,0 +1,29 @@
+package driver
+
+import (
+	"gnd.la/orm/query"
+)
+
+// Subselect represents a query which selects a single field,
+// used as the value of an In or NotIn condition. Drivers which
+// support it should use a subquery rather than loading the
+// results.
+type Subselect struct {
+	// Model is the model the query selects from.
+	Model Model
+	// Field is the qualified name of the selected field.
+	Field string
+	// Q is the query condition, which might be nil.
+	Q query.Q
+	// Sort, Limit and Offset have the same meaning as
+	// in Conn.Query.
+	Sort   []Sort
+	Limit  int
+	Offset int
+}
+
+// Selector is implemented by values which can be used as
+// a Subselect (e.g. *gnd.la/orm.Query).
+type Selector interface {
+	Subselect() (*Subselect, error)
+}
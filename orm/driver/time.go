@@ -0,0 +1,141 @@
+package driver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gnd.la/util/structs"
+)
+
+var (
+	truncations = map[string]time.Duration{
+		"microsecond": time.Microsecond,
+		"millisecond": time.Millisecond,
+		"second":      time.Second,
+		"minute":      time.Minute,
+		"hour":        time.Hour,
+	}
+	// time.LoadLocation reads the zoneinfo database
+	// each time, so cache the loaded locations.
+	locations struct {
+		sync.RWMutex
+		m map[string]*time.Location
+	}
+)
+
+// TimeOptions specify how time.Time values are stored in and
+// loaded from the database. A nil *TimeOptions is valid and
+// leaves time.Time values as the backend stores and returns
+// them (most backends store them in UTC).
+type TimeOptions struct {
+	// Location is the location of the time.Time values
+	// loaded from the database. If nil, UTC is used.
+	Location *time.Location
+	// Naive makes the driver store the wall clock of time.Time
+	// values as is, ignoring their location, rather than
+	// converting them to UTC. Values loaded from the database
+	// keep their wall clock too, in Location.
+	Naive bool
+	// Truncate, when non-zero, truncates the time.Time values
+	// to a multiple of it (e.g. time.Second) when they're stored
+	// or loaded, so round-tripping them is deterministic with
+	// backends which round the values to their own precision.
+	Truncate time.Duration
+}
+
+// FieldTimeOptions returns the TimeOptions for a time.Time field with
+// the given tag, derived from opts (which might be nil). The following
+// tag options are supported:
+//
+//	utc: values are stored and loaded in UTC, ignoring opts.
+//	location=Europe/Madrid: values are loaded in the given location.
+//	truncate=second: values are truncated to the given unit, which
+//	    might be microsecond, millisecond, second, minute or hour.
+//
+// If the tag has none of these options, opts is returned.
+func FieldTimeOptions(opts *TimeOptions, tag *structs.Tag) (*TimeOptions, error) {
+	if tag == nil || (!tag.Has("utc") && !tag.Has("location") && !tag.Has("truncate")) {
+		return opts, nil
+	}
+	fo := new(TimeOptions)
+	if opts != nil {
+		*fo = *opts
+	}
+	if tag.Has("utc") {
+		fo.Location = time.UTC
+		fo.Naive = false
+	} else if name := tag.Value("location"); name != "" {
+		loc, err := loadLocation(name)
+		if err != nil {
+			return nil, err
+		}
+		fo.Location = loc
+	}
+	if unit := tag.Value("truncate"); unit != "" {
+		d, ok := truncations[unit]
+		if !ok {
+			return nil, fmt.Errorf("invalid time truncation %q, must be microsecond, millisecond, second, minute or hour", unit)
+		}
+		fo.Truncate = d
+	}
+	return fo, nil
+}
+
+func loadLocation(name string) (*time.Location, error) {
+	locations.RLock()
+	loc := locations.m[name]
+	locations.RUnlock()
+	if loc != nil {
+		return loc, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Lock()
+	if locations.m == nil {
+		locations.m = make(map[string]*time.Location)
+	}
+	locations.m[name] = loc
+	locations.Unlock()
+	return loc, nil
+}
+
+// Out returns the value to be stored in the database
+// for the given time.
+func (o *TimeOptions) Out(t time.Time) time.Time {
+	if o == nil || t.IsZero() {
+		return t
+	}
+	if o.Truncate > 0 {
+		t = t.Truncate(o.Truncate)
+	}
+	if o.Naive {
+		return wallClock(t, time.UTC)
+	}
+	return t.UTC()
+}
+
+// In returns the value to be loaded into a struct for
+// the given time, as returned from the database.
+func (o *TimeOptions) In(t time.Time) time.Time {
+	if o == nil || t.IsZero() {
+		return t
+	}
+	if o.Truncate > 0 {
+		t = t.Truncate(o.Truncate)
+	}
+	loc := o.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	if o.Naive {
+		return wallClock(t, loc)
+	}
+	return t.In(loc)
+}
+
+func wallClock(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
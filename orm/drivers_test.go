@@ -10,6 +10,8 @@ import (
 	"os/user"
 	"testing"
 
+	"gnd.la/config"
+	_ "gnd.la/orm/driver/cockroach"
 	_ "gnd.la/orm/driver/mysql"
 	_ "gnd.la/orm/driver/postgres"
 	_ "gnd.la/orm/driver/sqlite"
@@ -74,6 +76,29 @@ func (o *mysqlOpener) Open(t T) (*Orm, interface{}) {
 
 func (o *mysqlOpener) Close(_ interface{}) {}
 
+type cockroachOpener struct {
+}
+
+func (o *cockroachOpener) Open(t T) (*Orm, interface{}) {
+	orm, err := New(config.MustParseURL("cockroach://dbname=defaultdb user=root host=localhost port=26257 sslmode=disable"))
+	if err != nil {
+		t.Skipf("cannot connect to cockroach database, skipping test: %s", err)
+	}
+	db := orm.SqlDB()
+	if _, err := db.Exec("DROP DATABASE IF EXISTS gotest CASCADE"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("CREATE DATABASE gotest"); err != nil {
+		t.Fatal(err)
+	}
+	if err := orm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return newOrm(t, "cockroach://dbname=gotest user=root host=localhost port=26257 sslmode=disable", true), nil
+}
+
+func (o *cockroachOpener) Close(_ interface{}) {}
+
 func TestSqlite(t *testing.T) {
 	runAllTests(t, &sqliteOpener{})
 }
@@ -86,9 +111,14 @@ func TestMysql(t *testing.T) {
 	runAllTests(t, &mysqlOpener{})
 }
 
+func TestCockroach(t *testing.T) {
+	runAllTests(t, &cockroachOpener{})
+}
+
 func init() {
 	openers["default"] = &sqliteOpener{}
 	openers["sqlite"] = &sqliteOpener{}
 	openers["postgres"] = &postgresOpener{}
 	openers["mysql"] = &mysqlOpener{}
+	openers["cockroach"] = &cockroachOpener{}
 }
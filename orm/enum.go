@@ -0,0 +1,86 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gnd.la/orm/driver"
+)
+
+// EnumError is returned when saving an object with an enum
+// field (e.g. `orm:",enum='draft,published,archived'"`) whose
+// value is not one of the allowed ones.
+type EnumError struct {
+	// Model is the name of the model.
+	Model string
+	// Field is the qualified name of the field.
+	Field string
+	// Value is the invalid value.
+	Value string
+	// Allowed contains the allowed values, in the order
+	// they were declared.
+	Allowed []string
+}
+
+func (e *EnumError) Error() string {
+	return fmt.Sprintf("invalid value %q for field %q in model %s, allowed values are %s",
+		e.Value, e.Field, e.Model, strings.Join(e.Allowed, ", "))
+}
+
+func enumValues(val string) ([]string, error) {
+	var values []string
+	seen := make(map[string]bool)
+	for _, v := range strings.Split(val, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return nil, errors.New("enum values can't be empty")
+		}
+		if seen[v] {
+			return nil, fmt.Errorf("duplicate enum value %q", v)
+		}
+		seen[v] = true
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// checkEnums returns an *EnumError if any of the enum fields in
+// obj has a value which is not allowed. Empty values are stored
+// as NULL by default, so they're only checked when the field is
+// not nullempty.
+func (o *Orm) checkEnums(m *model, obj interface{}) error {
+	f := m.fields
+	if len(f.Enums) == 0 {
+		return nil
+	}
+	val := driver.Direct(reflect.ValueOf(obj))
+	for idx, allowed := range f.Enums {
+		fval := o.fieldByIndex(val, f.Indexes[idx])
+		if !fval.IsValid() {
+			// Nil embedded pointer, field will be NULL
+			continue
+		}
+		s := fval.String()
+		if s == "" && (f.NullEmpty[idx] || f.OmitEmpty[idx]) {
+			continue
+		}
+		found := false
+		for _, v := range allowed {
+			if v == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &EnumError{
+				Model:   m.name,
+				Field:   f.QNames[idx],
+				Value:   s,
+				Allowed: allowed,
+			}
+		}
+	}
+	return nil
+}
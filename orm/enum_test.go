@@ -0,0 +1,67 @@
+package orm
+
+import (
+	"testing"
+)
+
+type Post struct {
+	Id     int64 `orm:",primary_key,auto_increment"`
+	Title  string
+	Status string `orm:",enum='draft,published,archived'"`
+}
+
+type BadEnum struct {
+	Id     int64 `orm:",primary_key,auto_increment"`
+	Status int   `orm:",enum='1,2'"`
+}
+
+func TestEnumValues(t *testing.T) {
+	values, err := enumValues("draft, published,archived")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 3 || values[0] != "draft" || values[1] != "published" || values[2] != "archived" {
+		t.Errorf("unexpected enum values %v", values)
+	}
+	for _, v := range []string{"a,,b", "a,b,a"} {
+		if _, err := enumValues(v); err == nil {
+			t.Errorf("expecting an error for enum %q", v)
+		}
+	}
+}
+
+func testEnum(t *testing.T, o *Orm) {
+	if _, err := o.Register((*BadEnum)(nil), &Options{Table: "test_enum_bad"}); err == nil {
+		t.Error("expecting an error when registering non-string enum")
+	}
+	table := o.mustRegister((*Post)(nil), &Options{
+		Table: "test_enum_post",
+	})
+	o.mustInitialize()
+	o.MustInsert(&Post{Title: "First", Status: "draft"})
+	// Empty values are stored as NULL
+	o.MustInsert(&Post{Title: "Second"})
+	_, err := o.Insert(&Post{Title: "Third", Status: "deleted"})
+	if eerr, ok := err.(*EnumError); !ok {
+		t.Errorf("expecting an *EnumError, got %v", err)
+	} else if eerr.Field != "Status" || eerr.Value != "deleted" || len(eerr.Allowed) != 3 {
+		t.Errorf("unexpected EnumError %+v", eerr)
+	}
+	var post *Post
+	o.Query(Eq("Status", "draft")).Table(table).MustOne(&post)
+	post.Status = "bogus"
+	if _, err := o.Save(post); err == nil {
+		t.Error("expecting an error when saving invalid enum value")
+	}
+	post.Status = "published"
+	o.MustSave(post)
+	if c := o.Query(Eq("Status", "published")).Table(table).MustCount(); c != 1 {
+		t.Errorf("expecting 1 published post, got %d", c)
+	}
+	if db := o.SqlDB(); db != nil {
+		// Bypass the ORM, the database should reject the value too
+		if _, err := db.Exec("UPDATE test_enum_post SET status = 'bogus'"); err == nil {
+			t.Error("expecting the database to reject an invalid enum value")
+		}
+	}
+}
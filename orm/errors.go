@@ -7,4 +7,23 @@ import (
 var (
 	// ErrNotSql indicates that the current driver is not using database/sql.
 	ErrNoSql = errors.New("driver is not using database/sql")
+	// ErrNoQueryCache indicates that the current driver can't cache query results.
+	ErrNoQueryCache = errors.New("driver does not support caching query results")
+	// ErrNoTimeOptions indicates that the current driver does not support TimeOptions.
+	ErrNoTimeOptions = errors.New("driver does not support time options")
+	// ErrNoMigrations indicates that the current driver can't report schema migrations.
+	ErrNoMigrations = errors.New("driver does not support migrations")
+	// ErrNoInterceptors indicates that the current driver does not support interceptors.
+	ErrNoInterceptors = errors.New("driver does not support interceptors")
+	// ErrNoAggregates indicates that the current driver can't compute aggregates.
+	ErrNoAggregates = errors.New("driver does not support aggregates")
+	// ErrNoStats indicates that the current driver can't report statistics.
+	ErrNoStats = errors.New("driver does not support statistics")
+	// ErrNoExplain indicates that the current driver can't return query plans.
+	ErrNoExplain = errors.New("driver does not support EXPLAIN")
+	// ErrNoDistinct indicates that the current driver can't return distinct rows.
+	ErrNoDistinct = errors.New("driver does not support DISTINCT")
+	// ErrNoReturning indicates that the current driver can't return the
+	// primary keys of the rows affected by an update or a delete.
+	ErrNoReturning = errors.New("driver does not support RETURNING")
 )
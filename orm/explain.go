@@ -0,0 +1,61 @@
+package orm
+
+import (
+	"gnd.la/orm/driver"
+	"gnd.la/orm/query"
+)
+
+// Plan is the execution plan for a query, as returned by
+// Query.Explain. See gnd.la/orm/driver.Plan for the available
+// fields.
+type Plan driver.Plan
+
+// String returns the plan as text, with a line per row and
+// the row columns separated by tabs.
+func (p *Plan) String() string {
+	return (*driver.Plan)(p).String()
+}
+
+// Explainer is implemented by drivers which can return the
+// execution plan for a query (the sql driver implements this
+// interface).
+type Explainer interface {
+	Explain(m driver.Model, q query.Q, sort []driver.Sort, limit int, offset int, analyze bool) (*driver.Plan, error)
+}
+
+// Explain returns the execution plan the database would use for
+// the query, as reported by the backend (e.g. EXPLAIN on PostgreSQL
+// or EXPLAIN QUERY PLAN on SQLite), which is useful for diagnosing
+// slow queries. The query is not executed. Like Count, the table must
+// be set before calling Explain. If the driver does not implement
+// Explainer, ErrNoExplain is returned.
+func (q *Query) Explain() (*Plan, error) {
+	return q.explain("Explain", false)
+}
+
+// ExplainAnalyze works like Explain, but it executes the query and
+// the returned plan includes the actual timings (e.g. EXPLAIN ANALYZE
+// on PostgreSQL). Note that backends which can't analyze queries
+// (e.g. SQLite) return an error.
+func (q *Query) ExplainAnalyze() (*Plan, error) {
+	return q.explain("ExplainAnalyze", true)
+}
+
+func (q *Query) explain(f string, analyze bool) (*Plan, error) {
+	if err := q.ensureTable(f); err != nil {
+		return nil, err
+	}
+	if q.err != nil {
+		return nil, q.err
+	}
+	ex, ok := q.orm.conn.(Explainer)
+	if !ok {
+		return nil, ErrNoExplain
+	}
+	m, err := q.distinctModel(q.model)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := ex.Explain(m, q.condition(), q.sort, q.limit, q.offset, analyze)
+	return (*Plan)(plan), err
+}
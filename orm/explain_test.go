@@ -0,0 +1,49 @@
+package orm
+
+import (
+	"strings"
+	"testing"
+)
+
+type Explained struct {
+	Id    int64  `orm:",primary_key,auto_increment"`
+	Value string `orm:",index"`
+}
+
+func testExplain(t *testing.T, o *Orm) {
+	table := o.mustRegister((*Explained)(nil), &Options{Table: "explained"})
+	o.mustInitialize()
+	for ii := 0; ii < 10; ii++ {
+		o.MustInsert(&Explained{Value: strings.Repeat("a", ii)})
+	}
+	q := o.Query(Eq("Value", "aaa")).Table(table).Sort("Id", DESC).Limit(5)
+	plan, err := q.Explain()
+	if err == ErrNoExplain {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Columns) == 0 || len(plan.Rows) == 0 {
+		t.Fatalf("empty plan %+v", plan)
+	}
+	for _, v := range plan.Rows {
+		if len(v) != len(plan.Columns) {
+			t.Errorf("expecting %d columns in plan row, got %d", len(plan.Columns), len(v))
+		}
+	}
+	t.Logf("plan:\n%s", plan)
+	if plan.String() == "" {
+		t.Error("empty plan text")
+	}
+	// Backends which can't analyze queries return an error
+	if plan, err := q.ExplainAnalyze(); err == nil && len(plan.Rows) == 0 {
+		t.Error("empty analyzed plan")
+	}
+	// The query must still work after explaining it
+	var objs []*Explained
+	q.MustAll(&objs)
+	if len(objs) != 1 || objs[0].Value != "aaa" {
+		t.Errorf("unexpected results %v", objs)
+	}
+}
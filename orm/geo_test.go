@@ -0,0 +1,49 @@
+package orm
+
+import (
+	"testing"
+
+	"gnd.la/util/geo"
+)
+
+type Store struct {
+	Id       int64 `orm:",primary_key,auto_increment"`
+	Name     string
+	Location geo.Point
+}
+
+func testNear(t *testing.T, o *Orm) {
+	if o.SqlDB() == nil {
+		t.Skip("not a database/sql driver")
+	}
+	table := o.mustRegister((*Store)(nil), &Options{Table: "store"})
+	o.mustInitialize()
+	stores := []*Store{
+		{Name: "Sol", Location: geo.Point{Lat: 40.4169, Lng: -3.7035}},
+		{Name: "Retiro", Location: geo.Point{Lat: 40.4153, Lng: -3.6845}},
+		{Name: "Barcelona", Location: geo.Point{Lat: 41.3874, Lng: 2.1686}},
+	}
+	for _, v := range stores {
+		o.MustInsert(v)
+	}
+	center := geo.Point{Lat: 40.4168, Lng: -3.7038}
+	count := func(radius float64) int {
+		var objs []*Store
+		o.Query(Near("Location", center, radius)).Table(table).MustAll(&objs)
+		for _, v := range objs {
+			if d := center.Distance(v.Location); d > radius*1.01 {
+				t.Errorf("store %s is %vm away, outside radius %vm", v.Name, d, radius)
+			}
+		}
+		return len(objs)
+	}
+	if n := count(100); n != 1 {
+		t.Errorf("expecting 1 store within 100m, got %d", n)
+	}
+	if n := count(5000); n != 2 {
+		t.Errorf("expecting 2 stores within 5km, got %d", n)
+	}
+	if n := count(1000000); n != 3 {
+		t.Errorf("expecting 3 stores within 1000km, got %d", n)
+	}
+}
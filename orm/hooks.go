@@ -0,0 +1,166 @@
+package orm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Models might implement any of the following interfaces to run code
+// around the operations performed by the ORM on their objects. Hooks
+// receiving a context.Context are passed the context the Orm was bound
+// to (see Orm.WithContext), or context.Background() if there's none.
+// If a Before hook returns an error, the operation is aborted and the
+// error is returned to the caller. After hooks are only called when
+// the operation succeeds, but their errors are also returned.
+//
+// Hooks are detected when the model is registered, so mismatched
+// signatures (e.g. a BeforeInsert method which doesn't receive a
+// context.Context) are reported as registration errors.
+//
+// The Save method (see gnd.la/orm/driver.Methods) is still called
+// before any of these hooks.
+type (
+	// BeforeSaver is called before inserting, updating or
+	// upserting an object.
+	BeforeSaver interface {
+		BeforeSave(ctx context.Context) error
+	}
+	// AfterSaver is called after an object has been inserted,
+	// updated or upserted.
+	AfterSaver interface {
+		AfterSave(ctx context.Context) error
+	}
+	// BeforeInserter is called before inserting an object.
+	BeforeInserter interface {
+		BeforeInsert(ctx context.Context) error
+	}
+	// AfterInserter is called after inserting an object. At this
+	// point, auto_increment primary keys have been already set.
+	AfterInserter interface {
+		AfterInsert(ctx context.Context) error
+	}
+	// BeforeUpdater is called before updating an object.
+	BeforeUpdater interface {
+		BeforeUpdate(ctx context.Context) error
+	}
+	// AfterUpdater is called after updating an object. Note that
+	// Save and Upsert might perform an update which doesn't
+	// affect any rows followed by an insert, calling the
+	// update hooks before the insert ones.
+	AfterUpdater interface {
+		AfterUpdate(ctx context.Context) error
+	}
+	// BeforeDeleter is called before deleting an object with
+	// Delete or HardDelete.
+	BeforeDeleter interface {
+		BeforeDelete(ctx context.Context) error
+	}
+	// AfterDeleter is called after deleting an object with
+	// Delete or HardDelete.
+	AfterDeleter interface {
+		AfterDelete(ctx context.Context) error
+	}
+	// AfterLoader is called after an object has been loaded
+	// from the database, after its Load method.
+	AfterLoader interface {
+		AfterLoad() error
+	}
+)
+
+type hook uint
+
+const (
+	hookBeforeSave hook = 1 << iota
+	hookAfterSave
+	hookBeforeInsert
+	hookAfterInsert
+	hookBeforeUpdate
+	hookAfterUpdate
+	hookBeforeDelete
+	hookAfterDelete
+	hookAfterLoad
+)
+
+var hookTypes = []struct {
+	hook  hook
+	name  string
+	iface reflect.Type
+}{
+	{hookBeforeSave, "BeforeSave", reflect.TypeOf((*BeforeSaver)(nil)).Elem()},
+	{hookAfterSave, "AfterSave", reflect.TypeOf((*AfterSaver)(nil)).Elem()},
+	{hookBeforeInsert, "BeforeInsert", reflect.TypeOf((*BeforeInserter)(nil)).Elem()},
+	{hookAfterInsert, "AfterInsert", reflect.TypeOf((*AfterInserter)(nil)).Elem()},
+	{hookBeforeUpdate, "BeforeUpdate", reflect.TypeOf((*BeforeUpdater)(nil)).Elem()},
+	{hookAfterUpdate, "AfterUpdate", reflect.TypeOf((*AfterUpdater)(nil)).Elem()},
+	{hookBeforeDelete, "BeforeDelete", reflect.TypeOf((*BeforeDeleter)(nil)).Elem()},
+	{hookAfterDelete, "AfterDelete", reflect.TypeOf((*AfterDeleter)(nil)).Elem()},
+	{hookAfterLoad, "AfterLoad", reflect.TypeOf((*AfterLoader)(nil)).Elem()},
+}
+
+// modelHooks returns the hooks implemented by the given type,
+// or an error if it has a hook method with the wrong signature.
+func modelHooks(typ reflect.Type) (hook, error) {
+	if typ.Kind() != reflect.Ptr {
+		typ = reflect.PtrTo(typ)
+	}
+	var hooks hook
+	for _, v := range hookTypes {
+		m, ok := typ.MethodByName(v.name)
+		if !ok {
+			continue
+		}
+		if !typ.Implements(v.iface) {
+			return 0, fmt.Errorf("method %q on type %v has signature %v, must be %v to be used as a hook", v.name, typ, m.Type, v.iface.Method(0).Type)
+		}
+		hooks |= v.hook
+	}
+	return hooks, nil
+}
+
+// hookReceiver returns the value which hooks should be called on,
+// always a pointer to the object.
+func hookReceiver(obj interface{}) interface{} {
+	val := reflect.ValueOf(obj)
+	for val.Kind() == reflect.Ptr && val.Elem().Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Ptr {
+		// Hooks can't modify non-pointer objects, but
+		// they still might check them or return errors
+		p := reflect.New(val.Type())
+		p.Elem().Set(val)
+		val = p
+	}
+	return val.Interface()
+}
+
+// runHook calls the given hook on obj, if its model implements it.
+func (o *Orm) runHook(m *model, h hook, obj interface{}) error {
+	if m.hooks&h == 0 {
+		return nil
+	}
+	recv := hookReceiver(obj)
+	ctx := o.Context()
+	switch h {
+	case hookBeforeSave:
+		return recv.(BeforeSaver).BeforeSave(ctx)
+	case hookAfterSave:
+		return recv.(AfterSaver).AfterSave(ctx)
+	case hookBeforeInsert:
+		return recv.(BeforeInserter).BeforeInsert(ctx)
+	case hookAfterInsert:
+		return recv.(AfterInserter).AfterInsert(ctx)
+	case hookBeforeUpdate:
+		return recv.(BeforeUpdater).BeforeUpdate(ctx)
+	case hookAfterUpdate:
+		return recv.(AfterUpdater).AfterUpdate(ctx)
+	case hookBeforeDelete:
+		return recv.(BeforeDeleter).BeforeDelete(ctx)
+	case hookAfterDelete:
+		return recv.(AfterDeleter).AfterDelete(ctx)
+	case hookAfterLoad:
+		return recv.(AfterLoader).AfterLoad()
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var errHookRejected = errors.New("rejected by hook")
+
+type HookedObject struct {
+	Id     int64 `orm:",primary_key,auto_increment"`
+	Value  string
+	called []string
+}
+
+func (h *HookedObject) record(name string) error {
+	h.called = append(h.called, name)
+	if h.Value == "reject "+name {
+		return errHookRejected
+	}
+	return nil
+}
+
+func (h *HookedObject) BeforeSave(ctx context.Context) error   { return h.record("BeforeSave") }
+func (h *HookedObject) AfterSave(ctx context.Context) error    { return h.record("AfterSave") }
+func (h *HookedObject) BeforeInsert(ctx context.Context) error { return h.record("BeforeInsert") }
+func (h *HookedObject) AfterInsert(ctx context.Context) error  { return h.record("AfterInsert") }
+func (h *HookedObject) BeforeUpdate(ctx context.Context) error { return h.record("BeforeUpdate") }
+func (h *HookedObject) AfterUpdate(ctx context.Context) error  { return h.record("AfterUpdate") }
+func (h *HookedObject) BeforeDelete(ctx context.Context) error { return h.record("BeforeDelete") }
+func (h *HookedObject) AfterDelete(ctx context.Context) error  { return h.record("AfterDelete") }
+func (h *HookedObject) AfterLoad() error                       { return h.record("AfterLoad") }
+
+type BadHookObject struct {
+	Id int64 `orm:",primary_key,auto_increment"`
+}
+
+func (b *BadHookObject) BeforeInsert() error { return nil }
+
+func testHooks(t *testing.T, o *Orm) {
+	if _, err := o.Register((*BadHookObject)(nil), nil); err == nil {
+		t.Error("expecting an error when registering a model with an invalid hook")
+	}
+	table := o.mustRegister((*HookedObject)(nil), &Options{
+		Table: "test_hooks",
+	})
+	o.mustInitialize()
+	expectCalls := func(obj *HookedObject, calls ...string) {
+		if !reflect.DeepEqual(obj.called, calls) {
+			t.Errorf("expecting hooks %v, got %v", calls, obj.called)
+		}
+		obj.called = nil
+	}
+	obj := &HookedObject{Value: "foo"}
+	o.MustInsert(obj)
+	expectCalls(obj, "BeforeSave", "BeforeInsert", "AfterInsert", "AfterSave")
+	o.MustSave(obj)
+	expectCalls(obj, "BeforeSave", "BeforeUpdate", "AfterUpdate", "AfterSave")
+	var loaded *HookedObject
+	o.Table(table).MustOne(&loaded)
+	expectCalls(loaded, "AfterLoad")
+	o.MustDelete(obj)
+	expectCalls(obj, "BeforeDelete", "AfterDelete")
+	rejected := &HookedObject{Value: "reject BeforeInsert"}
+	if _, err := o.Insert(rejected); err != errHookRejected {
+		t.Errorf("expecting error %v from BeforeInsert, got %v", errHookRejected, err)
+	}
+	expectCalls(rejected, "BeforeSave", "BeforeInsert")
+	if c := o.Table(table).MustCount(); c != 0 {
+		t.Errorf("expecting no objects after rejected insert, got %d", c)
+	}
+}
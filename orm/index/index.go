@@ -15,8 +15,20 @@ type Index struct {
 	//    New("Foo.A", "Foo.B")
 	Fields []string
 	// Wheter the index should be unique.
-	Unique  bool
-	options map[int]interface{}
+	Unique bool
+	// Where is an optional condition which restricts the index
+	// to the rows matching it, creating a partial index. It's
+	// written in SQL, using the database column names e.g.
+	//    "deleted_at" IS NULL
+	// Not all drivers support partial indexes (e.g. MySQL doesn't).
+	Where string
+	// Expressions are indexed after the Fields, in order. They're
+	// written in SQL, using the database column names e.g.
+	//    lower("email")
+	// Not all drivers support expression indexes (e.g. MySQL
+	// requires version 8.0.13 or later).
+	Expressions []string
+	options     map[int]interface{}
 }
 
 // Set sets a driver dependent option for the given index.
@@ -37,6 +49,31 @@ func (i *Index) Set(opt int, values ...interface{}) *Index {
 	return i
 }
 
+// Desc marks the given fields as sorted in descending order. It's
+// a shorthand for Set(DESC, fields...). The same index is returned,
+// to allow chaining calls.
+func (i *Index) Desc(fields ...string) *Index {
+	values := make([]interface{}, len(fields))
+	for ii, v := range fields {
+		values[ii] = v
+	}
+	return i.Set(DESC, values...)
+}
+
+// Partial sets the Where condition, making the index a partial
+// one. The same index is returned, to allow chaining calls.
+func (i *Index) Partial(where string) *Index {
+	i.Where = where
+	return i
+}
+
+// Expr adds the given SQL expressions to the index. The same
+// index is returned, to allow chaining calls.
+func (i *Index) Expr(exprs ...string) *Index {
+	i.Expressions = append(i.Expressions, exprs...)
+	return i
+}
+
 // Get returns the value for the given option.
 func (i *Index) Get(opt int) interface{} {
 	return i.options[opt]
@@ -0,0 +1,37 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"gnd.la/orm/driver"
+	"gnd.la/orm/index"
+)
+
+type PartiallyIndexed struct {
+	Id        int64 `orm:",primary_key,auto_increment"`
+	Email     string
+	DeletedAt *time.Time
+}
+
+func testPartialIndexes(t *testing.T, o *Orm) {
+	caps := o.Driver().Capabilities()
+	if caps&driver.CAP_PARTIAL_INDEX == 0 || caps&driver.CAP_EXPRESSION_INDEX == 0 {
+		t.Skipf("driver %T does not support partial and expression indexes", o.Driver())
+	}
+	o.mustRegister((*PartiallyIndexed)(nil), &Options{
+		Table: "test_partially_indexed",
+		Indexes: index.Indexes(
+			index.NewUnique("Email").Partial(`"deleted_at" IS NULL`),
+			index.New("Id").Desc("Id").Expr(`lower("email")`),
+		),
+	})
+	o.mustInitialize()
+	deleted := time.Now()
+	o.MustInsert(&PartiallyIndexed{Email: "alice@example.com", DeletedAt: &deleted})
+	// Deleted rows are not included in the unique index
+	o.MustInsert(&PartiallyIndexed{Email: "alice@example.com"})
+	if _, err := o.Insert(&PartiallyIndexed{Email: "alice@example.com"}); err == nil {
+		t.Error("expecting an error when violating partial unique index")
+	}
+}
@@ -0,0 +1,27 @@
+package orm
+
+import (
+	"gnd.la/orm/driver"
+)
+
+// Interceptable is implemented by drivers which allow registering
+// a driver.Interceptor (the sql driver implements this interface).
+type Interceptable interface {
+	AddInterceptor(driver.Interceptor)
+}
+
+// AddInterceptor registers an interceptor which is notified before
+// and after every database operation performed by the ORM, receiving
+// the query, its arguments, the time it took and its error. This
+// allows e.g. creating tracing spans or sending timings to a metrics
+// system without modifying the driver. Interceptors should be added
+// before the ORM is used. If the driver does not implement Interceptable,
+// ErrNoInterceptors is returned.
+func (o *Orm) AddInterceptor(i driver.Interceptor) error {
+	ic, ok := o.driver.(Interceptable)
+	if !ok {
+		return ErrNoInterceptors
+	}
+	ic.AddInterceptor(i)
+	return nil
+}
@@ -0,0 +1,90 @@
+package orm
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type Intercepted struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Value string
+}
+
+type interceptorKey struct{}
+
+type recordingInterceptor struct {
+	mu      sync.Mutex
+	before  []string
+	after   []string
+	errors  []error
+	badCtx  int
+	stopped bool
+}
+
+func (r *recordingInterceptor) BeforeQuery(ctx context.Context, query string, args []interface{}) context.Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.stopped {
+		r.before = append(r.before, query)
+	}
+	return context.WithValue(ctx, interceptorKey{}, query)
+}
+
+func (r *recordingInterceptor) AfterQuery(ctx context.Context, query string, args []interface{}, elapsed time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped {
+		return
+	}
+	if ctx.Value(interceptorKey{}) != query || elapsed < 0 {
+		r.badCtx++
+	}
+	r.after = append(r.after, query)
+	r.errors = append(r.errors, err)
+}
+
+func (r *recordingInterceptor) stop() {
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+}
+
+func testInterceptor(t *testing.T, o *Orm) {
+	r := &recordingInterceptor{}
+	if err := o.AddInterceptor(r); err != nil {
+		t.Skip(err)
+	}
+	// Interceptors can't be removed, so stop recording
+	// once this test finishes.
+	defer r.stop()
+	table := o.mustRegister((*Intercepted)(nil), &Options{Table: "intercepted"})
+	o.mustInitialize()
+	o.MustInsert(&Intercepted{Value: "foo"})
+	var objs []*Intercepted
+	o.Table(table).MustAll(&objs)
+	if _, err := o.SqlDB().Exec("SELECT * FROM does_not_exist"); err == nil {
+		t.Fatal("expecting an error when querying a non-existing table")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.before) == 0 || len(r.before) != len(r.after) {
+		t.Fatalf("expecting the same number of calls to BeforeQuery and AfterQuery, got %d and %d", len(r.before), len(r.after))
+	}
+	if r.badCtx > 0 {
+		t.Errorf("AfterQuery received an invalid context %d times", r.badCtx)
+	}
+	var insert, sel bool
+	for _, v := range r.after {
+		insert = insert || strings.Contains(v, "INSERT")
+		sel = sel || strings.Contains(v, "SELECT")
+	}
+	if !insert || !sel {
+		t.Errorf("expecting INSERT and SELECT queries to be intercepted, got %v", r.after)
+	}
+	if last := len(r.errors) - 1; r.errors[last] == nil {
+		t.Errorf("expecting an error for query %q", r.after[last])
+	}
+}
@@ -17,6 +17,8 @@ type Interface interface {
 	Query(q query.Q) *Query
 	One(q query.Q, out ...interface{}) (bool, error)
 	MustOne(q query.Q, out ...interface{}) bool
+	OneByKey(t *Table, out interface{}, key ...interface{}) (bool, error)
+	MustOneByKey(t *Table, out interface{}, key ...interface{}) bool
 	All() *Query
 	Insert(obj interface{}) (Result, error)
 	MustInsert(obj interface{}) Result
@@ -9,6 +9,12 @@ type Iter struct {
 	limit int
 	driver.Iter
 	err error
+	// last object loaded, used by Cursor
+	last interface{}
+	// state for streaming, see Query.Stream
+	total   int
+	fetched int
+	next    string
 }
 
 // Next advances the iter to the next result,
@@ -30,14 +36,45 @@ func (i *Iter) Next(out ...interface{}) bool {
 				i.q.methods = append(i.q.methods, cur.model.fields.Methods)
 			}
 		}
-		i.Iter = i.q.exec(i.limit)
+		if len(i.q.fields) > 0 {
+			if i.err = i.q.project(); i.err != nil {
+				return false
+			}
+		}
+		if i.Iter, i.err = i.q.exec(i.batchLimit(), i.q.offset, i.q.after); i.err != nil {
+			return false
+		}
 	}
 	ok := i.Iter.Next(out...)
+	if !ok && i.next != "" {
+		ok = i.nextBatch(out)
+	}
 	if ok {
+		m := i.q.model
 		for ii, v := range out {
 			if i.err = i.q.methods[ii].Load(v); i.err != nil {
 				break
 			}
+			if m != nil {
+				if !m.skip {
+					if i.err = i.q.orm.runHook(m.model, hookAfterLoad, v); i.err != nil {
+						break
+					}
+				}
+				m = m.nextModel()
+			}
+		}
+		if len(out) > 0 {
+			i.last = out[0]
+		}
+		i.total++
+		if i.q.stream > 0 {
+			// Save the cursor for the next batch before
+			// returning the last object in this one, since
+			// the caller might modify it.
+			if i.fetched++; i.fetched == i.q.stream && i.err == nil {
+				i.next, i.err = i.q.cursor(i.last)
+			}
 		}
 	} else {
 		i.Close()
@@ -0,0 +1,50 @@
+package orm
+
+import (
+	"testing"
+
+	"gnd.la/orm/driver"
+)
+
+type Document struct {
+	Id   int64                  `orm:",primary_key,auto_increment"`
+	Meta map[string]interface{} `orm:",codec=json"`
+}
+
+func testJSONContains(t *testing.T, o *Orm) {
+	if o.Driver().Capabilities()&driver.CAP_JSON == 0 {
+		t.Skipf("driver %T does not support JSON queries", o.Driver())
+	}
+	table := o.mustRegister((*Document)(nil), nil)
+	o.mustInitialize()
+	for _, v := range []*Document{
+		{Meta: map[string]interface{}{"lang": "go", "tags": []string{"orm", "sql"}}},
+		{Meta: map[string]interface{}{"lang": "go", "tags": []string{"web"}}},
+		{Meta: map[string]interface{}{"lang": "python", "tags": []string{"orm"}}},
+	} {
+		o.MustInsert(v)
+	}
+	tests := []struct {
+		value interface{}
+		count uint64
+	}{
+		{map[string]interface{}{"lang": "go"}, 2},
+		{map[string]interface{}{"tags": []string{"orm"}}, 2},
+		{map[string]interface{}{"lang": "go", "tags": []string{"orm"}}, 1},
+		{map[string]interface{}{"lang": "rust"}, 0},
+	}
+	for _, v := range tests {
+		count, err := o.Query(JSONContains("Meta", v.value)).Table(table).Count()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != v.count {
+			t.Errorf("expecting %d documents containing %v, got %d", v.count, v.value, count)
+		}
+	}
+	var doc *Document
+	o.MustOne(JSONContains("Meta", map[string]interface{}{"lang": "python"}), &doc)
+	if doc == nil || doc.Meta["lang"] != "python" {
+		t.Errorf("expecting a document with lang = python, got %+v", doc)
+	}
+}
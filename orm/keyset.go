@@ -0,0 +1,174 @@
+package orm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gnd.la/orm/driver"
+	"gnd.la/orm/query"
+)
+
+// After makes the query return only the results which come after
+// the given cursor, which must have been obtained from Iter.Cursor
+// using a query with the same model and sorting. This implements
+// keyset pagination, which unlike Offset remains fast for large
+// tables and doesn't skip nor repeat results when objects are
+// added or removed between pages. e.g.
+//
+//	q := o.Query(orm.Eq("Published", true)).Sort("Created", orm.DESC).After(cursor).Limit(20)
+//	iter := q.Iter()
+//	for iter.Next(&article) {
+//		...
+//	}
+//	next, err := iter.Cursor()
+//
+// The primary key is appended to the sort fields, so the order is
+// stable when several objects have the same values for them. Use an
+// empty cursor to request the first page, so it uses the same order
+// as the following ones. An empty cursor returned from Iter.Cursor
+// indicates there were no results.
+//
+// Keyset pagination requires a model with a single field primary key,
+// no joins and sort fields which don't contain NULL values. Note
+// that After only affects One, All and Iter.
+func (q *Query) After(cursor string) *Query {
+	q.keyset = true
+	q.after = cursor
+	return q
+}
+
+// keysetSort returns the sort fields for keyset pagination: the
+// query sort fields followed by the primary key, unless it's already
+// sorted by it, plus their indexes in the model fields.
+func (q *Query) keysetSort() ([]driver.Sort, []int, error) {
+	if q.model == nil {
+		return nil, nil, errNoModel
+	}
+	if q.model.join != nil {
+		return nil, nil, errors.New("joins are not supported with keyset pagination")
+	}
+	fields := q.model.fields
+	if fields.PrimaryKey < 0 {
+		return nil, nil, fmt.Errorf("model %s must have a single field primary key to use keyset pagination", q.model)
+	}
+	pk := fields.QNames[fields.PrimaryKey]
+	var sort []driver.Sort
+	var indexes []int
+	hasPk := false
+	dir := driver.SortDirection(ASC)
+	for _, v := range q.sort {
+		idx, ok := fields.QNameMap[v.Field()]
+		if !ok {
+			return nil, nil, fmt.Errorf("can't use field %q for keyset pagination in model %s", v.Field(), q.model)
+		}
+		sort = append(sort, v)
+		indexes = append(indexes, idx)
+		dir = v.Direction()
+		if idx == fields.PrimaryKey {
+			hasPk = true
+		}
+	}
+	if !hasPk {
+		// Use the same direction as the last field, so
+		// the order looks natural (e.g. newest first).
+		sort = append(sort, &querySort{field: pk, dir: dir})
+		indexes = append(indexes, fields.PrimaryKey)
+	}
+	return sort, indexes, nil
+}
+
+// keysetCondition returns the condition and sorting for executing
+// the query with keyset pagination, starting after the given cursor.
+func (q *Query) keysetCondition(cond query.Q, after string) (query.Q, []driver.Sort, error) {
+	sort, indexes, err := q.keysetSort()
+	if err != nil {
+		return nil, nil, err
+	}
+	if after == "" {
+		return cond, sort, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(after)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid cursor %q: %s", after, err)
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("invalid cursor %q: %s", after, err)
+	}
+	if len(raw) != len(indexes) {
+		return nil, nil, fmt.Errorf("invalid cursor %q: expecting %d values, got %d", after, len(indexes), len(raw))
+	}
+	values := make([]interface{}, len(raw))
+	for ii, v := range raw {
+		val := reflect.New(q.model.fields.Types[indexes[ii]])
+		if err := json.Unmarshal(v, val.Interface()); err != nil {
+			return nil, nil, fmt.Errorf("invalid cursor %q: %s", after, err)
+		}
+		values[ii] = val.Elem().Interface()
+	}
+	// (a > x) OR (a = x AND b > y) OR ..., using < for
+	// descending fields.
+	var or []query.Q
+	for ii, s := range sort {
+		var and []query.Q
+		for jj := 0; jj < ii; jj++ {
+			and = append(and, Eq(sort[jj].Field(), values[jj]))
+		}
+		if s.Direction() == driver.DESC {
+			and = append(and, Lt(s.Field(), values[ii]))
+		} else {
+			and = append(and, Gt(s.Field(), values[ii]))
+		}
+		if len(and) == 1 {
+			or = append(or, and[0])
+		} else {
+			or = append(or, And(and...))
+		}
+	}
+	var next query.Q = Or(or...)
+	if len(or) == 1 {
+		next = or[0]
+	}
+	if cond != nil {
+		next = And(cond, next)
+	}
+	return next, sort, nil
+}
+
+// cursor returns the cursor for paginating after the given object.
+func (q *Query) cursor(obj interface{}) (string, error) {
+	_, indexes, err := q.keysetSort()
+	if err != nil {
+		return "", err
+	}
+	val := reflect.ValueOf(obj)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return "", nil
+		}
+		val = val.Elem()
+	}
+	values := make([]interface{}, len(indexes))
+	for ii, idx := range indexes {
+		values[ii] = val.FieldByIndex(q.model.fields.Indexes[idx]).Interface()
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// Cursor returns an opaque cursor which can be passed to Query.After
+// to continue iterating after the last result returned by Next. If
+// Next hasn't returned any results, the cursor is empty. See
+// Query.After for more details.
+func (i *Iter) Cursor() (string, error) {
+	if i.last == nil {
+		return "", nil
+	}
+	return i.q.cursor(i.last)
+}
@@ -0,0 +1,64 @@
+package orm
+
+import (
+	"testing"
+)
+
+type Paged struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Score int
+}
+
+func testKeyset(t *testing.T, o *Orm) {
+	table := o.mustRegister((*Paged)(nil), &Options{Table: "paged"})
+	o.mustInitialize()
+	for ii := 0; ii < 25; ii++ {
+		// Lots of repeated scores, to check ties are
+		// broken by the primary key.
+		o.MustInsert(&Paged{Score: ii % 4})
+	}
+	var expected []*Paged
+	o.Query(nil).Table(table).Sort("Score", DESC).Sort("Id", DESC).MustAll(&expected)
+	var got []int64
+	cursor := ""
+	pages := 0
+	for {
+		iter := o.Query(nil).Table(table).Sort("Score", DESC).After(cursor).Limit(10).Iter()
+		var p *Paged
+		n := 0
+		for iter.Next(&p) {
+			got = append(got, p.Id)
+			n++
+		}
+		if err := iter.Err(); err != nil {
+			t.Fatal(err)
+		}
+		next, err := iter.Cursor()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 {
+			if next != "" {
+				t.Errorf("expecting empty cursor after last page, got %q", next)
+			}
+			break
+		}
+		pages++
+		cursor = next
+	}
+	if pages != 3 {
+		t.Errorf("expecting 3 pages, got %d", pages)
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expecting %d results, got %d", len(expected), len(got))
+	}
+	for ii, v := range expected {
+		if got[ii] != v.Id {
+			t.Fatalf("result %d differs: expecting id %d, got %d", ii, v.Id, got[ii])
+		}
+	}
+	var p *Paged
+	if _, err := o.Query(nil).Table(table).After("not a cursor").One(&p); err == nil {
+		t.Error("expecting an error with an invalid cursor")
+	}
+}
@@ -0,0 +1,46 @@
+package orm
+
+import (
+	"testing"
+
+	"gnd.la/orm/query"
+)
+
+type Matched struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Value string
+}
+
+func testLike(t *testing.T, o *Orm) {
+	table := o.mustRegister((*Matched)(nil), &Options{Table: "matched"})
+	o.mustInitialize()
+	values := []string{"foo", "Foobar", "100%", "1000", "a_b", "axb", "x!y"}
+	for _, v := range values {
+		o.MustInsert(&Matched{Value: v})
+	}
+	cases := []struct {
+		q     query.Q
+		count uint64
+	}{
+		{Contains("Value", "oo"), 2},
+		{Contains("Value", "%"), 1},
+		{Contains("Value", "_"), 1},
+		{Contains("Value", "!"), 1},
+		{StartsWith("Value", "10"), 2},
+		{StartsWith("Value", "100%"), 1},
+		{StartsWith("Value", "a_"), 1},
+		{Like("Value", "a_b"), 2},
+		{Like("Value", "a"+EscapeLike("_")+"b"), 1},
+		{ILike("Value", "foo%"), 2},
+		{ILike("Value", "FOO"), 1},
+	}
+	for _, v := range cases {
+		count, err := o.Query(v.q).Table(table).Count()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != v.count {
+			t.Errorf("expecting %d results for %v, got %d", v.count, v.q, count)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package orm
+
+import (
+	"gnd.la/orm/driver"
+)
+
+// Migrator is implemented by drivers which can report the
+// statements used to bring the database schema up to date
+// with the registered models (the sql driver implements this
+// interface).
+type Migrator interface {
+	Migrate(ms []driver.Model, dryRun bool) ([]string, error)
+}
+
+// Migrate works like Initialize, resolving model references and
+// updating the database schema to match the registered models by
+// creating the missing tables, columns and indexes, but it also returns
+// the DDL statements required to do so. If dryRun is true, the
+// statements are not executed and the database is left untouched,
+// which is useful to review the planned changes before applying them.
+//
+// Note that migrations are additive: columns and indexes which are no
+// longer used by the models are never dropped. If the driver does not
+// implement Migrator, ErrNoMigrations is returned.
+func (o *Orm) Migrate(dryRun bool) ([]string, error) {
+	m, ok := o.driver.(Migrator)
+	if !ok {
+		return nil, ErrNoMigrations
+	}
+	var statements []string
+	err := o.initialize(func(ms []driver.Model) error {
+		var err error
+		statements, err = m.Migrate(ms, dryRun)
+		return err
+	})
+	return statements, err
+}
@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -122,6 +123,42 @@ func TestMigrations(t *testing.T) {
 	runTest(t, testMigrations)
 }
 
+func testMigrateDryRun(t *testing.T, o *Orm) {
+	opts := &Options{Name: "Migration", Table: "migration_dry_run"}
+	o.mustRegister((*Migration1)(nil), opts)
+	if _, err := o.Migrate(false); err != nil {
+		t.Fatal(err)
+	}
+	clearRegistry(o)
+	o.mustRegister((*Migration2)(nil), opts)
+	for ii := 0; ii < 2; ii++ {
+		// Dry runs must not alter the schema, so the
+		// plan should be the same in both iterations.
+		stmts, err := o.Migrate(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stmts) != 1 || !strings.Contains(stmts[0], "ADD COLUMN") || !strings.Contains(stmts[0], "value") {
+			t.Fatalf("expecting ADD COLUMN for value, got %v", stmts)
+		}
+	}
+	stmts, err := o.Migrate(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stmts) != 1 {
+		t.Errorf("expecting 1 executed statement, got %v", stmts)
+	}
+	o.MustInsert(&Migration2{Value: "Gondola"})
+	if stmts, err := o.Migrate(true); err != nil || len(stmts) != 0 {
+		t.Errorf("expecting no planned statements after migrating, got %v (error %v)", stmts, err)
+	}
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	runTest(t, testMigrateDryRun)
+}
+
 /*func TestBadMigration1(t *testing.T) {
 	runTest(t, testBadMigration1)
 }*/
@@ -33,8 +33,10 @@ func (j JoinType) String() string {
 }
 
 type reference struct {
-	model string
-	field string
+	model    string
+	field    string
+	onDelete string
+	onUpdate string
 }
 
 type model struct {
@@ -47,6 +49,10 @@ type model struct {
 	references      map[string]*reference
 	modelReferences map[*model][]*join
 	namedReferences map[string]*model
+	// qualified name of the softdelete field, if any
+	softDelete string
+	// hooks implemented by the model type
+	hooks hook
 }
 
 func (m *model) Type() reflect.Type {
@@ -68,6 +74,14 @@ func (m *model) Indexes() []*index.Index {
 	}
 	// Add indexes declared in the fields
 	for ii, v := range m.fields.Tags {
+		if v.Has("polymorphic") {
+			// Type and Id fields in a Polymorphic
+			qname := m.fields.QNames[ii]
+			prefix := qname[:strings.LastIndex(qname, ".")+1]
+			indexes = append(indexes, &index.Index{
+				Fields: []string{qname, prefix + "Id"},
+			})
+		}
 		if v.Has("index") {
 			dir := v.Value("index")
 			if dir == "" || dir == "asc" || dir == "both" {
@@ -112,6 +126,39 @@ func (m *model) Join() driver.Join {
 	return nil
 }
 
+func (m *model) Comment() string {
+	if m.options != nil && m.options.Comment != "" {
+		return m.options.Comment
+	}
+	if c := commentsFor(m.Type()); c != nil {
+		return c.table
+	}
+	return ""
+}
+
+func (m *model) FieldComment(qname string) string {
+	if idx, ok := m.fields.QNameMap[qname]; ok {
+		if c := m.fields.Tags[idx].Value("comment"); c != "" {
+			return c
+		}
+	}
+	if c := commentsFor(m.Type()); c != nil {
+		return c.fields[qname]
+	}
+	return ""
+}
+
+func (m *model) CacheTimeout() int {
+	if m.options != nil {
+		return m.options.CacheTimeout
+	}
+	return 0
+}
+
+func (m *model) Distinct() bool {
+	return false
+}
+
 func (m *model) String() string {
 	return m.name
 }
@@ -150,12 +197,24 @@ type joinModel struct {
 	*model
 	skip bool
 	join *join
+	// non-nil when only some fields are
+	// loaded (see Query.Fields)
+	projected *driver.Fields
+	// non-zero when the query overrides the
+	// model cache timeout (see Query.Cached)
+	cacheTimeout int
+	// true when the query returns only distinct
+	// rows (see Query.Distinct)
+	distinct bool
 }
 
 func (j *joinModel) clone() *joinModel {
 	nj := &joinModel{
-		model: j.model,
-		skip:  j.skip,
+		model:        j.model,
+		skip:         j.skip,
+		projected:    j.projected,
+		cacheTimeout: j.cacheTimeout,
+		distinct:     j.distinct,
 	}
 	if j.join != nil {
 		nj.join = j.join.clone()
@@ -167,9 +226,31 @@ func (j *joinModel) Fields() *driver.Fields {
 	if j.skip {
 		return nil
 	}
+	if j.projected != nil {
+		return j.projected
+	}
 	return j.model.Fields()
 }
 
+func (j *joinModel) CacheTimeout() int {
+	if j.cacheTimeout != 0 {
+		return j.cacheTimeout
+	}
+	return j.model.CacheTimeout()
+}
+
+func (j *joinModel) Distinct() bool {
+	return j.distinct
+}
+
+// nextModel returns the next joined model, or nil.
+func (j *joinModel) nextModel() *joinModel {
+	if j.join == nil {
+		return nil
+	}
+	return j.join.model
+}
+
 func (j *joinModel) Skip() bool {
 	return j.skip
 }
@@ -342,30 +423,35 @@ type mapCandidate struct {
 	typ  reflect.Type
 }
 
-type sortModels []driver.Model
+type modelsByName []driver.Model
 
-func (s sortModels) Len() int {
-	return len(s)
-}
+func (s modelsByName) Len() int           { return len(s) }
+func (s modelsByName) Less(i, j int) bool { return s[i].Table() < s[j].Table() }
+func (s modelsByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 
-func (s sortModels) less(mi, mj driver.Model) bool {
-	for _, v := range mi.Fields().References {
-		if v.Model == mj {
-			return false
+// sortModels returns the given models sorted so every model
+// appears after the models it references, keeping the original
+// order otherwise. References forming a cycle are ignored.
+func sortModels(models []driver.Model) []driver.Model {
+	sorted := make([]driver.Model, 0, len(models))
+	visited := make(map[driver.Model]bool, len(models))
+	var visit func(m driver.Model)
+	visit = func(m driver.Model) {
+		if _, ok := visited[m]; ok {
+			return
 		}
-		if v.Model != mi && !s.less(v.Model, mj) {
-			return false
+		// false means in progress, used to break cycles
+		visited[m] = false
+		for _, v := range m.Fields().References {
+			visit(v.Model)
 		}
+		visited[m] = true
+		sorted = append(sorted, m)
 	}
-	return true
-}
-
-func (s sortModels) Less(i, j int) bool {
-	return s.less(s[i], s[j])
-}
-
-func (s sortModels) Swap(i, j int) {
-	s[i], s[j] = s[j], s[i]
+	for _, v := range models {
+		visit(v)
+	}
+	return sorted
 }
 
 type errCantMap string
@@ -0,0 +1,68 @@
+package orm
+
+import (
+	"reflect"
+
+	"gnd.la/util/structs"
+)
+
+// NamingStrategy determines the names of the tables and columns for
+// the registered models. Tables with an explicit name (see Options.Table)
+// and fields with an explicit name in their tag (e.g. `orm:"name"`) are
+// not affected by it. Use Orm.SetNamingStrategy to change the strategy
+// used by an Orm.
+//
+// To override only one of the names, embed DefaultNamingStrategy:
+//
+//	type pluralTables struct {
+//		orm.DefaultNamingStrategy
+//	}
+//
+//	func (pluralTables) TableName(typ reflect.Type) string {
+//		return orm.DefaultNamingStrategy{}.TableName(typ) + "s"
+//	}
+type NamingStrategy interface {
+	// TableName returns the table name for the given struct type.
+	TableName(typ reflect.Type) string
+	// ColumnName returns the column name for the given struct field.
+	// Columns for fields in embedded structs are prefixed with the
+	// name of the embedded field, unless it's tagged as inline.
+	ColumnName(field reflect.StructField) string
+}
+
+// DefaultNamingStrategy is the NamingStrategy used by default. Table
+// names are formed from the package path and the type name, converted
+// from camel case to lowercase words separated by underscores (e.g.
+// example.com/blog.BlogPost becomes example_com_blog_blog_post), while
+// the package path is omitted for types in the main package. Column
+// names are formed by converting the field name in the same way
+// (e.g. CreatedAt becomes created_at).
+type DefaultNamingStrategy struct{}
+
+// TableName implements NamingStrategy.
+func (DefaultNamingStrategy) TableName(typ reflect.Type) string {
+	return defaultTableName(typ)
+}
+
+// ColumnName implements NamingStrategy.
+func (DefaultNamingStrategy) ColumnName(field reflect.StructField) string {
+	return structs.DefaultNamer(field)
+}
+
+// NamingStrategy returns the NamingStrategy used by the Orm. By
+// default, it's DefaultNamingStrategy.
+func (o *Orm) NamingStrategy() NamingStrategy {
+	if o.naming == nil {
+		return DefaultNamingStrategy{}
+	}
+	return o.naming
+}
+
+// SetNamingStrategy sets the NamingStrategy used by the Orm. Since the
+// names are determined when registering the models, it must be called
+// before registering them with Orm.Register or, for the models registered
+// with orm.Register, before calling Orm.Initialize. Passing nil restores
+// the default strategy.
+func (o *Orm) SetNamingStrategy(ns NamingStrategy) {
+	o.naming = ns
+}
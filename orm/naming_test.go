@@ -0,0 +1,47 @@
+package orm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type Legacy struct {
+	Id       int64 `orm:",primary_key,auto_increment"`
+	FullName string
+	Code     string `orm:"the_code"`
+}
+
+type legacyNaming struct {
+	DefaultNamingStrategy
+}
+
+func (legacyNaming) TableName(typ reflect.Type) string {
+	return "tbl_" + strings.ToLower(typ.Name()) + "s"
+}
+
+func (legacyNaming) ColumnName(field reflect.StructField) string {
+	return "col_" + strings.ToLower(field.Name)
+}
+
+func testNamingStrategy(t *testing.T, o *Orm) {
+	o.SetNamingStrategy(legacyNaming{})
+	defer o.SetNamingStrategy(nil)
+	table := o.mustRegister((*Legacy)(nil), nil)
+	o.mustInitialize()
+	if name := table.model.Table(); name != "tbl_legacys" {
+		t.Errorf("expecting table name tbl_legacys, got %q", name)
+	}
+	fields := table.Fields()
+	for k, v := range map[string]string{"Id": "col_id", "FullName": "col_fullname", "Code": "the_code"} {
+		if mname := fields.MNames[fields.QNameMap[k]]; mname != v {
+			t.Errorf("expecting column %q for field %s, got %q", v, k, mname)
+		}
+	}
+	o.MustInsert(&Legacy{FullName: "Gondola", Code: "gnd"})
+	var obj *Legacy
+	o.MustOne(Eq("FullName", "Gondola"), &obj)
+	if obj == nil || obj.Code != "gnd" {
+		t.Errorf("expecting object with code gnd, got %+v", obj)
+	}
+}
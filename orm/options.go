@@ -31,4 +31,35 @@ type Options struct {
 	// defined in both the a field tag and using this field, an
 	// error will be returned when registering the model.
 	PrimaryKey []string
+	// References declares references to other models for fields
+	// which can't be tagged. Keys are the qualified names of the
+	// fields, while values use the same syntax as the references
+	// tag (i.e. Model or Model(Field)). Models with references can
+	// be joined in queries (see Table.Join and Query.Join), which
+	// allows loading an object and its related objects in one
+	// round trip, e.g.
+	//
+	//	o.Register((*Comment)(nil), &orm.Options{
+	//		References: map[string]string{"PostId": "Post"},
+	//	})
+	//	...
+	//	// All the comments for the given post, loading both in each iteration
+	//	iter := o.Query(orm.Eq("Post|Id", postId)).Iter()
+	//	for iter.Next(&post, &comment) {
+	//		...
+	//	}
+	References map[string]string
+	// Comment is a human readable description of the table,
+	// which is stored in the database when the table is created
+	// (if the driver supports it). Field comments might be
+	// specified using the comment struct tag, e.g.
+	// `orm:",comment='User email address'"`.
+	Comment string
+	// CacheTimeout enables caching the results of the queries
+	// involving this model, for the given number of seconds, when
+	// the ORM has a cache (see Orm.SetCache). Negative values cache
+	// the results without expiration, until they're invalidated by
+	// a write to any of the tables involved in the query. Zero, the
+	// default, disables caching.
+	CacheTimeout int
 }
@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -17,10 +18,12 @@ import (
 
 var (
 	imports = map[string]string{
-		"postgres": "gnd.la/orm/driver/postgres",
-		"sqlite":   "gnd.la/orm/driver/sqlite",
-		"sqlite3":  "gnd.la/orm/driver/sqlite",
-		"mysql":    "gnd.la/orm/driver/mysql",
+		"postgres":    "gnd.la/orm/driver/postgres",
+		"sqlite":      "gnd.la/orm/driver/sqlite",
+		"sqlite3":     "gnd.la/orm/driver/sqlite",
+		"mysql":       "gnd.la/orm/driver/mysql",
+		"cockroach":   "gnd.la/orm/driver/cockroach",
+		"cockroachdb": "gnd.la/orm/driver/cockroach",
 	}
 	errUntypedNilPointer = errors.New("untyped nil pointer passed to Next(). Please, cast it to the appropriate type e.g. (*MyType)(nil)")
 	errNoModel           = errors.New("query without model - did you forget output parameters?")
@@ -43,6 +46,9 @@ type Orm struct {
 	logger       *log.Logger
 	tags         string
 	typeRegistry typeRegistry
+	ctx          context.Context
+	txRetries    int
+	naming       NamingStrategy
 	// these fields are non-nil iff the ORM driver uses database/sql
 	db *sql.DB
 }
@@ -102,6 +108,26 @@ func (o *Orm) MustOne(q query.Q, out ...interface{}) bool {
 	return ok
 }
 
+// OneByKey loads the object in the given table with the given primary
+// key into out, returning true iff it was found. See Table.Key for the
+// order of the values with composite primary keys.
+func (o *Orm) OneByKey(t *Table, out interface{}, key ...interface{}) (bool, error) {
+	q, err := t.Key(key...)
+	if err != nil {
+		return false, err
+	}
+	return o.Query(q).Table(t).One(out)
+}
+
+// MustOneByKey works like OneByKey, but panics if there's an error.
+func (o *Orm) MustOneByKey(t *Table, out interface{}, key ...interface{}) bool {
+	ok, err := o.OneByKey(t, out, key...)
+	if err != nil {
+		panic(err)
+	}
+	return ok
+}
+
 // All is a shorthand for Query(nil)
 func (o *Orm) All() *Query {
 	return o.Query(nil)
@@ -119,7 +145,14 @@ func (o *Orm) Insert(obj interface{}) (Result, error) {
 	if err := m.fields.Methods.Save(obj); err != nil {
 		return nil, err
 	}
-	return o.insert(m, obj)
+	if err := o.runHook(m, hookBeforeSave, obj); err != nil {
+		return nil, err
+	}
+	res, err := o.insert(m, obj)
+	if err != nil {
+		return nil, err
+	}
+	return res, o.runHook(m, hookAfterSave, obj)
 }
 
 // MustInsert works like Insert, but panics if there's
@@ -133,8 +166,19 @@ func (o *Orm) MustInsert(obj interface{}) Result {
 }
 
 func (o *Orm) insert(m *model, obj interface{}) (Result, error) {
+	return o.insertOrUpsert(m, obj, nil)
+}
+
+// insertOrUpsert inserts obj or, when upsert is non-nil, performs
+// a native upsert with it (see upsertsNatively). In both cases,
+// defaults are applied and the auto_increment primary key is set.
+func (o *Orm) insertOrUpsert(m *model, obj interface{}, upsert query.Q) (Result, error) {
 	if profile.On && profile.Profiling() {
-		defer profile.Start(orm).Note("insert", m.name).End()
+		op := "insert"
+		if upsert != nil {
+			op = "upsert"
+		}
+		defer profile.Start(orm).Note(op, m.name).End()
 	}
 	var pkName string
 	var pkVal reflect.Value
@@ -170,7 +214,19 @@ func (o *Orm) insert(m *model, obj interface{}) (Result, error) {
 			}
 		}
 	}
-	res, err := o.conn.Insert(m, obj)
+	if err := o.checkEnums(m, obj); err != nil {
+		return nil, err
+	}
+	if err := o.runHook(m, hookBeforeInsert, obj); err != nil {
+		return nil, err
+	}
+	var res Result
+	var err error
+	if upsert != nil {
+		res, err = o.conn.Upsert(m, upsert, obj)
+	} else {
+		res, err = o.conn.Insert(m, obj)
+	}
 	if err == nil && pkVal.IsValid() && pkVal.Int() == 0 {
 		id, err := res.LastInsertId()
 		if err == nil && id != 0 {
@@ -182,7 +238,10 @@ func (o *Orm) insert(m *model, obj interface{}) (Result, error) {
 			o.logger.Errorf("could not obtain last insert id: %s", err)
 		}
 	}
-	return res, err
+	if err != nil {
+		return nil, err
+	}
+	return res, o.runHook(m, hookAfterInsert, obj)
 }
 
 func (o *Orm) Update(q query.Q, obj interface{}) (Result, error) {
@@ -190,10 +249,12 @@ func (o *Orm) Update(q query.Q, obj interface{}) (Result, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := m.fields.Methods.Save(obj); err != nil {
-		return nil, err
-	}
-	return o.update(m, q, obj)
+	var res Result
+	err = o.saving(m, obj, func() (err error) {
+		res, err = o.update(m, q, obj)
+		return err
+	})
+	return res, err
 }
 
 // MustUpdate works like update, but panics if there's
@@ -207,30 +268,72 @@ func (o *Orm) MustUpdate(q query.Q, obj interface{}) Result {
 }
 
 func (o *Orm) update(m *model, q query.Q, obj interface{}) (Result, error) {
+	var res Result
+	err := o.updating(m, obj, func() (err error) {
+		res, err = o.conn.Update(m, q, obj)
+		return err
+	})
+	return res, err
+}
+
+// saving calls the Save method and the save hooks of obj
+// around fn, which should insert or update obj.
+func (o *Orm) saving(m *model, obj interface{}, fn func() error) error {
+	if err := m.fields.Methods.Save(obj); err != nil {
+		return err
+	}
+	if err := o.runHook(m, hookBeforeSave, obj); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return o.runHook(m, hookAfterSave, obj)
+}
+
+// updating checks the enums and calls the update hooks
+// of obj around fn, which should update obj.
+func (o *Orm) updating(m *model, obj interface{}, fn func() error) error {
 	if profile.On && profile.Profiling() {
 		defer profile.Start(orm).Note("update", m.name).End()
 	}
-	return o.conn.Update(m, q, obj)
+	if err := o.checkEnums(m, obj); err != nil {
+		return err
+	}
+	if err := o.runHook(m, hookBeforeUpdate, obj); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return o.runHook(m, hookAfterUpdate, obj)
 }
 
 // Upsert tries to perform an update with the given query
 // and object. If there are not affected rows, it performs
-// an insert. Some drivers (like mongodb) are able to perform
-// this operation in just one query, but most require two
-// trips to the database.
+// an insert. Some drivers are able to perform this operation
+// in just one query (e.g. the sql driver with PostgreSQL or
+// MySQL, when q only contains Eq conditions on the fields of
+// an unique key), but others require two trips to the database.
+// Models implementing any insert or update hooks always use
+// two trips, since it's not known in advance whether the
+// object will be inserted or updated.
 func (o *Orm) Upsert(q query.Q, obj interface{}) (Result, error) {
 	m, err := o.model(obj)
 	if err != nil {
 		return nil, err
 	}
-	if err := m.fields.Methods.Save(obj); err != nil {
-		return nil, err
-	}
-	if o.driver.Upserts() {
-		if profile.On && profile.Profiling() {
-			defer profile.Start(orm).Note("upsert", "").End()
-		}
-		return o.conn.Upsert(m, q, obj)
+	var res Result
+	err = o.saving(m, obj, func() (err error) {
+		res, err = o.upsert(m, q, obj)
+		return err
+	})
+	return res, err
+}
+
+func (o *Orm) upsert(m *model, q query.Q, obj interface{}) (Result, error) {
+	if o.upsertsNatively(m, q) {
+		return o.insertOrUpsert(m, obj, q)
 	}
 	res, err := o.update(m, q, obj)
 	if err != nil {
@@ -246,6 +349,17 @@ func (o *Orm) Upsert(q query.Q, obj interface{}) (Result, error) {
 	return res, err
 }
 
+// upsertsNatively returns true iff the upsert for m and q can
+// be performed by the driver in a single statement.
+func (o *Orm) upsertsNatively(m *model, q query.Q) bool {
+	const hooks = hookBeforeInsert | hookAfterInsert | hookBeforeUpdate | hookAfterUpdate
+	if !o.driver.Upserts() || m.hooks&hooks != 0 {
+		return false
+	}
+	n, ok := o.conn.(driver.NativeUpserter)
+	return ok && n.UpsertsNatively(m, q)
+}
+
 // MustUpsert works like Upsert, but panics if there's an error.
 func (o *Orm) MustUpsert(q query.Q, obj interface{}) Result {
 	res, err := o.Upsert(q, obj)
@@ -272,7 +386,14 @@ func (o *Orm) Save(obj interface{}) (Result, error) {
 	if err := m.fields.Methods.Save(obj); err != nil {
 		return nil, err
 	}
-	return o.save(m, obj)
+	if err := o.runHook(m, hookBeforeSave, obj); err != nil {
+		return nil, err
+	}
+	res, err := o.save(m, obj)
+	if err != nil {
+		return nil, err
+	}
+	return res, o.runHook(m, hookAfterSave, obj)
 }
 
 // MustSave works like save, but panics if there's an
@@ -303,11 +424,8 @@ func (o *Orm) save(m *model, obj interface{}) (Result, error) {
 		for _, v := range values {
 			if !driver.IsZero(v) {
 				// We have a non-zero value, try to update
-				qs := make([]query.Q, len(names))
-				for ii := range names {
-					qs[ii] = Eq(names[ii], values[ii].Interface())
-				}
-				res, err = o.update(m, And(qs...), obj)
+				// the row matching all the fields in the key
+				res, err = o.update(m, keyQuery(names, interfaces(values)), obj)
 				break
 			}
 		}
@@ -333,20 +451,41 @@ func (o *Orm) save(m *model, obj interface{}) (Result, error) {
 }
 
 // DeleteFrom removes all objects from the given table matching
-// the query.
+// the query. If the model uses soft deletes, the objects are
+// marked as deleted instead (see HardDeleteFrom).
 func (o *Orm) DeleteFrom(t *Table, q query.Q) (Result, error) {
-	return o.delete(t.model.model, q)
+	m := t.model.model
+	if m.softDelete != "" {
+		return o.softDelete(m, q)
+	}
+	return o.delete(m, q)
 }
 
 // Delete removes the given object, which must be of a type
 // previously registered as a table and must have a primary key,
-// either simple or composite.
+// either simple or composite. If the model uses soft deletes, the
+// object is marked as deleted instead (see HardDelete).
 func (o *Orm) Delete(obj interface{}) error {
 	m, err := o.model(obj)
 	if err != nil {
 		return err
 	}
-	return o.deleteByPk(m, obj)
+	q, err := o.pkQuery(m, obj)
+	if err != nil {
+		return err
+	}
+	if err := o.runHook(m, hookBeforeDelete, obj); err != nil {
+		return err
+	}
+	if m.softDelete != "" {
+		err = o.softDeleteObject(m, q, obj)
+	} else {
+		_, err = o.delete(m, q)
+	}
+	if err != nil {
+		return err
+	}
+	return o.runHook(m, hookAfterDelete, obj)
 }
 
 // MustDelete works like Delete, but panics if there's an error.
@@ -356,7 +495,7 @@ func (o *Orm) MustDelete(obj interface{}) {
 	}
 }
 
-func (o *Orm) deleteByPk(m *model, obj interface{}) error {
+func (o *Orm) pkQuery(m *model, obj interface{}) (query.Q, error) {
 	var q query.Q
 	if m.fields.PrimaryKey >= 0 {
 		pkName, pkVal := o.primaryKey(m.fields, obj)
@@ -365,17 +504,12 @@ func (o *Orm) deleteByPk(m *model, obj interface{}) error {
 		}
 	} else if len(m.fields.CompositePrimaryKey) > 0 {
 		names, values := o.compositePrimaryKey(m.fields, obj)
-		conditions := make([]query.Q, len(names))
-		for ii, v := range names {
-			conditions[ii] = Eq(v, values[ii].Interface())
-		}
-		q = And(conditions...)
+		q = keyQuery(names, interfaces(values))
 	}
 	if q == nil {
-		return fmt.Errorf("type %T does not have a primary key", obj)
+		return nil, fmt.Errorf("type %T does not have a primary key", obj)
 	}
-	_, err := o.delete(m, q)
-	return err
+	return q, nil
 }
 
 func (o *Orm) delete(m *model, q query.Q) (Result, error) {
@@ -430,7 +564,31 @@ func (o *Orm) MustBegin() *Tx {
 // error will be returned from Transaction. If no errors are returned
 // from f, the transaction is commited and the only error that might be
 // returned from Transaction will be one produced while committing.
+//
+// When the driver implements RetryClassifier (e.g. the sql driver) and
+// the transaction fails due to a transient conflict with another
+// transaction (e.g. a serialization failure or a deadlock), f is run
+// again in a new transaction, waiting a bit longer between attempts,
+// up to the number of retries set with SetTransactionRetries. For
+// this reason, f should not have side effects outside of the
+// transaction.
 func (o *Orm) Transaction(f func(o *Orm) error) error {
+	rc, _ := o.driver.(RetryClassifier)
+	for attempt := 0; ; attempt++ {
+		err := o.transaction(f)
+		if err == nil || rc == nil || attempt >= o.txRetries || !rc.IsRetryable(err) {
+			return err
+		}
+		if o.logger != nil {
+			o.logger.Debugf("Retrying transaction after error: %s", err)
+		}
+		if err := o.retryWait(attempt); err != nil {
+			return err
+		}
+	}
+}
+
+func (o *Orm) transaction(f func(o *Orm) error) error {
 	caps := o.driver.Capabilities()
 	if caps&driver.CAP_TRANSACTION == 0 {
 		return fmt.Errorf("ORM driver %T does not support transactions", o.driver)
@@ -490,6 +648,27 @@ func (o *Orm) SqlDB() *sql.DB {
 	return o.db
 }
 
+// Schema returns the live schema of the database, including
+// the tables not managed by the ORM, as well as the tables
+// defined by the registered models. Use sql.Schema.DDL to
+// dump the latter as SQL statements for review. It's only
+// supported by the drivers using database/sql. Otherwise,
+// ErrNoSql is returned. See gnd.la/orm/driver/sql.Driver.Schema
+// for more information.
+func (o *Orm) Schema() (*sql.Schema, error) {
+	drv, ok := o.driver.(*sql.Driver)
+	if !ok {
+		return nil, ErrNoSql
+	}
+	var schema *sql.Schema
+	err := o.initialize(func(ms []driver.Model) error {
+		var err error
+		schema, err = drv.Schema(ms)
+		return err
+	})
+	return schema, err
+}
+
 // Logger returns the logger for this ORM. By default, it's
 // nil.
 func (o *Orm) Logger() *log.Logger {
@@ -591,6 +770,14 @@ func (o *Orm) compositePrimaryKey(f *driver.Fields, obj interface{}) ([]string,
 	return names, values
 }
 
+func interfaces(values []reflect.Value) []interface{} {
+	ifaces := make([]interface{}, len(values))
+	for ii, v := range values {
+		ifaces[ii] = v.Interface()
+	}
+	return ifaces
+}
+
 func (o *Orm) compileTimeInterfaceTest() Interface {
 	return o
 }
@@ -622,6 +809,7 @@ func New(url *config.URL) (*Orm, error) {
 		driver:       drv,
 		tags:         tags,
 		typeRegistry: typeRegistry,
+		txRetries:    DefaultTransactionRetries,
 	}
 	if db, ok := drv.Connection().(*sql.DB); ok {
 		o.db = db
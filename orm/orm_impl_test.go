@@ -495,6 +495,25 @@ func testCompositePrimaryKey(t *testing.T, o *Orm) {
 	} else if c2 != 2 {
 		t.Errorf("expecting 2 rows, got %v instead", c2)
 	}
+	var comp3 *Composite
+	if !o.MustOneByKey(table, &comp3, 1, "Foo") {
+		t.Error("object not found by composite key")
+	} else if comp3.Value != "Baz" {
+		t.Errorf("expecting value %q, got %q", "Baz", comp3.Value)
+	}
+	if o.MustOneByKey(table, &comp3, 2, "Foo") {
+		t.Error("unexpected object found by composite key")
+	}
+	if _, err := table.Key(1); err == nil {
+		t.Error("expecting an error with an incomplete composite key")
+	}
+	// Saving must only update the row matching the whole key
+	comp3.Value = "Updated"
+	o.MustSave(comp3)
+	var other *Composite
+	if !o.MustOneByKey(table, &other, 1, "Go!") || other.Value == "Updated" {
+		t.Errorf("row with a different key was updated: %+v", other)
+	}
 }
 
 func testQueryAll(t *testing.T, o *Orm) {
@@ -678,6 +697,41 @@ func testOrm(t *testing.T, o *Orm) {
 		testDefaults,
 		testMigrations,
 		testSaveUnchanged,
+		testSchema,
+		testQueryCache,
+		testDecimal,
+		testNear,
+		testQueryComment,
+		testUpsert,
+		testBatches,
+		testReferenceActions,
+		testPolymorphic,
+		testEnum,
+		testOptionsReferences,
+		testContext,
+		testProjection,
+		testAggregate,
+		testSoftDelete,
+		testHooks,
+		testPartialIndexes,
+		testStats,
+		testCachedQuery,
+		testTransactionRetry,
+		testLike,
+		testBetweenNotIn,
+		testSubquery,
+		testKeyset,
+		testStream,
+		testDistinct,
+		testJSONContains,
+		testArray,
+		testCheck,
+		testNamingStrategy,
+		testInterceptor,
+		testExplain,
+		testReturning,
+		testRaw,
+		testFieldTime,
 	}
 	for _, v := range tests {
 		clearRegistry(o)
@@ -72,6 +72,150 @@ func TestSaveUnchanged(t *testing.T) {
 	runTest(t, testSaveUnchanged)
 }
 
+func TestSchema(t *testing.T) {
+	runTest(t, testSchema)
+}
+
+func TestComments(t *testing.T) {
+	runTest(t, testComments)
+}
+
+func TestQueryCache(t *testing.T) {
+	runTest(t, testQueryCache)
+}
+
+func TestDecimal(t *testing.T) {
+	runTest(t, testDecimal)
+}
+
+func TestNear(t *testing.T) {
+	runTest(t, testNear)
+}
+
+func TestQueryComment(t *testing.T) {
+	runTest(t, testQueryComment)
+}
+
+func TestUpsert(t *testing.T) {
+	runTest(t, testUpsert)
+}
+
+func TestBatches(t *testing.T) {
+	runTest(t, testBatches)
+}
+
+func TestReferenceActions(t *testing.T) {
+	runTest(t, testReferenceActions)
+}
+
+func TestPolymorphic(t *testing.T) {
+	runTest(t, testPolymorphic)
+}
+
+func TestEnum(t *testing.T) {
+	runTest(t, testEnum)
+}
+
+func TestOptionsReferences(t *testing.T) {
+	runTest(t, testOptionsReferences)
+}
+
+func TestContext(t *testing.T) {
+	runTest(t, testContext)
+}
+
+func TestProjection(t *testing.T) {
+	runTest(t, testProjection)
+}
+
+func TestAggregate(t *testing.T) {
+	runTest(t, testAggregate)
+}
+
+func TestSoftDelete(t *testing.T) {
+	runTest(t, testSoftDelete)
+}
+
+func TestHooks(t *testing.T) {
+	runTest(t, testHooks)
+}
+
+func TestPartialIndexes(t *testing.T) {
+	runTest(t, testPartialIndexes)
+}
+
+func TestStats(t *testing.T) {
+	runTest(t, testStats)
+}
+
+func TestCachedQuery(t *testing.T) {
+	runTest(t, testCachedQuery)
+}
+
+func TestTransactionRetry(t *testing.T) {
+	runTest(t, testTransactionRetry)
+}
+
+func TestLike(t *testing.T) {
+	runTest(t, testLike)
+}
+
+func TestBetweenNotIn(t *testing.T) {
+	runTest(t, testBetweenNotIn)
+}
+
+func TestSubquery(t *testing.T) {
+	runTest(t, testSubquery)
+}
+
+func TestKeyset(t *testing.T) {
+	runTest(t, testKeyset)
+}
+
+func TestStream(t *testing.T) {
+	runTest(t, testStream)
+}
+
+func TestDistinct(t *testing.T) {
+	runTest(t, testDistinct)
+}
+
+func TestJSONContains(t *testing.T) {
+	runTest(t, testJSONContains)
+}
+
+func TestArray(t *testing.T) {
+	runTest(t, testArray)
+}
+
+func TestCheck(t *testing.T) {
+	runTest(t, testCheck)
+}
+
+func TestNamingStrategy(t *testing.T) {
+	runTest(t, testNamingStrategy)
+}
+
+func TestInterceptor(t *testing.T) {
+	runTest(t, testInterceptor)
+}
+
+func TestExplain(t *testing.T) {
+	runTest(t, testExplain)
+}
+
+func TestReturning(t *testing.T) {
+	runTest(t, testReturning)
+}
+
+func TestRaw(t *testing.T) {
+	runTest(t, testRaw)
+}
+
+func TestFieldTime(t *testing.T) {
+	runTest(t, testFieldTime)
+}
+
 func BenchmarkLoadSaveMethods(b *testing.B) {
 	runBenchmark(b, benchmarkLoadSaveMethods)
 }
@@ -0,0 +1,123 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+
+	"gnd.la/orm/query"
+	"gnd.la/util/types"
+)
+
+// Polymorphic is a reference to an object of any registered model,
+// stored as a pair of columns holding the model name (see NameTable)
+// and the primary key of the referenced object. It's useful for models
+// which might belong to objects of different types, like comments or
+// attachments shared by several kinds of entities. e.g.
+//
+//	type Comment struct {
+//		Id    int64 `orm:",primary_key,auto_increment"`
+//		Owner orm.Polymorphic
+//		Text  string
+//	}
+//
+// The referenced models must have a single field integer primary key.
+// An index over both columns is created automatically. Use Orm.Polymorphic
+// to create a reference, Orm.Resolve to load the referenced object and
+// Orm.OwnedBy or References to query the objects which reference a given
+// one.
+type Polymorphic struct {
+	Type string `orm:",polymorphic,max_length=255"`
+	Id   int64
+}
+
+// IsZero returns true iff p does not reference any object.
+func (p Polymorphic) IsZero() bool {
+	return p.Type == "" && p.Id == 0
+}
+
+func (p Polymorphic) String() string {
+	return fmt.Sprintf("%s(%d)", p.Type, p.Id)
+}
+
+// References returns a query which matches the objects with
+// the given Polymorphic field referencing p.
+func References(field string, p Polymorphic) query.Q {
+	return And(Eq(field+".Type", p.Type), Eq(field+".Id", p.Id))
+}
+
+// Polymorphic returns a Polymorphic referencing the given object,
+// whose type must have been registered.
+func (o *Orm) Polymorphic(obj interface{}) (Polymorphic, error) {
+	m, err := o.model(obj)
+	if err != nil {
+		return Polymorphic{}, err
+	}
+	pk := m.fields.PrimaryKey
+	if pk < 0 {
+		return Polymorphic{}, fmt.Errorf("model %s can't be referenced by a Polymorphic, it has no single field primary key", m.name)
+	}
+	val := reflect.ValueOf(obj)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return Polymorphic{}, fmt.Errorf("can't reference a nil %T", obj)
+		}
+		val = val.Elem()
+	}
+	id := val.FieldByIndex(m.fields.Indexes[pk])
+	p := Polymorphic{Type: m.name}
+	switch types.Kind(id.Kind()) {
+	case types.Int:
+		p.Id = id.Int()
+	case types.Uint:
+		p.Id = int64(id.Uint())
+	default:
+		return Polymorphic{}, fmt.Errorf("model %s can't be referenced by a Polymorphic, its primary key is %s rather than an integer", m.name, id.Type())
+	}
+	return p, nil
+}
+
+// MustPolymorphic works like Polymorphic, but panics if there's an error.
+func (o *Orm) MustPolymorphic(obj interface{}) Polymorphic {
+	p, err := o.Polymorphic(obj)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// Resolve loads the object referenced by p and returns it as a pointer
+// to its concrete type (e.g. *Article). If p is zero or the referenced
+// object does not exist, it returns nil without any error.
+func (o *Orm) Resolve(p Polymorphic) (interface{}, error) {
+	if p.IsZero() {
+		return nil, nil
+	}
+	table := o.NameTable(p.Type)
+	if table == nil {
+		return nil, fmt.Errorf("no model named %q", p.Type)
+	}
+	m := table.model.model
+	if m.fields.PrimaryKey < 0 {
+		return nil, fmt.Errorf("model %s has no single field primary key", m.name)
+	}
+	obj := reflect.New(m.Type()).Interface()
+	ok, err := o.Query(Eq(m.fields.QNames[m.fields.PrimaryKey], p.Id)).Table(table).One(obj)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// OwnedBy returns a query which matches the objects with the given
+// Polymorphic field referencing owner. e.g.
+//
+//	q, err := o.OwnedBy("Owner", article)
+//	var comments []*Comment
+//	err = o.Query(q).All(&comments)
+func (o *Orm) OwnedBy(field string, owner interface{}) (query.Q, error) {
+	p, err := o.Polymorphic(owner)
+	if err != nil {
+		return nil, err
+	}
+	return References(field, p), nil
+}
@@ -0,0 +1,75 @@
+package orm
+
+import (
+	"testing"
+)
+
+type Article struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Title string
+}
+
+type Photo struct {
+	Id  int64 `orm:",primary_key,auto_increment"`
+	URL string
+}
+
+type Comment struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Owner Polymorphic
+	Text  string
+}
+
+func testPolymorphic(t *testing.T, o *Orm) {
+	o.mustRegister((*Article)(nil), &Options{
+		Table: "test_polymorphic_article",
+	})
+	o.mustRegister((*Photo)(nil), &Options{
+		Table: "test_polymorphic_photo",
+	})
+	commentTable := o.mustRegister((*Comment)(nil), &Options{
+		Table: "test_polymorphic_comment",
+	})
+	o.mustInitialize()
+	article := &Article{Title: "Hello"}
+	o.MustInsert(article)
+	photo := &Photo{URL: "http://example.com/photo.jpg"}
+	o.MustInsert(photo)
+	o.MustInsert(&Comment{Owner: o.MustPolymorphic(article), Text: "A1"})
+	o.MustInsert(&Comment{Owner: o.MustPolymorphic(article), Text: "A2"})
+	o.MustInsert(&Comment{Owner: o.MustPolymorphic(photo), Text: "P1"})
+	q, err := o.OwnedBy("Owner", article)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var comments []*Comment
+	o.Query(q).Table(commentTable).Sort("Id", ASC).MustAll(&comments)
+	if len(comments) != 2 || comments[0].Text != "A1" || comments[1].Text != "A2" {
+		t.Fatalf("expecting comments A1 and A2 for article, got %+v", comments)
+	}
+	var comment *Comment
+	o.Query(References("Owner", o.MustPolymorphic(photo))).Table(commentTable).MustOne(&comment)
+	if comment.Text != "P1" {
+		t.Errorf("expecting comment P1 for photo, got %q", comment.Text)
+	}
+	owner, err := o.Resolve(comment.Owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := owner.(*Photo); !ok || p.Id != photo.Id || p.URL != photo.URL {
+		t.Errorf("expecting owner %+v, got %+v", photo, owner)
+	}
+	owner, err = o.Resolve(comments[0].Owner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a, ok := owner.(*Article); !ok || a.Title != article.Title {
+		t.Errorf("expecting owner %+v, got %+v", article, owner)
+	}
+	if owner, err := o.Resolve(Polymorphic{}); owner != nil || err != nil {
+		t.Errorf("expecting nil owner for zero Polymorphic, got %v (error %v)", owner, err)
+	}
+	if _, err := o.Resolve(Polymorphic{Type: "Unknown", Id: 1}); err == nil {
+		t.Error("expecting an error when resolving an unknown model")
+	}
+}
@@ -0,0 +1,154 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gnd.la/orm/driver"
+	"gnd.la/util/structs"
+)
+
+// Fields restricts the fields loaded by the query to the given ones,
+// which must be qualified names (e.g. Name or Address.City). Naming an
+// embedded struct selects all its fields. The remaining fields in the
+// loaded objects are left at their zero values, so this is useful for
+// avoiding loading large fields (e.g. blobs) when they're not needed.
+//
+// When the query involves several models, fields might be prefixed by
+// the model name (e.g. User|Name). Unprefixed fields refer to the first
+// model in the query. Models without any selected fields are fully
+// loaded.
+//
+// Keep in mind that saving a partially loaded object will overwrite
+// the fields which weren't loaded with their zero values.
+func (q *Query) Fields(names ...string) *Query {
+	q.fields = append(q.fields, names...)
+	return q
+}
+
+// project replaces the query model with a copy which
+// only loads the fields selected by Fields.
+func (q *Query) project() error {
+	model, err := q.projectedModel()
+	if err != nil {
+		return err
+	}
+	q.model = model
+	return nil
+}
+
+// projectedModel returns a copy of the query model which
+// only loads the fields selected by Fields.
+func (q *Query) projectedModel() (*joinModel, error) {
+	model := q.model.clone()
+	selected := make(map[*joinModel][]string)
+	for _, v := range q.fields {
+		m := model
+		if sep := strings.IndexByte(v, '|'); sep >= 0 {
+			name := v[:sep]
+			for m = model; m != nil; m = m.nextModel() {
+				if m.name == name || m.shortName == name {
+					break
+				}
+			}
+			if m == nil {
+				return nil, fmt.Errorf("can't select field %q, no model named %q in query", v, name)
+			}
+			v = v[sep+1:]
+		}
+		selected[m] = append(selected[m], v)
+	}
+	for m, names := range selected {
+		fields, err := projectFields(m.model.fields, names)
+		if err != nil {
+			return nil, fmt.Errorf("can't select fields in model %s: %s", m.model.name, err)
+		}
+		m.projected = fields
+	}
+	return model, nil
+}
+
+// projectFields returns a copy of f with only the given fields,
+// in the same order they appear in f.
+func projectFields(f *driver.Fields, names []string) (*driver.Fields, error) {
+	var indexes []int
+	seen := make(map[int]bool)
+	add := func(idx int) {
+		if !seen[idx] {
+			seen[idx] = true
+			indexes = append(indexes, idx)
+		}
+	}
+	for _, v := range names {
+		if idx, ok := f.QNameMap[v]; ok {
+			add(idx)
+			continue
+		}
+		// Check if it's an embedded struct
+		prefix := v + "."
+		found := false
+		for ii, qname := range f.QNames {
+			if strings.HasPrefix(qname, prefix) {
+				add(ii)
+				found = true
+			}
+		}
+		if !found {
+			return nil, errCantMap(v)
+		}
+	}
+	sort.Ints(indexes)
+	s := &structs.Struct{
+		Type:     f.Type,
+		MNameMap: make(map[string]int, len(indexes)),
+		QNameMap: make(map[string]int, len(indexes)),
+		Pointers: f.Pointers,
+	}
+	p := &driver.Fields{
+		Struct:     s,
+		PrimaryKey: -1,
+		Methods:    f.Methods,
+		References: f.References,
+	}
+	remap := make(map[int]int, len(indexes))
+	for ii, idx := range indexes {
+		remap[idx] = ii
+		s.MNames = append(s.MNames, f.MNames[idx])
+		s.QNames = append(s.QNames, f.QNames[idx])
+		s.Indexes = append(s.Indexes, f.Indexes[idx])
+		s.Types = append(s.Types, f.Types[idx])
+		s.Tags = append(s.Tags, f.Tags[idx])
+		s.MNameMap[f.MNames[idx]] = ii
+		s.QNameMap[f.QNames[idx]] = ii
+		p.QuotedNames = append(p.QuotedNames, f.QuotedNames[idx])
+		p.OmitEmpty = append(p.OmitEmpty, f.OmitEmpty[idx])
+		p.NullEmpty = append(p.NullEmpty, f.NullEmpty[idx])
+		if idx == f.PrimaryKey {
+			p.PrimaryKey = ii
+			p.AutoincrementPk = f.AutoincrementPk
+		}
+		if def, ok := f.Defaults[idx]; ok {
+			if p.Defaults == nil {
+				p.Defaults = make(map[int]reflect.Value)
+			}
+			p.Defaults[ii] = def
+		}
+		if enum, ok := f.Enums[idx]; ok {
+			if p.Enums == nil {
+				p.Enums = make(map[int][]string)
+			}
+			p.Enums[ii] = enum
+		}
+	}
+	for _, v := range f.CompositePrimaryKey {
+		idx, ok := remap[v]
+		if !ok {
+			p.CompositePrimaryKey = nil
+			break
+		}
+		p.CompositePrimaryKey = append(p.CompositePrimaryKey, idx)
+	}
+	return p, nil
+}
@@ -0,0 +1,75 @@
+package orm
+
+import (
+	"strings"
+	"testing"
+)
+
+type ProjectionAddress struct {
+	City    string
+	Country string
+}
+
+type ProjectionUser struct {
+	Id      int64 `orm:",primary_key,auto_increment"`
+	Name    string
+	Email   string
+	Avatar  []byte
+	Address ProjectionAddress
+}
+
+func testProjection(t *testing.T, o *Orm) {
+	table := o.mustRegister((*ProjectionUser)(nil), &Options{
+		Table: "test_projection_user",
+	})
+	o.mustInitialize()
+	o.MustInsert(&ProjectionUser{
+		Name:    "Alice",
+		Email:   "alice@example.com",
+		Avatar:  []byte("large blob"),
+		Address: ProjectionAddress{City: "Madrid", Country: "Spain"},
+	})
+	var u *ProjectionUser
+	if !o.Query(Eq("Name", "Alice")).Table(table).Fields("Name", "Email").MustOne(&u) {
+		t.Fatal("user not found")
+	}
+	if u.Name != "Alice" || u.Email != "alice@example.com" {
+		t.Errorf("selected fields not loaded: %+v", u)
+	}
+	if u.Id != 0 || u.Avatar != nil || u.Address.City != "" {
+		t.Errorf("unselected fields should have their zero value: %+v", u)
+	}
+	// Embedded structs select all their fields
+	var users []*ProjectionUser
+	o.Query(nil).Table(table).Fields("Id", "Address").MustAll(&users)
+	if len(users) != 1 {
+		t.Fatalf("expecting 1 user, got %d", len(users))
+	}
+	if u := users[0]; u.Id == 0 || u.Address.City != "Madrid" || u.Address.Country != "Spain" || u.Name != "" {
+		t.Errorf("unexpected projection result %+v", u)
+	}
+	// Clones keep the selected fields, without sharing them
+	q := o.Query(nil).Table(table).Fields("Name")
+	c := q.Clone().Fields("Email")
+	q.MustOne(&u)
+	if u.Name != "Alice" || u.Email != "" {
+		t.Errorf("expecting only Name to be loaded, got %+v", u)
+	}
+	c.MustOne(&u)
+	if u.Name != "Alice" || u.Email != "alice@example.com" || u.Avatar != nil {
+		t.Errorf("expecting Name and Email to be loaded by clone, got %+v", u)
+	}
+	// Unknown fields return an error
+	iter := o.Query(nil).Table(table).Fields("Nope").Iter()
+	if iter.Next(&u) {
+		t.Error("expecting no results when selecting an unknown field")
+	}
+	if err := iter.Err(); err == nil || !strings.Contains(err.Error(), "Nope") {
+		t.Errorf("expecting an error selecting an unknown field, got %v", err)
+	}
+	// Full objects must still be loaded without Fields
+	o.Query(nil).Table(table).MustOne(&u)
+	if u.Avatar == nil || u.Address.City == "" {
+		t.Errorf("expecting full object, got %+v", u)
+	}
+}
@@ -2,6 +2,7 @@ package orm
 
 import (
 	"gnd.la/orm/query"
+	"gnd.la/util/geo"
 )
 
 func Eq(field string, value interface{}) query.Q {
@@ -22,6 +23,11 @@ func Neq(field string, value interface{}) query.Q {
 	}
 }
 
+// Contains returns a condition which matches values containing the
+// given string. Any % or _ characters in value are escaped, so they
+// are matched literally. For slice fields stored as native arrays
+// (see driver.CAP_ARRAY), it matches arrays which include value as
+// one of their elements.
 func Contains(field string, value interface{}) query.Q {
 	return &query.Contains{
 		Field: query.Field{
@@ -31,6 +37,46 @@ func Contains(field string, value interface{}) query.Q {
 	}
 }
 
+// StartsWith returns a condition which matches values starting
+// with the given string. As in Contains, value is escaped.
+func StartsWith(field string, value interface{}) query.Q {
+	return &query.StartsWith{
+		Field: query.Field{
+			Field: field,
+			Value: value,
+		},
+	}
+}
+
+// Like returns a condition which matches the field against the
+// given LIKE pattern. Note that pattern is passed verbatim to the
+// backend, so any user input used in it should be escaped with
+// EscapeLike.
+func Like(field string, pattern string) query.Q {
+	return &query.Like{
+		Field: query.Field{
+			Field: field,
+			Value: pattern,
+		},
+	}
+}
+
+// ILike works like Like, but performs a case insensitive match.
+func ILike(field string, pattern string) query.Q {
+	return &query.ILike{
+		Field: query.Field{
+			Field: field,
+			Value: pattern,
+		},
+	}
+}
+
+// EscapeLike escapes the wildcard characters in s, so it can
+// be safely used as a part of a pattern passed to Like or ILike.
+func EscapeLike(s string) string {
+	return query.EscapeLike(s)
+}
+
 func Lt(field string, value interface{}) query.Q {
 	return &query.Lt{
 		Field: query.Field{
@@ -67,6 +113,10 @@ func Gte(field string, value interface{}) query.Q {
 	}
 }
 
+// In returns the objects whose field value is any of the values in
+// the given slice or array. The value might also be a query.Subquery
+// or a *Query, which is translated into a subquery by the driver (see
+// Query.Subselect).
 func In(field string, value interface{}) query.Q {
 	return &query.In{
 		Field: query.Field{
@@ -76,6 +126,52 @@ func In(field string, value interface{}) query.Q {
 	}
 }
 
+// NotIn returns the objects whose field value is not any of the
+// values in the given slice or array. As in In, value might also be
+// a query.Subquery or a *Query.
+func NotIn(field string, value interface{}) query.Q {
+	return &query.NotIn{
+		Field: query.Field{
+			Field: field,
+			Value: value,
+		},
+	}
+}
+
+// JSONContains returns the objects whose field, which must use the json
+// codec (e.g. `orm:",codec=json"`), contains the JSON document obtained by
+// encoding value. Containment is checked recursively, so objects match
+// when they include at least the given keys with the given values, while
+// arrays match when they include all the given elements (e.g.
+// JSONContains("Meta", map[string]interface{}{"tags": []string{"go"}})
+// matches the objects with "go" in Meta.tags). PostgreSQL uses the
+// @> operator, while MySQL uses JSON_CONTAINS. Other backends don't
+// support this query (see driver.CAP_JSON).
+func JSONContains(field string, value interface{}) query.Q {
+	return &query.JSONContains{
+		Field: query.Field{
+			Field: field,
+			Value: value,
+		},
+	}
+}
+
+// Near returns the objects whose field, which must be of type
+// gnd.la/util/geo.Point, is within radius meters of point. The
+// exact implementation depends on the backend. PostgreSQL uses
+// ST_DWithin when PostGIS is available, MySQL uses
+// ST_Distance_Sphere and other backends fall back to the haversine
+// formula or an approximation suitable for small radii.
+func Near(field string, point geo.Point, radius float64) query.Q {
+	return &query.Near{
+		Field: query.Field{
+			Field: field,
+			Value: point,
+		},
+		Radius: radius,
+	}
+}
+
 func And(qs ...query.Q) query.Q {
 	return &query.And{
 		Combinator: query.Combinator{
@@ -100,8 +196,15 @@ func Between(field string, begin interface{}, end interface{}) query.Q {
 }
 
 // CBetween stands for closed between and is equivalent to field >= begin AND field <= end.
+// Backends which support it use the BETWEEN operator.
 func CBetween(field string, begin interface{}, end interface{}) query.Q {
-	return And(Gte(field, begin), Lte(field, end))
+	return &query.Between{
+		Field: query.Field{
+			Field: field,
+			Value: begin,
+		},
+		End: end,
+	}
 }
 
 // LCBetween stands for left closed between and is equivalent to field >= begin AND field < end.
@@ -113,3 +216,9 @@ func LCBetween(field string, begin interface{}, end interface{}) query.Q {
 func RCBetween(field string, begin interface{}, end interface{}) query.Q {
 	return And(Gt(field, begin), Lte(field, end))
 }
+
+// Raw returns a condition with the given SQL fragment and arguments.
+// See gnd.la/orm/query.Raw for the details.
+func Raw(sql string, args ...interface{}) query.Q {
+	return query.Raw(sql, args...)
+}
@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"errors"
 	"fmt"
 	"gnd.la/app/profile"
 	"gnd.la/orm/driver"
@@ -8,16 +9,25 @@ import (
 	"reflect"
 )
 
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
 type Query struct {
-	orm     *Orm
-	model   *joinModel
-	methods []*driver.Methods
-	jtype   JoinType
-	q       query.Q
-	sort    []driver.Sort
-	limit   int
-	offset  int
-	err     error
+	orm      *Orm
+	model    *joinModel
+	methods  []*driver.Methods
+	jtype    JoinType
+	q        query.Q
+	sort     []driver.Sort
+	limit    int
+	offset   int
+	fields   []string
+	unscoped bool
+	cached   int
+	keyset   bool
+	after    string
+	stream   int
+	distinct bool
+	err      error
 }
 
 func (q *Query) ensureTable(f string) error {
@@ -122,7 +132,7 @@ func (q *Query) Exists() (bool, error) {
 	if profile.On && profile.Profiling() {
 		defer profile.Start(orm).Note("exists", q.model.String()).End()
 	}
-	return q.orm.driver.Exists(q.model, q.q)
+	return q.orm.driver.Exists(q.model, q.condition())
 }
 
 // Iter returns an Iter object which lets you
@@ -193,7 +203,19 @@ func (q *Query) Count() (uint64, error) {
 	if profile.On && profile.Profiling() {
 		defer profile.Start(orm).Note("count", q.model.String()).End()
 	}
-	return q.orm.driver.Count(q.model, q.q, q.limit, q.offset)
+	m := q.model
+	if q.distinct && len(q.fields) > 0 {
+		// Count the distinct values of the selected fields
+		var err error
+		if m, err = q.projectedModel(); err != nil {
+			return 0, err
+		}
+	}
+	m, err := q.distinctModel(m)
+	if err != nil {
+		return 0, err
+	}
+	return q.orm.driver.Count(m, q.condition(), q.limit, q.offset)
 }
 
 // MustCount works like Count, but panics if there's an error.
@@ -205,16 +227,118 @@ func (q *Query) MustCount() uint64 {
 	return c
 }
 
+// Batches iterates over the results of the query in batches of at
+// most size objects, calling fn with each one of them. fn must be a
+// function which receives a slice of the model type and returns an
+// error (e.g. func([]*User) error). Rather than keeping a cursor open
+// or using offsets, each batch is loaded with a new query which
+// selects the objects with a primary key greater than the last one in
+// the previous batch, so fn might safely modify or delete the objects
+// it receives. Since no transaction is held open while iterating,
+// fn might use its own transaction (see Orm.Transaction) to commit
+// the changes for each batch before the next one is loaded, which
+// makes Batches suitable for backfills and maintenance tasks over
+// large tables.
+//
+// The model must have a primary key formed by a single field and
+// joins are not supported. Any sorting, limit or offset set in the
+// query is ignored. If fn returns an error, the iteration stops and
+// the error is returned.
+func (q *Query) Batches(size int, fn interface{}) error {
+	if size <= 0 {
+		return fmt.Errorf("invalid batch size %d", size)
+	}
+	fval := reflect.ValueOf(fn)
+	ftyp := fval.Type()
+	if ftyp.Kind() != reflect.Func || ftyp.NumIn() != 1 || ftyp.In(0).Kind() != reflect.Slice ||
+		ftyp.NumOut() != 1 || ftyp.Out(0) != errorType {
+		return fmt.Errorf("argument to Batches() must be a func([]T) error, not %T", fn)
+	}
+	sliceType := ftyp.In(0)
+	model := q.model
+	if model == nil {
+		table := q.orm.TypeTable(sliceType.Elem())
+		if table == nil {
+			return fmt.Errorf("no model registered for type %v", sliceType.Elem())
+		}
+		model = table.model
+	}
+	if model.join != nil {
+		return errors.New("joins are not supported by Batches()")
+	}
+	fields := model.fields
+	if fields.PrimaryKey < 0 {
+		return fmt.Errorf("model %s must have a single field primary key to use Batches()", model)
+	}
+	pk := fields.QNames[fields.PrimaryKey]
+	pkIndex := fields.Indexes[fields.PrimaryKey]
+	sort := []driver.Sort{&querySort{field: pk, dir: driver.SortDirection(ASC)}}
+	var last interface{}
+	for {
+		cond := q.q
+		if last != nil {
+			if cond == nil {
+				cond = Gt(pk, last)
+			} else {
+				cond = And(cond, Gt(pk, last))
+			}
+		}
+		bq := &Query{
+			orm:      q.orm,
+			model:    model,
+			q:        cond,
+			sort:     sort,
+			limit:    size,
+			offset:   -1,
+			unscoped: q.unscoped,
+		}
+		batch := reflect.New(sliceType)
+		if err := bq.All(batch.Interface()); err != nil {
+			return err
+		}
+		n := batch.Elem().Len()
+		if n == 0 {
+			break
+		}
+		if res := fval.Call([]reflect.Value{batch.Elem()}); !res[0].IsNil() {
+			return res[0].Interface().(error)
+		}
+		if n < size {
+			break
+		}
+		obj := batch.Elem().Index(n - 1)
+		for obj.Kind() == reflect.Ptr {
+			obj = obj.Elem()
+		}
+		last = obj.FieldByIndex(pkIndex).Interface()
+	}
+	return nil
+}
+
+// MustBatches works like Batches, but panics if there's an error.
+func (q *Query) MustBatches(size int, fn interface{}) {
+	if err := q.Batches(size, fn); err != nil {
+		panic(err)
+	}
+}
+
 // Clone returns a copy of the query.
 func (q *Query) Clone() *Query {
 	return &Query{
-		orm:    q.orm,
-		model:  q.model,
-		q:      q.q,
-		sort:   q.sort,
-		limit:  q.limit,
-		offset: q.offset,
-		err:    q.err,
+		orm:      q.orm,
+		model:    q.model,
+		q:        q.q,
+		sort:     q.sort,
+		limit:    q.limit,
+		offset:   q.offset,
+		fields:   append([]string(nil), q.fields...),
+		unscoped: q.unscoped,
+		cached:   q.cached,
+		keyset:   q.keyset,
+		after:    q.after,
+		stream:   q.stream,
+		distinct: q.distinct,
+		err:      q.err,
 	}
 }
 
@@ -226,11 +350,22 @@ func (q *Query) iter(limit int) *Iter {
 	}
 }
 
-func (q *Query) exec(limit int) driver.Iter {
+func (q *Query) exec(limit int, offset int, after string) (driver.Iter, error) {
+	cond, sort := q.condition(), q.sort
+	if q.keyset {
+		var err error
+		if cond, sort, err = q.keysetCondition(cond, after); err != nil {
+			return nil, err
+		}
+	}
 	if profile.On && profile.Profiling() {
 		defer profile.Start(orm).Note("query", q.model.String()).End()
 	}
-	return q.orm.conn.Query(q.model, q.q, q.sort, limit, q.offset)
+	m, err := q.distinctModel(q.cachedModel())
+	if err != nil {
+		return nil, err
+	}
+	return q.orm.conn.Query(m, cond, sort, limit, offset), nil
 }
 
 // Field is a conveniency function which returns a reference to a field
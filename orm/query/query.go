@@ -61,6 +61,37 @@ func (c *Contains) String() string {
 	return qDesc(&c.Field, "CONTAINS (") + ")"
 }
 
+// StartsWith matches values which start with the given
+// string. Wildcard characters in the value are matched
+// literally.
+type StartsWith struct {
+	Field
+}
+
+func (s *StartsWith) String() string {
+	return qDesc(&s.Field, "STARTS WITH (") + ")"
+}
+
+// Like matches values against a LIKE pattern, where %
+// matches any sequence of characters and _ matches a
+// single one. The pattern is not escaped.
+type Like struct {
+	Field
+}
+
+func (l *Like) String() string {
+	return qDesc(&l.Field, "LIKE ")
+}
+
+// ILike is the case insensitive version of Like.
+type ILike struct {
+	Field
+}
+
+func (l *ILike) String() string {
+	return qDesc(&l.Field, "ILIKE ")
+}
+
 type Lt struct {
 	Field
 }
@@ -97,6 +128,43 @@ type In struct {
 	Field
 }
 
+// NotIn is the negation of In. Note that, as in SQL, a
+// NULL field never matches, neither In nor NotIn.
+type NotIn struct {
+	Field
+}
+
+// Between matches values in the closed interval [Value, End].
+type Between struct {
+	Field
+	End interface{}
+}
+
+func (b *Between) String() string {
+	return fmt.Sprintf("%s AND %v", qDesc(&b.Field, "BETWEEN "), b.End)
+}
+
+// JSONContains matches the objects with a JSON encoded field
+// which contains the JSON document obtained by encoding Value.
+type JSONContains struct {
+	Field
+}
+
+func (j *JSONContains) String() string {
+	return qDesc(&j.Field, "@> ")
+}
+
+// Near matches the objects with a gnd.la/util/geo.Point field
+// (stored in Value) within Radius meters of the given point.
+type Near struct {
+	Field
+	Radius float64
+}
+
+func (n *Near) String() string {
+	return fmt.Sprintf("%q NEAR %v (%vm)", n.Field.Field, n.Value, n.Radius)
+}
+
 type Combinator struct {
 	Conditions []Q
 }
@@ -144,6 +212,42 @@ func (o *Operator) String() string {
 	return qDesc(&o.Field, o.Operator+" ")
 }
 
+// RawSQL represents a condition written in SQL, with ? used as
+// the placeholder for each argument. See Raw.
+type RawSQL struct {
+	SQL  string
+	Args []interface{}
+}
+
+// Raw returns a condition with the given SQL fragment, which is
+// passed to the database almost as-is. Each ? outside of quoted
+// strings and identifiers is replaced by the placeholder for the
+// corresponding argument, using the syntax required by the backend
+// (e.g. $1 in Postgres), while ?? is replaced by a literal ?.
+// Arguments of type F and Subquery are inlined, like in the other
+// conditions.
+//
+// Since the fragment isn't parsed, it must use the database column
+// names. Raw is intended as an escape hatch for expressions which
+// can't be represented with the other conditions e.g.
+//
+//	query.Raw("lower(email) = ?", email)
+func Raw(sql string, args ...interface{}) Q {
+	return &RawSQL{SQL: sql, Args: args}
+}
+
+func (r *RawSQL) FieldName() string {
+	return ""
+}
+
+func (r *RawSQL) SubQ() []Q {
+	return nil
+}
+
+func (r *RawSQL) String() string {
+	return fmt.Sprintf("RAW(%q %v)", r.SQL, r.Args)
+}
+
 func combDesc(c *Combinator, w string) string {
 	qs := make([]string, len(c.Conditions))
 	for ii, v := range c.Conditions {
@@ -158,3 +262,20 @@ func qDesc(f *Field, symb string) string {
 	}
 	return fmt.Sprintf("%q %s%v", f.Field, symb, f.Value)
 }
+
+// LikeEscape is the character used for escaping wildcards in
+// LIKE patterns. Drivers must declare it as the escape character
+// when translating Like, ILike, Contains and StartsWith.
+const LikeEscape = '!'
+
+var likeEscaper = strings.NewReplacer(
+	string(LikeEscape), string(LikeEscape)+string(LikeEscape),
+	"%", string(LikeEscape)+"%",
+	"_", string(LikeEscape)+"_",
+)
+
+// EscapeLike escapes the wildcard characters in s using
+// LikeEscape.
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,70 @@
+package orm
+
+import (
+	"bytes"
+	"net/url"
+	"sort"
+	"strings"
+
+	"gnd.la/orm/driver"
+)
+
+// Commenter is implemented by drivers which can prepend a
+// comment to the queries they perform (the sql driver
+// implements this interface).
+type Commenter interface {
+	WithComment(comment string) driver.Conn
+}
+
+// WithComment returns a copy of the Orm which prepends the given
+// key/value pairs as an SQL comment to all the queries it performs,
+// using the sqlcommenter format (e.g. /*request_id='abc',route='list'*/).
+// This allows correlating the queries which appear in the database
+// logs (e.g. slow query logs) with the code which issued them. If
+// the driver does not implement Commenter or values is empty, o is
+// returned unchanged.
+//
+// gnd.la/app can populate these comments automatically for each
+// request, see App.SetOrmComments.
+func (o *Orm) WithComment(values map[string]string) *Orm {
+	cm, ok := o.conn.(Commenter)
+	if !ok || len(values) == 0 {
+		return o
+	}
+	conn := cm.WithComment(formatComment(values))
+	cpy := *o
+	cpy.conn = conn
+	if driver.Conn(o.driver) == o.conn {
+		if drv, ok := conn.(driver.Driver); ok {
+			cpy.driver = drv
+		}
+	}
+	return &cpy
+}
+
+func formatComment(values map[string]string) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	buf.WriteString("/*")
+	for ii, k := range keys {
+		if ii > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(commentEscape(k))
+		buf.WriteString("='")
+		buf.WriteString(commentEscape(values[k]))
+		buf.WriteByte('\'')
+	}
+	buf.WriteString("*/")
+	return buf.String()
+}
+
+// commentEscape URL encodes s, which guarantees that the result
+// can't terminate the comment nor contain quotes or placeholders.
+func commentEscape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
@@ -0,0 +1,44 @@
+package orm
+
+import (
+	"testing"
+)
+
+type Commented struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Value string
+}
+
+func TestFormatComment(t *testing.T) {
+	cases := []struct {
+		values  map[string]string
+		comment string
+	}{
+		{map[string]string{"route": "users", "request_id": "abc"}, "/*request_id='abc',route='users'*/"},
+		{map[string]string{"route": "/users/{id}"}, "/*route='%2Fusers%2F%7Bid%7D'*/"},
+		{map[string]string{"a b": "it's ? */"}, "/*a%20b='it%27s%20%3F%20%2A%2F'*/"},
+	}
+	for _, v := range cases {
+		if c := formatComment(v.values); c != v.comment {
+			t.Errorf("expecting comment %q for %v, got %q", v.comment, v.values, c)
+		}
+	}
+}
+
+func testQueryComment(t *testing.T, o *Orm) {
+	table := o.mustRegister((*Commented)(nil), &Options{Table: "commented"})
+	o.mustInitialize()
+	co := o.WithComment(map[string]string{"route": "test", "request_id": "it's ?"})
+	co.MustInsert(&Commented{Value: "foo"})
+	var obj *Commented
+	if ok := co.Query(Eq("Value", "foo")).Table(table).MustOne(&obj); !ok {
+		t.Fatal("object not found using commented queries")
+	}
+	if n := co.Table(table).MustCount(); n != 1 {
+		t.Errorf("expecting 1 object, got %d", n)
+	}
+	co.MustDelete(obj)
+	if n := o.Table(table).MustCount(); n != 0 {
+		t.Errorf("expecting 0 objects after delete, got %d", n)
+	}
+}
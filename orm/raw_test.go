@@ -0,0 +1,46 @@
+package orm
+
+import (
+	"testing"
+
+	"gnd.la/orm/query"
+)
+
+type RawMatched struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Email string
+	Score int
+}
+
+func testRaw(t *testing.T, o *Orm) {
+	table := o.mustRegister((*RawMatched)(nil), &Options{Table: "raw_matched"})
+	o.mustInitialize()
+	o.MustInsert(&RawMatched{Email: "Foo@Example.com", Score: 1})
+	o.MustInsert(&RawMatched{Email: "bar@example.com", Score: 2})
+	o.MustInsert(&RawMatched{Email: "baz@example.org", Score: 3})
+	cases := []struct {
+		q     query.Q
+		count uint64
+	}{
+		{Raw("lower(email) = ?", "foo@example.com"), 1},
+		{Raw("score > ? AND score < ?", 1, 3), 1},
+		{And(Eq("Score", 1), Raw("lower(email) LIKE ?", "%example.com")), 1},
+		{Or(Raw("score = ?", 3), Raw("lower(email) = ?", "bar@example.com")), 2},
+		{Raw("email != '?' AND score >= ?", 2), 2},
+		{Raw("score = ?", query.F("Score")), 3},
+	}
+	for _, v := range cases {
+		count, err := o.Query(v.q).Table(table).Count()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != v.count {
+			t.Errorf("expecting %d results for %v, got %d", v.count, v.q, count)
+		}
+	}
+	for _, v := range []query.Q{Raw("score = ?"), Raw("score = ?", 1, 2), Raw("email = 'foo")} {
+		if _, err := o.Query(v).Table(table).Count(); err == nil {
+			t.Errorf("expecting an error with %v", v)
+		}
+	}
+}
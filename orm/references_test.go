@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -226,3 +227,112 @@ func testReferences(t *testing.T, o *Orm) {
 		t.Error("expecting an error when violating FK")
 	}
 }
+
+type CascadeChild struct {
+	Id     int64 `orm:",primary_key,auto_increment"`
+	Parent int64 `orm:",references=CascadeParent,on_delete=cascade"`
+}
+
+type CascadeParent struct {
+	Id   int64 `orm:",primary_key,auto_increment"`
+	Name string
+}
+
+func TestReferenceAction(t *testing.T) {
+	cases := map[string]string{
+		"":            "",
+		"cascade":     "CASCADE",
+		"set_null":    "SET NULL",
+		"SET_DEFAULT": "SET DEFAULT",
+		"no_action":   "NO ACTION",
+		"restrict":    "RESTRICT",
+	}
+	for k, v := range cases {
+		action, err := referenceAction(k)
+		if err != nil {
+			t.Errorf("error parsing action %q: %s", k, err)
+			continue
+		}
+		if action != v {
+			t.Errorf("expecting action %q for %q, got %q", v, k, action)
+		}
+	}
+	if _, err := referenceAction("delete"); err == nil {
+		t.Error("expecting an error with an invalid action")
+	}
+}
+
+func testReferenceActions(t *testing.T, o *Orm) {
+	if o.SqlDB() == nil {
+		t.Skip("not a database/sql driver")
+	}
+	// Register the child first, so the tables need
+	// to be sorted before they're created.
+	child := o.mustRegister((*CascadeChild)(nil), &Options{Table: "cascade_child"})
+	o.mustRegister((*CascadeParent)(nil), &Options{Table: "cascade_parent"})
+	o.mustInitialize()
+	p := &CascadeParent{Name: "p"}
+	o.MustInsert(p)
+	o.MustInsert(&CascadeChild{Parent: p.Id})
+	o.MustInsert(&CascadeChild{Parent: p.Id})
+	if n := o.Table(child).MustCount(); n != 2 {
+		t.Fatalf("expecting 2 children, got %d", n)
+	}
+	o.MustDelete(p)
+	if n := o.Table(child).MustCount(); n != 0 {
+		t.Errorf("expecting 0 children after deleting the parent, got %d", n)
+	}
+}
+
+type OptionsAuthor struct {
+	Id   int64 `orm:",primary_key,auto_increment"`
+	Name string
+}
+
+type OptionsBook struct {
+	Id       int64 `orm:",primary_key,auto_increment"`
+	AuthorId int64
+	Title    string
+}
+
+func testOptionsReferences(t *testing.T, o *Orm) {
+	if o.Driver().Capabilities()&driver.CAP_JOIN == 0 {
+		t.Log("skipping options references test")
+		return
+	}
+	bad := &Options{
+		Table:      "test_options_references_bad",
+		References: map[string]string{"Missing": "OptionsAuthor"},
+	}
+	if _, err := o.Register((*OptionsBook)(nil), bad); err == nil {
+		t.Error("expecting an error when declaring a reference on a non-existing field")
+	}
+	o.mustRegister((*OptionsBook)(nil), &Options{
+		Table:      "test_options_references_book",
+		References: map[string]string{"AuthorId": "OptionsAuthor"},
+	})
+	o.mustRegister((*OptionsAuthor)(nil), &Options{
+		Table: "test_options_references_author",
+		Name:  "OptionsAuthor",
+	})
+	o.mustInitialize()
+	author := &OptionsAuthor{Name: "A"}
+	o.MustInsert(author)
+	for _, v := range []string{"B1", "B2"} {
+		o.MustInsert(&OptionsBook{AuthorId: author.Id, Title: v})
+	}
+	var a *OptionsAuthor
+	var book *OptionsBook
+	var count int
+	iter := o.Query(Eq("OptionsAuthor|Id", author.Id)).Sort("OptionsBook|Id", ASC).Iter()
+	for count = 0; iter.Next(&a, &book); count++ {
+		if a.Name != author.Name {
+			t.Errorf("expecting author %q, got %q", author.Name, a.Name)
+		}
+		if expect := fmt.Sprintf("B%d", count+1); book.Title != expect {
+			t.Errorf("expecting book %q, got %q", expect, book.Title)
+		}
+	}
+	testCount(t, count, 2, "author books")
+	testIterErr(t, iter)
+}
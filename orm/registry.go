@@ -93,7 +93,8 @@ func (o *Orm) Register(t interface{}, opts *Options) (*Table, error) {
 }
 
 func (o *Orm) registerLocked(t interface{}, opts *Options) (*Table, error) {
-	s, err := structs.NewStruct(t, o.dtags())
+	naming := o.NamingStrategy()
+	s, err := structs.NewStructNamer(t, o.dtags(), naming.ColumnName)
 	if err != nil {
 		switch err {
 		case structs.ErrNoStruct:
@@ -108,7 +109,7 @@ func (o *Orm) registerLocked(t interface{}, opts *Options) (*Table, error) {
 		table = opts.Table
 	}
 	if table == "" {
-		table = defaultTableName(s.Type)
+		table = naming.TableName(s.Type)
 	}
 	if globalRegistry.names[o.tags] == nil {
 		globalRegistry.names[o.tags] = nameRegistry{}
@@ -126,6 +127,14 @@ func (o *Orm) registerLocked(t interface{}, opts *Options) (*Table, error) {
 	if err != nil {
 		return nil, err
 	}
+	softDelete, err := softDeleteField(fields)
+	if err != nil {
+		return nil, err
+	}
+	hooks, err := modelHooks(s.Type)
+	if err != nil {
+		return nil, err
+	}
 	var name string
 	if opts != nil && opts.Name != "" {
 		name = opts.Name
@@ -147,6 +156,28 @@ func (o *Orm) registerLocked(t interface{}, opts *Options) (*Table, error) {
 				fields.CompositePrimaryKey[ii] = pos
 			}
 		}
+		for k, v := range opts.References {
+			pos, ok := fields.QNameMap[k]
+			if !ok {
+				return nil, fmt.Errorf("can't map qualified name %q on model %q when declaring reference", k, name)
+			}
+			if _, ok := references[k]; ok {
+				return nil, fmt.Errorf("duplicate reference for field %q in model %q, declared by both tags and Options", k, name)
+			}
+			ftag := fields.Tags[pos]
+			r, err := newReference(k, v, ftag)
+			if err != nil {
+				return nil, err
+			}
+			if references == nil {
+				references = make(map[string]*reference)
+			}
+			references[k] = r
+			// References default to nullempty, like the ones declared in tags
+			if !ftag.Has("notnullempty") {
+				fields.NullEmpty[pos] = true
+			}
+		}
 	}
 	model := &model{
 		fields:     fields,
@@ -156,6 +187,8 @@ func (o *Orm) registerLocked(t interface{}, opts *Options) (*Table, error) {
 		options:    opts,
 		table:      table,
 		tags:       o.tags,
+		softDelete: softDelete,
+		hooks:      hooks,
 	}
 	names[table] = model
 	types[s.Type] = model
@@ -186,6 +219,10 @@ func (o *Orm) initializePending() error {
 // AFTER all the models have been registered and BEFORE starting
 // to use the ORM for queries for each ORM type.
 func (o *Orm) Initialize() error {
+	return o.initialize(o.driver.Initialize)
+}
+
+func (o *Orm) initialize(f func([]driver.Model) error) error {
 	globalRegistry.Lock()
 	defer globalRegistry.Unlock()
 	signal.Emit(WILL_INITIALIZE, o)
@@ -232,8 +269,10 @@ func (o *Orm) Initialize() error {
 						r.field, referenced.name, fkt, k, v.name, ft)
 				}
 				v.fields.References[k] = &driver.Reference{
-					Model: referenced,
-					Field: r.field,
+					Model:    referenced,
+					Field:    r.field,
+					OnDelete: r.onDelete,
+					OnUpdate: r.onUpdate,
 				}
 				if v.modelReferences == nil {
 					v.modelReferences = make(map[*model][]*join)
@@ -266,10 +305,11 @@ func (o *Orm) Initialize() error {
 	for _, v := range nr {
 		models = append(models, v)
 	}
-	// Sort models to the ones with FKs are created after
-	// the models they reference
-	sort.Sort(sortModels(models))
-	return o.driver.Initialize(models)
+	// Sort models by name first, so the order is deterministic,
+	// and then so the ones with FKs are created after the models
+	// they reference.
+	sort.Sort(modelsByName(models))
+	return f(sortModels(models))
 }
 
 func (o *Orm) fields(table string, s *structs.Struct) (*driver.Fields, map[string]*reference, error) {
@@ -329,14 +369,27 @@ func (o *Orm) fields(table string, s *structs.Struct) (*driver.Fields, map[strin
 			fields.AutoincrementPk = fields.PrimaryKey == ii
 		}
 		if ref := ftag.Value("references"); ref != "" {
-			m := referencesRe.FindStringSubmatch(ref)
-			if len(m) != 4 {
-				return nil, nil, fmt.Errorf("field %q has invalid references %q. Must be in the form references=Model or references=Model(Field)", v, ref)
+			r, err := newReference(v, ref, ftag)
+			if err != nil {
+				return nil, nil, err
 			}
 			if references == nil {
 				references = make(map[string]*reference)
 			}
-			references[v] = &reference{model: m[1], field: m[3]}
+			references[v] = r
+		}
+		if enum := ftag.Value("enum"); enum != "" {
+			if t.Kind() != reflect.String {
+				return nil, nil, fmt.Errorf("enum field %q in struct %s must be of string type, not %s", v, s.Type, t)
+			}
+			values, err := enumValues(enum)
+			if err != nil {
+				return nil, nil, fmt.Errorf("field %q has invalid enum: %s", v, err)
+			}
+			if fields.Enums == nil {
+				fields.Enums = make(map[int][]string)
+			}
+			fields.Enums[ii] = values
 		}
 	}
 	if err := o.setFieldsDefaults(fields); err != nil {
@@ -467,6 +520,39 @@ func (o *Orm) TypeTable(typ reflect.Type) *Table {
 	return nil
 }
 
+// newReference parses a reference from the given field to another
+// model, in the form Model or Model(Field). Referential actions are
+// read from the on_delete and on_update options in the field tag.
+func newReference(field string, ref string, ftag *structs.Tag) (*reference, error) {
+	m := referencesRe.FindStringSubmatch(ref)
+	if len(m) != 4 {
+		return nil, fmt.Errorf("field %q has invalid references %q. Must be in the form references=Model or references=Model(Field)", field, ref)
+	}
+	onDelete, err := referenceAction(ftag.Value("on_delete"))
+	if err != nil {
+		return nil, fmt.Errorf("field %q has invalid on_delete: %s", field, err)
+	}
+	onUpdate, err := referenceAction(ftag.Value("on_update"))
+	if err != nil {
+		return nil, fmt.Errorf("field %q has invalid on_update: %s", field, err)
+	}
+	return &reference{model: m[1], field: m[3], onDelete: onDelete, onUpdate: onUpdate}, nil
+}
+
+// referenceAction returns the referential action for the given
+// on_delete or on_update tag value (e.g. set_null => SET NULL).
+func referenceAction(val string) (string, error) {
+	if val == "" {
+		return "", nil
+	}
+	action := strings.ToUpper(strings.Replace(val, "_", " ", -1))
+	switch action {
+	case "CASCADE", "RESTRICT", "SET NULL", "SET DEFAULT", "NO ACTION":
+		return action, nil
+	}
+	return "", fmt.Errorf("unknown action %q, must be one of cascade, restrict, set_null, set_default or no_action", val)
+}
+
 // returns wheter the kind defaults to nullempty option
 func defaultsToNullEmpty(typ reflect.Type, t *structs.Tag) bool {
 	if t.Has("references") || t.Has("codec") || (t.Has("notnull") && typ.Kind() != reflect.Bool) {
@@ -0,0 +1,55 @@
+package orm
+
+import (
+	"math/rand"
+	"time"
+)
+
+const (
+	// DefaultTransactionRetries is the default number of times
+	// a transaction is retried by Orm.Transaction after failing
+	// due to a transient conflict. See Orm.SetTransactionRetries.
+	DefaultTransactionRetries = 3
+
+	minRetryWait = 10 * time.Millisecond
+	maxRetryWait = time.Second
+)
+
+// RetryClassifier is implemented by drivers which can tell if an
+// error was caused by a transient conflict between transactions,
+// like a serialization failure or a deadlock (the sql driver
+// implements this interface).
+type RetryClassifier interface {
+	IsRetryable(err error) bool
+}
+
+// SetTransactionRetries sets the maximum number of times Orm.Transaction
+// runs a transaction again after it fails due to a transient conflict.
+// Zero disables retrying. The default is DefaultTransactionRetries.
+func (o *Orm) SetTransactionRetries(retries int) {
+	if retries < 0 {
+		retries = 0
+	}
+	o.txRetries = retries
+}
+
+// retryWait waits before retrying a failed transaction, using
+// exponential backoff with jitter. If the Orm context is done
+// while waiting, its error is returned.
+func (o *Orm) retryWait(attempt int) error {
+	wait := minRetryWait << uint(attempt)
+	if wait <= 0 || wait > maxRetryWait {
+		wait = maxRetryWait
+	}
+	// Randomize the wait, so the conflicting transactions
+	// don't run again at the same time.
+	wait = wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-o.Context().Done():
+		return o.Context().Err()
+	}
+}
@@ -0,0 +1,61 @@
+package orm
+
+import (
+	"errors"
+	"testing"
+
+	"gnd.la/orm/driver"
+)
+
+var errConflict = errors.New("conflict")
+
+type retryingDriver struct {
+	driver.Driver
+}
+
+func (d *retryingDriver) IsRetryable(err error) bool {
+	return err == errConflict
+}
+
+func testTransactionRetry(t *testing.T, o *Orm) {
+	if o.driver.Capabilities()&driver.CAP_TRANSACTION == 0 {
+		t.Skip("driver does not support transactions")
+	}
+	ro := *o
+	ro.driver = &retryingDriver{o.driver}
+	attempts := 0
+	err := ro.Transaction(func(o *Orm) error {
+		attempts++
+		if attempts < 3 {
+			return errConflict
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Errorf("expecting 3 attempts, got %d", attempts)
+	}
+	attempts = 0
+	ro.SetTransactionRetries(1)
+	err = ro.Transaction(func(o *Orm) error {
+		attempts++
+		return errConflict
+	})
+	if err != errConflict {
+		t.Errorf("expecting errConflict, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("expecting 2 attempts, got %d", attempts)
+	}
+	// Non retryable errors are returned immediately
+	attempts = 0
+	myErr := errors.New("my error")
+	if err := ro.Transaction(func(o *Orm) error {
+		attempts++
+		return myErr
+	}); err != myErr || attempts != 1 {
+		t.Errorf("expecting my error after 1 attempt, got %v after %d", err, attempts)
+	}
+}
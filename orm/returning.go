@@ -0,0 +1,78 @@
+package orm
+
+import (
+	"time"
+
+	"gnd.la/app/profile"
+	"gnd.la/orm/driver"
+	"gnd.la/orm/operation"
+	"gnd.la/orm/query"
+)
+
+// Returner is implemented by drivers which can return the primary
+// keys of the rows affected by an update or a delete without
+// performing another query (the sql driver implements this interface,
+// but only the postgres and cockroach backends support it). Returning
+// must return true iff the current backend supports these operations.
+type Returner interface {
+	Returning() bool
+	UpdateReturning(m driver.Model, q query.Q, data interface{}) ([]interface{}, error)
+	OperateReturning(m driver.Model, q query.Q, ops []*operation.Operation) ([]interface{}, error)
+	DeleteReturning(m driver.Model, q query.Q) ([]interface{}, error)
+}
+
+// UpdateReturning works like Update, but returns the primary keys of
+// the updated rows, using a RETURNING clause, which is useful for
+// invalidating caches or logging the affected objects. Each key has
+// the type of the primary key field or, for models with a composite
+// primary key, it's an []interface{} with the values of its fields. If
+// the driver does not implement Returner or its backend can't return
+// the keys, ErrNoReturning is returned.
+func (o *Orm) UpdateReturning(q query.Q, obj interface{}) ([]interface{}, error) {
+	r, err := o.returner()
+	if err != nil {
+		return nil, err
+	}
+	m, err := o.model(obj)
+	if err != nil {
+		return nil, err
+	}
+	var keys []interface{}
+	err = o.saving(m, obj, func() error {
+		return o.updating(m, obj, func() (err error) {
+			keys, err = r.UpdateReturning(m, q, obj)
+			return err
+		})
+	})
+	return keys, err
+}
+
+// DeleteFromReturning works like DeleteFrom, but returns the primary
+// keys of the deleted rows (or, with soft deletes, the rows marked as
+// deleted). See UpdateReturning for the format of the keys and the
+// supported drivers.
+func (o *Orm) DeleteFromReturning(t *Table, q query.Q) ([]interface{}, error) {
+	r, err := o.returner()
+	if err != nil {
+		return nil, err
+	}
+	m := t.model.model
+	if m.softDelete != "" {
+		if profile.On && profile.Profiling() {
+			defer profile.Start(orm).Note("soft delete", m.name).End()
+		}
+		q, ops := softDeleteOperation(m, q, time.Now())
+		return r.OperateReturning(m, q, ops)
+	}
+	if profile.On && profile.Profiling() {
+		defer profile.Start(orm).Note("delete", m.name).End()
+	}
+	return r.DeleteReturning(m, q)
+}
+
+func (o *Orm) returner() (Returner, error) {
+	if r, ok := o.conn.(Returner); ok && r.Returning() {
+		return r, nil
+	}
+	return nil, ErrNoReturning
+}
@@ -0,0 +1,63 @@
+package orm
+
+import (
+	"sort"
+	"testing"
+)
+
+type Returned struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Value int
+}
+
+func testReturning(t *testing.T, o *Orm) {
+	table := o.mustRegister((*Returned)(nil), &Options{Table: "returned"})
+	o.mustInitialize()
+	var ids []int64
+	for ii := 0; ii < 5; ii++ {
+		obj := &Returned{Value: ii}
+		o.MustInsert(obj)
+		ids = append(ids, obj.Id)
+	}
+	keys, err := o.UpdateReturning(Gt("Value", 2), &Returned{Value: 10})
+	if err == ErrNoReturning {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !returnedIds(keys, ids[3:]) {
+		t.Errorf("expecting updated ids %v, got %v", ids[3:], keys)
+	}
+	keys, err = o.DeleteFromReturning(table, Lt("Value", 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !returnedIds(keys, ids[:2]) {
+		t.Errorf("expecting deleted ids %v, got %v", ids[:2], keys)
+	}
+	if c := o.Query(nil).Table(table).MustCount(); c != 3 {
+		t.Errorf("expecting 3 remaining objects, got %d", c)
+	}
+}
+
+func returnedIds(keys []interface{}, ids []int64) bool {
+	if len(keys) != len(ids) {
+		return false
+	}
+	got := make([]int64, len(keys))
+	for ii, v := range keys {
+		id, ok := v.(int64)
+		if !ok {
+			return false
+		}
+		got[ii] = id
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+	for ii, v := range ids {
+		if got[ii] != v {
+			return false
+		}
+	}
+	return true
+}
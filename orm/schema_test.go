@@ -0,0 +1,95 @@
+package orm
+
+import (
+	"strings"
+	"testing"
+
+	"gnd.la/orm/driver/sql"
+)
+
+type SchemaParent struct {
+	Id   int64  `orm:",primary_key,auto_increment"`
+	Name string `orm:",notnull,index,unique"`
+}
+
+type SchemaChild struct {
+	Id     int64 `orm:",primary_key,auto_increment"`
+	Parent int64 `orm:",references=SchemaParent"`
+	Value  string
+}
+
+func testSchema(t *testing.T, o *Orm) {
+	if o.SqlDB() == nil {
+		t.Skip("not a database/sql driver")
+	}
+	parent := o.mustRegister((*SchemaParent)(nil), &Options{Table: "schema_parent"})
+	child := o.mustRegister((*SchemaChild)(nil), &Options{Table: "schema_child"})
+	o.mustInitialize()
+	schema, err := o.Schema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt := schema.Table(parent.model.Table())
+	if pt == nil {
+		t.Fatalf("table %s not found in schema", parent.model.Table())
+	}
+	if pks := pt.PrimaryKeys(); len(pks) != 1 || pks[0] != "id" {
+		t.Errorf("expecting primary key [id], got %v", pks)
+	}
+	var name *sql.Field
+	for _, v := range pt.Fields {
+		if v.Name == "name" {
+			name = v
+		}
+	}
+	if name == nil {
+		t.Fatal("field name not found")
+	}
+	if name.Constraint(sql.ConstraintNotNull) == nil {
+		t.Error("expecting field name to be NOT NULL")
+	}
+	found := false
+	for _, v := range pt.Indexes {
+		if len(v.Fields) == 1 && v.Fields[0] == "name" && v.Unique {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("unique index on name not found in %+v", pt.Indexes)
+	}
+	ct := schema.Table(child.model.Table())
+	if ct == nil {
+		t.Fatalf("table %s not found in schema", child.model.Table())
+	}
+	for _, v := range ct.Fields {
+		if v.Name == "parent" {
+			fk := v.Constraint(sql.ConstraintForeignKey)
+			if fk == nil || fk.References.Table() != parent.model.Table() {
+				t.Errorf("expecting field parent to reference %s", parent.model.Table())
+			}
+		}
+	}
+	if schema.Table("does_not_exist") != nil {
+		t.Error("expecting nil for a non-existing table")
+	}
+	pm := schema.Model(parent.model.Table())
+	if pm == nil {
+		t.Fatalf("model table %s not found in schema", parent.model.Table())
+	}
+	if len(pm.Fields) != len(pt.Fields) {
+		t.Errorf("expecting %d fields in model table, got %d", len(pt.Fields), len(pm.Fields))
+	}
+	if len(pm.Indexes) != 1 || !pm.Indexes[0].Unique || len(pm.Indexes[0].Fields) != 1 || pm.Indexes[0].Fields[0] != "name" {
+		t.Errorf("expecting unique index on name in model table, got %+v", pm.Indexes)
+	}
+	ddl, err := schema.DDL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("DDL:\n%s", ddl)
+	for _, v := range []string{"CREATE TABLE", parent.model.Table(), child.model.Table(), "CREATE UNIQUE INDEX"} {
+		if !strings.Contains(ddl, v) {
+			t.Errorf("expecting DDL to contain %q", v)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package orm
+
+import (
+	"gnd.la/orm/query"
+	"gnd.la/util/stringutil"
+)
+
+// UniqueSlug returns a slug for s which is not used in the given field
+// by any object in the table t matching q. The query q might be nil or
+// e.g. exclude the object being saved, so it doesn't conflict with its
+// own slug. See gnd.la/util/stringutil.UniqueSlug for how the slug is
+// generated.
+func UniqueSlug(o Interface, t *Table, field string, s string, opts *stringutil.SlugOptions, q query.Q) (string, error) {
+	return stringutil.UniqueSlug(s, opts, func(slug string) (bool, error) {
+		var cond query.Q = Eq(field, slug)
+		if q != nil {
+			cond = And(cond, q)
+		}
+		return o.Exists(t, cond)
+	})
+}
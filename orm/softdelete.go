@@ -0,0 +1,160 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"gnd.la/app/profile"
+	"gnd.la/orm/driver"
+	"gnd.la/orm/operation"
+	"gnd.la/orm/query"
+)
+
+// softDeleteField returns the qualified name of the field tagged
+// with softdelete in the given fields, or an empty string if there's
+// none. Soft delete fields must be of type time.Time or *time.Time
+// and stored as NULL when they're empty.
+//
+//	type Post struct {
+//		Id        int64     `orm:",primary_key,auto_increment"`
+//		Title     string
+//		DeletedAt time.Time `orm:",softdelete"`
+//	}
+func softDeleteField(f *driver.Fields) (string, error) {
+	var name string
+	for ii, v := range f.Tags {
+		if !v.Has("softdelete") {
+			continue
+		}
+		qname := f.QNames[ii]
+		if name != "" {
+			return "", fmt.Errorf("duplicate softdelete field in struct %s (%s and %s)", f.Type, name, qname)
+		}
+		typ := f.Type.FieldByIndex(f.Indexes[ii]).Type
+		if typ != timeType && (typ.Kind() != reflect.Ptr || typ.Elem() != timeType) {
+			return "", fmt.Errorf("softdelete field %q in struct %s must be of type time.Time or *time.Time, not %s", qname, f.Type, typ)
+		}
+		if !f.NullEmpty[ii] || v.Has("notnull") {
+			return "", fmt.Errorf("softdelete field %q in struct %s must be nullable", qname, f.Type)
+		}
+		name = qname
+	}
+	return name, nil
+}
+
+// Unscoped makes the query also return the objects which have been
+// soft deleted. By default, queries involving models with a field
+// tagged with softdelete only return the objects which haven't been
+// deleted.
+func (q *Query) Unscoped() *Query {
+	q.unscoped = true
+	return q
+}
+
+// condition returns the query condition, including the
+// conditions for excluding soft deleted objects.
+func (q *Query) condition() query.Q {
+	if q.unscoped || q.model == nil {
+		return q.q
+	}
+	var conditions []query.Q
+	for m := q.model; m != nil; m = m.nextModel() {
+		if m.softDelete != "" {
+			// Use the model name, so fields in joined models
+			// are not ambiguous. Note that for outer joins, this
+			// excludes the rows with a soft deleted object rather
+			// than treating them as rows with no joined object.
+			conditions = append(conditions, Eq(m.name+"|"+m.softDelete, nil))
+		}
+	}
+	if len(conditions) == 0 {
+		return q.q
+	}
+	if q.q != nil {
+		conditions = append([]query.Q{q.q}, conditions...)
+	}
+	if len(conditions) == 1 {
+		return conditions[0]
+	}
+	return And(conditions...)
+}
+
+// HardDelete works like Delete, but it always removes the
+// object from the database, even if its model uses soft deletes.
+func (o *Orm) HardDelete(obj interface{}) error {
+	m, err := o.model(obj)
+	if err != nil {
+		return err
+	}
+	q, err := o.pkQuery(m, obj)
+	if err != nil {
+		return err
+	}
+	if err := o.runHook(m, hookBeforeDelete, obj); err != nil {
+		return err
+	}
+	if _, err := o.delete(m, q); err != nil {
+		return err
+	}
+	return o.runHook(m, hookAfterDelete, obj)
+}
+
+// MustHardDelete works like HardDelete, but panics if there's an error.
+func (o *Orm) MustHardDelete(obj interface{}) {
+	if err := o.HardDelete(obj); err != nil {
+		panic(err)
+	}
+}
+
+// HardDeleteFrom works like DeleteFrom, but it always removes
+// the objects from the database, even if the model uses soft deletes.
+func (o *Orm) HardDeleteFrom(t *Table, q query.Q) (Result, error) {
+	return o.delete(t.model.model, q)
+}
+
+// softDelete marks the objects matching q which haven't been
+// deleted yet as deleted at the current time.
+func (o *Orm) softDelete(m *model, q query.Q) (Result, error) {
+	return o.softDeleteAt(m, q, time.Now())
+}
+
+func (o *Orm) softDeleteAt(m *model, q query.Q, t time.Time) (Result, error) {
+	if profile.On && profile.Profiling() {
+		defer profile.Start(orm).Note("soft delete", m.name).End()
+	}
+	q, ops := softDeleteOperation(m, q, t)
+	return o.conn.Operate(m, q, ops)
+}
+
+// softDeleteOperation returns the query and the operations for
+// marking the objects matched by q which haven't been deleted
+// yet as deleted at t.
+func softDeleteOperation(m *model, q query.Q, t time.Time) (query.Q, []*operation.Operation) {
+	notDeleted := Eq(m.softDelete, nil)
+	if q != nil {
+		q = And(q, notDeleted)
+	} else {
+		q = notDeleted
+	}
+	return q, []*operation.Operation{operation.Set(m.softDelete, t)}
+}
+
+// softDeleteObject marks the object matched by q as deleted and
+// sets its soft delete field accordingly.
+func (o *Orm) softDeleteObject(m *model, q query.Q, obj interface{}) error {
+	now := time.Now()
+	if _, err := o.softDeleteAt(m, q, now); err != nil {
+		return err
+	}
+	idx := m.fields.QNameMap[m.softDelete]
+	val := driver.Direct(reflect.ValueOf(obj))
+	if field := o.fieldByIndexCreating(val, m.fields.Indexes[idx]); field.CanSet() {
+		if field.Kind() == reflect.Ptr {
+			field.Set(reflect.ValueOf(&now))
+		} else {
+			field.Set(reflect.ValueOf(now))
+		}
+	}
+	return nil
+}
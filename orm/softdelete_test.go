@@ -0,0 +1,63 @@
+package orm
+
+import (
+	"testing"
+	"time"
+)
+
+type SoftDeletePost struct {
+	Id        int64 `orm:",primary_key,auto_increment"`
+	Title     string
+	DeletedAt time.Time `orm:",softdelete"`
+}
+
+type InvalidSoftDelete struct {
+	Id        int64 `orm:",primary_key,auto_increment"`
+	DeletedAt int64 `orm:",softdelete"`
+}
+
+func testSoftDelete(t *testing.T, o *Orm) {
+	if _, err := o.Register((*InvalidSoftDelete)(nil), nil); err == nil {
+		t.Error("expecting an error when registering a non-time softdelete field")
+	}
+	table := o.mustRegister((*SoftDeletePost)(nil), &Options{
+		Table: "test_soft_delete_post",
+	})
+	o.mustInitialize()
+	p1 := &SoftDeletePost{Title: "first"}
+	p2 := &SoftDeletePost{Title: "second"}
+	p3 := &SoftDeletePost{Title: "third"}
+	o.MustInsert(p1)
+	o.MustInsert(p2)
+	o.MustInsert(p3)
+	o.MustDelete(p1)
+	if p1.DeletedAt.IsZero() {
+		t.Error("Delete() did not set DeletedAt")
+	}
+	if c := o.Query(nil).Table(table).MustCount(); c != 2 {
+		t.Errorf("expecting 2 posts, got %d", c)
+	}
+	if c := o.Query(nil).Table(table).Unscoped().MustCount(); c != 3 {
+		t.Errorf("expecting 3 posts including deleted ones, got %d", c)
+	}
+	var p *SoftDeletePost
+	if o.Query(Eq("Id", p1.Id)).Table(table).MustOne(&p) {
+		t.Error("soft deleted post was returned")
+	}
+	if !o.Query(Eq("Id", p1.Id)).Table(table).Unscoped().MustOne(&p) || p.DeletedAt.IsZero() {
+		t.Errorf("expecting soft deleted post with Unscoped(), got %+v", p)
+	}
+	if ok, _ := o.Query(Eq("Title", "first")).Table(table).Exists(); ok {
+		t.Error("soft deleted post exists")
+	}
+	if _, err := o.DeleteFrom(table, Eq("Title", "second")); err != nil {
+		t.Fatal(err)
+	}
+	if c := o.Query(nil).Table(table).MustCount(); c != 1 {
+		t.Errorf("expecting 1 post, got %d", c)
+	}
+	o.MustHardDelete(p3)
+	if c := o.Query(nil).Table(table).Unscoped().MustCount(); c != 2 {
+		t.Errorf("expecting 2 posts after HardDelete, got %d", c)
+	}
+}
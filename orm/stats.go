@@ -0,0 +1,29 @@
+package orm
+
+import (
+	"gnd.la/orm/driver"
+)
+
+// Stats contains the connection pool statistics and the query
+// timings collected by the driver. See gnd.la/orm/driver.Stats
+// for the available fields.
+type Stats driver.Stats
+
+// StatsReporter is implemented by drivers which can report
+// connection pool statistics and query timings (the sql
+// driver implements this interface).
+type StatsReporter interface {
+	Stats() *driver.Stats
+}
+
+// Stats returns the connection pool statistics and the timings
+// for the queries executed so far. The returned value is a copy
+// and it's safe to modify it. If the driver does not implement
+// StatsReporter, ErrNoStats is returned.
+func (o *Orm) Stats() (*Stats, error) {
+	sr, ok := o.driver.(StatsReporter)
+	if !ok {
+		return nil, ErrNoStats
+	}
+	return (*Stats)(sr.Stats()), nil
+}
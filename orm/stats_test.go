@@ -0,0 +1,41 @@
+package orm
+
+import (
+	"strings"
+	"testing"
+)
+
+type Measured struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Value string
+}
+
+func testStats(t *testing.T, o *Orm) {
+	if _, err := o.Stats(); err != nil {
+		t.Skip(err)
+	}
+	table := o.mustRegister((*Measured)(nil), &Options{Table: "test_measured"})
+	o.mustInitialize()
+	o.MustInsert(&Measured{Value: "foo"})
+	var objs []*Measured
+	o.Table(table).MustAll(&objs)
+	o.WithComment(map[string]string{"route": "stats"}).Table(table).MustAll(&objs)
+	stats, err := o.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.OpenConnections < stats.InUse+stats.Idle {
+		t.Errorf("inconsistent connection counts %+v", stats)
+	}
+	if len(stats.Queries) == 0 {
+		t.Fatal("no query stats recorded")
+	}
+	for k, v := range stats.Queries {
+		if strings.HasPrefix(k, "/*") {
+			t.Errorf("query %q recorded with its comment", k)
+		}
+		if v.Count <= 0 || v.Max > v.Total || v.Average() > v.Max {
+			t.Errorf("invalid stats for query %q: %+v", k, v)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package orm
+
+// Stream makes the query load its results in batches of at most size
+// objects, rather than requesting all of them at once. When Iter.Next
+// exhausts a batch, the following one is requested using keyset
+// pagination (see Query.After), so only one batch is held by the
+// driver at any time. This allows iterating over very large result
+// sets (e.g. when exporting a whole table) with bounded memory, and
+// without the performance degradation of using increasing offsets.
+//
+//	iter := o.Query(orm.Eq("Active", true)).Sort("Created", orm.ASC).Stream(1000).Iter()
+//	for iter.Next(&user) {
+//		...
+//	}
+//	if err := iter.Err(); err != nil {
+//		...
+//	}
+//
+// Since each batch is a separate query, objects inserted, modified or
+// deleted while iterating might be (or not be) returned, like in
+// Query.Batches. Streaming has the same requirements as Query.After,
+// whose cursor (if any) is used for the first batch, as well as the
+// query offset. The query limit, if any, is respected. A size <= 0
+// disables streaming. Note that Stream only affects One, All and Iter.
+func (q *Query) Stream(size int) *Query {
+	if size > 0 {
+		q.keyset = true
+		q.stream = size
+	} else {
+		q.stream = 0
+	}
+	return q
+}
+
+// batchLimit returns the limit for the next query executed by
+// the Iter, taking into account its batch size.
+func (i *Iter) batchLimit() int {
+	size := i.q.stream
+	if size <= 0 {
+		return i.limit
+	}
+	if i.limit >= 0 {
+		if rem := i.limit - i.total; rem < size {
+			size = rem
+		}
+	}
+	return size
+}
+
+// nextBatch closes the current batch and requests the next
+// one, returning the result from calling Next on it.
+func (i *Iter) nextBatch(out []interface{}) bool {
+	if i.err = i.Iter.Err(); i.err != nil {
+		return false
+	}
+	i.Iter.Close()
+	i.Iter = nil
+	cursor := i.next
+	i.next = ""
+	i.fetched = 0
+	limit := i.batchLimit()
+	if limit == 0 {
+		return false
+	}
+	if i.Iter, i.err = i.q.exec(limit, -1, cursor); i.err != nil {
+		return false
+	}
+	return i.Iter.Next(out...)
+}
@@ -0,0 +1,38 @@
+package orm
+
+import (
+	"testing"
+)
+
+type Streamed struct {
+	Id    int64 `orm:",primary_key,auto_increment"`
+	Value int
+}
+
+func testStream(t *testing.T, o *Orm) {
+	table := o.mustRegister((*Streamed)(nil), &Options{Table: "streamed"})
+	o.mustInitialize()
+	for ii := 0; ii < 23; ii++ {
+		o.MustInsert(&Streamed{Value: ii % 5})
+	}
+	var expected []*Streamed
+	o.Query(Neq("Value", 0)).Table(table).Sort("Value", ASC).Sort("Id", ASC).MustAll(&expected)
+	for _, size := range []int{1, 3, 5, 100} {
+		var got []*Streamed
+		o.Query(Neq("Value", 0)).Table(table).Sort("Value", ASC).Stream(size).MustAll(&got)
+		if len(got) != len(expected) {
+			t.Fatalf("batch size %d: expecting %d results, got %d", size, len(expected), len(got))
+		}
+		for ii, v := range got {
+			if v.Id != expected[ii].Id {
+				t.Errorf("batch size %d: expecting id %d at %d, got %d", size, expected[ii].Id, ii, v.Id)
+			}
+		}
+	}
+	// Limit must be respected across batches
+	var limited []*Streamed
+	o.Query(nil).Table(table).Stream(4).Limit(10).MustAll(&limited)
+	if len(limited) != 10 {
+		t.Errorf("expecting 10 results with limit, got %d", len(limited))
+	}
+}
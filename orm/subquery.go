@@ -0,0 +1,52 @@
+package orm
+
+import (
+	"errors"
+	"fmt"
+
+	"gnd.la/orm/driver"
+)
+
+// Subselect implements the driver.Selector interface, which allows using a
+// *Query as the value of In and NotIn. When the query has exactly one field
+// selected with Fields, that field is selected. Otherwise, the primary key
+// of its model is used. e.g.
+//
+//	admins := o.Query(orm.Eq("Admin", true)).Table(usersTable)
+//	q := orm.In("AuthorId", admins)
+//
+// Selects the objects with an AuthorId matching the primary key of any admin
+// user. Drivers generate a subquery, so the results of the inner query are never
+// loaded. Queries with joins can't be used as subqueries.
+func (q *Query) Subselect() (*driver.Subselect, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	if q.model == nil {
+		return nil, errors.New("no table selected for subquery, set one with Table()")
+	}
+	if q.model.join != nil {
+		return nil, errors.New("queries with joins can't be used as subqueries")
+	}
+	var field string
+	switch len(q.fields) {
+	case 0:
+		fields := q.model.fields
+		if fields.PrimaryKey < 0 {
+			return nil, fmt.Errorf("model %s has no primary key, select a field for the subquery with Fields()", q.model.name)
+		}
+		field = fields.QNames[fields.PrimaryKey]
+	case 1:
+		field = q.fields[0]
+	default:
+		return nil, fmt.Errorf("subqueries must select exactly one field, not %d", len(q.fields))
+	}
+	return &driver.Subselect{
+		Model:  q.model,
+		Field:  field,
+		Q:      q.condition(),
+		Sort:   q.sort,
+		Limit:  q.limit,
+		Offset: q.offset,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package orm
+
+import (
+	"testing"
+)
+
+type SubqueryTeam struct {
+	Id     int64 `orm:",primary_key,auto_increment"`
+	Name   string
+	Active bool
+}
+
+type SubqueryMember struct {
+	Id     int64 `orm:",primary_key,auto_increment"`
+	TeamId int64
+	Name   string
+}
+
+func testSubquery(t *testing.T, o *Orm) {
+	teams := o.mustRegister((*SubqueryTeam)(nil), &Options{Table: "subquery_team"})
+	members := o.mustRegister((*SubqueryMember)(nil), &Options{Table: "subquery_member"})
+	o.mustInitialize()
+	a := &SubqueryTeam{Name: "a", Active: true}
+	b := &SubqueryTeam{Name: "b", Active: false}
+	c := &SubqueryTeam{Name: "c", Active: true}
+	for _, v := range []*SubqueryTeam{a, b, c} {
+		o.MustInsert(v)
+	}
+	for ii, v := range []*SubqueryTeam{a, a, b, c, c, c} {
+		o.MustInsert(&SubqueryMember{TeamId: v.Id, Name: string(rune('a' + ii))})
+	}
+	active := o.Query(Eq("Active", true)).Table(teams)
+	count, err := o.Query(In("TeamId", active)).Table(members).Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 5 {
+		t.Errorf("expecting 5 members in active teams, got %d", count)
+	}
+	count, err = o.Query(NotIn("TeamId", active)).Table(members).Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expecting 1 member in inactive teams, got %d", count)
+	}
+	// Select a field other than the primary key
+	withMembers := o.Query(Neq("Name", "")).Table(members).Fields("TeamId")
+	count, err = o.Query(And(In("Id", withMembers), Eq("Active", false))).Table(teams).Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expecting 1 inactive team with members, got %d", count)
+	}
+	two := o.Query(Eq("Active", true)).Table(teams).Fields("Id", "Name")
+	if _, err := o.Query(In("TeamId", two)).Table(members).Count(); err == nil {
+		t.Error("expecting an error when the subquery selects more than one field")
+	}
+}
@@ -1,6 +1,10 @@
 package orm
 
 import (
+	"fmt"
+	"reflect"
+
+	"gnd.la/orm/driver"
 	"gnd.la/orm/query"
 )
 
@@ -8,6 +12,59 @@ type Table struct {
 	model *joinModel
 }
 
+// Name returns the name of the model for this table. See
+// Orm.NameTable for the rules used to assign model names.
+func (t *Table) Name() string {
+	return t.model.name
+}
+
+// Type returns the type of the model for this table.
+func (t *Table) Type() reflect.Type {
+	return t.model.Type()
+}
+
+// Fields returns the fields of the model for this table.
+func (t *Table) Fields() *driver.Fields {
+	return t.model.model.Fields()
+}
+
+// Key returns a query which matches the row with the given primary
+// key. For models with a composite primary key, the values must be
+// provided in the same order their fields were specified in
+// Options.PrimaryKey. e.g.
+//
+//	table := o.MustRegister((*Membership)(nil), &orm.Options{
+//		PrimaryKey: []string{"UserId", "GroupId"},
+//	})
+//	...
+//	o.Query(table.MustKey(userId, groupId)).Table(table).One(&membership)
+//
+// See also Orm.OneByKey. Key returns an error if the table is a join,
+// its model has no primary key or the number of values does not match
+// the number of fields in the key.
+func (t *Table) Key(values ...interface{}) (query.Q, error) {
+	if t.model.join != nil {
+		return nil, fmt.Errorf("can't query joined table %s by key", t.Name())
+	}
+	names := primaryKeyNames(t.Fields())
+	if len(names) == 0 {
+		return nil, fmt.Errorf("model %s does not have a primary key", t.Name())
+	}
+	if len(values) != len(names) {
+		return nil, fmt.Errorf("primary key of model %s has %d fields (%v), %d values provided", t.Name(), len(names), names, len(values))
+	}
+	return keyQuery(names, values), nil
+}
+
+// MustKey works like Key, but panics if there's an error.
+func (t *Table) MustKey(values ...interface{}) query.Q {
+	q, err := t.Key(values...)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
 func (t *Table) Join(table *Table, q query.Q, jt JoinType) (*Table, error) {
 	join := t.model.clone()
 	if _, err := join.joinWith(table.model.model, q, jt); err != nil {
@@ -40,3 +97,29 @@ func (t *Table) Skip() *Table {
 func tableWithModel(m *model) *Table {
 	return &Table{model: &joinModel{model: m}}
 }
+
+// primaryKeyNames returns the qualified names of the fields
+// forming the primary key, either simple or composite.
+func primaryKeyNames(f *driver.Fields) []string {
+	if f.PrimaryKey >= 0 {
+		return []string{f.QNames[f.PrimaryKey]}
+	}
+	names := make([]string, len(f.CompositePrimaryKey))
+	for ii, v := range f.CompositePrimaryKey {
+		names[ii] = f.QNames[v]
+	}
+	return names
+}
+
+// keyQuery returns a query which compares each field in names
+// with its value.
+func keyQuery(names []string, values []interface{}) query.Q {
+	if len(names) == 1 {
+		return Eq(names[0], values[0])
+	}
+	conditions := make([]query.Q, len(names))
+	for ii, v := range names {
+		conditions[ii] = Eq(v, values[ii])
+	}
+	return And(conditions...)
+}
@@ -0,0 +1,50 @@
+package orm
+
+import (
+	"time"
+
+	"gnd.la/orm/driver"
+)
+
+// TimeOptions specify how time.Time values are stored and loaded.
+// See gnd.la/orm/driver.TimeOptions for the available fields.
+type TimeOptions driver.TimeOptions
+
+// TimeOptionsSetter is implemented by drivers which allow
+// specifying how time.Time values are stored and loaded
+// (the sql driver implements this interface).
+type TimeOptionsSetter interface {
+	SetTimeOptions(*driver.TimeOptions)
+}
+
+// SetTimeOptions sets the policy for storing and loading time.Time
+// values. By default, drivers store times in UTC, but the location of
+// the loaded values depends on the driver. Setting TimeOptions makes
+// all values be normalized to UTC (or kept naive, see TimeOptions.Naive)
+// when they're stored or used as query parameters, and converted to
+// TimeOptions.Location when they're loaded. If the driver does not
+// implement TimeOptionsSetter, ErrNoTimeOptions is returned.
+//
+// Individual time.Time fields might override these options using the
+// utc, location and truncate tag options (e.g. `orm:",utc,truncate=second"`),
+// see gnd.la/orm/driver.FieldTimeOptions for their description. Additionally,
+// the timezone tag option makes the postgres and mysql backends store the
+// field in a TIMESTAMP WITH TIME ZONE or TIMESTAMP column, respectively.
+// Keep in mind that MySQL TIMESTAMP columns only hold values between 1970
+// and 2038 and, unless the explicit_defaults_for_timestamp server option
+// is enabled, the first one in each table is automatically set to the
+// current time whenever a row is updated without assigning it.
+func (o *Orm) SetTimeOptions(opts *TimeOptions) error {
+	ts, ok := o.driver.(TimeOptionsSetter)
+	if !ok {
+		return ErrNoTimeOptions
+	}
+	ts.SetTimeOptions((*driver.TimeOptions)(opts))
+	return nil
+}
+
+// SetLocation is a shorthand for setting TimeOptions which store
+// times in UTC and load them in the given location.
+func (o *Orm) SetLocation(loc *time.Location) error {
+	return o.SetTimeOptions(&TimeOptions{Location: loc})
+}
@@ -0,0 +1,29 @@
+package orm
+
+import (
+	"testing"
+	"time"
+)
+
+type TruncatedTime struct {
+	Id   int64     `orm:",primary_key,auto_increment"`
+	Time time.Time `orm:",truncate=second"`
+}
+
+func testFieldTime(t *testing.T, o *Orm) {
+	table := o.mustRegister((*TruncatedTime)(nil), &Options{Table: "test_truncated_time"})
+	o.mustInitialize()
+	now := time.Date(2014, 7, 1, 12, 0, 0, 500000000, time.UTC)
+	o.MustSave(&TruncatedTime{Time: now})
+	// Query parameters must be truncated like the stored value
+	var obj *TruncatedTime
+	if ok := o.Query(Eq("Time", now)).Table(table).MustOne(&obj); !ok {
+		t.Fatalf("expecting an object with time %v", now)
+	}
+	if !obj.Time.Equal(now.Truncate(time.Second)) {
+		t.Errorf("expecting truncated time %v, got %v", now.Truncate(time.Second), obj.Time)
+	}
+	if n := o.Query(In("Time", []time.Time{now})).Table(table).MustCount(); n != 1 {
+		t.Errorf("expecting 1 object with IN, got %d", n)
+	}
+}
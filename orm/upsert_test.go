@@ -0,0 +1,80 @@
+package orm
+
+import (
+	"reflect"
+	"testing"
+
+	"gnd.la/orm/driver"
+)
+
+type Upserted struct {
+	Id    int64  `orm:",primary_key,auto_increment"`
+	Name  string `orm:",unique,notnull"`
+	Value int
+}
+
+type UpsertedCode struct {
+	Code  string `orm:",primary_key,max_length=32"`
+	Value int
+}
+
+func testUpsert(t *testing.T, o *Orm) {
+	table := o.mustRegister((*Upserted)(nil), &Options{Table: "test_upserted"})
+	codes := o.mustRegister((*UpsertedCode)(nil), &Options{Table: "test_upserted_code"})
+	hooked := o.mustRegister((*HookedObject)(nil), &Options{Table: "test_upserted_hooks"})
+	o.mustInitialize()
+	foo := &Upserted{Name: "foo", Value: 1}
+	o.MustUpsert(Eq("Name", "foo"), foo)
+	if foo.Id == 0 {
+		t.Error("expecting the primary key to be set after upserting a new object")
+	}
+	o.MustUpsert(Eq("Name", "bar"), &Upserted{Name: "bar", Value: 2})
+	o.MustUpsert(Eq("Name", "foo"), &Upserted{Name: "foo", Value: 3})
+	if n := o.Table(table).MustCount(); n != 2 {
+		t.Fatalf("expecting 2 objects after upserting, got %d", n)
+	}
+	var obj *Upserted
+	if ok := o.Query(Eq("Name", "foo")).Table(table).MustOne(&obj); !ok || obj.Value != 3 || obj.Id != foo.Id {
+		t.Errorf("expecting foo with id %d and value 3, got %+v", foo.Id, obj)
+	}
+	// Conditions other than Eq use update + insert
+	o.MustUpsert(And(Eq("Name", "bar"), Gt("Value", 1)), &Upserted{Name: "bar", Value: 4})
+	if ok := o.Query(Eq("Name", "bar")).Table(table).MustOne(&obj); !ok || obj.Value != 4 {
+		t.Errorf("expecting bar with value 4, got %+v", obj)
+	}
+	// Fields which don't form an unique key use update + insert
+	o.MustUpsert(Eq("Value", 4), &Upserted{Name: "bar", Value: 5})
+	if n := o.Table(table).MustCount(); n != 2 {
+		t.Errorf("expecting 2 objects after upserting, got %d", n)
+	}
+	if n, ok := o.conn.(driver.NativeUpserter); ok && o.driver.Upserts() {
+		if n.UpsertsNatively(table.model.model, Eq("Value", 4)) {
+			t.Error("expecting non-unique field Value not to be upserted natively")
+		}
+		if n.UpsertsNatively(codes.model.model, And(Eq("Code", "a"), Eq("Value", 1))) {
+			t.Error("expecting Code and Value not to be upserted natively")
+		}
+		if !n.UpsertsNatively(codes.model.model, Eq("Code", "a")) {
+			t.Error("expecting primary key Code to be upserted natively")
+		}
+	}
+	o.MustUpsert(Eq("Code", "a"), &UpsertedCode{Code: "a", Value: 1})
+	o.MustUpsert(Eq("Code", "a"), &UpsertedCode{Code: "a", Value: 2})
+	var code *UpsertedCode
+	if ok := o.Table(codes).MustOne(&code); !ok || code.Value != 2 {
+		t.Errorf("expecting code a with value 2, got %+v", code)
+	}
+	if n := o.Table(codes).MustCount(); n != 1 {
+		t.Errorf("expecting 1 code after upserting, got %d", n)
+	}
+	// Models with insert or update hooks always use update + insert
+	h := &HookedObject{Id: 1, Value: "foo"}
+	o.MustUpsert(Eq("Id", 1), h)
+	calls := []string{"BeforeSave", "BeforeUpdate", "AfterUpdate", "BeforeInsert", "AfterInsert", "AfterSave"}
+	if !reflect.DeepEqual(h.called, calls) {
+		t.Errorf("expecting hooks %v, got %v", calls, h.called)
+	}
+	if n := o.Table(hooked).MustCount(); n != 1 {
+		t.Errorf("expecting 1 hooked object after upserting, got %d", n)
+	}
+}
@@ -0,0 +1,177 @@
+package tasks
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"gnd.la/app"
+	"gnd.la/orm"
+)
+
+var (
+	// HistoryRetention is the time task executions are kept in the
+	// history. Older executions are deleted after recording new ones,
+	// at most once every HistoryPruneInterval. If zero, executions
+	// are never deleted.
+	HistoryRetention = 30 * 24 * time.Hour
+	// HistoryPruneInterval is the minimum interval between deletions
+	// of old executions from the history.
+	HistoryPruneInterval = time.Hour
+	// MaxHistoryOutput is the maximum length in bytes of the Output
+	// and Error fields stored in the history. Longer values are truncated.
+	MaxHistoryOutput = 4096
+
+	executionType = reflect.TypeOf(Execution{})
+
+	lastPrune struct {
+		sync.Mutex
+		time time.Time
+	}
+)
+
+// Execution represents a task execution stored in the history. To
+// record the task executions, Execution must be registered with the
+// ORM e.g.
+//
+//	orm.Register(&tasks.Execution{}, nil)
+type Execution struct {
+	Id       int64         `orm:",primary_key,auto_increment" json:"id"`
+	Task     string        `orm:",index" json:"task"`
+	Started  time.Time     `orm:",index" json:"started"`
+	Duration time.Duration `json:"duration"`
+	// Instance is the InstanceId of the process which
+	// ran the task.
+	Instance string            `json:"instance"`
+	Params   map[string]string `orm:",codec=json" json:"params"`
+	Failed   bool              `orm:",default=false" json:"failed"`
+	// Output is the value returned by the task function
+	// formatted as a string, if any.
+	Output string `orm:",omitempty,nullempty" json:"output"`
+	Error  string `orm:",omitempty,nullempty" json:"error"`
+}
+
+// contextOrm returns the ORM for the given context, or nil
+// if the app has no ORM configured.
+func contextOrm(ctx *app.Context) (o *orm.Orm) {
+	defer func() {
+		if r := recover(); r != nil {
+			o = nil
+		}
+	}()
+	return ctx.Orm()
+}
+
+// truncate returns s truncated to at most MaxHistoryOutput bytes,
+// without splitting any UTF-8 encoded characters.
+func truncate(s string) string {
+	if MaxHistoryOutput > 0 && len(s) > MaxHistoryOutput {
+		n := MaxHistoryOutput
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		return s[:n]
+	}
+	return s
+}
+
+// recordExecution stores the given result in the history, if
+// Execution has been registered with the ORM.
+func recordExecution(ctx *app.Context, task *Task, res *Result) {
+	o := contextOrm(ctx)
+	if o == nil || o.TypeTable(executionType) == nil {
+		return
+	}
+	e := &Execution{
+		Task:     task.Name(),
+		Started:  res.Started.UTC(),
+		Duration: res.Duration,
+		Instance: InstanceId(),
+		Params:   res.Params,
+		Failed:   res.Error != nil,
+	}
+	if res.Value != nil {
+		e.Output = truncate(fmt.Sprint(res.Value))
+	}
+	if res.Error != nil {
+		e.Error = truncate(res.Error.Error())
+	}
+	if _, err := o.Insert(e); err != nil {
+		ctx.Logger().Errorf("error recording execution of task %s: %s", e.Task, err)
+		return
+	}
+	if HistoryRetention > 0 {
+		lastPrune.Lock()
+		prune := time.Since(lastPrune.time) > HistoryPruneInterval
+		if prune {
+			lastPrune.time = time.Now()
+		}
+		lastPrune.Unlock()
+		if prune {
+			if err := PruneHistory(ctx, time.Now().Add(-HistoryRetention)); err != nil {
+				ctx.Logger().Errorf("error pruning task history: %s", err)
+			}
+		}
+	}
+}
+
+// History returns the executions of the given task recorded in the
+// history, most recent first, skipping the first offset ones and
+// returning at most limit of them. If name is empty, executions of all
+// the tasks are returned. If Execution is not registered with the ORM,
+// it returns no executions.
+func History(ctx *app.Context, name string, offset int, limit int) ([]*Execution, error) {
+	o := ctx.Orm()
+	tbl := o.TypeTable(executionType)
+	if tbl == nil {
+		return nil, nil
+	}
+	q := o.Table(tbl)
+	if name != "" {
+		q = q.Filter(orm.Eq("Task", name))
+	}
+	var executions []*Execution
+	// Some backends store times with second precision, so
+	// use the id to sort executions started in the same second.
+	if err := q.Sort("Started", orm.DESC).Sort("Id", orm.DESC).Offset(offset).Limit(limit).All(&executions); err != nil {
+		return nil, err
+	}
+	return executions, nil
+}
+
+// HistoryCount returns the number of executions of the given task
+// recorded in the history. If name is empty, executions of all the
+// tasks are counted.
+func HistoryCount(ctx *app.Context, name string) (int, error) {
+	o := ctx.Orm()
+	tbl := o.TypeTable(executionType)
+	if tbl == nil {
+		return 0, nil
+	}
+	q := o.Table(tbl)
+	if name != "" {
+		q = q.Filter(orm.Eq("Task", name))
+	}
+	count, err := q.Count()
+	return int(count), err
+}
+
+// History returns the executions of the task recorded in the history.
+// See the History function for the arguments.
+func (t *Task) History(ctx *app.Context, offset int, limit int) ([]*Execution, error) {
+	return History(ctx, t.Name(), offset, limit)
+}
+
+// PruneHistory deletes the executions started before the given
+// time from the history.
+func PruneHistory(ctx *app.Context, before time.Time) error {
+	o := ctx.Orm()
+	tbl := o.TypeTable(executionType)
+	if tbl == nil {
+		return nil
+	}
+	_, err := o.DeleteFrom(tbl, orm.Lt("Started", before.UTC()))
+	return err
+}
@@ -0,0 +1,129 @@
+package tasks
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"gnd.la/app"
+	"gnd.la/orm"
+)
+
+func clearHistory(t *testing.T, ctx *app.Context, name string) {
+	if _, err := ctx.Orm().DeleteFrom(ctx.Orm().TypeTable(executionType), orm.Eq("Task", name)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	fail := false
+	task := RegisterFunc(testApp, func(ctx *app.Context) (string, error) {
+		if fail {
+			return "", errors.New("failed")
+		}
+		return "done", nil
+	}, &Options{Name: "test-history"})
+	defer task.Delete()
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	clearHistory(t, ctx, task.Name())
+	task.Call(ctx, nil)
+	fail = true
+	task.Call(ctx, nil)
+	if n, err := HistoryCount(ctx, task.Name()); err != nil || n != 2 {
+		t.Fatalf("expecting 2 executions, got %d (error %v)", n, err)
+	}
+	executions, err := task.History(ctx, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(executions) != 2 {
+		t.Fatalf("expecting 2 executions, got %d", len(executions))
+	}
+	last, first := executions[0], executions[1]
+	if !last.Failed || last.Error != "failed" || last.Output != "" {
+		t.Errorf("expecting failed execution, got %+v", last)
+	}
+	if first.Failed || first.Error != "" || first.Output != "done" || first.Instance != InstanceId() {
+		t.Errorf("expecting successful execution, got %+v", first)
+	}
+	if first.Started.After(last.Started) {
+		t.Errorf("expecting most recent execution first, got %v and %v", last.Started, first.Started)
+	}
+	if executions, err := History(ctx, task.Name(), 1, 10); err != nil || len(executions) != 1 || executions[0].Id != first.Id {
+		t.Errorf("expecting execution %d with offset 1, got %+v (error %v)", first.Id, executions, err)
+	}
+	if executions, err := History(ctx, task.Name(), 0, 1); err != nil || len(executions) != 1 || executions[0].Id != last.Id {
+		t.Errorf("expecting execution %d with limit 1, got %+v (error %v)", last.Id, executions, err)
+	}
+}
+
+func TestHistoryTruncate(t *testing.T) {
+	defer func(max int) { MaxHistoryOutput = max }(MaxHistoryOutput)
+	MaxHistoryOutput = 8
+	output := strings.Repeat("é", 10)
+	task := RegisterFunc(testApp, func(ctx *app.Context) (string, error) {
+		return output, errors.New(output)
+	}, &Options{Name: "test-history-truncate"})
+	defer task.Delete()
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	clearHistory(t, ctx, task.Name())
+	task.Call(ctx, nil)
+	executions, err := task.History(ctx, 0, 1)
+	if err != nil || len(executions) != 1 {
+		t.Fatalf("expecting 1 execution, got %d (error %v)", len(executions), err)
+	}
+	e := executions[0]
+	for _, v := range []string{e.Output, e.Error} {
+		if v != output[:8] || !utf8.ValidString(v) {
+			t.Errorf("expecting truncated value %q, got %q", output[:8], v)
+		}
+	}
+	MaxHistoryOutput = 7
+	if s := truncate(output); s != output[:6] {
+		t.Errorf("expecting %q truncated at a rune boundary, got %q", output[:6], s)
+	}
+}
+
+func TestPruneHistory(t *testing.T) {
+	defer func(retention time.Duration) { HistoryRetention = retention }(HistoryRetention)
+	HistoryRetention = time.Hour
+	task := RegisterFunc(testApp, func(ctx *app.Context) error {
+		return nil
+	}, &Options{Name: "test-history-prune"})
+	defer task.Delete()
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	clearHistory(t, ctx, task.Name())
+	insertOld := func() {
+		e := &Execution{Task: task.Name(), Started: time.Now().UTC().Add(-2 * HistoryRetention)}
+		if _, err := ctx.Orm().Insert(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	insertOld()
+	if err := PruneHistory(ctx, time.Now().Add(-HistoryRetention)); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := HistoryCount(ctx, task.Name()); err != nil || n != 0 {
+		t.Errorf("expecting no executions after pruning, got %d (error %v)", n, err)
+	}
+	// Old executions are pruned after recording new ones
+	insertOld()
+	lastPrune.Lock()
+	lastPrune.time = time.Time{}
+	lastPrune.Unlock()
+	task.Call(ctx, nil)
+	if n, err := HistoryCount(ctx, task.Name()); err != nil || n != 1 {
+		t.Errorf("expecting 1 execution after recording, got %d (error %v)", n, err)
+	}
+	// But at most once every HistoryPruneInterval
+	insertOld()
+	task.Call(ctx, nil)
+	if n, err := HistoryCount(ctx, task.Name()); err != nil || n != 3 {
+		t.Errorf("expecting 3 executions before the next pruning, got %d (error %v)", n, err)
+	}
+}
@@ -0,0 +1,136 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/orm"
+	"gnd.la/util/stringutil"
+)
+
+var (
+	// ErrLeaseNotRegistered is returned by the default Locker when
+	// the Lease type has not been registered with the ORM.
+	ErrLeaseNotRegistered = errors.New("tasks.Lease is not registered with the orm - add orm.Register(&tasks.Lease{}, nil) somewhere in your app")
+
+	leaseType = reflect.TypeOf(Lease{})
+
+	lockers struct {
+		sync.RWMutex
+		locker Locker
+	}
+
+	instanceIdOnce sync.Once
+	instanceId     string
+)
+
+// Locker is the interface implemented by the distributed locks used
+// to coordinate scheduled tasks with a Lease among several instances
+// of the same app. See SetLocker.
+type Locker interface {
+	// Acquire tries to acquire or renew the lease with the given name
+	// for the given owner and duration. It must return true iff the
+	// owner holds the lease after the call. Note that owners must be
+	// able to renew their leases before they expire.
+	Acquire(ctx *app.Context, name string, owner string, lease time.Duration) (bool, error)
+}
+
+// Lease is used by the default Locker to store the leases in the
+// database. To use it, it must be registered with the ORM e.g.
+//
+//	orm.Register(&tasks.Lease{}, nil)
+type Lease struct {
+	Name    string `orm:",primary_key"`
+	Owner   string
+	Expires time.Time
+}
+
+// ormLocker implements Locker using the app ORM. Expired leases are
+// taken over with an UPDATE which checks the expiration time, so it's
+// atomic in every backend. Leases which don't exist yet are created
+// with an INSERT, relying on the primary key to avoid two instances
+// from creating the same lease.
+type ormLocker struct{}
+
+func (ormLocker) Acquire(ctx *app.Context, name string, owner string, lease time.Duration) (bool, error) {
+	o := ctx.Orm()
+	tbl := o.TypeTable(leaseType)
+	if tbl == nil {
+		return false, ErrLeaseNotRegistered
+	}
+	now := time.Now().UTC()
+	l := &Lease{Name: name, Owner: owner, Expires: now.Add(lease)}
+	q := orm.And(orm.Eq("Name", name), orm.Or(orm.Eq("Owner", owner), orm.Lt("Expires", now)))
+	res, err := o.Update(q, l)
+	if err != nil {
+		return false, err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if aff > 0 {
+		return true, nil
+	}
+	if _, err := o.Insert(l); err != nil {
+		// If the lease exists, another instance holds it
+		// or it just created it.
+		if exists, eerr := o.Exists(tbl, orm.Eq("Name", name)); eerr == nil && exists {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// SetLocker sets the Locker used to coordinate tasks scheduled with a
+// Lease. The default Locker stores the leases in the database using the
+// ORM (see Lease). Passing nil restores the default Locker.
+func SetLocker(locker Locker) {
+	lockers.Lock()
+	lockers.locker = locker
+	lockers.Unlock()
+}
+
+func currentLocker() Locker {
+	lockers.RLock()
+	defer lockers.RUnlock()
+	if lockers.locker != nil {
+		return lockers.locker
+	}
+	return ormLocker{}
+}
+
+// InstanceId returns the identifier used as the owner for the
+// leases held by this process. It's unique for every process.
+func InstanceId() string {
+	instanceIdOnce.Do(func() {
+		host, _ := os.Hostname()
+		instanceId = fmt.Sprintf("%s-%d-%s", host, os.Getpid(), stringutil.Random(8))
+	})
+	return instanceId
+}
+
+// hasLease returns true iff the task can run in this instance. Tasks
+// without a Lease can always run, while tasks with a Lease must acquire
+// it first.
+func (t *Task) hasLease(ctx *app.Context) bool {
+	if t.Options == nil || t.Options.Lease <= 0 {
+		return true
+	}
+	name := t.Name()
+	ok, err := currentLocker().Acquire(ctx, name, InstanceId(), t.Options.Lease)
+	if err != nil {
+		ctx.Logger().Errorf("error acquiring lease for task %s: %s", name, err)
+		return false
+	}
+	if !ok {
+		ctx.Logger().Debugf("Not running task %s, its lease is held by another instance", name)
+	}
+	return ok
+}
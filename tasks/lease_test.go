@@ -0,0 +1,80 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/orm"
+)
+
+func TestOrmLocker(t *testing.T) {
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	var l ormLocker
+	const name = "test-orm-locker"
+	if _, err := ctx.Orm().DeleteFrom(ctx.Orm().TypeTable(leaseType), orm.Eq("Name", name)); err != nil {
+		t.Fatal(err)
+	}
+	acquire := func(owner string, lease time.Duration, exp bool) {
+		ok, err := l.Acquire(ctx, name, owner, lease)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != exp {
+			t.Errorf("expecting %s to acquire lease = %v, got %v", owner, exp, ok)
+		}
+	}
+	acquire("a", time.Minute, true)
+	acquire("b", time.Minute, false)
+	// Renew, letting it expire
+	acquire("a", -time.Second, true)
+	// Take over the expired lease
+	acquire("b", time.Minute, true)
+	acquire("a", time.Minute, false)
+	acquire("b", time.Minute, true)
+}
+
+// testLocker grants the lease to its owner.
+type testLocker struct {
+	owner string
+	calls int
+}
+
+func (l *testLocker) Acquire(ctx *app.Context, name string, owner string, lease time.Duration) (bool, error) {
+	l.calls++
+	return owner == l.owner, nil
+}
+
+func TestTaskLease(t *testing.T) {
+	locker := &testLocker{}
+	SetLocker(locker)
+	defer SetLocker(nil)
+	if _, ok := currentLocker().(*testLocker); !ok {
+		t.Fatalf("expecting testLocker, got %T", currentLocker())
+	}
+	var runs int
+	task := Register(testApp, func(ctx *app.Context) {
+		runs++
+	}, &Options{Name: "test-lease", Lease: time.Minute})
+	defer task.Delete()
+	// Held by another instance, scheduled runs are skipped
+	task.executeTask()
+	if runs != 0 || locker.calls != 1 {
+		t.Errorf("expecting 0 runs and 1 lock call, got %d and %d", runs, locker.calls)
+	}
+	// Manual runs don't require the lease
+	task.executeTaskWithParams(nil, false)
+	if runs != 1 || locker.calls != 1 {
+		t.Errorf("expecting 1 run and 1 lock call, got %d and %d", runs, locker.calls)
+	}
+	locker.owner = InstanceId()
+	task.executeTask()
+	if runs != 2 || locker.calls != 2 {
+		t.Errorf("expecting 2 runs and 2 lock calls, got %d and %d", runs, locker.calls)
+	}
+	SetLocker(nil)
+	if _, ok := currentLocker().(ormLocker); !ok {
+		t.Errorf("expecting ormLocker after resetting, got %T", currentLocker())
+	}
+}
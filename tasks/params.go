@@ -0,0 +1,320 @@
+package tasks
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/form/input"
+	"gnd.la/util/stringutil"
+	"gnd.la/util/structs"
+)
+
+var (
+	contextType  = reflect.TypeOf((*app.Context)(nil))
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// Param describes a parameter accepted by a task registered
+// with RegisterFunc or ScheduleFunc.
+type Param struct {
+	// Name is the parameter name, taken from the task tag or,
+	// if there's no tag, from the field name transformed from
+	// camel case to lowercase words separated by '-'.
+	Name string
+	// Type is the parameter type.
+	Type reflect.Type
+	// Default is the default value for the parameter, as a string.
+	Default string
+	// Help is the description of the parameter.
+	Help string
+	// Required indicates if the parameter must be always provided.
+	Required bool
+	index    []int
+}
+
+func (p *Param) parse(s string, params reflect.Value) error {
+	field := params.Elem().FieldByIndex(p.index)
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	return input.Parse(s, field.Addr().Interface())
+}
+
+// taskFunc wraps a function used as a task with typed
+// parameters and results.
+type taskFunc struct {
+	fn       reflect.Value
+	params   reflect.Type
+	fields   []*Param
+	hasValue bool
+}
+
+// newTaskFunc validates the given function. It must have one
+// of the signatures documented in RegisterFunc.
+func newTaskFunc(fn interface{}) (*taskFunc, error) {
+	val := reflect.ValueOf(fn)
+	typ := val.Type()
+	if typ.Kind() != reflect.Func {
+		return nil, fmt.Errorf("task function must be a func, not %T", fn)
+	}
+	if typ.NumIn() == 0 || typ.NumIn() > 2 || typ.In(0) != contextType {
+		return nil, fmt.Errorf("task function %s must receive a *app.Context and an optional pointer to a struct", typ)
+	}
+	if typ.NumOut() == 0 || typ.NumOut() > 2 || typ.Out(typ.NumOut()-1) != errorType {
+		return nil, fmt.Errorf("task function %s must return an error or a value and an error", typ)
+	}
+	f := &taskFunc{fn: val, hasValue: typ.NumOut() == 2}
+	if typ.NumIn() == 2 {
+		in := typ.In(1)
+		if in.Kind() != reflect.Ptr || in.Elem().Kind() != reflect.Struct {
+			return nil, fmt.Errorf("task function %s must receive a pointer to a struct as its parameters, not %s", typ, in)
+		}
+		f.params = in.Elem()
+		if err := f.parseFields(f.params, nil); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (f *taskFunc) parseFields(typ reflect.Type, index []int) error {
+	for ii := 0; ii < typ.NumField(); ii++ {
+		field := typ.Field(ii)
+		if field.PkgPath != "" {
+			continue
+		}
+		idx := make([]int, len(index)+1)
+		copy(idx, index)
+		idx[len(index)] = ii
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := f.parseFields(field.Type, idx); err != nil {
+				return err
+			}
+			continue
+		}
+		tag := structs.NewTagNamed(field, "task")
+		name := tag.Name()
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = stringutil.CamelCaseToLower(field.Name, "-")
+		}
+		f.fields = append(f.fields, &Param{
+			Name:     name,
+			Type:     field.Type,
+			Default:  tag.Value("default"),
+			Help:     tag.Value("help"),
+			Required: tag.Required(),
+			index:    idx,
+		})
+	}
+	// Check that the default values can be parsed
+	_, err := f.newParams()
+	return err
+}
+
+// newParams returns a new pointer to the parameters
+// struct, with the default values set.
+func (f *taskFunc) newParams() (reflect.Value, error) {
+	params := reflect.New(f.params)
+	for _, v := range f.fields {
+		if v.Default != "" {
+			if err := v.parse(v.Default, params); err != nil {
+				return reflect.Value{}, fmt.Errorf("invalid default value %q for task parameter %s: %s", v.Default, v.Name, err)
+			}
+		}
+	}
+	return params, nil
+}
+
+// contextParams parses the task parameters from the
+// ctx parameters.
+func (f *taskFunc) contextParams(ctx *app.Context) (interface{}, error) {
+	if f.params == nil {
+		return nil, nil
+	}
+	params, err := f.newParams()
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range f.fields {
+		value := ctx.ParamValue(v.Name)
+		if value == "" {
+			if v.Required {
+				return nil, fmt.Errorf("missing required task parameter %s", v.Name)
+			}
+			continue
+		}
+		if err := v.parse(value, params); err != nil {
+			return nil, fmt.Errorf("invalid value %q for task parameter %s: %s", value, v.Name, err)
+		}
+	}
+	return params.Interface(), nil
+}
+
+func (f *taskFunc) call(ctx *app.Context, params interface{}) (interface{}, error) {
+	in := []reflect.Value{reflect.ValueOf(ctx)}
+	if f.params != nil {
+		pv := reflect.ValueOf(params)
+		if params == nil {
+			var err error
+			if pv, err = f.newParams(); err != nil {
+				return nil, err
+			}
+		} else if pv.Type() != reflect.PtrTo(f.params) {
+			return nil, fmt.Errorf("invalid task parameters type %T, must be %s", params, reflect.PtrTo(f.params))
+		}
+		in = append(in, pv)
+	} else if params != nil {
+		return nil, fmt.Errorf("task does not accept parameters, %T provided", params)
+	}
+	out := f.fn.Call(in)
+	var value interface{}
+	if f.hasValue {
+		value = out[0].Interface()
+	}
+	err, _ := out[len(out)-1].Interface().(error)
+	return value, err
+}
+
+// flagValue implements flag.Value, setting the
+// parsed value into a parameters struct.
+type flagValue struct {
+	param  *Param
+	params reflect.Value
+	set    bool
+}
+
+func (v *flagValue) String() string {
+	if v.param == nil {
+		return ""
+	}
+	return fmt.Sprint(v.params.Elem().FieldByIndex(v.param.index).Interface())
+}
+
+func (v *flagValue) Set(s string) error {
+	v.set = true
+	return v.param.parse(s, v.params)
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.param != nil && v.param.Type.Kind() == reflect.Bool
+}
+
+// Params returns the parameters accepted by the task. Only tasks
+// registered with RegisterFunc or ScheduleFunc with a function which
+// receives a parameters struct accept parameters.
+func (t *Task) Params() []*Param {
+	if t.fn == nil {
+		return nil
+	}
+	return t.fn.fields
+}
+
+// ParseFlags parses the given command line arguments as flags named
+// after the task parameters (e.g. -name=value) and returns the resulting
+// parameters, which can be then passed to Call. Parameters not present
+// in args take their default values. Note that tasks without parameters
+// return nil.
+func (t *Task) ParseFlags(args []string) (interface{}, error) {
+	if t.fn == nil || t.fn.params == nil {
+		if len(args) > 0 {
+			return nil, fmt.Errorf("task %s does not accept parameters", t.Name())
+		}
+		return nil, nil
+	}
+	params, err := t.fn.newParams()
+	if err != nil {
+		return nil, err
+	}
+	set := flag.NewFlagSet(t.Name(), flag.ContinueOnError)
+	set.SetOutput(ioutil.Discard)
+	values := make([]*flagValue, len(t.fn.fields))
+	for ii, v := range t.fn.fields {
+		values[ii] = &flagValue{param: v, params: params}
+		set.Var(values[ii], v.Name, v.Help)
+	}
+	if err := set.Parse(args); err != nil {
+		return nil, err
+	}
+	if set.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments for task %s: %v", t.Name(), set.Args())
+	}
+	for _, v := range values {
+		if v.param.Required && !v.set {
+			return nil, fmt.Errorf("missing required task parameter %s", v.param.Name)
+		}
+	}
+	return params.Interface(), nil
+}
+
+// Call runs the task synchronously in the given context, passing it
+// the given parameters, which must be either nil (to use the default
+// parameters) or a pointer to the parameters struct accepted by the task
+// function. It returns the value returned by the task function, if any,
+// and any error returned by the function or produced while running it
+// (e.g. a panic or too many running instances).
+func (t *Task) Call(ctx *app.Context, params interface{}) (interface{}, error) {
+	_, value, err := executeTask(ctx, t, nil, params)
+	return value, err
+}
+
+// RegisterFunc works like Register, but receives a function with
+// typed parameters and results rather than an app.Handler. The function
+// must have one of the following signatures:
+//
+//	func(ctx *app.Context) error
+//	func(ctx *app.Context) (R, error)
+//	func(ctx *app.Context, params *P) error
+//	func(ctx *app.Context, params *P) (R, error)
+//
+// Where P is a struct and R might be any type. The exported fields of P
+// are the task parameters, which can be configured using the task
+// struct tag e.g.
+//
+//	type CleanupParams struct {
+//		MaxAge   time.Duration `task:"max-age,default=24h,help='Maximum age of the files to keep'"`
+//		DryRun   bool          `task:",help='Just print the files which would be removed'"`
+//		Bucket   string        `task:",required"`
+//	}
+//
+// When the task is started with Start, Run or when it's scheduled, its
+// parameters are parsed from the context parameters. The value returned
+// by the function and its error are available in the task Result.
+func RegisterFunc(m *app.App, fn interface{}, opts *Options) *Task {
+	f, err := newTaskFunc(fn)
+	if err != nil {
+		panic(err)
+	}
+	t := &Task{App: m, Options: opts, fn: f}
+	// The Handler is only used when the task is run outside of
+	// executeTask (which calls f directly), so call the function
+	// without acquiring another instance of the task.
+	t.Handler = func(ctx *app.Context) {
+		params, err := f.contextParams(ctx)
+		if err == nil {
+			_, err = f.call(ctx, params)
+		}
+		if err != nil {
+			panic(err)
+		}
+	}
+	return register(t)
+}
+
+// ScheduleFunc works like Schedule, but receives a function with typed
+// parameters and results. See RegisterFunc for the accepted functions.
+func ScheduleFunc(m *app.App, fn interface{}, opts *Options, interval time.Duration, onListen bool) *Task {
+	return schedule(RegisterFunc(m, fn, opts), interval, onListen)
+}
@@ -0,0 +1,138 @@
+package tasks
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gnd.la/app"
+)
+
+type cleanupParams struct {
+	MaxAge time.Duration `task:"max-age,default=24h,help='Maximum age'"`
+	DryRun bool
+	Bucket string `task:",required"`
+	Count  int    `task:",default=1"`
+	Ignore string `task:"-"`
+}
+
+func cleanup(ctx *app.Context, p *cleanupParams) (string, error) {
+	if p.Count < 0 {
+		return "", errors.New("negative count")
+	}
+	return strings.Repeat(p.Bucket, p.Count), nil
+}
+
+func TestParams(t *testing.T) {
+	task := RegisterFunc(testApp, cleanup, &Options{Name: "test-params"})
+	defer task.Delete()
+	var names []string
+	for _, v := range task.Params() {
+		names = append(names, v.Name)
+	}
+	if exp := []string{"max-age", "dry-run", "bucket", "count"}; !reflect.DeepEqual(names, exp) {
+		t.Errorf("expecting params %v, got %v", exp, names)
+	}
+	args, err := task.ParseFlags([]string{"-bucket=b", "-max-age=1h", "-dry-run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := &cleanupParams{MaxAge: time.Hour, DryRun: true, Bucket: "b", Count: 1}
+	if !reflect.DeepEqual(args, exp) {
+		t.Errorf("expecting params %+v, got %+v", exp, args)
+	}
+	invalid := [][]string{
+		{"-max-age=1h"},
+		{"-bucket=b", "-count=x"},
+		{"-bucket=b", "-max-age=1"},
+		{"-bucket=b", "-ignore=x"},
+		{"-bucket=b", "extra"},
+	}
+	for _, v := range invalid {
+		if _, err := task.ParseFlags(v); err == nil {
+			t.Errorf("expecting an error parsing %v", v)
+		}
+	}
+}
+
+func TestParamsResult(t *testing.T) {
+	task := RegisterFunc(testApp, cleanup, &Options{Name: "test-params-result"})
+	defer task.Delete()
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	value, err := task.Call(ctx, &cleanupParams{Bucket: "a", Count: 2})
+	if err != nil || value != "aa" {
+		t.Errorf("expecting value aa, got %v (error %v)", value, err)
+	}
+	res := task.LastResult()
+	if res == nil || res.Value != "aa" || res.Error != nil {
+		t.Errorf("expecting result aa, got %+v", res)
+	}
+	if _, err := task.Call(ctx, &cleanupParams{Count: -1}); err == nil || err.Error() != "negative count" {
+		t.Errorf("expecting negative count error, got %v", err)
+	}
+	if res = task.LastResult(); res == nil || res.Error == nil {
+		t.Errorf("expecting result with error, got %+v", res)
+	}
+	if _, err := task.Call(ctx, "bad"); err == nil {
+		t.Error("expecting an error with invalid parameters")
+	}
+	// Parameters from the context
+	prev := task.LastResult()
+	params := map[string]string{"bucket": "c", "count": "3"}
+	task.Start(params)
+	res = waitResult(t, task, prev)
+	if res.Value != "ccc" || res.Error != nil || !reflect.DeepEqual(res.Params, params) {
+		t.Errorf("expecting result ccc with params %v, got %+v", params, res)
+	}
+	if args, ok := res.Args.(*cleanupParams); !ok || args.MaxAge != 24*time.Hour || args.Count != 3 {
+		t.Errorf("expecting parsed args, got %+v", res.Args)
+	}
+	for _, v := range []map[string]string{{"count": "3"}, {"bucket": "c", "count": "x"}} {
+		prev = res
+		task.Start(v)
+		if res = waitResult(t, task, prev); res.Error == nil {
+			t.Errorf("expecting an error with params %v", v)
+		}
+	}
+}
+
+func TestParamsPanic(t *testing.T) {
+	task := RegisterFunc(testApp, func(ctx *app.Context) error {
+		panic("oops")
+	}, &Options{Name: "test-params-panic"})
+	defer task.Delete()
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	if _, err := task.Call(ctx, nil); err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("expecting panic error, got %v", err)
+	}
+	if _, err := task.Call(ctx, &cleanupParams{}); err == nil {
+		t.Error("expecting an error passing params to a task without them")
+	}
+	if _, err := task.ParseFlags([]string{"-bucket=b"}); err == nil {
+		t.Error("expecting an error parsing flags for a task without params")
+	}
+}
+
+func TestInvalidTaskFunc(t *testing.T) {
+	type badDefault struct {
+		Count int `task:",default=x"`
+	}
+	invalid := []interface{}{
+		"task",
+		func() error { return nil },
+		func(ctx *app.Context) {},
+		func(ctx *app.Context) string { return "" },
+		func(ctx *app.Context, p cleanupParams) error { return nil },
+		func(ctx *app.Context, p *int) error { return nil },
+		func(ctx *app.Context, p *badDefault) error { return nil },
+	}
+	for _, v := range invalid {
+		if _, err := newTaskFunc(v); err == nil {
+			t.Errorf("expecting an error with task function %T", v)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Overflow indicates what happens when a task can't be started
+// because of the concurrency limits. See Options.Overflow.
+type Overflow int
+
+const (
+	// OverflowError makes the task fail to start with an error,
+	// which is logged for scheduled tasks and returned by Run.
+	OverflowError Overflow = iota
+	// OverflowDrop silently skips the execution.
+	OverflowDrop
+	// OverflowWait makes the execution wait until there's a
+	// free slot. Use Options.MaxQueued to limit the number of
+	// waiting executions.
+	OverflowWait
+)
+
+func (o Overflow) String() string {
+	switch o {
+	case OverflowError:
+		return "error"
+	case OverflowDrop:
+		return "drop"
+	case OverflowWait:
+		return "wait"
+	}
+	return fmt.Sprintf("Overflow(%d)", int(o))
+}
+
+var (
+	errDropped = errors.New("task dropped because of the concurrency limits")
+
+	maxWorkers int
+)
+
+// SetMaxWorkers sets the maximum number of tasks which might be
+// running at the same time, shared by all the tasks. Tasks exceeding
+// this limit are handled according to their Options.Overflow. If
+// zero (the default), there is no limit.
+func SetMaxWorkers(max int) {
+	running.Lock()
+	maxWorkers = max
+	running.Unlock()
+	// Increasing the limit might allow waiting tasks to run
+	signalDone()
+}
+
+// MaxWorkers returns the maximum number of tasks which might be
+// running at the same time. See SetMaxWorkers.
+func MaxWorkers() int {
+	running.Lock()
+	defer running.Unlock()
+	return maxWorkers
+}
+
+// Workers returns the number of task instances currently running
+// and the number of them waiting for a free slot.
+func Workers() (active int, queued int) {
+	running.Lock()
+	defer running.Unlock()
+	for _, v := range running.queued {
+		queued += v
+	}
+	return running.total, queued
+}
+
+// Queued returns the number of instances of this task which
+// are waiting for a free slot.
+func (t *Task) Queued() int {
+	running.Lock()
+	defer running.Unlock()
+	return running.queued[t]
+}
+
+func signalDone() {
+	running.Lock()
+	if running.done != nil {
+		running.done.Broadcast()
+	}
+	running.Unlock()
+}
+
+// overflow returns a non-empty reason if the task can't start
+// right now. It must be called with running locked.
+func overflow(task *Task) string {
+	if task.Options != nil && task.Options.MaxInstances > 0 {
+		if c := running.tasks[task]; c >= task.Options.MaxInstances {
+			return fmt.Sprintf("it's already running %d instances", c)
+		}
+	}
+	if maxWorkers > 0 && running.total >= maxWorkers {
+		return fmt.Sprintf("there are already %d tasks running", running.total)
+	}
+	return ""
+}
+
+// acquire reserves a slot for running the given task, according to
+// its Options, and returns the number of instances of the task
+// running, including the new one. If the task was dropped because
+// of the concurrency limits, it returns errDropped.
+func acquire(task *Task) (int, error) {
+	running.Lock()
+	defer running.Unlock()
+	for {
+		reason := overflow(task)
+		if reason == "" {
+			break
+		}
+		var policy Overflow
+		var maxQueued int
+		if task.Options != nil {
+			policy = task.Options.Overflow
+			maxQueued = task.Options.MaxQueued
+		}
+		switch policy {
+		case OverflowDrop:
+			return 0, errDropped
+		case OverflowWait:
+			if maxQueued > 0 && running.queued[task] >= maxQueued {
+				return 0, fmt.Errorf("not starting task %s because %s and there are already %d instances waiting", task.Name(), reason, running.queued[task])
+			}
+			if running.done == nil {
+				running.done = sync.NewCond(&running.Mutex)
+			}
+			if running.queued == nil {
+				running.queued = make(map[*Task]int)
+			}
+			running.queued[task]++
+			running.done.Wait()
+			if c := running.queued[task] - 1; c > 0 {
+				running.queued[task] = c
+			} else {
+				delete(running.queued, task)
+			}
+		default:
+			return 0, fmt.Errorf("not starting task %s because %s", task.Name(), reason)
+		}
+	}
+	if running.tasks == nil {
+		running.tasks = make(map[*Task]int)
+	}
+	c := running.tasks[task] + 1
+	running.tasks[task] = c
+	running.total++
+	return c, nil
+}
+
+// release frees the slot used by the task and returns the number
+// of instances of the task still running. It must be called with
+// running locked.
+func release(task *Task) int {
+	c := running.tasks[task] - 1
+	if c > 0 {
+		running.tasks[task] = c
+	} else {
+		delete(running.tasks, task)
+	}
+	running.total--
+	if running.done != nil {
+		running.done.Broadcast()
+	}
+	return c
+}
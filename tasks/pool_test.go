@@ -0,0 +1,127 @@
+package tasks
+
+import (
+	"testing"
+	"time"
+
+	"gnd.la/app"
+)
+
+// blockingTask is a task which blocks until it's released.
+type blockingTask struct {
+	*Task
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingTask(opts *Options) *blockingTask {
+	b := &blockingTask{started: make(chan struct{}, 10), release: make(chan struct{})}
+	b.Task = Register(testApp, func(ctx *app.Context) {
+		b.started <- struct{}{}
+		<-b.release
+	}, opts)
+	return b
+}
+
+func (b *blockingTask) waitStarted(t *testing.T) {
+	select {
+	case <-b.started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("task %s did not start", b.Name())
+	}
+}
+
+// finish releases all the instances and waits until they're done.
+func (b *blockingTask) finish(t *testing.T) {
+	close(b.release)
+	waitFor(t, func() bool { return b.Running() == 0 && b.Queued() == 0 })
+	b.Delete()
+}
+
+func waitFor(t *testing.T, f func() bool) {
+	timeout := time.After(5 * time.Second)
+	for !f() {
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for condition")
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func TestOverflowError(t *testing.T) {
+	b := newBlockingTask(&Options{Name: "test-overflow-error", MaxInstances: 1})
+	defer b.finish(t)
+	b.Start(nil)
+	b.waitStarted(t)
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	if ran, err := Run(ctx, b.Name()); ran || err == nil {
+		t.Errorf("expecting an error running over MaxInstances, got %v and %v", ran, err)
+	}
+	if n := b.Running(); n != 1 {
+		t.Errorf("expecting 1 instance running, got %d", n)
+	}
+}
+
+func TestOverflowDrop(t *testing.T) {
+	b := newBlockingTask(&Options{Name: "test-overflow-drop", MaxInstances: 1, Overflow: OverflowDrop})
+	defer b.finish(t)
+	b.Start(nil)
+	b.waitStarted(t)
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	if ran, err := Run(ctx, b.Name()); ran || err != nil {
+		t.Errorf("expecting task to be dropped, got %v and %v", ran, err)
+	}
+}
+
+func TestOverflowWait(t *testing.T) {
+	b := newBlockingTask(&Options{Name: "test-overflow-wait", MaxInstances: 1, Overflow: OverflowWait, MaxQueued: 1})
+	b.Start(nil)
+	b.waitStarted(t)
+	b.Start(nil)
+	waitFor(t, func() bool { return b.Queued() == 1 })
+	if active, queued := Workers(); active != 1 || queued != 1 {
+		t.Errorf("expecting 1 active and 1 queued workers, got %d and %d", active, queued)
+	}
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	if ran, err := Run(ctx, b.Name()); ran || err == nil {
+		t.Errorf("expecting an error running over MaxQueued, got %v and %v", ran, err)
+	}
+	// Releasing the first instance lets the queued one run
+	b.release <- struct{}{}
+	b.waitStarted(t)
+	if running, queued := b.Running(), b.Queued(); running != 1 || queued != 0 {
+		t.Errorf("expecting 1 running and 0 queued instances, got %d and %d", running, queued)
+	}
+	b.finish(t)
+}
+
+func TestMaxWorkers(t *testing.T) {
+	SetMaxWorkers(1)
+	defer SetMaxWorkers(0)
+	if n := MaxWorkers(); n != 1 {
+		t.Errorf("expecting 1 max worker, got %d", n)
+	}
+	b1 := newBlockingTask(&Options{Name: "test-max-workers-1"})
+	b2 := newBlockingTask(&Options{Name: "test-max-workers-2", Overflow: OverflowWait})
+	b1.Start(nil)
+	b1.waitStarted(t)
+	ctx := testApp.NewContext(nil)
+	defer testApp.CloseContext(ctx)
+	if ran, err := Run(ctx, b1.Name()); ran || err == nil {
+		t.Errorf("expecting an error running over the worker limit, got %v and %v", ran, err)
+	}
+	b2.Start(nil)
+	waitFor(t, func() bool { return b2.Queued() == 1 })
+	// Increasing the limit lets the queued task run
+	SetMaxWorkers(2)
+	b2.waitStarted(t)
+	if active, queued := Workers(); active != 2 || queued != 0 {
+		t.Errorf("expecting 2 active and 0 queued workers, got %d and %d", active, queued)
+	}
+	b1.finish(t)
+	b2.finish(t)
+}
@@ -1,5 +1,9 @@
 package tasks
 
+import (
+	"sort"
+)
+
 // context provider for tasks. Since the tasks
 // receive no parameters, the provider is just
 // a dummy one which always returns zero/empty.
@@ -20,3 +24,28 @@ func (c contextProvider) Param(name string) string {
 func (c contextProvider) Params() []string {
 	return nil
 }
+
+// paramsProvider is used for tasks started with
+// parameters. It only provides named parameters.
+type paramsProvider map[string]string
+
+func (p paramsProvider) Count() int {
+	return 0
+}
+
+func (p paramsProvider) Arg(i int) string {
+	return ""
+}
+
+func (p paramsProvider) Param(name string) string {
+	return p[name]
+}
+
+func (p paramsProvider) Params() []string {
+	params := make([]string, 0, len(p))
+	for k := range p {
+		params = append(params, k)
+	}
+	sort.Strings(params)
+	return params
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
 	"time"
 
@@ -17,7 +18,14 @@ import (
 
 var running struct {
 	sync.Mutex
-	tasks map[*Task]int
+	tasks  map[*Task]int
+	queued map[*Task]int
+	// total is the number of tasks running, used
+	// to enforce the global worker limit.
+	total int
+	// done is signaled every time a task finishes
+	// running, to wake up tasks waiting for a slot.
+	done *sync.Cond
 }
 
 var registered struct {
@@ -36,15 +44,45 @@ type Task struct {
 	Handler  app.Handler
 	Interval time.Duration
 	Options  *Options
+	mu       sync.Mutex
 	ticker   *time.Ticker
 	stop     chan struct{}
 	stopped  chan struct{}
+	resumed  time.Time
+	last     *Result
+	fn       *taskFunc
+}
+
+// Result contains information about a task execution.
+type Result struct {
+	// Started is the time when the task started running.
+	Started time.Time
+	// Duration is the time the task took to complete.
+	Duration time.Duration
+	// Params are the parameters the task was started with,
+	// if any (see Task.Start).
+	Params map[string]string
+	// Args are the typed parameters received by the task function,
+	// for tasks registered with RegisterFunc or ScheduleFunc.
+	Args interface{}
+	// Value is the value returned by the task function, for tasks
+	// registered with RegisterFunc or ScheduleFunc.
+	Value interface{}
+	// Error is non-nil if the task panicked or its
+	// function returned an error.
+	Error error
 }
 
 // Stop de-schedules the task. After stopping the task, it
 // won't be started again but if it's currently running, it will
 // be completed.
 func (t *Task) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopLocked()
+}
+
+func (t *Task) stopLocked() {
 	if t.stop != nil {
 		t.stop <- struct{}{}
 		<-t.stopped
@@ -53,22 +91,81 @@ func (t *Task) Stop() {
 	}
 }
 
+// Resume schedules the task again after it has been stopped. If now
+// is true, the task is also started immediately. Tasks without an
+// Interval can't be scheduled, so Resume does nothing for them.
 func (t *Task) Resume(now bool) {
-	t.Stop()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopLocked()
+	if t.Interval <= 0 {
+		return
+	}
 	t.ticker = time.NewTicker(t.Interval)
 	t.stop = make(chan struct{}, 1)
 	t.stopped = make(chan struct{}, 1)
+	t.resumed = time.Now()
 	go t.execute(now)
 }
 
+// IsScheduled returns true iff the task is currently scheduled
+// to run periodically.
+func (t *Task) IsScheduled() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stop != nil
+}
+
+// Next returns the next time the task is scheduled to run. If the
+// task is not scheduled, it returns the zero time.Time.
+func (t *Task) Next() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stop == nil {
+		return time.Time{}
+	}
+	runs := time.Since(t.resumed)/t.Interval + 1
+	return t.resumed.Add(runs * t.Interval)
+}
+
+// LastResult returns the result of the last completed execution
+// of the task, or nil if it hasn't completed any execution yet.
+func (t *Task) LastResult() *Result {
+	running.Lock()
+	defer running.Unlock()
+	return t.last
+}
+
+// Start runs the task in the background, making the given parameters
+// available to its handler via gnd.la/app.Context.ParamValue. The
+// restrictions set by the task Options still apply, so the task
+// might not run if there are too many instances running. Use
+// LastResult to check the result once the task finishes. Note
+// that on App Engine the parameters are ignored and the task
+// runs when the next cron request comes in.
+func (t *Task) Start(params map[string]string) {
+	t.start(params)
+}
+
 // Name returns the task name.
 func (t *Task) Name() string {
 	if t.Options != nil && t.Options.Name != "" {
 		return t.Options.Name
 	}
+	if t.fn != nil {
+		return runtimeutil.FuncName(t.fn.fn.Interface())
+	}
 	return runtimeutil.FuncName(t.Handler)
 }
 
+// Running returns the number of instances of this
+// task which are currently running.
+func (t *Task) Running() int {
+	running.Lock()
+	defer running.Unlock()
+	return running.tasks[t]
+}
+
 // Delete stops the task by calling t.Stop() and then removes
 // it from the internal task register.
 func (t *Task) Delete() {
@@ -113,9 +210,29 @@ type Options struct {
 	// this function that can be simultaneously running. If zero,
 	// there is no limit.
 	MaxInstances int
+	// Overflow indicates what happens when the task can't start
+	// because it's already running MaxInstances instances or
+	// there are no available workers (see SetMaxWorkers). The
+	// default is OverflowError.
+	Overflow Overflow
+	// MaxQueued indicates the maximum number of instances which
+	// might be waiting for a free slot when Overflow is OverflowWait.
+	// Instances exceeding this limit fail with an error. If zero,
+	// there is no limit.
+	MaxQueued int
+	// Lease is used to coordinate scheduled tasks when there are
+	// several instances of the same app running. If non-zero, every
+	// scheduled run must acquire a lease (see Locker) for this duration
+	// before running, so only the instance holding the lease runs it.
+	// The instance holding the lease renews it every time the task
+	// runs, so Lease must be greater than the task interval. If the
+	// instance holding the lease stops, other instance takes it over
+	// once it expires. Note that tasks run manually (e.g. with Run or
+	// Start) don't require the lease.
+	Lease time.Duration
 }
 
-func afterTask(ctx *app.Context, task *Task, started time.Time, terr *error) {
+func afterTask(ctx *app.Context, task *Task, res *Result, terr *error) {
 	name := task.Name()
 	if err := recover(); err != nil {
 		skip, stackSkip, _, _ := runtimeutil.GetPanic()
@@ -139,43 +256,44 @@ func afterTask(ctx *app.Context, task *Task, started time.Time, terr *error) {
 		*terr = errors.New(buf.String())
 	}
 	end := time.Now()
+	res.Duration = end.Sub(res.Started)
+	res.Error = *terr
+	recordExecution(ctx, task, res)
 	running.Lock()
 	defer running.Unlock()
-	c := running.tasks[task] - 1
-	if c > 0 {
-		running.tasks[task] = c
-	} else {
-		delete(running.tasks, task)
-	}
-	ctx.Logger().Infof("Finished task %s (%d instances now running) at %v (took %v)", name, c, end, end.Sub(started))
-}
-
-func numberOfInstances(task *Task) (int, error) {
-	running.Lock()
-	defer running.Unlock()
-	c := running.tasks[task]
-	if task.Options != nil && task.Options.MaxInstances > 0 {
-		if c >= task.Options.MaxInstances {
-			return 0, fmt.Errorf("not starting task %s because it's already running %d instances", task.Name(), c)
-		}
-	}
-	if running.tasks == nil {
-		running.tasks = make(map[*Task]int)
-	}
-	c++
-	running.tasks[task] = c
-	return c, nil
+	task.last = res
+	c := release(task)
+	ctx.Logger().Infof("Finished task %s (%d instances now running) at %v (took %v)", name, c, end, res.Duration)
 }
 
-func executeTask(ctx *app.Context, task *Task) (ran bool, err error) {
+// executeTask runs the given task in ctx. params are the parameters
+// the task was started with, while args are the typed parameters for
+// tasks with a function. If args is nil, they're parsed from ctx.
+func executeTask(ctx *app.Context, task *Task, params map[string]string, args interface{}) (ran bool, value interface{}, err error) {
 	var n int
-	if n, err = numberOfInstances(task); err != nil {
+	if n, err = acquire(task); err != nil {
+		if err == errDropped {
+			ctx.Logger().Debugf("Dropped task %s: %s", task.Name(), err)
+			err = nil
+		}
 		return
 	}
 	started := time.Now()
 	ctx.Logger().Infof("Starting task %s (%d instances now running) at %v", task.Name(), n, started)
 	ran = true
-	defer afterTask(ctx, task, started, &err)
+	res := &Result{Started: started, Params: params}
+	defer afterTask(ctx, task, res, &err)
+	if task.fn != nil {
+		if args == nil {
+			if args, err = task.fn.contextParams(ctx); err != nil {
+				return
+			}
+		}
+		res.Args = args
+		value, err = task.fn.call(ctx, args)
+		res.Value = value
+		return
+	}
 	task.Handler(ctx)
 	return
 }
@@ -185,7 +303,10 @@ func executeTask(ctx *app.Context, task *Task) (ran bool, err error) {
 // registered with the same name, it will panic (use Task.Delete
 // previously to remove it).
 func Register(m *app.App, task app.Handler, opts *Options) *Task {
-	t := &Task{App: m, Handler: task, Options: opts}
+	return register(&Task{App: m, Handler: task, Options: opts})
+}
+
+func register(t *Task) *Task {
 	registered.Lock()
 	defer registered.Unlock()
 	if registered.tasks == nil {
@@ -209,9 +330,12 @@ func Register(m *app.App, task app.Handler, opts *Options) *Task {
 //
 // Schedule returns a Task instance, which might be used to stop, resume or delete a it.
 func Schedule(m *app.App, task app.Handler, opts *Options, interval time.Duration, onListen bool) *Task {
-	t := Register(m, task, opts)
+	return schedule(Register(m, task, opts), interval, onListen)
+}
+
+func schedule(t *Task, interval time.Duration, onListen bool) *Task {
 	t.Interval = interval
-	go t.Resume(false)
+	t.Resume(false)
 	if onListen {
 		onListenTasks.Lock()
 		onListenTasks.tasks = append(onListenTasks.tasks, t)
@@ -220,6 +344,32 @@ func Schedule(m *app.App, task app.Handler, opts *Options, interval time.Duratio
 	return t
 }
 
+// Tasks returns all the registered tasks, sorted by name.
+func Tasks() []*Task {
+	registered.RLock()
+	tasks := make([]*Task, 0, len(registered.tasks))
+	for _, v := range registered.tasks {
+		tasks = append(tasks, v)
+	}
+	registered.RUnlock()
+	sort.Sort(tasksByName(tasks))
+	return tasks
+}
+
+// Lookup returns the registered task with the given name, or
+// nil if there's no such task.
+func Lookup(name string) *Task {
+	registered.RLock()
+	defer registered.RUnlock()
+	return registered.tasks[name]
+}
+
+type tasksByName []*Task
+
+func (t tasksByName) Len() int           { return len(t) }
+func (t tasksByName) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t tasksByName) Less(i, j int) bool { return t[i].Name() < t[j].Name() }
+
 // Run starts the given task identifier by it's name, unless
 // it has been previously registered with Options which
 // prevent from running it right now (e.g. it was registered
@@ -233,7 +383,8 @@ func Run(ctx *app.Context, name string) (bool, error) {
 	if task == nil {
 		return false, fmt.Errorf("there's no task registered with the name %q", name)
 	}
-	return executeTask(ctx, task)
+	ran, _, err := executeTask(ctx, task, nil, nil)
+	return ran, err
 }
 
 // RunHandler starts the given task identifier by it's handler. The same
@@ -253,14 +404,15 @@ func RunHandler(ctx *app.Context, handler app.Handler) (bool, error) {
 	if task == nil {
 		return false, fmt.Errorf("there's no task registered with the handler %s", runtimeutil.FuncName(handler))
 	}
-	return executeTask(ctx, task)
+	ran, _, err := executeTask(ctx, task, nil, nil)
+	return ran, err
 }
 
 // Execute runs the given handler in a task context. If the handler fails
 // with a panic, it will be returned in the error return value.
 func Execute(ctx *app.Context, handler app.Handler) error {
 	t := &Task{App: ctx.App(), Handler: handler}
-	_, err := executeTask(ctx, t)
+	_, _, err := executeTask(ctx, t, nil, nil)
 	return err
 }
 
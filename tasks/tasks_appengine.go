@@ -20,6 +20,12 @@ func (t *Task) executeTask() {
 	pendingTasks.Unlock()
 }
 
+func (t *Task) start(params map[string]string) {
+	// Tasks can't receive parameters on App Engine, since
+	// they run in the context of the cron request.
+	t.executeTask()
+}
+
 func gondolaRunTasksHandler(ctx *app.Context) {
 	if ctx.GetHeader("X-Appengine-Cron") != "true" {
 		ctx.Forbidden("")
@@ -29,7 +35,10 @@ func gondolaRunTasksHandler(ctx *app.Context) {
 	for _, v := range pendingTasks.tasks {
 		task := v
 		ctx.Go(func(c *app.Context) {
-			if _, err := executeTask(c, task); err != nil {
+			if !task.hasLease(c) {
+				return
+			}
+			if _, _, err := executeTask(c, task, nil, nil); err != nil {
 				ctx.Logger().Error(err)
 			}
 		})
@@ -2,11 +2,30 @@
 
 package tasks
 
+import (
+	"gnd.la/app"
+)
+
 func (t *Task) executeTask() {
-	ctx := t.App.NewContext(contextProvider(0))
+	t.executeTaskWithParams(nil, true)
+}
+
+func (t *Task) executeTaskWithParams(params map[string]string, scheduled bool) {
+	var p app.ContextProvider = contextProvider(0)
+	if params != nil {
+		p = paramsProvider(params)
+	}
+	ctx := t.App.NewContext(p)
 	defer t.App.CloseContext(ctx)
-	_, err := executeTask(ctx, t)
+	if scheduled && !t.hasLease(ctx) {
+		return
+	}
+	_, _, err := executeTask(ctx, t, params, nil)
 	if err != nil {
 		ctx.Logger().Error(err)
 	}
 }
+
+func (t *Task) start(params map[string]string) {
+	go t.executeTaskWithParams(params, false)
+}
@@ -0,0 +1,54 @@
+package tasks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"gnd.la/app"
+	"gnd.la/config"
+	"gnd.la/orm"
+	_ "gnd.la/orm/driver/sqlite"
+)
+
+// testApp is shared by all the tests, since the tasks
+// and the models are registered globally.
+var testApp *app.App
+
+func TestMain(m *testing.M) {
+	orm.Register(&Lease{}, &orm.Options{Table: "test_task_leases"})
+	orm.Register(&Execution{}, &orm.Options{Table: "test_task_executions"})
+	f, err := ioutil.TempFile("", "tasks-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	f.Close()
+	testApp = app.New()
+	testApp.Config().Database = config.MustParseURL("sqlite://" + f.Name())
+	testApp.Config().Cache = config.MustParseURL("memory://")
+	code := m.Run()
+	if o, err := testApp.Orm(); err == nil {
+		o.Close()
+	}
+	os.Remove(f.Name())
+	os.Exit(code)
+}
+
+// waitResult waits until the task finishes an execution
+// after prev and returns its result.
+func waitResult(t *testing.T, task *Task, prev *Result) *Result {
+	timeout := time.After(5 * time.Second)
+	for {
+		if res := task.LastResult(); res != nil && res != prev {
+			return res
+		}
+		select {
+		case <-timeout:
+			t.Fatalf("task %s did not finish", task.Name())
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
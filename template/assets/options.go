@@ -10,20 +10,11 @@ import (
 type Options map[string]string
 
 func ParseOptions(options string) (Options, error) {
-	values, err := stringutil.SplitFields(options, ",")
+	kv, err := stringutil.ParseKeyValues(options, ",")
 	if err != nil {
 		return nil, fmt.Errorf("error parsing asset options: %s", err)
 	}
-	opts := make(Options)
-	for _, v := range values {
-		eq := strings.IndexByte(v, '=')
-		if eq < 0 {
-			opts[v] = ""
-		} else {
-			opts[v[:eq]] = v[eq+1:]
-		}
-	}
-	return opts, nil
+	return Options(kv.Map()), nil
 }
 
 func (o Options) BoolOpt(key string) bool {
@@ -38,3 +38,23 @@ func RenderTo(w io.Writer, m *Manager, a *Asset) error {
 	_, err = io.WriteString(w, string(h))
 	return err
 }
+
+// Preload returns the value for a Link header which makes browsers
+// preload the given asset (e.g. </css/style.css>; rel=preload; as=style).
+// Only CSS and Javascript assets without conditions can be preloaded,
+// for other assets an empty string is returned.
+func Preload(m *Manager, a *Asset) string {
+	if a.Condition != nil || a.Name == "" {
+		return ""
+	}
+	var as string
+	switch a.Type {
+	case TypeCSS:
+		as = "style"
+	case TypeJavascript:
+		as = "script"
+	default:
+		return ""
+	}
+	return fmt.Sprintf("<%s>; rel=preload; as=%s", m.URL(a.Name), as)
+}
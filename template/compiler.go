@@ -840,6 +840,12 @@ func (s *scratch) argc() int {
 	var argc int
 	if len(s.cmd) > 0 {
 		argc = s.cmd[len(s.cmd)-1]
+		if argc < 0 {
+			// Not the first node in the command, so it's
+			// an argument to another function/field and
+			// it's called without arguments.
+			return 0
+		}
 		if len(s.pipe) > 0 && s.pipe[len(s.pipe)-1] > 0 {
 			argc++
 		}
@@ -1093,7 +1099,11 @@ func (p *program) walk(n parse.Node) error {
 		argc := 0
 		p.s.cmd = append(p.s.cmd, argc)
 		for ii := len(x.Args) - 1; ii >= 0; ii-- {
-			p.s.cmd[len(p.s.cmd)-1] = argc
+			if ii == 0 {
+				p.s.cmd[len(p.s.cmd)-1] = argc
+			} else {
+				p.s.cmd[len(p.s.cmd)-1] = -1
+			}
 			node := x.Args[ii]
 			if err := p.walk(node); err != nil {
 				return err
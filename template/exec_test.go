@@ -394,6 +394,9 @@ var execTests = []execTest{
 	{"printf dot", `{{with .I}}{{printf "%d" .}}{{end}}`, "17", tVal, true},
 	{"printf var", `{{with $x := .I}}{{printf "%d" $x}}{{end}}`, "17", tVal, true},
 	{"printf lots", `{{printf "%d %s %g %s" 127 "hello" 7-3i .Method0}}`, "127 hello (7-3i) M0", tVal, true},
+	{"printf method arg", `{{printf "%s %s" .Method0 .X}}`, "M0 x", tVal, true},
+	{"printf method arg in pipeline", `{{.X | printf "%s %s" .Method0}}`, "M0 x", tVal, true},
+	{"eq method arg", `{{eq .Method0 "M0"}}`, "true", tVal, true},
 
 	// HTML.
 	{"html", `{{html "<script>alert(\"XSS\");</script>"}}`,
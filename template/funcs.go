@@ -14,6 +14,8 @@ import (
 
 	"gnd.la/app/serialize"
 	"gnd.la/html"
+	"gnd.la/i18n"
+	"gnd.la/util/humanize"
 	"gnd.la/util/types"
 )
 
@@ -288,6 +290,38 @@ func toHtml(s string) template.HTML {
 	return template.HTML(strings.Replace(html.Escape(s), "\n", "<br>", -1))
 }
 
+func bytesSize(size interface{}) (string, error) {
+	s, err := types.ToUint64(size)
+	if err != nil {
+		return "", err
+	}
+	return humanize.Bytes(s), nil
+}
+
+func ibytesSize(size interface{}) (string, error) {
+	s, err := types.ToUint64(size)
+	if err != nil {
+		return "", err
+	}
+	return humanize.IBytes(s), nil
+}
+
+func shortNumber(n interface{}) (string, error) {
+	v, err := types.ToInt64(n)
+	if err != nil {
+		return "", err
+	}
+	return humanize.ShortNumber(v), nil
+}
+
+func ordinal(lang i18n.Languager, n interface{}) (string, error) {
+	v, err := types.ToInt(n)
+	if err != nil {
+		return "", err
+	}
+	return humanize.Ordinal(lang, v), nil
+}
+
 func getVar(s *State, name string) interface{} {
 	v, ok := s.Var(name)
 	if !ok || !v.IsValid() {
@@ -380,6 +414,14 @@ var templateFuncs = makeFuncMap(FuncMap{
 	// Converts plain text to HTML by escaping it and replacing
 	// newlines with <br> tags.
 	"#to_html": toHtml,
+	// Return the given size in bytes formatted using SI units (e.g. 1.2 MB).
+	"#bytes": bytesSize,
+	// Return the given size in bytes formatted using binary units (e.g. 1.2 MiB).
+	"#ibytes": ibytesSize,
+	// Return the given number abbreviated (e.g. 1.2k).
+	"#short_number": shortNumber,
+	// Return the ordinal for the given number in the current language (e.g. 1st).
+	"!ordinal": ordinal,
 
 	// !state manipulation functions
 
@@ -0,0 +1,193 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template/parse"
+
+	"gnd.la/internal/templateutil"
+)
+
+// LintIssue represents a potential problem found by Template.Lint.
+type LintIssue struct {
+	// Template is the name of the template or block
+	// where the issue was found.
+	Template string
+	// Location is the position of the issue, in the form
+	// file:line. It might be empty.
+	Location string
+	// Message describes the issue.
+	Message string
+}
+
+func (i *LintIssue) String() string {
+	if i.Location != "" {
+		return i.Location + ": " + i.Message
+	}
+	return i.Template + ": " + i.Message
+}
+
+// LintOptions specifies the options for Template.Lint.
+type LintOptions struct {
+	// Types contains the types of the variables passed to the
+	// template, by name (e.g. "Ctx"). Variables found here are
+	// checked for references to undefined fields or methods
+	// (e.g. @Ctx.Usr instead of @Ctx.User).
+	Types map[string]reflect.Type
+	// Blocks lists blocks which are executed directly, rather
+	// than from another template (see ExecuteTemplateContext),
+	// so they're not reported as unused.
+	Blocks []string
+}
+
+// Lint performs some checks on the template, looking for common mistakes
+// which can't be detected while parsing it. Currently, these include:
+//
+//   - References to undefined variables, when the variables were provided
+//     while parsing the template (see ParseVars).
+//   - References to undefined fields or methods in variables with known
+//     types (see LintOptions.Types).
+//   - Blocks which are defined but never used.
+//   - Calls to undefined functions.
+//
+// Note that Lint might report false positives (e.g. a block which is
+// only used by templates extending this one), so its results should be
+// reviewed rather than treated as errors. Issues are returned sorted by
+// location.
+func (t *Template) Lint(opts *LintOptions) []*LintIssue {
+	var types map[string]reflect.Type
+	ignored := make(map[string]bool)
+	if opts != nil {
+		types = opts.Types
+		for _, v := range opts.Blocks {
+			ignored[v] = true
+		}
+	}
+	var issues []*LintIssue
+	referenced := make(map[string]bool)
+	t.walkTrees(parse.NodeTemplate, func(n parse.Node) {
+		referenced[strings.TrimRight(n.(*parse.TemplateNode).Name, "_")] = true
+	})
+	for name, tree := range t.trees {
+		add := func(n parse.Node, format string, args ...interface{}) {
+			issue := &LintIssue{Template: name, Message: fmt.Sprintf(format, args...)}
+			if n != nil {
+				loc, _ := tree.ErrorContext(n)
+				if file, line, _, ok := splitErrorContext(loc); ok {
+					loc = fmt.Sprintf("%s:%d", file, line)
+				}
+				issue.Location = loc
+			}
+			issues = append(issues, issue)
+		}
+		if name != "" && name != t.root && !strings.HasPrefix(name, "_gondola") {
+			base := strings.TrimRight(name, "_")
+			if !referenced[base] && !ignored[base] {
+				add(nil, "block %q is defined but never used", base)
+			}
+		}
+		vars := t.treeVars[tree]
+		templateutil.WalkTree(tree, func(n, p parse.Node) {
+			switch x := n.(type) {
+			case *parse.IdentifierNode:
+				if t.funcMap[x.Ident] == nil {
+					add(n, "undefined function %q", x.Ident)
+				}
+			case *parse.VariableNode:
+				if len(x.Ident) < 2 || x.Ident[0] != "$"+varsKey {
+					return
+				}
+				varName := x.Ident[1]
+				if typ, ok := types[varName]; ok {
+					if path, field := lintFields(typ, x.Ident[2:]); field != "" {
+						add(n, "@%s has no field or method %q", strings.Join(append([]string{varName}, path...), "."), field)
+					}
+					return
+				}
+				if vars != nil {
+					if _, ok := vars[varName]; !ok {
+						add(n, "undefined variable @%s", varName)
+					}
+				}
+			}
+		})
+	}
+	sort.Sort(lintIssues(issues))
+	return issues
+}
+
+// lintFields checks that the fields or methods in names can be
+// resolved starting at typ. If there's an undefined one, it returns
+// the names before it and the undefined name.
+func lintFields(typ reflect.Type, names []string) ([]string, string) {
+	for ii, name := range names {
+		if typ == nil {
+			break
+		}
+		next, ok := lintField(typ, name)
+		if !ok {
+			return names[:ii], name
+		}
+		typ = next
+	}
+	return nil, ""
+}
+
+// lintField returns the type of the given field or method in typ.
+// If the type can't be determined (e.g. with maps or empty interfaces)
+// the returned type is nil.
+func lintField(typ reflect.Type, name string) (reflect.Type, bool) {
+	if m, ok := typ.MethodByName(name); ok {
+		return lintMethodType(m.Type), true
+	}
+	if typ.Kind() != reflect.Interface {
+		if m, ok := reflect.PtrTo(typ).MethodByName(name); ok {
+			return lintMethodType(m.Type), true
+		}
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch typ.Kind() {
+	case reflect.Struct:
+		if f, ok := typ.FieldByName(name); ok {
+			return f.Type, true
+		}
+		return nil, false
+	case reflect.Map:
+		// Keys can't be checked
+		return nil, true
+	case reflect.Interface:
+		// Empty interfaces can't be checked
+		return nil, typ.NumMethod() == 0
+	}
+	return nil, false
+}
+
+func lintMethodType(typ reflect.Type) reflect.Type {
+	if typ.NumOut() > 0 {
+		return typ.Out(0)
+	}
+	return nil
+}
+
+type lintIssues []*LintIssue
+
+func (l lintIssues) Len() int      { return len(l) }
+func (l lintIssues) Swap(i, j int) { l[i], l[j] = l[j], l[i] }
+func (l lintIssues) Less(i, j int) bool {
+	fi, li, _, _ := splitErrorContext(l[i].Location + ":0")
+	fj, lj, _, _ := splitErrorContext(l[j].Location + ":0")
+	if fi != fj {
+		return fi < fj
+	}
+	if li != lj {
+		return li < lj
+	}
+	if l[i].Template != l[j].Template {
+		return l[i].Template < l[j].Template
+	}
+	return l[i].Message < l[j].Message
+}
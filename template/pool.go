@@ -3,12 +3,15 @@ package template
 import (
 	"bytes"
 
+	bpool "gnd.la/internal/pool"
+
 	"gopkgs.com/pool.v1"
 )
 
+const bufSize = 4096
+
 var (
 	statePool = pool.New(0)
-	bufPool   = pool.New(0)
 )
 
 func getState() *State {
@@ -21,17 +24,11 @@ func putState(s *State) {
 }
 
 func getBuffer() *bytes.Buffer {
-	v := bufPool.Get()
-	if v != nil {
-		b := v.(*bytes.Buffer)
-		b.Reset()
-		return b
-	}
-	return new(bytes.Buffer)
+	return bpool.Get(bufSize)
 }
 
 func putBuffer(buf *bytes.Buffer) {
-	bufPool.Put(buf)
+	bpool.Put(buf)
 }
 
 func newBuffer() interface{} {
@@ -174,6 +174,7 @@ type Template struct {
 	fs            vfs.VFS
 	trees         map[string]*parse.Tree
 	offsets       map[*parse.Tree]map[int]int
+	treeVars      map[*parse.Tree]VarMap
 	final         bool
 	funcMap       funcMap
 	vars          VarMap
@@ -181,6 +182,7 @@ type Template struct {
 	assetGroups   []*assets.Group
 	topAssets     []byte
 	bottomAssets  []byte
+	preloads      []string
 	contentType   string
 	hooks         []*Hook
 	children      []*Template
@@ -248,6 +250,14 @@ func (t *Template) Assets() []*assets.Group {
 	return t.assetGroups
 }
 
+// Preloads returns the Link header values which make browsers
+// preload the CSS and Javascript assets used by the template, in
+// the same order they appear in the rendered document. It's only
+// available after the template has been compiled.
+func (t *Template) Preloads() []string {
+	return t.preloads
+}
+
 func (t *Template) AddAssets(groups []*assets.Group) error {
 	if err := t.noCompiled("can't add assets"); err != nil {
 		return err
@@ -508,6 +518,7 @@ func (t *Template) prepareAssets() error {
 	}
 	var top bytes.Buffer
 	var bottom bytes.Buffer
+	var preloads []string
 	for _, group := range groups {
 		// Only bundle and use CDNs in non-debug mode
 		if !t.Debug {
@@ -551,6 +562,9 @@ func (t *Template) prepareAssets() error {
 		}
 		for _, g := range group {
 			for _, v := range g.Assets {
+				if p := assets.Preload(g.Manager, v); p != "" {
+					preloads = append(preloads, p)
+				}
 				switch v.Position {
 				case assets.Top:
 					if err := assets.RenderTo(&top, g.Manager, v); err != nil {
@@ -570,6 +584,7 @@ func (t *Template) prepareAssets() error {
 	}
 	t.topAssets = top.Bytes()
 	t.bottomAssets = bottom.Bytes()
+	t.preloads = preloads
 	return nil
 }
 
@@ -849,6 +864,12 @@ func (t *Template) load(name string, included bool, from string) error {
 	var renames map[string]string
 	for k, v := range treeMap {
 		v.Root.Nodes = t.removeVarNopNodes(v, v.Root.Nodes)
+		if t.vars != nil {
+			if t.treeVars == nil {
+				t.treeVars = make(map[*parse.Tree]VarMap)
+			}
+			t.treeVars[v] = t.vars
+		}
 		if _, contains := t.trees[k]; contains {
 			log.Debugf("Template %s redefined", k)
 			// Redefinition of a template, which is allowed
@@ -1086,6 +1107,28 @@ func (t *Template) Execute(w io.Writer, data interface{}) error {
 }
 
 func (t *Template) ExecuteContext(w io.Writer, data interface{}, context interface{}, vars VarMap) error {
+	return t.executeTemplate(w, t.root, data, context, vars)
+}
+
+// ExecuteTemplateContext works like ExecuteContext, but executes the
+// template (usually a block) with the given name, rather than the root
+// template. If there's no template with the given name, an error is
+// returned.
+func (t *Template) ExecuteTemplateContext(w io.Writer, name string, data interface{}, context interface{}, vars VarMap) error {
+	if t.prog == nil {
+		return errors.New("template is not compiled")
+	}
+	if _, ok := t.prog.code[name]; !ok {
+		qname := t.qname(name)
+		if _, ok := t.prog.code[qname]; !ok {
+			return fmt.Errorf("no template named %q in %s", name, t.name)
+		}
+		name = qname
+	}
+	return t.executeTemplate(w, name, data, context, vars)
+}
+
+func (t *Template) executeTemplate(w io.Writer, name string, data interface{}, context interface{}, vars VarMap) error {
 	if profile.On && profile.Profiling() {
 		ev := profile.Start("template").Note("exec", t.qname(t.name))
 		defer ev.End()
@@ -1095,7 +1138,7 @@ func (t *Template) ExecuteContext(w io.Writer, data interface{}, context interfa
 		ev.AutoEnd()
 	}
 	buf := getBuffer()
-	err := t.prog.execute(buf, t.root, data, context, vars)
+	err := t.prog.execute(buf, name, data, context, vars)
 	if err != nil {
 		return err
 	}
@@ -6,6 +6,7 @@ import (
 	"html/template"
 	"io/ioutil"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -379,3 +380,102 @@ func BenchmarkRange(b *testing.B) {
 func BenchmarkRangeGo(b *testing.B) {
 	benchmarkHTMLTemplate(b, rangeTests())
 }
+
+func TestPreloads(t *testing.T) {
+	const text = `{{/*
+  styles: style.css
+  scripts|top: app.js
+  scripts|if=ie-lt-9: https://example.com/html5shiv.js
+*/}}<html><head></head><body></body></html>`
+	fs, err := vfs.Map(map[string]*vfs.File{
+		"template.html": &vfs.File{Data: []byte(text)},
+		"style.css":     &vfs.File{Data: []byte("body { color: red; }")},
+		"app.js":        &vfs.File{Data: []byte("alert(1);")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := New(fs, assets.New(fs, "/assets/"))
+	tmpl.Debug = true
+	if err := tmpl.Parse("template.html"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tmpl.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	preloads := tmpl.Preloads()
+	if len(preloads) != 2 {
+		t.Fatalf("expecting 2 preloads, got %v", preloads)
+	}
+	for ii, v := range []string{"rel=preload; as=style", "rel=preload; as=script"} {
+		if !strings.HasPrefix(preloads[ii], "</assets/") || !strings.HasSuffix(preloads[ii], ">; "+v) {
+			t.Errorf("unexpected preload %q, expecting %s", preloads[ii], v)
+		}
+	}
+}
+
+func TestExecuteTemplateContext(t *testing.T) {
+	tmpl := parseNamedText(t, "blocks", `{{ define "greeting" }}Hello {{ . }}{{ end }}page: {{ template "greeting" . }}`, nil, "text/plain")
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplateContext(&buf, "greeting", "world", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "Hello world"; buf.String() != expected {
+		t.Errorf("expecting %q, got %q instead", expected, buf.String())
+	}
+	if err := tmpl.ExecuteTemplateContext(ioutil.Discard, "missing", nil, nil, nil); err == nil {
+		t.Error("expecting an error when executing a missing template")
+	}
+}
+
+type lintContext struct {
+	User string
+}
+
+func (c *lintContext) Language() string { return "en" }
+
+func TestLint(t *testing.T) {
+	const text = `{{ define "used" }}{{ @Ctx.User }}{{ end }}{{ define "unused" }}{{ @Ctx.Usr }}{{ end }}
+{{ template "used" . }}{{ @Title }}
+{{ @Missing }}{{ @Ctx.Language.Foo }}`
+	fs, err := vfs.Map(map[string]*vfs.File{"template.html": &vfs.File{Data: []byte(text)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := New(fs, nil)
+	if err := tmpl.ParseVars("template.html", VarMap{"Ctx": nil, "Title": nil}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tmpl.Compile(); err != nil {
+		t.Fatal(err)
+	}
+	issues := tmpl.Lint(&LintOptions{Types: map[string]reflect.Type{"Ctx": reflect.TypeOf((*lintContext)(nil))}})
+	var messages []string
+	for _, v := range issues {
+		messages = append(messages, v.Message)
+	}
+	expected := []string{
+		`block "unused" is defined but never used`,
+		`@Ctx has no field or method "Usr"`,
+		`@Ctx.Language has no field or method "Foo"`,
+		`undefined variable @Missing`,
+	}
+	if len(messages) != len(expected) {
+		t.Fatalf("expecting issues %q, got %q", expected, messages)
+	}
+	for _, v := range expected {
+		found := false
+		for _, m := range messages {
+			if m == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("missing issue %q, got %q", v, messages)
+		}
+	}
+	if issues := tmpl.Lint(&LintOptions{Blocks: []string{"unused"}}); len(issues) != 1 {
+		t.Errorf("expecting only the undefined variable issue, got %v", issues)
+	}
+}
@@ -0,0 +1,14 @@
+// Package trace implements lightweight distributed tracing
+// compatible with OpenTelemetry.
+//
+// Spans are propagated using context.Context values and
+// between processes using W3C Trace Context (traceparent)
+// headers. Finished spans are handed to an Exporter, usually
+// an OTLP exporter created with NewOTLPExporter, which sends
+// them to an OpenTelemetry collector.
+//
+// Users will usually just set the Tracing field in the
+// gnd.la/app Config, which makes the App start a span for
+// each request. Other packages (e.g. gnd.la/orm) create child
+// spans using Start with the request context.
+package trace
@@ -0,0 +1,179 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	otlpTracesPath = "/v1/traces"
+	otlpScope      = "gnd.la/trace"
+
+	otlpStatusError = 2
+)
+
+// OTLPExporter is an Exporter which sends spans to an
+// OpenTelemetry collector using OTLP over HTTP, with JSON
+// encoding.
+type OTLPExporter struct {
+	// Endpoint is the URL spans are POST'ed to.
+	Endpoint string
+	// Headers are added to each request (e.g. for
+	// authentication).
+	Headers http.Header
+	// Client is the *http.Client used for sending the
+	// spans. If nil, a client with a 10 seconds timeout
+	// is used.
+	Client *http.Client
+}
+
+// NewOTLPExporter returns a new *OTLPExporter for the given
+// collector endpoint. If the endpoint has no path,
+// /v1/traces is used, as the OTLP specification requires.
+func NewOTLPExporter(endpoint string) (*OTLPExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q, scheme must be http or https", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = otlpTracesPath
+	}
+	return &OTLPExporter{Endpoint: u.String()}, nil
+}
+
+var defaultOTLPClient = &http.Client{Timeout: 10 * time.Second}
+
+// Export implements the Exporter interface.
+func (e *OTLPExporter) Export(service string, spans []*Span) error {
+	data, err := json.Marshal(otlpRequest(service, spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", e.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for k, v := range e.Headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := e.Client
+	if client == nil {
+		client = defaultOTLPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("OTLP endpoint %s returned status %d", e.Endpoint, resp.StatusCode)
+	}
+	return nil
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              Kind            `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+func otlpRequest(service string, spans []*Span) interface{} {
+	converted := make([]*otlpSpan, len(spans))
+	for ii, s := range spans {
+		converted[ii] = newOTLPSpan(s)
+	}
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": []otlpAttribute{otlpAttr("service.name", service)},
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]string{"name": otlpScope},
+						"spans": converted,
+					},
+				},
+			},
+		},
+	}
+}
+
+func newOTLPSpan(s *Span) *otlpSpan {
+	sc := s.SpanContext()
+	sp := &otlpSpan{
+		TraceID:           sc.TraceID.String(),
+		SpanID:            sc.SpanID.String(),
+		Name:              s.Name(),
+		Kind:              s.Kind(),
+		StartTimeUnixNano: strconv.FormatInt(s.StartTime().UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.EndTime().UnixNano(), 10),
+	}
+	if p := s.Parent(); !p.IsZero() {
+		sp.ParentSpanID = p.String()
+	}
+	for k, v := range s.Attributes() {
+		sp.Attributes = append(sp.Attributes, otlpAttr(k, v))
+	}
+	if err := s.Err(); err != nil {
+		sp.Status = otlpStatus{Code: otlpStatusError, Message: err.Error()}
+	}
+	return sp
+}
+
+func otlpAttr(key string, value interface{}) otlpAttribute {
+	var v otlpValue
+	switch x := value.(type) {
+	case string:
+		v.StringValue = &x
+	case bool:
+		v.BoolValue = &x
+	case int:
+		s := strconv.Itoa(x)
+		v.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(x, 10)
+		v.IntValue = &s
+	case float64:
+		v.DoubleValue = &x
+	default:
+		s := fmt.Sprint(x)
+		v.StringValue = &s
+	}
+	return otlpAttribute{Key: key, Value: v}
+}
@@ -0,0 +1,218 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"gnd.la/log"
+)
+
+const (
+	otlpLogsPath = "/v1/logs"
+)
+
+var otlpSeverities = map[log.LLevel]int{
+	log.LDebug:   5,
+	log.LInfo:    9,
+	log.LWarning: 13,
+	log.LError:   17,
+	log.LPanic:   18,
+	log.LFatal:   21,
+}
+
+// OTLPLogWriter is a gnd.la/log.Writer which sends the log records
+// to an OpenTelemetry collector using OTLP over HTTP, with JSON
+// encoding. Records are batched like spans are by a Tracer.
+//
+// When messages are logged with a context.Context containing a
+// span (e.g. using gnd.la/app.Context.Logger while tracing is
+// enabled), the records include its trace and span ids, so the
+// backend can correlate them with the request trace.
+type OTLPLogWriter struct {
+	// Endpoint is the URL records are POST'ed to.
+	Endpoint string
+	// Headers are added to each request (e.g. for
+	// authentication).
+	Headers http.Header
+	// Client is the *http.Client used for sending the
+	// records. If nil, a client with a 10 seconds timeout
+	// is used.
+	Client *http.Client
+	// BatchSize is the maximum number of records sent in
+	// a single request. If zero, 512 is used.
+	BatchSize int
+	// FlushInterval is the maximum time a record waits
+	// before being sent. If zero, 5 seconds is used.
+	FlushInterval time.Duration
+	service       string
+	level         log.LLevel
+	mu            sync.Mutex
+	pending       []*otlpLogRecord
+	timer         *time.Timer
+}
+
+// NewOTLPLogWriter returns a new *OTLPLogWriter for the given service
+// name and collector endpoint, which sends the records with at least
+// the given level. If the endpoint has no path, /v1/logs is used, as
+// the OTLP specification requires.
+func NewOTLPLogWriter(service string, endpoint string, level log.LLevel) (*OTLPLogWriter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q, scheme must be http or https", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = otlpLogsPath
+	}
+	return &OTLPLogWriter{Endpoint: u.String(), service: service, level: level}, nil
+}
+
+// Level implements the gnd.la/log.Writer interface.
+func (w *OTLPLogWriter) Level() log.LLevel {
+	return w.level
+}
+
+// Write implements the gnd.la/log.Writer interface.
+func (w *OTLPLogWriter) Write(level log.LLevel, flags int, b []byte) (int, error) {
+	return w.WriteContext(nil, level, flags, b)
+}
+
+// WriteContext implements the gnd.la/log.ContextWriter interface. If
+// ctx contains a span, its trace and span ids are added to the record.
+func (w *OTLPLogWriter) WriteContext(ctx context.Context, level log.LLevel, flags int, b []byte) (int, error) {
+	rec := &otlpLogRecord{
+		TimeUnixNano:   strconv.FormatInt(time.Now().UnixNano(), 10),
+		SeverityNumber: otlpSeverities[level],
+		SeverityText:   level.String(),
+		Body:           otlpAttr("", string(bytes.TrimRight(b, "\n"))).Value,
+	}
+	if ctx != nil {
+		if s := FromContext(ctx); s != nil {
+			rec.TraceID = s.sc.TraceID.String()
+			rec.SpanID = s.sc.SpanID.String()
+		}
+	}
+	w.enqueue(rec)
+	return len(b), nil
+}
+
+func (w *OTLPLogWriter) batchSize() int {
+	if w.BatchSize > 0 {
+		return w.BatchSize
+	}
+	return defaultBatchSize
+}
+
+func (w *OTLPLogWriter) flushInterval() time.Duration {
+	if w.FlushInterval > 0 {
+		return w.FlushInterval
+	}
+	return defaultFlushInterval
+}
+
+func (w *OTLPLogWriter) enqueue(rec *otlpLogRecord) {
+	w.mu.Lock()
+	w.pending = append(w.pending, rec)
+	full := len(w.pending) >= w.batchSize()
+	if !full && w.timer == nil {
+		w.timer = time.AfterFunc(w.flushInterval(), func() { w.Flush() })
+	}
+	w.mu.Unlock()
+	if full {
+		go w.Flush()
+	}
+}
+
+// Flush sends all the records which haven't been sent yet.
+// Applications should call Flush before exiting, to avoid
+// losing records. Note that errors are not logged, since
+// that could produce more records to be sent.
+func (w *OTLPLogWriter) Flush() error {
+	w.mu.Lock()
+	records := w.pending
+	w.pending = nil
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.mu.Unlock()
+	size := w.batchSize()
+	for len(records) > 0 {
+		n := size
+		if n > len(records) {
+			n = len(records)
+		}
+		if err := w.export(records[:n]); err != nil {
+			return err
+		}
+		records = records[n:]
+	}
+	return nil
+}
+
+func (w *OTLPLogWriter) export(records []*otlpLogRecord) error {
+	data, err := json.Marshal(otlpLogsRequest(w.service, records))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", w.Endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for k, v := range w.Headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = defaultOTLPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("OTLP endpoint %s returned status %d", w.Endpoint, resp.StatusCode)
+	}
+	return nil
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano   string    `json:"timeUnixNano"`
+	SeverityNumber int       `json:"severityNumber,omitempty"`
+	SeverityText   string    `json:"severityText"`
+	Body           otlpValue `json:"body"`
+	TraceID        string    `json:"traceId,omitempty"`
+	SpanID         string    `json:"spanId,omitempty"`
+}
+
+func otlpLogsRequest(service string, records []*otlpLogRecord) interface{} {
+	return map[string]interface{}{
+		"resourceLogs": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": []otlpAttribute{otlpAttr("service.name", service)},
+				},
+				"scopeLogs": []interface{}{
+					map[string]interface{}{
+						"scope":      map[string]string{"name": otlpScope},
+						"logRecords": records,
+					},
+				},
+			},
+		},
+	}
+}
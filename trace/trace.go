@@ -0,0 +1,356 @@
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kind indicates the role of a span in a trace. Their values
+// match the ones used by OpenTelemetry.
+type Kind int
+
+const (
+	KindInternal Kind = iota + 1
+	KindServer
+	KindClient
+	KindProducer
+	KindConsumer
+)
+
+const (
+	// TraceparentHeader is the name of the W3C Trace
+	// Context header.
+	TraceparentHeader = "traceparent"
+	// FlagSampled is set in SpanContext.Flags when the trace
+	// is being recorded.
+	FlagSampled = 0x01
+)
+
+var (
+	errInvalidTraceparent = errors.New("invalid traceparent")
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// IsZero returns true iff all the bytes in the id are zero.
+func (t TraceID) IsZero() bool {
+	return t == TraceID{}
+}
+
+// String returns the id encoded as lowercase hex.
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+// IsZero returns true iff all the bytes in the id are zero.
+func (s SpanID) IsZero() bool {
+	return s == SpanID{}
+}
+
+// String returns the id encoded as lowercase hex.
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext contains the information which is propagated
+// between processes.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Flags   byte
+}
+
+// IsValid returns true iff both the TraceID and the SpanID
+// are non-zero.
+func (s SpanContext) IsValid() bool {
+	return !s.TraceID.IsZero() && !s.SpanID.IsZero()
+}
+
+// Traceparent returns the SpanContext formatted as a W3C
+// traceparent header value.
+func (s SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", s.TraceID, s.SpanID, s.Flags)
+}
+
+// ParseTraceparent parses a W3C traceparent header value. Versions
+// other than 00 are accepted as long as their first 4 fields
+// have the expected format, as required by the specification.
+func ParseTraceparent(s string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 ||
+		len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, errInvalidTraceparent
+	}
+	if parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, errInvalidTraceparent
+	}
+	var version, flags [1]byte
+	if err := decodeHex(version[:], parts[0]); err != nil {
+		return sc, err
+	}
+	if err := decodeHex(sc.TraceID[:], parts[1]); err != nil {
+		return sc, err
+	}
+	if err := decodeHex(sc.SpanID[:], parts[2]); err != nil {
+		return sc, err
+	}
+	if err := decodeHex(flags[:], parts[3]); err != nil {
+		return sc, err
+	}
+	sc.Flags = flags[0]
+	if !sc.IsValid() {
+		return SpanContext{}, errInvalidTraceparent
+	}
+	return sc, nil
+}
+
+func decodeHex(dst []byte, s string) error {
+	// Only lowercase is allowed by the spec
+	if strings.ToLower(s) != s {
+		return errInvalidTraceparent
+	}
+	if _, err := hex.Decode(dst, []byte(s)); err != nil {
+		return errInvalidTraceparent
+	}
+	return nil
+}
+
+// Extract returns the SpanContext in the traceparent header
+// from h. If the header is missing or invalid, the returned
+// SpanContext is not valid.
+func Extract(h http.Header) SpanContext {
+	sc, _ := ParseTraceparent(h.Get(TraceparentHeader))
+	return sc
+}
+
+// Inject sets the traceparent header in h to the span in ctx. If
+// ctx contains no span, h is not modified.
+func Inject(ctx context.Context, h http.Header) {
+	if s := FromContext(ctx); s != nil {
+		h.Set(TraceparentHeader, s.SpanContext().Traceparent())
+	}
+}
+
+// Span represents a unit of work. All Span methods might
+// be safely called on a nil *Span, so code creating spans
+// doesn't need to check if tracing is enabled.
+type Span struct {
+	tracer     *Tracer
+	sc         SpanContext
+	parent     SpanID
+	kind       Kind
+	start      time.Time
+	mu         sync.Mutex
+	name       string
+	end        time.Time
+	attributes map[string]interface{}
+	err        error
+}
+
+// Name returns the span name.
+func (s *Span) Name() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.name
+}
+
+// SetName changes the span name.
+func (s *Span) SetName(name string) {
+	if s != nil {
+		s.mu.Lock()
+		s.name = name
+		s.mu.Unlock()
+	}
+}
+
+// SpanContext returns the propagable information of the span.
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// Parent returns the SpanID of the parent span, which
+// is zero for root spans.
+func (s *Span) Parent() SpanID {
+	if s == nil {
+		return SpanID{}
+	}
+	return s.parent
+}
+
+// SetAttribute sets the attribute with the given key. Values
+// should be strings, bools or numbers. Any other type will
+// be formatted using fmt.Sprint when exporting the span.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s != nil {
+		s.mu.Lock()
+		if s.attributes == nil {
+			s.attributes = make(map[string]interface{})
+		}
+		s.attributes[key] = value
+		s.mu.Unlock()
+	}
+}
+
+// Attribute returns the attribute with the given key, or nil
+// if there's no such attribute.
+func (s *Span) Attribute(key string) interface{} {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attributes[key]
+}
+
+// SetError marks the span as failed. Passing a nil error
+// does nothing.
+func (s *Span) SetError(err error) {
+	if s != nil && err != nil {
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+	}
+}
+
+// Err returns the error set with SetError, if any.
+func (s *Span) Err() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Kind returns the span Kind.
+func (s *Span) Kind() Kind {
+	if s == nil {
+		return 0
+	}
+	return s.kind
+}
+
+// StartTime returns the time when the span was started.
+func (s *Span) StartTime() time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	return s.start
+}
+
+// EndTime returns the time when the span was ended, or the
+// zero time.Time if it hasn't ended yet.
+func (s *Span) EndTime() time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.end
+}
+
+// Attributes returns a copy of the span attributes.
+func (s *Span) Attributes() map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attrs := make(map[string]interface{}, len(s.attributes))
+	for k, v := range s.attributes {
+		attrs[k] = v
+	}
+	return attrs
+}
+
+// Duration returns the span duration. For spans
+// which haven't ended yet, it returns zero.
+func (s *Span) Duration() time.Duration {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.end.IsZero() {
+		return 0
+	}
+	return s.end.Sub(s.start)
+}
+
+// End finishes the span and hands it to the Tracer for
+// exporting it. Calling End more than once has no effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	ended := !s.end.IsZero()
+	if !ended {
+		s.end = time.Now()
+	}
+	s.mu.Unlock()
+	if !ended && s.sc.Flags&FlagSampled != 0 {
+		s.tracer.enqueue(s)
+	}
+}
+
+type contextKey struct{}
+
+// FromContext returns the Span stored in ctx, or nil.
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(contextKey{}).(*Span)
+	return s
+}
+
+// NewContext returns a new context.Context with the given Span.
+func NewContext(ctx context.Context, s *Span) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// Start starts a child span of the span in ctx, using the same
+// Tracer. If ctx contains no span, tracing is not enabled for
+// the current operation and Start returns ctx and a nil *Span,
+// which can still be used safely.
+func Start(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	parent := FromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	s := parent.tracer.newSpan(name, kind, parent.sc)
+	return NewContext(ctx, s), s
+}
+
+func newSpanID() (id SpanID) {
+	for id.IsZero() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func newTraceID() (id TraceID) {
+	for id.IsZero() {
+		rand.Read(id[:])
+	}
+	return id
+}
@@ -0,0 +1,163 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"gnd.la/log"
+)
+
+type recordExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (r *recordExporter) Export(service string, spans []*Span) error {
+	r.mu.Lock()
+	r.spans = append(r.spans, spans...)
+	r.mu.Unlock()
+	return nil
+}
+
+func TestParseTraceparent(t *testing.T) {
+	const valid = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceparent(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := sc.TraceID.String(); s != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("bad trace id %s", s)
+	}
+	if s := sc.SpanID.String(); s != "00f067aa0ba902b7" {
+		t.Errorf("bad span id %s", s)
+	}
+	if sc.Flags != FlagSampled {
+		t.Errorf("bad flags %x", sc.Flags)
+	}
+	if s := sc.Traceparent(); s != valid {
+		t.Errorf("expecting traceparent %s, got %s", valid, s)
+	}
+	// Future versions might add more fields
+	if _, err := ParseTraceparent("cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what"); err != nil {
+		t.Errorf("future version should be accepted, got %s", err)
+	}
+	invalid := []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+	}
+	for _, v := range invalid {
+		if _, err := ParseTraceparent(v); err == nil {
+			t.Errorf("expecting an error parsing %q", v)
+		}
+	}
+}
+
+func TestSpans(t *testing.T) {
+	exp := &recordExporter{}
+	tracer := NewTracer("test", exp)
+	remote, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, root := tracer.Start(context.Background(), "root", KindServer, remote)
+	if root.SpanContext().TraceID != remote.TraceID || root.Parent() != remote.SpanID {
+		t.Errorf("root span does not continue remote trace")
+	}
+	cctx, child := Start(ctx, "child", KindClient)
+	if child.SpanContext().TraceID != remote.TraceID || child.Parent() != root.SpanContext().SpanID {
+		t.Errorf("child span is not a child of root")
+	}
+	h := make(http.Header)
+	Inject(cctx, h)
+	if sc := Extract(h); sc != child.SpanContext() {
+		t.Errorf("expecting injected %+v, got %+v", child.SpanContext(), sc)
+	}
+	child.SetError(errors.New("failed"))
+	child.End()
+	root.End()
+	root.End()
+	if err := tracer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(exp.spans) != 2 {
+		t.Fatalf("expecting 2 exported spans, got %d", len(exp.spans))
+	}
+	// Without a span in the context, Start is a no-op
+	bctx, s := Start(context.Background(), "nothing", KindInternal)
+	if s != nil || bctx != context.Background() {
+		t.Errorf("expecting no span")
+	}
+	s.SetAttribute("foo", "bar")
+	s.End()
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+	exp, err := NewOTLPExporter(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer := NewTracer("test", exp)
+	_, s := tracer.Start(context.Background(), "GET /", KindServer, SpanContext{})
+	s.SetAttribute("http.status_code", 200)
+	s.End()
+	if err := tracer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(body)
+	for _, v := range []string{`"service.name"`, `"GET /"`, s.SpanContext().TraceID.String(), `"intValue":"200"`} {
+		if !strings.Contains(string(data), v) {
+			t.Errorf("exported data %s does not contain %s", string(data), v)
+		}
+	}
+}
+
+func TestOTLPLogWriter(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/logs" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+	w, err := NewOTLPLogWriter("test", srv.URL, log.LInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := log.New(w, 0, log.LDebug)
+	ctx, s := NewTracer("test", nil).Start(context.Background(), "GET /", KindServer, SpanContext{})
+	logger.WithContext(ctx).Warningf("something %s", "happened")
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.Marshal(body)
+	for _, v := range []string{`"service.name"`, `"something happened"`, `"severityNumber":13`, s.SpanContext().TraceID.String(), s.SpanContext().SpanID.String()} {
+		if !strings.Contains(string(data), v) {
+			t.Errorf("exported data %s does not contain %s", string(data), v)
+		}
+	}
+}
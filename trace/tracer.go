@@ -0,0 +1,139 @@
+package trace
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gnd.la/log"
+)
+
+const (
+	defaultBatchSize     = 512
+	defaultFlushInterval = 5 * time.Second
+)
+
+// Exporter is the interface implemented by the types
+// which send finished spans to a tracing backend.
+type Exporter interface {
+	Export(service string, spans []*Span) error
+}
+
+// Tracer creates root spans and batches finished spans,
+// handing them to its Exporter. A Tracer is safe for
+// concurrent use from multiple goroutines.
+type Tracer struct {
+	// BatchSize is the maximum number of spans sent in
+	// a single call to Export. If zero, 512 is used.
+	BatchSize int
+	// FlushInterval is the maximum time a finished span
+	// waits before being exported. If zero, 5 seconds
+	// is used.
+	FlushInterval time.Duration
+	service       string
+	exporter      Exporter
+	mu            sync.Mutex
+	pending       []*Span
+	timer         *time.Timer
+}
+
+// NewTracer returns a new Tracer for the given service name,
+// which sends its spans to the given Exporter.
+func NewTracer(service string, exporter Exporter) *Tracer {
+	return &Tracer{service: service, exporter: exporter}
+}
+
+// Service returns the service name this Tracer was created with.
+func (t *Tracer) Service() string {
+	return t.service
+}
+
+// Start starts a new span. If ctx already contains a span,
+// the new span will be its child. Otherwise, if remote is
+// valid (e.g. it was obtained using Extract), the new span
+// continues the trace started by another process. If neither
+// is true, a new trace is started.
+func (t *Tracer) Start(ctx context.Context, name string, kind Kind, remote SpanContext) (context.Context, *Span) {
+	parent := remote
+	if s := FromContext(ctx); s != nil {
+		parent = s.sc
+	}
+	s := t.newSpan(name, kind, parent)
+	return NewContext(ctx, s), s
+}
+
+func (t *Tracer) newSpan(name string, kind Kind, parent SpanContext) *Span {
+	s := &Span{
+		tracer: t,
+		kind:   kind,
+		name:   name,
+		start:  time.Now(),
+	}
+	s.sc.SpanID = newSpanID()
+	if parent.IsValid() {
+		s.sc.TraceID = parent.TraceID
+		s.sc.Flags = parent.Flags
+		s.parent = parent.SpanID
+	} else {
+		s.sc.TraceID = newTraceID()
+		s.sc.Flags = FlagSampled
+	}
+	return s
+}
+
+func (t *Tracer) batchSize() int {
+	if t.BatchSize > 0 {
+		return t.BatchSize
+	}
+	return defaultBatchSize
+}
+
+func (t *Tracer) flushInterval() time.Duration {
+	if t.FlushInterval > 0 {
+		return t.FlushInterval
+	}
+	return defaultFlushInterval
+}
+
+func (t *Tracer) enqueue(s *Span) {
+	t.mu.Lock()
+	t.pending = append(t.pending, s)
+	full := len(t.pending) >= t.batchSize()
+	if !full && t.timer == nil {
+		t.timer = time.AfterFunc(t.flushInterval(), func() { t.Flush() })
+	}
+	t.mu.Unlock()
+	if full {
+		go t.Flush()
+	}
+}
+
+// Flush exports all the finished spans which haven't
+// been exported yet. Applications should call Flush
+// before exiting, to avoid losing spans.
+func (t *Tracer) Flush() error {
+	t.mu.Lock()
+	spans := t.pending
+	t.pending = nil
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	t.mu.Unlock()
+	if len(spans) == 0 || t.exporter == nil {
+		return nil
+	}
+	size := t.batchSize()
+	for len(spans) > 0 {
+		n := size
+		if n > len(spans) {
+			n = len(spans)
+		}
+		if err := t.exporter.Export(t.service, spans[:n]); err != nil {
+			log.Errorf("error exporting %d spans: %s", n, err)
+			return err
+		}
+		spans = spans[n:]
+	}
+	return nil
+}
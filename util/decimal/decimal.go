@@ -0,0 +1,344 @@
+// Package decimal implements arbitrary precision fixed point
+// decimal numbers, suitable for representing amounts of money
+// without the rounding errors introduced by floating point.
+//
+// Decimal implements database/sql.Scanner and
+// database/sql/driver.Valuer, and the ORM maps it to NUMERIC
+// columns. Use the precision and scale tags to specify the
+// column size, e.g.
+//
+//	type Product struct {
+//		Price decimal.Decimal `orm:",precision=12,scale=2"`
+//	}
+//
+// Since decimals are stored as numbers in the database, they
+// might also be compared in queries, e.g.
+//
+//	orm.Lt("Price", decimal.MustParse("9.99"))
+package decimal
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalid is returned when parsing a string
+	// which does not represent a decimal number.
+	ErrInvalid = errors.New("invalid decimal number")
+
+	bigZero = big.NewInt(0)
+	bigOne  = big.NewInt(1)
+	bigTen  = big.NewInt(10)
+)
+
+// Decimal represents a decimal number as an arbitrary precision
+// unscaled integer and a scale, which indicates the number of
+// digits after the decimal point (e.g. 12.345 is represented
+// as 12345 with a scale of 3). The zero value represents 0.
+// Decimals are immutable, all the operations return a new
+// Decimal, so they can be safely copied and passed by value.
+type Decimal struct {
+	value *big.Int
+	scale int
+}
+
+// New returns a Decimal representing value * 10^-scale,
+// e.g. New(1234, 2) returns 12.34. Scale must be >= 0.
+func New(value int64, scale int) Decimal {
+	if scale < 0 {
+		panic(fmt.Errorf("negative scale %d", scale))
+	}
+	return Decimal{value: big.NewInt(value), scale: scale}
+}
+
+// NewFromFloat returns the Decimal with the shortest
+// representation which converts back to f.
+func NewFromFloat(f float64) Decimal {
+	d, err := Parse(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		// Inf or NaN
+		panic(fmt.Errorf("can't represent %v as a decimal", f))
+	}
+	return d
+}
+
+// Parse parses a decimal number from the given string,
+// which might include a sign, a decimal point and an
+// exponent (e.g. "-12.5", "1e3" or "1.5E-2").
+func Parse(s string) (Decimal, error) {
+	orig := s
+	s = strings.TrimSpace(s)
+	exp := 0
+	if pos := strings.IndexAny(s, "eE"); pos >= 0 {
+		e, err := strconv.Atoi(s[pos+1:])
+		if err != nil {
+			return Decimal{}, fmt.Errorf("%s %q", ErrInvalid, orig)
+		}
+		exp = e
+		s = s[:pos]
+	}
+	var neg bool
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	scale := 0
+	if pos := strings.IndexByte(s, '.'); pos >= 0 {
+		scale = len(s) - pos - 1
+		s = s[:pos] + s[pos+1:]
+	}
+	if s == "" || strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return Decimal{}, fmt.Errorf("%s %q", ErrInvalid, orig)
+	}
+	value, _ := new(big.Int).SetString(s, 10)
+	if neg {
+		value.Neg(value)
+	}
+	scale -= exp
+	if scale < 0 {
+		value.Mul(value, pow10(-scale))
+		scale = 0
+	}
+	return Decimal{value: value, scale: scale}, nil
+}
+
+// MustParse works like Parse, but panics if
+// there's an error.
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func pow10(n int) *big.Int {
+	if n == 0 {
+		return bigOne
+	}
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+func (d Decimal) unscaled() *big.Int {
+	if d.value == nil {
+		return bigZero
+	}
+	return d.value
+}
+
+// rescale returns the unscaled value of d with the given
+// scale, which must be >= d.scale.
+func (d Decimal) rescale(scale int) *big.Int {
+	return new(big.Int).Mul(d.unscaled(), pow10(scale-d.scale))
+}
+
+// align returns the unscaled values of a and b, using
+// the maximum of their scales, and that scale.
+func align(a Decimal, b Decimal) (*big.Int, *big.Int, int) {
+	scale := a.scale
+	if b.scale > scale {
+		scale = b.scale
+	}
+	return a.rescale(scale), b.rescale(scale), scale
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int {
+	return d.scale
+}
+
+// Sign returns -1 if d < 0, 0 if d == 0 and +1 if d > 0.
+func (d Decimal) Sign() int {
+	return d.unscaled().Sign()
+}
+
+// IsZero returns true iff d == 0.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{value: new(big.Int).Neg(d.unscaled()), scale: d.scale}
+}
+
+// Abs returns the absolute value of d.
+func (d Decimal) Abs() Decimal {
+	return Decimal{value: new(big.Int).Abs(d.unscaled()), scale: d.scale}
+}
+
+// Cmp compares d and other and returns -1 if d < other,
+// 0 if d == other and +1 if d > other. Note that numbers
+// with different scales might be equal (e.g. 1.5 and 1.50).
+func (d Decimal) Cmp(other Decimal) int {
+	a, b, _ := align(d, other)
+	return a.Cmp(b)
+}
+
+// Equal returns true iff d and other represent the same
+// number, regardless of their scales.
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// Add returns d + other. The scale of the result is the
+// maximum of both scales.
+func (d Decimal) Add(other Decimal) Decimal {
+	a, b, scale := align(d, other)
+	return Decimal{value: a.Add(a, b), scale: scale}
+}
+
+// Sub returns d - other. The scale of the result is the
+// maximum of both scales.
+func (d Decimal) Sub(other Decimal) Decimal {
+	a, b, scale := align(d, other)
+	return Decimal{value: a.Sub(a, b), scale: scale}
+}
+
+// Mul returns d * other. The scale of the result is the
+// sum of both scales.
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{value: new(big.Int).Mul(d.unscaled(), other.unscaled()), scale: d.scale + other.scale}
+}
+
+// Div returns d / other, rounded to the given scale (see Round
+// for the rounding mode). It panics if other is zero.
+func (d Decimal) Div(other Decimal, scale int) Decimal {
+	if other.IsZero() {
+		panic(errors.New("decimal division by zero"))
+	}
+	// d / other * 10^scale = d.value * 10^(scale + other.scale - d.scale) / other.value
+	num := new(big.Int).Set(d.unscaled())
+	den := new(big.Int).Set(other.unscaled())
+	if e := scale + other.scale - d.scale; e >= 0 {
+		num.Mul(num, pow10(e))
+	} else {
+		den.Mul(den, pow10(-e))
+	}
+	return Decimal{value: quo(num, den), scale: scale}
+}
+
+// Round returns d rounded to the given number of digits after the
+// decimal point. Halves are rounded away from zero (e.g. 2.5 becomes
+// 3 and -2.5 becomes -3). If scale is greater than the scale of d,
+// zeros are added.
+func (d Decimal) Round(scale int) Decimal {
+	if scale >= d.scale {
+		return Decimal{value: d.rescale(scale), scale: scale}
+	}
+	return Decimal{value: quo(new(big.Int).Set(d.unscaled()), pow10(d.scale-scale)), scale: scale}
+}
+
+// quo returns num / den, with halves rounded away from zero.
+func quo(num *big.Int, den *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() != 0 {
+		r.Abs(r)
+		r.Lsh(r, 1)
+		if r.Cmp(new(big.Int).Abs(den)) >= 0 {
+			if num.Sign()*den.Sign() < 0 {
+				q.Sub(q, bigOne)
+			} else {
+				q.Add(q, bigOne)
+			}
+		}
+	}
+	return q
+}
+
+// Int64 returns the integer part of d, truncated towards zero. If
+// it does not fit into an int64, the result is undefined.
+func (d Decimal) Int64() int64 {
+	return new(big.Int).Quo(d.unscaled(), pow10(d.scale)).Int64()
+}
+
+// Float64 returns the nearest float64 to d.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String returns d formatted with all its decimal digits,
+// e.g. "-12.50".
+func (d Decimal) String() string {
+	s := new(big.Int).Abs(d.unscaled()).String()
+	if d.scale > 0 {
+		if len(s) <= d.scale {
+			s = strings.Repeat("0", d.scale-len(s)+1) + s
+		}
+		s = s[:len(s)-d.scale] + "." + s[len(s)-d.scale:]
+	}
+	if d.Sign() < 0 {
+		return "-" + s
+	}
+	return s
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Decimal) UnmarshalText(data []byte) error {
+	dec, err := Parse(string(data))
+	if err != nil {
+		return err
+	}
+	*d = dec
+	return nil
+}
+
+// MarshalJSON implements encoding/json.Marshaler. Decimals are
+// encoded as strings, to avoid losing precision when they're
+// decoded as floating point numbers.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON implements encoding/json.Unmarshaler. Both
+// strings and numbers are accepted.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if len(s) > 1 && s[0] == '"' {
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return err
+		}
+		s = unquoted
+	}
+	return d.UnmarshalText([]byte(s))
+}
+
+// Scan implements database/sql.Scanner.
+func (d *Decimal) Scan(src interface{}) error {
+	switch x := src.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case int64:
+		*d = New(x, 0)
+		return nil
+	case float64:
+		*d = NewFromFloat(x)
+		return nil
+	case []byte:
+		return d.UnmarshalText(x)
+	case string:
+		return d.UnmarshalText([]byte(x))
+	}
+	return fmt.Errorf("can't scan %T into a decimal", src)
+}
+
+// Value implements database/sql/driver.Valuer.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
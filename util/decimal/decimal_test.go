@@ -0,0 +1,139 @@
+package decimal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		s      string
+		result string
+	}{
+		{"0", "0"},
+		{"12.50", "12.50"},
+		{"-0.05", "-0.05"},
+		{"+3", "3"},
+		{".5", "0.5"},
+		{"1e3", "1000"},
+		{"1.5E-2", "0.015"},
+		{"123456789012345678901234567890.123456789", "123456789012345678901234567890.123456789"},
+	}
+	for _, v := range cases {
+		d, err := Parse(v.s)
+		if err != nil {
+			t.Errorf("error parsing %q: %s", v.s, err)
+			continue
+		}
+		if s := d.String(); s != v.result {
+			t.Errorf("expecting %q when parsing %q, got %q", v.result, v.s, s)
+		}
+	}
+	for _, v := range []string{"", "-", "1.2.3", "abc", "1e", "1,5"} {
+		if _, err := Parse(v); err == nil {
+			t.Errorf("expecting an error when parsing %q", v)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a := MustParse("0.1")
+	b := MustParse("0.2")
+	if s := a.Add(b).String(); s != "0.3" {
+		t.Errorf("expecting 0.1 + 0.2 = 0.3, got %s", s)
+	}
+	if s := a.Sub(MustParse("1.25")).String(); s != "-1.15" {
+		t.Errorf("expecting 0.1 - 1.25 = -1.15, got %s", s)
+	}
+	if s := MustParse("19.99").Mul(New(3, 0)).String(); s != "59.97" {
+		t.Errorf("expecting 19.99 * 3 = 59.97, got %s", s)
+	}
+	if s := New(10, 0).Div(New(3, 0), 2).String(); s != "3.33" {
+		t.Errorf("expecting 10 / 3 = 3.33, got %s", s)
+	}
+	if s := New(-2, 0).Div(New(3, 0), 2).String(); s != "-0.67" {
+		t.Errorf("expecting -2 / 3 = -0.67, got %s", s)
+	}
+	if s := MustParse("1.50").Div(MustParse("0.5"), 0).String(); s != "3" {
+		t.Errorf("expecting 1.50 / 0.5 = 3, got %s", s)
+	}
+	var zero Decimal
+	if s := zero.Add(a).String(); s != "0.1" {
+		t.Errorf("expecting 0 + 0.1 = 0.1, got %s", s)
+	}
+}
+
+func TestRound(t *testing.T) {
+	cases := []struct {
+		s      string
+		scale  int
+		result string
+	}{
+		{"2.5", 0, "3"},
+		{"-2.5", 0, "-3"},
+		{"2.49", 0, "2"},
+		{"1.005", 2, "1.01"},
+		{"1.004", 2, "1.00"},
+		{"1.5", 3, "1.500"},
+	}
+	for _, v := range cases {
+		if s := MustParse(v.s).Round(v.scale).String(); s != v.result {
+			t.Errorf("expecting %s rounded to %d = %s, got %s", v.s, v.scale, v.result, s)
+		}
+	}
+}
+
+func TestCmp(t *testing.T) {
+	if !MustParse("1.5").Equal(MustParse("1.500")) {
+		t.Error("expecting 1.5 == 1.500")
+	}
+	if MustParse("9.99").Cmp(MustParse("10")) != -1 {
+		t.Error("expecting 9.99 < 10")
+	}
+	if MustParse("-1").Cmp(Decimal{}) != -1 {
+		t.Error("expecting -1 < 0")
+	}
+}
+
+func TestScan(t *testing.T) {
+	cases := []struct {
+		src    interface{}
+		result string
+	}{
+		{nil, "0"},
+		{int64(42), "42"},
+		{float64(9.99), "9.99"},
+		{[]byte("12.30"), "12.30"},
+		{"-7.125", "-7.125"},
+	}
+	for _, v := range cases {
+		var d Decimal
+		if err := d.Scan(v.src); err != nil {
+			t.Errorf("error scanning %v: %s", v.src, err)
+			continue
+		}
+		if s := d.String(); s != v.result {
+			t.Errorf("expecting %q when scanning %v, got %q", v.result, v.src, s)
+		}
+	}
+	if val, err := MustParse("12.30").Value(); err != nil || val != "12.30" {
+		t.Errorf("expecting value \"12.30\", got %v (error %v)", val, err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		A Decimal
+		B Decimal
+	}
+	if err := json.Unmarshal([]byte(`{"A": "12.30", "B": 1.5}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); s != `{"A":"12.30","B":"1.5"}` {
+		t.Errorf("unexpected JSON %s", s)
+	}
+}
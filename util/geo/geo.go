@@ -0,0 +1,57 @@
+// Package geo implements geographic points and distances.
+//
+// Point fields in ORM models are stored as two columns, holding
+// the latitude and the longitude, so they work with any backend.
+// Use gnd.la/orm.Near to find the objects within a given distance
+// of a point, e.g.
+//
+//	type Store struct {
+//		Id       int64 `orm:",primary_key,auto_increment"`
+//		Location geo.Point
+//	}
+//
+//	// Stores within 5km of the given point
+//	q := orm.Near("Location", geo.Point{Lat: 40.4168, Lng: -3.7038}, 5000)
+package geo
+
+import (
+	"fmt"
+	"math"
+)
+
+// EarthRadius is the mean radius of the Earth, in meters.
+const EarthRadius = 6371008.8
+
+// Point represents a point on the surface of the Earth,
+// using the WGS 84 coordinates in degrees.
+type Point struct {
+	// Lat is the latitude, between -90 and 90.
+	Lat float64
+	// Lng is the longitude, between -180 and 180.
+	Lng float64
+}
+
+// IsValid returns true iff p has a valid latitude
+// and longitude.
+func (p Point) IsValid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// Distance returns the great circle distance between p and
+// other in meters, calculated using the haversine formula.
+func (p Point) Distance(other Point) float64 {
+	lat1 := radians(p.Lat)
+	lat2 := radians(other.Lat)
+	sinLat := math.Sin((lat2 - lat1) / 2)
+	sinLng := math.Sin(radians(other.Lng-p.Lng) / 2)
+	a := sinLat*sinLat + math.Cos(lat1)*math.Cos(lat2)*sinLng*sinLng
+	return 2 * EarthRadius * math.Asin(math.Sqrt(a))
+}
+
+func (p Point) String() string {
+	return fmt.Sprintf("(%v, %v)", p.Lat, p.Lng)
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
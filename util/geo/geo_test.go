@@ -0,0 +1,39 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	cases := []struct {
+		a, b     Point
+		expected float64
+	}{
+		{Point{40.4168, -3.7038}, Point{40.4168, -3.7038}, 0},
+		// Madrid - Barcelona
+		{Point{40.4168, -3.7038}, Point{41.3874, 2.1686}, 505000},
+		// London - New York
+		{Point{51.5074, -0.1278}, Point{40.7128, -74.0060}, 5570000},
+		{Point{0, 0}, Point{0, 180}, math.Pi * EarthRadius},
+	}
+	for _, v := range cases {
+		d := v.a.Distance(v.b)
+		// 1% error
+		if math.Abs(d-v.expected) > v.expected/100 {
+			t.Errorf("expecting distance %v between %v and %v, got %v", v.expected, v.a, v.b, d)
+		}
+		if r := v.b.Distance(v.a); math.Abs(r-d) > 1e-6 {
+			t.Errorf("distance is not symmetric: %v vs %v", d, r)
+		}
+	}
+}
+
+func TestIsValid(t *testing.T) {
+	if !(Point{90, -180}).IsValid() {
+		t.Error("expecting (90, -180) to be valid")
+	}
+	if (Point{91, 0}).IsValid() || (Point{0, 181}).IsValid() {
+		t.Error("expecting out of range points to be invalid")
+	}
+}
@@ -0,0 +1,101 @@
+// Package humanize implements functions for formatting sizes,
+// numbers and ordinals in a human readable way.
+//
+// These functions are also available in templates as bytes,
+// ibytes, short_number and ordinal.
+package humanize
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	siUnits     = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+	binaryUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	// Suffixes for thousands, millions, billions
+	// and trillions.
+	shortSuffixes = []string{"", "k", "M", "B", "T"}
+)
+
+// BytesOptions specify the options for FormatBytes.
+type BytesOptions struct {
+	// Binary makes FormatBytes use binary units (KiB, MiB, etc...)
+	// with a base of 1024. Otherwise, SI units (kB, MB, etc...)
+	// with a base of 1000 are used.
+	Binary bool
+	// Precision indicates the maximum number of decimal digits.
+	// Trailing zeros are always removed. If zero, 1 is used and
+	// if negative, no decimal digits are printed.
+	Precision int
+}
+
+// FormatBytes returns the given size in bytes formatted as a human
+// readable string, using the largest unit which results in a value
+// >= 1. See BytesOptions for the available options. If opts is nil,
+// it's equivalent to Bytes.
+func FormatBytes(size uint64, opts *BytesOptions) string {
+	base := 1000.0
+	units := siUnits
+	precision := 1
+	if opts != nil {
+		if opts.Binary {
+			base = 1024
+			units = binaryUnits
+		}
+		if opts.Precision != 0 {
+			precision = opts.Precision
+		}
+	}
+	return format(float64(size), base, precision, units, " ")
+}
+
+// Bytes returns the given size formatted using SI units,
+// e.g. 1.2 MB.
+func Bytes(size uint64) string {
+	return FormatBytes(size, nil)
+}
+
+// IBytes returns the given size formatted using binary units,
+// e.g. 1.2 MiB.
+func IBytes(size uint64) string {
+	return FormatBytes(size, &BytesOptions{Binary: true})
+}
+
+// ShortNumber returns the given number abbreviated using the
+// k (thousands), M (millions), B (billions) and T (trillions)
+// suffixes and at most one decimal digit e.g. 1234 is
+// formatted as 1.2k. Numbers < 1000 are returned unchanged.
+func ShortNumber(n int64) string {
+	if n < 0 {
+		return "-" + format(-float64(n), 1000, 1, shortSuffixes, "")
+	}
+	return format(float64(n), 1000, 1, shortSuffixes, "")
+}
+
+// format divides value by base until it's < base or there are
+// no more units, and formats it with the given precision.
+func format(value float64, base float64, precision int, units []string, sep string) string {
+	if value < base {
+		return strconv.FormatFloat(value, 'f', 0, 64) + sep + units[0]
+	}
+	if precision < 0 {
+		precision = 0
+	}
+	ii := 0
+	for value >= base && ii < len(units)-1 {
+		value /= base
+		ii++
+	}
+	s := strconv.FormatFloat(value, 'f', precision, 64)
+	// Rounding might have produced e.g. 1000.0 kB
+	if rounded, _ := strconv.ParseFloat(s, 64); rounded >= base && ii < len(units)-1 {
+		ii++
+		s = strconv.FormatFloat(rounded/base, 'f', precision, 64)
+	}
+	if strings.IndexByte(s, '.') >= 0 {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return fmt.Sprintf("%s%s%s", s, sep, units[ii])
+}
@@ -0,0 +1,92 @@
+package humanize
+
+import (
+	"testing"
+)
+
+type Languager string
+
+func (l Languager) Language() string {
+	return string(l)
+}
+
+func TestBytes(t *testing.T) {
+	cases := []struct {
+		size uint64
+		opts *BytesOptions
+		out  string
+	}{
+		{0, nil, "0 B"},
+		{999, nil, "999 B"},
+		{1000, nil, "1 kB"},
+		{1234, nil, "1.2 kB"},
+		{999999, nil, "1 MB"},
+		{1500000000, nil, "1.5 GB"},
+		{1023, &BytesOptions{Binary: true}, "1023 B"},
+		{1024, &BytesOptions{Binary: true}, "1 KiB"},
+		{1536, &BytesOptions{Binary: true}, "1.5 KiB"},
+		{5 * 1024 * 1024, &BytesOptions{Binary: true}, "5 MiB"},
+		{1234567, &BytesOptions{Precision: 3}, "1.235 MB"},
+		{1234567, &BytesOptions{Precision: -1}, "1 MB"},
+	}
+	for _, v := range cases {
+		if out := FormatBytes(v.size, v.opts); out != v.out {
+			t.Errorf("expecting %q formatting %d bytes with options %+v, got %q", v.out, v.size, v.opts, out)
+		}
+	}
+	if out := IBytes(2048); out != "2 KiB" {
+		t.Errorf("expecting 2 KiB, got %q", out)
+	}
+}
+
+func TestShortNumber(t *testing.T) {
+	cases := map[int64]string{
+		0:             "0",
+		999:           "999",
+		1000:          "1k",
+		1234:          "1.2k",
+		-1234:         "-1.2k",
+		99999:         "100k",
+		999999:        "1M",
+		2500000:       "2.5M",
+		3100000000:    "3.1B",
+		7000000000000: "7T",
+	}
+	for k, v := range cases {
+		if out := ShortNumber(k); out != v {
+			t.Errorf("expecting %q abbreviating %d, got %q", v, k, out)
+		}
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	cases := []struct {
+		lang Languager
+		n    int
+		out  string
+	}{
+		{"", 1, "1st"},
+		{"en", 2, "2nd"},
+		{"en_US", 3, "3rd"},
+		{"en", 4, "4th"},
+		{"en", 11, "11th"},
+		{"en", 12, "12th"},
+		{"en", 13, "13th"},
+		{"en", 21, "21st"},
+		{"en", 112, "112th"},
+		{"es", 1, "1º"},
+		{"es_ES", 2, "2º"},
+		{"de", 3, "3."},
+		{"fr", 1, "1er"},
+		{"fr", 2, "2e"},
+		{"xx", 2, "2nd"},
+	}
+	for _, v := range cases {
+		if out := Ordinal(v.lang, v.n); out != v.out {
+			t.Errorf("expecting %q for ordinal %d in %q, got %q", v.out, v.n, v.lang, out)
+		}
+	}
+	if out := Ordinal(nil, 22); out != "22nd" {
+		t.Errorf("expecting 22nd with nil language, got %q", out)
+	}
+}
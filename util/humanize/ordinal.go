@@ -0,0 +1,91 @@
+package humanize
+
+import (
+	"strconv"
+	"sync"
+
+	"gnd.la/i18n"
+)
+
+// OrdinalFunc returns the ordinal for the given number
+// in a given language, e.g. 1st or 2nd in English.
+type OrdinalFunc func(n int) string
+
+var ordinals = struct {
+	sync.RWMutex
+	funcs map[string]OrdinalFunc
+}{
+	funcs: map[string]OrdinalFunc{
+		"en": englishOrdinal,
+		"es": suffixOrdinal("º"),
+		"it": suffixOrdinal("º"),
+		"pt": suffixOrdinal("º"),
+		"de": suffixOrdinal("."),
+		"nl": suffixOrdinal("e"),
+		"fr": frenchOrdinal,
+	},
+}
+
+func englishOrdinal(n int) string {
+	s := strconv.Itoa(n)
+	if n < 0 {
+		n = -n
+	}
+	switch n % 100 {
+	case 11, 12, 13:
+		return s + "th"
+	}
+	switch n % 10 {
+	case 1:
+		return s + "st"
+	case 2:
+		return s + "nd"
+	case 3:
+		return s + "rd"
+	}
+	return s + "th"
+}
+
+func frenchOrdinal(n int) string {
+	if n == 1 {
+		return "1er"
+	}
+	return strconv.Itoa(n) + "e"
+}
+
+func suffixOrdinal(suffix string) OrdinalFunc {
+	return func(n int) string {
+		return strconv.Itoa(n) + suffix
+	}
+}
+
+// RegisterOrdinal registers the function used to format ordinals in
+// the given language, which might include a country (e.g. "pt_BR") or
+// not (e.g. "pt"). Functions for English, Spanish, Italian, Portuguese,
+// German, Dutch and French are already registered.
+func RegisterOrdinal(lang string, f OrdinalFunc) {
+	ordinals.Lock()
+	ordinals.funcs[lang] = f
+	ordinals.Unlock()
+}
+
+// Ordinal returns the ordinal for n in the language returned by lang,
+// e.g. 1st in English or 1º in Spanish. If there's no OrdinalFunc
+// registered for the language, nor for the language without the
+// country, or lang is nil, English is used.
+func Ordinal(lang i18n.Languager, n int) string {
+	ordinals.RLock()
+	defer ordinals.RUnlock()
+	if lang != nil {
+		l := lang.Language()
+		if f := ordinals.funcs[l]; f != nil {
+			return f(n)
+		}
+		if len(l) > 2 {
+			if f := ordinals.funcs[l[:2]]; f != nil {
+				return f(n)
+			}
+		}
+	}
+	return englishOrdinal(n)
+}
@@ -0,0 +1,197 @@
+package stringutil
+
+import (
+	"bytes"
+	"strings"
+)
+
+// SplitCSV splits the given text into records and fields using the RFC 4180
+// semantics, with comma as the field separator. Use ',' for CSV, ';' for
+// the CSV files exported by spreadsheets in some locales and '\t' for TSV.
+// See SplitRecords and SplitOptions.CSV for more details.
+func SplitCSV(text string, comma rune) ([][]string, error) {
+	return SplitRecords(text, string(comma), &SplitOptions{CSV: true})
+}
+
+// SplitRecords splits the given text into records separated by newlines
+// ("\n" or "\r\n") and then splits each record into fields, using
+// any character in sep as separator between fields. Empty lines are
+// ignored. When opts.CSV is true, newlines inside quoted fields are part
+// of the field rather than record separators. Otherwise, each line is
+// split using SplitFieldsOptions, so newlines might only be included in
+// a field by escaping them.
+func SplitRecords(text string, sep string, opts *SplitOptions) ([][]string, error) {
+	if opts != nil && opts.CSV {
+		return splitCSV(text, sep, opts, true)
+	}
+	var records [][]string
+	errs := &splitErrors{lenient: opts != nil && opts.Lenient}
+	split := func(start int, offset int, end int) error {
+		record := strings.TrimSuffix(text[offset:end], "\r")
+		if record == "" {
+			return nil
+		}
+		fields, err := SplitFieldsOptions(record, sep, opts)
+		if err != nil {
+			// Make the error position relative to text
+			serr := err.(*SplitError)
+			serr.Pos += start
+			serr.Offset += offset
+			serr.Line += strings.Count(text[:offset], "\n")
+			if !errs.add(serr) {
+				return serr
+			}
+		}
+		if len(fields) > 0 {
+			records = append(records, fields)
+		}
+		return nil
+	}
+	// start and offset indicate where the current line starts,
+	// in runes and bytes respectively.
+	var pos, start, offset, escapes int
+	for ii, v := range text {
+		if v == '\\' {
+			escapes++
+		} else {
+			if v == '\n' && escapes%2 == 0 {
+				if err := split(start, offset, ii); err != nil {
+					return nil, err
+				}
+				start = pos + 1
+				offset = ii + 1
+			}
+			escapes = 0
+		}
+		pos++
+	}
+	if err := split(start, offset, len(text)); err != nil {
+		return nil, err
+	}
+	return records, errs.err()
+}
+
+// splitCSV implements the RFC 4180 mode for SplitFieldsOptions and
+// SplitRecords. If records is false, newlines are not treated specially
+// and the whole text is considered a single record.
+func splitCSV(text string, sep string, opts *SplitOptions, records bool) ([][]string, error) {
+	if sep == "" {
+		sep = ","
+	}
+	quotes := "\""
+	if opts.Quotes == NO_QUOTES {
+		quotes = ""
+	} else if opts.Quotes != "" {
+		quotes = opts.Quotes
+	}
+	isSep := makeRuneChecker(sep)
+	isQuote := makeRuneChecker(quotes)
+	var result [][]string
+	var fields []string
+	var buf bytes.Buffer
+	// quoted is true while inside a quoted field, while closed
+	// is true after the closing quote of a field has been found.
+	var quoted, closed bool
+	var curQuote rune
+	var quotePos int
+	// raw is true after MaxSplits has been reached. The rest of the
+	// record is then added verbatim to the last field, only tracking
+	// quotes to find where the record ends.
+	var raw, rawQuoted bool
+	errs := &splitErrors{lenient: opts.Lenient}
+	endField := func() {
+		fields = append(fields, buf.String())
+		buf.Reset()
+		closed = false
+	}
+	// endRecord ends the current record at the given position. It
+	// returns false iff splitting must stop.
+	endRecord := func(pos int) bool {
+		if len(fields) == 0 && buf.Len() == 0 && !closed {
+			// Empty line
+			return true
+		}
+		endField()
+		if opts.ExactCount > 0 && opts.ExactCount != len(fields) {
+			if !errs.add(newSplitError(text, pos, SplitFieldCount, "invalid number of fields %d, must be %d", len(fields), opts.ExactCount)) {
+				return false
+			}
+		}
+		result = append(result, fields)
+		fields = nil
+		raw = false
+		rawQuoted = false
+		return true
+	}
+	runes := []rune(text)
+	for ii := 0; ii < len(runes); ii++ {
+		v := runes[ii]
+		if quoted {
+			if v == curQuote {
+				if ii+1 < len(runes) && runes[ii+1] == curQuote {
+					// Doubled quote
+					if opts.KeepQuotes {
+						buf.WriteRune(v)
+					}
+					buf.WriteRune(v)
+					ii++
+					continue
+				}
+				if opts.KeepQuotes {
+					buf.WriteRune(v)
+				}
+				quoted = false
+				closed = true
+				continue
+			}
+			buf.WriteRune(v)
+			continue
+		}
+		if records && (v == '\n' || (v == '\r' && ii+1 < len(runes) && runes[ii+1] == '\n')) && !rawQuoted {
+			if !endRecord(ii) {
+				return nil, errs.err()
+			}
+			if v == '\r' {
+				ii++
+			}
+			continue
+		}
+		if raw {
+			if isQuote(v) {
+				rawQuoted = !rawQuoted
+			}
+			buf.WriteRune(v)
+			continue
+		}
+		switch {
+		case isSep(v):
+			if opts.MaxSplits > 0 && opts.MaxSplits == len(fields) {
+				raw = true
+				buf.WriteRune(v)
+				continue
+			}
+			endField()
+		case closed:
+			if !errs.add(newSplitError(text, ii, SplitUnexpectedCharacter, "unexpected %q after quoted field", v)) {
+				return nil, errs.err()
+			}
+			buf.WriteRune(v)
+		case isQuote(v) && buf.Len() == 0:
+			quoted = true
+			curQuote = v
+			quotePos = ii
+			if opts.KeepQuotes {
+				buf.WriteRune(v)
+			}
+		default:
+			buf.WriteRune(v)
+		}
+	}
+	if quoted && !errs.add(newSplitError(text, quotePos, SplitUnclosedQuote, "unclosed quote")) {
+		return nil, errs.err()
+	}
+	if !endRecord(len(runes)) {
+		return nil, errs.err()
+	}
+	return result, errs.err()
+}
@@ -0,0 +1,176 @@
+package stringutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KeyValues represents a set of key=value pairs parsed by
+// ParseKeyValues. Keys are kept in the same order they appeared
+// in the input.
+type KeyValues struct {
+	keys   []string
+	values map[string]string
+}
+
+// ParseKeyValues parses a list of key=value pairs separated by any of
+// the characters in sep, using the same rules as SplitFields, e.g.
+//
+//	host=localhost, port=8080, debug, timeout=5s
+//
+// Pairs without a '=' are parsed as keys with an empty value, which
+// Bool interprets as true. Whitespace around keys and values is ignored.
+// If a key appears more than once, its last value is used.
+func ParseKeyValues(text string, sep string) (*KeyValues, error) {
+	fields, err := SplitFields(text, sep)
+	if err != nil {
+		return nil, err
+	}
+	kv := &KeyValues{values: make(map[string]string, len(fields))}
+	for _, v := range fields {
+		var key, value string
+		if eq := strings.IndexByte(v, '='); eq >= 0 {
+			key = strings.TrimSpace(v[:eq])
+			value = strings.TrimSpace(v[eq+1:])
+		} else {
+			key = strings.TrimSpace(v)
+		}
+		if key == "" {
+			return nil, fmt.Errorf("empty key in %q", v)
+		}
+		kv.Set(key, value)
+	}
+	return kv, nil
+}
+
+// Keys returns the keys in the same order they were added.
+func (kv *KeyValues) Keys() []string {
+	return kv.keys
+}
+
+// Len returns the number of keys.
+func (kv *KeyValues) Len() int {
+	return len(kv.keys)
+}
+
+// Has returns true iff the given key is present, even if
+// its value is empty.
+func (kv *KeyValues) Has(key string) bool {
+	_, ok := kv.values[key]
+	return ok
+}
+
+// Lookup returns the value for the given key and a boolean
+// indicating if the key is present.
+func (kv *KeyValues) Lookup(key string) (string, bool) {
+	v, ok := kv.values[key]
+	return v, ok
+}
+
+// Get returns the value for the given key or an empty
+// string if it's not present.
+func (kv *KeyValues) Get(key string) string {
+	return kv.values[key]
+}
+
+// Set sets the value for the given key. New keys are
+// added after the existing ones.
+func (kv *KeyValues) Set(key string, value string) {
+	if kv.values == nil {
+		kv.values = make(map[string]string)
+	}
+	if _, ok := kv.values[key]; !ok {
+		kv.keys = append(kv.keys, key)
+	}
+	kv.values[key] = value
+}
+
+// Int returns the value for the given key parsed as an int. If
+// the key is not present, it returns zero and no error.
+func (kv *KeyValues) Int(key string) (int, error) {
+	v, ok := kv.values[key]
+	if !ok {
+		return 0, nil
+	}
+	val, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, kv.valueError(key, v, err)
+	}
+	return val, nil
+}
+
+// Float returns the value for the given key parsed as a float64. If
+// the key is not present, it returns zero and no error.
+func (kv *KeyValues) Float(key string) (float64, error) {
+	v, ok := kv.values[key]
+	if !ok {
+		return 0, nil
+	}
+	val, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, kv.valueError(key, v, err)
+	}
+	return val, nil
+}
+
+// Bool returns the value for the given key parsed as a bool. Keys
+// present with an empty value (e.g. "debug" in "debug,port=8080") are
+// considered true, while missing keys are considered false.
+func (kv *KeyValues) Bool(key string) (bool, error) {
+	v, ok := kv.values[key]
+	if !ok {
+		return false, nil
+	}
+	if v == "" {
+		return true, nil
+	}
+	val, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, kv.valueError(key, v, err)
+	}
+	return val, nil
+}
+
+// Duration returns the value for the given key parsed with
+// time.ParseDuration. If the key is not present, it returns zero
+// and no error.
+func (kv *KeyValues) Duration(key string) (time.Duration, error) {
+	v, ok := kv.values[key]
+	if !ok {
+		return 0, nil
+	}
+	val, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, kv.valueError(key, v, err)
+	}
+	return val, nil
+}
+
+// Map returns the key=value pairs as a map.
+func (kv *KeyValues) Map() map[string]string {
+	m := make(map[string]string, len(kv.values))
+	for k, v := range kv.values {
+		m[k] = v
+	}
+	return m
+}
+
+// String returns the pairs in their original order, separated
+// by commas. Keys with empty values are written without the '='.
+func (kv *KeyValues) String() string {
+	values := make([]string, len(kv.keys))
+	for ii, k := range kv.keys {
+		if v := kv.values[k]; v != "" {
+			values[ii] = k + "=" + v
+		} else {
+			values[ii] = k
+		}
+	}
+	return strings.Join(values, ",")
+}
+
+func (kv *KeyValues) valueError(key string, value string, err error) error {
+	return fmt.Errorf("invalid value %q for %s: %s", value, key, err)
+}
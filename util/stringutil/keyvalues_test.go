@@ -0,0 +1,80 @@
+package stringutil
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseKeyValues(t *testing.T) {
+	cases := []struct {
+		s      string
+		sep    string
+		keys   []string
+		values map[string]string
+	}{
+		{"a=1,b=2", ",", []string{"a", "b"}, map[string]string{"a": "1", "b": "2"}},
+		{" b = 2 , a = 1 ", ",", []string{"b", "a"}, map[string]string{"b": "2", "a": "1"}},
+		{"debug,port=8080", ",", []string{"debug", "port"}, map[string]string{"debug": "", "port": "8080"}},
+		{"q=a=b;c=d", ";", []string{"q", "c"}, map[string]string{"q": "a=b", "c": "d"}},
+		{"'t=a, b', u=c\\,d", ",", []string{"t", "u"}, map[string]string{"t": "a, b", "u": "c,d"}},
+		{"a=1 b=2 a=3", "", []string{"a", "b"}, map[string]string{"a": "3", "b": "2"}},
+		{"", ",", nil, map[string]string{}},
+	}
+	for _, v := range cases {
+		kv, err := ParseKeyValues(v.s, v.sep)
+		if err != nil {
+			t.Errorf("error parsing %q with sep %s: %s", v.s, sepRepr(v.sep), err)
+			continue
+		}
+		if !reflect.DeepEqual(kv.Keys(), v.keys) {
+			t.Errorf("error parsing keys from %q. wanted %v, got %v", v.s, v.keys, kv.Keys())
+		}
+		if m := kv.Map(); !reflect.DeepEqual(m, v.values) {
+			t.Errorf("error parsing values from %q. wanted %v, got %v", v.s, v.values, m)
+		}
+	}
+	if _, err := ParseKeyValues("a=1,=2", ","); err == nil {
+		t.Error("expecting an error with an empty key")
+	}
+}
+
+func TestKeyValuesAccessors(t *testing.T) {
+	kv, err := ParseKeyValues("port=8080, debug, verbose=false, timeout=1m30s, ratio=0.5, bad=x", ",")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port, err := kv.Int("port"); err != nil || port != 8080 {
+		t.Errorf("expecting port = 8080, got %v (%v)", port, err)
+	}
+	if missing, err := kv.Int("missing"); err != nil || missing != 0 {
+		t.Errorf("expecting missing = 0, got %v (%v)", missing, err)
+	}
+	if _, err := kv.Int("bad"); err == nil {
+		t.Error("expecting an error parsing bad as int")
+	}
+	if debug, err := kv.Bool("debug"); err != nil || !debug {
+		t.Errorf("expecting debug = true, got %v (%v)", debug, err)
+	}
+	if verbose, err := kv.Bool("verbose"); err != nil || verbose {
+		t.Errorf("expecting verbose = false, got %v (%v)", verbose, err)
+	}
+	if missing, err := kv.Bool("missing"); err != nil || missing {
+		t.Errorf("expecting missing = false, got %v (%v)", missing, err)
+	}
+	if _, err := kv.Bool("bad"); err == nil {
+		t.Error("expecting an error parsing bad as bool")
+	}
+	if timeout, err := kv.Duration("timeout"); err != nil || timeout != 90*time.Second {
+		t.Errorf("expecting timeout = 1m30s, got %v (%v)", timeout, err)
+	}
+	if _, err := kv.Duration("bad"); err == nil {
+		t.Error("expecting an error parsing bad as duration")
+	}
+	if ratio, err := kv.Float("ratio"); err != nil || ratio != 0.5 {
+		t.Errorf("expecting ratio = 0.5, got %v (%v)", ratio, err)
+	}
+	if s := kv.String(); s != "port=8080,debug,verbose=false,timeout=1m30s,ratio=0.5,bad=x" {
+		t.Errorf("unexpected String() %q", s)
+	}
+}
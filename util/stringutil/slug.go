@@ -2,6 +2,7 @@ package stringutil
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 
 	"gopkgs.com/unidecode.v1"
@@ -11,15 +12,39 @@ var (
 	slugRegexp = regexp.MustCompile("\\W+")
 )
 
+// SlugOptions specify the options for SlugWithOptions and UniqueSlug.
+type SlugOptions struct {
+	// MaxLength is the maximum length of the slug. Longer slugs
+	// are truncated at the last separator before MaxLength, so
+	// words are not cut unless the first one is longer than
+	// MaxLength. Values <= 0 are ignored.
+	MaxLength int
+	// Separator is used to replace every sequence of non
+	// alphanumeric characters. If empty, "-" is used.
+	Separator string
+}
+
+func (o *SlugOptions) separator() string {
+	if o != nil && o.Separator != "" {
+		return o.Separator
+	}
+	return "-"
+}
+
+func (o *SlugOptions) maxLength() int {
+	if o != nil && o.MaxLength > 0 {
+		return o.MaxLength
+	}
+	return 0
+}
+
 // Slug returns a slugified version of the given string, which
 // consists in transliterating unicode characters to ascii
 // (e.g. ó becomes o and â becomes a), replacing all sequences of
 // whitespaces with '-' and converting to lowercase. Very useful
 // for making arbitrary strings, like a post title, part of URLs.
 func Slug(s string) string {
-	decoded := unidecode.Unidecode(s)
-	spaceless := slugRegexp.ReplaceAllString(decoded, "-")
-	return strings.ToLower(strings.Trim(spaceless, "-"))
+	return SlugWithOptions(s, nil)
 }
 
 // SlugN works like Slug, but returns at string with, at
@@ -32,3 +57,58 @@ func SlugN(s string, n int) string {
 	}
 	return slug
 }
+
+// SlugWithOptions works like Slug, but accepts an additional
+// options parameter. Note that, unlike SlugN, when the slug is
+// longer than opts.MaxLength it's truncated at a word boundary.
+// If opts is nil, it's equivalent to Slug.
+func SlugWithOptions(s string, opts *SlugOptions) string {
+	sep := opts.separator()
+	decoded := unidecode.Unidecode(s)
+	spaceless := slugRegexp.ReplaceAllString(decoded, sep)
+	slug := strings.ToLower(strings.Trim(spaceless, sep))
+	return truncateSlug(slug, opts.maxLength(), sep)
+}
+
+func truncateSlug(slug string, n int, sep string) string {
+	if n <= 0 || len(slug) <= n {
+		return slug
+	}
+	if !strings.HasPrefix(slug[n:], sep) {
+		if p := strings.LastIndex(slug[:n], sep); p > 0 {
+			n = p
+		}
+	}
+	return strings.Trim(slug[:n], sep)
+}
+
+// UniqueSlug returns a slug for s generated with SlugWithOptions for
+// which exists returns false. When the slug is already taken, numeric
+// suffixes starting at 2 are appended (e.g. hello-world-2, hello-world-3,
+// etc...) until exists returns false or an error. The slug is truncated
+// as needed to leave room for the suffix without exceeding opts.MaxLength.
+// See also gnd.la/orm.UniqueSlug.
+func UniqueSlug(s string, opts *SlugOptions, exists func(slug string) (bool, error)) (string, error) {
+	sep := opts.separator()
+	slug := SlugWithOptions(s, opts)
+	maxLength := opts.maxLength()
+	candidate := slug
+	for ii := 2; ; ii++ {
+		taken, err := exists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		suffix := strconv.Itoa(ii)
+		base := slug
+		if base != "" {
+			suffix = sep + suffix
+			if maxLength > 0 {
+				base = truncateSlug(base, maxLength-len(suffix), sep)
+			}
+		}
+		candidate = base + suffix
+	}
+}
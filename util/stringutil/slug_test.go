@@ -21,3 +21,55 @@ func TestSlug(t *testing.T) {
 	testSlug(t, "el-bng-pide-el-cese-de-feijoo-y-el-resto-de-la-oposicion-explicaciones", "El BNG pide el cese de Feijóo y el resto de la oposición, explicaciones", -1)
 	testSlug(t, "el-papa-pide-una-solucion-politica-para-el-conflicto-en-siria", "El papa pide una “solución política” para el conflicto en Siria", -1)
 }
+
+func TestSlugWithOptions(t *testing.T) {
+	cases := []struct {
+		s    string
+		opts *SlugOptions
+		slug string
+	}{
+		{"The quick brown fox", nil, "the-quick-brown-fox"},
+		{"The quick brown fox", &SlugOptions{MaxLength: 5}, "the"},
+		{"The quick brown fox", &SlugOptions{MaxLength: 9}, "the-quick"},
+		{"The quick brown fox", &SlugOptions{MaxLength: 12}, "the-quick"},
+		{"Supercalifragilistic day", &SlugOptions{MaxLength: 5}, "super"},
+		{"The quick brown fox", &SlugOptions{Separator: "_"}, "the_quick_brown_fox"},
+		{"  Hello, World!  ", &SlugOptions{Separator: "."}, "hello.world"},
+	}
+	for _, v := range cases {
+		if slug := SlugWithOptions(v.s, v.opts); slug != v.slug {
+			t.Errorf("unexpected slug for %q with options %+v, expecting %q, got %q", v.s, v.opts, v.slug, slug)
+		}
+	}
+}
+
+func TestUniqueSlug(t *testing.T) {
+	taken := map[string]bool{
+		"hello-world":   true,
+		"hello-world-2": true,
+		"hello-2":       true,
+		"hello-3":       true,
+	}
+	exists := func(slug string) (bool, error) {
+		return taken[slug], nil
+	}
+	cases := []struct {
+		s    string
+		opts *SlugOptions
+		slug string
+	}{
+		{"Goodbye world", nil, "goodbye-world"},
+		{"Hello world", nil, "hello-world-3"},
+		{"Hello world", &SlugOptions{MaxLength: 11}, "hello-4"},
+	}
+	for _, v := range cases {
+		slug, err := UniqueSlug(v.s, v.opts, exists)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if slug != v.slug {
+			t.Errorf("unexpected unique slug for %q with options %+v, expecting %q, got %q", v.s, v.opts, v.slug, slug)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 const (
@@ -21,13 +22,56 @@ const (
 	NO_QUOTES = "\uffff"
 )
 
-// SplitError represents an error while splitting the fields. Note that not
-// all errors returned for SplitFields are *SplitError (e.g. if the number of
-// fields does not match ExactCount, the error is NOT an *SplitError).
+// SplitReason indicates why splitting a text failed.
+type SplitReason int
+
+const (
+	// SplitInvalidEscape indicates that a \ was followed by a
+	// character which can't be escaped.
+	SplitInvalidEscape SplitReason = iota + 1
+	// SplitUnclosedQuote indicates that a quoted field was
+	// not closed before the end of the input.
+	SplitUnclosedQuote
+	// SplitUnexpectedCharacter indicates that a quoted field was
+	// followed by a character other than a separator (only
+	// returned in CSV mode).
+	SplitUnexpectedCharacter
+	// SplitFieldCount indicates that the number of fields did
+	// not match SplitOptions.ExactCount.
+	SplitFieldCount
+)
+
+func (r SplitReason) String() string {
+	switch r {
+	case SplitInvalidEscape:
+		return "invalid escape"
+	case SplitUnclosedQuote:
+		return "unclosed quote"
+	case SplitUnexpectedCharacter:
+		return "unexpected character"
+	case SplitFieldCount:
+		return "invalid field count"
+	}
+	return fmt.Sprintf("SplitReason(%d)", int(r))
+}
+
+// SplitError represents an error while splitting the fields. All the
+// errors returned by SplitFields, SplitFieldsOptions, SplitRecords and
+// SplitCSV are of type *SplitError.
 type SplitError struct {
 	// Pos indicates the position in the input while the error originated,
-	// zero indexed.
+	// zero indexed and measured in runes.
 	Pos int
+	// Offset is the same position as Pos, but measured in bytes.
+	Offset int
+	// Line is the line in the input where the error originated, starting
+	// at 1.
+	Line int
+	// Rune is the character at Pos, or zero if the error originated
+	// at the end of the input.
+	Rune rune
+	// Reason indicates the kind of error.
+	Reason SplitReason
 	// Err is the original error.
 	Err error
 }
@@ -36,12 +80,55 @@ func (s *SplitError) Error() string {
 	return fmt.Sprintf("index %d: %s", s.Pos, s.Err)
 }
 
-func newSplitError(text string, pos int, format string, args ...interface{}) *SplitError {
-	e := fmt.Errorf(format, args...)
+func newSplitError(text string, pos int, reason SplitReason, format string, args ...interface{}) *SplitError {
+	offset := len(text)
+	n := 0
+	for ii := range text {
+		if n == pos {
+			offset = ii
+			break
+		}
+		n++
+	}
+	var r rune
+	if offset < len(text) {
+		r, _ = utf8.DecodeRuneInString(text[offset:])
+	}
 	return &SplitError{
-		Pos: pos,
-		Err: e,
+		Pos:    pos,
+		Offset: offset,
+		Line:   strings.Count(text[:offset], "\n") + 1,
+		Rune:   r,
+		Reason: reason,
+		Err:    fmt.Errorf(format, args...),
+	}
+}
+
+// splitErrors keeps the first error found while splitting in
+// lenient mode.
+type splitErrors struct {
+	lenient bool
+	first   *SplitError
+}
+
+// add records the given error and returns true iff splitting
+// should continue.
+func (s *splitErrors) add(err *SplitError) bool {
+	if !s.lenient {
+		s.first = err
+		return false
+	}
+	if s.first == nil {
+		s.first = err
 	}
+	return true
+}
+
+func (s *splitErrors) err() error {
+	if s.first != nil {
+		return s.first
+	}
+	return nil
 }
 
 // SplitOptions represent options which can be specified when calling SplitFieldsOptions.
@@ -61,11 +148,31 @@ type SplitOptions struct {
 	// KeepQuotes indicates wheter to keep the quotes in the quoted fields.
 	// Otherwise, quotes are removed from the fields.
 	KeepQuotes bool
+	// CSV enables the RFC 4180 semantics, used by CSV and TSV files. In
+	// this mode the only default quoting character is ", quotes inside
+	// quoted fields are escaped by doubling them and \ has no special
+	// meaning. Whitespace is preserved and empty fields are not skipped,
+	// so "a,,b" yields 3 fields. Quoted fields might also contain
+	// separators and newlines. If the separator is empty, "," is used.
+	CSV bool
+	// Lenient makes the splitting functions return the fields they
+	// were able to split even when the input is malformed, together
+	// with the first error found. Invalid escape sequences and
+	// unexpected characters are kept verbatim, while unclosed quotes
+	// extend to the end of the input.
+	Lenient bool
 }
 
 // SplitFieldsOptions works like SplitFields, but accepts an additional
 // options parameter. See the type SplitOptions for the available options.
 func SplitFieldsOptions(text string, sep string, opts *SplitOptions) ([]string, error) {
+	if opts != nil && opts.CSV {
+		records, err := splitCSV(text, sep, opts, false)
+		if len(records) == 0 {
+			return nil, err
+		}
+		return records[0], err
+	}
 	quotes := "'\""
 	if opts != nil {
 		if opts.Quotes == NO_QUOTES {
@@ -82,15 +189,19 @@ func SplitFieldsOptions(text string, sep string, opts *SplitOptions) ([]string,
 	isSep := makeSeparator(sep)
 	isQuote := makeRuneChecker(quotes)
 	var buf bytes.Buffer
+	errs := &splitErrors{lenient: opts != nil && opts.Lenient}
 	runes := []rune(text)
 	for ii := 0; ii < len(runes); ii++ {
 		v := runes[ii]
 		if state == stateEscape {
+			state = prevState
 			if !isSep(v) && !isQuote(v) && v != '\n' {
 				quoted := strconv.Quote(string(v))
-				return nil, newSplitError(text, ii, "invalid escape sequence \"\\%s\"", quoted[1:len(quoted)-1])
+				if !errs.add(newSplitError(text, ii, SplitInvalidEscape, "invalid escape sequence \"\\%s\"", quoted[1:len(quoted)-1])) {
+					return nil, errs.err()
+				}
+				buf.WriteRune('\\')
 			}
-			state = prevState
 			buf.WriteRune(v)
 			continue
 		}
@@ -112,7 +223,7 @@ func SplitFieldsOptions(text string, sep string, opts *SplitOptions) ([]string,
 				s = strings.TrimSuffix(s, NO_QUOTES)
 				values = append(values, s)
 				if done {
-					return values, nil
+					return values, errs.err()
 				}
 				buf.Reset()
 				state = stateValue
@@ -150,10 +261,14 @@ func SplitFieldsOptions(text string, sep string, opts *SplitOptions) ([]string,
 			}
 		}
 	}
-	if buf.Len() > 0 || state == stateValueUnquoted {
-		if state == stateValueQuoted {
-			return nil, newSplitError(text, quotePos, "unclosed quote")
+	if state == stateValueQuoted {
+		if !errs.add(newSplitError(text, quotePos, SplitUnclosedQuote, "unclosed quote")) {
+			return nil, errs.err()
 		}
+		// Keep the field, without trimming it
+		state = stateValueUnquoted
+	}
+	if buf.Len() > 0 || state == stateValueUnquoted {
 		s := buf.String()
 		if state != stateValueUnquoted {
 			s = strings.TrimRightFunc(s, unicode.IsSpace)
@@ -165,10 +280,12 @@ func SplitFieldsOptions(text string, sep string, opts *SplitOptions) ([]string,
 	}
 	if opts != nil && opts.ExactCount > 0 {
 		if opts.ExactCount != len(values) {
-			return nil, fmt.Errorf("invalid number of fields %d, must be %d", len(values), opts.ExactCount)
+			if !errs.add(newSplitError(text, len(runes), SplitFieldCount, "invalid number of fields %d, must be %d", len(values), opts.ExactCount)) {
+				return nil, errs.err()
+			}
 		}
 	}
-	return values, nil
+	return values, errs.err()
 }
 
 // SplitFields separates the given text into multiple fields, using
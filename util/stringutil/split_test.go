@@ -142,3 +142,132 @@ func TestSplitCommonPrefix(t *testing.T) {
 		}
 	}
 }
+
+func TestSplitCSV(t *testing.T) {
+	cases := []struct {
+		s      string
+		comma  rune
+		result [][]string
+	}{
+		{"a,b,c\n1,2,3\n", ',', [][]string{{"a", "b", "c"}, {"1", "2", "3"}}},
+		{"a,b,c\r\n1,2,3", ',', [][]string{{"a", "b", "c"}, {"1", "2", "3"}}},
+		{"a,,c\n,\n", ',', [][]string{{"a", "", "c"}, {"", ""}}},
+		{" a , b ", ',', [][]string{{" a ", " b "}}},
+		{"\"a,b\",\"say \"\"hi\"\"\"", ',', [][]string{{"a,b", "say \"hi\""}}},
+		{"\"multi\nline\",x\ny,z", ',', [][]string{{"multi\nline", "x"}, {"y", "z"}}},
+		{"a;b\n\nc;d", ';', [][]string{{"a", "b"}, {"c", "d"}}},
+		{"a\tb,c\td", '\t', [][]string{{"a", "b,c", "d"}}},
+		{"c:\\dir,'x'", ',', [][]string{{"c:\\dir", "'x'"}}},
+		{"\"\"", ',', [][]string{{""}}},
+		{"", ',', nil},
+	}
+	for _, v := range cases {
+		records, err := SplitCSV(v.s, v.comma)
+		if err != nil {
+			t.Errorf("error splitting CSV %q with comma %q: %s", v.s, v.comma, err)
+			continue
+		}
+		if !reflect.DeepEqual(records, v.result) {
+			t.Errorf("error splitting CSV %q with comma %q. wanted %q, got %q", v.s, v.comma, v.result, records)
+		}
+	}
+	errors := []string{
+		"\"unclosed,a",
+		"\"a\"b,c",
+	}
+	for _, v := range errors {
+		if _, err := SplitCSV(v, ','); err == nil {
+			t.Errorf("expecting an error splitting CSV %q", v)
+		}
+	}
+}
+
+func TestSplitFieldsCSV(t *testing.T) {
+	opts := &SplitOptions{CSV: true, MaxSplits: 1}
+	fields, err := SplitFieldsOptions("a,b,\"c,d\"", ",", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{"a", "b,\"c,d\""}
+	if !reflect.DeepEqual(fields, exp) {
+		t.Errorf("error splitting CSV with MaxSplits - want %q, got %q", exp, fields)
+	}
+	if _, err := SplitRecords("a,b\nc", ",", &SplitOptions{CSV: true, ExactCount: 2}); err == nil {
+		t.Error("expecting an error with ExactCount = 2")
+	}
+	records, err := SplitRecords("a b\nc 'd e'\n", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expRecords := [][]string{{"a", "b"}, {"c", "d e"}}
+	if !reflect.DeepEqual(records, expRecords) {
+		t.Errorf("error splitting records - want %q, got %q", expRecords, records)
+	}
+}
+
+func TestSplitError(t *testing.T) {
+	cases := []struct {
+		s      string
+		sep    string
+		opts   *SplitOptions
+		pos    int
+		offset int
+		line   int
+		r      rune
+		reason SplitReason
+	}{
+		{"a, b\\x", ",", nil, 5, 5, 1, 'x', SplitInvalidEscape},
+		{"ñ, 'b", ",", nil, 3, 4, 1, '\'', SplitUnclosedQuote},
+		{"a, b", ",", &SplitOptions{ExactCount: 3}, 4, 4, 1, 0, SplitFieldCount},
+		{"a,b\n\"c\"d", ",", &SplitOptions{CSV: true}, 7, 7, 2, 'd', SplitUnexpectedCharacter},
+	}
+	for _, v := range cases {
+		var err error
+		if v.opts != nil && v.opts.CSV {
+			_, err = SplitRecords(v.s, v.sep, v.opts)
+		} else {
+			_, err = SplitFieldsOptions(v.s, v.sep, v.opts)
+		}
+		serr, ok := err.(*SplitError)
+		if !ok {
+			t.Errorf("expecting a *SplitError splitting %q, got %v", v.s, err)
+			continue
+		}
+		if serr.Pos != v.pos || serr.Offset != v.offset || serr.Line != v.line || serr.Rune != v.r || serr.Reason != v.reason {
+			t.Errorf("unexpected error splitting %q: want pos %d, offset %d, line %d, rune %q, reason %s - got pos %d, offset %d, line %d, rune %q, reason %s",
+				v.s, v.pos, v.offset, v.line, v.r, v.reason, serr.Pos, serr.Offset, serr.Line, serr.Rune, serr.Reason)
+		}
+	}
+}
+
+func TestSplitLenient(t *testing.T) {
+	cases := []struct {
+		s      string
+		sep    string
+		opts   *SplitOptions
+		result []string
+		reason SplitReason
+	}{
+		{"a, b\\x, c", ",", &SplitOptions{Lenient: true}, []string{"a", "b\\x", "c"}, SplitInvalidEscape},
+		{"a, 'b, c", ",", &SplitOptions{Lenient: true}, []string{"a", "b, c"}, SplitUnclosedQuote},
+		{"a, b", ",", &SplitOptions{Lenient: true, ExactCount: 3}, []string{"a", "b"}, SplitFieldCount},
+		{"\"a\"b,c", ",", &SplitOptions{Lenient: true, CSV: true}, []string{"ab", "c"}, SplitUnexpectedCharacter},
+	}
+	for _, v := range cases {
+		fields, err := SplitFieldsOptions(v.s, v.sep, v.opts)
+		if serr, ok := err.(*SplitError); !ok || serr.Reason != v.reason {
+			t.Errorf("expecting error with reason %s splitting %q, got %v", v.reason, v.s, err)
+		}
+		if !reflect.DeepEqual(fields, v.result) {
+			t.Errorf("error splitting %q in lenient mode. wanted %v, got %v", v.s, resultRepr(v.result), resultRepr(fields))
+		}
+	}
+	records, err := SplitRecords("a b\nc \\x\nd", "", &SplitOptions{Lenient: true})
+	if serr, ok := err.(*SplitError); !ok || serr.Line != 2 || serr.Pos != 7 || serr.Offset != 7 {
+		t.Errorf("expecting error at line 2, index 7 splitting records, got %v", err)
+	}
+	exp := [][]string{{"a", "b"}, {"c", "\\x"}, {"d"}}
+	if !reflect.DeepEqual(records, exp) {
+		t.Errorf("error splitting records in lenient mode. wanted %q, got %q", exp, records)
+	}
+}
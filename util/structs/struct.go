@@ -59,7 +59,27 @@ func (s *Struct) Has(field string, typ reflect.Type) bool {
 	return false
 }
 
+// Namer returns the mangled name for a struct field which
+// doesn't specify its name in its tag.
+type Namer func(field reflect.StructField) string
+
+// DefaultNamer is the Namer used by NewStruct. It converts the field
+// name from camel case to lowercase words separated by underscores
+// (e.g. CreatedAt becomes created_at).
+func DefaultNamer(field reflect.StructField) string {
+	return stringutil.CamelCaseToLower(field.Name, "_")
+}
+
 func NewStruct(t interface{}, tags []string) (*Struct, error) {
+	return NewStructNamer(t, tags, nil)
+}
+
+// NewStructNamer works like NewStruct, but uses the given Namer for the
+// fields without an explicit name. If namer is nil, DefaultNamer is used.
+func NewStructNamer(t interface{}, tags []string, namer Namer) (*Struct, error) {
+	if namer == nil {
+		namer = DefaultNamer
+	}
 	var typ reflect.Type
 	if tt, ok := t.(reflect.Type); ok {
 		typ = tt
@@ -80,13 +100,13 @@ func NewStruct(t interface{}, tags []string) (*Struct, error) {
 		MNameMap: make(map[string]int),
 		QNameMap: make(map[string]int),
 	}
-	if err := fields(typ, tags, s, "", "", nil); err != nil {
+	if err := fields(typ, tags, namer, s, "", "", nil); err != nil {
 		return nil, err
 	}
 	return s, nil
 }
 
-func fields(typ reflect.Type, tags []string, s *Struct, qprefix, mprefix string, index []int) error {
+func fields(typ reflect.Type, tags []string, namer Namer, s *Struct, qprefix, mprefix string, index []int) error {
 	n := typ.NumField()
 	for ii := 0; ii < n; ii++ {
 		field := typ.Field(ii)
@@ -102,7 +122,7 @@ func fields(typ reflect.Type, tags []string, s *Struct, qprefix, mprefix string,
 		}
 		if name == "" {
 			// Default name
-			name = stringutil.CamelCaseToLower(field.Name, "_")
+			name = namer(field)
 		}
 		name = mprefix + name
 		qname := qprefix + field.Name
@@ -125,7 +145,7 @@ func fields(typ reflect.Type, tags []string, s *Struct, qprefix, mprefix string,
 			if !ftag.Has("inline") {
 				prefix += name + "_"
 			}
-			err := fields(t, tags, s, qname+".", prefix, idx)
+			err := fields(t, tags, namer, s, qname+".", prefix, idx)
 			if err != nil {
 				return err
 			}
@@ -156,5 +176,6 @@ func decompose(typ reflect.Type, tag *Tag) bool {
 	// parameter, since other users of this function
 	// might want all the fields. Make also struct types
 	// like time.Time configurable
-	return !tag.Has("codec") && !(typ.Name() == "Time" && typ.PkgPath() == "time")
+	return !tag.Has("codec") && !(typ.Name() == "Time" && typ.PkgPath() == "time") &&
+		!(typ.Name() == "Decimal" && typ.PkgPath() == "gnd.la/util/decimal")
 }